@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateInventorySpec defines the desired state of CertificateInventory
+type CertificateInventorySpec struct {
+
+	// ExpiringWithinDays is the number of days before expiry from which a certificate is reported as expiring.
+	// Defaults to 14.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ExpiringWithinDays int `json:"expiringWithinDays,omitempty"`
+}
+
+// CertificateInventoryStatus defines the observed state of CertificateInventory
+type CertificateInventoryStatus struct {
+
+	// LastUpdated is the time at which the inventory was last refreshed.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// Total is the number of CertificateRequests across all namespaces.
+	// +optional
+	Total int `json:"total,omitempty"`
+
+	// Expiring is the number of certificates expiring within expiringWithinDays.
+	// +optional
+	Expiring int `json:"expiring,omitempty"`
+
+	// Failing is the number of CertificateRequests whose last issuance failed.
+	// +optional
+	Failing int `json:"failing,omitempty"`
+
+	// Certificates summarizes every CertificateRequest, those without a certificate first and then soonest expiry first.
+	// +optional
+	Certificates []CertificateSummary `json:"certificates,omitempty"`
+}
+
+// CertificateSummary summarizes the certificate of a CertificateRequest.
+type CertificateSummary struct {
+
+	// Namespace of the CertificateRequest.
+	Namespace string `json:"namespace"`
+
+	// Name of the CertificateRequest.
+	Name string `json:"name"`
+
+	// ClusterName is the name of the ClusterDeployment owning the CertificateRequest.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// DNSNames are the DNS names requested for the certificate.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// IssuerName is the common name of the issuer of the current certificate.
+	// +optional
+	IssuerName string `json:"issuerName,omitempty"`
+
+	// NotAfter is the expiration time of the current certificate.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// DaysRemaining is the number of whole days until the current certificate expires.
+	// +optional
+	DaysRemaining *int `json:"daysRemaining,omitempty"`
+
+	// Expiring is true if the current certificate expires within expiringWithinDays.
+	// +optional
+	Expiring bool `json:"expiring,omitempty"`
+
+	// Imported is true if the certificate is provided by the customer instead of being issued by the operator, so
+	// it must be replaced by hand before it expires.
+	// +optional
+	Imported bool `json:"imported,omitempty"`
+
+	// LastError is the error of the last failed issuance, if the CertificateRequest is failing.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+
+// CertificateInventory is the Schema for the certificateinventories API
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+// +kubebuilder:printcolumn:name="Expiring",type="integer",JSONPath=".status.expiring"
+// +kubebuilder:printcolumn:name="Failing",type="integer",JSONPath=".status.failing"
+// +kubebuilder:printcolumn:name="LastUpdated",type="date",JSONPath=".status.lastUpdated"
+type CertificateInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateInventorySpec   `json:"spec,omitempty"`
+	Status CertificateInventoryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CertificateInventoryList contains a list of CertificateInventory
+type CertificateInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateInventory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertificateInventory{}, &CertificateInventoryList{})
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificatePolicySpec defines the constraints on the certificates CertificateRequests may ask for. Unset fields
+// constrain nothing.
+type CertificatePolicySpec struct {
+
+	// AllowedDomains are the DNS domains the names of a certificate must belong to. A name is allowed if it is one
+	// of them or a subdomain of one.
+	// +optional
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+
+	// MaxDNSNames is the maximum number of DNS names of a certificate.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxDNSNames *int32 `json:"maxDNSNames,omitempty"`
+
+	// AllowedKeyAlgorithms are the algorithms the private key of a certificate may use. CertificateRequests without
+	// a key algorithm use RSA.
+	// +kubebuilder:validation:items:Enum=RSA;ECDSA
+	// +optional
+	AllowedKeyAlgorithms []KeyAlgorithm `json:"allowedKeyAlgorithms,omitempty"`
+
+	// AllowWildcards allows wildcard DNS names such as *.apps.example.com. Defaults to true.
+	// +optional
+	AllowWildcards *bool `json:"allowWildcards,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// CertificatePolicy is the Schema for the certificatepolicies API. Every CertificateRequest must conform to every
+// CertificatePolicy: the webhook of the operator rejects new CertificateRequests and spec changes that do not, and
+// existing CertificateRequests that do not are reported with a Violations condition.
+type CertificatePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificatePolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CertificatePolicyList contains a list of CertificatePolicy
+type CertificatePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificatePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertificatePolicy{}, &CertificatePolicyList{})
+}
@@ -36,17 +36,45 @@ type CertificateRequestSpec struct {
 	// Platform contains specific cloud provider information such as credentials and secrets for the cluster infrastructure.
 	Platform Platform `json:"platform"`
 
+	// DNSProvider answers the DNS-01 challenges of the certificate instead of Platform, for base domains hosted
+	// outside of the cloud the cluster runs on. Its credentials are read from the namespace of the CertificateRequest.
+	// +optional
+	DNSProvider *Platform `json:"dnsProvider,omitempty"`
+
+	// ChallengeType is the type of the ACME challenges proving control of the DNS names, dns-01 by default. http-01
+	// challenges are answered by the challenge server of the operator, to which the DNS names must route
+	// /.well-known/acme-challenge/ on port 80, and cannot prove control of wildcard names.
+	// +kubebuilder:validation:Enum=dns-01;http-01
+	// +optional
+	ChallengeType ChallengeType `json:"challengeType,omitempty"`
+
+	// Solvers select the challenge type of some of the DNS names instead of ChallengeType, such as http-01 for a
+	// public apps domain and dns-01 for the rest. The first solver matching a DNS name applies.
+	// +optional
+	Solvers []ChallengeSolver `json:"solvers,omitempty"`
+
 	// DNSNames is a list of subject alt names to be used on the Certificate.
 	DnsNames []string `json:"dnsNames"`
 
 	// Let's Encrypt will use this to contact you about expiring certificates, and issues related to your account.
-	Email string `json:"email"`
+	// +optional
+	Email string `json:"email,omitempty"`
+
+	// Emails are further contacts, such as shared mailboxes and team aliases, registered with the certificate
+	// authority alongside Email and sent the same notifications.
+	// +optional
+	Emails []string `json:"emails,omitempty"`
 
 	// Number of days before expiration to reissue certificate.
 	// NOTE: Keeping "renew" in JSON for backward-compatibility.
 	// +optional
 	ReissueBeforeDays int `json:"renewBeforeDays,omitempty"`
 
+	// RenewBefore is how long before expiry the certificate is reissued, for example "1080h".
+	// Takes precedence over renewBeforeDays and must be shorter than the certificate's lifetime.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+
 	// APIURL is the URL where the cluster's API can be accessed.
 	// +optional
 	APIURL string `json:"apiURL,omitempty"`
@@ -54,49 +82,404 @@ type CertificateRequestSpec struct {
 	// WebConsoleURL is the URL for the cluster's web console UI.
 	// +optional
 	WebConsoleURL string `json:"webConsoleURL,omitempty"`
+
+	// IssuerRef is the reference to the cluster-scoped CertIssuer used to request the certificate.
+	// If unset, the operator's default Let's Encrypt account is used.
+	// +optional
+	IssuerRef *corev1.LocalObjectReference `json:"issuerRef,omitempty"`
+
+	// KeyAlgorithm is the algorithm of the certificate's private key. Defaults to RSA.
+	// +kubebuilder:validation:Enum=RSA;ECDSA
+	// +optional
+	KeyAlgorithm KeyAlgorithm `json:"keyAlgorithm,omitempty"`
+
+	// KeyCurve is the elliptic curve used when KeyAlgorithm is ECDSA. Defaults to P-256.
+	// +kubebuilder:validation:Enum=P-256;P-384
+	// +optional
+	KeyCurve KeyCurve `json:"keyCurve,omitempty"`
+
+	// KeySize is the size in bits of the RSA private key. Defaults to the operator's configured default key size, or 2048.
+	// +kubebuilder:validation:Enum=2048;3072;4096
+	// +optional
+	KeySize int `json:"keySize,omitempty"`
+
+	// RotationPolicy controls whether a renewal generates a new private key (Always) or reuses the key
+	// stored in the certificate secret (Never). Defaults to Always.
+	// +kubebuilder:validation:Enum=Always;Never
+	// +optional
+	RotationPolicy PrivateKeyRotationPolicy `json:"rotationPolicy,omitempty"`
+
+	// KMS generates the private key in a cloud key management service, which also signs the certificate signing
+	// request, so the private key is never stored in the certificate secret. The secret holds the key identifier
+	// in kms.key-id instead of tls.key. Cannot be combined with combinedPEM or keystores.
+	// +optional
+	KMS *KMSKey `json:"kms,omitempty"`
+
+	// MustStaple requests the OCSP must-staple TLS feature extension in the certificate.
+	// +optional
+	MustStaple bool `json:"mustStaple,omitempty"`
+
+	// Duration is the requested lifetime of the certificate, for example "168h". Only certificate authorities
+	// that honor notAfter on new orders issue certificates with the requested lifetime.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// CombinedPEM adds a tls-combined.pem key holding the private key followed by the certificate chain to the certificate secret.
+	// +optional
+	CombinedPEM bool `json:"combinedPEM,omitempty"`
+
+	// Keystores configures keystores written to the certificate secret in addition to the PEM encoded certificate.
+	// +optional
+	Keystores *CertificateKeystores `json:"keystores,omitempty"`
+
+	// SecretTemplate holds labels and annotations applied to the certificate secret.
+	// +optional
+	SecretTemplate *CertificateSecretTemplate `json:"secretTemplate,omitempty"`
+
+	// SecretReplicas lists additional secrets kept in sync with the certificate secret. If the namespace of a
+	// replica is unset, the namespace of the CertificateRequest is used.
+	// +optional
+	SecretReplicas []corev1.SecretReference `json:"secretReplicas,omitempty"`
+
+	// ImportedSecret references a secret in the namespace of the CertificateRequest holding a customer-provided
+	// certificate chain and private key in its tls.crt and tls.key keys, and optionally the CA to verify the chain
+	// against in its ca.crt key. No certificate is ordered: the imported certificate is validated, copied into the
+	// certificate secret and reported on like an issued one, and a warning is raised instead of renewing it.
+	// +optional
+	ImportedSecret *corev1.LocalObjectReference `json:"importedSecret,omitempty"`
+
+	// AzureKeyVault uploads the certificate and its private key as a PKCS#12 bundle to an Azure Key Vault whenever
+	// the certificate is issued or renewed, for services such as Application Gateway that only read certificates
+	// from Key Vault. Cannot be combined with kms.
+	// +optional
+	AzureKeyVault *AzureKeyVault `json:"azureKeyVault,omitempty"`
+
+	// SecretSinks lists external secret stores the certificate chain, private key and issuing chain of the
+	// certificate secret are pushed to whenever the certificate is issued or renewed, for consumers outside the
+	// cluster. Cannot be combined with kms.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	SecretSinks []SecretSink `json:"secretSinks,omitempty"`
+
+	// SyncSet delivers the certificate secret to the cluster of the owning ClusterDeployment through a Hive
+	// SyncSet, which also configures the API server or an ingress controller of the cluster to serve it.
+	// +optional
+	SyncSet *CertificateSyncSet `json:"syncSet,omitempty"`
+}
+
+// SyncSetTarget is the component of a managed cluster a certificate delivered by SyncSet is served by.
+type SyncSetTarget string
+
+const (
+	// SyncSetTargetAPIServer adds the certificate to the named certificates of the API server for the dnsNames.
+	SyncSetTargetAPIServer SyncSetTarget = "APIServer"
+	// SyncSetTargetIngress makes the certificate the default certificate of an ingress controller.
+	SyncSetTargetIngress SyncSetTarget = "Ingress"
+)
+
+// CertificateSyncSet configures the Hive SyncSet delivering the certificate to a managed cluster.
+type CertificateSyncSet struct {
+
+	// Name is the SyncSet the certificate secret and the patch serving it are added to. An existing SyncSet is
+	// patched, keeping its other secret mappings and patches. Defaults to the name of the CertificateRequest.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name,omitempty"`
+
+	// Target is the component of the cluster serving the certificate.
+	// +kubebuilder:validation:Enum=APIServer;Ingress
+	Target SyncSetTarget `json:"target"`
+
+	// IngressController is the ingress controller serving the certificate when target is Ingress. Defaults to
+	// default.
+	// +optional
+	IngressController string `json:"ingressController,omitempty"`
+}
+
+// SecretSink configures an external secret store the certificate is pushed to. Exactly one store must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.vault), has(self.awsSecretsManager), has(self.gcpSecretManager)].filter(x, x).size() == 1",message="exactly one of vault, awsSecretsManager and gcpSecretManager must be set"
+type SecretSink struct {
+
+	// Name identifies the sink in the status of the CertificateRequest.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Vault writes the certificate to a KV version 2 secrets engine of HashiCorp Vault.
+	// +optional
+	Vault *VaultSecretSink `json:"vault,omitempty"`
+
+	// AWSSecretsManager writes the certificate to a secret of AWS Secrets Manager.
+	// +optional
+	AWSSecretsManager *AWSSecretsManagerSink `json:"awsSecretsManager,omitempty"`
+
+	// GCPSecretManager writes the certificate to a secret of GCP Secret Manager.
+	// +optional
+	GCPSecretManager *GCPSecretManagerSink `json:"gcpSecretManager,omitempty"`
 }
 
-// CertificateRequestCondition defines conditions required for certificate requests.
-type CertificateRequestCondition struct {
+// VaultSecretSink configures a secret of a HashiCorp Vault KV version 2 secrets engine.
+type VaultSecretSink struct {
 
-	// Type is the type of the condition.
-	Type CertificateRequestConditionType `json:"type"`
+	// Server is the URL of the Vault server, such as https://vault.example.com:8200.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Server string `json:"server"`
 
-	// Status is the status of the condition.
-	Status corev1.ConditionStatus `json:"status"`
+	// Namespace is the Vault Enterprise namespace of the secrets engine.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 
-	// LastProbeTime is the last time we probed the condition.
+	// MountPath is the path the KV version 2 secrets engine is mounted at. Defaults to secret.
 	// +optional
-	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+	MountPath string `json:"mountPath,omitempty"`
+
+	// Path is the path of the secret in the secrets engine.
+	Path string `json:"path"`
 
-	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	// TokenSecretRef refers to the key of a secret in the namespace of the CertificateRequest holding a Vault token
+	// allowed to write the secret.
+	TokenSecretRef SecretKeyReference `json:"tokenSecretRef"`
+
+	// CABundle is a PEM encoded bundle of certificate authorities trusted to serve Vault, in addition to the system
+	// roots.
 	// +optional
-	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// AWSSecretsManagerSink configures a secret of AWS Secrets Manager.
+type AWSSecretsManagerSink struct {
+
+	// Credentials refers to a secret in the namespace of the CertificateRequest that contains the AWS access key
+	// allowed to create the secret and put its values.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
+
+	// Region is the AWS region of the secret.
+	Region string `json:"region"`
+
+	// SecretName is the name of the secret, which is created if it does not exist.
+	SecretName string `json:"secretName"`
+}
+
+// GCPSecretManagerSink configures a secret of GCP Secret Manager.
+type GCPSecretManagerSink struct {
+
+	// Credentials refers to a secret in the namespace of the CertificateRequest holding, in osServiceAccount.json,
+	// the key of a GCP service account allowed to create the secret and add versions to it.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
+
+	// Project is the GCP project of the secret. Defaults to the project of the service account.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// SecretID is the ID of the secret, which is created with automatic replication if it does not exist.
+	SecretID string `json:"secretID"`
+}
+
+// AzureKeyVault configures the Azure Key Vault certificates are uploaded to.
+type AzureKeyVault struct {
+
+	// VaultURL is the URL of the key vault, such as https://myvault.vault.azure.net.
+	// +kubebuilder:validation:Pattern=`^https://`
+	VaultURL string `json:"vaultURL"`
+
+	// CertificateName is the name of the certificate in the key vault. Defaults to the name of the
+	// CertificateRequest, with the characters Key Vault does not allow replaced by dashes.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9a-zA-Z-]{1,127}$`
+	CertificateName string `json:"certificateName,omitempty"`
+
+	// Credentials refers to a secret in the namespace of the CertificateRequest holding the osServicePrincipal.json
+	// of a service principal allowed to import certificates into the key vault. Defaults to the Azure credentials
+	// of the platform, or of the DNS provider.
+	// +optional
+	Credentials *corev1.LocalObjectReference `json:"credentials,omitempty"`
+}
+
+// CertificateKeystores configures keystores written to the certificate secret.
+type CertificateKeystores struct {
+
+	// PKCS12 writes a PKCS#12 keystore to the keystore.p12 key of the certificate secret.
+	// +optional
+	PKCS12 *KeystoreOptions `json:"pkcs12,omitempty"`
+
+	// JKS writes a Java keystore to the keystore.jks key of the certificate secret.
+	// +optional
+	JKS *KeystoreOptions `json:"jks,omitempty"`
+}
+
+// CertificateSecretTemplate defines labels and annotations applied to the certificate secret.
+type CertificateSecretTemplate struct {
+
+	// Labels to set on the certificate secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to set on the certificate secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// KeystoreOptions configures a keystore written to the certificate secret.
+type KeystoreOptions struct {
+
+	// PasswordSecretRef references the secret key holding the password protecting the keystore.
+	PasswordSecretRef SecretKeyReference `json:"passwordSecretRef"`
+}
+
+// SecretKeyReference references a key of a secret in the namespace of the CertificateRequest.
+type SecretKeyReference struct {
+
+	// Name of the secret.
+	Name string `json:"name"`
+
+	// Key of the secret data holding the value.
+	Key string `json:"key"`
+}
+
+// KMSKey configures the key management service holding the private key of a certificate.
+type KMSKey struct {
+
+	// AWS holds the private key in AWS KMS.
+	// +optional
+	AWS *AWSKMSKey `json:"aws,omitempty"`
+}
+
+// AWSKMSKey configures the AWS KMS keys of a certificate.
+type AWSKMSKey struct {
+
+	// Credentials refers to a secret in the namespace of the CertificateRequest holding the aws_access_key_id and
+	// aws_secret_access_key of an identity allowed to create, sign with and schedule the deletion of KMS keys.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
 
-	// Reason is a unique, one-word, CamelCase reason for the condition's last transition.
+	// Region is the AWS region the keys are created in.
+	Region string `json:"region"`
+}
+
+// ChallengeType is the type of an ACME challenge proving control of a DNS name.
+type ChallengeType string
+
+const (
+	// DNS01ChallengeType proves control of a DNS name with a TXT record in its DNS zone.
+	DNS01ChallengeType ChallengeType = "dns-01"
+	// HTTP01ChallengeType proves control of a DNS name by serving a token on port 80 of the name.
+	HTTP01ChallengeType ChallengeType = "http-01"
+)
+
+// ChallengeSolver selects the challenge type of the DNS names it matches.
+type ChallengeSolver struct {
+	// DNSNames are the DNS names of the certificate the solver applies to. Wildcard names are matched as written,
+	// such as *.apps.example.com.
 	// +optional
-	Reason *string `json:"reason,omitempty"`
+	DNSNames []string `json:"dnsNames,omitempty"`
 
-	// Message is a human-readable message indicating details about last transition.
+	// DNSZones are the DNS domains the solver applies to. A DNS name matches if it is one of them or a subdomain of
+	// one.
 	// +optional
-	Message *string `json:"message,omitempty"`
+	DNSZones []string `json:"dnsZones,omitempty"`
+
+	// ChallengeType is the type of the challenges of the matching DNS names.
+	// +kubebuilder:validation:Enum=dns-01;http-01
+	ChallengeType ChallengeType `json:"challengeType"`
 }
 
-// CertificateRequestConditionType is the condition that populates the Type var
-// within the CertificateRequestCondition struct
+// KeyAlgorithm is the algorithm of a certificate's private key.
+type KeyAlgorithm string
+
+const (
+	// RSAKeyAlgorithm issues certificates with an RSA private key.
+	RSAKeyAlgorithm KeyAlgorithm = "RSA"
+	// ECDSAKeyAlgorithm issues certificates with an ECDSA private key.
+	ECDSAKeyAlgorithm KeyAlgorithm = "ECDSA"
+)
+
+// PrivateKeyRotationPolicy controls the reuse of a certificate's private key on renewal.
+type PrivateKeyRotationPolicy string
+
+const (
+	// AlwaysRotationPolicy generates a new private key on every renewal.
+	AlwaysRotationPolicy PrivateKeyRotationPolicy = "Always"
+	// NeverRotationPolicy reuses the private key stored in the certificate secret on renewal.
+	NeverRotationPolicy PrivateKeyRotationPolicy = "Never"
+)
+
+// KeyCurve is the elliptic curve of an ECDSA private key.
+type KeyCurve string
+
+const (
+	// P256KeyCurve is the NIST P-256 curve.
+	P256KeyCurve KeyCurve = "P-256"
+	// P384KeyCurve is the NIST P-384 curve.
+	P384KeyCurve KeyCurve = "P-384"
+)
+
+// CertificateRequestConditionType is the type of a condition of a CertificateRequest.
 type CertificateRequestConditionType string
 
+const (
+	// CertificateRequestReady is true while the certificate secret holds a valid certificate for the spec.
+	CertificateRequestReady CertificateRequestConditionType = "Ready"
+	// CertificateRequestIssuing is true while a certificate is being ordered.
+	CertificateRequestIssuing CertificateRequestConditionType = "Issuing"
+	// CertificateRequestDNSVerified is true once the DNS challenge records of the last order have been verified.
+	CertificateRequestDNSVerified CertificateRequestConditionType = "DNSVerified"
+	// CertificateRequestRateLimited is true while new orders are held back by a rate limit.
+	CertificateRequestRateLimited CertificateRequestConditionType = "RateLimited"
+	// CertificateRequestFailed is true once the retry budget of the CertificateRequest is exhausted. No orders are
+	// placed until its spec changes or the RetryAnnotation is set.
+	CertificateRequestFailed CertificateRequestConditionType = "Failed"
+)
+
+// CertificateAction is an action on the certificate of a CertificateRequest recorded in its history.
+// +kubebuilder:validation:Enum=Issued;Renewed;Revoked;Adopted
+type CertificateAction string
+
+const (
+	// CertificateIssued is the issuance of the first certificate of a CertificateRequest, or of a certificate replacing
+	// a revoked one.
+	CertificateIssued CertificateAction = "Issued"
+	// CertificateRenewed is the issuance of a certificate replacing a valid one.
+	CertificateRenewed CertificateAction = "Renewed"
+	// CertificateRevoked is the revocation of a certificate.
+	CertificateRevoked CertificateAction = "Revoked"
+	// CertificateAdopted is the adoption of a valid certificate found in a pre-existing certificate secret, such as
+	// one written by the tool the operator replaced.
+	CertificateAdopted CertificateAction = "Adopted"
+)
+
+// MaxHistoryEntries bounds the history of a CertificateRequest. The oldest entries are dropped first.
+const MaxHistoryEntries = 20
+
 // CertificateRequestStatus defines the observed state of CertificateRequest
 // +k8s:openapi-gen=true
 type CertificateRequestStatus struct {
 
 	// Issued is true once certificates have been issued.
+	// Deprecated: use the Ready condition.
 	Issued bool `json:"issued,omitempty"`
 
-	// Status
+	// Status is Success once certificates have been issued and Error if the last attempt failed.
+	// Deprecated: use the Ready condition.
 	// +optional
 	Status string `json:"status,omitempty"`
 
+	// ObservedGeneration is the generation of the spec the status was last updated for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// FailedAttempts counts the consecutive failed attempts to issue the certificate.
+	// +optional
+	FailedAttempts int32 `json:"failedAttempts,omitempty"`
+
+	// FailureCount counts the failed attempts to issue the certificate since it was last issued. Unlike
+	// FailedAttempts, it is not reset by a change of the spec or the retry annotation.
+	// +optional
+	FailureCount int32 `json:"failureCount,omitempty"`
+
+	// LastFailure describes the last failed attempt to issue the certificate. It is cleared once the certificate is
+	// issued.
+	// +optional
+	LastFailure *CertificateFailure `json:"lastFailure,omitempty"`
+
 	// The expiration time of the certificate stored in the secret named by this resource in spec.secretName.
 	// +optional
 	NotAfter string `json:"notAfter,omitempty"`
@@ -113,9 +496,159 @@ type CertificateRequestStatus struct {
 	// +optional
 	SerialNumber string `json:"serialNumber,omitempty"`
 
+	// The SHA-256 fingerprint of the certificate stored in the secret named by this resource in spec.secretName.
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// The DNS names of the certificate stored in the secret named by this resource in spec.secretName.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// The time at which the certificate stored in the secret named by this resource in spec.secretName will be reissued.
+	// +optional
+	RenewalTime string `json:"renewalTime,omitempty"`
+
+	// The lifetime, from notBefore to notAfter, of the certificate stored in the secret named by this resource in spec.secretName.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
 	// Conditions includes more detailed status for the Certificate Request
 	// +optional
-	Conditions []CertificateRequestCondition `json:"conditions,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// SignedCertificateTimestamps lists the Certificate Transparency SCTs embedded in the certificate
+	// stored in the secret named by this resource in spec.secretName.
+	// +optional
+	SignedCertificateTimestamps []SignedCertificateTimestamp `json:"signedCertificateTimestamps,omitempty"`
+
+	// History records the last issuances, renewals and revocations of the certificate, oldest first. It is
+	// append-only and bounded to the last 20 entries.
+	// +optional
+	// +kubebuilder:validation:MaxItems=20
+	History []CertificateHistoryEntry `json:"history,omitempty"`
+
+	// ACMEDNSZone is the zone of the DNS provider the DNS-01 challenges are answered in: the closest zone enclosing
+	// spec.acmeDNSDomain, such as the parent zone a base domain is delegated from.
+	// +optional
+	ACMEDNSZone string `json:"acmeDNSZone,omitempty"`
+
+	// AzureKeyVault reports the last upload of the certificate to the Azure Key Vault of spec.azureKeyVault.
+	// +optional
+	AzureKeyVault *AzureKeyVaultStatus `json:"azureKeyVault,omitempty"`
+
+	// PagerDutyIncident is when the PagerDuty incident escalating the certificate was opened. It is cleared once the
+	// incident is resolved.
+	// +optional
+	PagerDutyIncident *metav1.Time `json:"pagerDutyIncident,omitempty"`
+
+	// SecretSinks reports the last push of the certificate to each sink of spec.secretSinks.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	SecretSinks []SecretSinkStatus `json:"secretSinks,omitempty"`
+}
+
+// SecretSinkStatus describes the certificate last pushed to a secret sink.
+type SecretSinkStatus struct {
+
+	// Name is the name of the sink.
+	Name string `json:"name"`
+
+	// Version identifies the version of the secret the certificate was pushed as, in the terms of the store.
+	Version string `json:"version"`
+
+	// SerialNumber is the serial number of the pushed certificate.
+	SerialNumber string `json:"serialNumber"`
+
+	// PushTime is when the certificate was pushed.
+	PushTime metav1.Time `json:"pushTime"`
+}
+
+// AzureKeyVaultStatus describes the certificate last uploaded to an Azure Key Vault.
+type AzureKeyVaultStatus struct {
+
+	// CertificateID is the identifier of the uploaded version of the certificate in the key vault.
+	CertificateID string `json:"certificateID"`
+
+	// SerialNumber is the serial number of the uploaded certificate.
+	SerialNumber string `json:"serialNumber"`
+
+	// UploadTime is when the certificate was uploaded.
+	UploadTime metav1.Time `json:"uploadTime"`
+}
+
+// CertificateFailure describes a failed attempt to issue the certificate of a CertificateRequest.
+type CertificateFailure struct {
+
+	// Reason is a CamelCase category of the failure, such as AcmeError or IssuanceFailed.
+	Reason string `json:"reason"`
+
+	// Message is the error the attempt failed with.
+	Message string `json:"message"`
+
+	// Time is when the attempt failed.
+	Time metav1.Time `json:"time"`
+
+	// Since is when the first of the failed attempts since the certificate was last issued failed.
+	// +optional
+	Since *metav1.Time `json:"since,omitempty"`
+}
+
+// CertificateHistoryEntry records an issuance, renewal or revocation of the certificate of a CertificateRequest.
+type CertificateHistoryEntry struct {
+
+	// Action is what was done to the certificate.
+	Action CertificateAction `json:"action"`
+
+	// Time is when the action was completed.
+	Time metav1.Time `json:"time"`
+
+	// Trigger is the reason the operator acted, such as SecretNotFound, RenewalTimeReached, DNSNamesChanged,
+	// SecretUnusable or RevokeAndReissueRequested.
+	Trigger string `json:"trigger"`
+
+	// Actor is the field manager of the change that triggered the action, such as kubectl-annotate for the
+	// revoke-and-reissue annotation, if it was triggered by a user.
+	// +optional
+	Actor string `json:"actor,omitempty"`
+
+	// OrderURL is the URL of the ACME order the certificate was issued by.
+	// +optional
+	OrderURL string `json:"orderURL,omitempty"`
+
+	// SerialNumber is the serial number of the certificate issued or revoked.
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// NotBefore is the time from which the certificate is valid.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+
+	// NotAfter is the expiration time of the certificate.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// RevocationReason is the reason given to the ACME server for a revocation.
+	// +optional
+	RevocationReason string `json:"revocationReason,omitempty"`
+}
+
+// SignedCertificateTimestamp records a Certificate Transparency log's promise to include a certificate.
+type SignedCertificateTimestamp struct {
+
+	// LogID is the base64 encoded ID of the CT log that issued the SCT.
+	LogID string `json:"logID"`
+
+	// LogName is the description of the CT log in the log list of the operator config, if it lists the log.
+	// +optional
+	LogName string `json:"logName,omitempty"`
+
+	// Timestamp is the time at which the CT log issued the SCT.
+	Timestamp string `json:"timestamp"`
 }
 
 // +kubebuilder:object:root=true
@@ -123,10 +656,14 @@ type CertificateRequestStatus struct {
 // CertificateRequest is the Schema for the certificaterequests API
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
-// +kubebuilder:printcolumn:name="IssuerName",type="string",JSONPath=".status.issuerName"
-// +kubebuilder:printcolumn:name="NotBefore",type="string",JSONPath=".status.notBefore"
-// +kubebuilder:printcolumn:name="NotAfter",type="string",JSONPath=".status.notAfter"
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Issuer",type="string",JSONPath=".status.issuerName"
 // +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".spec.certificateSecret.name"
+// +kubebuilder:printcolumn:name="Not After",type="string",JSONPath=".status.notAfter"
+// +kubebuilder:printcolumn:name="Renewal Time",type="string",JSONPath=".status.renewalTime"
+// +kubebuilder:printcolumn:name="Not Before",type="string",JSONPath=".status.notBefore",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type CertificateRequest struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -150,15 +687,58 @@ type Platform struct {
 	GCP   *GCPPlatformSecrets   `json:"gcp,omitempty"`
 	Azure *AzurePlatformSecrets `json:"azure,omitempty"`
 	Mock  *MockPlatformSecrets  `json:"mock,omitempty"`
+
+	// Cloudflare answers the DNS-01 challenges in Cloudflare. Clusters do not run on Cloudflare, so it is only
+	// meaningful as a DNSProvider or as the default platform of a CertIssuer.
+	// +optional
+	Cloudflare *CloudflarePlatformSecrets `json:"cloudflare,omitempty"`
+
+	// AcmeDNS answers the DNS-01 challenges with an acme-dns server, for base domains whose DNS cannot be automated.
+	// Each domain is delegated to the server once, with a CNAME of its _acme-challenge record. Like Cloudflare, it is
+	// only meaningful as a DNSProvider or as the default platform of a CertIssuer.
+	// +optional
+	AcmeDNS *AcmeDNSPlatformSecrets `json:"acmeDNS,omitempty"`
+}
+
+// CloudflarePlatformSecrets contains the secrets of a DNS zone hosted in Cloudflare.
+type CloudflarePlatformSecrets struct {
+	// Credentials refers to a secret that contains the api_token of a Cloudflare API token allowed to read the zone
+	// and edit its DNS records.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
+}
+
+// AcmeDNSPlatformSecrets contains the accounts of the domains registered with an acme-dns server.
+type AcmeDNSPlatformSecrets struct {
+	// Host is the URL of the API of the acme-dns server.
+	Host string `json:"host"`
+	// Credentials refers to a secret that contains, in its acmedns.json key, the JSON of the acme-dns accounts of the
+	// domains keyed by domain, in the format written by the registration clients of acme-dns.
+	Credentials corev1.LocalObjectReference `json:"credentials"`
 }
 
 // AWSPlatformSecrets contains secrets for clusters on the AWS platform.
+// +kubebuilder:validation:XValidation:rule="has(self.roleARN) || (!has(self.externalID) && !has(self.sessionTags))",message="externalID and sessionTags require roleARN"
 type AWSPlatformSecrets struct {
 	// Credentials refers to a secret that contains the AWS account access
 	// credentials.
 	Credentials corev1.LocalObjectReference `json:"credentials"`
 	// Region specifies the AWS region where the cluster will be created.
 	Region string `json:"region"`
+	// RoleARN is an IAM role assumed with the credentials to manage the DNS records, so that the credentials only
+	// need to be allowed to assume it.
+	// +kubebuilder:validation:Pattern=`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`
+	// +optional
+	RoleARN string `json:"roleARN,omitempty"`
+	// ExternalID is passed when assuming RoleARN, for roles whose trust policy requires it.
+	// +kubebuilder:validation:MinLength=2
+	// +kubebuilder:validation:MaxLength=1224
+	// +optional
+	ExternalID string `json:"externalID,omitempty"`
+	// SessionTags are set on the session of RoleARN, so that its policies can scope it with aws:PrincipalTag
+	// conditions.
+	// +kubebuilder:validation:MaxProperties=50
+	// +optional
+	SessionTags map[string]string `json:"sessionTags,omitempty"`
 }
 
 // GCPPlatformSecrets contains secrets for clusters on the GCP platform.
@@ -194,6 +774,23 @@ const (
 	// CertmanOperatorFinalizerLabel is a K8's finalizer. An arbitrary string that when
 	// present ensures a hard delete of a resource is not possible.
 	CertmanOperatorFinalizerLabel = "certificaterequests.certman.managed.openshift.io"
+
+	// PausedAnnotation stops the operator from ordering certificates, writing DNS records and updating secrets for
+	// a CertificateRequest, or for every CertificateRequest of a ClusterDeployment, while it is set to "true".
+	PausedAnnotation = "certman.managed.openshift.io/paused"
+
+	// RetryAnnotation resets the retry budget of a Failed CertificateRequest, so that it orders certificates again.
+	// The operator removes it once it is handled.
+	RetryAnnotation = "certman.managed.openshift.io/retry"
+
+	// MigratedFromAnnotation is set on the CertificateRequests converted from a cert-manager Certificate to the
+	// <namespace>/<name> of the Certificate. Such CertificateRequests do not belong to a ClusterDeployment, and are
+	// left alone by the ClusterDeployment controller.
+	MigratedFromAnnotation = "certman.managed.openshift.io/migrated-from"
+
+	// CustomDomainAnnotation is set on the CertificateRequests of the custom domains of a ClusterDeployment to the
+	// name of the CustomDomain of the managed cluster the certificate is issued for.
+	CustomDomainAnnotation = "certman.managed.openshift.io/custom-domain"
 )
 
 func init() {
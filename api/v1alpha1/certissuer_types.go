@@ -0,0 +1,192 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertIssuerSpec defines the desired state of CertIssuer
+// +kubebuilder:validation:XValidation:rule="[has(self.acme), has(self.googleCAS), has(self.venafi)].filter(x, x).size() == 1",message="exactly one of acme, googleCAS and venafi must be set"
+type CertIssuerSpec struct {
+
+	// ACME configures an ACME certificate authority.
+	// +optional
+	ACME *ACMEIssuer `json:"acme,omitempty"`
+
+	// GoogleCAS configures a CA pool of Google Certificate Authority Service, which issues certificates to the
+	// operator's service account without ACME challenges.
+	// +optional
+	GoogleCAS *GoogleCASIssuer `json:"googleCAS,omitempty"`
+
+	// Venafi configures a policy zone of Venafi Trust Protection Platform or Venafi as a Service, which broker the
+	// certificates to the certificate authorities of the organization without ACME challenges.
+	// +optional
+	Venafi *VenafiIssuer `json:"venafi,omitempty"`
+}
+
+// ACMEIssuer contains the configuration needed to request certificates from an ACME server.
+type ACMEIssuer struct {
+
+	// DirectoryURL is the URL of the ACME server's directory endpoint.
+	DirectoryURL string `json:"directoryURL"`
+
+	// AccountSecretRef is the reference to the secret holding the ACME account private key and URL.
+	// If the namespace is unset, the operator namespace is used.
+	AccountSecretRef corev1.SecretReference `json:"accountSecretRef"`
+
+	// DefaultPlatform is the DNS-01 solver configuration used by CertificateRequests that do not
+	// define their own platform.
+	// +optional
+	DefaultPlatform *Platform `json:"defaultPlatform,omitempty"`
+
+	// CABundle is a PEM encoded bundle of CA certificates trusted, in addition to the system roots,
+	// when validating certificates issued by this ACME server. Required for private ACME servers.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// ExternalAccountBinding binds the ACME account to an account of the certificate authority, as required by
+	// ACME servers such as ZeroSSL. An account secret holding a private key but no account URL is registered
+	// with the binding, and the URL of the registered account is stored in the secret.
+	// +optional
+	ExternalAccountBinding *ACMEExternalAccountBinding `json:"externalAccountBinding,omitempty"`
+
+	// PreferredChain is the common name of the root, or of the issuer of the top certificate, of the chain to
+	// store when the ACME server offers alternate chains, such as ISRG Root X1. The default chain is stored when
+	// none matches.
+	// +optional
+	PreferredChain string `json:"preferredChain,omitempty"`
+}
+
+// ACMEExternalAccountBinding contains the MAC key the certificate authority issued to bind an ACME account to
+// one of its accounts, as described in RFC 8555 section 7.3.4.
+type ACMEExternalAccountBinding struct {
+
+	// KeyID is the ID of the MAC key.
+	KeyID string `json:"keyID"`
+
+	// KeySecretRef is the reference to the secret holding, in hmac-key, the base64url encoded MAC key. If the
+	// namespace is unset, the operator namespace is used.
+	KeySecretRef corev1.SecretReference `json:"keySecretRef"`
+}
+
+// GoogleCASIssuer contains the configuration needed to request certificates from a Google Certificate Authority
+// Service CA pool.
+type GoogleCASIssuer struct {
+
+	// Project is the GCP project of the CA pool. Defaults to the project of the service account.
+	// +optional
+	Project string `json:"project,omitempty"`
+
+	// Location is the region of the CA pool, such as us-central1.
+	Location string `json:"location"`
+
+	// CAPool is the ID of the CA pool issuing the certificates.
+	CAPool string `json:"caPool"`
+
+	// CertificateAuthority is the ID of the certificate authority of the pool issuing the certificates. If unset,
+	// the pool selects one.
+	// +optional
+	CertificateAuthority string `json:"certificateAuthority,omitempty"`
+
+	// CredentialsSecretRef is the reference to the secret holding, in osServiceAccount.json, the key of a GCP service
+	// account allowed to create, list and revoke the certificates of the CA pool. If the namespace is unset, the
+	// operator namespace is used.
+	CredentialsSecretRef corev1.SecretReference `json:"credentialsSecretRef"`
+
+	// CABundle is a PEM encoded bundle of the root certificates of the CA pool. If unset, issued certificates are
+	// verified against the root at the top of the chain returned by the service.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// VenafiIssuer contains the configuration needed to request certificates from Venafi.
+// +kubebuilder:validation:XValidation:rule="has(self.tpp) != has(self.cloud)",message="exactly one of tpp and cloud must be set"
+type VenafiIssuer struct {
+
+	// Zone is the zone certificates are requested in: the policy folder of Trust Protection Platform, such as
+	// Certificates\Kubernetes, or the application name and issuing template alias of Venafi as a Service, such as
+	// My Application\My Template.
+	Zone string `json:"zone"`
+
+	// TPP configures a Venafi Trust Protection Platform server.
+	// +optional
+	TPP *VenafiTPP `json:"tpp,omitempty"`
+
+	// Cloud configures Venafi as a Service.
+	// +optional
+	Cloud *VenafiCloud `json:"cloud,omitempty"`
+
+	// CABundle is a PEM encoded bundle of the root certificates of the certificate authorities of the zone. If unset,
+	// issued certificates are verified against the root at the top of the chain returned by Venafi.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// VenafiTPP contains the configuration needed to connect to Venafi Trust Protection Platform.
+type VenafiTPP struct {
+
+	// URL is the URL of the web SDK of the server, such as https://tpp.example.com/vedsdk.
+	URL string `json:"url"`
+
+	// CredentialsSecretRef is the reference to the secret holding, in access-token, an OAuth access token with the
+	// certificate:manage,revoke scope. If the namespace is unset, the operator namespace is used.
+	CredentialsSecretRef corev1.SecretReference `json:"credentialsSecretRef"`
+
+	// ServerCABundle is a PEM encoded bundle of CA certificates trusted, in addition to the system roots, when
+	// connecting to the server.
+	// +optional
+	ServerCABundle []byte `json:"serverCABundle,omitempty"`
+}
+
+// VenafiCloud contains the configuration needed to connect to Venafi as a Service.
+type VenafiCloud struct {
+
+	// URL is the URL of the API. Defaults to https://api.venafi.cloud.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// CredentialsSecretRef is the reference to the secret holding, in api-key, an API key of the service. If the
+	// namespace is unset, the operator namespace is used.
+	CredentialsSecretRef corev1.SecretReference `json:"credentialsSecretRef"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// CertIssuer is the Schema for the certissuers API
+// +kubebuilder:printcolumn:name="DirectoryURL",type="string",JSONPath=".spec.acme.directoryURL"
+type CertIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertIssuerSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CertIssuerList contains a list of CertIssuer
+type CertIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertIssuer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertIssuer{}, &CertIssuerList{})
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertmanOperatorConfigName is the name of the only CertmanOperatorConfig the operator reads.
+const CertmanOperatorConfigName = "cluster"
+
+// HibernationRenewalPolicy is how the certificates of hibernating clusters are renewed.
+type HibernationRenewalPolicy string
+
+const (
+	// RenewHibernationRenewalPolicy keeps renewing the certificates of hibernating clusters.
+	RenewHibernationRenewalPolicy HibernationRenewalPolicy = "Renew"
+
+	// DeferHibernationRenewalPolicy defers the renewals of hibernating clusters until they resume.
+	DeferHibernationRenewalPolicy HibernationRenewalPolicy = "Defer"
+)
+
+// CertmanOperatorConfigSpec defines the configuration of the operator. Unset fields fall back to the keys of the
+// operator ConfigMap, and then to the defaults of the operator.
+type CertmanOperatorConfigSpec struct {
+
+	// DefaultNotificationEmail is the email address stamped on new CertificateRequests, which receives the
+	// notifications of the certificate authority.
+	// +optional
+	DefaultNotificationEmail string `json:"defaultNotificationEmail,omitempty"`
+
+	// ACMEDirectoryURL overrides the directory URL of the ACME server of the lets-encrypt-account secret, which is
+	// otherwise derived from the account URL. CertIssuers set their own directory URL.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	ACMEDirectoryURL string `json:"acmeDirectoryURL,omitempty"`
+
+	// ExtraRecord is the label of an extra DNS name added to the control plane certificate of every cluster, as
+	// <extraRecord>.<cluster name>.<base domain>. It replaces the EXTRA_RECORD environment variable.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	ExtraRecord string `json:"extraRecord,omitempty"`
+
+	// TrustedCABundle is the name of a ConfigMap of the operator namespace whose ca-bundle.crt key holds PEM encoded
+	// CA certificates trusted, in addition to the system roots, by the connections to the ACME servers and the DNS
+	// providers.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`
+	// +optional
+	TrustedCABundle string `json:"trustedCABundle,omitempty"`
+
+	// DefaultKeySize is the size in bits of the RSA private keys of the CertificateRequests that do not set one.
+	// +kubebuilder:validation:Enum=2048;3072;4096
+	// +optional
+	DefaultKeySize *int32 `json:"defaultKeySize,omitempty"`
+
+	// RevokeCertificatesOnDelete revokes the certificate of a CertificateRequest when it is deleted. Defaults to true.
+	// +optional
+	RevokeCertificatesOnDelete *bool `json:"revokeCertificatesOnDelete,omitempty"`
+
+	// VerifyCertificateTransparency requires issued certificates to carry embedded Certificate Transparency SCTs.
+	// +optional
+	VerifyCertificateTransparency *bool `json:"verifyCertificateTransparency,omitempty"`
+
+	// VerifyCertificateTransparencyInclusion requires issued certificates to be included in at least one of the CT
+	// logs of CTLogList that issued their embedded SCTs.
+	// +optional
+	VerifyCertificateTransparencyInclusion *bool `json:"verifyCertificateTransparencyInclusion,omitempty"`
+
+	// CTLogList is the name of a ConfigMap of the operator namespace whose log_list.json key holds a Certificate
+	// Transparency log list in the v3 format published by Google. It names the CT logs of the SCTs recorded in the
+	// status of the CertificateRequests, and lists the logs checked by VerifyCertificateTransparencyInclusion.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-.a-z0-9]*[a-z0-9])?$`
+	// +optional
+	CTLogList string `json:"ctLogList,omitempty"`
+
+	// ManageAlerts manages the PrometheusRule of the alerts of the operator.
+	// +optional
+	ManageAlerts *bool `json:"manageAlerts,omitempty"`
+
+	// ManageDashboard manages the ConfigMap of the Grafana dashboard of the operator.
+	// +optional
+	ManageDashboard *bool `json:"manageDashboard,omitempty"`
+
+	// ReconcileTimeout is how long a single reconcile may take before its API, ACME and DNS calls are cancelled.
+	// Must be at least a minute.
+	// +optional
+	ReconcileTimeout *metav1.Duration `json:"reconcileTimeout,omitempty"`
+
+	// MaxIssuanceAttempts is the number of consecutive failed attempts to issue a certificate after which a
+	// CertificateRequest is marked Failed. 0 retries forever.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxIssuanceAttempts *int32 `json:"maxIssuanceAttempts,omitempty"`
+
+	// Renewal configures when certificates are checked for renewal and renewed.
+	// +optional
+	Renewal *RenewalConfig `json:"renewal,omitempty"`
+
+	// RateLimits bounds the new orders placed with each ACME account.
+	// +optional
+	RateLimits *RateLimitsConfig `json:"rateLimits,omitempty"`
+
+	// Notifications configures the notifications of the certificate events.
+	// +optional
+	Notifications *NotificationsConfig `json:"notifications,omitempty"`
+
+	// DNS tunes the checks of the propagation of the DNS-01 challenge records.
+	// +optional
+	DNS *DNSConfig `json:"dns,omitempty"`
+}
+
+// RenewalConfig configures when certificates are checked for renewal and renewed.
+type RenewalConfig struct {
+
+	// CheckInterval is how often certificates are checked for renewal. Must be at least a minute.
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+
+	// JitterWindow is the window over which the renewals of certificates issued at the same time are spread.
+	// +optional
+	JitterWindow *metav1.Duration `json:"jitterWindow,omitempty"`
+
+	// HibernationPolicy is how the certificates of hibernating clusters are renewed.
+	// +kubebuilder:validation:Enum=Renew;Defer
+	// +optional
+	HibernationPolicy HibernationRenewalPolicy `json:"hibernationPolicy,omitempty"`
+}
+
+// RateLimitsConfig bounds the new orders placed with each ACME account. Unset or 0 is unlimited.
+type RateLimitsConfig struct {
+
+	// MaxOrdersPerHour is the maximum number of new orders per ACME account and hour.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxOrdersPerHour *int32 `json:"maxOrdersPerHour,omitempty"`
+
+	// MaxOrdersPerWeek is the maximum number of new orders per ACME account and week.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxOrdersPerWeek *int32 `json:"maxOrdersPerWeek,omitempty"`
+}
+
+// NotificationsConfig configures the notifications of the certificate events.
+type NotificationsConfig struct {
+
+	// Webhook configures the notifications posted to the webhook of the certman-operator-webhook secret.
+	// +optional
+	Webhook *WebhookNotificationConfig `json:"webhook,omitempty"`
+
+	// Email configures the emails sent to the owners of the certificates.
+	// +optional
+	Email *EmailNotificationConfig `json:"email,omitempty"`
+
+	// PagerDuty configures the PagerDuty incidents opened on the routing key of the certman-operator-pagerduty
+	// secret.
+	// +optional
+	PagerDuty *PagerDutyNotificationConfig `json:"pagerDuty,omitempty"`
+
+	// ServiceLogFailureThreshold is the number of failed attempts to issue a certificate since it was last issued
+	// after which a service log is posted to the owners of the cluster. 0 posts no service logs.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ServiceLogFailureThreshold *int32 `json:"serviceLogFailureThreshold,omitempty"`
+}
+
+// WebhookNotificationConfig configures the notifications posted to a webhook.
+type WebhookNotificationConfig struct {
+
+	// Format is the format of the notifications, json or slack.
+	// +kubebuilder:validation:Enum=json;slack
+	Format string `json:"format"`
+
+	// Template is the Go template of the payload of the json format, rendered from the event.
+	// +optional
+	Template string `json:"template,omitempty"`
+}
+
+// EmailNotificationConfig configures the emails sent to the owners of the certificates.
+type EmailNotificationConfig struct {
+
+	// Enabled emails the owners of the certificates about their renewals and upcoming expiries.
+	Enabled bool `json:"enabled"`
+
+	// ExpiryWarningDays is how many days before its expiry the owner of a certificate that was not renewed is
+	// emailed.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ExpiryWarningDays *int32 `json:"expiryWarningDays,omitempty"`
+}
+
+// PagerDutyNotificationConfig configures when a PagerDuty incident is opened for a certificate. An incident is
+// resolved once the certificate is issued.
+type PagerDutyNotificationConfig struct {
+
+	// FailureDuration opens an incident when the certificate has failed to be issued for longer than this duration.
+	// Unset or 0 opens no incidents on failures.
+	// +optional
+	FailureDuration *metav1.Duration `json:"failureDuration,omitempty"`
+
+	// ExpiryDays opens an incident when the certificate expires within this many days without having been renewed.
+	// Unset or 0 opens no incidents on expiries.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ExpiryDays *int32 `json:"expiryDays,omitempty"`
+}
+
+// DNSConfig tunes the checks of the propagation of the DNS-01 challenge records.
+type DNSConfig struct {
+
+	// PropagationCheckAttempts is the number of times a challenge record is looked up before giving up.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PropagationCheckAttempts *int32 `json:"propagationCheckAttempts,omitempty"`
+
+	// PropagationCheckInterval is the wait between the lookups of a challenge record.
+	// +optional
+	PropagationCheckInterval *metav1.Duration `json:"propagationCheckInterval,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'cluster'",message="the CertmanOperatorConfig must be named cluster"
+
+// CertmanOperatorConfig is the Schema for the certmanoperatorconfigs API. The operator only reads the one named
+// cluster, and watches it so that changes apply without restarting it.
+type CertmanOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertmanOperatorConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CertmanOperatorConfigList contains a list of CertmanOperatorConfig
+type CertmanOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertmanOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertmanOperatorConfig{}, &CertmanOperatorConfigList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngressCertificateRolloutSpec defines the desired state of IngressCertificateRollout
+type IngressCertificateRolloutSpec struct {
+
+	// CertificateRequest is the CertificateRequest whose certificate is rolled out.
+	CertificateRequest CertificateRequestReference `json:"certificateRequest"`
+
+	// ClusterSelector selects the ClusterDeployments, in any namespace, whose clusters serve the certificate.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// IngressController is the ingress controller of the clusters serving the certificate. Defaults to default.
+	// +optional
+	IngressController string `json:"ingressController,omitempty"`
+
+	// SecretName is the name of the secret holding the certificate in the openshift-ingress namespace of the
+	// clusters. Defaults to the name of the certificate secret.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// CertificateRequestReference refers to a CertificateRequest by namespace and name.
+type CertificateRequestReference struct {
+
+	// Namespace of the CertificateRequest.
+	Namespace string `json:"namespace"`
+
+	// Name of the CertificateRequest.
+	Name string `json:"name"`
+}
+
+// IngressCertificateRolloutStatus defines the observed state of IngressCertificateRollout
+type IngressCertificateRolloutStatus struct {
+
+	// SelectorSyncSet is the name of the Hive SelectorSyncSet delivering the certificate.
+	// +optional
+	SelectorSyncSet string `json:"selectorSyncSet,omitempty"`
+
+	// SerialNumber is the serial number of the certificate being rolled out.
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// NotAfter is the expiration time of the certificate being rolled out.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// Clusters is the number of ClusterDeployments matching the cluster selector.
+	// +optional
+	Clusters int `json:"clusters,omitempty"`
+
+	// Conditions hold the Ready condition of the rollout.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// IngressCertificateRolloutReady is true while the SelectorSyncSet delivers the current certificate of the
+// CertificateRequest.
+const IngressCertificateRolloutReady = "Ready"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+
+// IngressCertificateRollout is the Schema for the ingresscertificaterollouts API
+// +kubebuilder:printcolumn:name="Clusters",type="integer",JSONPath=".status.clusters"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="NotAfter",type="date",JSONPath=".status.notAfter"
+type IngressCertificateRollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IngressCertificateRolloutSpec   `json:"spec,omitempty"`
+	Status IngressCertificateRolloutStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IngressCertificateRolloutList contains a list of IngressCertificateRollout
+type IngressCertificateRolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IngressCertificateRollout `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IngressCertificateRollout{}, &IngressCertificateRolloutList{})
+}
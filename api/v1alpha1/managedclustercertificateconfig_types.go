@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ManagedClusterCertificateConfigSpec defines the desired state of ManagedClusterCertificateConfig
+type ManagedClusterCertificateConfigSpec struct {
+
+	// BaseDomain is the domain of the public DNS zone the domains of the cluster belong to, in which the challenges
+	// of its certificates are answered.
+	BaseDomain string `json:"baseDomain"`
+
+	// Platform holds the credentials of the DNS provider of the base domain, read from secrets in the namespace of
+	// the config.
+	Platform Platform `json:"platform"`
+
+	// APIDomain is the domain of the API server of the cluster. Defaults to the host of the first client config URL
+	// of the ManagedCluster.
+	// +optional
+	APIDomain string `json:"apiDomain,omitempty"`
+
+	// IngressDomain is the domain of the default ingress of the cluster, which is issued a wildcard certificate.
+	// Defaults to the domain of the console URL claim of the ManagedCluster.
+	// +optional
+	IngressDomain string `json:"ingressDomain,omitempty"`
+
+	// Emails are the notification contacts of the certificates of the cluster. Defaults to the default
+	// notification email address of the operator.
+	// +optional
+	Emails []string `json:"emails,omitempty"`
+}
+
+// ManagedClusterCertificateConfigStatus defines the observed state of ManagedClusterCertificateConfig
+type ManagedClusterCertificateConfigStatus struct {
+
+	// CertificateRequests are the names of the CertificateRequests of the cluster.
+	// +optional
+	CertificateRequests []string `json:"certificateRequests,omitempty"`
+
+	// Conditions hold the Ready condition of the config.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ManagedClusterCertificateConfigReady is true while the CertificateRequests of the cluster are in line with the
+// config and the ManagedCluster.
+const ManagedClusterCertificateConfigReady = "Ready"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ManagedClusterCertificateConfig is the Schema for the managedclustercertificateconfigs API. It is named after the
+// Open Cluster Management ManagedCluster whose certificates it configures.
+// +kubebuilder:printcolumn:name="Base Domain",type="string",JSONPath=".spec.baseDomain"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ManagedClusterCertificateConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagedClusterCertificateConfigSpec   `json:"spec,omitempty"`
+	Status ManagedClusterCertificateConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ManagedClusterCertificateConfigList contains a list of ManagedClusterCertificateConfig
+type ManagedClusterCertificateConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedClusterCertificateConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedClusterCertificateConfig{}, &ManagedClusterCertificateConfigList{})
+}
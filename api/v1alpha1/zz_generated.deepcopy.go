@@ -5,43 +5,1246 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEExternalAccountBinding) DeepCopyInto(out *ACMEExternalAccountBinding) {
+	*out = *in
+	out.KeySecretRef = in.KeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEExternalAccountBinding.
+func (in *ACMEExternalAccountBinding) DeepCopy() *ACMEExternalAccountBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEExternalAccountBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ACMEIssuer) DeepCopyInto(out *ACMEIssuer) {
+	*out = *in
+	out.AccountSecretRef = in.AccountSecretRef
+	if in.DefaultPlatform != nil {
+		in, out := &in.DefaultPlatform, &out.DefaultPlatform
+		*out = new(Platform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExternalAccountBinding != nil {
+		in, out := &in.ExternalAccountBinding, &out.ExternalAccountBinding
+		*out = new(ACMEExternalAccountBinding)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ACMEIssuer.
+func (in *ACMEIssuer) DeepCopy() *ACMEIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(ACMEIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSKMSKey) DeepCopyInto(out *AWSKMSKey) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSKMSKey.
+func (in *AWSKMSKey) DeepCopy() *AWSKMSKey {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSKMSKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSPlatformSecrets) DeepCopyInto(out *AWSPlatformSecrets) {
 	*out = *in
-	out.Credentials = in.Credentials
+	out.Credentials = in.Credentials
+	if in.SessionTags != nil {
+		in, out := &in.SessionTags, &out.SessionTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSPlatformSecrets.
+func (in *AWSPlatformSecrets) DeepCopy() *AWSPlatformSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSPlatformSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSSecretsManagerSink) DeepCopyInto(out *AWSSecretsManagerSink) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSSecretsManagerSink.
+func (in *AWSSecretsManagerSink) DeepCopy() *AWSSecretsManagerSink {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSSecretsManagerSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AcmeDNSPlatformSecrets) DeepCopyInto(out *AcmeDNSPlatformSecrets) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AcmeDNSPlatformSecrets.
+func (in *AcmeDNSPlatformSecrets) DeepCopy() *AcmeDNSPlatformSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(AcmeDNSPlatformSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureKeyVault) DeepCopyInto(out *AzureKeyVault) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureKeyVault.
+func (in *AzureKeyVault) DeepCopy() *AzureKeyVault {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureKeyVault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureKeyVaultStatus) DeepCopyInto(out *AzureKeyVaultStatus) {
+	*out = *in
+	in.UploadTime.DeepCopyInto(&out.UploadTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureKeyVaultStatus.
+func (in *AzureKeyVaultStatus) DeepCopy() *AzureKeyVaultStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureKeyVaultStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzurePlatformSecrets) DeepCopyInto(out *AzurePlatformSecrets) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzurePlatformSecrets.
+func (in *AzurePlatformSecrets) DeepCopy() *AzurePlatformSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(AzurePlatformSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertIssuer) DeepCopyInto(out *CertIssuer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertIssuer.
+func (in *CertIssuer) DeepCopy() *CertIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(CertIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertIssuer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertIssuerList) DeepCopyInto(out *CertIssuerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertIssuer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertIssuerList.
+func (in *CertIssuerList) DeepCopy() *CertIssuerList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertIssuerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertIssuerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertIssuerSpec) DeepCopyInto(out *CertIssuerSpec) {
+	*out = *in
+	if in.ACME != nil {
+		in, out := &in.ACME, &out.ACME
+		*out = new(ACMEIssuer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoogleCAS != nil {
+		in, out := &in.GoogleCAS, &out.GoogleCAS
+		*out = new(GoogleCASIssuer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Venafi != nil {
+		in, out := &in.Venafi, &out.Venafi
+		*out = new(VenafiIssuer)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertIssuerSpec.
+func (in *CertIssuerSpec) DeepCopy() *CertIssuerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertIssuerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateFailure) DeepCopyInto(out *CertificateFailure) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Since != nil {
+		in, out := &in.Since, &out.Since
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateFailure.
+func (in *CertificateFailure) DeepCopy() *CertificateFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateHistoryEntry) DeepCopyInto(out *CertificateHistoryEntry) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.NotBefore != nil {
+		in, out := &in.NotBefore, &out.NotBefore
+		*out = (*in).DeepCopy()
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateHistoryEntry.
+func (in *CertificateHistoryEntry) DeepCopy() *CertificateHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateInventory) DeepCopyInto(out *CertificateInventory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateInventory.
+func (in *CertificateInventory) DeepCopy() *CertificateInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateInventory) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateInventoryList) DeepCopyInto(out *CertificateInventoryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateInventory, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateInventoryList.
+func (in *CertificateInventoryList) DeepCopy() *CertificateInventoryList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateInventoryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateInventoryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateInventorySpec) DeepCopyInto(out *CertificateInventorySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateInventorySpec.
+func (in *CertificateInventorySpec) DeepCopy() *CertificateInventorySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateInventorySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateInventoryStatus) DeepCopyInto(out *CertificateInventoryStatus) {
+	*out = *in
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateInventoryStatus.
+func (in *CertificateInventoryStatus) DeepCopy() *CertificateInventoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateInventoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateKeystores) DeepCopyInto(out *CertificateKeystores) {
+	*out = *in
+	if in.PKCS12 != nil {
+		in, out := &in.PKCS12, &out.PKCS12
+		*out = new(KeystoreOptions)
+		**out = **in
+	}
+	if in.JKS != nil {
+		in, out := &in.JKS, &out.JKS
+		*out = new(KeystoreOptions)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateKeystores.
+func (in *CertificateKeystores) DeepCopy() *CertificateKeystores {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateKeystores)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicy) DeepCopyInto(out *CertificatePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicy.
+func (in *CertificatePolicy) DeepCopy() *CertificatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificatePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicyList) DeepCopyInto(out *CertificatePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificatePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicyList.
+func (in *CertificatePolicyList) DeepCopy() *CertificatePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificatePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicySpec) DeepCopyInto(out *CertificatePolicySpec) {
+	*out = *in
+	if in.AllowedDomains != nil {
+		in, out := &in.AllowedDomains, &out.AllowedDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxDNSNames != nil {
+		in, out := &in.MaxDNSNames, &out.MaxDNSNames
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AllowedKeyAlgorithms != nil {
+		in, out := &in.AllowedKeyAlgorithms, &out.AllowedKeyAlgorithms
+		*out = make([]KeyAlgorithm, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowWildcards != nil {
+		in, out := &in.AllowWildcards, &out.AllowWildcards
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicySpec.
+func (in *CertificatePolicySpec) DeepCopy() *CertificatePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequest) DeepCopyInto(out *CertificateRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequest.
+func (in *CertificateRequest) DeepCopy() *CertificateRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestList) DeepCopyInto(out *CertificateRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificateRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestList.
+func (in *CertificateRequestList) DeepCopy() *CertificateRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificateRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestReference) DeepCopyInto(out *CertificateRequestReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestReference.
+func (in *CertificateRequestReference) DeepCopy() *CertificateRequestReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestSpec) DeepCopyInto(out *CertificateRequestSpec) {
+	*out = *in
+	out.CertificateSecret = in.CertificateSecret
+	in.Platform.DeepCopyInto(&out.Platform)
+	if in.DNSProvider != nil {
+		in, out := &in.DNSProvider, &out.DNSProvider
+		*out = new(Platform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Solvers != nil {
+		in, out := &in.Solvers, &out.Solvers
+		*out = make([]ChallengeSolver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DnsNames != nil {
+		in, out := &in.DnsNames, &out.DnsNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSKey)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Keystores != nil {
+		in, out := &in.Keystores, &out.Keystores
+		*out = new(CertificateKeystores)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretTemplate != nil {
+		in, out := &in.SecretTemplate, &out.SecretTemplate
+		*out = new(CertificateSecretTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretReplicas != nil {
+		in, out := &in.SecretReplicas, &out.SecretReplicas
+		*out = make([]v1.SecretReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImportedSecret != nil {
+		in, out := &in.ImportedSecret, &out.ImportedSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.AzureKeyVault != nil {
+		in, out := &in.AzureKeyVault, &out.AzureKeyVault
+		*out = new(AzureKeyVault)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretSinks != nil {
+		in, out := &in.SecretSinks, &out.SecretSinks
+		*out = make([]SecretSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SyncSet != nil {
+		in, out := &in.SyncSet, &out.SyncSet
+		*out = new(CertificateSyncSet)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestSpec.
+func (in *CertificateRequestSpec) DeepCopy() *CertificateRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRequestStatus) DeepCopyInto(out *CertificateRequestStatus) {
+	*out = *in
+	if in.LastFailure != nil {
+		in, out := &in.LastFailure, &out.LastFailure
+		*out = new(CertificateFailure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SignedCertificateTimestamps != nil {
+		in, out := &in.SignedCertificateTimestamps, &out.SignedCertificateTimestamps
+		*out = make([]SignedCertificateTimestamp, len(*in))
+		copy(*out, *in)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]CertificateHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AzureKeyVault != nil {
+		in, out := &in.AzureKeyVault, &out.AzureKeyVault
+		*out = new(AzureKeyVaultStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PagerDutyIncident != nil {
+		in, out := &in.PagerDutyIncident, &out.PagerDutyIncident
+		*out = (*in).DeepCopy()
+	}
+	if in.SecretSinks != nil {
+		in, out := &in.SecretSinks, &out.SecretSinks
+		*out = make([]SecretSinkStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestStatus.
+func (in *CertificateRequestStatus) DeepCopy() *CertificateRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSecretTemplate) DeepCopyInto(out *CertificateSecretTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSecretTemplate.
+func (in *CertificateSecretTemplate) DeepCopy() *CertificateSecretTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSecretTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSummary) DeepCopyInto(out *CertificateSummary) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.DaysRemaining != nil {
+		in, out := &in.DaysRemaining, &out.DaysRemaining
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSummary.
+func (in *CertificateSummary) DeepCopy() *CertificateSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateSyncSet) DeepCopyInto(out *CertificateSyncSet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateSyncSet.
+func (in *CertificateSyncSet) DeepCopy() *CertificateSyncSet {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateSyncSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertmanOperatorConfig) DeepCopyInto(out *CertmanOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertmanOperatorConfig.
+func (in *CertmanOperatorConfig) DeepCopy() *CertmanOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CertmanOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertmanOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertmanOperatorConfigList) DeepCopyInto(out *CertmanOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertmanOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertmanOperatorConfigList.
+func (in *CertmanOperatorConfigList) DeepCopy() *CertmanOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertmanOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertmanOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertmanOperatorConfigSpec) DeepCopyInto(out *CertmanOperatorConfigSpec) {
+	*out = *in
+	if in.DefaultKeySize != nil {
+		in, out := &in.DefaultKeySize, &out.DefaultKeySize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RevokeCertificatesOnDelete != nil {
+		in, out := &in.RevokeCertificatesOnDelete, &out.RevokeCertificatesOnDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VerifyCertificateTransparency != nil {
+		in, out := &in.VerifyCertificateTransparency, &out.VerifyCertificateTransparency
+		*out = new(bool)
+		**out = **in
+	}
+	if in.VerifyCertificateTransparencyInclusion != nil {
+		in, out := &in.VerifyCertificateTransparencyInclusion, &out.VerifyCertificateTransparencyInclusion
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ManageAlerts != nil {
+		in, out := &in.ManageAlerts, &out.ManageAlerts
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ManageDashboard != nil {
+		in, out := &in.ManageDashboard, &out.ManageDashboard
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReconcileTimeout != nil {
+		in, out := &in.ReconcileTimeout, &out.ReconcileTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxIssuanceAttempts != nil {
+		in, out := &in.MaxIssuanceAttempts, &out.MaxIssuanceAttempts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Renewal != nil {
+		in, out := &in.Renewal, &out.Renewal
+		*out = new(RenewalConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimits != nil {
+		in, out := &in.RateLimits, &out.RateLimits
+		*out = new(RateLimitsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(NotificationsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertmanOperatorConfigSpec.
+func (in *CertmanOperatorConfigSpec) DeepCopy() *CertmanOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertmanOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChallengeSolver) DeepCopyInto(out *ChallengeSolver) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSZones != nil {
+		in, out := &in.DNSZones, &out.DNSZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChallengeSolver.
+func (in *ChallengeSolver) DeepCopy() *ChallengeSolver {
+	if in == nil {
+		return nil
+	}
+	out := new(ChallengeSolver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudflarePlatformSecrets) DeepCopyInto(out *CloudflarePlatformSecrets) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflarePlatformSecrets.
+func (in *CloudflarePlatformSecrets) DeepCopy() *CloudflarePlatformSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudflarePlatformSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSConfig) DeepCopyInto(out *DNSConfig) {
+	*out = *in
+	if in.PropagationCheckAttempts != nil {
+		in, out := &in.PropagationCheckAttempts, &out.PropagationCheckAttempts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PropagationCheckInterval != nil {
+		in, out := &in.PropagationCheckInterval, &out.PropagationCheckInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSConfig.
+func (in *DNSConfig) DeepCopy() *DNSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EmailNotificationConfig) DeepCopyInto(out *EmailNotificationConfig) {
+	*out = *in
+	if in.ExpiryWarningDays != nil {
+		in, out := &in.ExpiryWarningDays, &out.ExpiryWarningDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EmailNotificationConfig.
+func (in *EmailNotificationConfig) DeepCopy() *EmailNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EmailNotificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPPlatformSecrets) DeepCopyInto(out *GCPPlatformSecrets) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPPlatformSecrets.
+func (in *GCPPlatformSecrets) DeepCopy() *GCPPlatformSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPPlatformSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCPSecretManagerSink) DeepCopyInto(out *GCPSecretManagerSink) {
+	*out = *in
+	out.Credentials = in.Credentials
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPSecretManagerSink.
+func (in *GCPSecretManagerSink) DeepCopy() *GCPSecretManagerSink {
+	if in == nil {
+		return nil
+	}
+	out := new(GCPSecretManagerSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoogleCASIssuer) DeepCopyInto(out *GoogleCASIssuer) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleCASIssuer.
+func (in *GoogleCASIssuer) DeepCopy() *GoogleCASIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(GoogleCASIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressCertificateRollout) DeepCopyInto(out *IngressCertificateRollout) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressCertificateRollout.
+func (in *IngressCertificateRollout) DeepCopy() *IngressCertificateRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressCertificateRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IngressCertificateRollout) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressCertificateRolloutList) DeepCopyInto(out *IngressCertificateRolloutList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IngressCertificateRollout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressCertificateRolloutList.
+func (in *IngressCertificateRolloutList) DeepCopy() *IngressCertificateRolloutList {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressCertificateRolloutList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IngressCertificateRolloutList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressCertificateRolloutSpec) DeepCopyInto(out *IngressCertificateRolloutSpec) {
+	*out = *in
+	out.CertificateRequest = in.CertificateRequest
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressCertificateRolloutSpec.
+func (in *IngressCertificateRolloutSpec) DeepCopy() *IngressCertificateRolloutSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressCertificateRolloutSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressCertificateRolloutStatus) DeepCopyInto(out *IngressCertificateRolloutStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressCertificateRolloutStatus.
+func (in *IngressCertificateRolloutStatus) DeepCopy() *IngressCertificateRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressCertificateRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSKey) DeepCopyInto(out *KMSKey) {
+	*out = *in
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(AWSKMSKey)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSPlatformSecrets.
-func (in *AWSPlatformSecrets) DeepCopy() *AWSPlatformSecrets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSKey.
+func (in *KMSKey) DeepCopy() *KMSKey {
 	if in == nil {
 		return nil
 	}
-	out := new(AWSPlatformSecrets)
+	out := new(KMSKey)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AzurePlatformSecrets) DeepCopyInto(out *AzurePlatformSecrets) {
+func (in *KeystoreOptions) DeepCopyInto(out *KeystoreOptions) {
 	*out = *in
-	out.Credentials = in.Credentials
+	out.PasswordSecretRef = in.PasswordSecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzurePlatformSecrets.
-func (in *AzurePlatformSecrets) DeepCopy() *AzurePlatformSecrets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeystoreOptions.
+func (in *KeystoreOptions) DeepCopy() *KeystoreOptions {
 	if in == nil {
 		return nil
 	}
-	out := new(AzurePlatformSecrets)
+	out := new(KeystoreOptions)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CertificateRequest) DeepCopyInto(out *CertificateRequest) {
+func (in *ManagedClusterCertificateConfig) DeepCopyInto(out *ManagedClusterCertificateConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -49,18 +1252,18 @@ func (in *CertificateRequest) DeepCopyInto(out *CertificateRequest) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequest.
-func (in *CertificateRequest) DeepCopy() *CertificateRequest {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterCertificateConfig.
+func (in *ManagedClusterCertificateConfig) DeepCopy() *ManagedClusterCertificateConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CertificateRequest)
+	out := new(ManagedClusterCertificateConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CertificateRequest) DeepCopyObject() runtime.Object {
+func (in *ManagedClusterCertificateConfig) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -68,64 +1271,31 @@ func (in *CertificateRequest) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CertificateRequestCondition) DeepCopyInto(out *CertificateRequestCondition) {
-	*out = *in
-	if in.LastProbeTime != nil {
-		in, out := &in.LastProbeTime, &out.LastProbeTime
-		*out = (*in).DeepCopy()
-	}
-	if in.LastTransitionTime != nil {
-		in, out := &in.LastTransitionTime, &out.LastTransitionTime
-		*out = (*in).DeepCopy()
-	}
-	if in.Reason != nil {
-		in, out := &in.Reason, &out.Reason
-		*out = new(string)
-		**out = **in
-	}
-	if in.Message != nil {
-		in, out := &in.Message, &out.Message
-		*out = new(string)
-		**out = **in
-	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestCondition.
-func (in *CertificateRequestCondition) DeepCopy() *CertificateRequestCondition {
-	if in == nil {
-		return nil
-	}
-	out := new(CertificateRequestCondition)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CertificateRequestList) DeepCopyInto(out *CertificateRequestList) {
+func (in *ManagedClusterCertificateConfigList) DeepCopyInto(out *ManagedClusterCertificateConfigList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CertificateRequest, len(*in))
+		*out = make([]ManagedClusterCertificateConfig, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestList.
-func (in *CertificateRequestList) DeepCopy() *CertificateRequestList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterCertificateConfigList.
+func (in *ManagedClusterCertificateConfigList) DeepCopy() *ManagedClusterCertificateConfigList {
 	if in == nil {
 		return nil
 	}
-	out := new(CertificateRequestList)
+	out := new(ManagedClusterCertificateConfigList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CertificateRequestList) DeepCopyObject() runtime.Object {
+func (in *ManagedClusterCertificateConfigList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -133,76 +1303,124 @@ func (in *CertificateRequestList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CertificateRequestSpec) DeepCopyInto(out *CertificateRequestSpec) {
+func (in *ManagedClusterCertificateConfigSpec) DeepCopyInto(out *ManagedClusterCertificateConfigSpec) {
 	*out = *in
-	out.CertificateSecret = in.CertificateSecret
 	in.Platform.DeepCopyInto(&out.Platform)
-	if in.DnsNames != nil {
-		in, out := &in.DnsNames, &out.DnsNames
+	if in.Emails != nil {
+		in, out := &in.Emails, &out.Emails
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestSpec.
-func (in *CertificateRequestSpec) DeepCopy() *CertificateRequestSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterCertificateConfigSpec.
+func (in *ManagedClusterCertificateConfigSpec) DeepCopy() *ManagedClusterCertificateConfigSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CertificateRequestSpec)
+	out := new(ManagedClusterCertificateConfigSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CertificateRequestStatus) DeepCopyInto(out *CertificateRequestStatus) {
+func (in *ManagedClusterCertificateConfigStatus) DeepCopyInto(out *ManagedClusterCertificateConfigStatus) {
 	*out = *in
+	if in.CertificateRequests != nil {
+		in, out := &in.CertificateRequests, &out.CertificateRequests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]CertificateRequestCondition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRequestStatus.
-func (in *CertificateRequestStatus) DeepCopy() *CertificateRequestStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedClusterCertificateConfigStatus.
+func (in *ManagedClusterCertificateConfigStatus) DeepCopy() *ManagedClusterCertificateConfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(CertificateRequestStatus)
+	out := new(ManagedClusterCertificateConfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GCPPlatformSecrets) DeepCopyInto(out *GCPPlatformSecrets) {
+func (in *MockPlatformSecrets) DeepCopyInto(out *MockPlatformSecrets) {
 	*out = *in
-	out.Credentials = in.Credentials
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCPPlatformSecrets.
-func (in *GCPPlatformSecrets) DeepCopy() *GCPPlatformSecrets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockPlatformSecrets.
+func (in *MockPlatformSecrets) DeepCopy() *MockPlatformSecrets {
 	if in == nil {
 		return nil
 	}
-	out := new(GCPPlatformSecrets)
+	out := new(MockPlatformSecrets)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MockPlatformSecrets) DeepCopyInto(out *MockPlatformSecrets) {
+func (in *NotificationsConfig) DeepCopyInto(out *NotificationsConfig) {
 	*out = *in
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookNotificationConfig)
+		**out = **in
+	}
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = new(EmailNotificationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PagerDuty != nil {
+		in, out := &in.PagerDuty, &out.PagerDuty
+		*out = new(PagerDutyNotificationConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceLogFailureThreshold != nil {
+		in, out := &in.ServiceLogFailureThreshold, &out.ServiceLogFailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MockPlatformSecrets.
-func (in *MockPlatformSecrets) DeepCopy() *MockPlatformSecrets {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationsConfig.
+func (in *NotificationsConfig) DeepCopy() *NotificationsConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(MockPlatformSecrets)
+	out := new(NotificationsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PagerDutyNotificationConfig) DeepCopyInto(out *PagerDutyNotificationConfig) {
+	*out = *in
+	if in.FailureDuration != nil {
+		in, out := &in.FailureDuration, &out.FailureDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ExpiryDays != nil {
+		in, out := &in.ExpiryDays, &out.ExpiryDays
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PagerDutyNotificationConfig.
+func (in *PagerDutyNotificationConfig) DeepCopy() *PagerDutyNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PagerDutyNotificationConfig)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -213,7 +1431,7 @@ func (in *Platform) DeepCopyInto(out *Platform) {
 	if in.AWS != nil {
 		in, out := &in.AWS, &out.AWS
 		*out = new(AWSPlatformSecrets)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.GCP != nil {
 		in, out := &in.GCP, &out.GCP
@@ -230,6 +1448,16 @@ func (in *Platform) DeepCopyInto(out *Platform) {
 		*out = new(MockPlatformSecrets)
 		**out = **in
 	}
+	if in.Cloudflare != nil {
+		in, out := &in.Cloudflare, &out.Cloudflare
+		*out = new(CloudflarePlatformSecrets)
+		**out = **in
+	}
+	if in.AcmeDNS != nil {
+		in, out := &in.AcmeDNS, &out.AcmeDNS
+		*out = new(AcmeDNSPlatformSecrets)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Platform.
@@ -241,3 +1469,232 @@ func (in *Platform) DeepCopy() *Platform {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitsConfig) DeepCopyInto(out *RateLimitsConfig) {
+	*out = *in
+	if in.MaxOrdersPerHour != nil {
+		in, out := &in.MaxOrdersPerHour, &out.MaxOrdersPerHour
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxOrdersPerWeek != nil {
+		in, out := &in.MaxOrdersPerWeek, &out.MaxOrdersPerWeek
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitsConfig.
+func (in *RateLimitsConfig) DeepCopy() *RateLimitsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RenewalConfig) DeepCopyInto(out *RenewalConfig) {
+	*out = *in
+	if in.CheckInterval != nil {
+		in, out := &in.CheckInterval, &out.CheckInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.JitterWindow != nil {
+		in, out := &in.JitterWindow, &out.JitterWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RenewalConfig.
+func (in *RenewalConfig) DeepCopy() *RenewalConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RenewalConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretSink) DeepCopyInto(out *SecretSink) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(VaultSecretSink)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AWSSecretsManager != nil {
+		in, out := &in.AWSSecretsManager, &out.AWSSecretsManager
+		*out = new(AWSSecretsManagerSink)
+		**out = **in
+	}
+	if in.GCPSecretManager != nil {
+		in, out := &in.GCPSecretManager, &out.GCPSecretManager
+		*out = new(GCPSecretManagerSink)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretSink.
+func (in *SecretSink) DeepCopy() *SecretSink {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretSinkStatus) DeepCopyInto(out *SecretSinkStatus) {
+	*out = *in
+	in.PushTime.DeepCopyInto(&out.PushTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretSinkStatus.
+func (in *SecretSinkStatus) DeepCopy() *SecretSinkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretSinkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SignedCertificateTimestamp) DeepCopyInto(out *SignedCertificateTimestamp) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SignedCertificateTimestamp.
+func (in *SignedCertificateTimestamp) DeepCopy() *SignedCertificateTimestamp {
+	if in == nil {
+		return nil
+	}
+	out := new(SignedCertificateTimestamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretSink) DeepCopyInto(out *VaultSecretSink) {
+	*out = *in
+	out.TokenSecretRef = in.TokenSecretRef
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretSink.
+func (in *VaultSecretSink) DeepCopy() *VaultSecretSink {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiCloud) DeepCopyInto(out *VenafiCloud) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiCloud.
+func (in *VenafiCloud) DeepCopy() *VenafiCloud {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiCloud)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiIssuer) DeepCopyInto(out *VenafiIssuer) {
+	*out = *in
+	if in.TPP != nil {
+		in, out := &in.TPP, &out.TPP
+		*out = new(VenafiTPP)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cloud != nil {
+		in, out := &in.Cloud, &out.Cloud
+		*out = new(VenafiCloud)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiIssuer.
+func (in *VenafiIssuer) DeepCopy() *VenafiIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VenafiTPP) DeepCopyInto(out *VenafiTPP) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+	if in.ServerCABundle != nil {
+		in, out := &in.ServerCABundle, &out.ServerCABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VenafiTPP.
+func (in *VenafiTPP) DeepCopy() *VenafiTPP {
+	if in == nil {
+		return nil
+	}
+	out := new(VenafiTPP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookNotificationConfig) DeepCopyInto(out *WebhookNotificationConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookNotificationConfig.
+func (in *WebhookNotificationConfig) DeepCopy() *WebhookNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookNotificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -96,6 +96,33 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestSpec(ref c
 							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.Platform"),
 						},
 					},
+					"dnsProvider": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DNSProvider answers the DNS-01 challenges of the certificate instead of Platform, for base domains hosted outside of the cloud the cluster runs on. Its credentials are read from the namespace of the CertificateRequest.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.Platform"),
+						},
+					},
+					"challengeType": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ChallengeType is the type of the ACME challenges proving control of the DNS names, dns-01 by default. http-01 challenges are answered by the challenge server of the operator, to which the DNS names must route /.well-known/acme-challenge/ on port 80, and cannot prove control of wildcard names.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"solvers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Solvers select the challenge type of some of the DNS names instead of ChallengeType, such as http-01 for a public apps domain and dns-01 for the rest. The first solver matching a DNS name applies.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.ChallengeSolver"),
+									},
+								},
+							},
+						},
+					},
 					"dnsNames": {
 						SchemaProps: spec.SchemaProps{
 							Description: "DNSNames is a list of subject alt names to be used on the Certificate.",
@@ -114,11 +141,25 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestSpec(ref c
 					"email": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Let's Encrypt will use this to contact you about expiring certificates, and issues related to your account.",
-							Default:     "",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"emails": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Emails are further contacts, such as shared mailboxes and team aliases, registered with the certificate authority alongside Email and sent the same notifications.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 					"renewBeforeDays": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Number of days before expiration to reissue certificate. NOTE: Keeping \"renew\" in JSON for backward-compatibility.",
@@ -126,6 +167,12 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestSpec(ref c
 							Format:      "int32",
 						},
 					},
+					"renewBefore": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RenewBefore is how long before expiry the certificate is reissued, for example \"1080h\". Takes precedence over renewBeforeDays and must be shorter than the certificate's lifetime.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
 					"apiURL": {
 						SchemaProps: spec.SchemaProps{
 							Description: "APIURL is the URL where the cluster's API can be accessed.",
@@ -140,12 +187,138 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestSpec(ref c
 							Format:      "",
 						},
 					},
+					"issuerRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IssuerRef is the reference to the cluster-scoped CertIssuer used to request the certificate. If unset, the operator's default Let's Encrypt account is used.",
+							Ref:         ref("k8s.io/api/core/v1.LocalObjectReference"),
+						},
+					},
+					"keyAlgorithm": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeyAlgorithm is the algorithm of the certificate's private key. Defaults to RSA.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"keyCurve": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeyCurve is the elliptic curve used when KeyAlgorithm is ECDSA. Defaults to P-256.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"keySize": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KeySize is the size in bits of the RSA private key. Defaults to the operator's configured default key size, or 2048.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"rotationPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "RotationPolicy controls whether a renewal generates a new private key (Always) or reuses the key stored in the certificate secret (Never). Defaults to Always.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"kms": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KMS generates the private key in a cloud key management service, which also signs the certificate signing request, so the private key is never stored in the certificate secret. The secret holds the key identifier in kms.key-id instead of tls.key. Cannot be combined with combinedPEM or keystores.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.KMSKey"),
+						},
+					},
+					"mustStaple": {
+						SchemaProps: spec.SchemaProps{
+							Description: "MustStaple requests the OCSP must-staple TLS feature extension in the certificate.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Duration is the requested lifetime of the certificate, for example \"168h\". Only certificate authorities that honor notAfter on new orders issue certificates with the requested lifetime.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+					"combinedPEM": {
+						SchemaProps: spec.SchemaProps{
+							Description: "CombinedPEM adds a tls-combined.pem key holding the private key followed by the certificate chain to the certificate secret.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"keystores": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keystores configures keystores written to the certificate secret in addition to the PEM encoded certificate.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateKeystores"),
+						},
+					},
+					"secretTemplate": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretTemplate holds labels and annotations applied to the certificate secret.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateSecretTemplate"),
+						},
+					},
+					"secretReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretReplicas lists additional secrets kept in sync with the certificate secret. If the namespace of a replica is unset, the namespace of the CertificateRequest is used.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/api/core/v1.SecretReference"),
+									},
+								},
+							},
+						},
+					},
+					"importedSecret": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImportedSecret references a secret in the namespace of the CertificateRequest holding a customer-provided certificate chain and private key in its tls.crt and tls.key keys, and optionally the CA to verify the chain against in its ca.crt key. No certificate is ordered: the imported certificate is validated, copied into the certificate secret and reported on like an issued one, and a warning is raised instead of renewing it.",
+							Ref:         ref("k8s.io/api/core/v1.LocalObjectReference"),
+						},
+					},
+					"azureKeyVault": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AzureKeyVault uploads the certificate and its private key as a PKCS#12 bundle to an Azure Key Vault whenever the certificate is issued or renewed, for services such as Application Gateway that only read certificates from Key Vault. Cannot be combined with kms.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.AzureKeyVault"),
+						},
+					},
+					"secretSinks": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretSinks lists external secret stores the certificate chain, private key and issuing chain of the certificate secret are pushed to whenever the certificate is issued or renewed, for consumers outside the cluster. Cannot be combined with kms.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.SecretSink"),
+									},
+								},
+							},
+						},
+					},
+					"syncSet": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SyncSet delivers the certificate secret to the cluster of the owning ClusterDeployment through a Hive SyncSet, which also configures the API server or an ingress controller of the cluster to serve it.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateSyncSet"),
+						},
+					},
 				},
-				Required: []string{"acmeDNSDomain", "certificateSecret", "platform", "dnsNames", "email"},
+				Required: []string{"acmeDNSDomain", "certificateSecret", "platform", "dnsNames"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/openshift/certman-operator/api/v1alpha1.Platform", "k8s.io/api/core/v1.ObjectReference"},
+			"github.com/openshift/certman-operator/api/v1alpha1.AzureKeyVault", "github.com/openshift/certman-operator/api/v1alpha1.CertificateKeystores", "github.com/openshift/certman-operator/api/v1alpha1.CertificateSecretTemplate", "github.com/openshift/certman-operator/api/v1alpha1.CertificateSyncSet", "github.com/openshift/certman-operator/api/v1alpha1.ChallengeSolver", "github.com/openshift/certman-operator/api/v1alpha1.KMSKey", "github.com/openshift/certman-operator/api/v1alpha1.Platform", "github.com/openshift/certman-operator/api/v1alpha1.SecretSink", "k8s.io/api/core/v1.LocalObjectReference", "k8s.io/api/core/v1.ObjectReference", "k8s.io/api/core/v1.SecretReference", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
@@ -158,18 +331,45 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestStatus(ref
 				Properties: map[string]spec.Schema{
 					"issued": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Issued is true once certificates have been issued.",
+							Description: "Issued is true once certificates have been issued. Deprecated: use the Ready condition.",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
 					},
 					"status": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Status",
+							Description: "Status is Success once certificates have been issued and Error if the last attempt failed. Deprecated: use the Ready condition.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ObservedGeneration is the generation of the spec the status was last updated for.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"failedAttempts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailedAttempts counts the consecutive failed attempts to issue the certificate.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"failureCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FailureCount counts the failed attempts to issue the certificate since it was last issued. Unlike FailedAttempts, it is not reset by a change of the spec or the retry annotation.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"lastFailure": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastFailure describes the last failed attempt to issue the certificate. It is cleared once the certificate is issued.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateFailure"),
+						},
+					},
 					"notAfter": {
 						SchemaProps: spec.SchemaProps{
 							Description: "The expiration time of the certificate stored in the secret named by this resource in spec.secretName.",
@@ -198,7 +398,53 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestStatus(ref
 							Format:      "",
 						},
 					},
+					"fingerprint": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The SHA-256 fingerprint of the certificate stored in the secret named by this resource in spec.secretName.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dnsNames": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The DNS names of the certificate stored in the secret named by this resource in spec.secretName.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"renewalTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The time at which the certificate stored in the secret named by this resource in spec.secretName will be reissued.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The lifetime, from notBefore to notAfter, of the certificate stored in the secret named by this resource in spec.secretName.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"conditions": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"type",
+								},
+								"x-kubernetes-list-type":       "map",
+								"x-kubernetes-patch-merge-key": "type",
+								"x-kubernetes-patch-strategy":  "merge",
+							},
+						},
 						SchemaProps: spec.SchemaProps{
 							Description: "Conditions includes more detailed status for the Certificate Request",
 							Type:        []string{"array"},
@@ -206,7 +452,76 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestStatus(ref
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
 										Default: map[string]interface{}{},
-										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateRequestCondition"),
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"signedCertificateTimestamps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SignedCertificateTimestamps lists the Certificate Transparency SCTs embedded in the certificate stored in the secret named by this resource in spec.secretName.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.SignedCertificateTimestamp"),
+									},
+								},
+							},
+						},
+					},
+					"history": {
+						SchemaProps: spec.SchemaProps{
+							Description: "History records the last issuances, renewals and revocations of the certificate, oldest first. It is append-only and bounded to the last 20 entries.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.CertificateHistoryEntry"),
+									},
+								},
+							},
+						},
+					},
+					"acmeDNSZone": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ACMEDNSZone is the zone of the DNS provider the DNS-01 challenges are answered in: the closest zone enclosing spec.acmeDNSDomain, such as the parent zone a base domain is delegated from.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"azureKeyVault": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AzureKeyVault reports the last upload of the certificate to the Azure Key Vault of spec.azureKeyVault.",
+							Ref:         ref("github.com/openshift/certman-operator/api/v1alpha1.AzureKeyVaultStatus"),
+						},
+					},
+					"pagerDutyIncident": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PagerDutyIncident is when the PagerDuty incident escalating the certificate was opened. It is cleared once the incident is resolved.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"secretSinks": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "SecretSinks reports the last push of the certificate to each sink of spec.secretSinks.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/certman-operator/api/v1alpha1.SecretSinkStatus"),
 									},
 								},
 							},
@@ -216,6 +531,6 @@ func schema_openshift_certman_operator_api_v1alpha1_CertificateRequestStatus(ref
 			},
 		},
 		Dependencies: []string{
-			"github.com/openshift/certman-operator/api/v1alpha1.CertificateRequestCondition"},
+			"github.com/openshift/certman-operator/api/v1alpha1.AzureKeyVaultStatus", "github.com/openshift/certman-operator/api/v1alpha1.CertificateFailure", "github.com/openshift/certman-operator/api/v1alpha1.CertificateHistoryEntry", "github.com/openshift/certman-operator/api/v1alpha1.SecretSinkStatus", "github.com/openshift/certman-operator/api/v1alpha1.SignedCertificateTimestamp", "k8s.io/apimachinery/pkg/apis/meta/v1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
@@ -0,0 +1,440 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// ConversionDataAnnotation holds the fields of a v1alpha1 CertificateRequest that v1beta1 dropped, so that a
+// CertificateRequest read and written back in v1beta1 keeps them.
+const ConversionDataAnnotation = "certman.managed.openshift.io/v1alpha1-conversion-data"
+
+// conversionData holds the v1alpha1 fields without a v1beta1 counterpart.
+type conversionData struct {
+	RenewBeforeDays int `json:"renewBeforeDays,omitempty"`
+	// RenewBeforeFromDays is set when spec.renewBefore was computed from renewBeforeDays rather than set in v1alpha1.
+	RenewBeforeFromDays bool   `json:"renewBeforeFromDays,omitempty"`
+	Issued              bool   `json:"issued,omitempty"`
+	Status              string `json:"status,omitempty"`
+}
+
+var _ conversion.Convertible = &CertificateRequest{}
+
+// ConvertTo converts the CertificateRequest to the v1alpha1 hub.
+func (src *CertificateRequest) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha1.CertificateRequest)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	data := conversionData{}
+	if raw, ok := dst.Annotations[ConversionDataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return fmt.Errorf("invalid %s annotation: %w", ConversionDataAnnotation, err)
+		}
+		delete(dst.Annotations, ConversionDataAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	spec := &src.Spec
+	dst.Spec = v1alpha1.CertificateRequestSpec{
+		ACMEDNSDomain:     spec.ACMEDNSDomain,
+		CertificateSecret: spec.CertificateSecret,
+		Platform:          platformToV1alpha1(spec.Platform),
+		ChallengeType:     v1alpha1.ChallengeType(spec.ChallengeType),
+		Solvers:           solversToV1alpha1(spec.Solvers),
+		DnsNames:          spec.DNSNames,
+		ReissueBeforeDays: data.RenewBeforeDays,
+		RenewBefore:       spec.RenewBefore,
+		APIURL:            spec.APIURL,
+		WebConsoleURL:     spec.WebConsoleURL,
+		MustStaple:        spec.MustStaple,
+		Duration:          spec.Duration,
+		CombinedPEM:       spec.CombinedPEM,
+		Keystores:         keystoresToV1alpha1(spec.Keystores),
+		SecretTemplate:    (*v1alpha1.CertificateSecretTemplate)(spec.SecretTemplate),
+		SecretReplicas:    spec.SecretReplicas,
+		ImportedSecret:    spec.ImportedSecret,
+		AzureKeyVault:     (*v1alpha1.AzureKeyVault)(spec.AzureKeyVault),
+		SecretSinks:       secretSinksToV1alpha1(spec.SecretSinks),
+		SyncSet:           syncSetToV1alpha1(spec.SyncSet),
+	}
+	if len(spec.Emails) > 0 {
+		dst.Spec.Email = spec.Emails[0]
+		dst.Spec.Emails = spec.Emails[1:]
+		if len(dst.Spec.Emails) == 0 {
+			dst.Spec.Emails = nil
+		}
+	}
+	// drop the renewBefore computed from renewBeforeDays, unless it was changed since
+	if data.RenewBeforeFromDays && spec.RenewBefore != nil && spec.RenewBefore.Duration == daysToDuration(data.RenewBeforeDays) {
+		dst.Spec.RenewBefore = nil
+	}
+	if spec.IssuerRef != nil {
+		dst.Spec.IssuerRef = &corev1.LocalObjectReference{Name: spec.IssuerRef.Name}
+	}
+	if spec.DNSProvider != nil {
+		dnsProvider := platformToV1alpha1(*spec.DNSProvider)
+		dst.Spec.DNSProvider = &dnsProvider
+	}
+	if spec.KeySpec != nil {
+		dst.Spec.KeyAlgorithm = v1alpha1.KeyAlgorithm(spec.KeySpec.Algorithm)
+		dst.Spec.KeyCurve = v1alpha1.KeyCurve(spec.KeySpec.Curve)
+		dst.Spec.KeySize = spec.KeySpec.Size
+		dst.Spec.RotationPolicy = v1alpha1.PrivateKeyRotationPolicy(spec.KeySpec.RotationPolicy)
+		dst.Spec.KMS = kmsToV1alpha1(spec.KeySpec.KMS)
+	}
+
+	status := &src.Status
+	dst.Status = v1alpha1.CertificateRequestStatus{
+		Issued:             data.Issued,
+		Status:             data.Status,
+		ObservedGeneration: status.ObservedGeneration,
+		FailedAttempts:     status.FailedAttempts,
+		FailureCount:       status.FailureCount,
+		LastFailure:        (*v1alpha1.CertificateFailure)(status.LastFailure),
+		NotAfter:           status.NotAfter,
+		NotBefore:          status.NotBefore,
+		IssuerName:         status.IssuerName,
+		SerialNumber:       status.SerialNumber,
+		Fingerprint:        status.Fingerprint,
+		DNSNames:           status.DNSNames,
+		RenewalTime:        status.RenewalTime,
+		Duration:           status.Duration,
+		Conditions:         status.Conditions,
+		ACMEDNSZone:        status.ACMEDNSZone,
+		AzureKeyVault:      (*v1alpha1.AzureKeyVaultStatus)(status.AzureKeyVault),
+		PagerDutyIncident:  status.PagerDutyIncident,
+	}
+	for _, sct := range status.SignedCertificateTimestamps {
+		dst.Status.SignedCertificateTimestamps = append(dst.Status.SignedCertificateTimestamps, v1alpha1.SignedCertificateTimestamp(sct))
+	}
+	for _, sink := range status.SecretSinks {
+		dst.Status.SecretSinks = append(dst.Status.SecretSinks, v1alpha1.SecretSinkStatus(sink))
+	}
+	for _, entry := range status.History {
+		dst.Status.History = append(dst.Status.History, v1alpha1.CertificateHistoryEntry{
+			Action:           v1alpha1.CertificateAction(entry.Action),
+			Time:             entry.Time,
+			Trigger:          entry.Trigger,
+			Actor:            entry.Actor,
+			OrderURL:         entry.OrderURL,
+			SerialNumber:     entry.SerialNumber,
+			NotBefore:        entry.NotBefore,
+			NotAfter:         entry.NotAfter,
+			RevocationReason: entry.RevocationReason,
+		})
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub to the CertificateRequest. The v1alpha1 fields without a v1beta1 counterpart
+// are kept in the ConversionDataAnnotation.
+func (dst *CertificateRequest) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha1.CertificateRequest)
+	src.ObjectMeta.DeepCopyInto(&dst.ObjectMeta)
+
+	data := conversionData{
+		RenewBeforeDays: src.Spec.ReissueBeforeDays,
+		Issued:          src.Status.Issued,
+		Status:          src.Status.Status,
+	}
+
+	spec := &src.Spec
+	dst.Spec = CertificateRequestSpec{
+		ACMEDNSDomain:     spec.ACMEDNSDomain,
+		CertificateSecret: spec.CertificateSecret,
+		Platform:          platformFromV1alpha1(spec.Platform),
+		ChallengeType:     ChallengeType(spec.ChallengeType),
+		Solvers:           solversFromV1alpha1(spec.Solvers),
+		DNSNames:          spec.DnsNames,
+		Emails:            emailsFromV1alpha1(spec.Email, spec.Emails),
+		RenewBefore:       spec.RenewBefore,
+		APIURL:            spec.APIURL,
+		WebConsoleURL:     spec.WebConsoleURL,
+		MustStaple:        spec.MustStaple,
+		Duration:          spec.Duration,
+		CombinedPEM:       spec.CombinedPEM,
+		Keystores:         keystoresFromV1alpha1(spec.Keystores),
+		SecretTemplate:    (*CertificateSecretTemplate)(spec.SecretTemplate),
+		SecretReplicas:    spec.SecretReplicas,
+		ImportedSecret:    spec.ImportedSecret,
+		AzureKeyVault:     (*AzureKeyVault)(spec.AzureKeyVault),
+		SecretSinks:       secretSinksFromV1alpha1(spec.SecretSinks),
+		SyncSet:           syncSetFromV1alpha1(spec.SyncSet),
+	}
+	if spec.RenewBefore == nil && spec.ReissueBeforeDays > 0 {
+		dst.Spec.RenewBefore = &metav1.Duration{Duration: daysToDuration(spec.ReissueBeforeDays)}
+		data.RenewBeforeFromDays = true
+	}
+	if spec.IssuerRef != nil {
+		dst.Spec.IssuerRef = &IssuerReference{Kind: CertIssuerKind, Name: spec.IssuerRef.Name}
+	}
+	if spec.DNSProvider != nil {
+		dnsProvider := platformFromV1alpha1(*spec.DNSProvider)
+		dst.Spec.DNSProvider = &dnsProvider
+	}
+	if spec.KeyAlgorithm != "" || spec.KeyCurve != "" || spec.KeySize != 0 || spec.RotationPolicy != "" || spec.KMS != nil {
+		dst.Spec.KeySpec = &KeySpec{
+			Algorithm:      KeyAlgorithm(spec.KeyAlgorithm),
+			Curve:          KeyCurve(spec.KeyCurve),
+			Size:           spec.KeySize,
+			RotationPolicy: PrivateKeyRotationPolicy(spec.RotationPolicy),
+			KMS:            kmsFromV1alpha1(spec.KMS),
+		}
+	}
+
+	status := &src.Status
+	dst.Status = CertificateRequestStatus{
+		Conditions:         status.Conditions,
+		ObservedGeneration: status.ObservedGeneration,
+		FailedAttempts:     status.FailedAttempts,
+		FailureCount:       status.FailureCount,
+		LastFailure:        (*CertificateFailure)(status.LastFailure),
+		NotAfter:           status.NotAfter,
+		NotBefore:          status.NotBefore,
+		IssuerName:         status.IssuerName,
+		SerialNumber:       status.SerialNumber,
+		Fingerprint:        status.Fingerprint,
+		DNSNames:           status.DNSNames,
+		RenewalTime:        status.RenewalTime,
+		Duration:           status.Duration,
+		ACMEDNSZone:        status.ACMEDNSZone,
+		AzureKeyVault:      (*AzureKeyVaultStatus)(status.AzureKeyVault),
+		PagerDutyIncident:  status.PagerDutyIncident,
+	}
+	for _, sct := range status.SignedCertificateTimestamps {
+		dst.Status.SignedCertificateTimestamps = append(dst.Status.SignedCertificateTimestamps, SignedCertificateTimestamp(sct))
+	}
+	for _, sink := range status.SecretSinks {
+		dst.Status.SecretSinks = append(dst.Status.SecretSinks, SecretSinkStatus(sink))
+	}
+	for _, entry := range status.History {
+		dst.Status.History = append(dst.Status.History, CertificateHistoryEntry{
+			Action:           CertificateAction(entry.Action),
+			Time:             entry.Time,
+			Trigger:          entry.Trigger,
+			Actor:            entry.Actor,
+			OrderURL:         entry.OrderURL,
+			SerialNumber:     entry.SerialNumber,
+			NotBefore:        entry.NotBefore,
+			NotAfter:         entry.NotAfter,
+			RevocationReason: entry.RevocationReason,
+		})
+	}
+
+	if data != (conversionData{}) {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(raw)
+	}
+	return nil
+}
+
+// emailsFromV1alpha1 returns the primary email of a v1alpha1 CertificateRequest followed by its further emails.
+func emailsFromV1alpha1(email string, emails []string) []string {
+	if email == "" {
+		return emails
+	}
+	return append([]string{email}, emails...)
+}
+
+func daysToDuration(days int) time.Duration {
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func platformToV1alpha1(platform Platform) v1alpha1.Platform {
+	return v1alpha1.Platform{
+		AWS:        (*v1alpha1.AWSPlatformSecrets)(platform.AWS),
+		GCP:        (*v1alpha1.GCPPlatformSecrets)(platform.GCP),
+		Azure:      (*v1alpha1.AzurePlatformSecrets)(platform.Azure),
+		Mock:       (*v1alpha1.MockPlatformSecrets)(platform.Mock),
+		Cloudflare: (*v1alpha1.CloudflarePlatformSecrets)(platform.Cloudflare),
+		AcmeDNS:    (*v1alpha1.AcmeDNSPlatformSecrets)(platform.AcmeDNS),
+	}
+}
+
+func platformFromV1alpha1(platform v1alpha1.Platform) Platform {
+	return Platform{
+		AWS:        (*AWSPlatformSecrets)(platform.AWS),
+		GCP:        (*GCPPlatformSecrets)(platform.GCP),
+		Azure:      (*AzurePlatformSecrets)(platform.Azure),
+		Mock:       (*MockPlatformSecrets)(platform.Mock),
+		Cloudflare: (*CloudflarePlatformSecrets)(platform.Cloudflare),
+		AcmeDNS:    (*AcmeDNSPlatformSecrets)(platform.AcmeDNS),
+	}
+}
+
+func solversToV1alpha1(solvers []ChallengeSolver) []v1alpha1.ChallengeSolver {
+	if solvers == nil {
+		return nil
+	}
+	converted := make([]v1alpha1.ChallengeSolver, 0, len(solvers))
+	for _, solver := range solvers {
+		converted = append(converted, v1alpha1.ChallengeSolver{
+			DNSNames:      solver.DNSNames,
+			DNSZones:      solver.DNSZones,
+			ChallengeType: v1alpha1.ChallengeType(solver.ChallengeType),
+		})
+	}
+	return converted
+}
+
+func solversFromV1alpha1(solvers []v1alpha1.ChallengeSolver) []ChallengeSolver {
+	if solvers == nil {
+		return nil
+	}
+	converted := make([]ChallengeSolver, 0, len(solvers))
+	for _, solver := range solvers {
+		converted = append(converted, ChallengeSolver{
+			DNSNames:      solver.DNSNames,
+			DNSZones:      solver.DNSZones,
+			ChallengeType: ChallengeType(solver.ChallengeType),
+		})
+	}
+	return converted
+}
+
+func keystoresToV1alpha1(keystores *CertificateKeystores) *v1alpha1.CertificateKeystores {
+	if keystores == nil {
+		return nil
+	}
+	return &v1alpha1.CertificateKeystores{
+		PKCS12: keystoreOptionsToV1alpha1(keystores.PKCS12),
+		JKS:    keystoreOptionsToV1alpha1(keystores.JKS),
+	}
+}
+
+func keystoresFromV1alpha1(keystores *v1alpha1.CertificateKeystores) *CertificateKeystores {
+	if keystores == nil {
+		return nil
+	}
+	return &CertificateKeystores{
+		PKCS12: keystoreOptionsFromV1alpha1(keystores.PKCS12),
+		JKS:    keystoreOptionsFromV1alpha1(keystores.JKS),
+	}
+}
+
+func keystoreOptionsToV1alpha1(options *KeystoreOptions) *v1alpha1.KeystoreOptions {
+	if options == nil {
+		return nil
+	}
+	return &v1alpha1.KeystoreOptions{PasswordSecretRef: v1alpha1.SecretKeyReference(options.PasswordSecretRef)}
+}
+
+func keystoreOptionsFromV1alpha1(options *v1alpha1.KeystoreOptions) *KeystoreOptions {
+	if options == nil {
+		return nil
+	}
+	return &KeystoreOptions{PasswordSecretRef: SecretKeyReference(options.PasswordSecretRef)}
+}
+
+func kmsToV1alpha1(kms *KMSKey) *v1alpha1.KMSKey {
+	if kms == nil {
+		return nil
+	}
+	return &v1alpha1.KMSKey{AWS: (*v1alpha1.AWSKMSKey)(kms.AWS)}
+}
+
+func kmsFromV1alpha1(kms *v1alpha1.KMSKey) *KMSKey {
+	if kms == nil {
+		return nil
+	}
+	return &KMSKey{AWS: (*AWSKMSKey)(kms.AWS)}
+}
+
+func secretSinksToV1alpha1(sinks []SecretSink) []v1alpha1.SecretSink {
+	var converted []v1alpha1.SecretSink
+	for _, sink := range sinks {
+		c := v1alpha1.SecretSink{
+			Name:              sink.Name,
+			AWSSecretsManager: (*v1alpha1.AWSSecretsManagerSink)(sink.AWSSecretsManager),
+			GCPSecretManager:  (*v1alpha1.GCPSecretManagerSink)(sink.GCPSecretManager),
+		}
+		if sink.Vault != nil {
+			c.Vault = &v1alpha1.VaultSecretSink{
+				Server:         sink.Vault.Server,
+				Namespace:      sink.Vault.Namespace,
+				MountPath:      sink.Vault.MountPath,
+				Path:           sink.Vault.Path,
+				TokenSecretRef: v1alpha1.SecretKeyReference(sink.Vault.TokenSecretRef),
+				CABundle:       sink.Vault.CABundle,
+			}
+		}
+		converted = append(converted, c)
+	}
+	return converted
+}
+
+func secretSinksFromV1alpha1(sinks []v1alpha1.SecretSink) []SecretSink {
+	var converted []SecretSink
+	for _, sink := range sinks {
+		c := SecretSink{
+			Name:              sink.Name,
+			AWSSecretsManager: (*AWSSecretsManagerSink)(sink.AWSSecretsManager),
+			GCPSecretManager:  (*GCPSecretManagerSink)(sink.GCPSecretManager),
+		}
+		if sink.Vault != nil {
+			c.Vault = &VaultSecretSink{
+				Server:         sink.Vault.Server,
+				Namespace:      sink.Vault.Namespace,
+				MountPath:      sink.Vault.MountPath,
+				Path:           sink.Vault.Path,
+				TokenSecretRef: SecretKeyReference(sink.Vault.TokenSecretRef),
+				CABundle:       sink.Vault.CABundle,
+			}
+		}
+		converted = append(converted, c)
+	}
+	return converted
+}
+
+func syncSetToV1alpha1(syncSet *CertificateSyncSet) *v1alpha1.CertificateSyncSet {
+	if syncSet == nil {
+		return nil
+	}
+	return &v1alpha1.CertificateSyncSet{
+		Name:              syncSet.Name,
+		Target:            v1alpha1.SyncSetTarget(syncSet.Target),
+		IngressController: syncSet.IngressController,
+	}
+}
+
+func syncSetFromV1alpha1(syncSet *v1alpha1.CertificateSyncSet) *CertificateSyncSet {
+	if syncSet == nil {
+		return nil
+	}
+	return &CertificateSyncSet{
+		Name:              syncSet.Name,
+		Target:            SyncSetTarget(syncSet.Target),
+		IngressController: syncSet.IngressController,
+	}
+}
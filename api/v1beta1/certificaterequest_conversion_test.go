@@ -0,0 +1,259 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+var testTime = metav1.NewTime(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+
+func testV1alpha1CertificateRequest() *v1alpha1.CertificateRequest {
+	return &v1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-cluster-primary-cert-bundle",
+			Namespace:   "uhc-production-1234",
+			Labels:      map[string]string{"certificate_request": "true"},
+			Annotations: map[string]string{"example.com/owner": "sre"},
+		},
+		Spec: v1alpha1.CertificateRequestSpec{
+			ACMEDNSDomain:     "example.com",
+			CertificateSecret: corev1.ObjectReference{Name: "primary-cert-bundle-secret"},
+			Platform: v1alpha1.Platform{
+				AWS: &v1alpha1.AWSPlatformSecrets{
+					Credentials: corev1.LocalObjectReference{Name: "aws"},
+					Region:      "us-east-1",
+				},
+			},
+			DNSProvider: &v1alpha1.Platform{
+				Cloudflare: &v1alpha1.CloudflarePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "cloudflare"}},
+			},
+			ChallengeType: v1alpha1.DNS01ChallengeType,
+			Solvers: []v1alpha1.ChallengeSolver{
+				{DNSZones: []string{"apps.test-cluster.example.com"}, ChallengeType: v1alpha1.HTTP01ChallengeType},
+			},
+			DnsNames:       []string{"api.test-cluster.example.com", "*.apps.test-cluster.example.com"},
+			Email:          "sre@example.com",
+			Emails:         []string{"team@example.com"},
+			APIURL:         "https://api.test-cluster.example.com:6443",
+			IssuerRef:      &corev1.LocalObjectReference{Name: "staging"},
+			KeyAlgorithm:   v1alpha1.ECDSAKeyAlgorithm,
+			KeyCurve:       v1alpha1.P384KeyCurve,
+			RotationPolicy: v1alpha1.NeverRotationPolicy,
+			KMS: &v1alpha1.KMSKey{AWS: &v1alpha1.AWSKMSKey{
+				Credentials: corev1.LocalObjectReference{Name: "kms"},
+				Region:      "us-east-1",
+			}},
+			Duration: &metav1.Duration{Duration: 168 * time.Hour},
+			Keystores: &v1alpha1.CertificateKeystores{
+				PKCS12: &v1alpha1.KeystoreOptions{PasswordSecretRef: v1alpha1.SecretKeyReference{Name: "keystore", Key: "password"}},
+			},
+			SecretTemplate: &v1alpha1.CertificateSecretTemplate{Labels: map[string]string{"team": "sre"}},
+			SecretReplicas: []corev1.SecretReference{{Name: "replica", Namespace: "openshift-ingress"}},
+			ImportedSecret: &corev1.LocalObjectReference{Name: "customer-certificate"},
+			AzureKeyVault:  &v1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net", CertificateName: "api"},
+			SecretSinks: []v1alpha1.SecretSink{
+				{Name: "vault", Vault: &v1alpha1.VaultSecretSink{
+					Server:         "https://vault.example.com:8200",
+					Path:           "certs/api",
+					TokenSecretRef: v1alpha1.SecretKeyReference{Name: "vault", Key: "token"},
+				}},
+				{Name: "aws", AWSSecretsManager: &v1alpha1.AWSSecretsManagerSink{
+					Credentials: corev1.LocalObjectReference{Name: "aws"},
+					Region:      "us-east-1",
+					SecretName:  "api",
+				}},
+			},
+			SyncSet: &v1alpha1.CertificateSyncSet{Target: v1alpha1.SyncSetTargetIngress, IngressController: "default"},
+		},
+		Status: v1alpha1.CertificateRequestStatus{
+			ObservedGeneration: 2,
+			FailureCount:       1,
+			LastFailure:        &v1alpha1.CertificateFailure{Reason: "AcmeError", Message: "rate limited", Time: testTime, Since: &testTime},
+			NotAfter:           "2024-06-08 12:00:00 +0000 UTC",
+			IssuerName:         "R3",
+			SerialNumber:       "1234",
+			DNSNames:           []string{"api.test-cluster.example.com"},
+			Conditions: []metav1.Condition{{
+				Type:               string(v1alpha1.CertificateRequestReady),
+				Status:             metav1.ConditionTrue,
+				Reason:             "Issued",
+				LastTransitionTime: testTime,
+			}},
+			SignedCertificateTimestamps: []v1alpha1.SignedCertificateTimestamp{{LogID: "log", Timestamp: "2024-03-10"}},
+			History: []v1alpha1.CertificateHistoryEntry{{
+				Action:  v1alpha1.CertificateIssued,
+				Time:    testTime,
+				Trigger: "SecretNotFound",
+			}},
+			ACMEDNSZone: "example.com",
+			AzureKeyVault: &v1alpha1.AzureKeyVaultStatus{
+				CertificateID: "https://certs.vault.azure.net/certificates/api/1",
+				SerialNumber:  "1234",
+				UploadTime:    testTime,
+			},
+			PagerDutyIncident: &testTime,
+			SecretSinks:       []v1alpha1.SecretSinkStatus{{Name: "vault", Version: "3", SerialNumber: "1234", PushTime: testTime}},
+		},
+	}
+}
+
+func TestConvertFrom(t *testing.T) {
+	src := testV1alpha1CertificateRequest()
+	src.Spec.ReissueBeforeDays = 30
+	src.Status.Issued = true
+	src.Status.Status = "Success"
+
+	dst := &CertificateRequest{}
+	require.NoError(t, dst.ConvertFrom(src))
+
+	assert.Equal(t, src.Spec.DnsNames, dst.Spec.DNSNames)
+	assert.Equal(t, []string{"sre@example.com", "team@example.com"}, dst.Spec.Emails)
+	assert.Equal(t, &IssuerReference{Kind: CertIssuerKind, Name: "staging"}, dst.Spec.IssuerRef)
+	require.NotNil(t, dst.Spec.KeySpec)
+	assert.Equal(t, ECDSAKeyAlgorithm, dst.Spec.KeySpec.Algorithm)
+	assert.Equal(t, P384KeyCurve, dst.Spec.KeySpec.Curve)
+	assert.Equal(t, NeverRotationPolicy, dst.Spec.KeySpec.RotationPolicy)
+	assert.Equal(t, "kms", dst.Spec.KeySpec.KMS.AWS.Credentials.Name)
+	assert.Equal(t, &metav1.Duration{Duration: 30 * 24 * time.Hour}, dst.Spec.RenewBefore)
+	assert.Equal(t, src.Status.Conditions, dst.Status.Conditions)
+	assert.Equal(t, CertificateIssued, dst.Status.History[0].Action)
+	assert.JSONEq(t, `{"renewBeforeDays":30,"renewBeforeFromDays":true,"issued":true,"status":"Success"}`, dst.Annotations[ConversionDataAnnotation])
+
+	// the annotations of the source are not changed
+	assert.NotContains(t, src.Annotations, ConversionDataAnnotation)
+}
+
+func TestConvertFromWithoutDroppedFields(t *testing.T) {
+	src := testV1alpha1CertificateRequest()
+	src.Annotations = nil
+	src.Spec.IssuerRef = nil
+	src.Spec.KeyAlgorithm = ""
+	src.Spec.KeyCurve = ""
+	src.Spec.RotationPolicy = ""
+	src.Spec.KMS = nil
+
+	dst := &CertificateRequest{}
+	require.NoError(t, dst.ConvertFrom(src))
+
+	assert.Nil(t, dst.Annotations)
+	assert.Nil(t, dst.Spec.IssuerRef)
+	assert.Nil(t, dst.Spec.KeySpec)
+	assert.Nil(t, dst.Spec.RenewBefore)
+}
+
+func TestRoundTripFromV1alpha1(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cr *v1alpha1.CertificateRequest)
+	}{
+		{
+			name:   "all fields",
+			mutate: func(cr *v1alpha1.CertificateRequest) {},
+		},
+		{
+			name: "deprecated fields",
+			mutate: func(cr *v1alpha1.CertificateRequest) {
+				cr.Spec.ReissueBeforeDays = 30
+				cr.Status.Issued = true
+				cr.Status.Status = "Success"
+			},
+		},
+		{
+			name: "renewBefore and renewBeforeDays",
+			mutate: func(cr *v1alpha1.CertificateRequest) {
+				cr.Spec.ReissueBeforeDays = 30
+				cr.Spec.RenewBefore = &metav1.Duration{Duration: 720 * time.Hour}
+			},
+		},
+		{
+			name: "a single email",
+			mutate: func(cr *v1alpha1.CertificateRequest) {
+				cr.Spec.Emails = nil
+			},
+		},
+		{
+			name: "no annotations",
+			mutate: func(cr *v1alpha1.CertificateRequest) {
+				cr.Annotations = nil
+				cr.Spec.ReissueBeforeDays = 45
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := testV1alpha1CertificateRequest()
+			tt.mutate(original)
+
+			beta := &CertificateRequest{}
+			require.NoError(t, beta.ConvertFrom(original.DeepCopy()))
+			alpha := &v1alpha1.CertificateRequest{}
+			require.NoError(t, beta.ConvertTo(alpha))
+
+			assert.Equal(t, original, alpha)
+		})
+	}
+}
+
+func TestRoundTripFromV1beta1(t *testing.T) {
+	alpha := testV1alpha1CertificateRequest()
+	original := &CertificateRequest{}
+	require.NoError(t, original.ConvertFrom(alpha))
+	original.Spec.KeySpec.Size = 4096
+	original.Spec.RenewBefore = &metav1.Duration{Duration: 240 * time.Hour}
+
+	hub := &v1alpha1.CertificateRequest{}
+	require.NoError(t, original.DeepCopy().ConvertTo(hub))
+	assert.Equal(t, &metav1.Duration{Duration: 240 * time.Hour}, hub.Spec.RenewBefore)
+	assert.Equal(t, 4096, hub.Spec.KeySize)
+
+	beta := &CertificateRequest{}
+	require.NoError(t, beta.ConvertFrom(hub))
+	assert.Equal(t, original, beta)
+}
+
+func TestConvertToEditedRenewBefore(t *testing.T) {
+	alpha := testV1alpha1CertificateRequest()
+	alpha.Spec.ReissueBeforeDays = 30
+
+	beta := &CertificateRequest{}
+	require.NoError(t, beta.ConvertFrom(alpha))
+	beta.Spec.RenewBefore = &metav1.Duration{Duration: 240 * time.Hour}
+
+	hub := &v1alpha1.CertificateRequest{}
+	require.NoError(t, beta.ConvertTo(hub))
+	// renewBefore takes precedence over renewBeforeDays
+	assert.Equal(t, 30, hub.Spec.ReissueBeforeDays)
+	assert.Equal(t, &metav1.Duration{Duration: 240 * time.Hour}, hub.Spec.RenewBefore)
+	assert.NotContains(t, hub.Annotations, ConversionDataAnnotation)
+}
+
+func TestConvertToInvalidAnnotation(t *testing.T) {
+	beta := &CertificateRequest{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{ConversionDataAnnotation: "{"},
+	}}
+	assert.Error(t, beta.ConvertTo(&v1alpha1.CertificateRequest{}))
+}
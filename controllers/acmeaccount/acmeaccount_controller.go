@@ -0,0 +1,151 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmeaccount
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	// accountContactAnnotation records on an account secret the email address last set as the ACME account contact.
+	accountContactAnnotation = "certman.managed.openshift.io/account-contact"
+)
+
+var log = logf.Log.WithName("controller_acmeaccount")
+
+var _ reconcile.Reconciler = &ACMEAccountReconciler{}
+
+// ACMEAccountReconciler keeps the contact of every ACME account in sync with the default
+// notification email address in the operator ConfigMap.
+type ACMEAccountReconciler struct {
+	Client          client.Client
+	Scheme          *runtime.Scheme
+	LEClientBuilder func(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer) (leclient.LetsEncryptClientInterface, error)
+}
+
+// Reconcile reads the default notification email address and updates the contact of the default
+// Let's Encrypt account and of every CertIssuer account that does not already use it.
+func (r *ACMEAccountReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("reconciling ACME account contacts")
+
+	// Bound the reconcile, so that a stuck ACME account update cannot block the worker indefinitely
+	timeout, err := utils.GetReconcileTimeout(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	emailAddress, err := utils.GetDefaultNotificationEmailAddress(ctx, r.Client)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("operator configmap not found, skipping")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	// a nil issuer refers to the operator's default Let's Encrypt account
+	issuers := []*certmanv1alpha1.CertIssuer{nil}
+
+	issuerList := &certmanv1alpha1.CertIssuerList{}
+	if err := r.Client.List(ctx, issuerList); err != nil {
+		reqLogger.Error(err, "error listing CertIssuers")
+		return reconcile.Result{}, err
+	}
+	for i := range issuerList.Items {
+		// only ACME issuers have an account
+		if issuerList.Items[i].Spec.ACME != nil {
+			issuers = append(issuers, &issuerList.Items[i])
+		}
+	}
+
+	errs := []error{}
+	for _, issuer := range issuers {
+		if err := r.updateAccountContact(ctx, reqLogger, issuer, emailAddress); err != nil {
+			reqLogger.Error(err, "error updating ACME account contact", "Secret", leclient.AccountSecretName(issuer))
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return reconcile.Result{}, fmt.Errorf("failed to update %d ACME account contacts", len(errs))
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// updateAccountContact sets emailAddress as the contact of the account configured on issuer unless the
+// account secret records that it is already in use.
+func (r *ACMEAccountReconciler) updateAccountContact(ctx context.Context, reqLogger logr.Logger, issuer *certmanv1alpha1.CertIssuer, emailAddress string) error {
+	secretName := leclient.AccountSecretName(issuer)
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, secretName, secret); err != nil {
+		return err
+	}
+
+	if secret.Annotations[accountContactAnnotation] == emailAddress {
+		return nil
+	}
+
+	leClient, err := r.LEClientBuilder(ctx, r.Client, issuer)
+	if err != nil {
+		return err
+	}
+
+	reqLogger.Info("updating ACME account contact", "Secret", secretName)
+	if err := leClient.UpdateAccount(emailAddress); err != nil {
+		return err
+	}
+
+	baseToPatch := client.MergeFrom(secret.DeepCopy())
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[accountContactAnnotation] = emailAddress
+	return r.Client.Patch(ctx, secret, baseToPatch)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ACMEAccountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("acmeaccount").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertIssuer{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Complete(r)
+}
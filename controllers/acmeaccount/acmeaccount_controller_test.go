@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmeaccount
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	testEmailAddress     = "new@example.com"
+	testIssuerName       = "test-issuer"
+	testIssuerSecretName = "test-issuer-account"
+)
+
+func TestACMEAccountReconciler(t *testing.T) {
+	err := certmanv1alpha1.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.DefaultNotificationEmailAddress: testEmailAddress},
+	}
+	defaultAccountSecret := func(contact string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        leclient.AccountSecretName(nil).Name,
+				Namespace:   config.OperatorNamespace,
+				Annotations: map[string]string{accountContactAnnotation: contact},
+			},
+		}
+	}
+	issuer := &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: testIssuerName},
+		Spec: certmanv1alpha1.CertIssuerSpec{
+			ACME: &certmanv1alpha1.ACMEIssuer{AccountSecretRef: corev1.SecretReference{Name: testIssuerSecretName}},
+		},
+	}
+	issuerAccountSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testIssuerSecretName, Namespace: config.OperatorNamespace},
+	}
+
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		expectedUpdates []string
+		expectErr       bool
+	}{
+		{
+			name:            "no update without the operator configmap",
+			objects:         []runtime.Object{defaultAccountSecret("")},
+			expectedUpdates: nil,
+		},
+		{
+			name:            "updates the default account when the email changes",
+			objects:         []runtime.Object{configMap, defaultAccountSecret("old@example.com")},
+			expectedUpdates: []string{""},
+		},
+		{
+			name:            "skips accounts already using the email",
+			objects:         []runtime.Object{configMap, defaultAccountSecret(testEmailAddress)},
+			expectedUpdates: nil,
+		},
+		{
+			name:            "updates certissuer accounts",
+			objects:         []runtime.Object{configMap, defaultAccountSecret(testEmailAddress), issuer, issuerAccountSecret},
+			expectedUpdates: []string{testIssuerName},
+		},
+		{
+			name:      "errors when an account secret is missing",
+			objects:   []runtime.Object{configMap},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.objects...).Build()
+
+			var updates []string
+			r := &ACMEAccountReconciler{
+				Client: testClient,
+				LEClientBuilder: func(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer) (leclient.LetsEncryptClientInterface, error) {
+					name := ""
+					if issuer != nil {
+						name = issuer.Name
+					}
+					updates = append(updates, name)
+					return &leclient.LetsEncryptClient{
+						Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{Available: true}),
+					}, nil
+				},
+			}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace}})
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedUpdates, updates)
+
+			for _, name := range test.expectedUpdates {
+				secretName := leclient.AccountSecretName(nil)
+				if name != "" {
+					secretName = leclient.AccountSecretName(issuer)
+				}
+				secret := &corev1.Secret{}
+				require.NoError(t, testClient.Get(context.TODO(), secretName, secret))
+				assert.Equal(t, testEmailAddress, secret.Annotations[accountContactAnnotation])
+			}
+		})
+	}
+}
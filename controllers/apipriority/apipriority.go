@@ -0,0 +1,213 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apipriority
+
+import (
+	"context"
+	"time"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	// Name is the name of the FlowSchema and of the PriorityLevelConfiguration managed for the operator.
+	Name = "certman-operator"
+
+	// DefaultInterval is how often the FlowSchema and PriorityLevelConfiguration are reconciled, which reverts
+	// changes made to them by hand.
+	DefaultInterval = time.Hour
+
+	// DefaultNominalConcurrencyShares is a little more than the global-default priority level, which the requests of
+	// the operator share with every other client without a FlowSchema of its own.
+	DefaultNominalConcurrencyShares = 30
+
+	// matchingPrecedence matches the requests of the operator before the service-accounts and global-default
+	// FlowSchemas, and after those of the leader election and of the control plane.
+	matchingPrecedence = 1000
+)
+
+var log = logf.Log.WithName("apipriority")
+
+var _ manager.LeaderElectionRunnable = &Syncer{}
+
+// Syncer periodically creates or updates the PriorityLevelConfiguration of the operator and the FlowSchema that
+// assigns the requests of its service account to it, so that the API Priority and Fairness of the API server neither
+// throttles the operator behind the other workloads of a busy hub nor lets it starve them.
+type Syncer struct {
+	Client client.Client
+	// APIReader reads the FlowSchema and the PriorityLevelConfiguration, so that the operator does not cache those of
+	// the whole cluster.
+	APIReader client.Reader
+	// NominalConcurrencyShares is the share of the concurrency limit of the API server the operator is assured.
+	// Zero uses DefaultNominalConcurrencyShares.
+	NominalConcurrencyShares int32
+	// Interval is how often the FlowSchema and the PriorityLevelConfiguration are reconciled. Zero uses
+	// DefaultInterval.
+	Interval time.Duration
+}
+
+// Start syncs the FlowSchema and the PriorityLevelConfiguration every Interval until ctx is done.
+func (s *Syncer) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Sync(ctx); err != nil {
+			log.Error(err, "failed to sync the FlowSchema and PriorityLevelConfiguration")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection keeps replicas that are not the leader from updating the same objects.
+func (s *Syncer) NeedLeaderElection() bool {
+	return true
+}
+
+// Sync creates or updates the PriorityLevelConfiguration, then the FlowSchema referencing it. Both are skipped on
+// clusters without the flowcontrol.apiserver.k8s.io/v1 API.
+func (s *Syncer) Sync(ctx context.Context) error {
+	shares := s.NominalConcurrencyShares
+	if shares == 0 {
+		shares = DefaultNominalConcurrencyShares
+	}
+
+	if err := s.syncPriorityLevelConfiguration(ctx, shares); meta.IsNoMatchError(err) {
+		log.Info("API Priority and Fairness v1 is not served, skipping the FlowSchema and PriorityLevelConfiguration")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return s.syncFlowSchema(ctx)
+}
+
+func (s *Syncer) syncPriorityLevelConfiguration(ctx context.Context, shares int32) error {
+	desired := newPriorityLevelConfiguration(shares)
+
+	current := &flowcontrolv1.PriorityLevelConfiguration{}
+	err := s.APIReader.Get(ctx, types.NamespacedName{Name: Name}, current)
+	if errors.IsNotFound(err) {
+		log.Info("creating the PriorityLevelConfiguration", "Name", Name, "NominalConcurrencyShares", shares)
+		return s.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec, desired.Spec) && equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return nil
+	}
+
+	log.Info("updating the PriorityLevelConfiguration", "Name", Name, "NominalConcurrencyShares", shares)
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	return s.Client.Update(ctx, current)
+}
+
+func (s *Syncer) syncFlowSchema(ctx context.Context) error {
+	desired := newFlowSchema()
+
+	current := &flowcontrolv1.FlowSchema{}
+	err := s.APIReader.Get(ctx, types.NamespacedName{Name: Name}, current)
+	if errors.IsNotFound(err) {
+		log.Info("creating the FlowSchema", "Name", Name)
+		return s.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec, desired.Spec) && equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return nil
+	}
+
+	log.Info("updating the FlowSchema", "Name", Name)
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	return s.Client.Update(ctx, current)
+}
+
+// newPriorityLevelConfiguration returns the priority level of the operator, assured the given shares of the
+// concurrency limit. The requests beyond it are queued rather than rejected, as the reconciles retry them anyway.
+func newPriorityLevelConfiguration(shares int32) *flowcontrolv1.PriorityLevelConfiguration {
+	return &flowcontrolv1.PriorityLevelConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   Name,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": config.OperatorName},
+		},
+		Spec: flowcontrolv1.PriorityLevelConfigurationSpec{
+			Type: flowcontrolv1.PriorityLevelEnablementLimited,
+			Limited: &flowcontrolv1.LimitedPriorityLevelConfiguration{
+				NominalConcurrencyShares: ptr.To(shares),
+				// the API server defaults the share the operator lends to the other priority levels to none
+				LendablePercent: ptr.To[int32](0),
+				LimitResponse: flowcontrolv1.LimitResponse{
+					Type: flowcontrolv1.LimitResponseTypeQueue,
+					Queuing: &flowcontrolv1.QueuingConfiguration{
+						Queues:           64,
+						HandSize:         6,
+						QueueLengthLimit: 50,
+					},
+				},
+			},
+		},
+	}
+}
+
+// newFlowSchema returns the FlowSchema assigning every request of the operator's service account to its priority
+// level. Requests are queued by namespace, so that the CertificateRequests of one busy namespace do not hold up those
+// of the others.
+func newFlowSchema() *flowcontrolv1.FlowSchema {
+	return &flowcontrolv1.FlowSchema{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   Name,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": config.OperatorName},
+		},
+		Spec: flowcontrolv1.FlowSchemaSpec{
+			PriorityLevelConfiguration: flowcontrolv1.PriorityLevelConfigurationReference{Name: Name},
+			MatchingPrecedence:         matchingPrecedence,
+			DistinguisherMethod:        &flowcontrolv1.FlowDistinguisherMethod{Type: flowcontrolv1.FlowDistinguisherMethodByNamespaceType},
+			Rules: []flowcontrolv1.PolicyRulesWithSubjects{{
+				Subjects: []flowcontrolv1.Subject{{
+					Kind:           flowcontrolv1.SubjectKindServiceAccount,
+					ServiceAccount: &flowcontrolv1.ServiceAccountSubject{Namespace: config.OperatorNamespace, Name: config.OperatorName},
+				}},
+				ResourceRules: []flowcontrolv1.ResourcePolicyRule{{
+					Verbs:        []string{flowcontrolv1.VerbAll},
+					APIGroups:    []string{flowcontrolv1.APIGroupAll},
+					Resources:    []string{flowcontrolv1.ResourceAll},
+					ClusterScope: true,
+					Namespaces:   []string{flowcontrolv1.NamespaceEvery},
+				}},
+			}},
+		},
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apipriority
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+func TestSync(t *testing.T) {
+	drifted := newFlowSchema()
+	drifted.Spec.MatchingPrecedence = 9000
+
+	driftedPriorityLevel := newPriorityLevelConfiguration(5)
+
+	tests := []struct {
+		name           string
+		objects        []client.Object
+		shares         int32
+		expectedShares int32
+	}{
+		{
+			name:           "creates the FlowSchema and the PriorityLevelConfiguration",
+			expectedShares: DefaultNominalConcurrencyShares,
+		},
+		{
+			name:           "uses the configured concurrency shares",
+			shares:         100,
+			expectedShares: 100,
+		},
+		{
+			name:           "restores a FlowSchema and a PriorityLevelConfiguration changed by hand",
+			objects:        []client.Object{drifted, driftedPriorityLevel},
+			expectedShares: DefaultNominalConcurrencyShares,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tt.objects...).Build()
+			syncer := &Syncer{Client: kubeClient, APIReader: kubeClient, NominalConcurrencyShares: tt.shares}
+			require.NoError(t, syncer.Sync(context.TODO()))
+
+			priorityLevel := &flowcontrolv1.PriorityLevelConfiguration{}
+			require.NoError(t, kubeClient.Get(context.TODO(), types.NamespacedName{Name: Name}, priorityLevel))
+			assert.Equal(t, ptr.To(tt.expectedShares), priorityLevel.Spec.Limited.NominalConcurrencyShares)
+
+			flowSchema := &flowcontrolv1.FlowSchema{}
+			require.NoError(t, kubeClient.Get(context.TODO(), types.NamespacedName{Name: Name}, flowSchema))
+			assert.Equal(t, newFlowSchema().Spec, flowSchema.Spec)
+		})
+	}
+}
+
+func TestNewFlowSchema(t *testing.T) {
+	flowSchema := newFlowSchema()
+
+	assert.Equal(t, Name, flowSchema.Spec.PriorityLevelConfiguration.Name)
+	require.Len(t, flowSchema.Spec.Rules, 1)
+	assert.Equal(t, []flowcontrolv1.Subject{{
+		Kind:           flowcontrolv1.SubjectKindServiceAccount,
+		ServiceAccount: &flowcontrolv1.ServiceAccountSubject{Namespace: config.OperatorNamespace, Name: config.OperatorName},
+	}}, flowSchema.Spec.Rules[0].Subjects)
+}
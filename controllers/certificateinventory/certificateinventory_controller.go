@@ -0,0 +1,207 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificateinventory
+
+import (
+	"context"
+	"crypto/x509"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+)
+
+const (
+	controllerName            = "controller_certificateinventory"
+	defaultExpiringWithinDays = 14
+	clusterDeploymentType     = "ClusterDeployment"
+
+	// refreshInterval is how often an inventory is refreshed so that the days remaining stay current.
+	refreshInterval = time.Hour
+)
+
+var log = logf.Log.WithName(controllerName)
+
+var _ reconcile.Reconciler = &CertificateInventoryReconciler{}
+
+// CertificateInventoryReconciler summarizes every CertificateRequest into the status of each CertificateInventory.
+type CertificateInventoryReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// Reconcile lists the CertificateRequests of all namespaces and records a summary of their certificates in the
+// status of the CertificateInventory.
+func (r *CertificateInventoryReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("reconciling CertificateInventory")
+
+	inventory := &certmanv1alpha1.CertificateInventory{}
+	err := r.Client.Get(ctx, request.NamespacedName, inventory)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("cannot find certificateinventory, assumed deleted")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList); err != nil {
+		reqLogger.Error(err, "error listing CertificateRequests")
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	expiringWithinDays := inventory.Spec.ExpiringWithinDays
+	if expiringWithinDays <= 0 {
+		expiringWithinDays = defaultExpiringWithinDays
+	}
+
+	status := certmanv1alpha1.CertificateInventoryStatus{}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+
+		// the certificate is missing until the first issuance succeeds, which the summary reports as not issued
+		certificate, _ := certificaterequest.GetCertificate(ctx, r.Client, cr)
+
+		summary := summarizeCertificateRequest(cr, certificate, expiringWithinDays, now)
+		status.Certificates = append(status.Certificates, summary)
+		if summary.Expiring {
+			status.Expiring++
+		}
+		if summary.LastError != "" {
+			status.Failing++
+		}
+	}
+	status.Total = len(status.Certificates)
+	sortCertificateSummaries(status.Certificates)
+
+	// certificate requests change often, so the status is only rewritten when the summary changed or is stale
+	lastUpdated := inventory.Status.LastUpdated
+	status.LastUpdated = lastUpdated
+	if equality.Semantic.DeepEqual(inventory.Status, status) && lastUpdated != nil && now.Sub(lastUpdated.Time) < refreshInterval {
+		return reconcile.Result{RequeueAfter: refreshInterval - now.Sub(lastUpdated.Time)}, nil
+	}
+
+	status.LastUpdated = &metav1.Time{Time: now}
+	inventory.Status = status
+	if err := r.Client.Status().Update(ctx, inventory); err != nil {
+		reqLogger.Error(err, "failed to update CertificateInventory status")
+		return reconcile.Result{}, err
+	}
+
+	reqLogger.Info("updated certificate inventory", "Total", status.Total, "Expiring", status.Expiring, "Failing", status.Failing)
+	return reconcile.Result{RequeueAfter: refreshInterval}, nil
+}
+
+// summarizeCertificateRequest returns the inventory entry of a CertificateRequest. certificate is nil if none has
+// been issued yet.
+func summarizeCertificateRequest(cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate, expiringWithinDays int, now time.Time) certmanv1alpha1.CertificateSummary {
+	summary := certmanv1alpha1.CertificateSummary{
+		Namespace: cr.Namespace,
+		Name:      cr.Name,
+		DNSNames:  cr.Spec.DnsNames,
+		Imported:  cr.Spec.ImportedSecret != nil,
+		LastError: lastError(cr),
+	}
+
+	for _, o := range cr.OwnerReferences {
+		if o.Kind == clusterDeploymentType {
+			summary.ClusterName = o.Name
+		}
+	}
+
+	if certificate != nil {
+		daysRemaining := int(certificate.NotAfter.Sub(now).Hours() / 24)
+		summary.IssuerName = certificate.Issuer.CommonName
+		summary.NotAfter = &metav1.Time{Time: certificate.NotAfter}
+		summary.DaysRemaining = &daysRemaining
+		summary.Expiring = daysRemaining < expiringWithinDays
+	}
+
+	return summary
+}
+
+// lastError returns the error recorded by the last failed issuance of the CertificateRequest, or an empty string
+// if it is not failing.
+func lastError(cr *certmanv1alpha1.CertificateRequest) string {
+	ready := meta.FindStatusCondition(cr.Status.Conditions, string(certmanv1alpha1.CertificateRequestReady))
+	if ready == nil || ready.Status != metav1.ConditionFalse {
+		return ""
+	}
+
+	if ready.Message != "" {
+		return ready.Message
+	}
+	return "certificate issuance failed"
+}
+
+// sortCertificateSummaries orders certificates that have not been issued first, followed by the soonest to expire.
+func sortCertificateSummaries(summaries []certmanv1alpha1.CertificateSummary) {
+	sort.SliceStable(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if (a.NotAfter == nil) != (b.NotAfter == nil) {
+			return a.NotAfter == nil
+		}
+		if a.NotAfter != nil && !a.NotAfter.Equal(b.NotAfter) {
+			return a.NotAfter.Before(b.NotAfter)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CertificateInventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certmanv1alpha1.CertificateInventory{}).
+		Watches(&certmanv1alpha1.CertificateRequest{}, handler.EnqueueRequestsFromMapFunc(r.inventoriesForCertificateRequest)).
+		Complete(r)
+}
+
+// inventoriesForCertificateRequest enqueues every CertificateInventory when a CertificateRequest changes.
+func (r *CertificateInventoryReconciler) inventoriesForCertificateRequest(ctx context.Context, obj client.Object) []reconcile.Request {
+	inventoryList := &certmanv1alpha1.CertificateInventoryList{}
+	if err := r.Client.List(ctx, inventoryList); err != nil {
+		log.Error(err, "error listing CertificateInventories")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(inventoryList.Items))
+	for _, inventory := range inventoryList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: inventory.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificateinventory
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const testInventoryName = "cluster"
+
+// generateCertPEM returns a PEM encoded self-signed certificate expiring after validFor.
+func generateCertPEM(t *testing.T, validFor time.Duration) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newCertificateRequest(namespace, clusterName string) *certmanv1alpha1.CertificateRequest {
+	return &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            clusterName + "-primary-cert-bundle",
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: clusterDeploymentType, Name: clusterName}},
+		},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			CertificateSecret: corev1.ObjectReference{Name: "primary-cert-bundle-secret"},
+			DnsNames:          []string{"api." + clusterName + ".example.com"},
+		},
+	}
+}
+
+func TestCertificateInventoryReconciler(t *testing.T) {
+	err := certmanv1alpha1.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+
+	healthy := newCertificateRequest("uhc-healthy", "healthy")
+	expiring := newCertificateRequest("uhc-expiring", "expiring")
+	expiring.Spec.ImportedSecret = &corev1.LocalObjectReference{Name: "customer-certificate"}
+	failing := newCertificateRequest("uhc-failing", "failing")
+	message := "acme: error code 429: too many certificates already issued"
+	failing.Status = certmanv1alpha1.CertificateRequestStatus{
+		Status: "Error",
+		Conditions: []metav1.Condition{{
+			Type:               string(certmanv1alpha1.CertificateRequestReady),
+			Status:             metav1.ConditionFalse,
+			Reason:             "AcmeError",
+			Message:            message,
+			LastTransitionTime: metav1.Now(),
+		}},
+	}
+
+	secret := func(namespace string, validFor time.Duration) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "primary-cert-bundle-secret", Namespace: namespace},
+			Data:       map[string][]byte{corev1.TLSCertKey: generateCertPEM(t, validFor)},
+		}
+	}
+
+	inventory := &certmanv1alpha1.CertificateInventory{ObjectMeta: metav1.ObjectMeta{Name: testInventoryName}}
+
+	kubeClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(inventory, healthy, expiring, failing,
+			secret(healthy.Namespace, 60*24*time.Hour),
+			secret(expiring.Namespace, 5*24*time.Hour)).
+		WithStatusSubresource(inventory).
+		Build()
+
+	r := &CertificateInventoryReconciler{Client: kubeClient, Scheme: scheme.Scheme}
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testInventoryName}})
+	require.NoError(t, err)
+	assert.Equal(t, refreshInterval, result.RequeueAfter)
+
+	updated := &certmanv1alpha1.CertificateInventory{}
+	require.NoError(t, kubeClient.Get(context.TODO(), types.NamespacedName{Name: testInventoryName}, updated))
+
+	status := updated.Status
+	assert.Equal(t, 3, status.Total)
+	assert.Equal(t, 1, status.Expiring)
+	assert.Equal(t, 1, status.Failing)
+	assert.NotNil(t, status.LastUpdated)
+
+	require.Len(t, status.Certificates, 3)
+	assert.Equal(t, "failing", status.Certificates[0].ClusterName)
+	assert.Equal(t, message, status.Certificates[0].LastError)
+	assert.Nil(t, status.Certificates[0].NotAfter)
+
+	assert.Equal(t, "expiring", status.Certificates[1].ClusterName)
+	assert.True(t, status.Certificates[1].Expiring)
+	require.NotNil(t, status.Certificates[1].DaysRemaining)
+	assert.Equal(t, 4, *status.Certificates[1].DaysRemaining)
+	assert.Equal(t, "Test CA", status.Certificates[1].IssuerName)
+	assert.True(t, status.Certificates[1].Imported)
+
+	assert.Equal(t, "healthy", status.Certificates[2].ClusterName)
+	assert.False(t, status.Certificates[2].Expiring)
+	assert.False(t, status.Certificates[2].Imported)
+	assert.Equal(t, []string{"api.healthy.example.com"}, status.Certificates[2].DNSNames)
+
+	// an unchanged inventory is not rewritten until it becomes stale
+	result, err = r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testInventoryName}})
+	require.NoError(t, err)
+	assert.Less(t, result.RequeueAfter, refreshInterval)
+
+	unchanged := &certmanv1alpha1.CertificateInventory{}
+	require.NoError(t, kubeClient.Get(context.TODO(), types.NamespacedName{Name: testInventoryName}, unchanged))
+	assert.Equal(t, updated.ResourceVersion, unchanged.ResourceVersion)
+}
+
+func TestCertificateInventoryReconcilerMissingInventory(t *testing.T) {
+	err := certmanv1alpha1.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+
+	r := &CertificateInventoryReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testInventoryName}})
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatemetrics
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+// DefaultResyncInterval is how often the gauges are recomputed from the cache, which also counts down the days left
+// on certificates that did not change.
+const DefaultResyncInterval = 10 * time.Minute
+
+var log = logf.Log.WithName("certificatemetrics")
+
+var _ manager.LeaderElectionRunnable = &Collector{}
+
+// Collector keeps the certman_operator_certificate_requests_count and certman_operator_certificate_valid_duration_days
+// gauges up to date from the events of the informers of the CertificateRequests and of their certificate secrets, so
+// that they follow every change instead of the reconciles, without listing anything from the API server. The gauges
+// are recomputed from the cache every ResyncInterval, which makes up for any event that was missed.
+type Collector struct {
+	// Informers are the informers of the cache of the manager.
+	Informers cache.Informers
+	// Reader reads the CertificateRequests and their certificate secrets from the cache of the manager.
+	Reader client.Reader
+	// Scope restricts the CertificateRequests reported. A nil Scope reports all of them.
+	Scope *utils.Scope
+	// ResyncInterval is how often the gauges are recomputed. Zero uses DefaultResyncInterval.
+	ResyncInterval time.Duration
+
+	mu sync.Mutex
+	// requests are the CertificateRequests in scope, and whether they carry the finalizer of the operator, which
+	// makes them count as managed.
+	requests map[types.NamespacedName]bool
+}
+
+// Start registers the event handlers of the informers, then recomputes the gauges every ResyncInterval until ctx is
+// done.
+func (c *Collector) Start(ctx context.Context) error {
+	interval := c.ResyncInterval
+	if interval == 0 {
+		interval = DefaultResyncInterval
+	}
+
+	crInformer, err := c.Informers.GetInformer(ctx, &certmanv1alpha1.CertificateRequest{})
+	if err != nil {
+		return err
+	}
+	if _, err := crInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.certificateRequestChanged(ctx, obj) },
+		UpdateFunc: func(_, obj interface{}) { c.certificateRequestChanged(ctx, obj) },
+		DeleteFunc: func(obj interface{}) { c.certificateRequestDeleted(obj) },
+	}); err != nil {
+		return err
+	}
+
+	secretInformer, err := c.Informers.GetInformer(ctx, &corev1.Secret{})
+	if err != nil {
+		return err
+	}
+	if _, err := secretInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.secretChanged(ctx, obj) },
+		UpdateFunc: func(_, obj interface{}) { c.secretChanged(ctx, obj) },
+		DeleteFunc: func(obj interface{}) { c.secretChanged(ctx, obj) },
+	}); err != nil {
+		return err
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.Resync(ctx); err != nil {
+			log.Error(err, "failed to resync the certificate metrics")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection reports the gauges from the leader only, like the other metrics of the CertificateRequests, so
+// that summing them over the replicas does not count each CertificateRequest several times.
+func (c *Collector) NeedLeaderElection() bool {
+	return true
+}
+
+// Resync recomputes the gauges from every CertificateRequest in the cache, and deletes the series of those that are
+// gone.
+func (c *Collector) Resync(ctx context.Context) error {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := c.Reader.List(ctx, crList); err != nil {
+		return err
+	}
+
+	listed := map[types.NamespacedName]bool{}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		listed[types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}] = true
+		c.observe(ctx, cr)
+	}
+
+	c.mu.Lock()
+	for key := range c.requests {
+		if !listed[key] {
+			c.forget(key)
+		}
+	}
+	c.mu.Unlock()
+
+	c.report()
+	return nil
+}
+
+func (c *Collector) certificateRequestChanged(ctx context.Context, obj interface{}) {
+	cr, ok := obj.(*certmanv1alpha1.CertificateRequest)
+	if !ok {
+		return
+	}
+	c.observe(ctx, cr)
+	c.report()
+}
+
+func (c *Collector) certificateRequestDeleted(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cr, ok := obj.(*certmanv1alpha1.CertificateRequest)
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	c.forget(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+	c.mu.Unlock()
+	c.report()
+}
+
+// secretChanged updates the days left on the certificate of the CertificateRequests of the secret, including when it
+// is deleted.
+func (c *Collector) secretChanged(ctx context.Context, obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := c.Reader.List(ctx, crList, client.InNamespace(secret.Namespace)); err != nil {
+		log.Error(err, "failed to list CertificateRequests")
+		return
+	}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		if cr.Spec.CertificateSecret.Name == secret.Name {
+			c.observe(ctx, cr)
+		}
+	}
+}
+
+// observe records whether the CertificateRequest is managed, and sets the days left on its certificate. The series
+// of a CertificateRequest being deleted, or whose certificate cannot be read, is deleted.
+func (c *Collector) observe(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) {
+	key := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.Scope.MatchesNamespace(cr.Namespace) {
+		c.forget(key)
+		return
+	}
+	if c.requests == nil {
+		c.requests = map[types.NamespacedName]bool{}
+	}
+	c.requests[key] = utils.ContainsString(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel)
+
+	if !cr.DeletionTimestamp.IsZero() {
+		localmetrics.ClearCertValidDuration(cr.Namespace, cr.Name)
+		return
+	}
+
+	certificate, err := c.certificate(ctx, cr)
+	if err == nil {
+		err = localmetrics.UpdateCertValidDuration(certificate, cr.Name, cr.Namespace)
+	}
+	if err != nil {
+		localmetrics.ClearCertValidDuration(cr.Namespace, cr.Name)
+	}
+}
+
+// forget deletes the series of the CertificateRequest and stops counting it. c.mu must be held.
+func (c *Collector) forget(key types.NamespacedName) {
+	delete(c.requests, key)
+	localmetrics.ClearCertValidDuration(key.Namespace, key.Name)
+}
+
+// report sets the count of the managed CertificateRequests.
+func (c *Collector) report() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := 0
+	for _, managed := range c.requests {
+		if managed {
+			count++
+		}
+	}
+	localmetrics.SetCertRequestsCount(count)
+}
+
+// certificate returns the leaf certificate of the certificate secret of the CertificateRequest.
+func (c *Collector) certificate(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (*x509.Certificate, error) {
+	secret := &corev1.Secret{}
+	if err := c.Reader.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: cr.Spec.CertificateSecret.Name}, secret); err != nil {
+		return nil, err
+	}
+
+	data := secret.Data[corev1.TLSCertKey]
+	if data == nil {
+		return nil, fmt.Errorf("certificate data was not found in secret %v", secret.Name)
+	}
+	return certificaterequest.ParseCertificateData(data)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatemetrics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+func newCertificatePEM(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newCertificateRequest(namespace, name string) *certmanv1alpha1.CertificateRequest {
+	return &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Finalizers: []string{certmanv1alpha1.CertmanOperatorFinalizerLabel},
+		},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			CertificateSecret: corev1.ObjectReference{Name: name + "-tls"},
+		},
+	}
+}
+
+func TestCollector(t *testing.T) {
+	localmetrics.MetricCertValidDuration.Reset()
+	localmetrics.SetCertRequestsCount(0)
+
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+
+	issued := newCertificateRequest("uhc-issued", "issued")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "issued-tls", Namespace: "uhc-issued"},
+		Data:       map[string][]byte{corev1.TLSCertKey: newCertificatePEM(t, time.Now().Add(30*24*time.Hour))},
+	}
+	pending := newCertificateRequest("uhc-pending", "pending")
+	unmanaged := newCertificateRequest("uhc-pending", "unmanaged")
+	unmanaged.Finalizers = nil
+	outOfScope := newCertificateRequest("other", "out-of-scope")
+
+	kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(issued, secret, pending, unmanaged, outOfScope).Build()
+	scope, err := utils.NewScope("uhc-*", "")
+	require.NoError(t, err)
+	c := &Collector{Reader: kubeClient, Scope: scope}
+	ctx := context.TODO()
+
+	for _, cr := range []client.Object{issued, pending, unmanaged, outOfScope} {
+		c.certificateRequestChanged(ctx, cr)
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(localmetrics.MetricCertRequestsCount), "only managed CertificateRequests in scope are counted")
+	assert.Equal(t, 30.0, testutil.ToFloat64(localmetrics.MetricCertValidDuration.WithLabelValues("api.example.com", "issued", "uhc-issued")))
+	assert.Equal(t, 1, testutil.CollectAndCount(localmetrics.MetricCertValidDuration))
+
+	// a renewed certificate is reported as soon as its secret changes
+	secret.Data[corev1.TLSCertKey] = newCertificatePEM(t, time.Now().Add(90*24*time.Hour))
+	require.NoError(t, kubeClient.Update(ctx, secret))
+	c.secretChanged(ctx, secret)
+	assert.Equal(t, 90.0, testutil.ToFloat64(localmetrics.MetricCertValidDuration.WithLabelValues("api.example.com", "issued", "uhc-issued")))
+
+	// the series of a deleted CertificateRequest are deleted, including when only the tombstone is seen
+	c.certificateRequestDeleted(toolscache.DeletedFinalStateUnknown{Key: "uhc-issued/issued", Obj: issued})
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricCertRequestsCount))
+	assert.Equal(t, 0, testutil.CollectAndCount(localmetrics.MetricCertValidDuration))
+}
+
+func TestCollectorResync(t *testing.T) {
+	localmetrics.MetricCertValidDuration.Reset()
+	localmetrics.SetCertRequestsCount(0)
+
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+
+	live := newCertificateRequest("uhc-live", "live")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "live-tls", Namespace: "uhc-live"},
+		Data:       map[string][]byte{corev1.TLSCertKey: newCertificatePEM(t, time.Now().Add(60*24*time.Hour))},
+	}
+	gone := newCertificateRequest("uhc-gone", "gone")
+	kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(live, secret).Build()
+	c := &Collector{Reader: kubeClient}
+
+	// the deletion of the CertificateRequest was missed
+	c.certificateRequestChanged(context.TODO(), gone)
+	require.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricCertRequestsCount))
+
+	require.NoError(t, c.Resync(context.TODO()))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricCertRequestsCount))
+	assert.Equal(t, 60.0, testutil.ToFloat64(localmetrics.MetricCertValidDuration.WithLabelValues("api.example.com", "live", "uhc-live")))
+	assert.Equal(t, 1, testutil.CollectAndCount(localmetrics.MetricCertValidDuration))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const adoptedReason = "Adopted"
+
+// recordAdoptedCertificate records in the history of the CertificateRequest the certificate of the pre-existing
+// certificate secret it just adopted, such as one written by the tool the operator replaced, so that it is reported
+// as issued and renewed at its renewal time instead of being ordered again. A certificate that does not match the
+// CertificateRequest is not recorded, and is reissued by the renewal check that follows.
+func (r *CertificateRequestReconciler) recordAdoptedCertificate(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) error {
+	certificate, err := verifyExistingCertificate(cr, secret)
+	if err != nil {
+		reqLogger.Info("existing certificate does not match the certificaterequest and will be reissued", "reason", err.Error())
+		return nil
+	}
+
+	reqLogger.Info("adopted existing certificate", "SerialNumber", certificate.SerialNumber.String(), "NotAfter", certificate.NotAfter)
+	appendHistory(cr, newHistoryEntry(certmanv1alpha1.CertificateAdopted, existingSecretTrigger, certificate))
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		return err
+	}
+	r.recordEvent(cr, corev1.EventTypeNormal, adoptedReason, "adopted the certificate in secret %s, valid until %s", secret.Name, certificate.NotAfter)
+	return nil
+}
+
+// verifyExistingCertificate returns the certificate of the certificate secret if it is usable, covers every DNS name
+// of the CertificateRequest, has not expired and matches the private key of the secret.
+func verifyExistingCertificate(cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) (*x509.Certificate, error) {
+	if err := checkCertificateSecret(cr, secret); err != nil {
+		return nil, err
+	}
+
+	certificate, err := ParseCertificateData(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, err
+	}
+
+	if name := missingDNSName(certificate, cr.Spec.DnsNames); name != "" {
+		return nil, fmt.Errorf("certificate does not cover DNS name %s", name)
+	}
+	if !certificate.NotAfter.After(time.Now()) {
+		return nil, fmt.Errorf("certificate expired at %s", certificate.NotAfter)
+	}
+
+	// the private key of a KMS key is not in the secret
+	if cr.Spec.KMS == nil {
+		key, err := parsePrivateKey(secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, err
+		}
+		publicKey, ok := certificate.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !publicKey.Equal(key.Public()) {
+			return nil, errors.New("certificate does not match the private key")
+		}
+	}
+
+	return certificate, nil
+}
+
+// missingDNSName returns the first of dnsNames that certificate does not cover, or an empty string if it covers them
+// all. DNS names are compared regardless of case, as other tools may have issued the certificate.
+func missingDNSName(certificate *x509.Certificate, dnsNames []string) string {
+	for _, name := range dnsNames {
+		covered := false
+		for _, certificateName := range certificate.DNSNames {
+			if strings.EqualFold(name, certificateName) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return name
+		}
+	}
+	return ""
+}
+
+// issuedByOperator returns true if the current certificate of the CertificateRequest was ordered by the operator,
+// rather than imported or adopted from a pre-existing secret.
+func issuedByOperator(cr *certmanv1alpha1.CertificateRequest) bool {
+	if cr.Spec.ImportedSecret != nil {
+		return false
+	}
+	history := cr.Status.History
+	return len(history) == 0 || history[len(history)-1].Action != certmanv1alpha1.CertificateAdopted
+}
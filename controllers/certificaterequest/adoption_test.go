@@ -0,0 +1,149 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// newExistingCertificateSecret returns a certificate secret as written by another tool, holding a certificate for
+// dnsNames expiring at notAfter and the private key.
+func newExistingCertificateSecret(t *testing.T, dnsNames []string, notAfter time.Time, key crypto.Signer) *corev1.Secret {
+	ca := newTestCertificate(t, newTestCATemplate(1, "Test"), nil)
+	leaf := newTestCertificate(t, newTestLeafTemplate(dnsNames, notAfter), ca)
+	if key == nil {
+		key = leaf.key
+	}
+	keyData, err := encodePrivateKey(key)
+	require.NoError(t, err)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testHiveSecretName, Namespace: testHiveNamespace},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.cert.Raw}),
+			corev1.TLSPrivateKeyKey: keyData,
+		},
+	}
+}
+
+func TestVerifyExistingCertificate(t *testing.T) {
+	dnsNames := certRequest.Spec.DnsNames
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		secret        *corev1.Secret
+		expectedError string
+	}{
+		{
+			name:   "a matching certificate",
+			secret: newExistingCertificateSecret(t, dnsNames, time.Now().Add(24*time.Hour), nil),
+		},
+		{
+			name:   "DNS names in another case",
+			secret: newExistingCertificateSecret(t, []string{"API.Gibberish.Goes.Here"}, time.Now().Add(24*time.Hour), nil),
+		},
+		{
+			name:          "a missing DNS name",
+			secret:        newExistingCertificateSecret(t, []string{"other.example.com"}, time.Now().Add(24*time.Hour), nil),
+			expectedError: "does not cover DNS name api.gibberish.goes.here",
+		},
+		{
+			name:          "an expired certificate",
+			secret:        newExistingCertificateSecret(t, dnsNames, time.Now().Add(-time.Minute), nil),
+			expectedError: "certificate expired",
+		},
+		{
+			name:          "another private key",
+			secret:        newExistingCertificateSecret(t, dnsNames, time.Now().Add(24*time.Hour), otherKey),
+			expectedError: "does not match the private key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certificate, err := verifyExistingCertificate(certRequest, tt.secret)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, certificate)
+		})
+	}
+}
+
+func TestAdoptCertificateSecretRecordsAdoptedCertificate(t *testing.T) {
+	tests := []struct {
+		name            string
+		dnsNames        []string
+		expectedHistory []certmanv1alpha1.CertificateAction
+	}{
+		{
+			name:            "records a matching certificate",
+			dnsNames:        certRequest.Spec.DnsNames,
+			expectedHistory: []certmanv1alpha1.CertificateAction{certmanv1alpha1.CertificateAdopted},
+		},
+		{
+			name:     "leaves a mismatching certificate to be reissued",
+			dnsNames: []string{"other.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			secret := newExistingCertificateSecret(t, tt.dnsNames, time.Now().Add(24*time.Hour), nil)
+			testClient := setUpTestClient(t, []runtime.Object{cr, secret})
+			r := CertificateRequestReconciler{Client: testClient, Scheme: scheme.Scheme, Recorder: record.NewFakeRecorder(10)}
+
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, cr))
+			require.NoError(t, r.adoptCertificateSecret(context.TODO(), logr.Discard(), cr, secret))
+
+			stored := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, stored))
+			var actions []certmanv1alpha1.CertificateAction
+			for _, entry := range stored.Status.History {
+				actions = append(actions, entry.Action)
+				assert.Equal(t, existingSecretTrigger, entry.Trigger)
+			}
+			assert.Equal(t, tt.expectedHistory, actions)
+			assert.Equal(t, len(tt.expectedHistory) == 0, issuedByOperator(stored))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/audit"
+)
+
+// skipIfAuditBlocked requeues a CertificateRequest whose issuance or revocation was blocked in read-only audit mode
+// for its next renewal check. The attempt is neither recorded as failed nor reported, as the instance managing the
+// certificate makes it. It returns false if err is any other error.
+func (r *CertificateRequestReconciler) skipIfAuditBlocked(ctx context.Context, reqLogger logr.Logger, err error) (reconcile.Result, bool) {
+	if !errors.Is(err, audit.ErrBlocked) {
+		return reconcile.Result{}, false
+	}
+
+	interval, err := utils.GetRenewalCheckInterval(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to get the renewal check interval, using the default")
+		interval = utils.DefaultRenewalCheckInterval
+	}
+	reqLogger.Info("certificate is due but its issuance is blocked in read-only audit mode", "RequeueAfter", interval)
+	return reconcile.Result{RequeueAfter: interval}, true
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/audit"
+)
+
+func TestSkipIfAuditBlocked(t *testing.T) {
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{})}
+
+	_, skipped := r.skipIfAuditBlocked(context.TODO(), logr.Discard(), errors.New("acme error"))
+	assert.False(t, skipped)
+
+	result, skipped := r.skipIfAuditBlocked(context.TODO(), logr.Discard(), fmt.Errorf("failed to create order: %w", audit.ErrBlocked))
+	assert.True(t, skipped)
+	assert.Equal(t, utils.DefaultRenewalCheckInterval, result.RequeueAfter)
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"software.sslmate.com/src/go-pkcs12"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	keyVaultUploadedReason     = "KeyVaultUploaded"
+	keyVaultUploadFailedReason = "KeyVaultUploadFailed"
+
+	// keyVaultMaxNameLength is the longest certificate name Azure Key Vault accepts
+	keyVaultMaxNameLength = 127
+)
+
+// keyVaultInvalidNameChars matches the characters Azure Key Vault does not allow in certificate names.
+var keyVaultInvalidNameChars = regexp.MustCompile(`[^0-9a-zA-Z-]`)
+
+// syncAzureKeyVault uploads the certificate and private key of the certificate secret to the Azure Key Vault of the
+// CertificateRequest, unless its status records that this certificate was already uploaded there. A failed upload
+// is retried on the next reconcile.
+func (r *CertificateRequestReconciler) syncAzureKeyVault(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	vault := cr.Spec.AzureKeyVault
+	if vault == nil {
+		return nil
+	}
+
+	status, err := r.uploadToAzureKeyVault(ctx, cr, certificateSecret)
+	if err != nil {
+		r.recordEvent(cr, corev1.EventTypeWarning, keyVaultUploadFailedReason, "failed to upload the certificate to key vault %s: %v", vault.VaultURL, err)
+		return fmt.Errorf("failed to upload the certificate to key vault %s: %w", vault.VaultURL, err)
+	}
+	if status == nil {
+		return nil
+	}
+
+	reqLogger.Info("uploaded certificate to Azure Key Vault", "CertificateID", status.CertificateID)
+	r.recordEvent(cr, corev1.EventTypeNormal, keyVaultUploadedReason, "certificate uploaded to key vault as %s", status.CertificateID)
+
+	cr.Status.AzureKeyVault = status
+	return r.Client.Status().Update(ctx, cr)
+}
+
+// uploadToAzureKeyVault imports the certificate secret into the key vault as a PKCS#12 bundle and returns the status
+// of the upload, or nil if the certificate is already in the key vault.
+func (r *CertificateRequestReconciler) uploadToAzureKeyVault(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) (*certmanv1alpha1.AzureKeyVaultStatus, error) {
+	vault := cr.Spec.AzureKeyVault
+
+	// the private key never leaves the KMS, so there is nothing to bundle with the certificate
+	if cr.Spec.KMS != nil {
+		return nil, errors.New("azureKeyVault cannot be used with a KMS key")
+	}
+
+	certs, err := parseCertificates(certificateSecret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", corev1.TLSCertKey, err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("secret %s has no certificate", certificateSecret.Name)
+	}
+
+	name := keyVaultCertificateName(cr)
+	serialNumber := certs[0].SerialNumber.String()
+	if uploaded := cr.Status.AzureKeyVault; uploaded != nil && uploaded.SerialNumber == serialNumber &&
+		strings.HasPrefix(uploaded.CertificateID, keyVaultCertificateURL(vault.VaultURL, name)) {
+		return nil, nil
+	}
+
+	key, err := parsePrivateKey(certificateSecret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", corev1.TLSPrivateKeyKey, err)
+	}
+
+	// the bundle only needs to be protected in transit, so a throwaway password is used
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	// Key Vault does not read PKCS#12 bundles encrypted with AES
+	pfx, err := pkcs12.LegacyDES.Encode(key, certs[0], certs[1:], password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12 bundle: %w", err)
+	}
+
+	credentials, err := keyVaultCredentials(cr)
+	if err != nil {
+		return nil, err
+	}
+	kvClient, err := r.KeyVaultClientBuilder(ctx, r.Client, credentials, cr.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := kvClient.ImportCertificate(ctx, vault.VaultURL, name, pfx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certmanv1alpha1.AzureKeyVaultStatus{
+		CertificateID: id,
+		SerialNumber:  serialNumber,
+		UploadTime:    metav1.Now(),
+	}, nil
+}
+
+// keyVaultCertificateName returns the name of the certificate in the key vault.
+func keyVaultCertificateName(cr *certmanv1alpha1.CertificateRequest) string {
+	if cr.Spec.AzureKeyVault.CertificateName != "" {
+		return cr.Spec.AzureKeyVault.CertificateName
+	}
+	name := keyVaultInvalidNameChars.ReplaceAllString(cr.Name, "-")
+	if len(name) > keyVaultMaxNameLength {
+		name = name[:keyVaultMaxNameLength]
+	}
+	return name
+}
+
+// keyVaultCertificateURL returns the URL the versions of the certificate name in the key vault are identified by.
+func keyVaultCertificateURL(vaultURL, name string) string {
+	return strings.TrimSuffix(vaultURL, "/") + "/certificates/" + name + "/"
+}
+
+// keyVaultCredentials returns the name of the secret holding the credentials of the key vault: the one set on
+// spec.azureKeyVault, or else the Azure credentials of the platform or the DNS provider.
+func keyVaultCredentials(cr *certmanv1alpha1.CertificateRequest) (string, error) {
+	switch {
+	case cr.Spec.AzureKeyVault.Credentials != nil:
+		return cr.Spec.AzureKeyVault.Credentials.Name, nil
+	case cr.Spec.Platform.Azure != nil:
+		return cr.Spec.Platform.Azure.Credentials.Name, nil
+	case cr.Spec.DNSProvider != nil && cr.Spec.DNSProvider.Azure != nil:
+		return cr.Spec.DNSProvider.Azure.Credentials.Name, nil
+	}
+	return "", errors.New("azureKeyVault sets no credentials and the CertificateRequest has no Azure platform")
+}
+
+// randomPassword returns a random password for a PKCS#12 bundle.
+func randomPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"software.sslmate.com/src/go-pkcs12"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/clients/azure"
+)
+
+// fakeKeyVaultClient records the certificates imported into it.
+type fakeKeyVaultClient struct {
+	imports     []string
+	credentials string
+	pfx         []byte
+	password    string
+	err         error
+}
+
+func (f *fakeKeyVaultClient) ImportCertificate(_ context.Context, vaultURL, name string, pfx []byte, password string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.pfx, f.password = pfx, password
+	id := fmt.Sprintf("%s/certificates/%s/%d", strings.TrimSuffix(vaultURL, "/"), name, len(f.imports))
+	f.imports = append(f.imports, id)
+	return id, nil
+}
+
+func TestSyncAzureKeyVault(t *testing.T) {
+	leaf := newTestCertificate(t, newTestLeafTemplate(certRequest.Spec.DnsNames, time.Now().Add(24*time.Hour)), nil)
+	keyData, err := encodePrivateKey(leaf.key.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	secretData := map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.cert.Raw}),
+		corev1.TLSPrivateKeyKey: keyData,
+	}
+
+	tests := []struct {
+		name            string
+		vault           *certmanv1alpha1.AzureKeyVault
+		status          *certmanv1alpha1.AzureKeyVaultStatus
+		kms             bool
+		importErr       error
+		expectError     string
+		expectedImports []string
+		expectedCreds   string
+	}{
+		{
+			name: "does nothing without a key vault",
+		},
+		{
+			name:            "uploads the certificate with the platform credentials",
+			vault:           &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net"},
+			expectedImports: []string{"https://certs.vault.azure.net/certificates/" + certRequest.Name + "/0"},
+			expectedCreds:   "azure-creds",
+		},
+		{
+			name:            "uploads under the configured name with the configured credentials",
+			vault:           &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net/", CertificateName: "api", Credentials: &corev1.LocalObjectReference{Name: "kv-creds"}},
+			expectedImports: []string{"https://certs.vault.azure.net/certificates/api/0"},
+			expectedCreds:   "kv-creds",
+		},
+		{
+			name:  "skips a certificate that was already uploaded",
+			vault: &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net", CertificateName: "api"},
+			status: &certmanv1alpha1.AzureKeyVaultStatus{
+				CertificateID: "https://certs.vault.azure.net/certificates/api/0123",
+				SerialNumber:  leaf.cert.SerialNumber.String(),
+			},
+		},
+		{
+			name:  "uploads again when the certificate name changes",
+			vault: &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net", CertificateName: "api-new"},
+			status: &certmanv1alpha1.AzureKeyVaultStatus{
+				CertificateID: "https://certs.vault.azure.net/certificates/api/0123",
+				SerialNumber:  leaf.cert.SerialNumber.String(),
+			},
+			expectedImports: []string{"https://certs.vault.azure.net/certificates/api-new/0"},
+			expectedCreds:   "azure-creds",
+		},
+		{
+			name:        "rejects a KMS key",
+			vault:       &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net"},
+			kms:         true,
+			expectError: "cannot be used with a KMS key",
+		},
+		{
+			name:        "fails when the import fails",
+			vault:       &certmanv1alpha1.AzureKeyVault{VaultURL: "https://certs.vault.azure.net"},
+			importErr:   errors.New("forbidden"),
+			expectError: "forbidden",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Spec.Platform = certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "azure-creds"}}}
+			cr.Spec.AzureKeyVault = test.vault
+			cr.Status.AzureKeyVault = test.status
+			if test.kms {
+				cr.Spec.KMS = &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}}
+			}
+			secret := &corev1.Secret{Data: secretData}
+
+			kvClient := &fakeKeyVaultClient{err: test.importErr}
+			recorder := record.NewFakeRecorder(10)
+			r := &CertificateRequestReconciler{
+				Client:   setUpTestClient(t, []runtime.Object{cr}),
+				Recorder: recorder,
+				KeyVaultClientBuilder: func(_ context.Context, _ client.Client, secretName string, _ string) (azure.KeyVaultClient, error) {
+					kvClient.credentials = secretName
+					return kvClient, nil
+				},
+			}
+
+			err := r.syncAzureKeyVault(context.TODO(), logr.Discard(), cr, secret)
+			if test.expectError != "" {
+				assert.ErrorContains(t, err, test.expectError)
+				require.NotEmpty(t, recorder.Events)
+				assert.True(t, strings.HasPrefix(<-recorder.Events, "Warning "+keyVaultUploadFailedReason))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedImports, kvClient.imports)
+			if len(test.expectedImports) == 0 {
+				assert.Empty(t, recorder.Events)
+				return
+			}
+
+			assert.Equal(t, test.expectedCreds, kvClient.credentials)
+			key, cert, chain, err := pkcs12.DecodeChain(kvClient.pfx, kvClient.password)
+			require.NoError(t, err)
+			assert.True(t, leaf.key.(*ecdsa.PrivateKey).Equal(key))
+			assert.True(t, leaf.cert.Equal(cert))
+			assert.Empty(t, chain)
+
+			updated := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, updated))
+			require.NotNil(t, updated.Status.AzureKeyVault)
+			assert.Equal(t, test.expectedImports[0], updated.Status.AzureKeyVault.CertificateID)
+			assert.Equal(t, leaf.cert.SerialNumber.String(), updated.Status.AzureKeyVault.SerialNumber)
+			assert.True(t, strings.HasPrefix(<-recorder.Events, "Normal "+keyVaultUploadedReason))
+		})
+	}
+}
+
+func TestKeyVaultCertificateName(t *testing.T) {
+	cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{AzureKeyVault: &certmanv1alpha1.AzureKeyVault{}}}
+	cr.Name = "test-cluster-primary-cert.bundle"
+	assert.Equal(t, "test-cluster-primary-cert-bundle", keyVaultCertificateName(cr))
+
+	cr.Name = strings.Repeat("a", 200)
+	assert.Len(t, keyVaultCertificateName(cr), keyVaultMaxNameLength)
+
+	cr.Spec.AzureKeyVault.CertificateName = "api"
+	assert.Equal(t, "api", keyVaultCertificateName(cr))
+}
+
+func TestKeyVaultCredentials(t *testing.T) {
+	cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{AzureKeyVault: &certmanv1alpha1.AzureKeyVault{}}}
+	_, err := keyVaultCredentials(cr)
+	assert.Error(t, err)
+
+	cr.Spec.DNSProvider = &certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "dns"}}}
+	name, err := keyVaultCredentials(cr)
+	require.NoError(t, err)
+	assert.Equal(t, "dns", name)
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
@@ -28,9 +30,9 @@ import (
 )
 
 // GetCertificate returns a certificate to the caller after retrieving the certificates secret.
-func GetCertificate(kubeClient client.Client, cr *certmanv1alpha1.CertificateRequest) (*x509.Certificate, error) {
+func GetCertificate(ctx context.Context, kubeClient client.Client, cr *certmanv1alpha1.CertificateRequest) (*x509.Certificate, error) {
 
-	crtSecret, err := GetSecret(kubeClient, cr.Spec.CertificateSecret.Name, cr.Namespace)
+	crtSecret, err := GetSecret(ctx, kubeClient, cr.Spec.CertificateSecret.Name, cr.Namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -51,6 +53,9 @@ func GetCertificate(kubeClient client.Client, cr *certmanv1alpha1.CertificateReq
 // ParseCertificateData returns a decoded x509 certificate to the caller.
 func ParseCertificateData(data []byte) (*x509.Certificate, error) {
 	keyBlock, _ := pem.Decode(data)
+	if keyBlock == nil {
+		return nil, errors.New("certificate data is not PEM encoded")
+	}
 
 	certificate, err := x509.ParseCertificate(keyBlock.Bytes)
 	if err != nil {
@@ -59,3 +64,35 @@ func ParseCertificateData(data []byte) (*x509.Certificate, error) {
 
 	return certificate, nil
 }
+
+// checkCertificateSecret returns an error describing why the certificate secret no longer holds a usable
+// certificate and private key, for instance after it was modified out of band. Secrets of CertificateRequests
+// using a KMS key must reference the key instead of holding it.
+func checkCertificateSecret(cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) error {
+	data := secret.Data[corev1.TLSCertKey]
+	if len(data) == 0 {
+		return fmt.Errorf("certificate data was not found in secret %v", secret.Name)
+	}
+
+	if _, err := ParseCertificateData(data); err != nil {
+		return fmt.Errorf("failed to parse certificate in secret %v: %w", secret.Name, err)
+	}
+
+	if cr.Spec.KMS != nil {
+		if len(secret.Data[kmsKeyIDSecretKey]) == 0 {
+			return fmt.Errorf("KMS key identifier was not found in secret %v", secret.Name)
+		}
+		return nil
+	}
+
+	key := secret.Data[corev1.TLSPrivateKeyKey]
+	if len(key) == 0 {
+		return fmt.Errorf("private key was not found in secret %v", secret.Name)
+	}
+
+	if _, err := parsePrivateKey(key); err != nil {
+		return fmt.Errorf("failed to parse private key in secret %v: %w", secret.Name, err)
+	}
+
+	return nil
+}
@@ -17,8 +17,10 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
@@ -35,6 +37,11 @@ func TestParseCertificateData(t *testing.T) {
 			data:    leAccountPrivKey,
 			wantErr: true,
 		},
+		{
+			name:    "data that is not PEM encoded",
+			data:    []byte("not a certificate"),
+			wantErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -64,10 +71,69 @@ func TestGetCertificate(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			_, err := GetCertificate(c, test.testCert)
+			_, err := GetCertificate(context.TODO(), c, test.testCert)
 			if (err != nil) != test.wantErr {
 				t.Errorf("GetCertificate() Got unexpected error: %v", err)
 			}
 		})
 	}
 }
+
+func TestCheckCertificateSecret(t *testing.T) {
+	certPEM, _, err := generateValidCertPEM()
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		kms     *certmanv1alpha1.KMSKey
+		data    map[string][]byte
+		wantErr bool
+	}{
+		{
+			name: "usable certificate and key",
+			data: map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: leAccountPrivKey},
+		},
+		{
+			name:    "empty secret",
+			wantErr: true,
+		},
+		{
+			name:    "corrupted certificate",
+			data:    map[string][]byte{corev1.TLSCertKey: []byte("not a certificate"), corev1.TLSPrivateKeyKey: leAccountPrivKey},
+			wantErr: true,
+		},
+		{
+			name:    "missing private key",
+			data:    map[string][]byte{corev1.TLSCertKey: certPEM},
+			wantErr: true,
+		},
+		{
+			name:    "corrupted private key",
+			data:    map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: certPEM},
+			wantErr: true,
+		},
+		{
+			name: "KMS key reference",
+			kms:  &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}},
+			data: map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: {}, kmsKeyIDSecretKey: []byte("arn:aws:kms:us-east-1:123456789012:key/test")},
+		},
+		{
+			name:    "missing KMS key reference",
+			kms:     &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}},
+			data:    map[string][]byte{corev1.TLSCertKey: certPEM, corev1.TLSPrivateKeyKey: leAccountPrivKey},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{KMS: test.kms}}
+			err := checkCertificateSecret(cr, &corev1.Secret{Data: test.data})
+			if (err != nil) != test.wantErr {
+				t.Errorf("checkCertificateSecret() Got unexpected error: %v", err)
+			}
+		})
+	}
+}
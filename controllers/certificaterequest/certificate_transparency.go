@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	// ctLogListKey is the key of the CT log list in its ConfigMap.
+	ctLogListKey = "log_list.json"
+
+	// ctLogRequestTimeout bounds each request to a CT log.
+	ctLogRequestTimeout = 30 * time.Second
+)
+
+var (
+	// ctInclusionInterval is the interval at which the CT logs are asked for the inclusion of a new certificate.
+	ctInclusionInterval = 15 * time.Second
+	// ctInclusionTimeout is how long the CT logs are given to include a new certificate before its order is retried.
+	ctInclusionTimeout = 5 * time.Minute
+)
+
+// getCTLogs returns the CT logs of the log list set in the operator config, or nil if none is set.
+func (r *CertificateRequestReconciler) getCTLogs(ctx context.Context) (leclient.CTLogs, error) {
+	name, err := utils.GetCTLogList(ctx, r.Client)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: config.OperatorNamespace}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get the CT log list ConfigMap %s: %w", name, err)
+	}
+
+	logs, err := leclient.ParseCTLogList([]byte(cm.Data[ctLogListKey]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s of ConfigMap %s: %w", ctLogListKey, name, err)
+	}
+	return logs, nil
+}
+
+// verifyCTInclusion waits until one of logs that issued the SCTs embedded in the leaf of certs includes it, for at
+// most ctInclusionTimeout.
+func verifyCTInclusion(ctx context.Context, reqLogger logr.Logger, logs leclient.CTLogs, certs []*x509.Certificate) error {
+	if logs == nil {
+		return errors.New("verifying the inclusion in a CT log requires a CT log list")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ctInclusionTimeout)
+	defer cancel()
+	httpClient := &http.Client{Timeout: ctLogRequestTimeout}
+
+	for {
+		name, err := logs.VerifyInclusion(ctx, httpClient, certs)
+		if err == nil {
+			reqLogger.Info("certificate is included in a CT log", "Log", name)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("certificate was not included in a CT log in time: %w", err)
+		case <-time.After(ctInclusionInterval):
+			reqLogger.Info("waiting for the certificate to be included in a CT log", "Reason", err.Error())
+		}
+	}
+}
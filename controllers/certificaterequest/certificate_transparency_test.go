@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const testCTLogList = `{"operators": [{"name": "Google", "logs": [{"description": "Google 'Argon' log", "log_id": "qqo=", "key": "AQID", "url": "https://ct.example.com/argon/"}]}]}`
+
+func TestGetCTLogs(t *testing.T) {
+	operatorConfigMap := func(data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+			Data:       data,
+		}
+	}
+	logListConfigMap := func(logList string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ct-logs", Namespace: config.OperatorNamespace},
+			Data:       map[string]string{ctLogListKey: logList},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		objects     []runtime.Object
+		expectLogs  bool
+		expectError bool
+	}{
+		{
+			name:    "no log list configured",
+			objects: []runtime.Object{operatorConfigMap(nil)},
+		},
+		{
+			name:       "log list configured",
+			objects:    []runtime.Object{operatorConfigMap(map[string]string{cTypes.CTLogList: "ct-logs"}), logListConfigMap(testCTLogList)},
+			expectLogs: true,
+		},
+		{
+			name:        "log list ConfigMap missing",
+			objects:     []runtime.Object{operatorConfigMap(map[string]string{cTypes.CTLogList: "ct-logs"})},
+			expectError: true,
+		},
+		{
+			name:        "malformed log list",
+			objects:     []runtime.Object{operatorConfigMap(map[string]string{cTypes.CTLogList: "ct-logs"}), logListConfigMap(`{"operators": [`)},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, test.objects)}
+
+			logs, err := r.getCTLogs(context.TODO())
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if test.expectLogs {
+				assert.Equal(t, "Google 'Argon' log", logs["qqo="].Name)
+			} else {
+				assert.Nil(t, logs)
+			}
+		})
+	}
+}
+
+// certificateWithSCT returns a self-signed certificate embedding an SCT of logID.
+func certificateWithSCT(t *testing.T, logID []byte) *x509.Certificate {
+	t.Helper()
+
+	sct := append([]byte{0}, logID...)
+	sct = binary.BigEndian.AppendUint64(sct, uint64(time.Now().UnixMilli()))             //#nosec - G115: test timestamp is positive
+	sct = append(sct, 0, 0, 4, 3, 0, 1, 0)                                               // no extensions, sha256/ecdsa 1 byte signature
+	entries := append(binary.BigEndian.AppendUint16(nil, uint16(len(sct))), sct...)      //#nosec - G115: test SCT is small
+	list := append(binary.BigEndian.AppendUint16(nil, uint16(len(entries))), entries...) //#nosec - G115: test SCT list is small
+	value, err := asn1.Marshal(list)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "api.example.com"},
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, Value: value}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestVerifyCTInclusion(t *testing.T) {
+	defer func(interval, timeout time.Duration) {
+		ctInclusionInterval, ctInclusionTimeout = interval, timeout
+	}(ctInclusionInterval, ctInclusionTimeout)
+	ctInclusionInterval = time.Millisecond
+	ctInclusionTimeout = 50 * time.Millisecond
+
+	assert.ErrorContains(t, verifyCTInclusion(context.TODO(), logr.Discard(), nil, nil), "requires a CT log list")
+
+	// the log never includes the certificate, so it is waited for until the timeout
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logID := bytes.Repeat([]byte{0xaa}, 32)
+	leaf := certificateWithSCT(t, logID)
+	certs := []*x509.Certificate{leaf, leaf}
+	logs := leclient.CTLogs{base64.StdEncoding.EncodeToString(logID): {Name: "Test log", URL: server.URL}}
+
+	err := verifyCTInclusion(context.TODO(), logr.Discard(), logs, certs)
+	assert.ErrorContains(t, err, "certificate was not included in a CT log in time")
+	assert.Greater(t, requests.Load(), int32(1))
+}
@@ -23,30 +23,50 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/workqueue"
+	"k8s.io/client-go/tools/record"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/audit"
 	cClient "github.com/openshift/certman-operator/pkg/clients"
+	"github.com/openshift/certman-operator/pkg/clients/azure"
+	"github.com/openshift/certman-operator/pkg/googlecas"
+	"github.com/openshift/certman-operator/pkg/http01"
+	"github.com/openshift/certman-operator/pkg/kms"
 	"github.com/openshift/certman-operator/pkg/leclient"
 	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+	"github.com/openshift/certman-operator/pkg/mailer"
+	"github.com/openshift/certman-operator/pkg/notification"
+	"github.com/openshift/certman-operator/pkg/pagerduty"
+	"github.com/openshift/certman-operator/pkg/secretsink"
+	"github.com/openshift/certman-operator/pkg/servicelog"
+	"github.com/openshift/certman-operator/pkg/tracing"
+	"github.com/openshift/certman-operator/pkg/venafi"
 )
 
 const (
@@ -55,11 +75,19 @@ const (
 	hiveRelocationAnnotation              = "hive.openshift.io/relocate"
 	hiveRelocationOutgoingValue           = "outgoing"
 	hiveRelocationCertificateRequstStatus = "Not reconciling: ClusterDeployment is relocating"
+	revokeAndReissueAnnotation            = "certman.managed.openshift.io/revoke-and-reissue"
 	fedrampEnvVariable                    = "FEDRAMP"
 	fedrampHostedZoneIDVariable           = "HOSTED_ZONE_ID"
 	clusterDeploymentType                 = "ClusterDeployment"
+
+	// outcomeControllerName labels the reconcile outcomes of the controller
+	outcomeControllerName = "certificaterequest"
 )
 
+// defaultRateLimiterOptions retry the failed reconciles within 30 seconds, as most failures are transient errors of
+// the ACME server or the DNS provider. The retries are only limited overall when a QPS is set.
+var defaultRateLimiterOptions = utils.RateLimiterOptions{BaseDelay: time.Second, MaxDelay: 30 * time.Second, Burst: 100}
+
 var fedramp = os.Getenv(fedrampEnvVariable) == "true"
 var fedrampHostedZoneID = os.Getenv(fedrampHostedZoneIDVariable)
 var log = logf.Log.WithName(controllerName)
@@ -70,20 +98,75 @@ var _ reconcile.Reconciler = &CertificateRequestReconciler{}
 type CertificateRequestReconciler struct {
 	Client        client.Client
 	Scheme        *runtime.Scheme
-	ClientBuilder func(reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error)
+	ClientBuilder func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error)
+
+	// KMSClientBuilder returns the client of the key management service holding the private keys of
+	// CertificateRequests that set spec.kms.
+	KMSClientBuilder func(ctx context.Context, kubeClient client.Client, kmsKey *certmanv1alpha1.KMSKey, namespace string) (kms.Client, error)
+
+	// KeyVaultClientBuilder returns the client uploading the certificates of CertificateRequests that set
+	// spec.azureKeyVault, authenticated with the credentials in the secret.
+	KeyVaultClientBuilder func(ctx context.Context, kubeClient client.Client, secretName string, namespace string) (azure.KeyVaultClient, error)
+
+	// SecretSinkBuilder returns the sink pushing certificates to the external secret store configured by a secret
+	// sink of a CertificateRequest, reading its credentials from namespace.
+	SecretSinkBuilder func(ctx context.Context, kubeClient client.Client, sink *certmanv1alpha1.SecretSink, namespace string) (secretsink.Sink, error)
+
+	// ServiceLogClientBuilder returns the client posting service logs to the owners of clusters whose certificates
+	// persistently fail to be issued.
+	ServiceLogClientBuilder func(ctx context.Context, kubeClient client.Client) (servicelog.Client, error)
+
+	// NotificationSinkBuilder returns the sink of the notifications of the certificate events, in the format and with
+	// the payload template configured in the operator ConfigMap.
+	NotificationSinkBuilder func(ctx context.Context, kubeClient client.Client, format, payloadTemplate string) (notification.Sink, error)
+
+	// MailSenderBuilder returns the sender of the emails to the notification addresses of the CertificateRequests.
+	MailSenderBuilder func(ctx context.Context, kubeClient client.Client) (mailer.Sender, error)
+
+	// PagerDutyClientBuilder returns the client opening the PagerDuty incidents of the certificates that persistently
+	// fail to be issued or are about to expire.
+	PagerDutyClientBuilder func(ctx context.Context, kubeClient client.Client) (pagerduty.Client, error)
+
+	// IssuerClientBuilder returns the client of the ACME server or private certificate authority issuing the
+	// certificate of a CertificateRequest. When nil, the client of its CertIssuer or of the default Let's Encrypt
+	// account is used.
+	IssuerClientBuilder func(ctx context.Context, kubeClient client.Client, cr *certmanv1alpha1.CertificateRequest) (leclient.LetsEncryptClientInterface, error)
+
+	// Scope restricts the ClusterDeployments whose CertificateRequests are managed. A nil Scope manages all of them.
+	Scope *utils.Scope
+
+	// Standalone is set when the operator manages the certificates of clusters without Hive ClusterDeployments: the
+	// cluster it runs on, or the ManagedClusters of an ACM or MCE hub.
+	Standalone bool
+
+	// HTTP01Solver serves the HTTP-01 challenges. It is nil when the operator does not serve them, which fails the
+	// issuance of the DNS names using http-01 challenges.
+	HTTP01Solver *http01.Server
+
+	// Recorder records Events on the CertificateRequests and their ClusterDeployments.
+	Recorder record.EventRecorder
+
+	// RateLimiter tunes the rate limiter of the workqueue of the controller.
+	RateLimiter utils.RateLimiterOptions
+
+	// expiryNotified holds the certificates whose upcoming expiry was notified.
+	expiryNotified sync.Map
+
+	// emailed holds the kinds of emails sent for each certificate.
+	emailed sync.Map
 }
 
 // Reconcile reads that state of the cluster for a CertificateRequest object and makes changes based on the state read
 // and what is in the CertificateRequest.Spec
-func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
-	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request reconcile.Request) (_ reconcile.Result, err error) {
+	reqLogger := log.WithValues(logging.CertRequest, request.String())
 
 	reqLogger.Info("reconciling CertificateRequest")
 	envvar, present := os.LookupEnv(fedrampEnvVariable)
 	if len(envvar) == 0 || !present {
 		reqLogger.Info("FEDRAMP environment variable unset, defaulting to false")
 	} else {
-		reqLogger.Info(fmt.Sprintf("running in FedRAMP environment: %t", fedramp))
+		reqLogger.Info("running in FedRAMP environment", "fedramp", fedramp)
 	}
 
 	if fedramp {
@@ -93,22 +176,33 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request re
 			reqLogger.Error(err, "HOSTED_ZONE_ID environment variable is unset but is required in FedRAMP environment")
 			return reconcile.Result{}, nil
 		}
-		reqLogger.Info(fmt.Sprintf("running in FedRAMP zone: %s", fedrampHostedZoneID))
+		reqLogger.Info("running in FedRAMP zone", "hostedZoneID", fedrampHostedZoneID)
 	}
 
 	timer := prometheus.NewTimer(localmetrics.MetricCertificateRequestReconcileDuration)
+	outcome := localmetrics.ReconcileUnchanged
 	defer func() {
 		reconcileDuration := timer.ObserveDuration()
+		localmetrics.ObserveReconcileOutcome(outcomeControllerName, outcome, err)
 		reqLogger.WithValues("Duration", reconcileDuration).Info("Reconcile complete.")
 	}()
 
-	// Init the certificate request counter if nor already done
-	localmetrics.CheckInitCounter(r.Client)
+	// Bound the whole reconcile, so that a stuck ACME or DNS provider call cannot block the worker indefinitely
+	timeout, err := utils.GetReconcileTimeout(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "CertificateRequest.Reconcile", attribute.String("k8s.namespace.name", request.Namespace), attribute.String("certificaterequest.name", request.Name))
+	defer span.End()
 
 	// Fetch the CertificateRequest cr
 	cr := &certmanv1alpha1.CertificateRequest{}
 
-	err := r.Client.Get(context.TODO(), request.NamespacedName, cr)
+	err = r.Client.Get(ctx, request.NamespacedName, cr)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			reqLogger.Info("cannot find certificaterequest, assumed deleted")
@@ -119,90 +213,48 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request re
 		return reconcile.Result{}, err
 	}
 
+	// Leave the CertificateRequests of other operator instances alone, including their finalizers
+	inScope, err := r.inScope(ctx, cr)
+	if err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+	if !inScope {
+		reqLogger.Info("certificaterequest is out of the scope of this operator, skipping reconcile")
+		outcome = localmetrics.ReconcileSkippedNotManaged
+		return reconcile.Result{}, nil
+	}
+	if name := utils.ClusterDeploymentName(cr); name != "" {
+		reqLogger = reqLogger.WithValues(logging.Cluster, cr.Namespace+"/"+name)
+	}
+
+	// Conditions written before the switch to metav1.Condition would fail validation on the next status update
+	pruneInvalidConditions(cr)
+
 	// Handle the presence of a deletion timestamp.
 	if !cr.DeletionTimestamp.IsZero() {
-		return r.finalizeCertificateRequest(reqLogger, cr)
+		return r.finalizeCertificateRequest(ctx, reqLogger, cr)
 	}
 
-	defer func(cr *certmanv1alpha1.CertificateRequest) {
-		err := r.UpdateCertValidDuration(cr)
-		if err != nil {
-			reqLogger.Error(err, "failed to update the certman_operator_certificate_valid_duration_days metric")
-		} else {
-			reqLogger.Info("updated certificate duration metrics")
-		}
-	}(cr)
-
 	// Add finalizer if not exists
 	if !utils.ContainsString(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel) {
 		reqLogger.Info("adding finalizer to the certificate request")
-		localmetrics.IncrementCertRequestsCounter()
 		baseToPatch := client.MergeFrom(cr.DeepCopy())
 		cr.Finalizers = append(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel)
-		if err := r.Client.Patch(context.TODO(), cr, baseToPatch); err != nil {
-			reqLogger.Error(err, err.Error())
-			return reconcile.Result{}, err
-		}
-	}
-
-	// Just in case something else ever adds itself as an owner of the certificaterequest,
-	// loop through the owner references to find which one is the clusterdeployment
-	clusterDeploymentName := ""
-
-	for _, o := range cr.OwnerReferences {
-		if o.Kind == clusterDeploymentType {
-			clusterDeploymentName = o.Name
-		}
-	}
-	if clusterDeploymentName == "" {
-		// Assume there's only one clusterdeployment in a namespace and that it's the owner of this certificaterequest
-		// We have to assume this so that if/when a CertificateRequest loses its OwnerReferences, it can still reconcile
-		cdList := &hivev1.ClusterDeploymentList{}
-		err = r.Client.List(context.TODO(), cdList)
-		if err != nil {
+		if err := r.Client.Patch(ctx, cr, baseToPatch); err != nil {
 			reqLogger.Error(err, err.Error())
 			return reconcile.Result{}, err
 		}
-
-		// If we still can't find a clusterdeployment, throw an error
-		if len(cdList.Items) == 0 {
-			err = gerrors.New("ClusterDeployment not found")
-			reqLogger.Error(err, "ClusterDeployment not found")
-			return reconcile.Result{}, err
-		}
-
-		clusterDeploymentName = cdList.Items[0].Name
 	}
 
-	cd := &hivev1.ClusterDeployment{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: request.Namespace, Name: clusterDeploymentName}, cd)
+	cd, err := r.getClusterDeployment(ctx, reqLogger, cr)
 	if err != nil {
 		reqLogger.Error(err, err.Error())
 		return reconcile.Result{}, err
 	}
 
-	// If the ownerreference isn't there, add it
-	if len(cr.OwnerReferences) == 0 {
-		baseToPatch := client.MergeFrom(cr.DeepCopy())
-		missingOwnerReference := metav1.OwnerReference{
-			APIVersion:         fmt.Sprintf("%s/%s", hivev1.HiveAPIGroup, hivev1.HiveAPIVersion),
-			Kind:               "ClusterDeployment",
-			Name:               cd.Name,
-			UID:                cd.UID,
-			Controller:         boolPointer(true),
-			BlockOwnerDeletion: boolPointer(true),
-		}
-		cr.OwnerReferences = []metav1.OwnerReference{missingOwnerReference}
-
-		reqLogger.WithValues("CertificateRequest.Name", cr.Name, "OwnerReference.Name", missingOwnerReference.Name).Info("adding OwnerReference to CertificateRequest")
-		if err := r.Client.Patch(context.TODO(), cr, baseToPatch); err != nil {
-			reqLogger.Error(err, err.Error())
-			return reconcile.Result{}, err
-		}
-	}
-
 	// Fetch the clusterdeployment and bail out if there's an outgoing migration annotation
-	relocating, err := relocationBailOut(r.Client, types.NamespacedName{Namespace: request.Namespace, Name: cd.Name})
+	relocating, err := r.isRelocating(ctx, types.NamespacedName{Namespace: request.Namespace, Name: cd.Name})
 	if err != nil {
 		if !errors.IsNotFound(err) {
 			// If the ClusterDeployment was deleted by some other means, then we should just proceed anyways (we could be deleting this object)
@@ -214,9 +266,10 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request re
 
 	if relocating {
 		reqLogger.Info("Not reconciling, clusterdeployment is relocating")
+		outcome = localmetrics.ReconcileSkippedRelocating
 
 		cr.Status.Status = hiveRelocationCertificateRequstStatus
-		err = r.Client.Update(context.TODO(), cr)
+		err = r.Client.Status().Update(ctx, cr)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
@@ -224,46 +277,102 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request re
 		return reconcile.Result{}, nil
 	}
 
+	// Only report the status of a paused CertificateRequest, without ordering certificates or touching DNS and secrets
+	if utils.IsPaused(cr) || utils.IsPaused(cd) {
+		reqLogger.Info("Not reconciling, certificaterequest is paused")
+		outcome = localmetrics.ReconcileSkippedPaused
+		if err := r.reportPausedStatus(ctx, reqLogger, cr, cd); err != nil {
+			reqLogger.Error(err, "failed to report the status of the paused certificaterequest")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.clearCondition(ctx, cr, pausedConditionType); err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reportPolicyViolations(ctx, reqLogger, cr); err != nil {
+		reqLogger.Error(err, "failed to check the certificaterequest against the certificate policies")
+		return reconcile.Result{}, err
+	}
+
+	// Imported certificates are copied and monitored instead of being ordered from the ACME server
+	if cr.Spec.ImportedSecret != nil {
+		return r.reconcileImportedCertificate(ctx, reqLogger, cr)
+	}
+
+	// Stop ordering certificates once the retry budget is exhausted, until the spec changes or a retry is requested
+	if err := r.resetRetryBudget(ctx, reqLogger, cr); err != nil {
+		reqLogger.Error(err, "failed to reset the retry budget")
+		return reconcile.Result{}, err
+	}
+	if isFailed(cr) {
+		reqLogger.Info("Not reconciling, certificaterequest has exhausted its retry budget", "FailedAttempts", cr.Status.FailedAttempts)
+		return reconcile.Result{}, nil
+	}
+
 	found := &corev1.Secret{}
 
-	leClient, err := leclient.NewClient(r.Client)
+	leClient, err := r.getLetsEncryptClient(ctx, cr)
 	if err != nil {
 		reqLogger.Error(err, "failed to get letsencrypt client")
 		return reconcile.Result{}, err
 	}
+	leClient.SetTraceContext(ctx)
 
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.CertificateSecret.Name, Namespace: cr.Namespace}, found)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.CertificateSecret.Name, Namespace: cr.Namespace}, found)
 
 	// Issue new certificates if the secret does not already exist
 	if err != nil {
 		if errors.IsNotFound(err) {
+			// the certificate secret of a relocated cluster may arrive after its ClusterDeployment
+			if isIncomingRelocation(cd) {
+				reqLogger.Info("waiting for the certificate secret of the incoming clusterdeployment to be relocated")
+				outcome = localmetrics.ReconcileSkippedRelocating
+				return reconcile.Result{RequeueAfter: relocatedSecretRequeueInterval}, nil
+			}
+
 			reqLogger.Info("requesting new certificates as secret was not found")
-			return r.createCertificateSecret(reqLogger, cr, leClient)
+			result, err := r.createCertificateSecret(ctx, reqLogger, cr, leClient)
+			outcome = issuanceOutcome(cr, localmetrics.ReconcileIssued)
+			return result, err
 		}
 
 		reqLogger.Error(err, err.Error())
 		return reconcile.Result{}, err
 	}
 
+	if err := r.adoptCertificateSecret(ctx, reqLogger, cr, found); err != nil {
+		reqLogger.Error(err, "failed to adopt the certificate secret")
+		return reconcile.Result{}, err
+	}
+
 	reqLogger.Info("checking if certificates need to be reissued")
 
 	// Reissue Certificates
-	shouldReissue, err := r.ShouldReissue(reqLogger, cr)
+	reissueTrigger, err := r.reissueTrigger(ctx, reqLogger, cr)
 	if err != nil {
 		reqLogger.Error(err, err.Error())
 		return reconcile.Result{}, err
 	}
+	shouldReissue := reissueTrigger != ""
+	if reissueTrigger == renewalTimeReachedTrigger {
+		r.emailRenewalWindow(ctx, reqLogger, cr)
+	}
 
 	// Fetch the clusterdeployment and bail out if there's an outgoing migration annotation again
-	relocating, err = relocationBailOut(r.Client, types.NamespacedName{Namespace: request.Namespace, Name: clusterDeploymentName})
+	relocating, err = r.isRelocating(ctx, types.NamespacedName{Namespace: request.Namespace, Name: cd.Name})
 	if err != nil {
 		return reconcile.Result{}, err
 	}
 	if relocating {
 		reqLogger.Info("Not reconciling, clusterdeployment is relocating")
+		outcome = localmetrics.ReconcileSkippedRelocating
 
 		cr.Status.Status = hiveRelocationCertificateRequstStatus
-		err = r.Client.Update(context.TODO(), cr)
+		err = r.Client.Status().Update(ctx, cr)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
@@ -271,32 +380,133 @@ func (r *CertificateRequestReconciler) Reconcile(ctx context.Context, request re
 		return reconcile.Result{}, nil
 	}
 
+	if _, ok := cr.Annotations[revokeAndReissueAnnotation]; ok {
+		err := r.RevokeAndReissueCertificate(ctx, reqLogger, cr, found, leClient)
+		if result, parked := r.parkIfThrottled(ctx, reqLogger, cr, err); parked {
+			outcome = localmetrics.ReconcileThrottled
+			return result, nil
+		}
+		if result, skipped := r.skipIfAuditBlocked(ctx, reqLogger, err); skipped {
+			outcome = localmetrics.ReconcileAuditBlocked
+			return result, nil
+		}
+		if err != nil {
+			reqLogger.Error(err, "failed to revoke and reissue certificate")
+			r.recordEvent(cr, corev1.EventTypeWarning, failureReason(err), "failed to revoke and reissue the certificate: %v", err)
+			return reconcile.Result{}, err
+		}
+		r.recordEvent(cr, corev1.EventTypeNormal, reissuedReason, "certificate revoked and reissued into secret %s", found.Name)
+
+		err = r.syncDeliveries(ctx, reqLogger, cr, found)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+
+		err = r.updateStatus(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+		}
+
+		reqLogger.Info("certificate has been revoked and reissued.")
+		outcome = localmetrics.ReconcileRenewed
+		return r.nextRenewalCheck(ctx, reqLogger, cr), nil
+	}
+
 	if shouldReissue {
-		err := r.IssueCertificate(reqLogger, cr, found, leClient)
+		deferRenewal, err := r.shouldDeferRenewal(ctx, cd)
 		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+		if deferRenewal {
+			reqLogger.Info("Not reissuing, clusterdeployment is hibernating")
+			outcome = localmetrics.ReconcileSkippedHibernating
+			if err := r.setRenewalDeferredCondition(ctx, cr, cd); err != nil {
+				reqLogger.Error(err, err.Error())
+				return reconcile.Result{}, err
+			}
+			return r.nextRenewalCheck(ctx, reqLogger, cr), nil
+		}
+	}
+
+	if err := r.clearCondition(ctx, cr, renewalDeferredConditionType); err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	if shouldReissue {
+		err := r.IssueCertificate(ctx, reqLogger, cr, found, leClient)
+		if result, parked := r.parkIfThrottled(ctx, reqLogger, cr, err); parked {
+			outcome = localmetrics.ReconcileThrottled
+			return result, nil
+		}
+		if result, skipped := r.skipIfAuditBlocked(ctx, reqLogger, err); skipped {
+			outcome = localmetrics.ReconcileAuditBlocked
+			return result, nil
+		}
+		if err != nil {
+			r.recordEvent(cr, corev1.EventTypeWarning, failureReason(err), "failed to renew the certificate: %v", err)
+			if recordErr := r.recordFailedAttempt(ctx, reqLogger, cr, err); recordErr != nil {
+				reqLogger.Error(recordErr, "failed to record the failed attempt")
+			}
+			if isFailed(cr) {
+				reqLogger.Error(err, err.Error())
+				outcome = localmetrics.ReconcileError
+				return reconcile.Result{}, nil
+			}
 			return reconcile.Result{}, err
 		}
 
 		localmetrics.AddCertificateIssuance("renewal")
-		err = r.Client.Update(context.TODO(), found)
+		r.recordEvent(cr, corev1.EventTypeNormal, renewedReason, "certificate renewed into secret %s", found.Name)
+		err = r.writeSecret(ctx, found, false)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
+		appendHistory(cr, issuanceEntry(reqLogger, certmanv1alpha1.CertificateRenewed, reissueTrigger, found, leClient))
 
-		err = r.updateStatus(reqLogger, cr)
+		err = r.syncDeliveries(ctx, reqLogger, cr, found)
 		if err != nil {
 			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+
+		err = r.updateStatus(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+		}
+
+		if certificate, err := GetCertificate(ctx, r.Client, cr); err == nil {
+			r.notifyRenewal(ctx, reqLogger, cr, certificate)
 		}
 
 		reqLogger.Info("certificate has been reissued.")
-		return reconcile.Result{}, nil
+		outcome = localmetrics.ReconcileRenewed
+		return r.nextRenewalCheck(ctx, reqLogger, cr), nil
 	}
-	err = r.updateStatus(reqLogger, cr)
+
+	if applySecretTemplate(cr, found) {
+		reqLogger.Info("applying secret template to certificate secret")
+		err = r.Client.Update(ctx, found)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+	}
+
+	err = r.syncDeliveries(ctx, reqLogger, cr, found)
+	if err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateStatus(ctx, reqLogger, cr)
 	if err != nil {
 		reqLogger.Error(err, "Failed to update CertificateRequest status")
 	}
 	// reqLogger.Info("Skip reconcile as valid certificates exist", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
-	return reconcile.Result{}, nil
+	return r.nextRenewalCheck(ctx, reqLogger, cr), nil
 }
 
 // newSecret returns secret assigned to the secret name that is passed as the
@@ -312,23 +522,156 @@ func newSecret(cr *certmanv1alpha1.CertificateRequest) *corev1.Secret {
 }
 
 // getClient returns cloud specific client to the caller
-func (r *CertificateRequestReconciler) getClient(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (cClient.Client, error) {
-	clusterDeploymentName := ""
-	for _, ownerRef := range cr.OwnerReferences {
-		if ownerRef.Kind == "ClusterDeployment" {
-			clusterDeploymentName = ownerRef.Name
-		}
+func (r *CertificateRequestReconciler) getClient(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (cClient.Client, error) {
+	platform, err := r.getPlatform(ctx, cr)
+	if err != nil {
+		return nil, err
 	}
-	client, err := r.ClientBuilder(reqLogger, r.Client, cr.Spec.Platform, cr.Namespace, clusterDeploymentName)
+	r.labelCredentialsSecret(ctx, reqLogger, cr.Namespace, utils.CredentialsSecretName(platform))
+	client, err := r.ClientBuilder(ctx, reqLogger, r.Client, platform, cr.Namespace, utils.ClusterDeploymentName(cr))
 	return client, err
 }
 
+// getPlatform returns the DNS platform of the CertificateRequest, falling back to the default platform of its
+// CertIssuer.
+func (r *CertificateRequestReconciler) getPlatform(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (certmanv1alpha1.Platform, error) {
+	platform := utils.DNSPlatform(cr)
+	if isPlatformEmpty(platform) && cr.Spec.IssuerRef != nil {
+		issuer, err := r.getCertIssuer(ctx, cr)
+		if err != nil {
+			return platform, err
+		}
+		if issuer.Spec.ACME != nil && issuer.Spec.ACME.DefaultPlatform != nil {
+			platform = *issuer.Spec.ACME.DefaultPlatform
+		}
+	}
+	return platform, nil
+}
+
+// getCertIssuer returns the CertIssuer referenced by the CertificateRequest
+func (r *CertificateRequestReconciler) getCertIssuer(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (*certmanv1alpha1.CertIssuer, error) {
+	issuer := &certmanv1alpha1.CertIssuer{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.IssuerRef.Name}, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certissuer %s: %w", cr.Spec.IssuerRef.Name, err)
+	}
+	return issuer, nil
+}
+
+// getLetsEncryptClient returns the client for the CertIssuer referenced by the CertificateRequest, falling back to
+// the operator's default Let's Encrypt account when no issuer is referenced. Google CAS and Venafi issuers get a client
+// of their private CA, which implements the same interface without challenges. The mutating calls of the client are
+// blocked in read-only audit mode.
+func (r *CertificateRequestReconciler) getLetsEncryptClient(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (leclient.LetsEncryptClientInterface, error) {
+	c, err := r.newIssuerClient(ctx, cr)
+	if err != nil || !audit.Enabled() {
+		return c, err
+	}
+	return audit.IssuerClient(c), nil
+}
+
+// newIssuerClient returns the client of the ACME server or private certificate authority of the CertificateRequest.
+func (r *CertificateRequestReconciler) newIssuerClient(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (leclient.LetsEncryptClientInterface, error) {
+	if r.IssuerClientBuilder != nil {
+		return r.IssuerClientBuilder(ctx, r.Client, cr)
+	}
+
+	if cr.Spec.IssuerRef == nil {
+		return leclient.NewClient(ctx, r.Client)
+	}
+
+	issuer, err := r.getCertIssuer(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	var lifetime time.Duration
+	if cr.Spec.Duration != nil {
+		lifetime = cr.Spec.Duration.Duration
+	}
+	switch {
+	case issuer.Spec.GoogleCAS != nil:
+		return googlecas.NewClient(ctx, r.Client, issuer, lifetime)
+	case issuer.Spec.Venafi != nil:
+		return venafi.NewClient(ctx, r.Client, issuer, lifetime)
+	}
+	return leclient.NewClientForIssuer(ctx, r.Client, issuer)
+}
+
+// isPlatformEmpty returns true if no DNS solver is configured on the platform
+func isPlatformEmpty(platform certmanv1alpha1.Platform) bool {
+	return platform.AWS == nil && platform.GCP == nil && platform.Azure == nil && platform.Cloudflare == nil && platform.AcmeDNS == nil && platform.Mock == nil
+}
+
+// platformName names the DNS provider of the platform, for use in metric labels
+func platformName(platform certmanv1alpha1.Platform) string {
+	switch {
+	case platform.AWS != nil:
+		return "aws"
+	case platform.GCP != nil:
+		return "gcp"
+	case platform.Azure != nil:
+		return "azure"
+	case platform.Cloudflare != nil:
+		return "cloudflare"
+	case platform.AcmeDNS != nil:
+		return "acme-dns"
+	case platform.Mock != nil:
+		return "mock"
+	}
+	return "unknown"
+}
+
+// issuanceOutcome returns the outcome of a reconcile that issued a certificate without returning an error: success,
+// unless the issuance was throttled, exhausted the retry budget or was blocked in read-only audit mode.
+func issuanceOutcome(cr *certmanv1alpha1.CertificateRequest, success string) string {
+	switch {
+	case meta.IsStatusConditionTrue(cr.Status.Conditions, string(certmanv1alpha1.CertificateRequestRateLimited)):
+		return localmetrics.ReconcileThrottled
+	case isFailed(cr):
+		return localmetrics.ReconcileError
+	case audit.Enabled():
+		return localmetrics.ReconcileAuditBlocked
+	}
+	return success
+}
+
 // Helper function for Reconcile handles CertificateRequests with a deletion timestamp by
 // revoking the certificate and removing the finalizer if it exists.
-func (r *CertificateRequestReconciler) finalizeCertificateRequest(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (reconcile.Result, error) {
+func (r *CertificateRequestReconciler) finalizeCertificateRequest(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (reconcile.Result, error) {
 	if utils.ContainsString(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel) {
-		reqLogger.Info("revoking certificate and deleting secret")
-		if err := r.revokeCertificateAndDeleteSecret(reqLogger, cr); err != nil {
+		revoke, err := utils.GetRevokeCertificatesOnDelete(ctx, r.Client)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+
+		if revoke && cr.Spec.ImportedSecret != nil {
+			reqLogger.Info("certificate is imported, skipping revocation")
+		} else if revoke {
+			reqLogger.Info("revoking certificate and deleting secret")
+			if err := r.revokeCertificateAndDeleteSecret(ctx, reqLogger, cr); err != nil {
+				reqLogger.Error(err, err.Error())
+				return reconcile.Result{}, err
+			}
+		} else {
+			reqLogger.Info("certificate revocation on delete is disabled, skipping revocation")
+		}
+
+		// challenge records left behind by an interrupted issuance must not outlive the CertificateRequest
+		if err := r.deleteAcmeChallengeRecords(ctx, reqLogger, cr); err != nil {
+			reqLogger.Error(err, "failed to delete acme challenge records")
+			return reconcile.Result{}, err
+		}
+
+		// the KMS key is read from the certificate secret, so this must happen before the secret is deleted
+		if err := r.deleteCertificateSecretKMSKey(ctx, reqLogger, cr); err != nil {
+			reqLogger.Error(err, err.Error())
+			return reconcile.Result{}, err
+		}
+
+		// replicas in other namespaces are not garbage collected with the CertificateRequest
+		if err := r.pruneSecretReplicas(ctx, reqLogger, cr, nil); err != nil {
 			reqLogger.Error(err, err.Error())
 			return reconcile.Result{}, err
 		}
@@ -336,20 +679,46 @@ func (r *CertificateRequestReconciler) finalizeCertificateRequest(reqLogger logr
 		reqLogger.Info("removing finalizers")
 		baseToPatch := client.MergeFrom(cr.DeepCopy())
 		cr.Finalizers = utils.RemoveString(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel)
-		if err := r.Client.Patch(context.TODO(), cr, baseToPatch); err != nil {
+		if err := r.Client.Patch(ctx, cr, baseToPatch); err != nil {
 			reqLogger.Error(err, err.Error())
 			return reconcile.Result{}, err
 		}
 	}
 
-	localmetrics.ClearCertValidDuration(cr.Namespace, cr.Name)
-	localmetrics.DecrementCertRequestsCounter()
 	reqLogger.Info("certificaterequest has been deleted")
 	return reconcile.Result{}, nil
 }
 
+// deleteAcmeChallengeRecords deletes the DNS-01 challenge records of a CertificateRequest being deleted. The cleanup is
+// skipped if the DNS credentials are already gone, as happens when its whole namespace is deleted, since the
+// finalizer could otherwise never be removed.
+func (r *CertificateRequestReconciler) deleteAcmeChallengeRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	platform, err := r.getPlatform(ctx, cr)
+	if err == nil && isPlatformEmpty(platform) {
+		return nil
+	}
+
+	dnsClient, err := r.getClient(ctx, reqLogger, cr)
+	if errors.IsNotFound(err) {
+		reqLogger.Info("DNS credentials not found, skipping acme challenge record cleanup", "Error", err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	reqLogger.Info("deleting acme challenge records", "DNS", dnsClient.GetDNSName())
+	if err := dnsClient.DeleteAcmeChallengeResourceRecords(ctx, reqLogger, cr); gerrors.Is(err, audit.ErrBlocked) {
+		return nil
+	} else if err != nil {
+		localmetrics.IncrementDNSCleanupFailures()
+		return err
+	}
+	return nil
+}
+
 // Helper function for Reconcile creates a Secret object containing a newly issued certificate.
-func (r *CertificateRequestReconciler) createCertificateSecret(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, leClient leclient.LetsEncryptClientInterface) (reconcile.Result, error) {
+func (r *CertificateRequestReconciler) createCertificateSecret(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, leClient leclient.LetsEncryptClientInterface) (reconcile.Result, error) {
 	certificateSecret := newSecret(cr)
 
 	// Set CertificateRequest cr as the owner and controller
@@ -358,24 +727,34 @@ func (r *CertificateRequestReconciler) createCertificateSecret(reqLogger logr.Lo
 		return reconcile.Result{}, err
 	}
 
-	err := r.IssueCertificate(reqLogger, cr, certificateSecret, leClient)
+	err := r.IssueCertificate(ctx, reqLogger, cr, certificateSecret, leClient)
+	if result, parked := r.parkIfThrottled(ctx, reqLogger, cr, err); parked {
+		return result, nil
+	}
+	if result, skipped := r.skipIfAuditBlocked(ctx, reqLogger, err); skipped {
+		return result, nil
+	}
 	if err != nil {
-		updateErr := r.updateStatusError(reqLogger, cr, err)
+		r.recordEvent(cr, corev1.EventTypeWarning, failureReason(err), "failed to issue the certificate: %v", err)
+		updateErr := r.updateStatusError(ctx, reqLogger, cr, err)
 		if updateErr != nil {
 			reqLogger.Error(updateErr, updateErr.Error())
 		}
 		reqLogger.Error(err, err.Error())
+		if isFailed(cr) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
 
 	reqLogger.Info("creating secret with certificates")
 	localmetrics.AddCertificateIssuance("create")
 
-	err = r.Client.Create(context.TODO(), certificateSecret)
+	err = r.writeSecret(ctx, certificateSecret, true)
 	if err != nil {
 		if errors.IsAlreadyExists(err) {
 			reqLogger.Info("secret already exists. will update the existing secret with new certificates")
-			err = r.Client.Update(context.TODO(), certificateSecret)
+			err = r.writeSecret(ctx, certificateSecret, false)
 			if err != nil {
 				reqLogger.Error(err, err.Error())
 				return reconcile.Result{}, err
@@ -386,22 +765,47 @@ func (r *CertificateRequestReconciler) createCertificateSecret(reqLogger logr.Lo
 		}
 	}
 
+	appendHistory(cr, issuanceEntry(reqLogger, certmanv1alpha1.CertificateIssued, secretNotFoundTrigger, certificateSecret, leClient))
+
+	err = r.syncDeliveries(ctx, reqLogger, cr, certificateSecret)
+	if err != nil {
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
 	reqLogger.Info("updating certificate request status")
-	err = r.updateStatus(reqLogger, cr)
+	err = r.updateStatus(ctx, reqLogger, cr)
 	if err != nil {
 		reqLogger.Error(err, "could not update the status of the CertificateRequest")
 		return reconcile.Result{}, err
 	}
 
-	reqLogger.Info(fmt.Sprintf("certificates issued and stored in secret %s/%s", certificateSecret.Namespace, certificateSecret.Name))
-	return reconcile.Result{}, nil
+	reqLogger.Info("certificates issued and stored in secret", "secret", certificateSecret.Namespace+"/"+certificateSecret.Name)
+	r.recordEvent(cr, corev1.EventTypeNormal, issuedReason, "certificate issued into secret %s", certificateSecret.Name)
+	return r.nextRenewalCheck(ctx, reqLogger, cr), nil
 }
 
-// revokeCertificateAndDeleteSecret revokes certificate if it exists
-func (r *CertificateRequestReconciler) revokeCertificateAndDeleteSecret(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
-	//todo - actually delete secret when revoking
+// syncDeliveries copies the certificate secret to the replica namespaces, Azure Key Vault, external secret stores
+// and SyncSet the CertificateRequest delivers it to.
+func (r *CertificateRequestReconciler) syncDeliveries(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	if err := r.syncSecretReplicas(ctx, reqLogger, cr, certificateSecret); err != nil {
+		return err
+	}
+
+	if err := r.syncAzureKeyVault(ctx, reqLogger, cr, certificateSecret); err != nil {
+		return err
+	}
+
+	if err := r.syncSecretSinks(ctx, reqLogger, cr, certificateSecret); err != nil {
+		return err
+	}
 
-	exists, err := SecretExists(r.Client, cr.Spec.CertificateSecret.Name, cr.Namespace)
+	return r.syncSyncSet(ctx, reqLogger, cr, certificateSecret)
+}
+
+// revokeCertificateAndDeleteSecret revokes the certificate if it exists and then deletes its secret
+func (r *CertificateRequestReconciler) revokeCertificateAndDeleteSecret(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	exists, err := SecretExists(ctx, r.Client, cr.Spec.CertificateSecret.Name, cr.Namespace)
 	if err != nil {
 		return fmt.Errorf("error checking if secret exists: %w", err)
 	}
@@ -410,23 +814,99 @@ func (r *CertificateRequestReconciler) revokeCertificateAndDeleteSecret(reqLogge
 		return nil
 	}
 
-	error := r.RevokeCertificate(reqLogger, cr)
+	error := r.RevokeCertificate(ctx, reqLogger, cr)
 	if error != nil {
 		// TODO: handle error from certificate missing
 		return fmt.Errorf("error revoking certificate: %w", error)
 	}
 
 	reqLogger.Info("Certificate successfully revoked")
+
+	err = r.Client.Delete(ctx, newSecret(cr))
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting certificate secret: %w", err)
+	}
+
+	reqLogger.Info("Certificate secret deleted")
 	return nil
+}
+
+// getClusterDeployment returns the ClusterDeployment owning the CertificateRequest, adding the owner reference if it
+// is missing. In standalone mode the cluster the operator runs on has no ClusterDeployment, and CertificateRequests
+// migrated from cert-manager belong to none, so an empty one is returned: it is neither paused, hibernating nor
+// relocating.
+func (r *CertificateRequestReconciler) getClusterDeployment(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (*hivev1.ClusterDeployment, error) {
+	if r.Standalone || cr.Annotations[certmanv1alpha1.MigratedFromAnnotation] != "" {
+		return &hivev1.ClusterDeployment{}, nil
+	}
+
+	// Just in case something else ever adds itself as an owner of the certificaterequest,
+	// loop through the owner references to find which one is the clusterdeployment
+	clusterDeploymentName := ""
+
+	for _, o := range cr.OwnerReferences {
+		if o.Kind == clusterDeploymentType {
+			clusterDeploymentName = o.Name
+		}
+	}
+	if clusterDeploymentName == "" {
+		// Assume there's only one clusterdeployment in a namespace and that it's the owner of this certificaterequest
+		// We have to assume this so that if/when a CertificateRequest loses its OwnerReferences, it can still reconcile
+		cdList := &hivev1.ClusterDeploymentList{}
+		if err := r.Client.List(ctx, cdList); err != nil {
+			return nil, err
+		}
+
+		// If we still can't find a clusterdeployment, throw an error
+		if len(cdList.Items) == 0 {
+			return nil, gerrors.New("ClusterDeployment not found")
+		}
 
+		clusterDeploymentName = cdList.Items[0].Name
+	}
+
+	cd := &hivev1.ClusterDeployment{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: clusterDeploymentName}, cd); err != nil {
+		return nil, err
+	}
+
+	// If the ownerreference isn't there, add it
+	if len(cr.OwnerReferences) == 0 {
+		baseToPatch := client.MergeFrom(cr.DeepCopy())
+		missingOwnerReference := metav1.OwnerReference{
+			APIVersion:         fmt.Sprintf("%s/%s", hivev1.HiveAPIGroup, hivev1.HiveAPIVersion),
+			Kind:               "ClusterDeployment",
+			Name:               cd.Name,
+			UID:                cd.UID,
+			Controller:         boolPointer(true),
+			BlockOwnerDeletion: boolPointer(true),
+		}
+		cr.OwnerReferences = []metav1.OwnerReference{missingOwnerReference}
+
+		reqLogger.WithValues("CertificateRequest.Name", cr.Name, "OwnerReference.Name", missingOwnerReference.Name).Info("adding OwnerReference to CertificateRequest")
+		if err := r.Client.Patch(ctx, cr, baseToPatch); err != nil {
+			return nil, err
+		}
+	}
+
+	return cd, nil
+}
+
+// isRelocating checks to see if there's a cluster relocation in progress. Standalone clusters, and CertificateRequests
+// without a ClusterDeployment, are never relocated.
+func (r *CertificateRequestReconciler) isRelocating(ctx context.Context, nsn types.NamespacedName) (bool, error) {
+	if r.Standalone || nsn.Name == "" {
+		return false, nil
+	}
+	return relocationBailOut(ctx, r.Client, nsn)
 }
 
 // relocationBailOut checks to see if there's a cluster relocation in progress
-func relocationBailOut(k client.Client, nsn types.NamespacedName) (relocating bool, err error) {
+func relocationBailOut(ctx context.Context, k client.Client, nsn types.NamespacedName) (relocating bool, err error) {
 	relocating = false
 
 	cd := &hivev1.ClusterDeployment{}
-	err = k.Get(context.TODO(), nsn, cd)
+	err = k.Get(ctx, nsn, cd)
 	if err != nil {
 		return
 	}
@@ -441,24 +921,65 @@ func relocationBailOut(k client.Client, nsn types.NamespacedName) (relocating bo
 	return
 }
 
-// UpdateCertValidDuration retrieves the cluster's certificate from the on-hive secret and updates the 'certman_operator_certificate_valid_duration_days' metric
-func (r *CertificateRequestReconciler) UpdateCertValidDuration(cr *certmanv1alpha1.CertificateRequest) error {
-	certificate, err := GetCertificate(r.Client, cr)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve cluster certificate: %w", err)
-	}
-
-	return localmetrics.UpdateCertValidDuration(certificate, cr.Name, cr.Namespace)
+// clusterDeploymentStateChanged filters ClusterDeployment events down to the changes the CertificateRequests of the
+// cluster react to: the paused annotation being set or removed, the cluster hibernating or resuming, and the cluster
+// being relocated.
+var clusterDeploymentStateChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCD, okOld := e.ObjectOld.(*hivev1.ClusterDeployment)
+		newCD, okNew := e.ObjectNew.(*hivev1.ClusterDeployment)
+		if !okOld || !okNew {
+			return false
+		}
+		return utils.IsPaused(oldCD) != utils.IsPaused(newCD) ||
+			oldCD.Spec.PowerState != newCD.Spec.PowerState ||
+			oldCD.Annotations[hiveRelocationAnnotation] != newCD.Annotations[hiveRelocationAnnotation]
+	},
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. Owned certificate secrets are watched so that
+// deleting or corrupting one out of band reissues the certificate without waiting for its renewal time, secret
+// replicas are watched so that they are restored, imported secrets are watched so that replaced certificates are
+// copied, DNS provider credentials are watched so that rotated ones are used straight away, and ClusterDeployments
+// are watched so that pausing, hibernating and resuming a cluster take effect straight away. There are no
+// ClusterDeployments to watch in standalone mode.
 func (r *CertificateRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&certmanv1alpha1.CertificateRequest{}).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&certmanv1alpha1.CertificateRequest{}, builder.WithPredicates(r.Scope.NamespacePredicate())).
 		Owns(&corev1.Secret{}).
-		WithOptions(controller.Options{
-			MaxConcurrentReconciles: maxConcurrentReconciles,
-			RateLimiter:             workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](1*time.Second, 30*time.Second),
-		}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(certificateRequestForSecretReplica)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.certificateRequestsForImportedSecret)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.certificateRequestsForCredentials), builder.WithPredicates(secretDataChanged)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(r.certificateRequestsInScope), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Watches(&certmanv1alpha1.CertificatePolicy{}, handler.EnqueueRequestsFromMapFunc(r.certificateRequestsInScope))
+	if !r.Standalone {
+		b = b.Watches(&hivev1.ClusterDeployment{}, handler.EnqueueRequestsFromMapFunc(r.certificateRequestsForClusterDeployment), builder.WithPredicates(r.Scope.ClusterDeploymentPredicate(), predicate.Or(clusterDeploymentStateChanged, r.Scope.EnteredPredicate())))
+	}
+	return b.WithOptions(controller.Options{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter:             utils.NewRateLimiter(r.RateLimiter, defaultRateLimiterOptions),
+	}).
 		Complete(r)
 }
+
+// certificateRequestsInScope enqueues every CertificateRequest in scope when the CertmanOperatorConfig or a
+// CertificatePolicy changes, so that the change applies without waiting for the next renewal check.
+func (r *CertificateRequestReconciler) certificateRequestsInScope(ctx context.Context, obj client.Object) []reconcile.Request {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList); err != nil {
+		log.Error(err, "failed to list CertificateRequests")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, cr := range crList.Items {
+		if !r.Scope.MatchesNamespace(cr.Namespace) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+	}
+	return requests
+}
@@ -18,20 +18,32 @@ package certificaterequest
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/eggsampler/acme"
+	"github.com/go-logr/logr"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
 )
 
 func TestReconcile(t *testing.T) {
@@ -357,6 +369,7 @@ func TestReconcile(t *testing.T) {
 
 			// run the reconcile loop
 			rcr := CertificateRequestReconciler{
+				Recorder:      &record.FakeRecorder{},
 				Client:        testClient,
 				ClientBuilder: setUpFakeAWSClient,
 				Scheme:        s,
@@ -432,7 +445,7 @@ func TestRelocationBailOut(t *testing.T) {
 		t.Run(test.Name, func(t *testing.T) {
 			k := setUpTestClient(t, test.KubeObjects)
 
-			relocating, err := relocationBailOut(k, test.NamespacedName)
+			relocating, err := relocationBailOut(context.TODO(), k, test.NamespacedName)
 			if (err != nil) && !test.ExpectErr {
 				t.Errorf("relocationBailOut(): test.ExpectErr: %v, got: %v", test.ExpectErr, err)
 			}
@@ -444,6 +457,108 @@ func TestRelocationBailOut(t *testing.T) {
 	}
 }
 
+func TestReconcileRelocationStatus(t *testing.T) {
+	testClient := setUpTestClient(t, []runtime.Object{testLESecret, clusterDeploymentOutgoing, certRequest.DeepCopy(), expiredCertSecret})
+	rcr := CertificateRequestReconciler{
+		Recorder:      &record.FakeRecorder{},
+		Client:        testClient,
+		ClientBuilder: setUpFakeAWSClient,
+	}
+	_, err := rcr.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}})
+	require.NoError(t, err)
+
+	// the status is written through the status subresource, so that it is not dropped
+	actual := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, actual))
+	assert.Equal(t, hiveRelocationCertificateRequstStatus, actual.Status.Status)
+}
+
+func TestGetClusterDeploymentStandalone(t *testing.T) {
+	standaloneCertRequest := certRequest.DeepCopy()
+	standaloneCertRequest.OwnerReferences = nil
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{standaloneCertRequest}), Standalone: true}
+
+	cd, err := r.getClusterDeployment(context.TODO(), logr.Discard(), standaloneCertRequest)
+	assert.NoError(t, err)
+	assert.Empty(t, cd.Name)
+	assert.Empty(t, standaloneCertRequest.OwnerReferences)
+
+	relocating, err := r.isRelocating(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: cd.Name})
+	assert.NoError(t, err)
+	assert.False(t, relocating)
+
+	// outside of standalone mode, a CertificateRequest needs a ClusterDeployment
+	r.Standalone = false
+	_, err = r.getClusterDeployment(context.TODO(), logr.Discard(), standaloneCertRequest)
+	assert.Error(t, err)
+}
+
+// zoneRecordingAWSClient is a FakeAWSClient recording the zone IDs the challenge records are written in.
+type zoneRecordingAWSClient struct {
+	FakeAWSClient
+	zones *[]string
+}
+
+func (f zoneRecordingAWSClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
+	*f.zones = append(*f.zones, dnsZone)
+	return cTypes.AcmeChallengeSubDomain + "." + domain, nil
+}
+
+// newFakeIssuerClient returns a LetsEncryptClient backed by the fake acme client, whose order has a single
+// authorization offering a dns-01 challenge for domain.
+func newFakeIssuerClient(domain string) *leclient.LetsEncryptClient {
+	return &leclient.LetsEncryptClient{
+		Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{
+			Available:      true,
+			NewOrderResult: acme.Order{Authorizations: []string{"proto://a.fake.url"}},
+			FetchAuthorizationResult: acme.Authorization{
+				Identifier: acme.Identifier{Type: "dns", Value: domain},
+				ChallengeMap: map[string]acme.Challenge{
+					string(certmanv1alpha1.DNS01ChallengeType): {Type: string(certmanv1alpha1.DNS01ChallengeType), KeyAuthorization: "key-authorization"},
+				},
+			},
+		}),
+	}
+}
+
+func TestReconcileStandaloneIssuesCertificate(t *testing.T) {
+	// standalone CertificateRequests live in the operator namespace, which has no Hive DNSZone
+	standaloneCertRequest := certRequest.DeepCopy()
+	standaloneCertRequest.Namespace = config.OperatorNamespace
+	standaloneCertRequest.OwnerReferences = nil
+	standaloneCertRequest.Spec.CertificateSecret.Namespace = config.OperatorNamespace
+	testClient := setUpTestClient(t, []runtime.Object{standaloneCertRequest})
+
+	var zones []string
+	rcr := &CertificateRequestReconciler{
+		Recorder: &record.FakeRecorder{},
+		Client:   testClient,
+		Scheme:   testClient.Scheme(),
+		ClientBuilder: func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error) {
+			return zoneRecordingAWSClient{zones: &zones}, nil
+		},
+		IssuerClientBuilder: func(ctx context.Context, kubeClient client.Client, cr *certmanv1alpha1.CertificateRequest) (leclient.LetsEncryptClientInterface, error) {
+			return newFakeIssuerClient(cr.Spec.DnsNames[0]), nil
+		},
+		Standalone: true,
+	}
+
+	nsn := types.NamespacedName{Namespace: config.OperatorNamespace, Name: testHiveCertificateRequestName}
+	_, err := rcr.Reconcile(context.TODO(), reconcile.Request{NamespacedName: nsn})
+	require.NoError(t, err)
+
+	// the zone of the challenge is left to the DNS client to find
+	assert.Equal(t, []string{""}, zones)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: testHiveSecretName}, secret))
+	assert.NotEmpty(t, secret.Data[corev1.TLSCertKey])
+
+	actual := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), nsn, actual))
+	assert.True(t, actual.Status.Issued)
+}
+
 func TestRevokeCertificateAndDeleteSecret(t *testing.T) {
 	tests := []struct {
 		Name           string
@@ -478,6 +593,7 @@ func TestRevokeCertificateAndDeleteSecret(t *testing.T) {
 			s.AddKnownTypes(certmanv1alpha1.GroupVersion, certRequest)
 
 			rcr := CertificateRequestReconciler{
+				Recorder:      &record.FakeRecorder{},
 				Client:        test.setupClient(),
 				ClientBuilder: setUpFakeAWSClient,
 				Scheme:        s,
@@ -486,7 +602,7 @@ func TestRevokeCertificateAndDeleteSecret(t *testing.T) {
 			cr := &certmanv1alpha1.CertificateRequest{}
 
 			reqLogger := log.WithValues("Request.Namespace", test.NamespacedName.Namespace, "Request.Name", test.NamespacedName.Name)
-			err := rcr.revokeCertificateAndDeleteSecret(reqLogger, cr)
+			err := rcr.revokeCertificateAndDeleteSecret(context.TODO(), reqLogger, cr)
 
 			if test.ExpectErr {
 				assert.ErrorContains(t, err, test.ErrorMsg)
@@ -494,3 +610,235 @@ func TestRevokeCertificateAndDeleteSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestFinalizeCertificateRequest(t *testing.T) {
+	deletingCertRequest := func() *certmanv1alpha1.CertificateRequest {
+		cr := certRequest.DeepCopy()
+		cr.Finalizers = []string{certmanv1alpha1.CertmanOperatorFinalizerLabel}
+		cr.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		return cr
+	}
+
+	revokeConfigMap := func(revoke string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      config.OperatorName,
+				Namespace: config.OperatorNamespace,
+			},
+			Data: map[string]string{
+				cTypes.RevokeCertificatesOnDelete: revoke,
+			},
+		}
+	}
+
+	tests := []struct {
+		Name               string
+		KubeObjects        []runtime.Object
+		ExpectErr          bool
+		ExpectSecretExists bool
+	}{
+		{
+			Name:               "revocation disabled leaves the secret in place",
+			KubeObjects:        []runtime.Object{testLESecret, deletingCertRequest(), validCertSecret, revokeConfigMap("false")},
+			ExpectErr:          false,
+			ExpectSecretExists: true,
+		},
+		{
+			Name:               "revocation is enabled by default",
+			KubeObjects:        []runtime.Object{testLESecret, deletingCertRequest(), validCertSecret},
+			ExpectErr:          true,
+			ExpectSecretExists: true,
+		},
+		{
+			Name:               "revocation enabled attempts to revoke the certificate",
+			KubeObjects:        []runtime.Object{testLESecret, deletingCertRequest(), validCertSecret, revokeConfigMap("true")},
+			ExpectErr:          true,
+			ExpectSecretExists: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testClient := setUpTestClient(t, test.KubeObjects)
+
+			rcr := CertificateRequestReconciler{
+				Recorder:      &record.FakeRecorder{},
+				Client:        testClient,
+				ClientBuilder: setUpFakeAWSClient,
+			}
+
+			cr := &certmanv1alpha1.CertificateRequest{}
+			err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, cr)
+			if err != nil {
+				t.Fatalf("unexpected error getting certificate request: %s", err)
+			}
+
+			_, err = rcr.finalizeCertificateRequest(context.TODO(), logr.Discard(), cr)
+			if (err != nil) != test.ExpectErr {
+				t.Errorf("finalizeCertificateRequest() error = %v, expected error: %t", err, test.ExpectErr)
+			}
+
+			exists, err := SecretExists(context.TODO(), testClient, testHiveSecretName, testHiveNamespace)
+			if err != nil {
+				t.Fatalf("unexpected error checking secret: %s", err)
+			}
+			if exists != test.ExpectSecretExists {
+				t.Errorf("finalizeCertificateRequest() secret exists = %t, want %t", exists, test.ExpectSecretExists)
+			}
+		})
+	}
+}
+
+func TestGetClientForIssuer(t *testing.T) {
+	testIssuerSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: config.OperatorNamespace,
+			Name:      "test-issuer-account",
+		},
+		Data: map[string][]byte{
+			"private-key": leAccountPrivKey,
+			"account-url": []byte("proto://use.mock.acme.client"),
+		},
+	}
+	testIssuer := &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-issuer"},
+		Spec: certmanv1alpha1.CertIssuerSpec{
+			ACME: &certmanv1alpha1.ACMEIssuer{
+				DirectoryURL:     "https://acme.example.com/directory",
+				AccountSecretRef: corev1.SecretReference{Name: testIssuerSecret.Name},
+				DefaultPlatform: &certmanv1alpha1.Platform{
+					Mock: &certmanv1alpha1.MockPlatformSecrets{},
+				},
+			},
+		},
+	}
+	issuerCertRequest := certRequest.DeepCopy()
+	issuerCertRequest.Spec.IssuerRef = &corev1.LocalObjectReference{Name: testIssuer.Name}
+
+	tests := []struct {
+		Name             string
+		KubeObjects      []runtime.Object
+		ExpectErr        bool
+		ExpectedPlatform certmanv1alpha1.Platform
+	}{
+		{
+			Name:        "errors if the referenced certissuer does not exist",
+			KubeObjects: []runtime.Object{testIssuerSecret},
+			ExpectErr:   true,
+		},
+		{
+			Name:             "uses the account and default platform from the certissuer",
+			KubeObjects:      []runtime.Object{testIssuer, testIssuerSecret},
+			ExpectErr:        false,
+			ExpectedPlatform: *testIssuer.Spec.ACME.DefaultPlatform,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var platform certmanv1alpha1.Platform
+			rcr := CertificateRequestReconciler{
+				Recorder: &record.FakeRecorder{},
+				Client:   setUpTestClient(t, test.KubeObjects),
+				ClientBuilder: func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, p certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error) {
+					platform = p
+					return FakeAWSClient{}, nil
+				},
+			}
+
+			_, err := rcr.getLetsEncryptClient(context.TODO(), issuerCertRequest)
+			if (err != nil) != test.ExpectErr {
+				t.Errorf("getLetsEncryptClient() error = %v, expected error: %t", err, test.ExpectErr)
+			}
+
+			_, err = rcr.getClient(context.TODO(), logr.Discard(), issuerCertRequest)
+			if (err != nil) != test.ExpectErr {
+				t.Errorf("getClient() error = %v, expected error: %t", err, test.ExpectErr)
+			}
+
+			if !reflect.DeepEqual(platform, test.ExpectedPlatform) {
+				t.Errorf("getClient() platform = %v, want %v", platform, test.ExpectedPlatform)
+			}
+		})
+	}
+}
+
+// recordingDNSClient records the CertificateRequests whose challenge records were deleted.
+type recordingDNSClient struct {
+	FakeAWSClient
+	deleted *[]string
+}
+
+func (c recordingDNSClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	*c.deleted = append(*c.deleted, cr.Name)
+	return nil
+}
+
+func TestDeleteAcmeChallengeRecords(t *testing.T) {
+	awsPlatform := certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "aws"}}}
+
+	tests := []struct {
+		Name          string
+		Platform      certmanv1alpha1.Platform
+		BuilderErr    error
+		ExpectErr     bool
+		ExpectDeleted bool
+	}{
+		{
+			Name:          "deletes challenge records",
+			Platform:      awsPlatform,
+			ExpectDeleted: true,
+		},
+		{
+			Name:     "skips certificaterequests without a DNS platform",
+			Platform: certmanv1alpha1.Platform{},
+		},
+		{
+			Name:       "skips cleanup when the DNS credentials are gone",
+			Platform:   awsPlatform,
+			BuilderErr: errors.NewNotFound(corev1.Resource("secrets"), "aws"),
+		},
+		{
+			Name:       "keeps the finalizer on other errors",
+			Platform:   awsPlatform,
+			BuilderErr: fmt.Errorf("route53 unavailable"),
+			ExpectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			deleted := []string{}
+			rcr := CertificateRequestReconciler{
+				Recorder: &record.FakeRecorder{},
+				Client:   setUpTestClient(t, nil),
+				ClientBuilder: func(context.Context, logr.Logger, client.Client, certmanv1alpha1.Platform, string, string) (cClient.Client, error) {
+					if test.BuilderErr != nil {
+						return nil, test.BuilderErr
+					}
+					return recordingDNSClient{deleted: &deleted}, nil
+				},
+			}
+
+			cr := certRequest.DeepCopy()
+			cr.Spec.Platform = test.Platform
+
+			err := rcr.deleteAcmeChallengeRecords(context.TODO(), logr.Discard(), cr)
+			if (err != nil) != test.ExpectErr {
+				t.Errorf("deleteAcmeChallengeRecords() error = %v, expected error: %t", err, test.ExpectErr)
+			}
+			if (len(deleted) == 1) != test.ExpectDeleted {
+				t.Errorf("deleteAcmeChallengeRecords() deleted = %v, expected deletion: %t", deleted, test.ExpectDeleted)
+			}
+		})
+	}
+}
+
+func TestPlatformName(t *testing.T) {
+	assert.Equal(t, "aws", platformName(certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{}}))
+	assert.Equal(t, "gcp", platformName(certmanv1alpha1.Platform{GCP: &certmanv1alpha1.GCPPlatformSecrets{}}))
+	assert.Equal(t, "azure", platformName(certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{}}))
+	assert.Equal(t, "cloudflare", platformName(certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{}}))
+	assert.Equal(t, "acme-dns", platformName(certmanv1alpha1.Platform{AcmeDNS: &certmanv1alpha1.AcmeDNSPlatformSecrets{}}))
+	assert.Equal(t, "unknown", platformName(certmanv1alpha1.Platform{}))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -53,17 +54,18 @@ type DnsServerResponse struct {
 	Authority []DnsServerAnswer   `json:"Authority"`
 }
 
-// VerifyDnsResourceRecordUpdate verifies the presence of a TXT record with Cloudflare DNS.
-func VerifyDnsResourceRecordUpdate(reqLogger logr.Logger, fqdn string, txtValue string) bool {
+// VerifyDnsResourceRecordUpdate verifies the presence of a TXT record with Cloudflare DNS, looking it up attempts times
+// with interval in between. It gives up once ctx is done.
+func VerifyDnsResourceRecordUpdate(ctx context.Context, reqLogger logr.Logger, fqdn string, txtValue string, attempts int, interval time.Duration) bool {
 	var negativeCacheTTL int
 
-	for attempt := 1; attempt < maxAttemptsForDnsPropagationCheck; attempt++ {
+	for attempt := 1; attempt <= attempts; attempt++ {
 		var err error
 
 		// Sleep before querying Cloudflare DNS.  If the previous attempt returned
 		// a negative cache result, honor its TTL (within reason).  Otherwise wait
 		// for a predetermined duration.
-		sleepDuration := waitTimePeriodDnsPropagationCheck
+		sleepDuration := int(interval / time.Second)
 		if attempt > 1 && negativeCacheTTL > 0 {
 			// maxNegativeCacheTTL determines what is "reasonable".
 			// If the SOA TTL exceeds this, give up immediately.
@@ -77,14 +79,19 @@ func VerifyDnsResourceRecordUpdate(reqLogger logr.Logger, fqdn string, txtValue
 			}
 		}
 
-		reqLogger.Info(fmt.Sprintf("attempt %v to verify resource record %v has been updated with value %v", attempt, fqdn, txtValue))
+		reqLogger.Info("verifying resource record has been updated", "attempt", attempt, "fqdn", fqdn, "value", txtValue)
 
-		reqLogger.Info(fmt.Sprintf("will query DNS in %v seconds", sleepDuration))
-		time.Sleep(time.Duration(sleepDuration) * time.Second)
+		reqLogger.Info("waiting before querying DNS", "seconds", sleepDuration)
+		select {
+		case <-ctx.Done():
+			reqLogger.Info("giving up on DNS propagation check", "Reason", ctx.Err().Error())
+			return false
+		case <-time.After(time.Duration(sleepDuration) * time.Second):
+		}
 
 		negativeCacheTTL = 0
 
-		response, err := TryFetchResourceRecordUsingPublicDNS(reqLogger, fqdn)
+		response, err := TryFetchResourceRecordUsingPublicDNS(ctx, reqLogger, fqdn)
 		if err != nil {
 			reqLogger.Error(err, "failed to fetch DNS records")
 			continue
@@ -93,7 +100,7 @@ func VerifyDnsResourceRecordUpdate(reqLogger logr.Logger, fqdn string, txtValue
 		// Check for a negative cache result and note its TTL.
 		if dnsRCode(response.Status) == dnsRCodeNameError && len(response.Authority) > 0 {
 			negativeCacheTTL = response.Authority[0].TTL
-			reqLogger.Info(fmt.Sprintf("got a negative cache response with a TTL of %v seconds", negativeCacheTTL))
+			reqLogger.Info("got a negative cache response", "ttlSeconds", negativeCacheTTL)
 			// Add 5 seconds to ensure Cloudflare's negative
 			// cache record has expired on the next attempt.
 			negativeCacheTTL += 5
@@ -106,29 +113,32 @@ func VerifyDnsResourceRecordUpdate(reqLogger logr.Logger, fqdn string, txtValue
 			continue
 		}
 
-		// Trim any trailing dot from the answer name and quotes from the data.
-		cfName := strings.TrimSuffix(response.Answers[0].Name, ".")
-		cfData := strings.Trim(response.Answers[0].Data, "\"")
+		// The record holds several values when the challenges of a domain and its wildcard are presented together.
+		for _, answer := range response.Answers {
+			// Trim any trailing dot from the answer name and quotes from the data.
+			cfName := strings.TrimSuffix(answer.Name, ".")
+			cfData := strings.Trim(answer.Data, "\"")
 
-		if strings.EqualFold(cfName, fqdn) && cfData == txtValue {
-			return true
+			if strings.EqualFold(cfName, fqdn) && cfData == txtValue {
+				return true
+			}
 		}
 
 		reqLogger.Info("could not validate DNS propagation for " + fqdn)
 	}
 
-	errMsg := fmt.Sprintf("unable to verify that resource record %v has been updated to value %v after %v attempts.", fqdn, txtValue, maxAttemptsForDnsPropagationCheck)
+	errMsg := fmt.Sprintf("unable to verify that resource record %v has been updated to value %v after %v attempts.", fqdn, txtValue, attempts)
 	reqLogger.Error(errors.New(errMsg), errMsg)
 	return false
 }
 
 // Added TryFetchResourceRecordUsingPublicDNS which will run FetchResourceRecordUsingPublicDNS with cloudflareDNSOverHttpsEndpoint first,
 // and if that call fails (for instance, if cloudflare is down) will run FetchResourceRecordUsingPublicDNS with googleDNSOverHttpsEndpoint
-func TryFetchResourceRecordUsingPublicDNS(reqLogger logr.Logger, name string) (*DnsServerResponse, error) {
+func TryFetchResourceRecordUsingPublicDNS(ctx context.Context, reqLogger logr.Logger, name string) (*DnsServerResponse, error) {
 
-	response, err := FetchResourceRecordUsingPublicDNS(reqLogger, name, cloudflareDNSOverHttpsEndpoint)
+	response, err := FetchResourceRecordUsingPublicDNS(ctx, reqLogger, name, cloudflareDNSOverHttpsEndpoint)
 	if err != nil {
-		response, err = FetchResourceRecordUsingPublicDNS(reqLogger, name, googleDNSOverHttpsEndpoint)
+		response, err = FetchResourceRecordUsingPublicDNS(ctx, reqLogger, name, googleDNSOverHttpsEndpoint)
 	}
 	if err != nil {
 		localmetrics.IncrementDnsErrorCount()
@@ -137,12 +147,12 @@ func TryFetchResourceRecordUsingPublicDNS(reqLogger logr.Logger, name string) (*
 }
 
 // FetchResourceRecordUsingPublicDNS contacts dnsOverHttpsEndpoint and returns the json response.
-func FetchResourceRecordUsingPublicDNS(reqLogger logr.Logger, name string, dnsOverHttpsEndpoint string) (*DnsServerResponse, error) {
+func FetchResourceRecordUsingPublicDNS(ctx context.Context, reqLogger logr.Logger, name string, dnsOverHttpsEndpoint string) (*DnsServerResponse, error) {
 	requestUrl := dnsOverHttpsEndpoint + "?name=" + name + "&type=TXT"
 
-	reqLogger.Info(fmt.Sprintf("public DNS dns-over-https Request URL: %v", requestUrl))
+	reqLogger.Info("querying public DNS over https", "url", requestUrl)
 
-	var request, err = http.NewRequest("GET", requestUrl, nil)
+	var request, err = http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 	if err != nil {
 		reqLogger.Error(err, "error occurred creating new dns-over-https request")
 		return nil, err
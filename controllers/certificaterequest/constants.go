@@ -19,15 +19,19 @@ package certificaterequest
 type dnsRCode int
 
 const (
-	cloudflareDNSOverHttpsEndpoint    = "https://cloudflare-dns.com/dns-query"
-	googleDNSOverHttpsEndpoint        = "https://dns.google/dns-query"
-	dnsServerRequestContentType       = "application/dns-json"
-	dnsServerRequestTimeout           = 60
-	maxAttemptsForDnsPropagationCheck = 10  // Try 10 times (5 minutes total)
-	waitTimePeriodDnsPropagationCheck = 30  // Wait 30 seconds between checks
-	maxNegativeCacheTTL               = 600 // Sleep no more than 10 minutes
-	reissueCertificateBeforeDays      = 45  // This helps us avoid getting email notifications from Let's Encrypt.
-	rSAKeyBitSize                     = 2048
+	cloudflareDNSOverHttpsEndpoint = "https://cloudflare-dns.com/dns-query"
+	googleDNSOverHttpsEndpoint     = "https://dns.google/dns-query"
+	dnsServerRequestContentType    = "application/dns-json"
+	dnsServerRequestTimeout        = 60
+	maxNegativeCacheTTL            = 600 // Sleep no more than 10 minutes
+	reissueCertificateBeforeDays   = 45  // This helps us avoid getting email notifications from Let's Encrypt.
+	rSAKeyBitSize                  = 2048
+	maxConcurrentPropagationChecks = 5 // DNS-01 challenge records checked for propagation at the same time
+
+	// Keys of the certificate secret in addition to corev1.TLSCertKey and corev1.TLSPrivateKeyKey
+	caCertSecretKey      = "ca.crt"
+	combinedPEMSecretKey = "tls-combined.pem"
+	kmsKeyIDSecretKey    = "kms.key-id" // replaces corev1.TLSPrivateKeyKey when the private key is held in a KMS
 
 	// From golang.org/x/net/dns/dnsmessage
 	dnsRCodeNameError dnsRCode = 3
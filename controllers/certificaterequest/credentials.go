@@ -0,0 +1,110 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+// secretDataChanged passes the creation of a secret and the updates changing its data, such as the rotation of the
+// credentials it holds.
+var secretDataChanged = predicate.Funcs{
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSecret, okOld := e.ObjectOld.(*corev1.Secret)
+		newSecret, okNew := e.ObjectNew.(*corev1.Secret)
+		if !okOld || !okNew {
+			return false
+		}
+		return !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+	},
+}
+
+// certificateRequestsForCredentials enqueues the CertificateRequests whose DNS provider reads its credentials from
+// the secret, including those using the default platform of their CertIssuer. The DNS clients are built from the
+// secret on every reconcile, so rotated credentials are used straight away by a CertificateRequest that failed with
+// the previous ones, instead of once it is retried.
+func (r *CertificateRequestReconciler) certificateRequestsForCredentials(ctx context.Context, obj client.Object) []reconcile.Request {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list CertificateRequests")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		if !r.Scope.MatchesNamespace(cr.Namespace) {
+			continue
+		}
+		platform, err := r.getPlatform(ctx, cr)
+		if err != nil {
+			log.Error(err, "failed to get the DNS platform of the CertificateRequest", "Request.Namespace", cr.Namespace, "Request.Name", cr.Name)
+			continue
+		}
+		if utils.CredentialsSecretName(platform) == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+		}
+	}
+	return requests
+}
+
+// labelCredentialsSecret labels the credentials secret of the DNS provider of a CertificateRequest for the operator to
+// cache and watch it, as certificateRequestsForCredentials only sees the cached secrets. A missing secret is left to
+// fail the DNS client.
+func (r *CertificateRequestReconciler) labelCredentialsSecret(ctx context.Context, reqLogger logr.Logger, namespace, name string) {
+	if name == "" {
+		return
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			reqLogger.Error(err, "failed to get the credentials secret", "Secret.Name", name)
+		}
+		return
+	}
+	r.labelCachedSecret(ctx, reqLogger, secret)
+}
+
+// labelCachedSecret sets the cached label on a secret the operator does not own, so that it is cached and its changes
+// are watched. The secret is still read from the API server until then, so a failed patch is only logged.
+func (r *CertificateRequestReconciler) labelCachedSecret(ctx context.Context, reqLogger logr.Logger, secret *corev1.Secret) {
+	if secret.Labels[utils.CachedSecretLabel] == "true" {
+		return
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	mergeStringMap(&secret.Labels, map[string]string{utils.CachedSecretLabel: "true"})
+	if err := r.Client.Patch(ctx, secret, patch); err != nil {
+		reqLogger.Error(err, "failed to label the secret for caching", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+	}
+}
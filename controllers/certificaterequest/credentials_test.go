@@ -0,0 +1,97 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+func TestSecretDataChanged(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws", Namespace: testHiveNamespace, ResourceVersion: "1"},
+		Data:       map[string][]byte{"aws_access_key_id": []byte("AKIAOLD")},
+	}
+	relabelled := secret.DeepCopy()
+	relabelled.Labels = map[string]string{"rotated": "false"}
+	rotated := secret.DeepCopy()
+	rotated.Data["aws_access_key_id"] = []byte("AKIANEW")
+
+	assert.True(t, secretDataChanged.Create(event.CreateEvent{Object: secret}))
+	assert.False(t, secretDataChanged.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: relabelled}))
+	assert.True(t, secretDataChanged.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: rotated}))
+	assert.False(t, secretDataChanged.Delete(event.DeleteEvent{Object: secret}))
+}
+
+func TestCertificateRequestsForCredentials(t *testing.T) {
+	credentials := corev1.LocalObjectReference{Name: "dns-credentials"}
+
+	onPlatform := certRequest.DeepCopy()
+	onPlatform.Name = "platform"
+	onPlatform.Spec.Platform = certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: credentials, Region: "us-east-1"}}
+
+	overridden := onPlatform.DeepCopy()
+	overridden.Name = "overridden"
+	overridden.Spec.DNSProvider = &certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "cloudflare"}}}
+
+	issuer := &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "issuer"},
+		Spec: certmanv1alpha1.CertIssuerSpec{ACME: &certmanv1alpha1.ACMEIssuer{
+			DefaultPlatform: &certmanv1alpha1.Platform{GCP: &certmanv1alpha1.GCPPlatformSecrets{Credentials: credentials}},
+		}},
+	}
+	fromIssuer := certRequest.DeepCopy()
+	fromIssuer.Name = "issuer"
+	fromIssuer.Spec.IssuerRef = &corev1.LocalObjectReference{Name: issuer.Name}
+
+	otherNamespace := onPlatform.DeepCopy()
+	otherNamespace.Namespace = "other"
+
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{onPlatform, overridden, issuer, fromIssuer, otherNamespace})}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: credentials.Name, Namespace: testHiveNamespace}}
+
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: onPlatform.Name}},
+		{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: fromIssuer.Name}},
+	}, r.certificateRequestsForCredentials(context.TODO(), secret))
+}
+
+func TestLabelCredentialsSecret(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dns-credentials", Namespace: testHiveNamespace, Labels: map[string]string{"owner": "hive"}}}
+	r := CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{secret})}
+
+	r.labelCredentialsSecret(context.TODO(), logr.Discard(), testHiveNamespace, "dns-credentials")
+	r.labelCredentialsSecret(context.TODO(), logr.Discard(), testHiveNamespace, "missing")
+	r.labelCredentialsSecret(context.TODO(), logr.Discard(), testHiveNamespace, "")
+
+	labeled := &corev1.Secret{}
+	require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: "dns-credentials", Namespace: testHiveNamespace}, labeled))
+	assert.Equal(t, map[string]string{"owner": "hive", utils.CachedSecretLabel: "true"}, labeled.Labels)
+}
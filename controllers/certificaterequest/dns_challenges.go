@@ -0,0 +1,194 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+	"github.com/openshift/certman-operator/pkg/tracing"
+)
+
+// dnsChallenge is the DNS-01 challenge of an authorization of the order.
+type dnsChallenge struct {
+	authURL          string
+	domain           string
+	keyAuthorization string
+
+	// set once the challenge is presented
+	fqdn        string
+	presentedAt time.Time
+}
+
+// challengeRounds splits the challenges into rounds, each presented and answered before the next one. A DNS provider
+// that cannot batch its changes replaces the record of a challenge with each challenge it presents, so the challenges
+// sharing a record, those of a domain and of its wildcard, go in different rounds. A BatchClient presents them all in
+// one round.
+func challengeRounds(challenges []*dnsChallenge, batch bool) [][]*dnsChallenge {
+	if batch || len(challenges) == 0 {
+		return [][]*dnsChallenge{challenges}
+	}
+
+	var rounds [][]*dnsChallenge
+	presented := map[string]int{}
+	for _, challenge := range challenges {
+		domain := normalizeDNSName(challenge.domain)
+		round := presented[domain]
+		presented[domain]++
+		if round == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[round] = append(rounds[round], challenge)
+	}
+	return rounds
+}
+
+// presentDNSChallenges writes the records of the challenges, in a single change if dnsClient is a BatchClient.
+func (r *CertificateRequestReconciler) presentDNSChallenges(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, dnsClient cClient.Client, challenges []*dnsChallenge) error {
+	dnsZone, err := r.challengeZoneID(ctx, cr, dnsClient)
+	if err != nil {
+		return err
+	}
+
+	if batchClient, ok := dnsClient.(cClient.BatchClient); ok {
+		batch := make([]cTypes.DNSChallenge, len(challenges))
+		for i, challenge := range challenges {
+			batch[i] = cTypes.DNSChallenge{Domain: challenge.domain, KeyAuthorization: challenge.keyAuthorization}
+		}
+		fqdns, err := batchClient.AnswerDNSChallenges(ctx, reqLogger, batch, cr, dnsZone)
+		if err != nil {
+			return err
+		}
+		presentedAt := time.Now()
+		for i, challenge := range challenges {
+			challenge.fqdn, challenge.presentedAt = fqdns[i], presentedAt
+			r.recordEvent(cr, corev1.EventTypeNormal, challengePresentedReason, "presented the DNS-01 challenge of %s in record %s", challenge.domain, challenge.fqdn)
+		}
+		return nil
+	}
+
+	for _, challenge := range challenges {
+		fqdn, err := dnsClient.AnswerDNSChallenge(ctx, reqLogger.WithValues(logging.Domain, challenge.domain), challenge.keyAuthorization, challenge.domain, cr, dnsZone)
+		if err != nil {
+			return err
+		}
+		challenge.fqdn, challenge.presentedAt = fqdn, time.Now()
+		r.recordEvent(cr, corev1.EventTypeNormal, challengePresentedReason, "presented the DNS-01 challenge of %s in record %s", challenge.domain, challenge.fqdn)
+	}
+	return nil
+}
+
+// propagation is the outcome of the propagation check of the record of a challenge.
+type propagation struct {
+	challenge  *dnsChallenge
+	propagated bool
+}
+
+// solveDNSChallenges waits for the records of the presented challenges to propagate, checking up to
+// maxConcurrentPropagationChecks of them at a time, and answers the authorization of each challenge as soon as its
+// record has propagated. The ACME client is not safe for concurrent use, so the authorizations are answered one at a
+// time, while the records of the others keep being checked.
+func (r *CertificateRequestReconciler) solveDNSChallenges(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, leClient leclient.LetsEncryptClientInterface, challenges []*dnsChallenge, attempts int, interval time.Duration, platform certmanv1alpha1.Platform) error {
+	// the checks still running when a challenge fails are stopped before returning
+	checkCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	propagations := make(chan propagation, len(challenges))
+	slots := make(chan struct{}, maxConcurrentPropagationChecks)
+	for _, challenge := range challenges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				propagations <- propagation{challenge, waitForPropagation(checkCtx, reqLogger.WithValues(logging.Domain, challenge.domain), challenge, attempts, interval)}
+			case <-checkCtx.Done():
+				propagations <- propagation{challenge, false}
+			}
+		}()
+	}
+
+	for range challenges {
+		p := <-propagations
+		challenge := p.challenge
+		authLogger := reqLogger.WithValues(logging.Domain, challenge.domain)
+
+		if !p.propagated {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := fmt.Errorf("cannot complete Let's Encrypt challenege as DNS changes could not be verified")
+			r.reportProgress(ctx, authLogger, cr, certmanv1alpha1.CertificateRequestDNSVerified, metav1.ConditionFalse, dnsNotPropagatedReason, fmt.Sprintf("challenge record %s could not be verified", challenge.fqdn))
+			r.recordEvent(cr, corev1.EventTypeWarning, dnsNotPropagatedReason, "challenge record %s could not be verified", challenge.fqdn)
+			return err
+		}
+		r.recordEvent(cr, corev1.EventTypeNormal, dnsPropagatedReason, "challenge record %s has propagated", challenge.fqdn)
+
+		// the client answers the challenge of the authorization it last fetched
+		if err := leClient.FetchAuthorization(challenge.authURL); err != nil {
+			authLogger.Error(err, "could not fetch authorizations")
+			return err
+		}
+		leClient.SetChallengeType(string(certmanv1alpha1.DNS01ChallengeType))
+
+		authLogger.Info("updating challenge for authorization", "challenge", leClient.GetChallengeURL())
+		if err := leClient.UpdateChallenge(); err != nil {
+			authLogger.Error(err, "error updating authorization challenge")
+			return err
+		}
+		// UpdateChallenge polls the challenge until it is validated, so this includes the time the record took to propagate
+		localmetrics.ObserveDNSPropagation(platformName(platform), cr.Spec.ACMEDNSDomain, time.Since(challenge.presentedAt))
+
+		authLogger.Info("challenge successfully completed")
+	}
+	return nil
+}
+
+// waitForPropagation returns true once the record of the challenge is resolved to its key authorization by public
+// DNS, and false if it is not after the given attempts or ctx is done.
+func waitForPropagation(ctx context.Context, reqLogger logr.Logger, challenge *dnsChallenge, attempts int, interval time.Duration) bool {
+	// don't try verifying DNS while in testing
+	// TODO refactor VerifyDnsResourceRecordUpdate() to accept a mock client interface
+	if flag.Lookup("test.v") != nil {
+		return true
+	}
+
+	ctx, span := tracing.Start(ctx, "dns.WaitForPropagation", attribute.String("dns.fqdn", challenge.fqdn))
+	if !VerifyDnsResourceRecordUpdate(ctx, reqLogger, challenge.fqdn, challenge.keyAuthorization, attempts, interval) {
+		tracing.End(span, fmt.Errorf("challenge record %s could not be verified", challenge.fqdn))
+		return false
+	}
+	span.End()
+	return true
+}
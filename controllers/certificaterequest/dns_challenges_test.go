@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+func TestChallengeRounds(t *testing.T) {
+	apex := &dnsChallenge{authURL: "apex", domain: "example.com"}
+	wildcard := &dnsChallenge{authURL: "wildcard", domain: "Example.com"}
+	api := &dnsChallenge{authURL: "api", domain: "api.example.com"}
+	challenges := []*dnsChallenge{apex, wildcard, api}
+
+	assert.Equal(t, [][]*dnsChallenge{{apex, wildcard, api}}, challengeRounds(challenges, true))
+	assert.Equal(t, [][]*dnsChallenge{{apex, api}, {wildcard}}, challengeRounds(challenges, false))
+	assert.Len(t, challengeRounds(nil, false), 1)
+}
+
+func TestPresentDNSChallenges(t *testing.T) {
+	zoneID := "/hostedzone/Z1"
+	testClient := setUpTestClient(t, []runtime.Object{
+		&hivev1.DNSZone{
+			ObjectMeta: metav1.ObjectMeta{Name: "zone", Namespace: testHiveNamespace},
+			Status:     hivev1.DNSZoneStatus{AWS: &hivev1.AWSDNSZoneStatus{ZoneID: &zoneID}},
+		},
+	})
+	r := CertificateRequestReconciler{Recorder: &record.FakeRecorder{}, Client: testClient}
+	newChallenges := func() []*dnsChallenge {
+		return []*dnsChallenge{
+			{authURL: "apex", domain: "example.com", keyAuthorization: "apex"},
+			{authURL: "api", domain: "api.example.com", keyAuthorization: "api"},
+		}
+	}
+
+	t.Run("presents the challenges in one batch", func(t *testing.T) {
+		var batched []cTypes.DNSChallenge
+		challenges := newChallenges()
+		require.NoError(t, r.presentDNSChallenges(context.TODO(), logr.Discard(), certRequest.DeepCopy(), FakeBatchAWSClient{Challenges: &batched}, challenges))
+
+		assert.Equal(t, []cTypes.DNSChallenge{{Domain: "example.com", KeyAuthorization: "apex"}, {Domain: "api.example.com", KeyAuthorization: "api"}}, batched)
+		assert.Equal(t, "_acme-challenge.example.com", challenges[0].fqdn)
+		assert.Equal(t, "_acme-challenge.api.example.com", challenges[1].fqdn)
+		assert.False(t, challenges[1].presentedAt.IsZero())
+	})
+
+	t.Run("presents the challenges one by one", func(t *testing.T) {
+		challenges := newChallenges()
+		require.NoError(t, r.presentDNSChallenges(context.TODO(), logr.Discard(), certRequest.DeepCopy(), FakeAWSClient{}, challenges))
+
+		for _, challenge := range challenges {
+			assert.Equal(t, testHiveACMEDomain, challenge.fqdn)
+			assert.False(t, challenge.presentedAt.IsZero())
+		}
+	})
+}
+
+// fakeChallengeClient is an ACME client recording the authorizations whose challenge is answered.
+type fakeChallengeClient struct {
+	leclient.LetsEncryptClientInterface
+	authorization string
+	answered      []string
+	updateErr     error
+}
+
+func (c *fakeChallengeClient) FetchAuthorization(authURL string) error {
+	c.authorization = authURL
+	return nil
+}
+
+func (c *fakeChallengeClient) SetChallengeType(string) {}
+
+func (c *fakeChallengeClient) GetChallengeURL() string {
+	return c.authorization + "/challenge"
+}
+
+func (c *fakeChallengeClient) UpdateChallenge() error {
+	if c.updateErr != nil {
+		return c.updateErr
+	}
+	c.answered = append(c.answered, c.authorization)
+	return nil
+}
+
+func TestSolveDNSChallenges(t *testing.T) {
+	var challenges []*dnsChallenge
+	for _, domain := range []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com", "f.example.com", "g.example.com"} {
+		challenges = append(challenges, &dnsChallenge{authURL: domain, domain: domain, fqdn: "_acme-challenge." + domain})
+	}
+	r := CertificateRequestReconciler{Recorder: &record.FakeRecorder{}, Client: setUpTestClient(t, []runtime.Object{})}
+
+	t.Run("answers every authorization", func(t *testing.T) {
+		leClient := &fakeChallengeClient{}
+		require.NoError(t, r.solveDNSChallenges(context.TODO(), logr.Discard(), certRequest.DeepCopy(), leClient, challenges, 1, 0, certmanv1alpha1.Platform{}))
+
+		sort.Strings(leClient.answered)
+		assert.Equal(t, []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com", "f.example.com", "g.example.com"}, leClient.answered)
+	})
+
+	t.Run("stops at the first failed challenge", func(t *testing.T) {
+		leClient := &fakeChallengeClient{updateErr: errors.New("challenge is invalid")}
+		err := r.solveDNSChallenges(context.TODO(), logr.Discard(), certRequest.DeepCopy(), leClient, challenges, 1, 0, certmanv1alpha1.Platform{})
+
+		assert.EqualError(t, err, "challenge is invalid")
+		assert.Empty(t, leClient.answered)
+	})
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/mailer"
+)
+
+// email sends a message to the notification addresses of the CertificateRequest, if email notifications are enabled
+// in the operator ConfigMap. Each certificate is emailed once per kind of message, which is only remembered by the
+// operator until it restarts. Failing to send the email does not fail the reconcile, so errors are only logged.
+func (r *CertificateRequestReconciler) email(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate, kind, subject, body string) {
+	to := notificationEmails(cr)
+	if len(to) == 0 {
+		return
+	}
+	key := fmt.Sprintf("%s/%s/%s", cr.UID, certificate.SerialNumber, kind)
+	if _, emailed := r.emailed.Load(key); emailed {
+		return
+	}
+
+	enabled, err := utils.GetEmailNotifications(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the email notification configuration")
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	sender, err := r.MailSenderBuilder(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to create the email sender")
+		return
+	}
+	if err := sender.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		reqLogger.Error(err, "failed to send the email", "Email", kind)
+		return
+	}
+	reqLogger.Info("sent an email to the notification addresses", "Email", kind)
+	r.emailed.Store(key, true)
+}
+
+// emailRenewalWindow emails the notification addresses of the CertificateRequest that its certificate entered its
+// renewal window and is being renewed.
+func (r *CertificateRequestReconciler) emailRenewalWindow(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) {
+	certificate, err := GetCertificate(ctx, r.Client, cr)
+	if err != nil {
+		return
+	}
+	days, err := utils.GetEmailExpiryWarningDays(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the email expiry warning days")
+		return
+	}
+
+	subject := fmt.Sprintf("Certificate for %s is being renewed", certificateName(cr))
+	body := fmt.Sprintf("The certificate for %s expires at %s and entered its renewal window. "+
+		"It is being renewed, no action is needed. You will be emailed again if it is still not renewed %d days before it expires.\n\n%s",
+		strings.Join(cr.Spec.DnsNames, ", "), certificate.NotAfter, days, emailFooter(cr))
+	r.email(ctx, reqLogger, cr, certificate, "renewal-window", subject, body)
+}
+
+// emailExpiryWarning emails the notification addresses of the CertificateRequest that its certificate expires within
+// the days set in the operator ConfigMap without having been renewed.
+func (r *CertificateRequestReconciler) emailExpiryWarning(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate) {
+	days, err := utils.GetEmailExpiryWarningDays(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the email expiry warning days")
+		return
+	}
+	untilExpiry := time.Until(certificate.NotAfter)
+	if untilExpiry > time.Duration(days)*24*time.Hour {
+		return
+	}
+
+	subject := fmt.Sprintf("Certificate for %s expires in %d days", certificateName(cr), max(int(untilExpiry.Hours()/24), 0))
+	lastFailure := ""
+	if cr.Status.LastFailure != nil {
+		lastFailure = fmt.Sprintf(" The last attempt failed with: %s.", cr.Status.LastFailure.Message)
+	}
+	body := fmt.Sprintf("The certificate for %s expires at %s and could not be renewed.%s\n\n"+
+		"Check that the DNS zone of the certificate is still delegated to the DNS provider of the cluster, "+
+		"and that the credentials of the cluster still allow managing its DNS records.\n\n%s",
+		strings.Join(cr.Spec.DnsNames, ", "), certificate.NotAfter, lastFailure, emailFooter(cr))
+	r.email(ctx, reqLogger, cr, certificate, "expiry-warning", subject, body)
+}
+
+// certificateName returns the first DNS name of the certificate of the CertificateRequest, or its name if it has none.
+func certificateName(cr *certmanv1alpha1.CertificateRequest) string {
+	if len(cr.Spec.DnsNames) > 0 {
+		return cr.Spec.DnsNames[0]
+	}
+	return cr.Name
+}
+
+// emailFooter tells the recipient where the email comes from.
+func emailFooter(cr *certmanv1alpha1.CertificateRequest) string {
+	return fmt.Sprintf("This email was sent by certman-operator for CertificateRequest %s/%s.", cr.Namespace, cr.Name)
+}
+
+// notificationEmails returns the contacts of the CertificateRequest: its Email followed by its further Emails, without
+// empty or repeated addresses.
+func notificationEmails(cr *certmanv1alpha1.CertificateRequest) []string {
+	emails := []string{}
+	for _, email := range append([]string{cr.Spec.Email}, cr.Spec.Emails...) {
+		if email != "" && !utils.ContainsString(emails, email) {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/mailer"
+)
+
+type fakeMailSender struct {
+	sent []mailer.Message
+}
+
+func (s *fakeMailSender) Send(_ context.Context, message mailer.Message) error {
+	s.sent = append(s.sent, message)
+	return nil
+}
+
+func TestEmailExpiryWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]string
+		email       string
+		untilExpiry time.Duration
+		expectSent  bool
+	}{
+		{
+			name:        "disabled",
+			email:       "owner@example.com",
+			untilExpiry: 3 * 24 * time.Hour,
+		},
+		{
+			name:        "not expiring",
+			data:        map[string]string{cTypes.EmailNotifications: "true"},
+			email:       "owner@example.com",
+			untilExpiry: 20 * 24 * time.Hour,
+		},
+		{
+			name:        "expiring",
+			data:        map[string]string{cTypes.EmailNotifications: "true"},
+			email:       "owner@example.com",
+			untilExpiry: 3*24*time.Hour + time.Hour,
+			expectSent:  true,
+		},
+		{
+			name:        "expiring within custom warning days",
+			data:        map[string]string{cTypes.EmailNotifications: "true", cTypes.EmailExpiryWarningDays: "2"},
+			email:       "owner@example.com",
+			untilExpiry: 3 * 24 * time.Hour,
+		},
+		{
+			name:        "no notification address",
+			data:        map[string]string{cTypes.EmailNotifications: "true"},
+			untilExpiry: 3 * 24 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+				Data:       test.data,
+			}
+			sender := &fakeMailSender{}
+			r := &CertificateRequestReconciler{
+				Client:            setUpTestClient(t, []runtime.Object{configMap}),
+				MailSenderBuilder: func(context.Context, client.Client) (mailer.Sender, error) { return sender, nil },
+			}
+			cr := certRequest.DeepCopy()
+			cr.Spec.Email = test.email
+			cr.Spec.DnsNames = []string{"api.cluster.example.com"}
+			cr.Status.LastFailure = &certmanv1alpha1.CertificateFailure{Reason: issuanceFailedReason, Message: "AccessDenied", Time: metav1.Now()}
+			certificate := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(test.untilExpiry)}
+
+			// a certificate is only emailed once
+			r.emailExpiryWarning(context.TODO(), logr.Discard(), cr, certificate)
+			r.emailExpiryWarning(context.TODO(), logr.Discard(), cr, certificate)
+
+			if !test.expectSent {
+				assert.Empty(t, sender.sent)
+				return
+			}
+			require.Len(t, sender.sent, 1)
+			assert.Equal(t, []string{"owner@example.com"}, sender.sent[0].To)
+			assert.Equal(t, "Certificate for api.cluster.example.com expires in 3 days", sender.sent[0].Subject)
+			assert.Contains(t, sender.sent[0].Body, "The last attempt failed with: AccessDenied.")
+		})
+	}
+}
+
+func TestEmailRenewalWindow(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.EmailNotifications: "true"},
+	}
+	certPEM, _, err := generateValidCertPEM()
+	require.NoError(t, err)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: certRequest.Spec.CertificateSecret.Name, Namespace: certRequest.Namespace},
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+	}
+	sender := &fakeMailSender{}
+	r := &CertificateRequestReconciler{
+		Client:            setUpTestClient(t, []runtime.Object{configMap, secret}),
+		MailSenderBuilder: func(context.Context, client.Client) (mailer.Sender, error) { return sender, nil },
+	}
+	cr := certRequest.DeepCopy()
+	cr.Spec.Email = "owner@example.com"
+	cr.Spec.Emails = []string{"team@example.com", "owner@example.com"}
+
+	r.emailRenewalWindow(context.TODO(), logr.Discard(), cr)
+	r.emailRenewalWindow(context.TODO(), logr.Discard(), cr)
+
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, []string{"owner@example.com", "team@example.com"}, sender.sent[0].To)
+	assert.Contains(t, sender.sent[0].Subject, "is being renewed")
+	assert.Contains(t, sender.sent[0].Body, "still not renewed 7 days before it expires")
+}
+
+func TestNotificationEmails(t *testing.T) {
+	cr := certRequest.DeepCopy()
+	cr.Spec.Email = ""
+	assert.Empty(t, notificationEmails(cr))
+
+	cr.Spec.Emails = []string{"shared@example.com", "", "team@example.com"}
+	assert.Equal(t, []string{"shared@example.com", "team@example.com"}, notificationEmails(cr))
+
+	cr.Spec.Email = "team@example.com"
+	assert.Equal(t, []string{"team@example.com", "shared@example.com"}, notificationEmails(cr))
+}
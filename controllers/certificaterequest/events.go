@@ -0,0 +1,49 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	// Reasons of the Events that have no matching condition reason
+	challengePresentedReason = "ChallengePresented"
+	dnsPropagatedReason      = "DNSPropagated"
+	renewedReason            = "Renewed"
+	reissuedReason           = "Reissued"
+)
+
+// recordEvent records an Event on the CertificateRequest, and on the ClusterDeployment owning it so that the
+// certificates of a cluster can be followed from its ClusterDeployment.
+func (r *CertificateRequestReconciler) recordEvent(cr *certmanv1alpha1.CertificateRequest, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Recorder.Eventf(cr, eventtype, reason, messageFmt, args...)
+
+	for _, o := range cr.OwnerReferences {
+		if o.Kind != clusterDeploymentType {
+			continue
+		}
+		cd := &hivev1.ClusterDeployment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: o.APIVersion, Kind: o.Kind},
+			ObjectMeta: metav1.ObjectMeta{Name: o.Name, Namespace: cr.Namespace, UID: o.UID},
+		}
+		r.Recorder.Eventf(cd, eventtype, reason, "CertificateRequest %s: "+messageFmt, append([]interface{}{cr.Name}, args...)...)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &CertificateRequestReconciler{Recorder: recorder}
+
+	// the event is also recorded on the owning ClusterDeployment
+	r.recordEvent(certRequest.DeepCopy(), corev1.EventTypeNormal, issuedReason, "certificate issued into secret %s", testHiveSecretName)
+	require.Len(t, recorder.Events, 2)
+	assert.Equal(t, "Normal Issued certificate issued into secret "+testHiveSecretName, <-recorder.Events)
+	assert.Equal(t, "Normal Issued CertificateRequest "+testHiveCertificateRequestName+": certificate issued into secret "+testHiveSecretName, <-recorder.Events)
+
+	// standalone CertificateRequests have no ClusterDeployment
+	standaloneCertRequest := certRequest.DeepCopy()
+	standaloneCertRequest.OwnerReferences = nil
+	r.recordEvent(standaloneCertRequest, corev1.EventTypeWarning, acmeErrorReason, "failed to issue the certificate: %v", "acme: error")
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning AcmeError failed to issue the certificate: acme: error", <-recorder.Events)
+}
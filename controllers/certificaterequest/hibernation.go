@@ -0,0 +1,53 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+const (
+	renewalDeferredConditionType certmanv1alpha1.CertificateRequestConditionType = "RenewalDeferred"
+	renewalDeferredReason                                                        = "ClusterHibernating"
+)
+
+// shouldDeferRenewal returns true if the renewal of a certificate of the ClusterDeployment must wait for the cluster
+// to resume from hibernation.
+func (r *CertificateRequestReconciler) shouldDeferRenewal(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, error) {
+	if cd.Spec.PowerState != hivev1.ClusterPowerStateHibernating {
+		return false, nil
+	}
+
+	policy, err := utils.GetHibernationRenewalPolicy(ctx, r.Client)
+	if err != nil {
+		return false, err
+	}
+	return policy == utils.HibernationRenewalPolicyDefer, nil
+}
+
+// setRenewalDeferredCondition records on the CertificateRequest that its renewal waits for the cluster to resume.
+func (r *CertificateRequestReconciler) setRenewalDeferredCondition(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, cd *hivev1.ClusterDeployment) error {
+	setCondition(cr, renewalDeferredConditionType, metav1.ConditionTrue, renewalDeferredReason, fmt.Sprintf("renewal is deferred until ClusterDeployment %s resumes from hibernation", cd.Name))
+	return r.Client.Status().Update(ctx, cr)
+}
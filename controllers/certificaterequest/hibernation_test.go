@@ -0,0 +1,107 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+func TestReconcileHibernating(t *testing.T) {
+	hibernatingClusterDeployment := clusterDeploymentComplete.DeepCopy()
+	hibernatingClusterDeployment.Spec.PowerState = hivev1.ClusterPowerStateHibernating
+
+	deferConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.HibernationRenewalPolicy: "Defer"},
+	}
+
+	testClient := setUpTestClient(t, []runtime.Object{testLESecret, certRequest.DeepCopy(), hibernatingClusterDeployment, emptyCertSecret, deferConfigMap})
+	r := CertificateRequestReconciler{Client: testClient, Scheme: scheme.Scheme, ClientBuilder: setUpFakeAWSClient}
+
+	// the empty secret would be reissued, which fails without AWS credentials
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}})
+	require.NoError(t, err)
+	assert.NotZero(t, result.RequeueAfter)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, secret))
+	assert.Empty(t, secret.Data)
+
+	cr := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, cr))
+	require.Len(t, cr.Status.Conditions, 1)
+	assert.Equal(t, string(renewalDeferredConditionType), cr.Status.Conditions[0].Type)
+}
+
+func TestShouldDeferRenewal(t *testing.T) {
+	tests := []struct {
+		name       string
+		powerState hivev1.ClusterPowerState
+		policy     string
+		want       bool
+	}{
+		{name: "running cluster", policy: "Defer", want: false},
+		{name: "hibernating cluster with the default policy", powerState: hivev1.ClusterPowerStateHibernating, want: false},
+		{name: "hibernating cluster with the defer policy", powerState: hivev1.ClusterPowerStateHibernating, policy: "Defer", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cd := clusterDeploymentComplete.DeepCopy()
+			cd.Spec.PowerState = test.powerState
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+				Data:       map[string]string{cTypes.HibernationRenewalPolicy: test.policy},
+			}
+			r := CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{configMap})}
+
+			deferRenewal, err := r.shouldDeferRenewal(context.TODO(), cd)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, deferRenewal)
+		})
+	}
+}
+
+func TestClusterDeploymentStateChanged(t *testing.T) {
+	running := clusterDeploymentComplete.DeepCopy()
+	hibernating := running.DeepCopy()
+	hibernating.Spec.PowerState = hivev1.ClusterPowerStateHibernating
+	paused := running.DeepCopy()
+	paused.Annotations = map[string]string{certmanv1alpha1.PausedAnnotation: "true"}
+	relabelled := running.DeepCopy()
+	relabelled.Labels = map[string]string{"foo": "bar"}
+
+	assert.True(t, clusterDeploymentStateChanged.Update(event.UpdateEvent{ObjectOld: hibernating, ObjectNew: running}))
+	assert.True(t, clusterDeploymentStateChanged.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: paused}))
+	assert.False(t, clusterDeploymentStateChanged.Update(event.UpdateEvent{ObjectOld: running, ObjectNew: relabelled}))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"crypto/x509"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+// Triggers of the history entries
+const (
+	secretNotFoundTrigger     = "SecretNotFound"
+	renewalTimeReachedTrigger = "RenewalTimeReached"
+	dnsNamesChangedTrigger    = "DNSNamesChanged"
+	secretUnusableTrigger     = "SecretUnusable"
+	revokeAndReissueTrigger   = "RevokeAndReissueRequested"
+	existingSecretTrigger     = "ExistingSecret"
+)
+
+// newHistoryEntry returns a history entry for action on certificate, which may be nil if it could not be read.
+func newHistoryEntry(action certmanv1alpha1.CertificateAction, trigger string, certificate *x509.Certificate) certmanv1alpha1.CertificateHistoryEntry {
+	entry := certmanv1alpha1.CertificateHistoryEntry{
+		Action:  action,
+		Time:    metav1.Now(),
+		Trigger: trigger,
+	}
+	if certificate != nil {
+		notBefore := metav1.NewTime(certificate.NotBefore)
+		notAfter := metav1.NewTime(certificate.NotAfter)
+		entry.SerialNumber = certificate.SerialNumber.String()
+		entry.NotBefore = &notBefore
+		entry.NotAfter = &notAfter
+	}
+	return entry
+}
+
+// appendHistory appends entry to the history of the CertificateRequest, dropping the oldest entries beyond
+// MaxHistoryEntries. The status is not written.
+func appendHistory(cr *certmanv1alpha1.CertificateRequest, entry certmanv1alpha1.CertificateHistoryEntry) {
+	cr.Status.History = append(cr.Status.History, entry)
+	if excess := len(cr.Status.History) - certmanv1alpha1.MaxHistoryEntries; excess > 0 {
+		cr.Status.History = cr.Status.History[excess:]
+	}
+}
+
+// issuanceEntry returns a history entry for the certificate that the order of leClient stored in certificateSecret.
+func issuanceEntry(reqLogger logr.Logger, action certmanv1alpha1.CertificateAction, trigger string, certificateSecret *corev1.Secret, leClient leclient.LetsEncryptClientInterface) certmanv1alpha1.CertificateHistoryEntry {
+	certificate, err := ParseCertificateData(certificateSecret.Data[corev1.TLSCertKey])
+	if err != nil {
+		reqLogger.Error(err, "failed to parse the issued certificate for the history")
+	}
+	entry := newHistoryEntry(action, trigger, certificate)
+	entry.OrderURL = leClient.GetOrderURL()
+	return entry
+}
+
+// annotationManager returns the field manager that last set annotation on the CertificateRequest, or an empty
+// string if it is unknown.
+func annotationManager(cr *certmanv1alpha1.CertificateRequest, annotation string) string {
+	manager := ""
+	var managerTime *metav1.Time
+	for _, managedFields := range cr.ManagedFields {
+		if managedFields.FieldsV1 == nil || !strings.Contains(string(managedFields.FieldsV1.Raw), `"f:`+annotation+`"`) {
+			continue
+		}
+		if managerTime == nil || (managedFields.Time != nil && managerTime.Before(managedFields.Time)) {
+			manager = managedFields.Manager
+			managerTime = managedFields.Time
+		}
+	}
+	return manager
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestAppendHistory(t *testing.T) {
+	cr := certRequest.DeepCopy()
+	for i := 0; i < certmanv1alpha1.MaxHistoryEntries+5; i++ {
+		appendHistory(cr, certmanv1alpha1.CertificateHistoryEntry{Action: certmanv1alpha1.CertificateRenewed, SerialNumber: fmt.Sprint(i)})
+	}
+
+	assert.Len(t, cr.Status.History, certmanv1alpha1.MaxHistoryEntries)
+	// the oldest entries are dropped
+	assert.Equal(t, "5", cr.Status.History[0].SerialNumber)
+	assert.Equal(t, fmt.Sprint(certmanv1alpha1.MaxHistoryEntries+4), cr.Status.History[certmanv1alpha1.MaxHistoryEntries-1].SerialNumber)
+}
+
+func TestAnnotationManager(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	later := metav1.Now()
+	annotationFields := &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:` + revokeAndReissueAnnotation + `":{}}}}`)}
+
+	cr := certRequest.DeepCopy()
+	assert.Empty(t, annotationManager(cr, revokeAndReissueAnnotation))
+
+	cr.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "certman-operator", Time: &later, FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:finalizers":{}}}`)}},
+		{Manager: "kubectl-annotate", Time: &earlier, FieldsV1: annotationFields},
+		{Manager: "ocm-backplane", Time: &later, FieldsV1: annotationFields},
+	}
+	assert.Equal(t, "ocm-backplane", annotationManager(cr, revokeAndReissueAnnotation))
+}
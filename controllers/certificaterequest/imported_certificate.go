@@ -0,0 +1,251 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/fips"
+)
+
+const (
+	importedReason                    = "Imported"
+	invalidImportedCertificateReason  = "InvalidImportedCertificate"
+	importedCertificateExpiringReason = "ImportedCertificateExpiring"
+)
+
+// reconcileImportedCertificate copies the certificate provided in the imported secret of the CertificateRequest into
+// its certificate secret, instead of ordering one from the ACME server. The certificate must pass the same checks as
+// an issued one, with the ca.crt of the imported secret trusted besides the system roots. Imported certificates are
+// never renewed: once one reaches its renewal time an Event warns that it must be replaced.
+func (r *CertificateRequestReconciler) reconcileImportedCertificate(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (reconcile.Result, error) {
+	data, err := r.getImportedCertificateData(ctx, reqLogger, cr)
+	if err != nil {
+		reqLogger.Error(err, "imported certificate is invalid")
+		r.recordEvent(cr, corev1.EventTypeWarning, invalidImportedCertificateReason, "imported certificate is invalid: %v", err)
+		cr.Status.Issued = false
+		cr.Status.Status = "Error"
+		cr.Status.ObservedGeneration = cr.Generation
+		setCondition(cr, certmanv1alpha1.CertificateRequestReady, metav1.ConditionFalse, invalidImportedCertificateReason, err.Error())
+		if updateErr := r.Client.Status().Update(ctx, cr); updateErr != nil {
+			reqLogger.Error(updateErr, "failed to update CertificateRequest status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	certificateSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.CertificateSecret.Name, Namespace: cr.Namespace}, certificateSecret)
+	create := errors.IsNotFound(err)
+	if create {
+		certificateSecret = newSecret(cr)
+		if err := controllerutil.SetControllerReference(cr, certificateSecret, r.Scheme); err != nil {
+			return reconcile.Result{}, err
+		}
+	} else if err != nil {
+		return reconcile.Result{}, err
+	} else if err := r.adoptCertificateSecret(ctx, reqLogger, cr, certificateSecret); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	changed := applySecretTemplate(cr, certificateSecret)
+	imported := !importedDataMatches(certificateSecret.Data, data.secretData)
+	if imported {
+		if certificateSecret.Data == nil {
+			certificateSecret.Data = map[string][]byte{}
+		}
+		for k, v := range data.secretData {
+			certificateSecret.Data[k] = v
+		}
+		if err := r.addKeystores(ctx, cr, certificateSecret.Data, data.certs, data.key); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if create || imported || changed {
+		reqLogger.Info("writing imported certificate", "Secret.Name", certificateSecret.Name)
+		if err := r.writeSecret(ctx, certificateSecret, create); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if imported {
+		r.recordEvent(cr, corev1.EventTypeNormal, importedReason, "certificate imported from secret %s into secret %s", cr.Spec.ImportedSecret.Name, certificateSecret.Name)
+	}
+
+	if err := r.syncDeliveries(ctx, reqLogger, cr, certificateSecret); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err := r.updateStatus(ctx, reqLogger, cr); err != nil {
+		reqLogger.Error(err, "Failed to update CertificateRequest status")
+	}
+
+	jitterWindow, err := utils.GetRenewalJitterWindow(ctx, r.Client)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if renewalTime, err := getRenewalTime(cr, data.certs[0], jitterWindow); err != nil {
+		reqLogger.Error(err, "failed to determine the certificate renewal time")
+	} else if !time.Now().Before(renewalTime) {
+		reqLogger.Info("imported certificate reached its renewal time", "NotAfter", data.certs[0].NotAfter)
+		r.recordEvent(cr, corev1.EventTypeWarning, importedCertificateExpiringReason, "imported certificate expires at %s and must be replaced in secret %s", data.certs[0].NotAfter, cr.Spec.ImportedSecret.Name)
+	}
+
+	return r.nextRenewalCheck(ctx, reqLogger, cr), nil
+}
+
+// importedCertificate is the validated content of the imported secret of a CertificateRequest, with the data of
+// the certificate secret it is copied into.
+type importedCertificate struct {
+	certs      []*x509.Certificate
+	key        crypto.Signer
+	secretData map[string][]byte
+}
+
+// getImportedCertificateData reads and validates the certificate, private key and optional CA of the imported secret
+// of the CertificateRequest. The certificates of ca.crt complete the chain of tls.crt.
+func (r *CertificateRequestReconciler) getImportedCertificateData(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (*importedCertificate, error) {
+	if cr.Spec.ImportedSecret.Name == cr.Spec.CertificateSecret.Name {
+		return nil, fmt.Errorf("imported secret %s cannot be the certificate secret", cr.Spec.ImportedSecret.Name)
+	}
+
+	imported := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.ImportedSecret.Name, Namespace: cr.Namespace}, imported); err != nil {
+		return nil, fmt.Errorf("failed to get imported secret %s: %w", cr.Spec.ImportedSecret.Name, err)
+	}
+	r.labelCachedSecret(ctx, reqLogger, imported)
+
+	certs, err := parseCertificates(imported.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", corev1.TLSCertKey, err)
+	}
+	cas, err := parseCertificates(imported.Data[caCertSecretKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", caCertSecretKey, err)
+	}
+	for _, ca := range cas {
+		if !containsCertificate(certs, ca) {
+			certs = append(certs, ca)
+		}
+	}
+
+	key, err := parsePrivateKey(imported.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", corev1.TLSPrivateKeyKey, err)
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system roots: %w", err)
+	}
+	for _, ca := range cas {
+		roots.AddCert(ca)
+	}
+
+	if err := validateIssuedCertificate(cr, certs, key, roots); err != nil {
+		return nil, err
+	}
+	if fips.Enabled() {
+		if err := fips.CheckPrivateKey(key); err != nil {
+			return nil, err
+		}
+		if err := fips.CheckCertificates(certs); err != nil {
+			return nil, err
+		}
+	}
+
+	keyBytes, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &importedCertificate{certs: certs, key: key, secretData: certificateSecretData(cr, certs, keyBytes)}, nil
+}
+
+// parseCertificates decodes every PEM encoded certificate of data.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// importedDataMatches returns true if the certificate secret data already holds the imported certificate. Keystores
+// are left out since they are encoded with a random salt.
+func importedDataMatches(current, imported map[string][]byte) bool {
+	for k, v := range imported {
+		if !bytes.Equal(current[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// certificateRequestsForImportedSecret enqueues the CertificateRequests importing the secret, so that a replaced
+// certificate is copied straight away.
+func (r *CertificateRequestReconciler) certificateRequestsForImportedSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list CertificateRequests")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, cr := range crList.Items {
+		if cr.Spec.ImportedSecret != nil && cr.Spec.ImportedSecret.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+		}
+	}
+	return requests
+}
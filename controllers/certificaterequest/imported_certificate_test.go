@@ -0,0 +1,193 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestReconcileImportedCertificate(t *testing.T) {
+	dnsNames := certRequest.Spec.DnsNames
+
+	root := newTestCertificate(t, newTestCATemplate(1, "Test"), nil)
+	intermediate := newTestCertificate(t, newTestCATemplate(2, "Test"), root)
+	leaf := newTestCertificate(t, newTestLeafTemplate(dnsNames, time.Now().Add(24*time.Hour)), intermediate)
+	otherLeaf := newTestCertificate(t, newTestLeafTemplate([]string{"other.example.com"}, time.Now().Add(24*time.Hour)), intermediate)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encodeCerts := func(certs ...*x509.Certificate) []byte {
+		var data []byte
+		for _, c := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+		}
+		return data
+	}
+	encodeKey := func(key *ecdsa.PrivateKey) []byte {
+		data, err := encodePrivateKey(key)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name           string
+		data           map[string][]byte
+		renewBefore    time.Duration
+		expectError    string
+		expectedReason string
+		expectedEvents []string
+	}{
+		{
+			name: "copies a valid certificate",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(leaf.cert, intermediate.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(leaf.key.(*ecdsa.PrivateKey)),
+				caCertSecretKey:         encodeCerts(root.cert),
+			},
+			renewBefore:    time.Hour,
+			expectedReason: importedReason,
+			expectedEvents: []string{importedReason},
+		},
+		{
+			name: "completes the chain from the CA",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(leaf.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(leaf.key.(*ecdsa.PrivateKey)),
+				caCertSecretKey:         encodeCerts(intermediate.cert, root.cert),
+			},
+			renewBefore:    time.Hour,
+			expectedReason: importedReason,
+			expectedEvents: []string{importedReason},
+		},
+		{
+			name: "warns about a certificate to replace",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(leaf.cert, intermediate.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(leaf.key.(*ecdsa.PrivateKey)),
+				caCertSecretKey:         encodeCerts(root.cert),
+			},
+			renewBefore:    24 * time.Hour,
+			expectedReason: importedReason,
+			expectedEvents: []string{importedReason, importedCertificateExpiringReason},
+		},
+		{
+			name: "rejects a certificate not matching the key",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(leaf.cert, intermediate.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(otherKey),
+				caCertSecretKey:         encodeCerts(root.cert),
+			},
+			expectError:    "does not match the private key",
+			expectedReason: invalidImportedCertificateReason,
+			expectedEvents: []string{invalidImportedCertificateReason},
+		},
+		{
+			name: "rejects a certificate not covering the DNS names",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(otherLeaf.cert, intermediate.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(otherLeaf.key.(*ecdsa.PrivateKey)),
+				caCertSecretKey:         encodeCerts(root.cert),
+			},
+			expectError:    "does not cover requested DNS name",
+			expectedReason: invalidImportedCertificateReason,
+			expectedEvents: []string{invalidImportedCertificateReason},
+		},
+		{
+			name: "rejects a chain to an untrusted root",
+			data: map[string][]byte{
+				corev1.TLSCertKey:       encodeCerts(leaf.cert, intermediate.cert),
+				corev1.TLSPrivateKeyKey: encodeKey(leaf.key.(*ecdsa.PrivateKey)),
+			},
+			expectError:    "failed to verify issued certificate chain",
+			expectedReason: invalidImportedCertificateReason,
+			expectedEvents: []string{invalidImportedCertificateReason},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Spec.ImportedSecret = &corev1.LocalObjectReference{Name: "customer-certificate"}
+			if tt.renewBefore != 0 {
+				cr.Spec.RenewBefore = &metav1.Duration{Duration: tt.renewBefore}
+			}
+			importedSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "customer-certificate", Namespace: cr.Namespace},
+				Type:       corev1.SecretTypeTLS,
+				Data:       tt.data,
+			}
+
+			testClient := setUpTestClient(t, []runtime.Object{cr, importedSecret})
+			recorder := record.NewFakeRecorder(10)
+			r := &CertificateRequestReconciler{Client: testClient, Scheme: scheme.Scheme, Recorder: recorder}
+
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, cr))
+			_, err := r.reconcileImportedCertificate(context.TODO(), logr.Discard(), cr)
+
+			stored := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, stored))
+			ready := meta.FindStatusCondition(stored.Status.Conditions, string(certmanv1alpha1.CertificateRequestReady))
+			require.NotNil(t, ready)
+			assert.Equal(t, tt.expectedReason, ready.Reason)
+
+			// every Event is also recorded on the ClusterDeployment owning the CertificateRequest
+			close(recorder.Events)
+			reasons := map[string]int{}
+			for event := range recorder.Events {
+				reasons[strings.Fields(event)[1]]++
+			}
+			for _, reason := range tt.expectedEvents {
+				assert.Equal(t, 2, reasons[reason], "Events with reason %s", reason)
+			}
+
+			certificateSecret := &corev1.Secret{}
+			secretErr := testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: testHiveSecretName}, certificateSecret)
+			if tt.expectError != "" {
+				assert.ErrorContains(t, err, tt.expectError)
+				assert.Error(t, secretErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, secretErr)
+			assert.True(t, stored.Status.Issued)
+			assert.Equal(t, encodeCerts(leaf.cert, intermediate.cert), certificateSecret.Data[corev1.TLSCertKey])
+			assert.Equal(t, tt.data[corev1.TLSPrivateKeyKey], certificateSecret.Data[corev1.TLSPrivateKeyKey])
+			assert.True(t, metav1.IsControlledBy(certificateSecret, stored))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/throttle"
+)
+
+const (
+	throttledReason = "IssuanceLimitReached"
+
+	defaultIssuanceAccount = "default"
+)
+
+// issuanceThrottle limits the new orders of all CertificateRequests, so it is shared by every reconcile.
+var issuanceThrottle = throttle.New()
+
+// issuanceThrottledError is returned when a new order is refused by the issuance throttle.
+type issuanceThrottledError struct {
+	account string
+	wait    time.Duration
+}
+
+func (e *issuanceThrottledError) Error() string {
+	return fmt.Sprintf("new orders of ACME account %s are throttled for %s", e.account, e.wait.Round(time.Second))
+}
+
+// IssuanceAccount names the ACME account the CertificateRequest places its orders with.
+func IssuanceAccount(cr *certmanv1alpha1.CertificateRequest) string {
+	if cr.Spec.IssuerRef == nil {
+		return defaultIssuanceAccount
+	}
+	return "certissuer/" + cr.Spec.IssuerRef.Name
+}
+
+// takeIssuanceToken takes a token for a new order from the issuance throttle of the CertificateRequest's ACME
+// account, returning an issuanceThrottledError if the hourly or weekly budget is exhausted.
+func (r *CertificateRequestReconciler) takeIssuanceToken(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) error {
+	limits, err := utils.GetIssuanceLimits(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	account := IssuanceAccount(cr)
+	ok, wait := issuanceThrottle.Take(account, limits)
+	for window, tokens := range issuanceThrottle.Remaining(account) {
+		localmetrics.SetIssuanceTokensRemaining(account, window, tokens)
+	}
+
+	if !ok {
+		return &issuanceThrottledError{account: account, wait: wait}
+	}
+	return nil
+}
+
+// parkIfThrottled sets the RateLimited condition of a CertificateRequest whose new order was refused by the issuance
+// throttle, and requeues it for when a token is available. It returns false if err is any other error.
+func (r *CertificateRequestReconciler) parkIfThrottled(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) (reconcile.Result, bool) {
+	var throttled *issuanceThrottledError
+	if !errors.As(err, &throttled) {
+		return reconcile.Result{}, false
+	}
+
+	reqLogger.Info("issuance is throttled, requeueing", "Account", throttled.account, "RequeueAfter", throttled.wait)
+	setCondition(cr, certmanv1alpha1.CertificateRequestRateLimited, metav1.ConditionTrue, throttledReason, throttled.Error())
+	r.recordEvent(cr, corev1.EventTypeWarning, throttledReason, "%s, requeueing", throttled.Error())
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		reqLogger.Error(err, "failed to set the RateLimited condition")
+	}
+
+	return reconcile.Result{RequeueAfter: throttled.wait}, true
+}
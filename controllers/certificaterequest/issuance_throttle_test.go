@@ -0,0 +1,92 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/throttle"
+)
+
+func TestTakeIssuanceToken(t *testing.T) {
+	issuanceThrottle = throttle.New()
+	t.Cleanup(func() { issuanceThrottle = throttle.New() })
+
+	limitsConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.MaxOrdersPerHour: "1"},
+	}
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{limitsConfigMap})}
+
+	cr := certRequest.DeepCopy()
+	require.NoError(t, r.takeIssuanceToken(context.TODO(), cr))
+
+	err := r.takeIssuanceToken(context.TODO(), cr)
+	var throttled *issuanceThrottledError
+	require.True(t, errors.As(err, &throttled))
+	assert.Equal(t, defaultIssuanceAccount, throttled.account)
+	assert.InDelta(t, time.Hour, throttled.wait, float64(time.Second))
+
+	// a CertIssuer has its own ACME account and budget
+	cr.Spec.IssuerRef = &corev1.LocalObjectReference{Name: "internal-ca"}
+	assert.NoError(t, r.takeIssuanceToken(context.TODO(), cr))
+}
+
+func TestParkIfThrottled(t *testing.T) {
+	cr := certRequest.DeepCopy()
+	recorder := record.NewFakeRecorder(10)
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{cr}), Recorder: recorder}
+
+	_, parked := r.parkIfThrottled(context.TODO(), logr.Discard(), cr, errors.New("acme error"))
+	assert.False(t, parked)
+
+	throttledErr := &issuanceThrottledError{account: defaultIssuanceAccount, wait: 10 * time.Minute}
+	for i := 0; i < 2; i++ {
+		result, parked := r.parkIfThrottled(context.TODO(), logr.Discard(), cr, throttledErr)
+		assert.True(t, parked)
+		assert.Equal(t, 10*time.Minute, result.RequeueAfter)
+	}
+
+	updated := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, updated))
+	require.Len(t, updated.Status.Conditions, 1)
+	assert.Equal(t, string(certmanv1alpha1.CertificateRequestRateLimited), updated.Status.Conditions[0].Type)
+	assert.Equal(t, metav1.ConditionTrue, updated.Status.Conditions[0].Status)
+	assert.Equal(t, throttledReason, updated.Status.Conditions[0].Reason)
+	// each park is recorded on the CertificateRequest and on its ClusterDeployment
+	require.Len(t, recorder.Events, 4)
+	assert.Contains(t, <-recorder.Events, "Warning IssuanceLimitReached new orders of ACME account default are throttled")
+	assert.Contains(t, <-recorder.Events, "Warning IssuanceLimitReached CertificateRequest "+cr.Name+": new orders of ACME account default are throttled")
+
+	assert.True(t, removeCondition(updated, certmanv1alpha1.CertificateRequestRateLimited))
+	assert.Empty(t, updated.Status.Conditions)
+}
@@ -18,26 +18,31 @@ package certificaterequest
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
-	"flag"
 	"fmt"
 	"path/filepath"
 	"strings"
 
 	"github.com/go-logr/logr"
 	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/fips"
 	"github.com/openshift/certman-operator/pkg/leclient"
 	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+	"github.com/openshift/certman-operator/pkg/tracing"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 )
 
@@ -48,33 +53,71 @@ const (
 // IssueCertificate validates DNS write access then assess letsencrypt endpoint (prod or stage) based on leclient url.
 // It then iterates through the CertificateRequest.Spec.DnsNames, authorizes to letsencrypt and sets a challenge in the
 // form of resource record. Certificates are then generated and issued to kubernetes via corev1.
-func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret, leClient leclient.LetsEncryptClientInterface) error {
+func (r *CertificateRequestReconciler) IssueCertificate(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret, leClient leclient.LetsEncryptClientInterface) (err error) {
 	timer := prometheus.NewTimer(localmetrics.MetricIssueCertificateDuration)
 
 	defer timer.ObserveDuration()
 
-	// Get DNS client from CR.
-	dnsClient, err := r.getClient(reqLogger, cr)
+	ctx, span := tracing.Start(ctx, "IssueCertificate", traceAttributes(cr)...)
+	defer func() { tracing.End(span, err) }()
+	leClient.SetTraceContext(ctx)
+
+	rsaKeySize, err := r.getRSAKeySize(ctx, cr)
 	if err != nil {
-		reqLogger.Error(err, err.Error())
+		reqLogger.Error(err, "invalid key size")
 		return err
 	}
 
-	proceed, err := dnsClient.ValidateDNSWriteAccess(reqLogger, cr)
-	if err != nil {
-		reqLogger.Error(err, "failed to validate dns write access")
+	if cr.Spec.Duration != nil && cr.Spec.Duration.Duration <= 0 {
+		err = fmt.Errorf("duration must be positive, got %s", cr.Spec.Duration.Duration)
+		reqLogger.Error(err, "invalid duration")
 		return err
 	}
 
-	if proceed {
-		reqLogger.Info("write permissions for DNS has been validated")
-	} else {
-		err = errors.New("failed to get write access to DNS record")
-		reqLogger.Error(err, "failed to get write access to DNS record")
+	// a certificate is only ordered once it can be copied to every secret replica
+	if _, err = r.getSecretReplicas(ctx, cr, certificateSecret); err != nil {
+		reqLogger.Error(err, "invalid secret replicas")
 		return err
 	}
 
-	err = leClient.UpdateAccount(cr.Spec.Email)
+	// Get DNS client from CR, unless every DNS name is proven with an http-01 challenge or the issuer is a
+	// private CA that needs no challenges.
+	privateCA := leclient.IsPrivateCAClient(leClient)
+	var dnsClient cClient.Client
+	var platform certmanv1alpha1.Platform
+	if usesDNS01(cr) && !privateCA {
+		dnsClient, err = r.getClient(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return err
+		}
+
+		platform, err = r.getPlatform(ctx, cr)
+		if err != nil {
+			return err
+		}
+
+		if err := r.discoverACMEDNSZone(ctx, reqLogger, cr, dnsClient); err != nil {
+			reqLogger.Error(err, "failed to discover the DNS zone of the challenges")
+			return err
+		}
+
+		proceed, err := dnsClient.ValidateDNSWriteAccess(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, "failed to validate dns write access")
+			return err
+		}
+
+		if proceed {
+			reqLogger.Info("write permissions for DNS has been validated")
+		} else {
+			err = fmt.Errorf("%w: failed to get write access to DNS records in zone %s", cTypes.ErrInsufficientPermissions, utils.ACMEDNSZone(cr))
+			reqLogger.Error(err, "failed to get write access to DNS record")
+			return err
+		}
+	}
+
+	err = leClient.UpdateAccount(notificationEmails(cr)...)
 	if err != nil {
 		// if letsencrypt is down, return a better message and update the metric
 		if strings.Contains(err.Error(), leMaintMessage) {
@@ -90,15 +133,35 @@ func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, c
 
 	certDomains = append(certDomains, cr.Spec.DnsNames...)
 
+	propagationCheckAttempts, propagationCheckInterval, err := utils.GetDNSPropagationCheck(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	err = r.takeIssuanceToken(ctx, cr)
+	if err != nil {
+		return err
+	}
+
 	err = leClient.CreateOrder(cr.Spec.DnsNames)
 	if err != nil {
 		reqLogger.Error(err, "failed to create order")
 		return err
 	}
 	URL := leClient.GetOrderURL()
-	reqLogger.Info("created a new order with Let's Encrypt.", "URL", URL)
+	reqLogger = reqLogger.WithValues(logging.ACMEOrder, URL)
+	reqLogger.Info("created a new order with Let's Encrypt.")
+	r.reportProgress(ctx, reqLogger, cr, certmanv1alpha1.CertificateRequestIssuing, metav1.ConditionTrue, orderCreatedReason, fmt.Sprintf("order %s was created", URL))
+	r.recordEvent(cr, corev1.EventTypeNormal, orderCreatedReason, "created order %s", URL)
 
+	var challenges []*dnsChallenge
 	for _, authURL := range leClient.OrderAuthorization() {
+		// the acme client takes no context, so a cancelled reconcile is only noticed between its requests, each of
+		// which is bounded by the client's own http timeout
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		err := leClient.FetchAuthorization(authURL)
 		if err != nil {
 			reqLogger.Error(err, "could not fetch authorizations")
@@ -109,58 +172,81 @@ func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, c
 		if domErr != nil {
 			return fmt.Errorf("could not read domain for authorization")
 		}
-		leClient.SetChallengeType()
+		authLogger := reqLogger.WithValues(logging.Domain, domain)
+
+		dnsName := domain
+		if leClient.IsWildcardAuthorization() {
+			dnsName = wildcardPrefix + domain
+		}
+		challengeType := challengeTypeFor(cr, dnsName)
+		leClient.SetChallengeType(string(challengeType))
+
+		if challengeType == certmanv1alpha1.HTTP01ChallengeType {
+			if err := r.answerHTTP01Challenge(authLogger, cr, leClient, dnsName); err != nil {
+				return err
+			}
+			authLogger.Info("challenge successfully completed")
+			continue
+		}
 
 		DNS01KeyAuthorization, keyAuthErr := leClient.GetDNS01KeyAuthorization()
 		if keyAuthErr != nil {
 			return fmt.Errorf("could not get authorization key for dns challenge")
 		}
+		challenges = append(challenges, &dnsChallenge{authURL: authURL, domain: domain, keyAuthorization: DNS01KeyAuthorization})
+	}
 
-		var fqdn string
-		dnsZone, err := r.FindZoneIDForChallenge(cr.Namespace, dnsClient)
-		if err != nil {
-			return err
+	// the DNS-01 challenges are presented up front and answered as their records propagate, so that the propagation
+	// of the records of a multi-SAN certificate is waited for once rather than once per domain
+	_, batch := dnsClient.(cClient.BatchClient)
+	for _, round := range challengeRounds(challenges, batch) {
+		if len(round) == 0 {
+			continue
 		}
-
-		fqdn, err = dnsClient.AnswerDNSChallenge(reqLogger, DNS01KeyAuthorization, domain, cr, dnsZone)
-		if err != nil {
+		if err := r.presentDNSChallenges(ctx, reqLogger, cr, dnsClient, round); err != nil {
 			return err
 		}
-
-		// don't try verifying DNS while in testing
-		// TODO refactor VerifyDnsResourceRecordUpdate() to accept a mock client interface
-		if flag.Lookup("test.v") == nil {
-			dnsChangesVerified := VerifyDnsResourceRecordUpdate(reqLogger, fqdn, DNS01KeyAuthorization)
-			if !dnsChangesVerified {
-				return fmt.Errorf("cannot complete Let's Encrypt challenege as DNS changes could not be verified")
-			}
-		}
-
-		reqLogger.Info(fmt.Sprintf("updating challenge for authorization %v: %v", domain, leClient.GetChallengeURL()))
-		err = leClient.UpdateChallenge()
-		if err != nil {
-			reqLogger.Error(err, fmt.Sprintf("error updating authorization %s challenge: %v", domain, err))
+		if err := r.solveDNSChallenges(ctx, reqLogger, cr, leClient, round, propagationCheckAttempts, propagationCheckInterval, platform); err != nil {
 			return err
 		}
-
-		reqLogger.Info("challenge successfully completed")
+	}
+	if !privateCA {
+		r.reportProgress(ctx, reqLogger, cr, certmanv1alpha1.CertificateRequestDNSVerified, metav1.ConditionTrue, challengesCompletedReason, "the challenges of the order were completed")
 	}
 
-	reqLogger.Info("generating new key")
-
-	certKey, err := rsa.GenerateKey(rand.Reader, rSAKeyBitSize)
+	var certKey crypto.Signer
+	var kmsKeyID string
+	previousKMSKeyID := string(certificateSecret.Data[kmsKeyIDSecretKey])
+	if cr.Spec.KMS != nil {
+		certKey, kmsKeyID, err = r.getKMSKey(ctx, reqLogger, cr, certificateSecret, rsaKeySize)
+	} else {
+		certKey, err = getPrivateKey(reqLogger, cr, certificateSecret, rsaKeySize)
+	}
 	if err != nil {
 		return err
 	}
 
+	if fips.Enabled() {
+		if err := fips.CheckPrivateKey(certKey); err != nil {
+			return err
+		}
+	}
+
 	reqLogger.Info("creating certificate signing request")
 
+	// the signature algorithm is left unset so it is derived from the type of certKey
 	tpl := &x509.CertificateRequest{
-		SignatureAlgorithm: x509.SHA256WithRSA,
-		PublicKeyAlgorithm: x509.RSA,
-		PublicKey:          certKey.Public(),
-		Subject:            pkix.Name{CommonName: certDomains[0]},
-		DNSNames:           certDomains,
+		PublicKey: certKey.Public(),
+		Subject:   pkix.Name{CommonName: certDomains[0]},
+		DNSNames:  certDomains,
+	}
+
+	if cr.Spec.MustStaple {
+		extension, err := mustStapleExtension()
+		if err != nil {
+			return err
+		}
+		tpl.ExtraExtensions = append(tpl.ExtraExtensions, extension)
 	}
 
 	csrDer, err := x509.CreateCertificateRequest(rand.Reader, tpl, certKey)
@@ -175,6 +261,9 @@ func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, c
 
 	reqLogger.Info("finalizing order")
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	err = leClient.FinalizeOrder(csr)
 	if err != nil {
 		return err
@@ -187,6 +276,114 @@ func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, c
 		return err
 	}
 
+	// the mock acme client returns a placeholder certificate that would never validate
+	if !leclient.IsMockClient(leClient) {
+		roots, err := r.getTrustedRoots(ctx, cr)
+		if err != nil {
+			return err
+		}
+
+		// returning here leaves the secret untouched, so the next reconcile places a new order
+		err = validateIssuedCertificate(cr, certs, certKey, roots)
+		if err != nil {
+			reqLogger.Error(err, "issued certificate failed validation")
+			return err
+		}
+
+		if fips.Enabled() {
+			if err := fips.CheckCertificates(certs); err != nil {
+				reqLogger.Error(err, "issued certificate is not FIPS compliant")
+				return err
+			}
+		}
+	}
+
+	if cr.Spec.Duration != nil && len(certs) > 0 {
+		if lifetime := certs[0].NotAfter.Sub(certs[0].NotBefore); lifetime > cr.Spec.Duration.Duration {
+			reqLogger.Info("certificate authority issued a certificate valid for longer than requested", "lifetime", lifetime.String(), "requested", cr.Spec.Duration.Duration.String())
+		}
+	}
+
+	if cr.Spec.MustStaple && len(certs) > 0 && !hasMustStaple(certs[0]) {
+		reqLogger.Info("certificate authority did not honor the OCSP must-staple request, the issued certificate does not require stapled OCSP responses")
+	}
+
+	verifySCTs, err := utils.GetVerifyCertificateTransparency(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	verifyInclusion, err := utils.GetVerifyCTInclusion(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	// certificates of private CAs are not logged to certificate transparency
+	if (verifySCTs || verifyInclusion) && !privateCA {
+		ctLogs, err := r.getCTLogs(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = verifyCertificateTransparency(reqLogger, ctLogs, certs)
+		if err != nil {
+			return err
+		}
+
+		// returning here leaves the secret untouched, so the next reconcile places a new order
+		if verifyInclusion {
+			err = verifyCTInclusion(ctx, reqLogger, ctLogs, certs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	applySecretTemplate(cr, certificateSecret)
+
+	if kmsKeyID != "" {
+		// tls.key is required by kubernetes.io/tls secrets, consumers of KMS keys read kms.key-id instead
+		certificateSecret.Data = certificateSecretData(cr, certs, []byte{})
+		certificateSecret.Data[kmsKeyIDSecretKey] = []byte(kmsKeyID)
+	} else {
+		key, err := encodePrivateKey(certKey)
+		if err != nil {
+			return err
+		}
+
+		certificateSecret.Data = certificateSecretData(cr, certs, key)
+
+		err = r.addKeystores(ctx, cr, certificateSecret.Data, certs, certKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a key scheduled for deletion can no longer sign, consumers pick up the new key with the updated secret
+	if previousKMSKeyID != "" && previousKMSKeyID != kmsKeyID && cr.Spec.KMS != nil {
+		if err := r.deleteKMSKey(ctx, reqLogger, cr, previousKMSKeyID); err != nil {
+			reqLogger.Error(err, "failed to schedule deletion of the previous KMS key")
+		}
+	}
+
+	reqLogger.Info("certificates are now available")
+
+	// After resolving all new challenges, and storing the cert, delete the challenge records
+	// that were used from dns in this zone.
+	if dnsClient != nil {
+		err = dnsClient.DeleteAcmeChallengeResourceRecords(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, "error occurred deleting acme challenge resource records from %v", dnsClient.GetDNSName())
+			localmetrics.IncrementDNSCleanupFailures()
+		}
+	}
+
+	return nil
+}
+
+// certificateSecretData returns the data of the certificate secret: the fullchain, the PEM encoded private key
+// and the issuing chain, plus the combined PEM when the CertificateRequest asks for it.
+func certificateSecretData(cr *certmanv1alpha1.CertificateRequest, certs []*x509.Certificate, key []byte) map[string][]byte {
 	var pemData []string
 
 	for _, c := range certs {
@@ -196,42 +393,81 @@ func (r *CertificateRequestReconciler) IssueCertificate(reqLogger logr.Logger, c
 		})))
 	}
 
-	key := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(certKey),
-	})
+	fullchain := []byte(pemData[0] + pemData[1])
 
-	certificateSecret.Labels = map[string]string{
-		"certificate_request": cr.Name,
+	data := map[string][]byte{
+		corev1.TLSCertKey:       fullchain,
+		corev1.TLSPrivateKeyKey: key,
+		caCertSecretKey:         []byte(strings.Join(pemData[1:], "")), // issuing chain without the leaf
 	}
 
-	certificateSecret.Data = map[string][]byte{
-		corev1.TLSCertKey:       []byte(pemData[0] + pemData[1]), // create fullchain
-		corev1.TLSPrivateKeyKey: key,
+	if cr.Spec.CombinedPEM {
+		data[combinedPEMSecretKey] = append(append([]byte{}, key...), fullchain...)
 	}
 
-	reqLogger.Info("certificates are now available")
+	return data
+}
 
-	// After resolving all new challenges, and storing the cert, delete the challenge records
-	// that were used from dns in this zone.
-	err = dnsClient.DeleteAcmeChallengeResourceRecords(reqLogger, cr)
+// verifyCertificateTransparency ensures the leaf certificate carries at least one embedded SCT,
+// proving it has been submitted to a Certificate Transparency log. The logs of the SCTs are named after ctLogs.
+func verifyCertificateTransparency(reqLogger logr.Logger, ctLogs leclient.CTLogs, certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return errors.New("no certificates returned by the acme server")
+	}
+
+	scts, err := leclient.GetEmbeddedSCTs(certs[0])
 	if err != nil {
-		reqLogger.Error(err, "error occurred deleting acme challenge resource records from %v", dnsClient.GetDNSName())
+		return fmt.Errorf("failed to parse signed certificate timestamps: %w", err)
+	}
+
+	if len(scts) == 0 {
+		return errors.New("issued certificate has no embedded signed certificate timestamps")
+	}
+
+	ctLogs.NameSCTs(scts)
+	for _, sct := range scts {
+		reqLogger.Info("found signed certificate timestamp", "LogID", sct.LogID, "LogName", sct.LogName, "Timestamp", sct.Timestamp)
 	}
 
 	return nil
 }
 
-func (r *CertificateRequestReconciler) FindZoneIDForChallenge(namespace string, dnsClient cClient.Client) (string, error) {
+// challengeZoneID returns the ID of the zone the challenge records of cr are written in, or an empty string for the
+// DNS client to find the zone of the domain itself. The Hive DNSZone of the cluster is hosted by the provider of its
+// platform, so it is not used by an overridden DNS provider, by standalone clusters or by clusters without a
+// DNSZone.
+func (r *CertificateRequestReconciler) challengeZoneID(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, dnsClient cClient.Client) (string, error) {
+	if cr.Spec.DNSProvider != nil {
+		return "", nil
+	}
+
+	if !fedramp {
+		if r.Standalone {
+			return "", nil
+		}
+
+		dnsZones := hivev1.DNSZoneList{}
+		if err := r.Client.List(ctx, &dnsZones, client.InNamespace(cr.Namespace)); err != nil {
+			return "", err
+		}
+		if len(dnsZones.Items) == 0 {
+			return "", nil
+		}
+	}
+
+	return r.FindZoneIDForChallenge(ctx, cr.Namespace, dnsClient)
+}
+
+func (r *CertificateRequestReconciler) FindZoneIDForChallenge(ctx context.Context, namespace string, dnsClient cClient.Client) (string, error) {
 	if fedramp {
-		fedrampZoneid, err := dnsClient.GetFedrampHostedZoneIDPath(fedrampHostedZoneID)
+		fedrampZoneid, err := dnsClient.GetFedrampHostedZoneIDPath(ctx, fedrampHostedZoneID)
 		if err != nil {
 			return "", err
 		}
 		return fedrampZoneid, err
 	}
 	dnsZones := hivev1.DNSZoneList{}
-	err := r.Client.List(context.TODO(), &dnsZones, &client.ListOptions{Namespace: namespace})
+	err := r.Client.List(ctx, &dnsZones, &client.ListOptions{Namespace: namespace})
 	if err != nil {
 		return "", err
 	}
@@ -18,6 +18,8 @@ package certificaterequest
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"reflect"
 	"strings"
 	"testing"
@@ -31,6 +33,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
@@ -106,10 +109,11 @@ func TestIssueCertificate(t *testing.T) {
 			}
 
 			rcr := CertificateRequestReconciler{
+				Recorder:      &record.FakeRecorder{},
 				Client:        testClient,
 				ClientBuilder: setUpFakeAWSClient,
 			}
-			testErr := rcr.IssueCertificate(nullLogger, cr, s, test.LEClient)
+			testErr := rcr.IssueCertificate(context.TODO(), nullLogger, cr, s, test.LEClient)
 			if err != nil && !test.ExpectError {
 				t.Errorf("got unexpected error: %s", err)
 			}
@@ -273,13 +277,14 @@ func TestFindZoneIDForChallenge(t *testing.T) {
 
 			testClient := setUpTestClient(t, tc.KubeObjects)
 			reconciler := &CertificateRequestReconciler{
-				Client: testClient,
+				Recorder: &record.FakeRecorder{},
+				Client:   testClient,
 			}
 
 			mockClient := &dnschallenge.MockClient{
 				FedrampHostedZoneID: tc.fedrampHostedZoneID,
 			}
-			zoneID, err := reconciler.FindZoneIDForChallenge("test", mockClient)
+			zoneID, err := reconciler.FindZoneIDForChallenge(context.TODO(), "test", mockClient)
 
 			if err == nil && tc.expectedError {
 				t.Fatalf("got no error when expecting an error")
@@ -301,3 +306,59 @@ func TestFindZoneIDForChallenge(t *testing.T) {
 		})
 	}
 }
+
+func TestCertificateSecretData(t *testing.T) {
+	leaf := &x509.Certificate{Raw: []byte("leaf")}
+	intermediate := &x509.Certificate{Raw: []byte("intermediate")}
+	root := &x509.Certificate{Raw: []byte("root")}
+	key := []byte("key")
+
+	encode := func(certs ...*x509.Certificate) string {
+		var encoded string
+		for _, c := range certs {
+			encoded += string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name         string
+		combinedPEM  bool
+		expectedData map[string]string
+	}{
+		{
+			name: "writes the fullchain, key and issuing chain",
+			expectedData: map[string]string{
+				v1.TLSCertKey:       encode(leaf, intermediate),
+				v1.TLSPrivateKeyKey: string(key),
+				caCertSecretKey:     encode(intermediate, root),
+			},
+		},
+		{
+			name:        "adds the combined pem when requested",
+			combinedPEM: true,
+			expectedData: map[string]string{
+				v1.TLSCertKey:        encode(leaf, intermediate),
+				v1.TLSPrivateKeyKey:  string(key),
+				caCertSecretKey:      encode(intermediate, root),
+				combinedPEMSecretKey: string(key) + encode(leaf, intermediate),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{CombinedPEM: test.combinedPEM}}
+
+			data := certificateSecretData(cr, []*x509.Certificate{leaf, intermediate, root}, key)
+
+			actualData := map[string]string{}
+			for k, v := range data {
+				actualData[k] = string(v)
+			}
+			if !reflect.DeepEqual(actualData, test.expectedData) {
+				t.Errorf("certificateSecretData() = %v, want %v", actualData, test.expectedData)
+			}
+		})
+	}
+}
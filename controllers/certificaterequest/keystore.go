@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	pkcs12SecretKey = "keystore.p12"
+	jksSecretKey    = "keystore.jks"
+
+	// keystoreAlias is the alias of the private key entry in JKS keystores
+	keystoreAlias = "certificate"
+)
+
+// addKeystores writes the keystores requested by the CertificateRequest into the certificate secret data.
+func (r *CertificateRequestReconciler) addKeystores(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, data map[string][]byte, certs []*x509.Certificate, key crypto.Signer) error {
+	if cr.Spec.Keystores == nil {
+		return nil
+	}
+
+	if cr.Spec.Keystores.PKCS12 != nil {
+		password, err := r.getKeystorePassword(ctx, cr, cr.Spec.Keystores.PKCS12)
+		if err != nil {
+			return err
+		}
+
+		// the legacy encryption profile is used as it can be read by older Java runtimes
+		p12, err := pkcs12.LegacyDES.Encode(key, certs[0], certs[1:], password)
+		if err != nil {
+			return fmt.Errorf("failed to encode PKCS#12 keystore: %w", err)
+		}
+		data[pkcs12SecretKey] = p12
+	}
+
+	if cr.Spec.Keystores.JKS != nil {
+		password, err := r.getKeystorePassword(ctx, cr, cr.Spec.Keystores.JKS)
+		if err != nil {
+			return err
+		}
+
+		jks, err := encodeJKS(key, certs, []byte(password))
+		if err != nil {
+			return fmt.Errorf("failed to encode JKS keystore: %w", err)
+		}
+		data[jksSecretKey] = jks
+	}
+
+	return nil
+}
+
+// getKeystorePassword reads the keystore password from the secret referenced by options.
+func (r *CertificateRequestReconciler) getKeystorePassword(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, options *certmanv1alpha1.KeystoreOptions) (string, error) {
+	ref := options.PasswordSecretRef
+
+	secret, err := GetSecret(ctx, r.Client, ref.Name, cr.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get keystore password secret %s: %w", ref.Name, err)
+	}
+
+	password, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("keystore password secret %s has no key %s", ref.Name, ref.Key)
+	}
+
+	return string(password), nil
+}
+
+// encodeJKS returns a Java keystore holding key and its certificate chain, protected by password.
+func encodeJKS(key crypto.Signer, certs []*x509.Certificate, password []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]keystore.Certificate, 0, len(certs))
+	for _, c := range certs {
+		chain = append(chain, keystore.Certificate{Type: "X509", Content: c.Raw})
+	}
+
+	ks := keystore.New()
+	err = ks.SetPrivateKeyEntry(keystoreAlias, keystore.PrivateKeyEntry{
+		CreationTime:     time.Now(),
+		PrivateKey:       der,
+		CertificateChain: chain,
+	}, password)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := ks.Store(buf, password); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
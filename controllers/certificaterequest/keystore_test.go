@@ -0,0 +1,129 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pavlo-v-chernykh/keystore-go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"software.sslmate.com/src/go-pkcs12"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestAddKeystores(t *testing.T) {
+	const password = "changeit"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "keystore.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	passwordSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystore-password", Namespace: testHiveNamespace},
+		Data:       map[string][]byte{"password": []byte(password)},
+	}
+	keystoreOptions := func(key string) *certmanv1alpha1.KeystoreOptions {
+		return &certmanv1alpha1.KeystoreOptions{
+			PasswordSecretRef: certmanv1alpha1.SecretKeyReference{Name: passwordSecret.Name, Key: key},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		keystores    *certmanv1alpha1.CertificateKeystores
+		expectedKeys []string
+		expectError  bool
+	}{
+		{
+			name:         "no keystores requested",
+			expectedKeys: []string{},
+		},
+		{
+			name:         "writes pkcs12 and jks keystores",
+			keystores:    &certmanv1alpha1.CertificateKeystores{PKCS12: keystoreOptions("password"), JKS: keystoreOptions("password")},
+			expectedKeys: []string{pkcs12SecretKey, jksSecretKey},
+		},
+		{
+			name:        "errors when the password key is missing",
+			keystores:   &certmanv1alpha1.CertificateKeystores{PKCS12: keystoreOptions("missing")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{passwordSecret})}
+			cr := &certmanv1alpha1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testHiveNamespace},
+				Spec:       certmanv1alpha1.CertificateRequestSpec{Keystores: test.keystores},
+			}
+
+			data := map[string][]byte{}
+			err := r.addKeystores(context.TODO(), cr, data, []*x509.Certificate{certificate, certificate}, key)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			keys := []string{}
+			for k := range data {
+				keys = append(keys, k)
+			}
+			assert.ElementsMatch(t, test.expectedKeys, keys)
+
+			if p12, ok := data[pkcs12SecretKey]; ok {
+				decodedKey, decodedCert, _, err := pkcs12.DecodeChain(p12, password)
+				require.NoError(t, err)
+				assert.True(t, key.Equal(decodedKey))
+				assert.Equal(t, certificate.Raw, decodedCert.Raw)
+			}
+
+			if jks, ok := data[jksSecretKey]; ok {
+				ks := keystore.New()
+				require.NoError(t, ks.Load(bytes.NewReader(jks), []byte(password)))
+				entry, err := ks.GetPrivateKeyEntry(keystoreAlias, []byte(password))
+				require.NoError(t, err)
+				assert.Len(t, entry.CertificateChain, 2)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"errors"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/kms"
+)
+
+// getKMSClient returns the client of the key management service holding the private key of the CertificateRequest.
+func (r *CertificateRequestReconciler) getKMSClient(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (kms.Client, error) {
+	return r.KMSClientBuilder(ctx, r.Client, cr.Spec.KMS, cr.Namespace)
+}
+
+// getKMSKey returns a signer for the KMS key to issue the certificate with, and the identifier of that key. Under
+// the Never rotation policy the key referenced by certificateSecret is reused if it matches the requested algorithm
+// and size; a revoke-and-reissue always creates a new key.
+func (r *CertificateRequestReconciler) getKMSKey(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret, rsaKeySize int) (crypto.Signer, string, error) {
+	// both are written to the certificate secret and would require the private key
+	if cr.Spec.CombinedPEM || cr.Spec.Keystores != nil {
+		return nil, "", errors.New("combinedPEM and keystores cannot be used with a KMS key")
+	}
+
+	kmsClient, err := r.getKMSClient(ctx, cr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, revoking := cr.Annotations[revokeAndReissueAnnotation]
+	existingKeyID := string(certificateSecret.Data[kmsKeyIDSecretKey])
+	if cr.Spec.RotationPolicy == certmanv1alpha1.NeverRotationPolicy && !revoking && existingKeyID != "" {
+		existingKey, err := kmsClient.Signer(existingKeyID)
+		if err != nil {
+			reqLogger.Error(err, "failed to get the existing KMS key, creating a new one")
+		} else if privateKeyMatchesSpec(existingKey, cr, rsaKeySize) {
+			reqLogger.Info("reusing existing KMS key", "KeyID", existingKeyID)
+			return existingKey, existingKeyID, nil
+		} else {
+			reqLogger.Info("existing KMS key does not match the requested key algorithm or size, creating a new one")
+		}
+	}
+
+	keyID, err := kmsClient.CreateKey(cr, rsaKeySize)
+	if err != nil {
+		return nil, "", err
+	}
+	reqLogger.Info("created KMS key", "KeyID", keyID)
+
+	key, err := kmsClient.Signer(keyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, keyID, nil
+}
+
+// deleteKMSKey schedules the deletion of a KMS key that is no longer referenced by the certificate secret.
+func (r *CertificateRequestReconciler) deleteKMSKey(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, keyID string) error {
+	kmsClient, err := r.getKMSClient(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	reqLogger.Info("scheduling deletion of KMS key", "KeyID", keyID)
+	return kmsClient.ScheduleKeyDeletion(keyID)
+}
+
+// deleteCertificateSecretKMSKey schedules the deletion of the KMS key referenced by the certificate secret of a
+// CertificateRequest being deleted.
+func (r *CertificateRequestReconciler) deleteCertificateSecretKMSKey(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	if cr.Spec.KMS == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: cr.Spec.CertificateSecret.Name, Namespace: cr.Namespace}, secret)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	keyID := string(secret.Data[kmsKeyIDSecretKey])
+	if keyID == "" {
+		return nil
+	}
+
+	return r.deleteKMSKey(ctx, reqLogger, cr, keyID)
+}
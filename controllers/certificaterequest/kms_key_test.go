@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/kms"
+)
+
+// fakeKMSClient holds ECDSA P-256 keys in memory.
+type fakeKMSClient struct {
+	keys        map[string]*ecdsa.PrivateKey
+	deletedKeys []string
+}
+
+func (f *fakeKMSClient) CreateKey(cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	keyID := fmt.Sprintf("key-%d", len(f.keys))
+	f.keys[keyID] = key
+	return keyID, nil
+}
+
+func (f *fakeKMSClient) Signer(keyID string) (crypto.Signer, error) {
+	key, ok := f.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+	return key, nil
+}
+
+func (f *fakeKMSClient) ScheduleKeyDeletion(keyID string) error {
+	f.deletedKeys = append(f.deletedKeys, keyID)
+	return nil
+}
+
+func TestGetKMSKey(t *testing.T) {
+	existingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		rotationPolicy certmanv1alpha1.PrivateKeyRotationPolicy
+		combinedPEM    bool
+		expectedKeyID  string
+		expectError    bool
+	}{
+		{name: "creates a new key on every renewal", expectedKeyID: "key-1"},
+		{name: "reuses the existing key", rotationPolicy: certmanv1alpha1.NeverRotationPolicy, expectedKeyID: "existing"},
+		{name: "rejects combinedPEM", combinedPEM: true, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kmsClient := &fakeKMSClient{keys: map[string]*ecdsa.PrivateKey{"existing": existingKey}}
+			r := &CertificateRequestReconciler{
+				Recorder: &record.FakeRecorder{},
+				KMSClientBuilder: func(context.Context, client.Client, *certmanv1alpha1.KMSKey, string) (kms.Client, error) {
+					return kmsClient, nil
+				},
+			}
+			cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{
+				KeyAlgorithm:   certmanv1alpha1.ECDSAKeyAlgorithm,
+				RotationPolicy: test.rotationPolicy,
+				CombinedPEM:    test.combinedPEM,
+				KMS:            &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}},
+			}}
+			secret := &corev1.Secret{Data: map[string][]byte{kmsKeyIDSecretKey: []byte("existing")}}
+
+			key, keyID, err := r.getKMSKey(context.TODO(), logr.Discard(), cr, secret, rSAKeyBitSize)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedKeyID, keyID)
+			assert.True(t, kmsClient.keys[keyID].PublicKey.Equal(key.Public()))
+		})
+	}
+}
+
+func TestDeleteCertificateSecretKMSKey(t *testing.T) {
+	kmsClient := &fakeKMSClient{}
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cr", Namespace: testHiveNamespace},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			CertificateSecret: corev1.ObjectReference{Name: testHiveSecretName},
+			KMS:               &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testHiveSecretName, Namespace: testHiveNamespace},
+		Data:       map[string][]byte{kmsKeyIDSecretKey: []byte("existing")},
+	}
+	r := &CertificateRequestReconciler{
+		Recorder: &record.FakeRecorder{},
+		Client:   setUpTestClient(t, []runtime.Object{secret}),
+		KMSClientBuilder: func(context.Context, client.Client, *certmanv1alpha1.KMSKey, string) (kms.Client, error) {
+			return kmsClient, nil
+		},
+	}
+
+	require.NoError(t, r.deleteCertificateSecretKMSKey(context.TODO(), logr.Discard(), cr))
+	assert.Equal(t, []string{"existing"}, kmsClient.deletedKeys)
+}
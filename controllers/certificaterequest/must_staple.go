@@ -0,0 +1,57 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"slices"
+)
+
+// tlsFeatureExtensionOID is the TLS feature extension defined in RFC 7633.
+var tlsFeatureExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// statusRequestTLSFeature is the status_request TLS extension, which requires stapled OCSP responses.
+const statusRequestTLSFeature = 5
+
+// mustStapleExtension returns the TLS feature extension requesting OCSP must-staple.
+func mustStapleExtension() (pkix.Extension, error) {
+	value, err := asn1.Marshal([]int{statusRequestTLSFeature})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: tlsFeatureExtensionOID, Value: value}, nil
+}
+
+// hasMustStaple returns true if certificate carries a TLS feature extension requiring OCSP must-staple.
+func hasMustStaple(certificate *x509.Certificate) bool {
+	for _, extension := range certificate.Extensions {
+		if !extension.Id.Equal(tlsFeatureExtensionOID) {
+			continue
+		}
+
+		var features []int
+		if _, err := asn1.Unmarshal(extension.Value, &features); err != nil {
+			return false
+		}
+		return slices.Contains(features, statusRequestTLSFeature)
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func TestMustStapleExtension(t *testing.T) {
+	extension, err := mustStapleExtension()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// SEQUENCE { INTEGER 5 }
+	expected := []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+	if !bytes.Equal(extension.Value, expected) {
+		t.Errorf("mustStapleExtension() value = %x, want %x", extension.Value, expected)
+	}
+
+	tests := []struct {
+		desc       string
+		extensions []pkix.Extension
+		want       bool
+	}{
+		{
+			desc: "certificate without tls feature extension",
+			want: false,
+		},
+		{
+			desc:       "certificate with must-staple",
+			extensions: []pkix.Extension{extension},
+			want:       true,
+		},
+		{
+			desc:       "tls feature extension without status_request",
+			extensions: []pkix.Extension{{Id: tlsFeatureExtensionOID, Value: []byte{0x30, 0x03, 0x02, 0x01, 0x11}}},
+			want:       false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := hasMustStaple(&x509.Certificate{Extensions: test.extensions}); got != test.want {
+				t.Errorf("hasMustStaple() = %v, want = %v", got, test.want)
+			}
+		})
+	}
+}
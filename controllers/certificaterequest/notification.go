@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/notification"
+)
+
+const (
+	// expiryNotificationWindow is how long before its expiry a certificate that was not renewed is notified, the
+	// same as the CertmanCertificateExpiringSoon alert.
+	expiryNotificationWindow = 14 * 24 * time.Hour
+)
+
+// notify sends an event of the CertificateRequest to the notification webhook configured in the operator ConfigMap,
+// if any, and returns true if it was sent. Failing to send it does not fail the reconcile, so errors are only logged.
+func (r *CertificateRequestReconciler) notify(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, event notification.Event) bool {
+	format, payloadTemplate, err := utils.GetNotificationWebhook(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the notification webhook configuration")
+		return false
+	}
+	if format == "" {
+		return false
+	}
+
+	sink, err := r.NotificationSinkBuilder(ctx, r.Client, format, payloadTemplate)
+	if err != nil {
+		reqLogger.Error(err, "failed to create the notification sink")
+		return false
+	}
+
+	event.Namespace = cr.Namespace
+	event.CertificateRequest = cr.Name
+	event.ClusterDeployment = utils.ClusterDeploymentName(cr)
+	event.DNSNames = cr.Spec.DnsNames
+	event.Time = time.Now()
+	if err := sink.Send(ctx, event); err != nil {
+		reqLogger.Error(err, "failed to send the notification", "Event", event.Type)
+		return false
+	}
+	return true
+}
+
+// notifyIssuanceFailure notifies the first failed attempt to issue the certificate of the CertificateRequest since it
+// was last issued, so that the retries of a streak of failures are not notified.
+func (r *CertificateRequestReconciler) notifyIssuanceFailure(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) {
+	if cr.Status.FailureCount != 1 || cr.Status.LastFailure == nil {
+		return
+	}
+	r.notify(ctx, reqLogger, cr, notification.Event{
+		Type:    notification.EventIssuanceFailed,
+		Summary: "Certificate issuance failed",
+		Message: cr.Status.LastFailure.Message,
+	})
+}
+
+// notifyRenewal notifies the renewal of the certificate of the CertificateRequest.
+func (r *CertificateRequestReconciler) notifyRenewal(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate) {
+	r.notify(ctx, reqLogger, cr, notification.Event{
+		Type:     notification.EventRenewed,
+		Summary:  "Certificate renewed",
+		Message:  fmt.Sprintf("the certificate is valid until %s", certificate.NotAfter),
+		NotAfter: &certificate.NotAfter,
+	})
+}
+
+// notifyExpiry notifies that the certificate of the CertificateRequest expires within expiryNotificationWindow. Each
+// certificate is notified once, which is only remembered by the operator until it restarts.
+func (r *CertificateRequestReconciler) notifyExpiry(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate) {
+	untilExpiry := time.Until(certificate.NotAfter)
+	if untilExpiry > expiryNotificationWindow {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", cr.UID, certificate.SerialNumber)
+	if _, notified := r.expiryNotified.Load(key); notified {
+		return
+	}
+
+	summary := "Certificate has expired"
+	if untilExpiry > 0 {
+		summary = fmt.Sprintf("Certificate expires in %d days", int(untilExpiry.Hours()/24))
+	}
+	sent := r.notify(ctx, reqLogger, cr, notification.Event{
+		Type:     notification.EventExpiringSoon,
+		Summary:  summary,
+		Message:  fmt.Sprintf("the certificate was not renewed and expires at %s", certificate.NotAfter),
+		NotAfter: &certificate.NotAfter,
+	})
+	if sent {
+		r.expiryNotified.Store(key, true)
+	}
+}
+
+// notifyFailure sends the notifications of a failed attempt to issue the certificate of the CertificateRequest: the
+// first failure of a streak, its escalation to PagerDuty, and the notification and email of the expiry of the
+// certificate the failures keep from being renewed.
+func (r *CertificateRequestReconciler) notifyFailure(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) {
+	r.notifyIssuanceFailure(ctx, reqLogger, cr)
+
+	// a certificate that was never issued cannot expire
+	certificate, err := GetCertificate(ctx, r.Client, cr)
+	if err != nil {
+		r.escalateToPagerDuty(ctx, reqLogger, cr, nil)
+		return
+	}
+	r.escalateToPagerDuty(ctx, reqLogger, cr, certificate)
+	r.notifyExpiry(ctx, reqLogger, cr, certificate)
+	r.emailExpiryWarning(ctx, reqLogger, cr, certificate)
+}
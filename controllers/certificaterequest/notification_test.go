@@ -0,0 +1,114 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/notification"
+)
+
+type fakeNotificationSink struct {
+	sent []notification.Event
+}
+
+func (s *fakeNotificationSink) Send(_ context.Context, event notification.Event) error {
+	s.sent = append(s.sent, event)
+	return nil
+}
+
+func newNotifyingReconciler(t *testing.T, format string) (*CertificateRequestReconciler, *fakeNotificationSink) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.NotificationWebhookFormat: format},
+	}
+	sink := &fakeNotificationSink{}
+	return &CertificateRequestReconciler{
+		Client: setUpTestClient(t, []runtime.Object{configMap}),
+		NotificationSinkBuilder: func(_ context.Context, _ client.Client, f, _ string) (notification.Sink, error) {
+			assert.Equal(t, format, f)
+			return sink, nil
+		},
+	}, sink
+}
+
+func TestNotifyIssuanceFailure(t *testing.T) {
+	r, sink := newNotifyingReconciler(t, notification.FormatSlack)
+	cr := certRequest.DeepCopy()
+	cr.Status.LastFailure = &certmanv1alpha1.CertificateFailure{Reason: acmeErrorReason, Message: "acme: error code 403", Time: metav1.Now()}
+
+	// only the first failure of a streak is notified
+	for count := int32(1); count <= 3; count++ {
+		cr.Status.FailureCount = count
+		r.notifyIssuanceFailure(context.TODO(), logr.Discard(), cr)
+	}
+
+	require.Len(t, sink.sent, 1)
+	event := sink.sent[0]
+	assert.Equal(t, notification.EventIssuanceFailed, event.Type)
+	assert.Equal(t, cr.Namespace, event.Namespace)
+	assert.Equal(t, cr.Name, event.CertificateRequest)
+	assert.Equal(t, testHiveClusterDeploymentName, event.ClusterDeployment)
+	assert.Equal(t, "acme: error code 403", event.Message)
+	assert.False(t, event.Time.IsZero())
+}
+
+func TestNotifyExpiry(t *testing.T) {
+	r, sink := newNotifyingReconciler(t, notification.FormatJSON)
+	cr := certRequest.DeepCopy()
+
+	valid := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(60 * 24 * time.Hour)}
+	r.notifyExpiry(context.TODO(), logr.Discard(), cr, valid)
+	assert.Empty(t, sink.sent)
+
+	expiring := &x509.Certificate{SerialNumber: big.NewInt(2), NotAfter: time.Now().Add(10*24*time.Hour + time.Hour)}
+	r.notifyExpiry(context.TODO(), logr.Discard(), cr, expiring)
+	r.notifyExpiry(context.TODO(), logr.Discard(), cr, expiring)
+	require.Len(t, sink.sent, 1, "a certificate is notified once")
+	assert.Equal(t, notification.EventExpiringSoon, sink.sent[0].Type)
+	assert.Equal(t, "Certificate expires in 10 days", sink.sent[0].Summary)
+	assert.Equal(t, expiring.NotAfter, *sink.sent[0].NotAfter)
+
+	expired := &x509.Certificate{SerialNumber: big.NewInt(3), NotAfter: time.Now().Add(-time.Hour)}
+	r.notifyExpiry(context.TODO(), logr.Discard(), cr, expired)
+	require.Len(t, sink.sent, 2)
+	assert.Equal(t, "Certificate has expired", sink.sent[1].Summary)
+}
+
+func TestNotifyDisabled(t *testing.T) {
+	r, sink := newNotifyingReconciler(t, "")
+	r.NotificationSinkBuilder = nil
+
+	cr := certRequest.DeepCopy()
+	r.notifyRenewal(context.TODO(), logr.Discard(), cr, &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now()})
+	assert.Empty(t, sink.sent)
+}
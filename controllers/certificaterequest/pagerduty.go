@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/pagerduty"
+)
+
+const (
+	pagerDutyIncidentOpenedReason   = "PagerDutyIncidentOpened"
+	pagerDutyIncidentResolvedReason = "PagerDutyIncidentResolved"
+	pagerDutyFailedReason           = "PagerDutyFailed"
+)
+
+// escalateToPagerDuty opens a PagerDuty incident for the CertificateRequest once its certificate has failed to be
+// issued for longer than the failure duration set in the operator ConfigMap, or once its certificate, which may be
+// nil if it was never issued, is within the expiry days set there of its expiry. The incident is resolved once
+// neither holds anymore. Failing to reach PagerDuty does not fail the reconcile, so errors are only logged.
+func (r *CertificateRequestReconciler) escalateToPagerDuty(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate) {
+	failureDuration, expiryDays, err := utils.GetPagerDutyEscalation(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the PagerDuty escalation configuration")
+		return
+	}
+
+	incident := pagerDutyIncident(cr, certificate, failureDuration, expiryDays, time.Now())
+	switch {
+	case incident != nil && cr.Status.PagerDutyIncident == nil:
+		r.openIncident(ctx, reqLogger, cr, *incident)
+	case incident == nil && cr.Status.PagerDutyIncident != nil:
+		r.resolveIncident(ctx, reqLogger, cr)
+	}
+}
+
+// pagerDutyIncident returns the incident to open for the CertificateRequest at now, or nil if there is none. A zero
+// failureDuration or expiryDays disables the escalation of failures or expiries respectively.
+func pagerDutyIncident(cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate, failureDuration time.Duration, expiryDays int, now time.Time) *pagerduty.Incident {
+	source := fmt.Sprintf("%s/%s", cr.Namespace, cr.Name)
+	details := map[string]string{
+		"certificateRequest": source,
+		"dnsNames":           strings.Join(cr.Spec.DnsNames, ","),
+	}
+	if clusterDeploymentName := utils.ClusterDeploymentName(cr); clusterDeploymentName != "" {
+		details["clusterDeployment"] = clusterDeploymentName
+	}
+	if cr.Status.LastFailure != nil {
+		details["lastFailure"] = cr.Status.LastFailure.Message
+	}
+	if certificate != nil {
+		details["notAfter"] = certificate.NotAfter.String()
+	}
+
+	incident := &pagerduty.Incident{
+		DedupKey: pagerDutyDedupKey(cr),
+		Source:   source,
+		Details:  details,
+	}
+
+	if expiryDays > 0 && certificate != nil && certificate.NotAfter.Sub(now) <= time.Duration(expiryDays)*24*time.Hour {
+		incident.Severity = pagerduty.SeverityCritical
+		incident.Summary = fmt.Sprintf("Certificate of %s expires at %s and was not renewed", source, certificate.NotAfter)
+		return incident
+	}
+
+	if failure := cr.Status.LastFailure; failureDuration > 0 && failure != nil {
+		since := failure.Time
+		if failure.Since != nil {
+			since = *failure.Since
+		}
+		if now.Sub(since.Time) >= failureDuration {
+			incident.Severity = pagerduty.SeverityError
+			incident.Summary = fmt.Sprintf("Certificate of %s has failed to be issued since %s", source, since.Time)
+			return incident
+		}
+	}
+
+	return nil
+}
+
+// pagerDutyDedupKey returns the key of the PagerDuty incident of the CertificateRequest.
+func pagerDutyDedupKey(cr *certmanv1alpha1.CertificateRequest) string {
+	return fmt.Sprintf("certman/%s/%s", cr.Namespace, cr.Name)
+}
+
+// openIncident triggers the incident and records it in the status of the CertificateRequest, so that it is triggered
+// once and resolved later.
+func (r *CertificateRequestReconciler) openIncident(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, incident pagerduty.Incident) {
+	pd, err := r.PagerDutyClientBuilder(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to create the PagerDuty client")
+		r.recordEvent(cr, corev1.EventTypeWarning, pagerDutyFailedReason, "failed to open a PagerDuty incident: %v", err)
+		return
+	}
+
+	if err := pd.Trigger(ctx, incident); err != nil {
+		reqLogger.Error(err, "failed to open a PagerDuty incident")
+		r.recordEvent(cr, corev1.EventTypeWarning, pagerDutyFailedReason, "failed to open a PagerDuty incident: %v", err)
+		return
+	}
+
+	reqLogger.Info("opened a PagerDuty incident", "Summary", incident.Summary)
+	r.recordEvent(cr, corev1.EventTypeWarning, pagerDutyIncidentOpenedReason, "opened a PagerDuty incident: %s", incident.Summary)
+
+	// an incident that is not recorded is triggered again, which PagerDuty deduplicates
+	now := metav1.Now()
+	cr.Status.PagerDutyIncident = &now
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		reqLogger.Error(err, "failed to record the PagerDuty incident in the CertificateRequest status")
+	}
+}
+
+// resolveIncident resolves the incident of the CertificateRequest and removes it from its status. An incident of a
+// CertificateRequest whose operator no longer has a PagerDuty secret cannot be resolved, and is only removed.
+func (r *CertificateRequestReconciler) resolveIncident(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) {
+	pd, err := r.PagerDutyClientBuilder(ctx, r.Client)
+	switch {
+	case kerrors.IsNotFound(err):
+		reqLogger.Info("not resolving the PagerDuty incident, the PagerDuty secret was removed")
+	case err != nil:
+		reqLogger.Error(err, "failed to create the PagerDuty client")
+		r.recordEvent(cr, corev1.EventTypeWarning, pagerDutyFailedReason, "failed to resolve the PagerDuty incident: %v", err)
+		return
+	default:
+		if err := pd.Resolve(ctx, pagerDutyDedupKey(cr)); err != nil {
+			reqLogger.Error(err, "failed to resolve the PagerDuty incident")
+			r.recordEvent(cr, corev1.EventTypeWarning, pagerDutyFailedReason, "failed to resolve the PagerDuty incident: %v", err)
+			return
+		}
+		reqLogger.Info("resolved the PagerDuty incident")
+		r.recordEvent(cr, corev1.EventTypeNormal, pagerDutyIncidentResolvedReason, "resolved the PagerDuty incident")
+	}
+
+	cr.Status.PagerDutyIncident = nil
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		reqLogger.Error(err, "failed to remove the PagerDuty incident from the CertificateRequest status")
+	}
+}
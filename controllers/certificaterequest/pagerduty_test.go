@@ -0,0 +1,231 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/pagerduty"
+)
+
+type fakePagerDutyClient struct {
+	triggered []pagerduty.Incident
+	resolved  []string
+	err       error
+}
+
+func (c *fakePagerDutyClient) Trigger(_ context.Context, incident pagerduty.Incident) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.triggered = append(c.triggered, incident)
+	return nil
+}
+
+func (c *fakePagerDutyClient) Resolve(_ context.Context, dedupKey string) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.resolved = append(c.resolved, dedupKey)
+	return nil
+}
+
+func TestPagerDutyIncident(t *testing.T) {
+	now := time.Now()
+	failingSince := metav1.NewTime(now.Add(-7 * time.Hour))
+	failure := &certmanv1alpha1.CertificateFailure{Reason: issuanceFailedReason, Message: "AccessDenied", Time: metav1.NewTime(now), Since: &failingSince}
+	legacyFailure := &certmanv1alpha1.CertificateFailure{Reason: issuanceFailedReason, Message: "AccessDenied", Time: failingSince}
+
+	tests := []struct {
+		name             string
+		lastFailure      *certmanv1alpha1.CertificateFailure
+		notAfter         time.Time
+		failureDuration  time.Duration
+		expiryDays       int
+		expectedSeverity string
+	}{
+		{
+			name:        "disabled",
+			lastFailure: failure,
+			notAfter:    now.Add(time.Hour),
+		},
+		{
+			name:             "failing for longer than the failure duration",
+			lastFailure:      failure,
+			notAfter:         now.Add(30 * 24 * time.Hour),
+			failureDuration:  6 * time.Hour,
+			expectedSeverity: pagerduty.SeverityError,
+		},
+		{
+			name:             "failure without the start of its streak",
+			lastFailure:      legacyFailure,
+			failureDuration:  6 * time.Hour,
+			expectedSeverity: pagerduty.SeverityError,
+		},
+		{
+			name:            "failing for less than the failure duration",
+			lastFailure:     failure,
+			failureDuration: 12 * time.Hour,
+		},
+		{
+			name:             "expires within the expiry days",
+			notAfter:         now.Add(2 * 24 * time.Hour),
+			expiryDays:       3,
+			expectedSeverity: pagerduty.SeverityCritical,
+		},
+		{
+			name:       "expires after the expiry days",
+			notAfter:   now.Add(30 * 24 * time.Hour),
+			expiryDays: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Status.LastFailure = test.lastFailure
+			var certificate *x509.Certificate
+			if !test.notAfter.IsZero() {
+				certificate = &x509.Certificate{NotAfter: test.notAfter}
+			}
+
+			incident := pagerDutyIncident(cr, certificate, test.failureDuration, test.expiryDays, now)
+			if test.expectedSeverity == "" {
+				assert.Nil(t, incident)
+				return
+			}
+			require.NotNil(t, incident)
+			assert.Equal(t, test.expectedSeverity, incident.Severity)
+			assert.Equal(t, "certman/"+cr.Namespace+"/"+cr.Name, incident.DedupKey)
+			assert.Equal(t, cr.Namespace+"/"+cr.Name, incident.Source)
+		})
+	}
+}
+
+func TestEscalateToPagerDuty(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.PagerDutyFailureDuration: "6h"},
+	}
+	failingSince := metav1.NewTime(time.Now().Add(-7 * time.Hour))
+	openedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name           string
+		lastFailure    *certmanv1alpha1.CertificateFailure
+		incident       *metav1.Time
+		builderErr     error
+		clientErr      error
+		expectTrigger  bool
+		expectResolve  bool
+		expectIncident bool
+		expectedEvent  string
+	}{
+		{
+			name:           "opens an incident",
+			lastFailure:    &certmanv1alpha1.CertificateFailure{Message: "AccessDenied", Time: metav1.Now(), Since: &failingSince},
+			expectTrigger:  true,
+			expectIncident: true,
+			expectedEvent:  "Warning PagerDutyIncidentOpened opened a PagerDuty incident",
+		},
+		{
+			name:           "does not open an incident twice",
+			lastFailure:    &certmanv1alpha1.CertificateFailure{Message: "AccessDenied", Time: metav1.Now(), Since: &failingSince},
+			incident:       &openedAt,
+			expectIncident: true,
+		},
+		{
+			name:          "fails to open an incident",
+			lastFailure:   &certmanv1alpha1.CertificateFailure{Message: "AccessDenied", Time: metav1.Now(), Since: &failingSince},
+			clientErr:     errors.New("429 Too Many Requests"),
+			expectedEvent: "Warning PagerDutyFailed failed to open a PagerDuty incident: 429 Too Many Requests",
+		},
+		{
+			name:          "resolves the incident once issued",
+			incident:      &openedAt,
+			expectResolve: true,
+			expectedEvent: "Normal PagerDutyIncidentResolved resolved the PagerDuty incident",
+		},
+		{
+			name:           "keeps the incident it fails to resolve",
+			incident:       &openedAt,
+			clientErr:      errors.New("503 Service Unavailable"),
+			expectIncident: true,
+			expectedEvent:  "Warning PagerDutyFailed failed to resolve the PagerDuty incident: 503 Service Unavailable",
+		},
+		{
+			name:       "removes the incident once the PagerDuty secret is removed",
+			incident:   &openedAt,
+			builderErr: kerrors.NewNotFound(corev1.Resource("secrets"), pagerduty.SecretName),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Status.LastFailure = test.lastFailure
+			cr.Status.PagerDutyIncident = test.incident
+
+			pd := &fakePagerDutyClient{err: test.clientErr}
+			recorder := record.NewFakeRecorder(10)
+			r := &CertificateRequestReconciler{
+				Client:   setUpTestClient(t, []runtime.Object{configMap, cr}),
+				Recorder: recorder,
+				PagerDutyClientBuilder: func(context.Context, client.Client) (pagerduty.Client, error) {
+					if test.builderErr != nil {
+						return nil, test.builderErr
+					}
+					return pd, nil
+				},
+			}
+
+			r.escalateToPagerDuty(context.TODO(), logr.Discard(), cr, &x509.Certificate{NotAfter: time.Now().Add(30 * 24 * time.Hour)})
+
+			assert.Equal(t, test.expectTrigger, len(pd.triggered) == 1)
+			assert.Equal(t, test.expectResolve, len(pd.resolved) == 1)
+
+			updated := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, updated))
+			assert.Equal(t, test.expectIncident, updated.Status.PagerDutyIncident != nil)
+
+			if test.expectedEvent != "" {
+				require.NotEmpty(t, recorder.Events)
+				assert.Contains(t, <-recorder.Events, test.expectedEvent)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+const (
+	pausedConditionType certmanv1alpha1.CertificateRequestConditionType = "Paused"
+	pausedReason                                                        = "PausedAnnotation"
+)
+
+// reportPausedStatus sets the Paused condition of a paused CertificateRequest and refreshes its status from the
+// certificate already stored in its secret, without changing anything else.
+func (r *CertificateRequestReconciler) reportPausedStatus(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, cd *hivev1.ClusterDeployment) error {
+	pausedBy := fmt.Sprintf("CertificateRequest %s", cr.Name)
+	if utils.IsPaused(cd) {
+		pausedBy = fmt.Sprintf("ClusterDeployment %s", cd.Name)
+	}
+
+	setCondition(cr, pausedConditionType, metav1.ConditionTrue, pausedReason, fmt.Sprintf("reconciliation is paused by the %s annotation on %s", certmanv1alpha1.PausedAnnotation, pausedBy))
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		return err
+	}
+
+	exists, err := SecretExists(ctx, r.Client, cr.Spec.CertificateSecret.Name, cr.Namespace)
+	if err != nil || !exists {
+		return err
+	}
+	return r.updateStatus(ctx, reqLogger, cr)
+}
+
+// certificateRequestsForClusterDeployment maps a ClusterDeployment onto the CertificateRequests in its namespace, so
+// that they are reconciled when the ClusterDeployment is paused, hibernated or resumed.
+func (r *CertificateRequestReconciler) certificateRequestsForClusterDeployment(ctx context.Context, obj client.Object) []reconcile.Request {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "failed to list CertificateRequests of ClusterDeployment", "ClusterDeployment", obj.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, cr := range crList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}})
+	}
+	return requests
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	pausedCertRequest := certRequest.DeepCopy()
+	pausedCertRequest.Annotations = map[string]string{certmanv1alpha1.PausedAnnotation: "true"}
+
+	pausedClusterDeployment := clusterDeploymentComplete.DeepCopy()
+	pausedClusterDeployment.Annotations = map[string]string{certmanv1alpha1.PausedAnnotation: "true"}
+
+	tests := []struct {
+		name          string
+		clientObjects []runtime.Object
+	}{
+		{
+			name:          "paused certificaterequest",
+			clientObjects: []runtime.Object{pausedCertRequest, clusterDeploymentComplete, expiredCertSecret},
+		},
+		{
+			name:          "paused clusterdeployment",
+			clientObjects: []runtime.Object{certRequest.DeepCopy(), pausedClusterDeployment, expiredCertSecret},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testClient := setUpTestClient(t, test.clientObjects)
+			r := CertificateRequestReconciler{Client: testClient, ClientBuilder: setUpFakeAWSClient}
+
+			// the expired certificate would be reissued, which fails without a Let's Encrypt account secret
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}})
+			require.NoError(t, err)
+
+			secret := &corev1.Secret{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, secret))
+			assert.Equal(t, expiredCertSecret.Data, secret.Data)
+
+			cr := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, cr))
+			assert.True(t, meta.IsStatusConditionTrue(cr.Status.Conditions, string(pausedConditionType)))
+			assert.True(t, cr.Status.Issued)
+
+			// resuming removes the condition
+			require.NoError(t, r.clearCondition(context.TODO(), cr, pausedConditionType))
+			assert.Nil(t, meta.FindStatusCondition(cr.Status.Conditions, string(pausedConditionType)))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/certpolicy"
+)
+
+const (
+	violationsConditionType certmanv1alpha1.CertificateRequestConditionType = "Violations"
+	policyViolationReason                                                   = "PolicyViolation"
+)
+
+// reportPolicyViolations sets the Violations condition of a CertificateRequest that does not conform to the
+// CertificatePolicies, and clears it once it does. The webhook rejects new violations, so this reports the
+// CertificateRequests created before a policy, or while the webhook was unavailable. Their certificates keep being
+// renewed, so that a policy cannot take down the clusters it was written for.
+func (r *CertificateRequestReconciler) reportPolicyViolations(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	violations, err := certpolicy.Check(ctx, r.Client, &cr.Spec)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return r.clearCondition(ctx, cr, violationsConditionType)
+	}
+
+	reqLogger.Info("certificaterequest violates the certificate policies", "Violations", violations)
+	setCondition(cr, violationsConditionType, metav1.ConditionTrue, policyViolationReason, strings.Join(violations, "; "))
+	return r.Client.Status().Update(ctx, cr)
+}
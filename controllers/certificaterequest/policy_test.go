@@ -0,0 +1,59 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestReportPolicyViolations(t *testing.T) {
+	policy := &certmanv1alpha1.CertificatePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-only"},
+		Spec:       certmanv1alpha1.CertificatePolicySpec{AllowedDomains: []string{"example.com"}},
+	}
+	testClient := setUpTestClient(t, []runtime.Object{certRequest.DeepCopy(), policy})
+	r := CertificateRequestReconciler{Client: testClient}
+
+	cr := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, cr))
+	require.NoError(t, r.reportPolicyViolations(context.TODO(), logr.Discard(), cr))
+
+	actual := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, actual))
+	condition := meta.FindStatusCondition(actual.Status.Conditions, string(violationsConditionType))
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, policyViolationReason, condition.Reason)
+	assert.Equal(t, `CertificatePolicy example-only: dnsName "api.gibberish.goes.here" is not in the allowed domains example.com`, condition.Message)
+
+	// the condition is cleared once the CertificateRequest conforms
+	policy.Spec.AllowedDomains = append(policy.Spec.AllowedDomains, "goes.here")
+	require.NoError(t, testClient.Update(context.TODO(), policy))
+	require.NoError(t, r.reportPolicyViolations(context.TODO(), logr.Discard(), actual))
+	assert.Nil(t, meta.FindStatusCondition(actual.Status.Conditions, string(violationsConditionType)))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+// supportedRSAKeySizes lists the RSA key sizes, in bits, certificates can be issued with.
+var supportedRSAKeySizes = []int{2048, 3072, 4096}
+
+// getRSAKeySize returns the RSA key size requested by the CertificateRequest, falling back to the
+// operator's default key size and then rSAKeyBitSize. Unsupported sizes are rejected.
+func (r *CertificateRequestReconciler) getRSAKeySize(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (int, error) {
+	keySize := cr.Spec.KeySize
+	if keySize == 0 {
+		defaultKeySize, err := utils.GetDefaultKeySize(ctx, r.Client)
+		if err != nil {
+			return 0, err
+		}
+		keySize = defaultKeySize
+	}
+	if keySize == 0 {
+		keySize = rSAKeyBitSize
+	}
+
+	for _, supported := range supportedRSAKeySizes {
+		if keySize == supported {
+			return keySize, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unsupported RSA key size %d, must be one of %v", keySize, supportedRSAKeySizes)
+}
+
+// getPrivateKey returns the private key to issue the certificate with. Under the Never rotation policy the key
+// stored in certificateSecret is reused if it matches the requested algorithm and size; a revoke-and-reissue
+// always generates a new key.
+func getPrivateKey(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret, rsaKeySize int) (crypto.Signer, error) {
+	_, revoking := cr.Annotations[revokeAndReissueAnnotation]
+	if cr.Spec.RotationPolicy == certmanv1alpha1.NeverRotationPolicy && !revoking && len(certificateSecret.Data[corev1.TLSPrivateKeyKey]) > 0 {
+		existingKey, err := parsePrivateKey(certificateSecret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			reqLogger.Error(err, "failed to parse the existing private key, generating a new one")
+		} else if privateKeyMatchesSpec(existingKey, cr, rsaKeySize) {
+			reqLogger.Info("reusing existing private key")
+			return existingKey, nil
+		} else {
+			reqLogger.Info("existing private key does not match the requested key algorithm or size, generating a new one")
+		}
+	}
+
+	reqLogger.Info("generating new key")
+	return generatePrivateKey(cr, rsaKeySize)
+}
+
+// parsePrivateKey decodes a PEM encoded PKCS#1, SEC 1 or PKCS#8 private key.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// privateKeyMatchesSpec returns true if key has the algorithm, size and curve requested by the CertificateRequest.
+func privateKeyMatchesSpec(key crypto.Signer, cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) bool {
+	switch k := key.Public().(type) {
+	case *rsa.PublicKey:
+		return (cr.Spec.KeyAlgorithm == "" || cr.Spec.KeyAlgorithm == certmanv1alpha1.RSAKeyAlgorithm) && k.N.BitLen() == rsaKeySize
+	case *ecdsa.PublicKey:
+		curve, err := getEllipticCurve(cr.Spec.KeyCurve)
+		return err == nil && cr.Spec.KeyAlgorithm == certmanv1alpha1.ECDSAKeyAlgorithm && k.Curve == curve
+	default:
+		return false
+	}
+}
+
+// generatePrivateKey returns a new private key of the algorithm requested by the CertificateRequest.
+// rsaKeySize is only used for RSA keys.
+func generatePrivateKey(cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) (crypto.Signer, error) {
+	switch cr.Spec.KeyAlgorithm {
+	case "", certmanv1alpha1.RSAKeyAlgorithm:
+		return rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case certmanv1alpha1.ECDSAKeyAlgorithm:
+		curve, err := getEllipticCurve(cr.Spec.KeyCurve)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", cr.Spec.KeyAlgorithm)
+	}
+}
+
+// getEllipticCurve maps a KeyCurve onto its elliptic.Curve, defaulting to P-256.
+func getEllipticCurve(keyCurve certmanv1alpha1.KeyCurve) (elliptic.Curve, error) {
+	switch keyCurve {
+	case "", certmanv1alpha1.P256KeyCurve:
+		return elliptic.P256(), nil
+	case certmanv1alpha1.P384KeyCurve:
+		return elliptic.P384(), nil
+	default:
+		return nil, fmt.Errorf("unsupported key curve %q", keyCurve)
+	}
+}
+
+// encodePrivateKey PEM encodes a private key for the certificate secret. RSA keys keep the PKCS#1
+// encoding existing secrets use, ECDSA keys are stored as PKCS#8.
+func encodePrivateKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(k),
+		}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+func TestGetRSAKeySize(t *testing.T) {
+	defaultKeySizeConfigMap := func(keySize string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+			Data:       map[string]string{cTypes.DefaultKeySize: keySize},
+		}
+	}
+
+	tests := []struct {
+		Name            string
+		KeySize         int
+		KubeObjects     []runtime.Object
+		ExpectedKeySize int
+		ExpectError     bool
+	}{
+		{
+			Name:            "defaults to 2048 without operator config",
+			ExpectedKeySize: 2048,
+		},
+		{
+			Name:            "uses the operator default",
+			KubeObjects:     []runtime.Object{defaultKeySizeConfigMap("3072")},
+			ExpectedKeySize: 3072,
+		},
+		{
+			Name:            "spec overrides the operator default",
+			KeySize:         4096,
+			KubeObjects:     []runtime.Object{defaultKeySizeConfigMap("3072")},
+			ExpectedKeySize: 4096,
+		},
+		{
+			Name:        "rejects an unsupported spec key size",
+			KeySize:     1024,
+			ExpectError: true,
+		},
+		{
+			Name:        "rejects an unsupported operator default",
+			KubeObjects: []runtime.Object{defaultKeySizeConfigMap("8192")},
+			ExpectError: true,
+		},
+		{
+			Name:        "rejects a malformed operator default",
+			KubeObjects: []runtime.Object{defaultKeySizeConfigMap("large")},
+			ExpectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, test.KubeObjects)}
+			cr := &certmanv1alpha1.CertificateRequest{
+				Spec: certmanv1alpha1.CertificateRequestSpec{KeySize: test.KeySize},
+			}
+
+			keySize, err := r.getRSAKeySize(context.TODO(), cr)
+			if test.ExpectError {
+				if err == nil {
+					t.Fatalf("expected an error, got key size %d", keySize)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if keySize != test.ExpectedKeySize {
+				t.Errorf("expected key size %d, got %d", test.ExpectedKeySize, keySize)
+			}
+		})
+	}
+}
+
+func TestGeneratePrivateKey(t *testing.T) {
+	tests := []struct {
+		Name            string
+		KeyAlgorithm    certmanv1alpha1.KeyAlgorithm
+		KeyCurve        certmanv1alpha1.KeyCurve
+		ExpectedPEMType string
+		ExpectedCurve   string
+		ExpectError     bool
+	}{
+		{
+			Name:            "defaults to rsa",
+			ExpectedPEMType: "RSA PRIVATE KEY",
+		},
+		{
+			Name:            "ecdsa defaults to p-256",
+			KeyAlgorithm:    certmanv1alpha1.ECDSAKeyAlgorithm,
+			ExpectedPEMType: "PRIVATE KEY",
+			ExpectedCurve:   "P-256",
+		},
+		{
+			Name:            "ecdsa with p-384",
+			KeyAlgorithm:    certmanv1alpha1.ECDSAKeyAlgorithm,
+			KeyCurve:        certmanv1alpha1.P384KeyCurve,
+			ExpectedPEMType: "PRIVATE KEY",
+			ExpectedCurve:   "P-384",
+		},
+		{
+			Name:         "unsupported curve",
+			KeyAlgorithm: certmanv1alpha1.ECDSAKeyAlgorithm,
+			KeyCurve:     "P-521",
+			ExpectError:  true,
+		},
+		{
+			Name:         "unsupported algorithm",
+			KeyAlgorithm: "Ed25519",
+			ExpectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{
+				Spec: certmanv1alpha1.CertificateRequestSpec{
+					KeyAlgorithm: test.KeyAlgorithm,
+					KeyCurve:     test.KeyCurve,
+				},
+			}
+
+			key, err := generatePrivateKey(cr, rSAKeyBitSize)
+			if test.ExpectError {
+				if err == nil {
+					t.Fatalf("expected an error for key algorithm %q and curve %q", test.KeyAlgorithm, test.KeyCurve)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			encoded, err := encodePrivateKey(key)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			block, _ := pem.Decode(encoded)
+			if block == nil {
+				t.Fatalf("failed to decode the encoded private key")
+			}
+			if block.Type != test.ExpectedPEMType {
+				t.Errorf("expected PEM type %s, got %s", test.ExpectedPEMType, block.Type)
+			}
+
+			switch test.ExpectedPEMType {
+			case "RSA PRIVATE KEY":
+				if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+					t.Errorf("failed to parse PKCS#1 key: %s", err)
+				}
+				if _, ok := key.(*rsa.PrivateKey); !ok {
+					t.Errorf("expected an RSA key, got %T", key)
+				}
+			case "PRIVATE KEY":
+				parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+				if err != nil {
+					t.Fatalf("failed to parse PKCS#8 key: %s", err)
+				}
+				ecKey, ok := parsed.(*ecdsa.PrivateKey)
+				if !ok {
+					t.Fatalf("expected an ECDSA key, got %T", parsed)
+				}
+				if ecKey.Curve.Params().Name != test.ExpectedCurve {
+					t.Errorf("expected curve %s, got %s", test.ExpectedCurve, ecKey.Curve.Params().Name)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPrivateKey(t *testing.T) {
+	existingKey, err := generatePrivateKey(&certmanv1alpha1.CertificateRequest{}, rSAKeyBitSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	existingKeyPEM, err := encodePrivateKey(existingKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		Name           string
+		RotationPolicy certmanv1alpha1.PrivateKeyRotationPolicy
+		KeyAlgorithm   certmanv1alpha1.KeyAlgorithm
+		RSAKeySize     int
+		Annotations    map[string]string
+		SecretData     map[string][]byte
+		ExpectReuse    bool
+	}{
+		{
+			Name:       "defaults to rotating the key",
+			RSAKeySize: rSAKeyBitSize,
+			SecretData: map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+		},
+		{
+			Name:           "always rotates the key",
+			RotationPolicy: certmanv1alpha1.AlwaysRotationPolicy,
+			RSAKeySize:     rSAKeyBitSize,
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+		},
+		{
+			Name:           "never rotates the key",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			RSAKeySize:     rSAKeyBitSize,
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+			ExpectReuse:    true,
+		},
+		{
+			Name:           "generates a key when the secret has none",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			RSAKeySize:     rSAKeyBitSize,
+		},
+		{
+			Name:           "generates a key when the key size changed",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			RSAKeySize:     3072,
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+		},
+		{
+			Name:           "generates a key when the key algorithm changed",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			KeyAlgorithm:   certmanv1alpha1.ECDSAKeyAlgorithm,
+			RSAKeySize:     rSAKeyBitSize,
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+		},
+		{
+			Name:           "generates a key when revoking",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			RSAKeySize:     rSAKeyBitSize,
+			Annotations:    map[string]string{revokeAndReissueAnnotation: "keyCompromise"},
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: existingKeyPEM},
+		},
+		{
+			Name:           "generates a key when the existing key is malformed",
+			RotationPolicy: certmanv1alpha1.NeverRotationPolicy,
+			RSAKeySize:     rSAKeyBitSize,
+			SecretData:     map[string][]byte{corev1.TLSPrivateKeyKey: []byte("not a key")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Annotations: test.Annotations},
+				Spec: certmanv1alpha1.CertificateRequestSpec{
+					KeyAlgorithm:   test.KeyAlgorithm,
+					RotationPolicy: test.RotationPolicy,
+				},
+			}
+			secret := &corev1.Secret{Data: test.SecretData}
+
+			key, err := getPrivateKey(logr.Discard(), cr, secret, test.RSAKeySize)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			reused := existingKey.(*rsa.PrivateKey).Equal(key)
+			if reused != test.ExpectReuse {
+				t.Errorf("expected key reuse to be %t, got %t", test.ExpectReuse, reused)
+			}
+		})
+	}
+}
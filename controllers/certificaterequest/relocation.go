@@ -0,0 +1,91 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	hiveRelocationIncomingValue = "incoming"
+
+	// relocatedSecretRequeueInterval is how often a CertificateRequest of an incoming ClusterDeployment checks
+	// whether its certificate secret has been relocated yet.
+	relocatedSecretRequeueInterval = time.Minute
+)
+
+// isIncomingRelocation returns true if the ClusterDeployment is being relocated to this hub from another one.
+func isIncomingRelocation(cd *hivev1.ClusterDeployment) bool {
+	value, ok := cd.Annotations[hiveRelocationAnnotation]
+	if !ok {
+		return false
+	}
+	parts := strings.Split(value, "/")
+	return len(parts) == 2 && parts[1] == hiveRelocationIncomingValue
+}
+
+// shouldAdoptCertificateSecret returns true if the certificate secret has no controller, or is controlled by a
+// CertificateRequest of the same name that no longer exists, as happens to the certificate secrets relocated with
+// their ClusterDeployment from another hub.
+func shouldAdoptCertificateSecret(cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) bool {
+	owner := metav1.GetControllerOf(secret)
+	if owner == nil {
+		return true
+	}
+	return owner.Kind == "CertificateRequest" && owner.Name == cr.Name && owner.UID != cr.UID
+}
+
+// adoptCertificateSecret makes the CertificateRequest the controller of its existing certificate secret, so that
+// the certificate it holds is renewed when due instead of being ordered again from scratch, and the secret is
+// garbage collected with the CertificateRequest. The certificate of a CertificateRequest that has not been issued one
+// yet is recorded as adopted.
+func (r *CertificateRequestReconciler) adoptCertificateSecret(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) error {
+	if !shouldAdoptCertificateSecret(cr, secret) {
+		return nil
+	}
+
+	reqLogger.Info("adopting existing certificate secret", "Secret.Name", secret.Name)
+	ownerReferences := []metav1.OwnerReference{}
+	for _, o := range secret.OwnerReferences {
+		if o.Kind != "CertificateRequest" || o.Name != cr.Name {
+			ownerReferences = append(ownerReferences, o)
+		}
+	}
+	secret.OwnerReferences = ownerReferences
+
+	if err := controllerutil.SetControllerReference(cr, secret, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Client.Update(ctx, secret); err != nil {
+		return err
+	}
+
+	if cr.Status.Issued || cr.Spec.ImportedSecret != nil {
+		return nil
+	}
+	return r.recordAdoptedCertificate(ctx, reqLogger, cr, secret)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIsIncomingRelocation(t *testing.T) {
+	assert.True(t, isIncomingRelocation(clusterDeploymentIncoming))
+	assert.False(t, isIncomingRelocation(clusterDeploymentOutgoing))
+	assert.False(t, isIncomingRelocation(clusterDeploymentComplete))
+}
+
+func TestReconcileIncomingRelocationWithoutSecret(t *testing.T) {
+	incomingClusterDeployment := clusterDeploymentIncoming.DeepCopy()
+	incomingClusterDeployment.UID = testHiveClusterDeploymentUID
+	testClient := setUpTestClient(t, []runtime.Object{testLESecret, certRequest.DeepCopy(), incomingClusterDeployment})
+	r := CertificateRequestReconciler{Client: testClient, Scheme: scheme.Scheme, ClientBuilder: setUpFakeAWSClient}
+
+	// ordering a certificate would fail without AWS credentials
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}})
+	require.NoError(t, err)
+	assert.Equal(t, relocatedSecretRequeueInterval, result.RequeueAfter)
+
+	err = testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, &corev1.Secret{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestAdoptCertificateSecret(t *testing.T) {
+	cr := certRequest.DeepCopy()
+	cr.UID = "new-uid"
+
+	tests := []struct {
+		name            string
+		ownerReferences []metav1.OwnerReference
+		expectAdopted   bool
+	}{
+		{
+			name:          "secret without owner is adopted",
+			expectAdopted: true,
+		},
+		{
+			name: "secret owned by the relocated certificaterequest is adopted",
+			ownerReferences: []metav1.OwnerReference{
+				{APIVersion: "certman.managed.openshift.io/v1alpha1", Kind: "CertificateRequest", Name: cr.Name, UID: "old-uid", Controller: boolPointer(true)},
+			},
+			expectAdopted: true,
+		},
+		{
+			name: "secret controlled by something else is left alone",
+			ownerReferences: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid", Controller: boolPointer(true)},
+			},
+			expectAdopted: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := validCertSecret.DeepCopy()
+			secret.OwnerReferences = test.ownerReferences
+			testClient := setUpTestClient(t, []runtime.Object{secret})
+			r := CertificateRequestReconciler{Client: testClient, Scheme: scheme.Scheme}
+
+			require.NoError(t, r.adoptCertificateSecret(context.TODO(), logr.Discard(), cr, secret))
+
+			updated := &corev1.Secret{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, updated))
+			owner := metav1.GetControllerOf(updated)
+			assert.Equal(t, test.expectAdopted, owner != nil && owner.UID == cr.UID)
+			assert.Len(t, updated.OwnerReferences, 1)
+		})
+	}
+}
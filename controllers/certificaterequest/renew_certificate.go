@@ -17,66 +17,180 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
+	"hash/fnv"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
 )
 
-// ShouldReissue retrieves a reissueCertificateBeforeDays int and returns `true` to the caller if it is <= the expiry of the CertificateRequest.
-func (r *CertificateRequestReconciler) ShouldReissue(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+// ShouldReissue returns `true` to the caller if the certificate of the CertificateRequest has reached its renewal time,
+// is missing one of the requested DNS names, or its secret no longer holds a usable certificate and private key.
+func (r *CertificateRequestReconciler) ShouldReissue(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+	trigger, err := r.reissueTrigger(ctx, reqLogger, cr)
+	return trigger != "", err
+}
 
-	reissueBeforeDays := cr.Spec.ReissueBeforeDays
+// reissueTrigger returns the reason the certificate of the CertificateRequest should be reissued, as recorded in its
+// history, or an empty string if it should not.
+func (r *CertificateRequestReconciler) reissueTrigger(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (string, error) {
 
-	if reissueBeforeDays <= 0 {
-		reissueBeforeDays = reissueCertificateBeforeDays
+	renewBefore, err := getRenewBefore(cr)
+	if err != nil {
+		return "", err
 	}
 
-	reqLogger.Info(fmt.Sprintf("certificate is configured to be reissued %d days before expiry", reissueBeforeDays))
+	reqLogger.Info("certificate is configured to be reissued before expiry", "renewBefore", renewBefore.String())
 
-	crtSecret, err := GetSecret(r.Client, cr.Spec.CertificateSecret.Name, cr.Namespace)
+	crtSecret, err := GetSecret(ctx, r.Client, cr.Spec.CertificateSecret.Name, cr.Namespace)
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
-	data := crtSecret.Data[corev1.TLSCertKey]
-	if data == nil {
-		reqLogger.Info(fmt.Sprintf("certificate data was not found in secret %v", cr.Spec.CertificateSecret.Name))
-		return true, nil
+	// a secret deleted, emptied or corrupted out of band is reissued straight away
+	if err := checkCertificateSecret(cr, crtSecret); err != nil {
+		reqLogger.Info("certificate secret is unusable and will be reissued", "reason", err.Error())
+		return secretUnusableTrigger, nil
 	}
 
-	certificate, err := ParseCertificateData(data)
+	certificate, err := ParseCertificateData(crtSecret.Data[corev1.TLSCertKey])
 	if err != nil {
 		reqLogger.Error(err, err.Error())
-		return false, err
+		return "", err
 	}
 
 	if certificate != nil {
 
-		notAfter := certificate.NotAfter
+		jitterWindow, err := utils.GetRenewalJitterWindow(ctx, r.Client)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return "", err
+		}
+
+		renewalTime, err := getRenewalTime(cr, certificate, jitterWindow)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return "", err
+		}
+
 		currentTime := time.Now().In(time.UTC)
-		timeDiff := notAfter.Sub(currentTime)
-		daysCertificateValidFor := int(timeDiff.Hours() / 24)
-		shouldReissue := daysCertificateValidFor <= reissueBeforeDays
-
-		for _, DNSName := range cr.Spec.DnsNames {
-			if !utils.ContainsString(certificate.DNSNames, DNSName) {
-				reqLogger.Info(fmt.Sprintf("dnsname: %s not found in existing cert %s", DNSName, certificate.DNSNames))
-				shouldReissue = true
-			}
+		daysCertificateValidFor := int(certificate.NotAfter.Sub(currentTime).Hours() / 24)
+		trigger := ""
+		if !currentTime.Before(renewalTime) {
+			trigger = renewalTimeReachedTrigger
 		}
-		if shouldReissue {
-			reqLogger.Info(fmt.Sprintf("certificate is valid from (notBefore) %v and until (notAfter) %v and is valid for %d days and will be reissued", certificate.NotBefore.String(), certificate.NotAfter.String(), daysCertificateValidFor))
-		} else {
-			reqLogger.Info(fmt.Sprintf("certificate is valid from (notBefore) %v and until (notAfter) %v and is valid for %d days and will NOT be reissued", certificate.NotBefore.String(), certificate.NotAfter.String(), daysCertificateValidFor))
+
+		if DNSName := missingDNSName(certificate, cr.Spec.DnsNames); DNSName != "" {
+			reqLogger.Info("dnsname not found in existing cert", logging.Domain, DNSName, "certificateDNSNames", certificate.DNSNames)
+			trigger = dnsNamesChangedTrigger
+		}
+		reqLogger.Info("checked certificate validity", "notBefore", certificate.NotBefore.String(), "notAfter", certificate.NotAfter.String(),
+			"daysValid", daysCertificateValidFor, "reissue", trigger != "")
+
+		return trigger, nil
+	}
+
+	return "", nil
+}
+
+// nextRenewalCheck returns the result requeueing the CertificateRequest for its next renewal check: after the
+// renewal check interval, or at the renewal time of its certificate if that is sooner, so that short-lived
+// certificates are renewed on time. A certificate that is still not renewed close to its expiry is notified and
+// emailed, and the PagerDuty incident of the CertificateRequest is opened or resolved.
+func (r *CertificateRequestReconciler) nextRenewalCheck(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) reconcile.Result {
+	interval, err := utils.GetRenewalCheckInterval(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to get the renewal check interval, using the default")
+		interval = utils.DefaultRenewalCheckInterval
+	}
+	localmetrics.SetRenewalCheckInterval(interval)
+
+	certificate, err := GetCertificate(ctx, r.Client, cr)
+	if err != nil {
+		reqLogger.Error(err, "failed to get the certificate to schedule its renewal check")
+		return reconcile.Result{RequeueAfter: interval}
+	}
+	r.notifyExpiry(ctx, reqLogger, cr, certificate)
+	r.emailExpiryWarning(ctx, reqLogger, cr, certificate)
+	r.escalateToPagerDuty(ctx, reqLogger, cr, certificate)
+
+	jitterWindow, err := utils.GetRenewalJitterWindow(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to get the renewal jitter window to schedule the renewal check")
+		return reconcile.Result{RequeueAfter: interval}
+	}
+
+	renewalTime, err := getRenewalTime(cr, certificate, jitterWindow)
+	if err != nil {
+		reqLogger.Error(err, "failed to determine the certificate renewal time")
+		return reconcile.Result{RequeueAfter: interval}
+	}
+
+	if untilRenewal := time.Until(renewalTime); untilRenewal > 0 && untilRenewal < interval {
+		interval = untilRenewal
+	}
+	return reconcile.Result{RequeueAfter: interval}
+}
+
+// getRenewBefore returns how long before expiry the certificate of the CertificateRequest is reissued. RenewBefore
+// takes precedence over ReissueBeforeDays, which defaults to reissueCertificateBeforeDays.
+func getRenewBefore(cr *certmanv1alpha1.CertificateRequest) (time.Duration, error) {
+	if cr.Spec.RenewBefore != nil {
+		if cr.Spec.RenewBefore.Duration <= 0 {
+			return 0, fmt.Errorf("renewBefore must be positive, got %s", cr.Spec.RenewBefore.Duration)
 		}
+		return cr.Spec.RenewBefore.Duration, nil
+	}
+
+	reissueBeforeDays := cr.Spec.ReissueBeforeDays
+	if reissueBeforeDays <= 0 {
+		reissueBeforeDays = reissueCertificateBeforeDays
+	}
+
+	return time.Duration(reissueBeforeDays) * 24 * time.Hour, nil
+}
+
+// getRenewalTime returns the time at which the certificate of the CertificateRequest is reissued. The renewal window
+// must be shorter than the certificate's lifetime, otherwise the certificate would be reissued on every reconcile.
+// The renewal is brought forward by the jitter of the CertificateRequest within jitterWindow.
+func getRenewalTime(cr *certmanv1alpha1.CertificateRequest, certificate *x509.Certificate, jitterWindow time.Duration) (time.Time, error) {
+	renewBefore, err := getRenewBefore(cr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	lifetime := certificate.NotAfter.Sub(certificate.NotBefore)
+	if renewBefore >= lifetime {
+		return time.Time{}, fmt.Errorf("renewBefore %s must be shorter than the certificate lifetime of %s", renewBefore, lifetime)
+	}
+
+	// the jitter never uses more than half of the time left before the renewal window, so short-lived certificates
+	// are not reissued right after being issued
+	if maxWindow := (lifetime - renewBefore) / 2; jitterWindow > maxWindow {
+		jitterWindow = maxWindow
+	}
+
+	return certificate.NotAfter.Add(-renewBefore - renewalJitter(cr, jitterWindow)), nil
+}
 
-		return shouldReissue, nil
+// renewalJitter returns a duration within window derived from a hash of the namespace and name of the
+// CertificateRequest. Certificates of clusters created together then renew at different times instead of all hitting
+// the certificate authority and DNS providers at once, while each renewal time stays stable across reconciles.
+func renewalJitter(cr *certmanv1alpha1.CertificateRequest, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
 	}
 
-	return false, nil
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(cr.Namespace + "/" + cr.Name))
+	return time.Duration(h.Sum64() % uint64(window))
 }
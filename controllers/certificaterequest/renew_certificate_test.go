@@ -17,12 +17,19 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
+	"crypto/x509"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
 )
 
 func TestShouldReissue(t *testing.T) {
@@ -42,6 +49,7 @@ func TestShouldReissue(t *testing.T) {
 	testClient := setUpTestClient(t, []runtime.Object{testLESecret, certRequest, emptyCertSecret})
 	//create a reconcile certificate object
 	rcr := CertificateRequestReconciler{
+		Recorder:      &record.FakeRecorder{},
 		Client:        testClient,
 		ClientBuilder: setUpFakeAWSClient,
 	}
@@ -51,7 +59,7 @@ func TestShouldReissue(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.desc, func(t *testing.T) {
 
-			got, _ := rcr.ShouldReissue(nullLogger, test.testCert)
+			got, _ := rcr.ShouldReissue(context.TODO(), nullLogger, test.testCert)
 
 			if got != test.want {
 				t.Errorf("ShouldReissue() = %v, want = %v", got, test.want)
@@ -61,3 +69,153 @@ func TestShouldReissue(t *testing.T) {
 	}
 
 }
+
+func TestGetRenewalTime(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	certificate := &x509.Certificate{NotBefore: notBefore, NotAfter: notBefore.Add(90 * 24 * time.Hour)}
+
+	tests := []struct {
+		desc        string
+		spec        certmanv1alpha1.CertificateRequestSpec
+		want        time.Time
+		expectError bool
+	}{
+		{
+			desc: "defaults to reissueCertificateBeforeDays",
+			want: certificate.NotAfter.Add(-reissueCertificateBeforeDays * 24 * time.Hour),
+		},
+		{
+			desc: "uses renewBeforeDays",
+			spec: certmanv1alpha1.CertificateRequestSpec{ReissueBeforeDays: 10},
+			want: certificate.NotAfter.Add(-10 * 24 * time.Hour),
+		},
+		{
+			desc: "renewBefore takes precedence over renewBeforeDays",
+			spec: certmanv1alpha1.CertificateRequestSpec{ReissueBeforeDays: 10, RenewBefore: &metav1.Duration{Duration: 60 * 24 * time.Hour}},
+			want: certificate.NotAfter.Add(-60 * 24 * time.Hour),
+		},
+		{
+			desc:        "rejects a negative renewBefore",
+			spec:        certmanv1alpha1.CertificateRequestSpec{RenewBefore: &metav1.Duration{Duration: -time.Hour}},
+			expectError: true,
+		},
+		{
+			desc:        "rejects a renewBefore longer than the certificate lifetime",
+			spec:        certmanv1alpha1.CertificateRequestSpec{RenewBefore: &metav1.Duration{Duration: 91 * 24 * time.Hour}},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := getRenewalTime(&certmanv1alpha1.CertificateRequest{Spec: test.spec}, certificate, 0)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("getRenewalTime() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("getRenewalTime() = %v, want = %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetRenewalTimeJitter(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	certificate := &x509.Certificate{NotBefore: notBefore, NotAfter: notBefore.Add(90 * 24 * time.Hour)}
+	withoutJitter := certificate.NotAfter.Add(-reissueCertificateBeforeDays * 24 * time.Hour)
+	window := 72 * time.Hour
+
+	renewalTimes := map[time.Time]bool{}
+	for _, name := range []string{"cluster-a", "cluster-b", "cluster-c", "cluster-d"} {
+		cr := &certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "uhc-" + name}}
+
+		got, err := getRenewalTime(cr, certificate, window)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got.After(withoutJitter) || !got.After(withoutJitter.Add(-window)) {
+			t.Errorf("getRenewalTime() = %v, want within %s before %v", got, window, withoutJitter)
+		}
+
+		again, _ := getRenewalTime(cr, certificate, window)
+		if !again.Equal(got) {
+			t.Errorf("getRenewalTime() = %v, then %v, want a stable renewal time", got, again)
+		}
+		renewalTimes[got] = true
+	}
+
+	if len(renewalTimes) < 2 {
+		t.Errorf("getRenewalTime() returned the same renewal time for every CertificateRequest")
+	}
+
+	// the jitter is capped to half of the time left before the renewal window
+	cr := &certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: "cluster-a"}}
+	got, err := getRenewalTime(cr, certificate, 365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if maxJitter := (90 - reissueCertificateBeforeDays) * 24 * time.Hour / 2; got.Before(withoutJitter.Add(-maxJitter)) {
+		t.Errorf("getRenewalTime() = %v, want no earlier than %v", got, withoutJitter.Add(-maxJitter))
+	}
+}
+
+func TestNextRenewalCheck(t *testing.T) {
+	certPEM, _, err := generateValidCertPEM()
+	if err != nil {
+		t.Fatalf("failed to generate certificate: %v", err)
+	}
+	shortLivedCertSecret := validCertSecret.DeepCopy()
+	shortLivedCertSecret.Data[corev1.TLSCertKey] = certPEM
+
+	tests := []struct {
+		desc        string
+		secret      *corev1.Secret
+		renewBefore time.Duration
+		wantMin     time.Duration
+		wantMax     time.Duration
+	}{
+		{
+			desc:    "long-lived certificate is checked after the interval",
+			secret:  validCertSecret,
+			wantMin: utils.DefaultRenewalCheckInterval,
+			wantMax: utils.DefaultRenewalCheckInterval,
+		},
+		{
+			desc:        "short-lived certificate is checked at its renewal time",
+			secret:      shortLivedCertSecret,
+			renewBefore: 20 * time.Hour,
+			wantMin:     4*time.Hour - time.Minute,
+			wantMax:     4 * time.Hour,
+		},
+		{
+			desc:    "missing certificate is checked after the interval",
+			wantMin: utils.DefaultRenewalCheckInterval,
+			wantMax: utils.DefaultRenewalCheckInterval,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			if test.renewBefore != 0 {
+				cr.Spec.RenewBefore = &metav1.Duration{Duration: test.renewBefore}
+			}
+			objects := []runtime.Object{cr}
+			if test.secret != nil {
+				objects = append(objects, test.secret)
+			}
+			r := CertificateRequestReconciler{Client: setUpTestClient(t, objects)}
+
+			result := r.nextRenewalCheck(context.TODO(), logr.Discard(), cr)
+			if result.RequeueAfter < test.wantMin || result.RequeueAfter > test.wantMax {
+				t.Errorf("nextRenewalCheck() = %s, want between %s and %s", result.RequeueAfter, test.wantMin, test.wantMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	retryBudgetExhaustedReason = "RetryBudgetExhausted"
+)
+
+// isFailed returns true if the retry budget of the CertificateRequest is exhausted.
+func isFailed(cr *certmanv1alpha1.CertificateRequest) bool {
+	return meta.IsStatusConditionTrue(cr.Status.Conditions, string(certmanv1alpha1.CertificateRequestFailed))
+}
+
+// countFailedAttempt counts a failed attempt to issue the certificate of the CertificateRequest, records it as its
+// last failure, and sets its Failed condition once the retry budget is exhausted. The status is not written.
+func (r *CertificateRequestReconciler) countFailedAttempt(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) error {
+	recordFailure(cr, err)
+
+	maxAttempts, cfgErr := utils.GetMaxIssuanceAttempts(ctx, r.Client)
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	cr.Status.FailedAttempts++
+	cr.Status.ObservedGeneration = cr.Generation
+	if maxAttempts == 0 || int(cr.Status.FailedAttempts) < maxAttempts {
+		return nil
+	}
+
+	message := fmt.Sprintf("gave up after %d failed attempts, the last one with: %v. Change the spec or set the %s annotation to retry", cr.Status.FailedAttempts, err, certmanv1alpha1.RetryAnnotation)
+	if !isFailed(cr) {
+		reqLogger.Info("retry budget exhausted, no more orders are placed", "FailedAttempts", cr.Status.FailedAttempts)
+		localmetrics.IncrementCertRequestsFailed()
+		r.recordEvent(cr, corev1.EventTypeWarning, retryBudgetExhaustedReason, "%s", message)
+	}
+	setCondition(cr, certmanv1alpha1.CertificateRequestFailed, metav1.ConditionTrue, retryBudgetExhaustedReason, message)
+	return nil
+}
+
+// recordFailure counts a failed attempt to issue the certificate of the CertificateRequest since it was last issued,
+// and records why it failed and since when it has been failing.
+func recordFailure(cr *certmanv1alpha1.CertificateRequest, err error) {
+	now := metav1.Now()
+	since := now
+	if previous := cr.Status.LastFailure; previous != nil {
+		// failures recorded before the start of the streak was tracked only know their own time
+		since = previous.Time
+		if previous.Since != nil {
+			since = *previous.Since
+		}
+	}
+
+	cr.Status.FailureCount++
+	cr.Status.LastFailure = &certmanv1alpha1.CertificateFailure{
+		Reason:  failureReason(err),
+		Message: err.Error(),
+		Time:    now,
+		Since:   &since,
+	}
+}
+
+// recordFailedAttempt counts a failed attempt to renew the certificate of the CertificateRequest and writes its
+// status.
+func (r *CertificateRequestReconciler) recordFailedAttempt(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) error {
+	if err := r.countFailedAttempt(ctx, reqLogger, cr, err); err != nil {
+		return err
+	}
+
+	// a reconcile that timed out still counts the attempt, so the update must outlive ctx
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusErrorUpdateTimeout)
+	defer cancel()
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		return err
+	}
+
+	r.notifyPersistentFailure(ctx, reqLogger, cr)
+	r.notifyFailure(ctx, reqLogger, cr)
+	return nil
+}
+
+// resetRetryBudget clears the failed attempts of a CertificateRequest whose spec changed since its last failed
+// attempt, or that carries the RetryAnnotation, which is then removed.
+func (r *CertificateRequestReconciler) resetRetryBudget(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	_, retry := cr.Annotations[certmanv1alpha1.RetryAnnotation]
+	specChanged := cr.Status.FailedAttempts > 0 && cr.Status.ObservedGeneration != cr.Generation
+	if !retry && !specChanged {
+		return nil
+	}
+
+	if cr.Status.FailedAttempts > 0 || isFailed(cr) {
+		reqLogger.Info("resetting the retry budget", "FailedAttempts", cr.Status.FailedAttempts, "RetryAnnotation", retry)
+		cr.Status.FailedAttempts = 0
+		removeCondition(cr, certmanv1alpha1.CertificateRequestFailed)
+		if err := r.Client.Status().Update(ctx, cr); err != nil {
+			return err
+		}
+	}
+
+	if !retry {
+		return nil
+	}
+	baseToPatch := client.MergeFrom(cr.DeepCopy())
+	delete(cr.Annotations, certmanv1alpha1.RetryAnnotation)
+	return r.Client.Patch(ctx, cr, baseToPatch)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+func TestCountFailedAttempt(t *testing.T) {
+	budgetConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.MaxIssuanceAttempts: "2"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{budgetConfigMap}), Recorder: recorder}
+
+	cr := certRequest.DeepCopy()
+	require.NoError(t, r.countFailedAttempt(context.TODO(), logr.Discard(), cr, errors.New("caa forbids issuance")))
+	assert.Equal(t, int32(1), cr.Status.FailedAttempts)
+	assert.False(t, isFailed(cr))
+	assert.Equal(t, int32(1), cr.Status.FailureCount)
+	require.NotNil(t, cr.Status.LastFailure)
+	assert.Equal(t, issuanceFailedReason, cr.Status.LastFailure.Reason)
+	assert.Equal(t, "caa forbids issuance", cr.Status.LastFailure.Message)
+	assert.False(t, cr.Status.LastFailure.Time.IsZero())
+	require.NotNil(t, cr.Status.LastFailure.Since)
+	since := *cr.Status.LastFailure.Since
+
+	require.NoError(t, r.countFailedAttempt(context.TODO(), logr.Discard(), cr, errors.New("caa forbids issuance")))
+	assert.Equal(t, int32(2), cr.Status.FailedAttempts)
+	assert.True(t, isFailed(cr))
+	require.Len(t, recorder.Events, 2)
+	assert.Contains(t, <-recorder.Events, "Warning RetryBudgetExhausted gave up after 2 failed attempts")
+	assert.Contains(t, <-recorder.Events, "Warning RetryBudgetExhausted CertificateRequest "+cr.Name+": gave up after 2 failed attempts")
+
+	// the Event is only recorded when the budget runs out
+	require.NoError(t, r.countFailedAttempt(context.TODO(), logr.Discard(), cr, errors.New("acme: error code 400")))
+	assert.Empty(t, recorder.Events)
+	assert.Equal(t, int32(3), cr.Status.FailureCount)
+	assert.Equal(t, acmeErrorReason, cr.Status.LastFailure.Reason)
+	assert.Equal(t, since, *cr.Status.LastFailure.Since, "the streak started with the first failure")
+}
+
+func TestResetRetryBudget(t *testing.T) {
+	failedCertRequest := certRequest.DeepCopy()
+	failedCertRequest.Generation = 1
+	failedCertRequest.Status.ObservedGeneration = 1
+	failedCertRequest.Status.FailedAttempts = 10
+	failedCertRequest.Status.FailureCount = 10
+	setCondition(failedCertRequest, certmanv1alpha1.CertificateRequestFailed, metav1.ConditionTrue, retryBudgetExhaustedReason, "")
+
+	retriedCertRequest := failedCertRequest.DeepCopy()
+	retriedCertRequest.Annotations = map[string]string{certmanv1alpha1.RetryAnnotation: ""}
+
+	changedCertRequest := failedCertRequest.DeepCopy()
+	changedCertRequest.Generation = 2
+
+	tests := []struct {
+		name        string
+		cr          *certmanv1alpha1.CertificateRequest
+		expectReset bool
+	}{
+		{
+			name: "failed",
+			cr:   failedCertRequest,
+		},
+		{
+			name:        "retry annotation",
+			cr:          retriedCertRequest,
+			expectReset: true,
+		},
+		{
+			name:        "spec changed",
+			cr:          changedCertRequest,
+			expectReset: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := test.cr.DeepCopy()
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{cr})}
+
+			require.NoError(t, r.resetRetryBudget(context.TODO(), logr.Discard(), cr))
+
+			updated := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, updated))
+			assert.Equal(t, !test.expectReset, isFailed(updated))
+			assert.NotContains(t, updated.Annotations, certmanv1alpha1.RetryAnnotation)
+			if test.expectReset {
+				assert.Zero(t, updated.Status.FailedAttempts)
+			}
+			// the failures since the last issuance are kept for triage
+			assert.Equal(t, int32(10), updated.Status.FailureCount)
+		})
+	}
+}
+
+func TestReconcileFailed(t *testing.T) {
+	failedCertRequest := certRequest.DeepCopy()
+	failedCertRequest.Status.FailedAttempts = 10
+	setCondition(failedCertRequest, certmanv1alpha1.CertificateRequestFailed, metav1.ConditionTrue, retryBudgetExhaustedReason, "")
+
+	testClient := setUpTestClient(t, []runtime.Object{failedCertRequest, clusterDeploymentComplete, expiredCertSecret})
+	r := CertificateRequestReconciler{Client: testClient, ClientBuilder: setUpFakeAWSClient}
+
+	// the expired certificate would be reissued, which fails without a Let's Encrypt account secret
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}})
+	require.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, secret))
+	assert.Equal(t, expiredCertSecret.Data, secret.Data)
+}
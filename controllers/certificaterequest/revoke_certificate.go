@@ -17,38 +17,48 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
 	"github.com/openshift/certman-operator/pkg/leclient"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
 )
 
 // RevokeCertificate validates which letsencrypt endpoint is to be used along with corresponding account.
 // Then revokes certificate upon matching the CommonName of LetsEncryptCertIssuingAuthority.
 // Associated ACME challenge resources are also removed.
-func (r *CertificateRequestReconciler) RevokeCertificate(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
-	// Get DNS client from CR.
-	dnsClient, err := r.getClient(reqLogger, cr)
-	if err != nil {
-		reqLogger.Error(err, err.Error())
-		return err
-	}
-	leClient, err := leclient.NewClient(r.Client)
+func (r *CertificateRequestReconciler) RevokeCertificate(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	leClient, err := r.getLetsEncryptClient(ctx, cr)
 	if err != nil {
 		reqLogger.Error(err, "failed to get letsencrypt client")
 		return err
 	}
+	leClient.SetTraceContext(ctx)
+
+	// Get DNS client from CR, private CAs leave no challenge records behind.
+	var dnsClient cClient.Client
+	if !leclient.IsPrivateCAClient(leClient) {
+		dnsClient, err = r.getClient(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, err.Error())
+			return err
+		}
+	}
 
-	certificate, err := GetCertificate(r.Client, cr)
+	certificate, err := GetCertificate(ctx, r.Client, cr)
 	if err != nil {
 		reqLogger.Error(err, "error occurred loading current certificate")
 		return err
 	}
 
-	if leclient.IsCertificateIssuerLE(certificate.Issuer) {
+	if leclient.CanRevoke(leClient, certificate) {
 		if err := leClient.RevokeCertificate(certificate); err != nil {
 			if !strings.Contains(err.Error(), "urn:ietf:params:acme:error:alreadyRevoked") {
 				return err
@@ -59,10 +69,80 @@ func (r *CertificateRequestReconciler) RevokeCertificate(reqLogger logr.Logger,
 		return fmt.Errorf("certificate was not issued by Let's Encrypt and cannot be revoked by the operator")
 	}
 
-	err = dnsClient.DeleteAcmeChallengeResourceRecords(reqLogger, cr)
+	if dnsClient != nil {
+		err = dnsClient.DeleteAcmeChallengeResourceRecords(ctx, reqLogger, cr)
+		if err != nil {
+			reqLogger.Error(err, "error occurred deleting acme challenge resource records.")
+			localmetrics.IncrementDNSCleanupFailures()
+		}
+	}
+
+	return nil
+}
+
+// RevokeAndReissueCertificate handles the revoke-and-reissue annotation on a CertificateRequest.
+// A new certificate is issued with a freshly generated key and written to the secret, then the previous
+// certificate is revoked with the reason code named by the annotation and the annotation is removed. The
+// previous certificate stays in place, unrevoked, if the reissue fails.
+func (r *CertificateRequestReconciler) RevokeAndReissueCertificate(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret, leClient leclient.LetsEncryptClientInterface) error {
+	reason, err := leclient.RevocationReasonCode(cr.Annotations[revokeAndReissueAnnotation])
+	if err != nil {
+		return err
+	}
+
+	certificate, err := GetCertificate(ctx, r.Client, cr)
 	if err != nil {
-		reqLogger.Error(err, "error occurred deleting acme challenge resource records.")
+		reqLogger.Error(err, "error occurred loading current certificate")
+		return err
 	}
 
+	if !leclient.CanRevoke(leClient, certificate) {
+		return fmt.Errorf("certificate was not issued by Let's Encrypt and cannot be revoked by the operator")
+	}
+
+	// the certificate is only replaced once its replacement can be copied to every secret replica
+	if _, err := r.getSecretReplicas(ctx, cr, certificateSecret); err != nil {
+		return err
+	}
+
+	reqLogger.Info("reissuing certificate before revoking it", "serialNumber", certificate.SerialNumber.String())
+	err = r.IssueCertificate(ctx, reqLogger, cr, certificateSecret, leClient)
+	if err != nil {
+		return err
+	}
+
+	localmetrics.AddCertificateIssuance("revoke_and_reissue")
+	err = r.writeSecret(ctx, certificateSecret, false)
+	if err != nil {
+		return err
+	}
+
+	reqLogger.Info("revoking certificate", "serialNumber", certificate.SerialNumber.String(), "reason", cr.Annotations[revokeAndReissueAnnotation])
+	revokeErr := leClient.RevokeCertificateWithReason(certificate, reason)
+	if revokeErr != nil && strings.Contains(revokeErr.Error(), "urn:ietf:params:acme:error:alreadyRevoked") {
+		revokeErr = nil
+	}
+
+	revoked := newHistoryEntry(certmanv1alpha1.CertificateRevoked, revokeAndReissueTrigger, certificate)
+	revoked.Actor = annotationManager(cr, revokeAndReissueAnnotation)
+	revoked.RevocationReason = cr.Annotations[revokeAndReissueAnnotation]
+
+	// the annotation is removed even if the revocation failed, as retrying it would reissue the certificate again
+	reqLogger.Info("removing revoke-and-reissue annotation")
+	baseToPatch := client.MergeFrom(cr.DeepCopy())
+	delete(cr.Annotations, revokeAndReissueAnnotation)
+	if err := r.Client.Patch(ctx, cr, baseToPatch); err != nil {
+		return err
+	}
+
+	// the patch refreshed the status, so the history is appended to after it and written by the next status update
+	reissued := issuanceEntry(reqLogger, certmanv1alpha1.CertificateIssued, revokeAndReissueTrigger, certificateSecret, leClient)
+	reissued.Actor = revoked.Actor
+	if revokeErr != nil {
+		appendHistory(cr, reissued)
+		return fmt.Errorf("the certificate was reissued, but revoking the previous certificate %s failed: %w", certificate.SerialNumber.String(), revokeErr)
+	}
+	appendHistory(cr, revoked)
+	appendHistory(cr, reissued)
 	return nil
 }
@@ -17,26 +17,161 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+	"github.com/openshift/certman-operator/pkg/leclient"
 )
 
 func TestRevokeCertificate(t *testing.T) {
 	t.Run("errors if lets-encrypt account secret is bad", func(t *testing.T) {
 		testClient := setUpTestClient(t, []runtime.Object{certRequest, validCertSecret})
 		rcr := CertificateRequestReconciler{
+			Recorder:      &record.FakeRecorder{},
 			Client:        testClient,
 			ClientBuilder: setUpFakeAWSClient,
 		}
 
 		nullLogger := logr.Discard()
 
-		err := rcr.RevokeCertificate(nullLogger, certRequest)
+		err := rcr.RevokeCertificate(context.TODO(), nullLogger, certRequest)
 
 		if err == nil {
 			t.Error("expected an error")
 		}
 	})
 }
+
+func TestRevokeAndReissueCertificate(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		reissueErr error
+		expectErr  bool
+	}{
+		{
+			name:       "revokes and reissues a key compromised certificate",
+			annotation: "keyCompromise",
+			expectErr:  false,
+		},
+		{
+			name:       "errors on an unsupported revocation reason",
+			annotation: "notAReason",
+			expectErr:  true,
+		},
+		{
+			name:       "keeps the certificate unrevoked when the reissue fails",
+			annotation: "keyCompromise",
+			reissueErr: errors.New("order rejected"),
+			expectErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Annotations = map[string]string{revokeAndReissueAnnotation: test.annotation}
+
+			leSecret := testLESecret.DeepCopy()
+			leSecret.Data["account-url"] = []byte("proto://use.mock.acme.client")
+
+			certPEM, err := generateFakeLECertPEM()
+			if err != nil {
+				t.Fatalf("unexpected error generating certificate: %s", err)
+			}
+			certSecret := validCertSecret.DeepCopy()
+			certSecret.Data[corev1.TLSCertKey] = certPEM
+
+			testClient := setUpTestClient(t, []runtime.Object{leSecret, cr, certSecret, clusterDeploymentComplete})
+			rcr := CertificateRequestReconciler{
+				Recorder:      &record.FakeRecorder{},
+				Client:        testClient,
+				ClientBuilder: setUpFakeAWSClient,
+			}
+
+			leClient, err := leclient.NewClient(context.TODO(), testClient)
+			if err != nil {
+				t.Fatalf("unexpected error creating leclient: %s", err)
+			}
+
+			found := &corev1.Secret{}
+			if err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, found); err != nil {
+				t.Fatalf("unexpected error getting secret: %s", err)
+			}
+
+			var issuer leclient.LetsEncryptClientInterface = leClient
+			if test.reissueErr != nil {
+				issuer = &failingOrderClient{LetsEncryptClientInterface: leClient, err: test.reissueErr}
+			}
+
+			err = rcr.RevokeAndReissueCertificate(context.TODO(), logr.Discard(), cr, found, issuer)
+			if (err != nil) != test.expectErr {
+				t.Fatalf("RevokeAndReissueCertificate() error = %v, expected error: %t", err, test.expectErr)
+			}
+
+			actual := &certmanv1alpha1.CertificateRequest{}
+			if err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveCertificateRequestName}, actual); err != nil {
+				t.Fatalf("unexpected error getting certificate request: %s", err)
+			}
+			updated := &corev1.Secret{}
+			if err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: testHiveNamespace, Name: testHiveSecretName}, updated); err != nil {
+				t.Fatalf("unexpected error getting secret: %s", err)
+			}
+
+			if test.reissueErr != nil {
+				if leClient.Client.(*acmemock.FakeAcmeClient).RevokeCertificateCalled {
+					t.Error("expected the certificate not to be revoked before its replacement is issued")
+				}
+				if _, ok := actual.Annotations[revokeAndReissueAnnotation]; !ok {
+					t.Error("expected the revoke-and-reissue annotation to be kept for a retry")
+				}
+				if string(updated.Data[corev1.TLSCertKey]) != string(certPEM) {
+					t.Error("expected the certificate secret to be left untouched")
+				}
+				return
+			}
+			if test.expectErr {
+				return
+			}
+
+			if !leClient.Client.(*acmemock.FakeAcmeClient).RevokeCertificateCalled {
+				t.Error("expected the certificate to be revoked")
+			}
+			if _, ok := actual.Annotations[revokeAndReissueAnnotation]; ok {
+				t.Error("expected the revoke-and-reissue annotation to be removed")
+			}
+			if string(updated.Data[corev1.TLSCertKey]) == string(certPEM) {
+				t.Error("expected the certificate to be reissued")
+			}
+
+			if len(cr.Status.History) != 2 {
+				t.Fatalf("expected the revocation and the reissue in the history, got %v", cr.Status.History)
+			}
+			if revoked := cr.Status.History[0]; revoked.Action != certmanv1alpha1.CertificateRevoked || revoked.RevocationReason != test.annotation || revoked.SerialNumber == "" {
+				t.Errorf("unexpected revocation entry %+v", revoked)
+			}
+			if reissued := cr.Status.History[1]; reissued.Action != certmanv1alpha1.CertificateIssued || reissued.Trigger != revokeAndReissueTrigger || reissued.SerialNumber == cr.Status.History[0].SerialNumber {
+				t.Errorf("unexpected reissue entry %+v", reissued)
+			}
+		})
+	}
+}
+
+// failingOrderClient is an ACME client whose orders fail.
+type failingOrderClient struct {
+	leclient.LetsEncryptClientInterface
+	err error
+}
+
+func (c *failingOrderClient) CreateOrder([]string) error {
+	return c.err
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// inScope returns true if the CertificateRequest belongs to a ClusterDeployment in the Scope of the operator. A
+// CertificateRequest whose ClusterDeployment is already gone is kept in scope, so that its finalizer still runs.
+func (r *CertificateRequestReconciler) inScope(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+	if !r.Scope.MatchesNamespace(cr.Namespace) {
+		return false, nil
+	}
+	if !r.Scope.HasSelector() {
+		return true, nil
+	}
+
+	clusterDeploymentName := ""
+	for _, o := range cr.OwnerReferences {
+		if o.Kind == clusterDeploymentType {
+			clusterDeploymentName = o.Name
+		}
+	}
+	if clusterDeploymentName == "" {
+		return true, nil
+	}
+
+	cd := &hivev1.ClusterDeployment{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: clusterDeploymentName}, cd); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return r.Scope.Matches(cd), nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+func TestInScope(t *testing.T) {
+	labelledClusterDeployment := clusterDeploymentComplete.DeepCopy()
+	labelledClusterDeployment.Labels = map[string]string{"shard": "a"}
+
+	tests := []struct {
+		name          string
+		namespaces    string
+		selector      string
+		clientObjects []runtime.Object
+		expected      bool
+	}{
+		{
+			name:          "unset scope",
+			clientObjects: []runtime.Object{clusterDeploymentComplete},
+			expected:      true,
+		},
+		{
+			name:          "other namespace",
+			namespaces:    "uhc-staging-*",
+			clientObjects: []runtime.Object{labelledClusterDeployment},
+			expected:      false,
+		},
+		{
+			name:          "selected clusterdeployment",
+			selector:      "shard=a",
+			clientObjects: []runtime.Object{labelledClusterDeployment},
+			expected:      true,
+		},
+		{
+			name:          "clusterdeployment of another shard",
+			selector:      "shard=b",
+			clientObjects: []runtime.Object{labelledClusterDeployment},
+			expected:      false,
+		},
+		{
+			name:     "deleted clusterdeployment",
+			selector: "shard=b",
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scope, err := utils.NewScope(test.namespaces, test.selector)
+			require.NoError(t, err)
+			r := CertificateRequestReconciler{Client: setUpTestClient(t, test.clientObjects), Scope: scope}
+
+			inScope, err := r.inScope(context.TODO(), certRequest.DeepCopy())
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, inScope)
+		})
+	}
+}
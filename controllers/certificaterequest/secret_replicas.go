@@ -0,0 +1,186 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	// replicaOfNamespaceLabel and replicaOfNameLabel are set on secret replicas to the namespace and name of their
+	// CertificateRequest. Replicas cannot be owned by the CertificateRequest as they may live in other namespaces.
+	replicaOfNamespaceLabel = "certman.managed.openshift.io/replica-of-namespace"
+	replicaOfNameLabel      = "certman.managed.openshift.io/replica-of-name"
+)
+
+// syncSecretReplicas copies the certificate secret to every replica listed by the CertificateRequest and deletes
+// replicas that are no longer listed. Every replica is checked before any is written, so that a conflicting replica
+// leaves all of them on the same certificate. Replicas are compared with the certificate secret on every reconcile,
+// so a failed update is retried until all copies match.
+func (r *CertificateRequestReconciler) syncSecretReplicas(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	replicas, err := r.getSecretReplicas(ctx, cr, certificateSecret)
+	if err != nil {
+		return err
+	}
+
+	wanted := map[types.NamespacedName]bool{}
+	for _, replica := range replicas {
+		name := types.NamespacedName{Name: replica.Name, Namespace: replica.Namespace}
+		wanted[name] = true
+
+		if replica.ResourceVersion == "" {
+			reqLogger.Info("creating secret replica", "Secret", name)
+			replica.Type = certificateSecret.Type
+			applySecretReplica(cr, certificateSecret, replica)
+			if err := r.Client.Create(ctx, replica); err != nil {
+				return fmt.Errorf("failed to create secret replica %s: %w", name, err)
+			}
+			continue
+		}
+
+		if applySecretReplica(cr, certificateSecret, replica) {
+			reqLogger.Info("updating secret replica", "Secret", name)
+			if err := r.Client.Update(ctx, replica); err != nil {
+				return fmt.Errorf("failed to update secret replica %s: %w", name, err)
+			}
+		}
+	}
+
+	return r.pruneSecretReplicas(ctx, reqLogger, cr, wanted)
+}
+
+// getSecretReplicas returns the replicas listed by the CertificateRequest, as stored or, for those yet to be created,
+// with only their name and namespace set. It fails if a replica is listed twice, is the certificate secret, or is an
+// existing secret that is not a replica of the CertificateRequest, so that issuance can be refused before ordering a
+// certificate that could not be copied to every replica.
+func (r *CertificateRequestReconciler) getSecretReplicas(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) ([]*corev1.Secret, error) {
+	replicas := []*corev1.Secret{}
+	listed := map[types.NamespacedName]bool{}
+
+	for _, ref := range cr.Spec.SecretReplicas {
+		name := secretReplicaName(cr, ref)
+		if name.Name == certificateSecret.Name && name.Namespace == certificateSecret.Namespace {
+			return nil, fmt.Errorf("secret replica %s is the certificate secret", name)
+		}
+		if listed[name] {
+			return nil, fmt.Errorf("secret replica %s is listed more than once", name)
+		}
+		listed[name] = true
+
+		replica := &corev1.Secret{}
+		err := r.Client.Get(ctx, name, replica)
+		if errors.IsNotFound(err) {
+			replicas = append(replicas, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret replica %s: %w", name, err)
+		}
+
+		if !isSecretReplicaOf(cr, replica) {
+			return nil, fmt.Errorf("secret %s already exists and is not a replica of this certificaterequest", name)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return replicas, nil
+}
+
+// pruneSecretReplicas deletes the replicas of the CertificateRequest that are not in keep.
+func (r *CertificateRequestReconciler) pruneSecretReplicas(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, keep map[types.NamespacedName]bool) error {
+	replicas := &corev1.SecretList{}
+	err := r.Client.List(ctx, replicas, client.MatchingLabels{
+		replicaOfNamespaceLabel: cr.Namespace,
+		replicaOfNameLabel:      cr.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list secret replicas: %w", err)
+	}
+
+	for i := range replicas.Items {
+		replica := &replicas.Items[i]
+		name := types.NamespacedName{Name: replica.Name, Namespace: replica.Namespace}
+		if keep[name] {
+			continue
+		}
+
+		reqLogger.Info("deleting secret replica", "Secret", name)
+		if err := r.Client.Delete(ctx, replica); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret replica %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// secretReplicaName returns the namespaced name of a replica, defaulting to the namespace of the CertificateRequest.
+func secretReplicaName(cr *certmanv1alpha1.CertificateRequest, ref corev1.SecretReference) types.NamespacedName {
+	if ref.Namespace == "" {
+		return types.NamespacedName{Name: ref.Name, Namespace: cr.Namespace}
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+}
+
+// isSecretReplicaOf returns true if secret is labeled as a replica of the CertificateRequest.
+func isSecretReplicaOf(cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) bool {
+	return secret.Labels[replicaOfNamespaceLabel] == cr.Namespace && secret.Labels[replicaOfNameLabel] == cr.Name
+}
+
+// applySecretReplica copies the data of the certificate secret to replica and sets the replica labels and the
+// secretTemplate of the CertificateRequest on it. It returns true if replica was changed.
+func applySecretReplica(cr *certmanv1alpha1.CertificateRequest, certificateSecret, replica *corev1.Secret) bool {
+	changed := applySecretTemplate(cr, replica)
+
+	changed = mergeStringMap(&replica.Labels, map[string]string{
+		replicaOfNamespaceLabel: cr.Namespace,
+		replicaOfNameLabel:      cr.Name,
+	}) || changed
+
+	if !reflect.DeepEqual(replica.Data, certificateSecret.Data) {
+		replica.Data = make(map[string][]byte, len(certificateSecret.Data))
+		for k, v := range certificateSecret.Data {
+			replica.Data[k] = v
+		}
+		changed = true
+	}
+
+	return changed
+}
+
+// certificateRequestForSecretReplica enqueues the CertificateRequest a secret replica belongs to, so that replicas
+// modified or deleted out of band are restored.
+func certificateRequestForSecretReplica(ctx context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	namespace, name := labels[replicaOfNamespaceLabel], labels[replicaOfNameLabel]
+	if namespace == "" || name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}}
+}
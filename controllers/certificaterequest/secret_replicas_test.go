@@ -0,0 +1,157 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestSyncSecretReplicas(t *testing.T) {
+	certificateSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testHiveSecretName, Namespace: testHiveNamespace},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	replicaLabels := map[string]string{replicaOfNamespaceLabel: testHiveNamespace, replicaOfNameLabel: "test-cr"}
+
+	staleReplica := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: "ingress", Labels: replicaLabels},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("old cert")},
+	}
+	outdatedReplica := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "outdated", Namespace: "ingress", Labels: replicaLabels},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("old cert")},
+	}
+	unrelatedSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "ingress"},
+	}
+
+	tests := []struct {
+		name            string
+		replicas        []corev1.SecretReference
+		expectError     bool
+		expectedSecrets []types.NamespacedName
+		deletedSecrets  []types.NamespacedName
+		missingSecrets  []types.NamespacedName
+	}{
+		{
+			name: "creates and updates replicas and deletes unlisted ones",
+			replicas: []corev1.SecretReference{
+				{Name: "copy"},
+				{Name: "outdated", Namespace: "ingress"},
+			},
+			expectedSecrets: []types.NamespacedName{
+				{Name: "copy", Namespace: testHiveNamespace},
+				{Name: "outdated", Namespace: "ingress"},
+			},
+			deletedSecrets: []types.NamespacedName{{Name: "stale", Namespace: "ingress"}},
+		},
+		{
+			name:           "deletes all replicas when none are listed",
+			deletedSecrets: []types.NamespacedName{{Name: "stale", Namespace: "ingress"}, {Name: "outdated", Namespace: "ingress"}},
+		},
+		{
+			name:        "refuses to overwrite a secret that is not a replica",
+			replicas:    []corev1.SecretReference{{Name: "unrelated", Namespace: "ingress"}},
+			expectError: true,
+		},
+		{
+			name:        "refuses to replicate onto the certificate secret",
+			replicas:    []corev1.SecretReference{{Name: testHiveSecretName}},
+			expectError: true,
+		},
+		{
+			name: "writes no replica when one of them conflicts",
+			replicas: []corev1.SecretReference{
+				{Name: "copy"},
+				{Name: "unrelated", Namespace: "ingress"},
+			},
+			expectError:    true,
+			missingSecrets: []types.NamespacedName{{Name: "copy", Namespace: testHiveNamespace}},
+		},
+		{
+			name: "refuses a replica listed twice",
+			replicas: []corev1.SecretReference{
+				{Name: "copy"},
+				{Name: "copy", Namespace: testHiveNamespace},
+			},
+			expectError:    true,
+			missingSecrets: []types.NamespacedName{{Name: "copy", Namespace: testHiveNamespace}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{
+				certificateSecret.DeepCopy(), staleReplica.DeepCopy(), outdatedReplica.DeepCopy(), unrelatedSecret.DeepCopy(),
+			})}
+			cr := &certmanv1alpha1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cr", Namespace: testHiveNamespace},
+				Spec:       certmanv1alpha1.CertificateRequestSpec{SecretReplicas: test.replicas},
+			}
+
+			err := r.syncSecretReplicas(context.TODO(), logr.Discard(), cr, certificateSecret)
+			if test.expectError {
+				assert.Error(t, err)
+				for _, name := range test.missingSecrets {
+					err := r.Client.Get(context.TODO(), name, &corev1.Secret{})
+					assert.True(t, errors.IsNotFound(err), "expected %s not to be created", name)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			for _, name := range test.expectedSecrets {
+				replica := &corev1.Secret{}
+				require.NoError(t, r.Client.Get(context.TODO(), name, replica))
+				assert.Equal(t, certificateSecret.Data, replica.Data)
+				assert.True(t, isSecretReplicaOf(cr, replica))
+			}
+
+			for _, name := range test.deletedSecrets {
+				err := r.Client.Get(context.TODO(), name, &corev1.Secret{})
+				assert.True(t, errors.IsNotFound(err), "expected %s to be deleted", name)
+			}
+		})
+	}
+}
+
+func TestCertificateRequestForSecretReplica(t *testing.T) {
+	replica := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      "copy",
+		Namespace: "ingress",
+		Labels:    map[string]string{replicaOfNamespaceLabel: testHiveNamespace, replicaOfNameLabel: "test-cr"},
+	}}
+
+	requests := certificateRequestForSecretReplica(context.TODO(), replica)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Name: "test-cr", Namespace: testHiveNamespace}, requests[0].NamespacedName)
+
+	assert.Empty(t, certificateRequestForSecretReplica(context.TODO(), &corev1.Secret{}))
+}
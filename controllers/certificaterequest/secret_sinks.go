@@ -0,0 +1,117 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/secretsink"
+)
+
+const (
+	secretSinkPushedReason     = "SecretSinkPushed"
+	secretSinkPushFailedReason = "SecretSinkPushFailed"
+)
+
+// syncSecretSinks pushes the certificate secret to every sink of the CertificateRequest its status does not record
+// the certificate as pushed to, and forgets the status of the sinks that are no longer listed. A failed push does
+// not hold back the other sinks and is retried on the next reconcile.
+func (r *CertificateRequestReconciler) syncSecretSinks(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	if len(cr.Spec.SecretSinks) == 0 && len(cr.Status.SecretSinks) == 0 {
+		return nil
+	}
+
+	certs, err := parseCertificates(certificateSecret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", corev1.TLSCertKey, err)
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("secret %s has no certificate", certificateSecret.Name)
+	}
+	serialNumber := certs[0].SerialNumber.String()
+
+	pushed := map[string]certmanv1alpha1.SecretSinkStatus{}
+	for _, status := range cr.Status.SecretSinks {
+		pushed[status.Name] = status
+	}
+
+	var statuses []certmanv1alpha1.SecretSinkStatus
+	var errs []error
+	for i := range cr.Spec.SecretSinks {
+		sink := &cr.Spec.SecretSinks[i]
+		status, found := pushed[sink.Name]
+		if found && status.SerialNumber == serialNumber {
+			statuses = append(statuses, status)
+			continue
+		}
+
+		version, err := r.pushToSecretSink(ctx, cr, sink, certificateSecret)
+		if err != nil {
+			r.recordEvent(cr, corev1.EventTypeWarning, secretSinkPushFailedReason, "failed to push the certificate to secret sink %s: %v", sink.Name, err)
+			errs = append(errs, fmt.Errorf("failed to push the certificate to secret sink %s: %w", sink.Name, err))
+			if found {
+				statuses = append(statuses, status)
+			}
+			continue
+		}
+
+		reqLogger.Info("pushed certificate to secret sink", "SecretSink", sink.Name, "Version", version)
+		r.recordEvent(cr, corev1.EventTypeNormal, secretSinkPushedReason, "certificate pushed to secret sink %s as version %s", sink.Name, version)
+		statuses = append(statuses, certmanv1alpha1.SecretSinkStatus{
+			Name:         sink.Name,
+			Version:      version,
+			SerialNumber: serialNumber,
+			PushTime:     metav1.Now(),
+		})
+	}
+
+	if !reflect.DeepEqual(statuses, cr.Status.SecretSinks) {
+		cr.Status.SecretSinks = statuses
+		if err := r.Client.Status().Update(ctx, cr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// pushToSecretSink pushes the certificate secret to sink and returns the version it was pushed as.
+func (r *CertificateRequestReconciler) pushToSecretSink(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, sink *certmanv1alpha1.SecretSink, certificateSecret *corev1.Secret) (string, error) {
+	// the private key never leaves the KMS, so there is nothing to push with the certificate
+	if cr.Spec.KMS != nil {
+		return "", errors.New("secretSinks cannot be used with a KMS key")
+	}
+
+	s, err := r.SecretSinkBuilder(ctx, r.Client, sink, cr.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	return s.Push(ctx, &secretsink.Bundle{
+		Certificate: certificateSecret.Data[corev1.TLSCertKey],
+		PrivateKey:  certificateSecret.Data[corev1.TLSPrivateKeyKey],
+		CA:          certificateSecret.Data[caCertSecretKey],
+	})
+}
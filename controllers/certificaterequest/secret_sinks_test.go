@@ -0,0 +1,169 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/secretsink"
+)
+
+// fakeSecretSink records the bundles pushed to it.
+type fakeSecretSink struct {
+	pushed []*secretsink.Bundle
+	err    error
+}
+
+func (f *fakeSecretSink) Push(_ context.Context, bundle *secretsink.Bundle) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	f.pushed = append(f.pushed, bundle)
+	return "v1", nil
+}
+
+func TestSyncSecretSinks(t *testing.T) {
+	leaf := newTestCertificate(t, newTestLeafTemplate(certRequest.Spec.DnsNames, time.Now().Add(24*time.Hour)), nil)
+	serialNumber := leaf.cert.SerialNumber.String()
+	secret := &corev1.Secret{Data: map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.cert.Raw}),
+		corev1.TLSPrivateKeyKey: []byte("key"),
+		caCertSecretKey:         []byte("ca"),
+	}}
+	vault := certmanv1alpha1.SecretSink{Name: "vault", Vault: &certmanv1alpha1.VaultSecretSink{}}
+	aws := certmanv1alpha1.SecretSink{Name: "aws", AWSSecretsManager: &certmanv1alpha1.AWSSecretsManagerSink{}}
+
+	tests := []struct {
+		name             string
+		sinks            []certmanv1alpha1.SecretSink
+		status           []certmanv1alpha1.SecretSinkStatus
+		kms              bool
+		failing          map[string]bool
+		expectError      string
+		expectedPushes   map[string]int
+		expectedStatuses []string
+	}{
+		{
+			name:             "pushes to every sink",
+			sinks:            []certmanv1alpha1.SecretSink{vault, aws},
+			expectedPushes:   map[string]int{"vault": 1, "aws": 1},
+			expectedStatuses: []string{"vault", "aws"},
+		},
+		{
+			name:             "skips the sinks the certificate was pushed to",
+			sinks:            []certmanv1alpha1.SecretSink{vault, aws},
+			status:           []certmanv1alpha1.SecretSinkStatus{{Name: "vault", Version: "v0", SerialNumber: serialNumber}},
+			expectedPushes:   map[string]int{"aws": 1},
+			expectedStatuses: []string{"vault", "aws"},
+		},
+		{
+			name:             "pushes a renewed certificate",
+			sinks:            []certmanv1alpha1.SecretSink{vault},
+			status:           []certmanv1alpha1.SecretSinkStatus{{Name: "vault", Version: "v0", SerialNumber: "1"}},
+			expectedPushes:   map[string]int{"vault": 1},
+			expectedStatuses: []string{"vault"},
+		},
+		{
+			name:             "forgets the sinks no longer listed",
+			sinks:            []certmanv1alpha1.SecretSink{aws},
+			status:           []certmanv1alpha1.SecretSinkStatus{{Name: "vault", Version: "v0", SerialNumber: serialNumber}},
+			expectedPushes:   map[string]int{"aws": 1},
+			expectedStatuses: []string{"aws"},
+		},
+		{
+			name:             "pushes to the other sinks when one fails",
+			sinks:            []certmanv1alpha1.SecretSink{vault, aws},
+			failing:          map[string]bool{"vault": true},
+			expectError:      "secret sink vault: forbidden",
+			expectedPushes:   map[string]int{"aws": 1},
+			expectedStatuses: []string{"aws"},
+		},
+		{
+			name:        "rejects a KMS key",
+			sinks:       []certmanv1alpha1.SecretSink{vault},
+			kms:         true,
+			expectError: "cannot be used with a KMS key",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Spec.SecretSinks = test.sinks
+			cr.Status.SecretSinks = test.status
+			if test.kms {
+				cr.Spec.KMS = &certmanv1alpha1.KMSKey{AWS: &certmanv1alpha1.AWSKMSKey{}}
+			}
+
+			sinks := map[string]*fakeSecretSink{}
+			r := &CertificateRequestReconciler{
+				Client:   setUpTestClient(t, []runtime.Object{cr}),
+				Recorder: record.NewFakeRecorder(20),
+				SecretSinkBuilder: func(_ context.Context, _ client.Client, sink *certmanv1alpha1.SecretSink, _ string) (secretsink.Sink, error) {
+					s := &fakeSecretSink{}
+					if test.failing[sink.Name] {
+						s.err = errors.New("forbidden")
+					}
+					sinks[sink.Name] = s
+					return s, nil
+				},
+			}
+
+			err := r.syncSecretSinks(context.TODO(), logr.Discard(), cr, secret)
+			if test.expectError != "" {
+				assert.ErrorContains(t, err, test.expectError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			pushes := map[string]int{}
+			for name, s := range sinks {
+				if len(s.pushed) > 0 {
+					pushes[name] = len(s.pushed)
+					assert.Equal(t, []byte("ca"), s.pushed[0].CA)
+				}
+			}
+			if test.expectedPushes == nil {
+				test.expectedPushes = map[string]int{}
+			}
+			assert.Equal(t, test.expectedPushes, pushes)
+
+			updated := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, updated))
+			var statuses []string
+			for _, status := range updated.Status.SecretSinks {
+				statuses = append(statuses, status.Name)
+				assert.Equal(t, serialNumber, status.SerialNumber)
+			}
+			assert.Equal(t, test.expectedStatuses, statuses)
+		})
+	}
+}
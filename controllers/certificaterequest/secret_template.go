@@ -0,0 +1,63 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+// certificateRequestLabel is set on every certificate secret to the name of its CertificateRequest.
+const certificateRequestLabel = "certificate_request"
+
+// applySecretTemplate sets the certificate_request and cached labels and the labels and annotations of the
+// CertificateRequest's secretTemplate on the certificate secret, leaving any other labels and annotations untouched.
+// It returns true if the secret was changed.
+func applySecretTemplate(cr *certmanv1alpha1.CertificateRequest, secret *corev1.Secret) bool {
+	labels := map[string]string{certificateRequestLabel: cr.Name, utils.CachedSecretLabel: "true"}
+	annotations := map[string]string{}
+
+	if cr.Spec.SecretTemplate != nil {
+		for k, v := range cr.Spec.SecretTemplate.Labels {
+			labels[k] = v
+		}
+		for k, v := range cr.Spec.SecretTemplate.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	changed := mergeStringMap(&secret.Labels, labels)
+	return mergeStringMap(&secret.Annotations, annotations) || changed
+}
+
+// mergeStringMap copies src into dst, allocating dst if needed, and returns true if dst was changed.
+func mergeStringMap(dst *map[string]string, src map[string]string) bool {
+	changed := false
+	for k, v := range src {
+		if current, ok := (*dst)[k]; ok && current == v {
+			continue
+		}
+		if *dst == nil {
+			*dst = map[string]string{}
+		}
+		(*dst)[k] = v
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+func TestApplySecretTemplate(t *testing.T) {
+	template := &certmanv1alpha1.CertificateSecretTemplate{
+		Labels:      map[string]string{"cost-center": "1234"},
+		Annotations: map[string]string{"replicator.example.com/replicate": "true"},
+	}
+
+	tests := []struct {
+		name                string
+		template            *certmanv1alpha1.CertificateSecretTemplate
+		secret              *corev1.Secret
+		expectChanged       bool
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:           "sets the certificate_request label on a new secret",
+			secret:         &corev1.Secret{},
+			expectChanged:  true,
+			expectedLabels: map[string]string{certificateRequestLabel: "test-cr", utils.CachedSecretLabel: "true"},
+		},
+		{
+			name:           "sets the cached label on a secret labeled before it existed",
+			secret:         &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{certificateRequestLabel: "test-cr"}}},
+			expectChanged:  true,
+			expectedLabels: map[string]string{certificateRequestLabel: "test-cr", utils.CachedSecretLabel: "true"},
+		},
+		{
+			name:                "applies the template and keeps existing metadata",
+			template:            template,
+			secret:              &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"owner": "sre"}}},
+			expectChanged:       true,
+			expectedLabels:      map[string]string{certificateRequestLabel: "test-cr", utils.CachedSecretLabel: "true", "owner": "sre", "cost-center": "1234"},
+			expectedAnnotations: map[string]string{"replicator.example.com/replicate": "true"},
+		},
+		{
+			name:     "does not change a secret that already matches",
+			template: template,
+			secret: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{certificateRequestLabel: "test-cr", utils.CachedSecretLabel: "true", "cost-center": "1234"},
+				Annotations: map[string]string{"replicator.example.com/replicate": "true"},
+			}},
+			expectChanged:       false,
+			expectedLabels:      map[string]string{certificateRequestLabel: "test-cr", utils.CachedSecretLabel: "true", "cost-center": "1234"},
+			expectedAnnotations: map[string]string{"replicator.example.com/replicate": "true"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-cr"},
+				Spec:       certmanv1alpha1.CertificateRequestSpec{SecretTemplate: test.template},
+			}
+
+			changed := applySecretTemplate(cr, test.secret)
+
+			assert.Equal(t, test.expectChanged, changed)
+			assert.Equal(t, test.expectedLabels, test.secret.Labels)
+			assert.Equal(t, test.expectedAnnotations, test.secret.Annotations)
+		})
+	}
+}
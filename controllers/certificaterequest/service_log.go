@@ -0,0 +1,102 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/servicelog"
+)
+
+const (
+	serviceLogSentReason   = "ServiceLogSent"
+	serviceLogFailedReason = "ServiceLogFailed"
+
+	serviceLogServiceName = "certman-operator"
+)
+
+// notifyPersistentFailure posts a service log telling the owners of the cluster what to fix once the failed attempts
+// to issue the certificate of the CertificateRequest since it was last issued reach the threshold set in the operator
+// ConfigMap. It is posted once per streak of failures, since the count is only reset by an issuance. Failing to post
+// does not fail the reconcile, so errors are only logged.
+func (r *CertificateRequestReconciler) notifyPersistentFailure(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) {
+	threshold, err := utils.GetServiceLogFailureThreshold(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the service log failure threshold")
+		return
+	}
+	if threshold == 0 || int(cr.Status.FailureCount) != threshold || cr.Status.LastFailure == nil {
+		return
+	}
+
+	clusterDeploymentName := utils.ClusterDeploymentName(cr)
+	if clusterDeploymentName == "" {
+		// standalone clusters are not known to OpenShift Cluster Manager by a ClusterDeployment
+		return
+	}
+	cd := &hivev1.ClusterDeployment{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: clusterDeploymentName}, cd); err != nil {
+		reqLogger.Error(err, "failed to get the ClusterDeployment to post a service log for")
+		return
+	}
+	if cd.Spec.ClusterMetadata == nil || cd.Spec.ClusterMetadata.ClusterID == "" {
+		reqLogger.Info("not posting a service log, the ClusterDeployment has no cluster ID", "ClusterDeployment", cd.Name)
+		return
+	}
+
+	serviceLogs, err := r.ServiceLogClientBuilder(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "failed to create the service log client")
+		r.recordEvent(cr, corev1.EventTypeWarning, serviceLogFailedReason, "failed to post a service log: %v", err)
+		return
+	}
+
+	if err := serviceLogs.Post(ctx, persistentFailureLog(cr, cd)); err != nil {
+		reqLogger.Error(err, "failed to post a service log")
+		r.recordEvent(cr, corev1.EventTypeWarning, serviceLogFailedReason, "failed to post a service log: %v", err)
+		return
+	}
+	reqLogger.Info("posted a service log on the persistent failure", "FailureCount", cr.Status.FailureCount)
+	r.recordEvent(cr, corev1.EventTypeNormal, serviceLogSentReason, "posted a service log after %d failed attempts to issue the certificate", cr.Status.FailureCount)
+}
+
+// persistentFailureLog returns the service log telling the owners of the cluster of the ClusterDeployment that the
+// certificate of the CertificateRequest cannot be issued, and the usual causes they can fix.
+func persistentFailureLog(cr *certmanv1alpha1.CertificateRequest, cd *hivev1.ClusterDeployment) servicelog.Log {
+	description := fmt.Sprintf("The certificate for %s could not be issued after %d attempts. The last attempt failed with: %s. "+
+		"This usually happens when the DNS zone of the base domain %s is no longer delegated to the cluster's cloud DNS provider, "+
+		"or when the cloud credentials of the cluster were revoked or no longer allow managing DNS records. "+
+		"Restore them so that the certificate can be issued before the current one expires.",
+		strings.Join(cr.Spec.DnsNames, ", "), cr.Status.FailureCount, cr.Status.LastFailure.Message, cd.Spec.BaseDomain)
+
+	return servicelog.Log{
+		ClusterUUID: cd.Spec.ClusterMetadata.ClusterID,
+		ServiceName: serviceLogServiceName,
+		Severity:    servicelog.SeverityWarning,
+		Summary:     "Cluster certificate cannot be issued",
+		Description: description,
+	}
+}
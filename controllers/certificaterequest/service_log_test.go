@@ -0,0 +1,148 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/servicelog"
+)
+
+type fakeServiceLogClient struct {
+	posted []servicelog.Log
+	err    error
+}
+
+func (c *fakeServiceLogClient) Post(_ context.Context, log servicelog.Log) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.posted = append(c.posted, log)
+	return nil
+}
+
+func TestNotifyPersistentFailure(t *testing.T) {
+	cd := clusterDeploymentComplete.DeepCopy()
+	cd.Spec.BaseDomain = "example.com"
+	cd.Spec.ClusterMetadata = &hivev1.ClusterMetadata{ClusterID: "cluster-uuid"}
+	cdWithoutID := clusterDeploymentComplete.DeepCopy()
+
+	tests := []struct {
+		name          string
+		threshold     string
+		failureCount  int32
+		cd            *hivev1.ClusterDeployment
+		postErr       error
+		expectPosted  bool
+		expectedEvent string
+	}{
+		{
+			name:         "disabled",
+			failureCount: 3,
+			cd:           cd,
+		},
+		{
+			name:         "below the threshold",
+			threshold:    "3",
+			failureCount: 2,
+			cd:           cd,
+		},
+		{
+			name:          "threshold reached",
+			threshold:     "3",
+			failureCount:  3,
+			cd:            cd,
+			expectPosted:  true,
+			expectedEvent: "Normal ServiceLogSent posted a service log after 3 failed attempts",
+		},
+		{
+			name:         "already posted for the streak",
+			threshold:    "3",
+			failureCount: 4,
+			cd:           cd,
+		},
+		{
+			name:         "cluster without ID",
+			threshold:    "3",
+			failureCount: 3,
+			cd:           cdWithoutID,
+		},
+		{
+			name:          "post failed",
+			threshold:     "3",
+			failureCount:  3,
+			cd:            cd,
+			postErr:       errors.New("503 Service Unavailable"),
+			expectedEvent: "Warning ServiceLogFailed failed to post a service log: 503 Service Unavailable",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+				Data:       map[string]string{cTypes.ServiceLogFailureThreshold: test.threshold},
+			}
+			cr := certRequest.DeepCopy()
+			cr.Status.FailureCount = test.failureCount
+			cr.Status.LastFailure = &certmanv1alpha1.CertificateFailure{Reason: issuanceFailedReason, Message: "DNS zone not found", Time: metav1.Now()}
+
+			serviceLogs := &fakeServiceLogClient{err: test.postErr}
+			recorder := record.NewFakeRecorder(10)
+			r := &CertificateRequestReconciler{
+				Client:                  setUpTestClient(t, []runtime.Object{configMap, test.cd, cr}),
+				Recorder:                recorder,
+				ServiceLogClientBuilder: func(context.Context, client.Client) (servicelog.Client, error) { return serviceLogs, nil },
+			}
+
+			r.notifyPersistentFailure(context.TODO(), logr.Discard(), cr)
+
+			if test.expectPosted {
+				require.Len(t, serviceLogs.posted, 1)
+				posted := serviceLogs.posted[0]
+				assert.Equal(t, "cluster-uuid", posted.ClusterUUID)
+				assert.Equal(t, servicelog.SeverityWarning, posted.Severity)
+				assert.False(t, posted.InternalOnly)
+				assert.Contains(t, posted.Description, "DNS zone not found")
+				assert.Contains(t, posted.Description, "example.com")
+			} else {
+				assert.Empty(t, serviceLogs.posted)
+			}
+			if test.expectedEvent != "" {
+				require.NotEmpty(t, recorder.Events)
+				assert.Contains(t, <-recorder.Events, test.expectedEvent)
+			} else {
+				assert.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
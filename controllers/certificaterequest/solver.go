@@ -0,0 +1,103 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const wildcardPrefix = "*."
+
+// challengeTypeFor returns the type of the challenge proving control of dnsName: that of the first solver of the
+// CertificateRequest matching it, else the challenge type of the CertificateRequest, else dns-01.
+func challengeTypeFor(cr *certmanv1alpha1.CertificateRequest, dnsName string) certmanv1alpha1.ChallengeType {
+	for _, solver := range cr.Spec.Solvers {
+		if solverMatches(solver, dnsName) {
+			return solver.ChallengeType
+		}
+	}
+	if cr.Spec.ChallengeType != "" {
+		return cr.Spec.ChallengeType
+	}
+	return certmanv1alpha1.DNS01ChallengeType
+}
+
+// solverMatches returns true if dnsName is one of the DNS names of the solver, or is in one of its DNS zones.
+func solverMatches(solver certmanv1alpha1.ChallengeSolver, dnsName string) bool {
+	name := normalizeDNSName(dnsName)
+	for _, solverName := range solver.DNSNames {
+		if normalizeDNSName(solverName) == name {
+			return true
+		}
+	}
+
+	name = strings.TrimPrefix(name, wildcardPrefix)
+	for _, zone := range solver.DNSZones {
+		zone = normalizeDNSName(zone)
+		if name == zone || strings.HasSuffix(name, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// usesDNS01 returns true if the control of any DNS name of the CertificateRequest is proven with a dns-01 challenge.
+func usesDNS01(cr *certmanv1alpha1.CertificateRequest) bool {
+	for _, dnsName := range cr.Spec.DnsNames {
+		if challengeTypeFor(cr, dnsName) == certmanv1alpha1.DNS01ChallengeType {
+			return true
+		}
+	}
+	return false
+}
+
+// answerHTTP01Challenge serves the key authorization of the HTTP-01 challenge of the current authorization on the
+// challenge server of the operator, until the ACME server has validated it.
+func (r *CertificateRequestReconciler) answerHTTP01Challenge(authLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, leClient leclient.LetsEncryptClientInterface, dnsName string) error {
+	if strings.HasPrefix(dnsName, wildcardPrefix) {
+		return fmt.Errorf("http-01 challenges cannot prove control of the wildcard name %s", dnsName)
+	}
+	if r.HTTP01Solver == nil {
+		return fmt.Errorf("http-01 challenges are not available: the operator does not serve them")
+	}
+
+	token, keyAuth, err := leClient.GetHTTP01KeyAuthorization()
+	if err != nil {
+		return fmt.Errorf("could not get the http-01 challenge of %s: %w", dnsName, err)
+	}
+	r.HTTP01Solver.Present(token, keyAuth)
+	defer r.HTTP01Solver.CleanUp(token)
+	r.recordEvent(cr, corev1.EventTypeNormal, challengePresentedReason, "presented the HTTP-01 challenge of %s", dnsName)
+
+	authLogger.Info("updating challenge for authorization", "challenge", leClient.GetChallengeURL())
+	if err := leClient.UpdateChallenge(); err != nil {
+		authLogger.Error(err, "error updating authorization challenge")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eggsampler/acme"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+	"github.com/openshift/certman-operator/pkg/http01"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+func TestChallengeTypeFor(t *testing.T) {
+	solvers := []certmanv1alpha1.ChallengeSolver{
+		{DNSNames: []string{"*.apps.example.com"}, ChallengeType: certmanv1alpha1.DNS01ChallengeType},
+		{DNSZones: []string{"apps.example.com."}, ChallengeType: certmanv1alpha1.HTTP01ChallengeType},
+	}
+
+	tests := []struct {
+		name          string
+		challengeType certmanv1alpha1.ChallengeType
+		solvers       []certmanv1alpha1.ChallengeSolver
+		dnsName       string
+		expected      certmanv1alpha1.ChallengeType
+	}{
+		{
+			name:     "dns-01 by default",
+			dnsName:  "api.example.com",
+			expected: certmanv1alpha1.DNS01ChallengeType,
+		},
+		{
+			name:          "the challenge type of the CertificateRequest",
+			challengeType: certmanv1alpha1.HTTP01ChallengeType,
+			dnsName:       "api.example.com",
+			expected:      certmanv1alpha1.HTTP01ChallengeType,
+		},
+		{
+			name:     "the first solver matching the name",
+			solvers:  solvers,
+			dnsName:  "*.apps.example.com",
+			expected: certmanv1alpha1.DNS01ChallengeType,
+		},
+		{
+			name:     "a solver matching the zone of the name",
+			solvers:  solvers,
+			dnsName:  "Console.Apps.example.com",
+			expected: certmanv1alpha1.HTTP01ChallengeType,
+		},
+		{
+			name:          "the challenge type of the CertificateRequest without a matching solver",
+			challengeType: certmanv1alpha1.DNS01ChallengeType,
+			solvers:       solvers,
+			dnsName:       "api.example.com",
+			expected:      certmanv1alpha1.DNS01ChallengeType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{ChallengeType: tt.challengeType, Solvers: tt.solvers}}
+			assert.Equal(t, tt.expected, challengeTypeFor(cr, tt.dnsName))
+		})
+	}
+}
+
+func TestUsesDNS01(t *testing.T) {
+	cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{
+		DnsNames: []string{"console.apps.example.com", "api.example.com"},
+		Solvers:  []certmanv1alpha1.ChallengeSolver{{DNSZones: []string{"apps.example.com"}, ChallengeType: certmanv1alpha1.HTTP01ChallengeType}},
+	}}
+	assert.True(t, usesDNS01(cr))
+
+	cr.Spec.ChallengeType = certmanv1alpha1.HTTP01ChallengeType
+	assert.False(t, usesDNS01(cr))
+}
+
+func TestAnswerHTTP01Challenge(t *testing.T) {
+	challenge := acme.Challenge{Type: "http-01", Token: "token", KeyAuthorization: "token.thumbprint"}
+
+	tests := []struct {
+		name          string
+		solver        *http01.Server
+		challenge     acme.Challenge
+		dnsName       string
+		expectedError string
+	}{
+		{
+			name:      "answers the challenge",
+			solver:    &http01.Server{},
+			challenge: challenge,
+			dnsName:   "console.apps.example.com",
+		},
+		{
+			name:          "rejects wildcard names",
+			solver:        &http01.Server{},
+			challenge:     challenge,
+			dnsName:       "*.apps.example.com",
+			expectedError: "cannot prove control of the wildcard name",
+		},
+		{
+			name:          "fails when the operator does not serve http-01 challenges",
+			challenge:     challenge,
+			dnsName:       "console.apps.example.com",
+			expectedError: "http-01 challenges are not available",
+		},
+		{
+			name:          "fails when the authorization offers no http-01 challenge",
+			solver:        &http01.Server{},
+			dnsName:       "console.apps.example.com",
+			expectedError: "could not get the http-01 challenge",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeAcmeClient := acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{Available: true})
+			leClient := &leclient.LetsEncryptClient{Client: fakeAcmeClient, Challenge: tt.challenge}
+			r := &CertificateRequestReconciler{Recorder: &record.FakeRecorder{}, HTTP01Solver: tt.solver}
+
+			err := r.answerHTTP01Challenge(logr.Discard(), &certmanv1alpha1.CertificateRequest{}, leClient, tt.dnsName)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.False(t, fakeAcmeClient.UpdateChallengeCalled)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, fakeAcmeClient.UpdateChallengeCalled)
+
+			// the key authorization is no longer served once the challenge was validated
+			recorder := httptest.NewRecorder()
+			tt.solver.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/acme-challenge/token", nil))
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	syncSetUpdatedReason = "SyncSetUpdated"
+	syncSetFailedReason  = "SyncSetFailed"
+
+	// apiServerSecretNamespace and IngressSecretNamespace are the namespaces of a managed cluster the API server
+	// and the ingress controllers read their serving certificates from.
+	apiServerSecretNamespace = "openshift-config"
+	IngressSecretNamespace   = "openshift-ingress"
+
+	// DefaultIngressController is the ingress controller serving the routes of a managed cluster.
+	DefaultIngressController   = "default"
+	ingressControllerNamespace = "openshift-ingress-operator"
+)
+
+// syncSyncSet adds the certificate secret, and the patch making the API server or an ingress controller serve it,
+// to the SyncSet of the CertificateRequest, creating the SyncSet owned by the CertificateRequest if it does not
+// exist. Hive then applies both to the cluster of the ClusterDeployment owning the CertificateRequest, and syncs the
+// secret again whenever the certificate is renewed.
+func (r *CertificateRequestReconciler) syncSyncSet(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	if cr.Spec.SyncSet == nil {
+		return nil
+	}
+
+	err := r.applySyncSet(ctx, reqLogger, cr, certificateSecret)
+	if err != nil {
+		r.recordEvent(cr, corev1.EventTypeWarning, syncSetFailedReason, "failed to update SyncSet %s: %v", syncSetName(cr), err)
+		return fmt.Errorf("failed to update syncset %s: %w", syncSetName(cr), err)
+	}
+
+	return nil
+}
+
+// applySyncSet creates or updates the SyncSet of the CertificateRequest, recording an event if it was changed.
+func (r *CertificateRequestReconciler) applySyncSet(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) error {
+	clusterDeploymentName := ""
+	for _, o := range cr.OwnerReferences {
+		if o.Kind == clusterDeploymentType {
+			clusterDeploymentName = o.Name
+		}
+	}
+	if clusterDeploymentName == "" {
+		return errors.New("syncSet requires a CertificateRequest owned by a ClusterDeployment")
+	}
+
+	mapping, patch, err := syncSetEntries(cr, certificateSecret)
+	if err != nil {
+		return err
+	}
+
+	name := syncSetName(cr)
+	syncSet := &hivev1.SyncSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: cr.Namespace}, syncSet)
+	if kerrors.IsNotFound(err) {
+		syncSet = &hivev1.SyncSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cr.Namespace},
+			Spec: hivev1.SyncSetSpec{
+				SyncSetCommonSpec: hivev1.SyncSetCommonSpec{
+					Secrets: []hivev1.SecretMapping{mapping},
+					Patches: []hivev1.SyncObjectPatch{patch},
+				},
+				ClusterDeploymentRefs: []corev1.LocalObjectReference{{Name: clusterDeploymentName}},
+			},
+		}
+		// a SyncSet named after the CertificateRequest belongs to it, one named in the spec may be shared
+		if cr.Spec.SyncSet.Name == "" {
+			if err := controllerutil.SetControllerReference(cr, syncSet, r.Scheme); err != nil {
+				return err
+			}
+		}
+
+		reqLogger.Info("creating syncset", "SyncSet", name)
+		if err := r.Client.Create(ctx, syncSet); err != nil {
+			return err
+		}
+		r.recordEvent(cr, corev1.EventTypeNormal, syncSetUpdatedReason, "created SyncSet %s delivering secret %s to the cluster", name, certificateSecret.Name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !applySyncSetEntries(syncSet, clusterDeploymentName, mapping, patch) {
+		return nil
+	}
+
+	reqLogger.Info("updating syncset", "SyncSet", name)
+	if err := r.Client.Update(ctx, syncSet); err != nil {
+		return err
+	}
+	r.recordEvent(cr, corev1.EventTypeNormal, syncSetUpdatedReason, "updated SyncSet %s delivering secret %s to the cluster", name, certificateSecret.Name)
+	return nil
+}
+
+// syncSetEntries returns the secret mapping copying the certificate secret to the cluster, and the patch
+// configuring the target of the CertificateRequest to serve it.
+func syncSetEntries(cr *certmanv1alpha1.CertificateRequest, certificateSecret *corev1.Secret) (hivev1.SecretMapping, hivev1.SyncObjectPatch, error) {
+	var namespace string
+	var patch hivev1.SyncObjectPatch
+
+	switch cr.Spec.SyncSet.Target {
+	case certmanv1alpha1.SyncSetTargetAPIServer:
+		namespace = apiServerSecretNamespace
+		patch = hivev1.SyncObjectPatch{
+			APIVersion: "config.openshift.io/v1",
+			Kind:       "APIServer",
+			Name:       "cluster",
+		}
+		body := map[string]interface{}{
+			"spec": map[string]interface{}{
+				"servingCerts": map[string]interface{}{
+					"namedCertificates": []interface{}{
+						map[string]interface{}{
+							"names":              cr.Spec.DnsNames,
+							"servingCertificate": map[string]string{"name": certificateSecret.Name},
+						},
+					},
+				},
+			},
+		}
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return hivev1.SecretMapping{}, hivev1.SyncObjectPatch{}, err
+		}
+		patch.Patch = string(raw)
+		patch.PatchType = "merge"
+	case certmanv1alpha1.SyncSetTargetIngress:
+		ingressController := cr.Spec.SyncSet.IngressController
+		if ingressController == "" {
+			ingressController = DefaultIngressController
+		}
+		namespace = IngressSecretNamespace
+		patch = IngressControllerPatch(ingressController, certificateSecret.Name)
+	default:
+		return hivev1.SecretMapping{}, hivev1.SyncObjectPatch{}, fmt.Errorf("unknown syncSet target %q", cr.Spec.SyncSet.Target)
+	}
+
+	mapping := hivev1.SecretMapping{
+		SourceRef: hivev1.SecretReference{Name: certificateSecret.Name, Namespace: certificateSecret.Namespace},
+		TargetRef: hivev1.SecretReference{Name: certificateSecret.Name, Namespace: namespace},
+	}
+
+	return mapping, patch, nil
+}
+
+// IngressControllerPatch returns the SyncSet patch making the secret of the openshift-ingress namespace the default
+// certificate of the ingress controller of a managed cluster.
+func IngressControllerPatch(ingressController, secretName string) hivev1.SyncObjectPatch {
+	return hivev1.SyncObjectPatch{
+		APIVersion: "operator.openshift.io/v1",
+		Kind:       "IngressController",
+		Name:       ingressController,
+		Namespace:  ingressControllerNamespace,
+		Patch:      fmt.Sprintf(`{"spec":{"defaultCertificate":{"name":%q}}}`, secretName),
+		PatchType:  "merge",
+	}
+}
+
+// applySyncSetEntries adds the ClusterDeployment, the secret mapping and the patch to the SyncSet, replacing the
+// mapping with the same target secret and the patch of the same object. It returns true if the SyncSet was changed.
+func applySyncSetEntries(syncSet *hivev1.SyncSet, clusterDeploymentName string, mapping hivev1.SecretMapping, patch hivev1.SyncObjectPatch) bool {
+	changed := false
+
+	found := false
+	for _, ref := range syncSet.Spec.ClusterDeploymentRefs {
+		if ref.Name == clusterDeploymentName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		syncSet.Spec.ClusterDeploymentRefs = append(syncSet.Spec.ClusterDeploymentRefs, corev1.LocalObjectReference{Name: clusterDeploymentName})
+		changed = true
+	}
+
+	found = false
+	for i, m := range syncSet.Spec.Secrets {
+		if m.TargetRef == mapping.TargetRef {
+			found = true
+			if m.SourceRef != mapping.SourceRef {
+				syncSet.Spec.Secrets[i] = mapping
+				changed = true
+			}
+			break
+		}
+	}
+	if !found {
+		syncSet.Spec.Secrets = append(syncSet.Spec.Secrets, mapping)
+		changed = true
+	}
+
+	found = false
+	for i, p := range syncSet.Spec.Patches {
+		if p.APIVersion == patch.APIVersion && p.Kind == patch.Kind && p.Name == patch.Name && p.Namespace == patch.Namespace {
+			found = true
+			if p != patch {
+				syncSet.Spec.Patches[i] = patch
+				changed = true
+			}
+			break
+		}
+	}
+	if !found {
+		syncSet.Spec.Patches = append(syncSet.Spec.Patches, patch)
+		changed = true
+	}
+
+	return changed
+}
+
+// syncSetName returns the name of the SyncSet of the CertificateRequest.
+func syncSetName(cr *certmanv1alpha1.CertificateRequest) string {
+	if cr.Spec.SyncSet.Name != "" {
+		return cr.Spec.SyncSet.Name
+	}
+	return cr.Name
+}
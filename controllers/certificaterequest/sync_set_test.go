@@ -0,0 +1,179 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestSyncSyncSet(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: testHiveSecretName, Namespace: testHiveNamespace}}
+	ingressPatch := hivev1.SyncObjectPatch{
+		APIVersion: "operator.openshift.io/v1",
+		Kind:       "IngressController",
+		Name:       "default",
+		Namespace:  "openshift-ingress-operator",
+		Patch:      `{"spec":{"defaultCertificate":{"name":"` + testHiveSecretName + `"}}}`,
+		PatchType:  "merge",
+	}
+	ingressMapping := hivev1.SecretMapping{
+		SourceRef: hivev1.SecretReference{Name: testHiveSecretName, Namespace: testHiveNamespace},
+		TargetRef: hivev1.SecretReference{Name: testHiveSecretName, Namespace: "openshift-ingress"},
+	}
+	otherPatch := hivev1.SyncObjectPatch{APIVersion: "v1", Kind: "ConfigMap", Name: "other", Patch: "{}"}
+
+	tests := []struct {
+		name             string
+		syncSet          *certmanv1alpha1.CertificateSyncSet
+		existing         *hivev1.SyncSet
+		unowned          bool
+		expectError      string
+		expectedName     string
+		expectedPatches  []hivev1.SyncObjectPatch
+		expectedMappings []hivev1.SecretMapping
+		expectedOwned    bool
+	}{
+		{
+			name:    "does nothing without a syncSet",
+			syncSet: nil,
+		},
+		{
+			name:             "creates a SyncSet owned by the certificaterequest",
+			syncSet:          &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress},
+			expectedName:     testHiveCertificateRequestName,
+			expectedPatches:  []hivev1.SyncObjectPatch{ingressPatch},
+			expectedMappings: []hivev1.SecretMapping{ingressMapping},
+			expectedOwned:    true,
+		},
+		{
+			name:         "patches the API server",
+			syncSet:      &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetAPIServer},
+			expectedName: testHiveCertificateRequestName,
+			expectedPatches: []hivev1.SyncObjectPatch{{
+				APIVersion: "config.openshift.io/v1",
+				Kind:       "APIServer",
+				Name:       "cluster",
+				Patch:      `{"spec":{"servingCerts":{"namedCertificates":[{"names":["api.gibberish.goes.here"],"servingCertificate":{"name":"` + testHiveSecretName + `"}}]}}}`,
+				PatchType:  "merge",
+			}},
+			expectedMappings: []hivev1.SecretMapping{{
+				SourceRef: hivev1.SecretReference{Name: testHiveSecretName, Namespace: testHiveNamespace},
+				TargetRef: hivev1.SecretReference{Name: testHiveSecretName, Namespace: "openshift-config"},
+			}},
+			expectedOwned: true,
+		},
+		{
+			name:    "adds to an existing SyncSet",
+			syncSet: &certmanv1alpha1.CertificateSyncSet{Name: "shared", Target: certmanv1alpha1.SyncSetTargetIngress},
+			existing: &hivev1.SyncSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: testHiveNamespace},
+				Spec: hivev1.SyncSetSpec{
+					SyncSetCommonSpec:     hivev1.SyncSetCommonSpec{Patches: []hivev1.SyncObjectPatch{otherPatch}},
+					ClusterDeploymentRefs: []corev1.LocalObjectReference{{Name: testHiveClusterDeploymentName}},
+				},
+			},
+			expectedName:     "shared",
+			expectedPatches:  []hivev1.SyncObjectPatch{otherPatch, ingressPatch},
+			expectedMappings: []hivev1.SecretMapping{ingressMapping},
+		},
+		{
+			name:    "replaces its own patch",
+			syncSet: &certmanv1alpha1.CertificateSyncSet{Name: "shared", Target: certmanv1alpha1.SyncSetTargetIngress},
+			existing: &hivev1.SyncSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: testHiveNamespace},
+				Spec: hivev1.SyncSetSpec{
+					SyncSetCommonSpec: hivev1.SyncSetCommonSpec{
+						Patches: []hivev1.SyncObjectPatch{{
+							APIVersion: "operator.openshift.io/v1",
+							Kind:       "IngressController",
+							Name:       "default",
+							Namespace:  "openshift-ingress-operator",
+							Patch:      `{"spec":{"defaultCertificate":{"name":"old"}}}`,
+							PatchType:  "merge",
+						}},
+						Secrets: []hivev1.SecretMapping{ingressMapping},
+					},
+					ClusterDeploymentRefs: []corev1.LocalObjectReference{{Name: testHiveClusterDeploymentName}},
+				},
+			},
+			expectedName:     "shared",
+			expectedPatches:  []hivev1.SyncObjectPatch{ingressPatch},
+			expectedMappings: []hivev1.SecretMapping{ingressMapping},
+		},
+		{
+			name:        "requires an owning ClusterDeployment",
+			syncSet:     &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress},
+			unowned:     true,
+			expectError: "requires a CertificateRequest owned by a ClusterDeployment",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Spec.SyncSet = test.syncSet
+			if test.unowned {
+				cr.OwnerReferences = nil
+			}
+			objects := []runtime.Object{cr}
+			if test.existing != nil {
+				objects = append(objects, test.existing)
+			}
+
+			r := &CertificateRequestReconciler{
+				Client:   setUpTestClient(t, objects),
+				Scheme:   scheme.Scheme,
+				Recorder: record.NewFakeRecorder(20),
+			}
+
+			err := r.syncSyncSet(context.TODO(), logr.Discard(), cr, secret)
+			if test.expectError != "" {
+				assert.ErrorContains(t, err, test.expectError)
+				return
+			}
+			require.NoError(t, err)
+
+			syncSets := &hivev1.SyncSetList{}
+			require.NoError(t, r.Client.List(context.TODO(), syncSets))
+			if test.expectedName == "" {
+				assert.Empty(t, syncSets.Items)
+				return
+			}
+
+			syncSet := &hivev1.SyncSet{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: test.expectedName, Namespace: testHiveNamespace}, syncSet))
+			assert.Equal(t, test.expectedPatches, syncSet.Spec.Patches)
+			assert.Equal(t, test.expectedMappings, syncSet.Spec.Secrets)
+			assert.Equal(t, []corev1.LocalObjectReference{{Name: testHiveClusterDeploymentName}}, syncSet.Spec.ClusterDeploymentRefs)
+			assert.Equal(t, test.expectedOwned, metav1.IsControlledBy(syncSet, cr))
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package certificaterequest
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -41,6 +42,7 @@ import (
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/config"
 	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
 )
 
 // helpers
@@ -162,7 +164,7 @@ Z29lcy5oZXJlMA0GCSqGSIb3DQEBCwUAA4GBAI9pcwgyuy7bWn6E7GXALwvA/ba5
 BNfwM5mTnLOijduGlf52SqIW8l35OjtiBvzSVXoroXdvKxC35xTuwJ+Q5GGynVDs
 VoZplnP9BdVECzSa
 -----END CERTIFICATE-----`),
-		corev1.TLSPrivateKeyKey: []byte(""), // this should be irrelevant for testing at least for now
+		corev1.TLSPrivateKeyKey: leAccountPrivKey, // only checked for being a parseable private key
 	},
 }
 
@@ -192,7 +194,7 @@ DQYJKoZIhvcNAQELBQADgYEAbUH1aXUae9CyuqFwqmEE+OY8vKxlqEnAyQ6p67UL
 UdlLtE44bt04ZOkcS2n9ofSjl0iv8fsxkOl0i6NsWXpqm8aGAZDpVFKNShPsQ0rb
 cxA=
 -----END CERTIFICATE-----`),
-		corev1.TLSPrivateKeyKey: []byte(""), // this should be irrelevant for testing at least for now
+		corev1.TLSPrivateKeyKey: leAccountPrivKey, // only checked for being a parseable private key
 	},
 }
 
@@ -232,24 +234,43 @@ func (f FakeAWSClient) GetDNSName() string {
 	return "Route53"
 }
 
-func (f FakeAWSClient) GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (string, error) {
+func (f FakeAWSClient) GetFedrampHostedZoneIDPath(ctx context.Context, fedrampHostedZoneID string) (string, error) {
 	return testHiveFedRampZoneID, nil
 }
 
-func (f FakeAWSClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
+func (f FakeAWSClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
 	return testHiveACMEDomain, nil
 }
 
-func (f FakeAWSClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+func (f FakeAWSClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
 	return nil
 }
 
-func (f FakeAWSClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+func (f FakeAWSClient) ListZones(ctx context.Context) ([]string, error) {
+	return []string{testHiveACMEDomain}, nil
+}
+
+func (f FakeAWSClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
 	return true, nil
 }
 
+// FakeBatchAWSClient is a FakeAWSClient answering challenges in batches, which it records.
+type FakeBatchAWSClient struct {
+	FakeAWSClient
+	Challenges *[]cTypes.DNSChallenge
+}
+
+func (f FakeBatchAWSClient) AnswerDNSChallenges(ctx context.Context, reqLogger logr.Logger, challenges []cTypes.DNSChallenge, cr *certmanv1alpha1.CertificateRequest, dnsZone string) ([]string, error) {
+	*f.Challenges = append(*f.Challenges, challenges...)
+	fqdns := make([]string, len(challenges))
+	for i, challenge := range challenges {
+		fqdns[i] = cTypes.AcmeChallengeSubDomain + "." + challenge.Domain
+	}
+	return fqdns, nil
+}
+
 // Return an empty AWS client.
-func setUpFakeAWSClient(reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeplymentName string) (cClient.Client, error) {
+func setUpFakeAWSClient(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeplymentName string) (cClient.Client, error) {
 	return FakeAWSClient{}, nil
 }
 
@@ -259,10 +280,13 @@ func setUpTestClient(t *testing.T, objects []runtime.Object) client.Client {
 	t.Helper()
 
 	s := scheme.Scheme
-	s.AddKnownTypes(certmanv1alpha1.GroupVersion, certRequest)
+	s.AddKnownTypes(certmanv1alpha1.GroupVersion, certRequest, &certmanv1alpha1.CertificateRequestList{})
+	s.AddKnownTypes(certmanv1alpha1.GroupVersion, &certmanv1alpha1.CertIssuer{})
+	s.AddKnownTypes(certmanv1alpha1.GroupVersion, &certmanv1alpha1.CertificatePolicy{}, &certmanv1alpha1.CertificatePolicyList{})
 	s.AddKnownTypes(hivev1.SchemeGroupVersion, clusterDeploymentComplete)
 	s.AddKnownTypes(hivev1.SchemeGroupVersion, &hivev1.DNSZoneList{})
 	s.AddKnownTypes(hivev1.SchemeGroupVersion, &hivev1.DNSZone{})
+	s.AddKnownTypes(hivev1.SchemeGroupVersion, &hivev1.SyncSet{}, &hivev1.SyncSetList{})
 	return fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).WithStatusSubresource(certRequest).Build()
 }
 
@@ -300,3 +324,29 @@ func generateValidCertPEM() ([]byte, *x509.Certificate, error) {
 
 	return certPEM, certParsed, nil
 }
+
+// generateFakeLECertPEM generates a PEM encoded certificate issued by the Let's Encrypt staging
+// intermediate so that it can be revoked by the operator in tests.
+func generateFakeLECertPEM() ([]byte, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName: "Fake LE Intermediate X1",
+		},
+		DNSNames:  []string{"api.gibberish.goes.here"},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(24 * time.Hour),
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/tracing"
+)
+
+// traceAttributes returns the span attributes identifying the CertificateRequest.
+func traceAttributes(cr *certmanv1alpha1.CertificateRequest) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("k8s.namespace.name", cr.Namespace),
+		attribute.String("certificaterequest.name", cr.Name),
+	}
+}
+
+// writeSecret creates the secret, or updates it if it already exists, recording the write in a span.
+func (r *CertificateRequestReconciler) writeSecret(ctx context.Context, secret *corev1.Secret, create bool) (err error) {
+	ctx, span := tracing.Start(ctx, "WriteSecret", attribute.String("k8s.namespace.name", secret.Namespace), attribute.String("secret.name", secret.Name))
+	defer func() { tracing.End(span, err) }()
+
+	if create {
+		return r.Client.Create(ctx, secret)
+	}
+	return r.Client.Update(ctx, secret)
+}
@@ -18,23 +18,48 @@ package certificaterequest
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
 	"github.com/openshift/certman-operator/pkg/localmetrics"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	issuedReason         = "Issued"
+	issuanceFailedReason = "IssuanceFailed"
+	acmeErrorReason      = "AcmeError"
+
+	invalidCredentialsReason      = "InvalidCredentials"
+	insufficientPermissionsReason = "InsufficientPermissions"
+	dnsRecordRefusedReason        = "DNSRecordRefused"
+
+	orderCreatedReason        = "OrderCreated"
+	dnsNotPropagatedReason    = "DNSNotPropagated"
+	challengesCompletedReason = "ChallengesCompleted"
+
+	statusErrorUpdateTimeout = 30 * time.Second
 )
 
 // updateStatus attempts to retrieve a certificate and check its Issued state. If not Issued,
 // the required CertificateRequest variables are populated and updated.
-func (r *CertificateRequestReconciler) updateStatus(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+func (r *CertificateRequestReconciler) updateStatus(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
 	if cr == nil {
 		return fmt.Errorf("CertificateRequest is nil")
 	}
 
-	certificate, err := GetCertificate(r.Client, cr)
+	certificate, err := GetCertificate(ctx, r.Client, cr)
 	if err != nil {
 		return err
 	}
@@ -43,81 +68,194 @@ func (r *CertificateRequestReconciler) updateStatus(reqLogger logr.Logger, cr *c
 		return fmt.Errorf("no certificate found for %s/%s", cr.Namespace, cr.Name)
 	}
 
-	if !cr.Status.Issued ||
+	scts, err := leclient.GetEmbeddedSCTs(certificate)
+	if err != nil {
+		reqLogger.Error(err, "failed to parse signed certificate timestamps")
+	}
+
+	// the SCTs are recorded with their log IDs alone when the log list cannot be read
+	ctLogs, err := r.getCTLogs(ctx)
+	if err != nil {
+		reqLogger.Error(err, "failed to read the CT log list")
+	}
+	ctLogs.NameSCTs(scts)
+
+	duration := certificate.NotAfter.Sub(certificate.NotBefore).String()
+	fingerprint := certificateFingerprint(certificate)
+
+	renewalTime := ""
+	jitterWindow, err := utils.GetRenewalJitterWindow(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	if t, err := getRenewalTime(cr, certificate, jitterWindow); err != nil {
+		reqLogger.Error(err, "failed to determine the certificate renewal time")
+	} else {
+		renewalTime = t.String()
+	}
+
+	previous := cr.Status.DeepCopy()
+
+	// the certificate was issued, so the CertificateRequest is no longer waiting on a rate limit or failing
+	removeCondition(cr, certmanv1alpha1.CertificateRequestRateLimited)
+	removeCondition(cr, certmanv1alpha1.CertificateRequestFailed)
+	reason := issuedReason
+	if cr.Spec.ImportedSecret != nil {
+		reason = importedReason
+	}
+	setCondition(cr, certmanv1alpha1.CertificateRequestReady, metav1.ConditionTrue, reason, fmt.Sprintf("certificate is valid until %s", certificate.NotAfter))
+	setCondition(cr, certmanv1alpha1.CertificateRequestIssuing, metav1.ConditionFalse, reason, "")
+
+	if !reflect.DeepEqual(previous.Conditions, cr.Status.Conditions) ||
+		cr.Status.ObservedGeneration != cr.Generation ||
+		cr.Status.FailedAttempts != 0 ||
+		cr.Status.FailureCount != 0 ||
+		cr.Status.LastFailure != nil ||
+		!cr.Status.Issued ||
+		!reflect.DeepEqual(cr.Status.SignedCertificateTimestamps, scts) ||
 		cr.Status.IssuerName != certificate.Issuer.CommonName ||
 		cr.Status.NotBefore != certificate.NotBefore.String() ||
 		cr.Status.NotAfter != certificate.NotAfter.String() ||
-		cr.Status.SerialNumber != certificate.SerialNumber.String() {
+		cr.Status.SerialNumber != certificate.SerialNumber.String() ||
+		cr.Status.RenewalTime != renewalTime ||
+		cr.Status.Duration != duration ||
+		cr.Status.Fingerprint != fingerprint ||
+		!reflect.DeepEqual(cr.Status.DNSNames, certificate.DNSNames) {
 
+		cr.Status.ObservedGeneration = cr.Generation
+		cr.Status.FailedAttempts = 0
+		cr.Status.FailureCount = 0
+		cr.Status.LastFailure = nil
 		cr.Status.Issued = true
 		cr.Status.IssuerName = certificate.Issuer.CommonName
 		cr.Status.NotBefore = certificate.NotBefore.String()
 		cr.Status.NotAfter = certificate.NotAfter.String()
 		cr.Status.SerialNumber = certificate.SerialNumber.String()
+		cr.Status.RenewalTime = renewalTime
+		cr.Status.Duration = duration
+		cr.Status.Fingerprint = fingerprint
+		cr.Status.DNSNames = certificate.DNSNames
+		cr.Status.SignedCertificateTimestamps = scts
 		cr.Status.Status = "Success"
 
-		err := r.Client.Status().Update(context.TODO(), cr)
+		err := r.Client.Status().Update(ctx, cr)
 		if err != nil {
 			reqLogger.Error(err, "Failed to update CertificateRequest status")
 			return err
 		}
-		localmetrics.AddCertificateIssuance("issue")
+		if issuedByOperator(cr) {
+			localmetrics.AddCertificateIssuance("issue")
+		}
 	}
 
 	return nil
 }
 
-// Function for handling a generic ACME error from cert issuer.
-// Function will add a condition to the CertificateRequest with the return body from issuing cert request.
-func acmeError(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) (certmanv1alpha1.CertificateRequestCondition, error) {
-	var found bool
-	var newCondition certmanv1alpha1.CertificateRequestCondition
-	//Check for this as an existing Condition. If found no new action will be taken.
-	for index := range cr.Status.Conditions {
-		if cr.Status.Conditions[index].Type == "acme error" {
-			found = true
-		}
+// certificateFingerprint returns the SHA-256 fingerprint of certificate as colon separated uppercase hex.
+func certificateFingerprint(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.Raw)
+	hexBytes := make([]string, len(sum))
+	for i, b := range sum {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexBytes, ":")
+}
+
+// updateStatusError marks the CertificateRequest as not Ready because issuing its certificate failed with err, and
+// counts the failed attempt against its retry budget.
+func (r *CertificateRequestReconciler) updateStatusError(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) error {
+	if cr == nil {
+		return nil
 	}
-	//If Condition is not present then a new Condition will be constructed and returned.
-	if !found {
-		m := fmt.Sprint(err)
-		newCondition.Type = certmanv1alpha1.CertificateRequestConditionType("acme error")
-		newCondition.Status = corev1.ConditionStatus("Error")
-		newCondition.Message = &m
 
-		reqLogger.Info("Added condition 'acme error'")
+	reason := failureReason(err)
+
+	// a reconcile that timed out still records why, so the update must outlive ctx
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusErrorUpdateTimeout)
+	defer cancel()
+
+	if countErr := r.countFailedAttempt(ctx, reqLogger, cr, err); countErr != nil {
+		reqLogger.Error(countErr, "failed to count the failed attempt")
 	}
-	return newCondition, nil
-}
 
-func (r *CertificateRequestReconciler) updateStatusError(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, err error) error {
+	cr.Status.Issued = false
+	cr.Status.Status = "Error"
+	cr.Status.ObservedGeneration = cr.Generation
+	setCondition(cr, certmanv1alpha1.CertificateRequestReady, metav1.ConditionFalse, reason, err.Error())
+	setCondition(cr, certmanv1alpha1.CertificateRequestIssuing, metav1.ConditionFalse, reason, err.Error())
 
-	if cr != nil {
-		cr.Status.Issued = false
-		cr.Status.Status = "Error"
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		reqLogger.Error(err, err.Error())
+		return err
+	}
 
-		//Check the error for different strings to indicate reason for failure
-		if strings.Contains(err.Error(), "acme") {
-			newCondition, err2 := acmeError(reqLogger, cr, err)
-			if err2 != nil {
-				reqLogger.Error(err2, err2.Error())
-			} else if newCondition.Status != "" {
-				//If a new Condition has a status the new Condition is added to the Status.
-				cr.Status.Conditions = append(cr.Status.Conditions, newCondition)
-			}
+	r.notifyPersistentFailure(ctx, reqLogger, cr)
+	r.notifyFailure(ctx, reqLogger, cr)
+	return nil
+}
 
-		}
-		// add more known failure cases here when discovered.
-		// if strings.Contains(err.Error(), "string")
+// failureReason returns the reason of the conditions and Events reporting that issuing a certificate failed with err.
+func failureReason(err error) string {
+	// the errors of the DNS clients may name _acme-challenge records, so they are told apart before acme errors
+	switch {
+	case errors.Is(err, cTypes.ErrInvalidCredentials):
+		return invalidCredentialsReason
+	case errors.Is(err, cTypes.ErrInsufficientPermissions):
+		return insufficientPermissionsReason
+	case errors.Is(err, cTypes.ErrRecordRefused):
+		return dnsRecordRefusedReason
+	}
+	//Check the error for different strings to indicate reason for failure
+	if strings.Contains(err.Error(), "acme") {
+		return acmeErrorReason
+	}
+	// add more known failure cases here when discovered.
+	return issuanceFailedReason
+}
 
-		// Update the CertificateRequest status as Error and write any pending conditions
-		err := r.Client.Status().Update(context.TODO(), cr)
-		if err != nil {
-			reqLogger.Error(err, err.Error())
-			return err
+// reportProgress sets a condition of the CertificateRequest while its certificate is being issued. Failing to write
+// the status does not fail the issuance, so errors are only logged.
+func (r *CertificateRequestReconciler) reportProgress(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, conditionType certmanv1alpha1.CertificateRequestConditionType, status metav1.ConditionStatus, reason, message string) {
+	setCondition(cr, conditionType, status, reason, message)
+	if err := r.Client.Status().Update(ctx, cr); err != nil {
+		reqLogger.Error(err, "failed to update the condition", "Condition", conditionType)
+	}
+}
+
+// setCondition sets the condition of conditionType of the CertificateRequest for its current generation. The
+// transition time only changes with the status of the condition.
+func setCondition(cr *certmanv1alpha1.CertificateRequest, conditionType certmanv1alpha1.CertificateRequestConditionType, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:               string(conditionType),
+		Status:             status,
+		ObservedGeneration: cr.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// removeCondition removes the condition of conditionType from the CertificateRequest and returns true if it was
+// present.
+func removeCondition(cr *certmanv1alpha1.CertificateRequest, conditionType certmanv1alpha1.CertificateRequestConditionType) bool {
+	return meta.RemoveStatusCondition(&cr.Status.Conditions, string(conditionType))
+}
 
+// pruneInvalidConditions removes conditions written by earlier versions of the operator that are missing the reason
+// or transition time required of a metav1.Condition, and would otherwise fail validation on the next status update.
+func pruneInvalidConditions(cr *certmanv1alpha1.CertificateRequest) {
+	conditions := cr.Status.Conditions[:0]
+	for _, condition := range cr.Status.Conditions {
+		if condition.Reason != "" && !condition.LastTransitionTime.IsZero() {
+			conditions = append(conditions, condition)
 		}
+	}
+	cr.Status.Conditions = conditions
+}
 
+// clearCondition removes the condition of conditionType from the status of the CertificateRequest, if present.
+func (r *CertificateRequestReconciler) clearCondition(ctx context.Context, cr *certmanv1alpha1.CertificateRequest, conditionType certmanv1alpha1.CertificateRequestConditionType) error {
+	if !removeCondition(cr, conditionType) {
+		return nil
 	}
-	return nil
+	return r.Client.Status().Update(ctx, cr)
 }
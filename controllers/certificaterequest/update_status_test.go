@@ -1,6 +1,7 @@
 package certificaterequest
 
 import (
+	"context"
 	"crypto/x509"
 	"fmt"
 	"testing"
@@ -10,12 +11,16 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
 )
 
 func TestUpdateStatus(t *testing.T) {
@@ -61,7 +66,13 @@ func TestUpdateStatus(t *testing.T) {
 						},
 					},
 					Status: certmanv1alpha1.CertificateRequestStatus{
-						Issued: false,
+						Issued:       false,
+						FailureCount: 3,
+						LastFailure: &certmanv1alpha1.CertificateFailure{
+							Reason:  acmeErrorReason,
+							Message: "acme: error code 400",
+							Time:    metav1.Now(),
+						},
 					},
 				}
 
@@ -79,6 +90,14 @@ func TestUpdateStatus(t *testing.T) {
 			verifyExtra: func(cr *certmanv1alpha1.CertificateRequest, parsedCert *x509.Certificate) {
 				assert.Equal(t, parsedCert.Issuer.CommonName, cr.Status.IssuerName)
 				assert.Equal(t, parsedCert.SerialNumber.String(), cr.Status.SerialNumber)
+				assert.Equal(t, parsedCert.NotAfter.Sub(parsedCert.NotBefore).String(), cr.Status.Duration)
+				assert.Equal(t, parsedCert.DNSNames, cr.Status.DNSNames)
+				assert.Regexp(t, "^([0-9A-F]{2}:){31}[0-9A-F]{2}$", cr.Status.Fingerprint)
+				assert.True(t, meta.IsStatusConditionTrue(cr.Status.Conditions, string(certmanv1alpha1.CertificateRequestReady)))
+				assert.True(t, meta.IsStatusConditionFalse(cr.Status.Conditions, string(certmanv1alpha1.CertificateRequestIssuing)))
+				assert.Equal(t, cr.Generation, cr.Status.ObservedGeneration)
+				assert.Zero(t, cr.Status.FailureCount)
+				assert.Nil(t, cr.Status.LastFailure)
 			},
 		},
 		{
@@ -95,10 +114,11 @@ func TestUpdateStatus(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			cl, cr, parsedCert := tc.setup()
 			rcr := &CertificateRequestReconciler{
-				Client: cl,
-				Scheme: scheme,
+				Recorder: &record.FakeRecorder{},
+				Client:   cl,
+				Scheme:   scheme,
 			}
-			err := rcr.updateStatus(logr.Discard(), cr)
+			err := rcr.updateStatus(context.TODO(), logr.Discard(), cr)
 
 			if tc.expectError {
 				require.Error(t, err)
@@ -112,115 +132,76 @@ func TestUpdateStatus(t *testing.T) {
 	}
 }
 
-func TestAcmeError(t *testing.T) {
-	errMsg := "example acme failure" // Example error message for testing
-	mockErr := errors.New(errMsg)
-
+func TestUpdateStatusError(t *testing.T) {
 	tests := []struct {
-		name            string
-		existingConds   []certmanv1alpha1.CertificateRequestCondition
-		expectedType    certmanv1alpha1.CertificateRequestConditionType
-		expectedStatus  corev1.ConditionStatus
-		expectedMessage string
-		expectCondition bool
+		name           string
+		err            error
+		expectedReason string
 	}{
 		{
-			name:            "condition_not_present_should_create_new",
-			existingConds:   []certmanv1alpha1.CertificateRequestCondition{},
-			expectedType:    "acme error",
-			expectedStatus:  "Error",
-			expectedMessage: errMsg,
-			expectCondition: true,
+			name:           "acme_error",
+			err:            errors.New("acme: error code 429: too many certificates already issued"),
+			expectedReason: acmeErrorReason,
 		},
 		{
-			name: "condition_already_present_no_new_condition",
-			existingConds: []certmanv1alpha1.CertificateRequestCondition{
-				{
-					Type:   "acme error",
-					Status: "Error",
-				},
-			},
-			expectCondition: false,
+			name:           "other_error",
+			err:            errors.New("failed to reach Route53"),
+			expectedReason: issuanceFailedReason,
+		},
+		{
+			name:           "invalid_credentials",
+			err:            fmt.Errorf("%w: secret ns/aws has no aws_access_key_id", cTypes.ErrInvalidCredentials),
+			expectedReason: invalidCredentialsReason,
+		},
+		{
+			name:           "insufficient_permissions",
+			err:            fmt.Errorf("%w: failed to get write access to DNS records in zone example.com", cTypes.ErrInsufficientPermissions),
+			expectedReason: insufficientPermissionsReason,
+		},
+		{
+			name:           "dns_record_refused",
+			err:            fmt.Errorf("%w: ChangeResourceRecordSets of A record \"api.example.com\": it is not a TXT record", cTypes.ErrRecordRefused),
+			expectedReason: dnsRecordRefusedReason,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cr := &certmanv1alpha1.CertificateRequest{
-				Status: certmanv1alpha1.CertificateRequestStatus{
-					Conditions: tt.existingConds,
-				},
-			}
-
-			logger := logr.Discard()
-
-			cond, err := acmeError(logger, cr, mockErr)
-			require.NoError(t, err)
-
-			if tt.expectCondition {
-				require.Equal(t, tt.expectedType, cond.Type)
-				require.Equal(t, tt.expectedStatus, cond.Status)
-				require.NotNil(t, cond.Message)
-				require.Equal(t, tt.expectedMessage, *cond.Message)
-			} else {
-				require.Empty(t, cond.Type)
-				require.Empty(t, cond.Status)
-				require.Nil(t, cond.Message)
-			}
+			cr := certRequest.DeepCopy()
+			cr.Generation = 2
+			setCondition(cr, certmanv1alpha1.CertificateRequestReady, metav1.ConditionTrue, issuedReason, "")
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{cr})}
+
+			require.NoError(t, r.updateStatusError(context.TODO(), logr.Discard(), cr, tt.err))
+
+			updated := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, updated))
+			assert.Equal(t, "Error", updated.Status.Status)
+			assert.Equal(t, int64(2), updated.Status.ObservedGeneration)
+
+			ready := meta.FindStatusCondition(updated.Status.Conditions, string(certmanv1alpha1.CertificateRequestReady))
+			require.NotNil(t, ready)
+			assert.Equal(t, metav1.ConditionFalse, ready.Status)
+			assert.Equal(t, tt.expectedReason, ready.Reason)
+			assert.Equal(t, tt.err.Error(), ready.Message)
+			assert.Equal(t, int64(2), ready.ObservedGeneration)
+
+			assert.Equal(t, int32(1), updated.Status.FailureCount)
+			require.NotNil(t, updated.Status.LastFailure)
+			assert.Equal(t, tt.expectedReason, updated.Status.LastFailure.Reason)
+			assert.Equal(t, tt.err.Error(), updated.Status.LastFailure.Message)
 		})
 	}
 }
 
-func TestUpdateStatusError(t *testing.T) {
-	scheme := runtime.NewScheme()
-	require.NoError(t, certmanv1alpha1.AddToScheme(scheme))
-	require.NoError(t, corev1.AddToScheme(scheme))
-
-	tests := []struct {
-		name       string
-		inputError error
-		expectErr  bool
-	}{
-		{
-			name:       "acme_error_should_set_condition_and_update_status",
-			inputError: fmt.Errorf("acme: random error"),
-			expectErr:  false,
-		},
+func TestPruneInvalidConditions(t *testing.T) {
+	cr := certRequest.DeepCopy()
+	cr.Status.Conditions = []metav1.Condition{
+		{Type: "acme error", Status: "Error", Message: "acme: error"},
+		{Type: string(certmanv1alpha1.CertificateRequestReady), Status: metav1.ConditionTrue, Reason: issuedReason, LastTransitionTime: metav1.Now()},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cr := &certmanv1alpha1.CertificateRequest{
-				TypeMeta: metav1.TypeMeta{
-					APIVersion: "certman.managed.openshift.io/v1alpha1",
-					Kind:       "CertificateRequest",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-cr",
-					Namespace: "default",
-				},
-			}
-
-			secret := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-cert-secret",
-					Namespace: "default",
-				},
-			}
-			cl := fake.NewClientBuilder().
-				WithScheme(scheme).
-				WithRuntimeObjects(cr, secret).
-				WithStatusSubresource(&certmanv1alpha1.CertificateRequest{}).
-				Build()
-
-			reconciler := &CertificateRequestReconciler{
-				Client: cl,
-				Scheme: scheme,
-			}
-			err := reconciler.updateStatusError(logr.Discard(), cr, tt.inputError)
-			if (err != nil) != tt.expectErr {
-				t.Errorf("GetCertificate() Got unexpected error: %v", err)
-			}
-		})
-	}
+	pruneInvalidConditions(cr)
+	require.Len(t, cr.Status.Conditions, 1)
+	assert.Equal(t, string(certmanv1alpha1.CertificateRequestReady), cr.Status.Conditions[0].Type)
 }
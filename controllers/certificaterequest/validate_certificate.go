@@ -0,0 +1,138 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// letsEncryptStagingOrganization prefixes the organization of the Let's Encrypt staging hierarchy,
+// whose roots are not publicly trusted.
+const letsEncryptStagingOrganization = "(STAGING)"
+
+// getTrustedRoots returns the roots issued certificates must chain to: the system roots plus the CA
+// bundle of the CertIssuer referenced by the CertificateRequest. It returns nil for a Google CAS or Venafi
+// issuer without a CA bundle, whose certificates are verified against the top of their chain.
+func (r *CertificateRequestReconciler) getTrustedRoots(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) (*x509.CertPool, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system roots: %w", err)
+	}
+
+	if cr.Spec.IssuerRef == nil {
+		return roots, nil
+	}
+
+	issuer, err := r.getCertIssuer(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	var caBundle []byte
+	switch {
+	case issuer.Spec.ACME != nil:
+		caBundle = issuer.Spec.ACME.CABundle
+	case issuer.Spec.GoogleCAS != nil:
+		if len(issuer.Spec.GoogleCAS.CABundle) == 0 {
+			return nil, nil
+		}
+		caBundle = issuer.Spec.GoogleCAS.CABundle
+	case issuer.Spec.Venafi != nil:
+		if len(issuer.Spec.Venafi.CABundle) == 0 {
+			return nil, nil
+		}
+		caBundle = issuer.Spec.Venafi.CABundle
+	}
+	if len(caBundle) > 0 && !roots.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("certissuer %s has no valid certificates in its CA bundle", issuer.Name)
+	}
+
+	return roots, nil
+}
+
+// validateIssuedCertificate checks the certificates returned by the ACME server before they are stored:
+// the leaf must be for key, cover every requested DNS name and be currently valid, and the chain must
+// verify to one of roots. Certificates issued by the Let's Encrypt staging hierarchy, or checked against
+// nil roots, are verified against the top of the returned chain instead.
+func validateIssuedCertificate(cr *certmanv1alpha1.CertificateRequest, certs []*x509.Certificate, key crypto.Signer, roots *x509.CertPool) error {
+	if len(certs) < 2 {
+		return fmt.Errorf("expected a leaf certificate and its issuer, got %d certificates", len(certs))
+	}
+
+	leaf := certs[0]
+
+	publicKey, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(key.Public()) {
+		return errors.New("issued certificate does not match the private key")
+	}
+
+	issuedNames := make(map[string]bool, len(leaf.DNSNames))
+	for _, name := range leaf.DNSNames {
+		issuedNames[strings.ToLower(name)] = true
+	}
+	for _, name := range cr.Spec.DnsNames {
+		if !issuedNames[strings.ToLower(name)] {
+			return fmt.Errorf("issued certificate does not cover requested DNS name %s", name)
+		}
+	}
+
+	if !leaf.NotAfter.After(leaf.NotBefore) {
+		return fmt.Errorf("issued certificate has an invalid validity period %s to %s", leaf.NotBefore, leaf.NotAfter)
+	}
+	if !leaf.NotAfter.After(time.Now()) {
+		return fmt.Errorf("issued certificate expired at %s", leaf.NotAfter)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	if roots == nil || isStagingCertificate(leaf) {
+		roots = x509.NewCertPool()
+		roots.AddCert(certs[len(certs)-1])
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify issued certificate chain: %w", err)
+	}
+
+	return nil
+}
+
+// isStagingCertificate returns true if certificate was issued by the Let's Encrypt staging hierarchy.
+func isStagingCertificate(certificate *x509.Certificate) bool {
+	for _, o := range certificate.Issuer.Organization {
+		if strings.HasPrefix(o, letsEncryptStagingOrganization) {
+			return true
+		}
+	}
+	return false
+}
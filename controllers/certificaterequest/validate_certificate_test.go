@@ -0,0 +1,281 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// testCA is a certificate authority used to sign test certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// newTestCertificate signs template with parent, or self-signs it if parent is nil.
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCA) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signerCert, signerKey := template, crypto.Signer(key)
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, key.Public(), signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+func newTestCATemplate(serial int64, organization string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "Test CA", Organization: []string{organization}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(48 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+}
+
+func newTestLeafTemplate(dnsNames []string, notAfter time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(10),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+}
+
+func TestValidateIssuedCertificate(t *testing.T) {
+	dnsNames := []string{"api.example.com", "*.apps.example.com"}
+
+	root := newTestCertificate(t, newTestCATemplate(1, "Test"), nil)
+	intermediate := newTestCertificate(t, newTestCATemplate(2, "Test"), root)
+	leaf := newTestCertificate(t, newTestLeafTemplate(dnsNames, time.Now().Add(24*time.Hour)), intermediate)
+
+	stagingRoot := newTestCertificate(t, newTestCATemplate(3, "(STAGING) Internet Security Research Group"), nil)
+	stagingIntermediate := newTestCertificate(t, newTestCATemplate(4, "(STAGING) Let's Encrypt"), stagingRoot)
+	stagingLeaf := newTestCertificate(t, newTestLeafTemplate(dnsNames, time.Now().Add(24*time.Hour)), stagingIntermediate)
+
+	expiredLeaf := newTestCertificate(t, newTestLeafTemplate(dnsNames, time.Now().Add(-time.Minute)), intermediate)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.cert)
+
+	tests := []struct {
+		name        string
+		dnsNames    []string
+		certs       []*x509.Certificate
+		key         crypto.Signer
+		roots       *x509.CertPool
+		expectError bool
+	}{
+		{
+			name:     "accepts a valid chain",
+			dnsNames: dnsNames,
+			certs:    []*x509.Certificate{leaf.cert, intermediate.cert},
+			key:      leaf.key,
+			roots:    roots,
+		},
+		{
+			name:     "matches dns names case-insensitively",
+			dnsNames: []string{"API.example.com"},
+			certs:    []*x509.Certificate{leaf.cert, intermediate.cert},
+			key:      leaf.key,
+			roots:    roots,
+		},
+		{
+			name:     "accepts the staging hierarchy",
+			dnsNames: dnsNames,
+			certs:    []*x509.Certificate{stagingLeaf.cert, stagingIntermediate.cert},
+			key:      stagingLeaf.key,
+			roots:    x509.NewCertPool(),
+		},
+		{
+			name:     "verifies against the top of the chain without roots",
+			dnsNames: dnsNames,
+			certs:    []*x509.Certificate{leaf.cert, intermediate.cert, root.cert},
+			key:      leaf.key,
+		},
+		{
+			name:        "rejects a chain without an issuer",
+			dnsNames:    dnsNames,
+			certs:       []*x509.Certificate{leaf.cert},
+			key:         leaf.key,
+			roots:       roots,
+			expectError: true,
+		},
+		{
+			name:        "rejects a certificate for another key",
+			dnsNames:    dnsNames,
+			certs:       []*x509.Certificate{leaf.cert, intermediate.cert},
+			key:         otherKey,
+			roots:       roots,
+			expectError: true,
+		},
+		{
+			name:        "rejects a certificate missing a requested name",
+			dnsNames:    append([]string{"extra.example.com"}, dnsNames...),
+			certs:       []*x509.Certificate{leaf.cert, intermediate.cert},
+			key:         leaf.key,
+			roots:       roots,
+			expectError: true,
+		},
+		{
+			name:        "rejects an expired certificate",
+			dnsNames:    dnsNames,
+			certs:       []*x509.Certificate{expiredLeaf.cert, intermediate.cert},
+			key:         expiredLeaf.key,
+			roots:       roots,
+			expectError: true,
+		},
+		{
+			name:        "rejects an untrusted chain",
+			dnsNames:    dnsNames,
+			certs:       []*x509.Certificate{leaf.cert, intermediate.cert},
+			key:         leaf.key,
+			roots:       x509.NewCertPool(),
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{
+				Spec: certmanv1alpha1.CertificateRequestSpec{DnsNames: test.dnsNames},
+			}
+
+			err := validateIssuedCertificate(cr, test.certs, test.key, test.roots)
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetTrustedRoots(t *testing.T) {
+	root := newTestCertificate(t, newTestCATemplate(1, "Test"), nil)
+	rootPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.cert.Raw})
+
+	newIssuer := func(name string, caBundle []byte) *certmanv1alpha1.CertIssuer {
+		return &certmanv1alpha1.CertIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       certmanv1alpha1.CertIssuerSpec{ACME: &certmanv1alpha1.ACMEIssuer{CABundle: caBundle}},
+		}
+	}
+
+	newCASIssuer := func(name string, caBundle []byte) *certmanv1alpha1.CertIssuer {
+		return &certmanv1alpha1.CertIssuer{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       certmanv1alpha1.CertIssuerSpec{GoogleCAS: &certmanv1alpha1.GoogleCASIssuer{CABundle: caBundle}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		issuerRef     *corev1.LocalObjectReference
+		expectError   bool
+		expectCustom  bool
+		expectNoRoots bool
+	}{
+		{
+			name: "uses the system roots without an issuer",
+		},
+		{
+			name:         "adds the issuer ca bundle",
+			issuerRef:    &corev1.LocalObjectReference{Name: "private"},
+			expectCustom: true,
+		},
+		{
+			name:         "adds the google cas issuer ca bundle",
+			issuerRef:    &corev1.LocalObjectReference{Name: "cas"},
+			expectCustom: true,
+		},
+		{
+			name:          "leaves the roots of a google cas issuer without ca bundle to the chain",
+			issuerRef:     &corev1.LocalObjectReference{Name: "cas-without-bundle"},
+			expectNoRoots: true,
+		},
+		{
+			name:        "rejects an invalid ca bundle",
+			issuerRef:   &corev1.LocalObjectReference{Name: "invalid"},
+			expectError: true,
+		},
+		{
+			name:        "errors when the issuer is missing",
+			issuerRef:   &corev1.LocalObjectReference{Name: "missing"},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &CertificateRequestReconciler{Client: setUpTestClient(t, []runtime.Object{
+				newIssuer("private", rootPEM),
+				newIssuer("invalid", []byte("not a certificate")),
+				newCASIssuer("cas", rootPEM),
+				newCASIssuer("cas-without-bundle", nil),
+			})}
+			cr := &certmanv1alpha1.CertificateRequest{
+				Spec: certmanv1alpha1.CertificateRequestSpec{IssuerRef: test.issuerRef},
+			}
+
+			roots, err := r.getTrustedRoots(context.TODO(), cr)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if test.expectNoRoots {
+				assert.Nil(t, roots)
+				return
+			}
+
+			_, err = root.cert.Verify(x509.VerifyOptions{Roots: roots})
+			assert.Equal(t, test.expectCustom, err == nil)
+		})
+	}
+}
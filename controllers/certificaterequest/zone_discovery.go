@@ -0,0 +1,74 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+)
+
+// discoverACMEDNSZone records in the status of the CertificateRequest the closest zone of its DNS provider enclosing
+// its ACME DNS domain, which is walked up label by label. When the provider has no such zone the challenges keep
+// being answered in the ACME DNS domain itself. FedRAMP clusters answer them in a fixed hosted zone instead.
+func (r *CertificateRequestReconciler) discoverACMEDNSZone(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest, dnsClient cClient.Client) error {
+	if fedramp {
+		return nil
+	}
+
+	zones, err := dnsClient.ListZones(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list the DNS zones of the provider: %w", err)
+	}
+
+	zone, found := closestEnclosingZone(cr.Spec.ACMEDNSDomain, zones)
+	if !found {
+		reqLogger.Info("no DNS zone of the provider encloses the ACME DNS domain", "acmeDNSDomain", cr.Spec.ACMEDNSDomain)
+	}
+	if cr.Status.ACMEDNSZone == zone {
+		return nil
+	}
+
+	reqLogger.Info("discovered the DNS zone of the challenges", "zone", zone)
+	cr.Status.ACMEDNSZone = zone
+	return r.Client.Status().Update(ctx, cr)
+}
+
+// closestEnclosingZone returns the longest of zones that is domain or one of its parents.
+func closestEnclosingZone(domain string, zones []string) (string, bool) {
+	known := map[string]bool{}
+	for _, zone := range zones {
+		known[normalizeDNSName(zone)] = true
+	}
+
+	for name := normalizeDNSName(domain); name != ""; {
+		if known[name] {
+			return name, true
+		}
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok {
+			break
+		}
+		name = parent
+	}
+	return "", false
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificaterequest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// zonesFakeClient is a fake DNS client hosting the given zones.
+type zonesFakeClient struct {
+	FakeAWSClient
+	zones []string
+}
+
+func (f zonesFakeClient) ListZones(ctx context.Context) ([]string, error) {
+	return f.zones, nil
+}
+
+func TestClosestEnclosingZone(t *testing.T) {
+	tests := []struct {
+		name         string
+		domain       string
+		zones        []string
+		expectedZone string
+		expectedOK   bool
+	}{
+		{
+			name:         "the zone of the domain",
+			domain:       "cluster.example.com",
+			zones:        []string{"example.com.", "cluster.example.com."},
+			expectedZone: "cluster.example.com",
+			expectedOK:   true,
+		},
+		{
+			name:         "the parent zone of a delegated domain",
+			domain:       "apps.cluster.Example.com",
+			zones:        []string{"other.com", "Example.com."},
+			expectedZone: "example.com",
+			expectedOK:   true,
+		},
+		{
+			name:         "the closest of the enclosing zones",
+			domain:       "a.b.example.com",
+			zones:        []string{"example.com", "b.example.com"},
+			expectedZone: "b.example.com",
+			expectedOK:   true,
+		},
+		{
+			name:   "no zone enclosing the domain",
+			domain: "cluster.example.com",
+			zones:  []string{"example.org", "ample.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone, ok := closestEnclosingZone(tt.domain, tt.zones)
+			assert.Equal(t, tt.expectedZone, zone)
+			assert.Equal(t, tt.expectedOK, ok)
+		})
+	}
+}
+
+func TestDiscoverACMEDNSZone(t *testing.T) {
+	tests := []struct {
+		name         string
+		zones        []string
+		statusZone   string
+		expectedZone string
+	}{
+		{
+			name:         "records the parent zone",
+			zones:        []string{"a.valid.tld."},
+			expectedZone: "a.valid.tld",
+		},
+		{
+			name:         "clears a zone the provider no longer hosts",
+			zones:        []string{"other.tld"},
+			statusZone:   "valid.tld",
+			expectedZone: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := certRequest.DeepCopy()
+			cr.Status.ACMEDNSZone = tt.statusZone
+			testClient := setUpTestClient(t, []runtime.Object{cr})
+			r := &CertificateRequestReconciler{Client: testClient}
+
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, cr))
+			require.NoError(t, r.discoverACMEDNSZone(context.TODO(), logr.Discard(), cr, zonesFakeClient{zones: tt.zones}))
+
+			stored := &certmanv1alpha1.CertificateRequest{}
+			require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, stored))
+			assert.Equal(t, tt.expectedZone, stored.Status.ACMEDNSZone)
+		})
+	}
+}
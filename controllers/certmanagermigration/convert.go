@@ -0,0 +1,573 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanagermigration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	certManagerGroup = "cert-manager.io"
+
+	issuerKind        = "Issuer"
+	clusterIssuerKind = "ClusterIssuer"
+
+	// defaultSecretKey is the key cert-manager reads a private key from when a secret key selector has none.
+	defaultSecretKey = corev1.TLSPrivateKeyKey
+
+	// defaultAWSRegion is the region of the Route53 client when the solver sets none; Route53 is a global service.
+	defaultAWSRegion = "us-east-1"
+
+	// the keys of the credentials secrets read by the DNS clients of the operator
+	awsAccessKeyIDKey     = "aws_access_key_id"
+	awsSecretAccessKeyKey = "aws_secret_access_key"
+	gcpServiceAccountKey  = "osServiceAccount.json"
+	azureCredentialsKey   = "osServicePrincipal.json"
+	cloudflareAPITokenKey = "api_token"
+	acmeDNSAccountsKey    = "acmedns.json"
+)
+
+// The cert-manager types below hold the fields of the cert-manager.io/v1 API read by the migration. Fields of
+// features the operator has no equivalent of are only decoded to reject the objects using them.
+
+type certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec certificateSpec `json:"spec"`
+}
+
+type certificateSpec struct {
+	CommonName     string                `json:"commonName,omitempty"`
+	DNSNames       []string              `json:"dnsNames,omitempty"`
+	IPAddresses    []string              `json:"ipAddresses,omitempty"`
+	URIs           []string              `json:"uris,omitempty"`
+	EmailAddresses []string              `json:"emailAddresses,omitempty"`
+	IsCA           bool                  `json:"isCA,omitempty"`
+	SecretName     string                `json:"secretName"`
+	IssuerRef      issuerReference       `json:"issuerRef"`
+	Duration       *metav1.Duration      `json:"duration,omitempty"`
+	RenewBefore    *metav1.Duration      `json:"renewBefore,omitempty"`
+	PrivateKey     *certificateKey       `json:"privateKey,omitempty"`
+	SecretTemplate *secretTemplate       `json:"secretTemplate,omitempty"`
+	Keystores      *certificateKeystores `json:"keystores,omitempty"`
+}
+
+type issuerReference struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+type certificateKey struct {
+	Algorithm      string `json:"algorithm,omitempty"`
+	Size           int    `json:"size,omitempty"`
+	RotationPolicy string `json:"rotationPolicy,omitempty"`
+}
+
+type secretTemplate struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type certificateKeystores struct {
+	JKS    *keystore `json:"jks,omitempty"`
+	PKCS12 *keystore `json:"pkcs12,omitempty"`
+}
+
+type keystore struct {
+	Create            bool              `json:"create"`
+	PasswordSecretRef secretKeySelector `json:"passwordSecretRef"`
+}
+
+type secretKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+type issuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   issuerSpec   `json:"spec"`
+	Status issuerStatus `json:"status,omitempty"`
+}
+
+type issuerSpec struct {
+	ACME *acmeIssuer `json:"acme,omitempty"`
+}
+
+type acmeIssuer struct {
+	Server              string            `json:"server"`
+	Email               string            `json:"email,omitempty"`
+	CABundle            []byte            `json:"caBundle,omitempty"`
+	PreferredChain      string            `json:"preferredChain,omitempty"`
+	PrivateKeySecretRef secretKeySelector `json:"privateKeySecretRef"`
+	Solvers             []acmeSolver      `json:"solvers,omitempty"`
+}
+
+type issuerStatus struct {
+	ACME *acmeIssuerStatus `json:"acme,omitempty"`
+}
+
+type acmeIssuerStatus struct {
+	URI string `json:"uri,omitempty"`
+}
+
+type acmeSolver struct {
+	Selector *solverSelector `json:"selector,omitempty"`
+	HTTP01   *http01Solver   `json:"http01,omitempty"`
+	DNS01    *dns01Solver    `json:"dns01,omitempty"`
+}
+
+type solverSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	DNSNames    []string          `json:"dnsNames,omitempty"`
+	DNSZones    []string          `json:"dnsZones,omitempty"`
+}
+
+// http01Solver configures the ingress or gateway cert-manager answers http-01 challenges with. The challenge server
+// of the operator replaces it, so none of its fields are read.
+type http01Solver struct{}
+
+type dns01Solver struct {
+	Route53    *route53Provider    `json:"route53,omitempty"`
+	CloudDNS   *cloudDNSProvider   `json:"cloudDNS,omitempty"`
+	Cloudflare *cloudflareProvider `json:"cloudflare,omitempty"`
+	AzureDNS   *azureDNSProvider   `json:"azureDNS,omitempty"`
+	AcmeDNS    *acmeDNSProvider    `json:"acmeDNS,omitempty"`
+
+	Akamai       *unsupportedProvider `json:"akamai,omitempty"`
+	DigitalOcean *unsupportedProvider `json:"digitalocean,omitempty"`
+	RFC2136      *unsupportedProvider `json:"rfc2136,omitempty"`
+	Webhook      *unsupportedProvider `json:"webhook,omitempty"`
+}
+
+type route53Provider struct {
+	Region                   string             `json:"region,omitempty"`
+	AccessKeyID              string             `json:"accessKeyID,omitempty"`
+	AccessKeyIDSecretRef     *secretKeySelector `json:"accessKeyIDSecretRef,omitempty"`
+	SecretAccessKeySecretRef *secretKeySelector `json:"secretAccessKeySecretRef,omitempty"`
+	Role                     string             `json:"role,omitempty"`
+}
+
+type cloudDNSProvider struct {
+	ServiceAccountSecretRef *secretKeySelector `json:"serviceAccountSecretRef,omitempty"`
+}
+
+type cloudflareProvider struct {
+	APITokenSecretRef *secretKeySelector `json:"apiTokenSecretRef,omitempty"`
+	APIKeySecretRef   *secretKeySelector `json:"apiKeySecretRef,omitempty"`
+}
+
+type azureDNSProvider struct {
+	ClientID              string             `json:"clientID,omitempty"`
+	ClientSecretSecretRef *secretKeySelector `json:"clientSecretSecretRef,omitempty"`
+	SubscriptionID        string             `json:"subscriptionID"`
+	TenantID              string             `json:"tenantID,omitempty"`
+	ResourceGroupName     string             `json:"resourceGroupName"`
+}
+
+type acmeDNSProvider struct {
+	Host             string            `json:"host"`
+	AccountSecretRef secretKeySelector `json:"accountSecretRef"`
+}
+
+type unsupportedProvider struct{}
+
+// credentials describes the secret holding the credentials of a DNS provider in the keys read by the DNS client of
+// the operator, converted from the secrets a cert-manager solver refers to.
+type credentials struct {
+	// name of the secret, in the namespace of the CertificateRequest
+	name string
+	// refs maps the keys of the secret to the cert-manager secret keys their values are copied from
+	refs map[string]secretKeySelector
+	// values maps the keys of the secret to values taken from the solver itself
+	values map[string]string
+	// jsonKey, if set, is the only key of the secret, holding refs and values as a JSON object
+	jsonKey string
+}
+
+// certIssuerName returns the name of the CertIssuer an Issuer or ClusterIssuer is converted to. CertIssuers are
+// cluster-scoped, so the namespace of an Issuer is part of the name.
+func certIssuerName(iss *issuer) string {
+	if iss.Namespace == "" {
+		return "cert-manager-" + iss.Name
+	}
+	return fmt.Sprintf("cert-manager-%s-%s", iss.Namespace, iss.Name)
+}
+
+// convertIssuer returns the CertIssuer equivalent to an ACME Issuer or ClusterIssuer. Its account secret is named
+// after the CertIssuer, in the operator namespace. Solvers are converted with each Certificate, since a
+// CertificateRequest carries its own DNS provider.
+func convertIssuer(iss *issuer) (*certmanv1alpha1.CertIssuer, error) {
+	if iss.Spec.ACME == nil {
+		return nil, fmt.Errorf("%s is not an ACME issuer", iss.Name)
+	}
+	if iss.Status.ACME == nil || iss.Status.ACME.URI == "" {
+		return nil, fmt.Errorf("%s has not registered its ACME account yet", iss.Name)
+	}
+
+	name := certIssuerName(iss)
+	return &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{certmanv1alpha1.MigratedFromAnnotation: objectKey(&iss.ObjectMeta)},
+		},
+		Spec: certmanv1alpha1.CertIssuerSpec{
+			ACME: &certmanv1alpha1.ACMEIssuer{
+				DirectoryURL:     iss.Spec.ACME.Server,
+				AccountSecretRef: corev1.SecretReference{Name: name, Namespace: config.OperatorNamespace},
+				CABundle:         iss.Spec.ACME.CABundle,
+				PreferredChain:   iss.Spec.ACME.PreferredChain,
+			},
+		},
+	}, nil
+}
+
+// convertCertificate returns the CertificateRequest equivalent to a Certificate issued by iss, converted to the
+// CertIssuer certIssuer, and the credentials of its DNS provider if any of its DNS names is proven with a dns-01
+// challenge.
+func convertCertificate(cert *certificate, iss *issuer, certIssuer string) (*certmanv1alpha1.CertificateRequest, *credentials, error) {
+	spec := cert.Spec
+	switch {
+	case len(spec.IPAddresses) > 0:
+		return nil, nil, fmt.Errorf("IP addresses are not supported")
+	case len(spec.URIs) > 0:
+		return nil, nil, fmt.Errorf("URIs are not supported")
+	case len(spec.EmailAddresses) > 0:
+		return nil, nil, fmt.Errorf("email addresses are not supported")
+	case spec.IsCA:
+		return nil, nil, fmt.Errorf("CA certificates are not supported")
+	}
+
+	dnsNames := append([]string{}, spec.DNSNames...)
+	if spec.CommonName != "" && !containsFold(dnsNames, spec.CommonName) {
+		dnsNames = append([]string{spec.CommonName}, dnsNames...)
+	}
+	if len(dnsNames) == 0 {
+		return nil, nil, fmt.Errorf("certificate has no DNS names")
+	}
+
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cert.Name,
+			Namespace:   cert.Namespace,
+			Labels:      cert.Labels,
+			Annotations: map[string]string{certmanv1alpha1.MigratedFromAnnotation: objectKey(&cert.ObjectMeta)},
+		},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			// the operator discovers the zone enclosing the DNS name
+			ACMEDNSDomain:     strings.TrimPrefix(dnsNames[0], "*."),
+			CertificateSecret: corev1.ObjectReference{Kind: "Secret", Name: spec.SecretName, Namespace: cert.Namespace},
+			DnsNames:          dnsNames,
+			Email:             iss.Spec.ACME.Email,
+			IssuerRef:         &corev1.LocalObjectReference{Name: certIssuer},
+			Duration:          spec.Duration,
+			RenewBefore:       spec.RenewBefore,
+		},
+	}
+
+	if err := convertPrivateKey(spec.PrivateKey, &cr.Spec); err != nil {
+		return nil, nil, err
+	}
+	if spec.SecretTemplate != nil {
+		cr.Spec.SecretTemplate = &certmanv1alpha1.CertificateSecretTemplate{
+			Labels:      spec.SecretTemplate.Labels,
+			Annotations: spec.SecretTemplate.Annotations,
+		}
+	}
+	cr.Spec.Keystores = convertKeystores(spec.Keystores)
+
+	dns01, err := convertSolvers(cert, iss, &cr.Spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dns01 == nil {
+		return cr, nil, nil
+	}
+
+	platform, creds, err := convertDNSProvider(dns01, cert.Name+"-dns-credentials")
+	if err != nil {
+		return nil, nil, err
+	}
+	cr.Spec.DNSProvider = platform
+	return cr, creds, nil
+}
+
+// convertPrivateKey sets the key algorithm, size and rotation policy of spec from the private key of a Certificate.
+func convertPrivateKey(key *certificateKey, spec *certmanv1alpha1.CertificateRequestSpec) error {
+	if key == nil {
+		return nil
+	}
+
+	switch key.Algorithm {
+	case "", "RSA":
+		switch key.Size {
+		case 0:
+		case 2048, 3072, 4096:
+			spec.KeySize = key.Size
+		default:
+			return fmt.Errorf("RSA keys of %d bits are not supported", key.Size)
+		}
+	case "ECDSA":
+		spec.KeyAlgorithm = certmanv1alpha1.ECDSAKeyAlgorithm
+		switch key.Size {
+		case 0, 256:
+			spec.KeyCurve = certmanv1alpha1.P256KeyCurve
+		case 384:
+			spec.KeyCurve = certmanv1alpha1.P384KeyCurve
+		default:
+			return fmt.Errorf("ECDSA keys of %d bits are not supported", key.Size)
+		}
+	default:
+		return fmt.Errorf("%s keys are not supported", key.Algorithm)
+	}
+
+	switch key.RotationPolicy {
+	case "":
+	case "Always":
+		spec.RotationPolicy = certmanv1alpha1.AlwaysRotationPolicy
+	case "Never":
+		spec.RotationPolicy = certmanv1alpha1.NeverRotationPolicy
+	default:
+		return fmt.Errorf("rotation policy %s is not supported", key.RotationPolicy)
+	}
+	return nil
+}
+
+// convertKeystores returns the keystores of the CertificateRequest equivalent to those of a Certificate.
+func convertKeystores(keystores *certificateKeystores) *certmanv1alpha1.CertificateKeystores {
+	if keystores == nil {
+		return nil
+	}
+
+	convert := func(k *keystore) *certmanv1alpha1.KeystoreOptions {
+		if k == nil || !k.Create {
+			return nil
+		}
+		return &certmanv1alpha1.KeystoreOptions{
+			PasswordSecretRef: certmanv1alpha1.SecretKeyReference{Name: k.PasswordSecretRef.Name, Key: k.PasswordSecretRef.Key},
+		}
+	}
+	converted := &certmanv1alpha1.CertificateKeystores{PKCS12: convert(keystores.PKCS12), JKS: convert(keystores.JKS)}
+	if converted.PKCS12 == nil && converted.JKS == nil {
+		return nil
+	}
+	return converted
+}
+
+// convertSolvers sets the challenge type of every DNS name of spec to that of the solver cert-manager selects for it,
+// and returns the dns-01 solver selected for the dns-01 names, if any. The operator answers the dns-01 challenges of
+// a CertificateRequest with a single DNS provider, so all of them must select the same one.
+func convertSolvers(cert *certificate, iss *issuer, spec *certmanv1alpha1.CertificateRequestSpec) (*dns01Solver, error) {
+	var dns01 *dns01Solver
+	namesByType := map[certmanv1alpha1.ChallengeType][]string{}
+	for _, name := range spec.DnsNames {
+		solver := selectSolver(iss.Spec.ACME.Solvers, cert.Labels, name)
+		switch {
+		case solver == nil:
+			return nil, fmt.Errorf("no solver of issuer %s matches DNS name %s", iss.Name, name)
+		case solver.HTTP01 != nil:
+			namesByType[certmanv1alpha1.HTTP01ChallengeType] = append(namesByType[certmanv1alpha1.HTTP01ChallengeType], name)
+		case solver.DNS01 != nil:
+			if dns01 != nil && !reflect.DeepEqual(dns01, solver.DNS01) {
+				return nil, fmt.Errorf("DNS names use several dns-01 solvers, only one is supported")
+			}
+			dns01 = solver.DNS01
+			namesByType[certmanv1alpha1.DNS01ChallengeType] = append(namesByType[certmanv1alpha1.DNS01ChallengeType], name)
+		default:
+			return nil, fmt.Errorf("solver of DNS name %s has neither http01 nor dns01", name)
+		}
+	}
+
+	switch {
+	case len(namesByType[certmanv1alpha1.HTTP01ChallengeType]) == 0:
+		// dns-01 is the default challenge type
+	case len(namesByType[certmanv1alpha1.DNS01ChallengeType]) == 0:
+		spec.ChallengeType = certmanv1alpha1.HTTP01ChallengeType
+	default:
+		spec.Solvers = []certmanv1alpha1.ChallengeSolver{{
+			DNSNames:      namesByType[certmanv1alpha1.HTTP01ChallengeType],
+			ChallengeType: certmanv1alpha1.HTTP01ChallengeType,
+		}}
+	}
+	return dns01, nil
+}
+
+// selectSolver returns the solver cert-manager selects for a DNS name of a Certificate with the labels: the first of
+// the most specific ones matching it. Solvers listing the name are the most specific, then those of the longest
+// DNS zone enclosing it, then those matching the most labels, and last those without a selector.
+func selectSolver(solvers []acmeSolver, labels map[string]string, name string) *acmeSolver {
+	var selected *acmeSolver
+	best := -1
+	for i := range solvers {
+		score := solverScore(solvers[i].Selector, labels, name)
+		if score > best {
+			selected, best = &solvers[i], score
+		}
+	}
+	return selected
+}
+
+// solverScore returns how specifically a solver selector matches a DNS name of a Certificate with the labels, or -1
+// if it does not match.
+func solverScore(selector *solverSelector, labels map[string]string, name string) int {
+	// a DNS name or zone match always outranks a label match, whatever the number of labels
+	const (
+		zoneScore     = 1 << 16
+		dnsNamesScore = 1 << 24
+	)
+
+	if selector == nil {
+		return 0
+	}
+	for k, v := range selector.MatchLabels {
+		if labels[k] != v {
+			return -1
+		}
+	}
+	score := len(selector.MatchLabels)
+
+	if len(selector.DNSNames) > 0 || len(selector.DNSZones) > 0 {
+		if containsFold(selector.DNSNames, name) {
+			return dnsNamesScore + score
+		}
+		zone := ""
+		domain := strings.ToLower(strings.TrimPrefix(name, "*."))
+		for _, z := range selector.DNSZones {
+			z = strings.ToLower(strings.TrimSuffix(z, "."))
+			if (domain == z || strings.HasSuffix(domain, "."+z)) && len(z) > len(zone) {
+				zone = z
+			}
+		}
+		if zone == "" {
+			return -1
+		}
+		return zoneScore + len(zone)
+	}
+	return score
+}
+
+// convertDNSProvider returns the DNS provider of a CertificateRequest equivalent to a dns-01 solver, and the
+// credentials secret secretName it reads.
+func convertDNSProvider(solver *dns01Solver, secretName string) (*certmanv1alpha1.Platform, *credentials, error) {
+	ref := func(s *secretKeySelector) secretKeySelector { return keyOrDefault(*s) }
+	secret := corev1.LocalObjectReference{Name: secretName}
+
+	switch {
+	case solver.Route53 != nil:
+		p := solver.Route53
+		if p.SecretAccessKeySecretRef == nil || (p.AccessKeyID == "" && p.AccessKeyIDSecretRef == nil) {
+			return nil, nil, fmt.Errorf("route53 solvers with ambient credentials are not supported")
+		}
+		creds := &credentials{
+			name:   secretName,
+			refs:   map[string]secretKeySelector{awsSecretAccessKeyKey: ref(p.SecretAccessKeySecretRef)},
+			values: map[string]string{},
+		}
+		if p.AccessKeyIDSecretRef != nil {
+			creds.refs[awsAccessKeyIDKey] = ref(p.AccessKeyIDSecretRef)
+		} else {
+			creds.values[awsAccessKeyIDKey] = p.AccessKeyID
+		}
+		region := p.Region
+		if region == "" {
+			region = defaultAWSRegion
+		}
+		return &certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: secret, Region: region, RoleARN: p.Role}}, creds, nil
+
+	case solver.CloudDNS != nil:
+		if solver.CloudDNS.ServiceAccountSecretRef == nil {
+			return nil, nil, fmt.Errorf("cloudDNS solvers with ambient credentials are not supported")
+		}
+		creds := &credentials{
+			name: secretName,
+			refs: map[string]secretKeySelector{gcpServiceAccountKey: ref(solver.CloudDNS.ServiceAccountSecretRef)},
+		}
+		return &certmanv1alpha1.Platform{GCP: &certmanv1alpha1.GCPPlatformSecrets{Credentials: secret}}, creds, nil
+
+	case solver.Cloudflare != nil:
+		if solver.Cloudflare.APITokenSecretRef == nil {
+			return nil, nil, fmt.Errorf("cloudflare solvers authenticating with an API key are not supported")
+		}
+		creds := &credentials{
+			name: secretName,
+			refs: map[string]secretKeySelector{cloudflareAPITokenKey: ref(solver.Cloudflare.APITokenSecretRef)},
+		}
+		return &certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{Credentials: secret}}, creds, nil
+
+	case solver.AzureDNS != nil:
+		p := solver.AzureDNS
+		if p.ClientSecretSecretRef == nil {
+			return nil, nil, fmt.Errorf("azureDNS solvers with managed identities are not supported")
+		}
+		creds := &credentials{
+			name: secretName,
+			refs: map[string]secretKeySelector{"clientSecret": ref(p.ClientSecretSecretRef)},
+			values: map[string]string{
+				"clientId":       p.ClientID,
+				"tenantId":       p.TenantID,
+				"subscriptionId": p.SubscriptionID,
+			},
+			jsonKey: azureCredentialsKey,
+		}
+		return &certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{Credentials: secret, ResourceGroupName: p.ResourceGroupName}}, creds, nil
+
+	case solver.AcmeDNS != nil:
+		// cert-manager and the operator read the accounts in the same format
+		creds := &credentials{
+			name: secretName,
+			refs: map[string]secretKeySelector{acmeDNSAccountsKey: ref(&solver.AcmeDNS.AccountSecretRef)},
+		}
+		return &certmanv1alpha1.Platform{AcmeDNS: &certmanv1alpha1.AcmeDNSPlatformSecrets{Credentials: secret, Host: solver.AcmeDNS.Host}}, creds, nil
+	}
+	return nil, nil, fmt.Errorf("dns01 solvers other than route53, cloudDNS, cloudflare, azureDNS and acmeDNS are not supported")
+}
+
+// objectKey returns the <namespace>/<name> of an object, or its name if it is cluster-scoped.
+func objectKey(meta *metav1.ObjectMeta) string {
+	if meta.Namespace == "" {
+		return meta.Name
+	}
+	return meta.Namespace + "/" + meta.Name
+}
+
+// keyOrDefault returns the secret key selector with the key cert-manager reads when it sets none.
+func keyOrDefault(ref secretKeySelector) secretKeySelector {
+	if ref.Key == "" {
+		ref.Key = defaultSecretKey
+	}
+	return ref
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
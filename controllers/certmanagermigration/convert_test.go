@@ -0,0 +1,196 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanagermigration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func TestConvertCertificate(t *testing.T) {
+	route53 := &dns01Solver{Route53: &route53Provider{
+		AccessKeyID:              "AKIAEXAMPLE",
+		SecretAccessKeySecretRef: &secretKeySelector{Name: "route53", Key: "secret-access-key"},
+	}}
+	cloudflare := &dns01Solver{Cloudflare: &cloudflareProvider{APITokenSecretRef: &secretKeySelector{Name: "cloudflare", Key: "token"}}}
+
+	tests := []struct {
+		name                  string
+		spec                  certificateSpec
+		solvers               []acmeSolver
+		expectedError         string
+		expectedDNSNames      []string
+		expectedChallengeType certmanv1alpha1.ChallengeType
+		expectedSolvers       []certmanv1alpha1.ChallengeSolver
+		expectedDNSProvider   *certmanv1alpha1.Platform
+		expectedKeyCurve      certmanv1alpha1.KeyCurve
+	}{
+		{
+			name:             "converts a dns-01 certificate with its common name",
+			spec:             certificateSpec{CommonName: "example.com", DNSNames: []string{"*.example.com"}},
+			solvers:          []acmeSolver{{DNS01: route53}},
+			expectedDNSNames: []string{"example.com", "*.example.com"},
+			expectedDNSProvider: &certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{
+				Credentials: corev1.LocalObjectReference{Name: "web-dns-credentials"},
+				Region:      defaultAWSRegion,
+			}},
+		},
+		{
+			name: "converts a route53 solver assuming a role",
+			spec: certificateSpec{DNSNames: []string{"www.example.com"}},
+			solvers: []acmeSolver{{DNS01: &dns01Solver{Route53: &route53Provider{
+				Region:                   "eu-west-1",
+				AccessKeyID:              "AKIAEXAMPLE",
+				SecretAccessKeySecretRef: &secretKeySelector{Name: "route53", Key: "secret-access-key"},
+				Role:                     "arn:aws:iam::123456789012:role/dns",
+			}}}},
+			expectedDNSNames: []string{"www.example.com"},
+			expectedDNSProvider: &certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{
+				Credentials: corev1.LocalObjectReference{Name: "web-dns-credentials"},
+				Region:      "eu-west-1",
+				RoleARN:     "arn:aws:iam::123456789012:role/dns",
+			}},
+		},
+		{
+			name:                  "converts an http-01 certificate",
+			spec:                  certificateSpec{DNSNames: []string{"www.example.com"}},
+			solvers:               []acmeSolver{{HTTP01: &http01Solver{}}},
+			expectedDNSNames:      []string{"www.example.com"},
+			expectedChallengeType: certmanv1alpha1.HTTP01ChallengeType,
+		},
+		{
+			name:             "converts an acme-dns solver",
+			spec:             certificateSpec{DNSNames: []string{"www.example.com"}},
+			solvers:          []acmeSolver{{DNS01: &dns01Solver{AcmeDNS: &acmeDNSProvider{Host: "https://auth.example.org", AccountSecretRef: secretKeySelector{Name: "acme-dns", Key: "acmedns.json"}}}}},
+			expectedDNSNames: []string{"www.example.com"},
+			expectedDNSProvider: &certmanv1alpha1.Platform{AcmeDNS: &certmanv1alpha1.AcmeDNSPlatformSecrets{
+				Host:        "https://auth.example.org",
+				Credentials: corev1.LocalObjectReference{Name: "web-dns-credentials"},
+			}},
+		},
+		{
+			name: "selects the most specific solver of each DNS name",
+			spec: certificateSpec{DNSNames: []string{"www.example.com", "api.internal.example.com", "*.apps.example.com"}},
+			solvers: []acmeSolver{
+				{HTTP01: &http01Solver{}},
+				{Selector: &solverSelector{DNSZones: []string{"internal.example.com"}}, DNS01: cloudflare},
+				{Selector: &solverSelector{DNSNames: []string{"*.apps.example.com"}}, DNS01: cloudflare},
+			},
+			expectedDNSNames: []string{"www.example.com", "api.internal.example.com", "*.apps.example.com"},
+			expectedSolvers: []certmanv1alpha1.ChallengeSolver{{
+				DNSNames:      []string{"www.example.com"},
+				ChallengeType: certmanv1alpha1.HTTP01ChallengeType,
+			}},
+			expectedDNSProvider: &certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{
+				Credentials: corev1.LocalObjectReference{Name: "web-dns-credentials"},
+			}},
+		},
+		{
+			name:                  "converts the private key",
+			spec:                  certificateSpec{DNSNames: []string{"www.example.com"}, PrivateKey: &certificateKey{Algorithm: "ECDSA", Size: 384}},
+			solvers:               []acmeSolver{{HTTP01: &http01Solver{}}},
+			expectedDNSNames:      []string{"www.example.com"},
+			expectedChallengeType: certmanv1alpha1.HTTP01ChallengeType,
+			expectedKeyCurve:      certmanv1alpha1.P384KeyCurve,
+		},
+		{
+			name:          "rejects Ed25519 keys",
+			spec:          certificateSpec{DNSNames: []string{"www.example.com"}, PrivateKey: &certificateKey{Algorithm: "Ed25519"}},
+			solvers:       []acmeSolver{{HTTP01: &http01Solver{}}},
+			expectedError: "Ed25519 keys are not supported",
+		},
+		{
+			name:          "rejects IP addresses",
+			spec:          certificateSpec{DNSNames: []string{"www.example.com"}, IPAddresses: []string{"192.0.2.1"}},
+			solvers:       []acmeSolver{{HTTP01: &http01Solver{}}},
+			expectedError: "IP addresses are not supported",
+		},
+		{
+			name: "rejects several dns-01 solvers",
+			spec: certificateSpec{DNSNames: []string{"www.example.com", "www.example.org"}},
+			solvers: []acmeSolver{
+				{Selector: &solverSelector{DNSZones: []string{"example.com"}}, DNS01: route53},
+				{Selector: &solverSelector{DNSZones: []string{"example.org"}}, DNS01: cloudflare},
+			},
+			expectedError: "only one is supported",
+		},
+		{
+			name:          "rejects DNS names no solver matches",
+			spec:          certificateSpec{DNSNames: []string{"www.example.org"}},
+			solvers:       []acmeSolver{{Selector: &solverSelector{DNSZones: []string{"example.com"}}, DNS01: route53}},
+			expectedError: "no solver of issuer letsencrypt matches DNS name www.example.org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.SecretName = "web-tls"
+			cert := &certificate{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"}, Spec: tt.spec}
+			iss := &issuer{
+				ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt"},
+				Spec: issuerSpec{ACME: &acmeIssuer{
+					Server:  "https://acme-v02.api.letsencrypt.org/directory",
+					Email:   "admin@example.com",
+					Solvers: tt.solvers,
+				}},
+			}
+
+			cr, creds, err := convertCertificate(cert, iss, "cert-manager-letsencrypt")
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, "apps/web", cr.Annotations[certmanv1alpha1.MigratedFromAnnotation])
+			assert.Equal(t, tt.expectedDNSNames, cr.Spec.DnsNames)
+			assert.Equal(t, "web-tls", cr.Spec.CertificateSecret.Name)
+			assert.Equal(t, "admin@example.com", cr.Spec.Email)
+			assert.Equal(t, "cert-manager-letsencrypt", cr.Spec.IssuerRef.Name)
+			assert.Equal(t, tt.expectedChallengeType, cr.Spec.ChallengeType)
+			assert.Equal(t, tt.expectedSolvers, cr.Spec.Solvers)
+			assert.Equal(t, tt.expectedDNSProvider, cr.Spec.DNSProvider)
+			assert.Equal(t, tt.expectedKeyCurve, cr.Spec.KeyCurve)
+			assert.Equal(t, tt.expectedDNSProvider != nil, creds != nil)
+		})
+	}
+}
+
+func TestConvertIssuer(t *testing.T) {
+	iss := &issuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt", Namespace: "apps"},
+		Spec:       issuerSpec{ACME: &acmeIssuer{Server: "https://acme.example.com/directory", CABundle: []byte("bundle"), PreferredChain: "ISRG Root X1"}},
+	}
+
+	_, err := convertIssuer(iss)
+	assert.ErrorContains(t, err, "has not registered its ACME account yet")
+
+	iss.Status.ACME = &acmeIssuerStatus{URI: "https://acme.example.com/acct/1"}
+	certIssuer, err := convertIssuer(iss)
+	require.NoError(t, err)
+	assert.Equal(t, "cert-manager-apps-letsencrypt", certIssuer.Name)
+	assert.Equal(t, "https://acme.example.com/directory", certIssuer.Spec.ACME.DirectoryURL)
+	assert.Equal(t, "cert-manager-apps-letsencrypt", certIssuer.Spec.ACME.AccountSecretRef.Name)
+	assert.Equal(t, []byte("bundle"), certIssuer.Spec.ACME.CABundle)
+	assert.Equal(t, "ISRG Root X1", certIssuer.Spec.ACME.PreferredChain)
+}
@@ -0,0 +1,357 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanagermigration
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultInterval is how often cert-manager Certificates are looked for, so that those created after the
+	// migration started are migrated as well.
+	DefaultInterval = time.Hour
+
+	// DefaultClusterResourceNamespace is the namespace cert-manager reads the secrets of ClusterIssuers from by default.
+	DefaultClusterResourceNamespace = "cert-manager"
+
+	// the keys of the ACME account secret read by the operator
+	accountPrivateKeyKey = "private-key"
+	accountURLKey        = "account-url"
+)
+
+var log = logf.Log.WithName("certmanagermigration")
+
+var _ manager.LeaderElectionRunnable = &Migrator{}
+
+var (
+	certificateGVK   = schema.GroupVersionKind{Group: certManagerGroup, Version: "v1", Kind: "Certificate"}
+	issuerGVK        = schema.GroupVersionKind{Group: certManagerGroup, Version: "v1", Kind: issuerKind}
+	clusterIssuerGVK = schema.GroupVersionKind{Group: certManagerGroup, Version: "v1", Kind: clusterIssuerKind}
+)
+
+// Migrator periodically converts the cert-manager Certificates of ACME Issuers and ClusterIssuers into
+// CertificateRequests, so that the operator takes over certificates managed by cert-manager. Each Issuer becomes a
+// CertIssuer registered with the same ACME account, the credentials of its DNS-01 solvers are copied into the keys
+// the operator reads, and the certificate secret of each Certificate is released from cert-manager so that its
+// CertificateRequest adopts it instead of ordering a new certificate. Certificates that are already migrated, or
+// use features the operator has no equivalent of, are left alone. The cert-manager objects are never modified nor
+// deleted.
+type Migrator struct {
+	Client client.Client
+	// APIReader reads the cert-manager objects and the secrets they refer to, so that the operator does not cache
+	// them.
+	APIReader client.Reader
+	// ClusterResourceNamespace is the namespace cert-manager reads the secrets of ClusterIssuers from. Empty uses
+	// DefaultClusterResourceNamespace.
+	ClusterResourceNamespace string
+	// Interval is how often the Certificates are migrated. Zero uses DefaultInterval.
+	Interval time.Duration
+}
+
+// Start migrates the cert-manager Certificates every Interval until ctx is done.
+func (m *Migrator) Start(ctx context.Context) error {
+	interval := m.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := m.Migrate(ctx); err != nil {
+			log.Error(err, "failed to migrate cert-manager Certificates")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection keeps replicas that are not the leader from creating the same objects.
+func (m *Migrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Migrate converts every cert-manager Certificate without a CertificateRequest of the same name. It is a no-op on
+// clusters without the cert-manager CRDs. A Certificate that cannot be migrated does not keep the others from being
+// migrated.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	certificates := &unstructured.UnstructuredList{}
+	certificates.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind + "List"))
+	if err := m.APIReader.List(ctx, certificates); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("cert-manager CRDs are not installed, nothing to migrate")
+			return nil
+		}
+		return err
+	}
+
+	errs := []error{}
+	for i := range certificates.Items {
+		cert := &certificate{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(certificates.Items[i].Object, cert); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := m.migrateCertificate(ctx, cert); err != nil {
+			errs = append(errs, fmt.Errorf("certificate %s/%s: %w", cert.Namespace, cert.Name, err))
+		}
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// migrateCertificate creates the CertIssuer, the secrets and the CertificateRequest a Certificate is converted to.
+// The CertificateRequest is created last, once everything it refers to exists.
+func (m *Migrator) migrateCertificate(ctx context.Context, cert *certificate) error {
+	existing := &certmanv1alpha1.CertificateRequest{}
+	err := m.APIReader.Get(ctx, types.NamespacedName{Namespace: cert.Namespace, Name: cert.Name}, existing)
+	if err == nil {
+		if existing.Annotations[certmanv1alpha1.MigratedFromAnnotation] == "" {
+			log.V(1).Info("skipping certificate, a certificaterequest of the same name exists", "Namespace", cert.Namespace, "Name", cert.Name)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	iss, err := m.getIssuer(ctx, cert)
+	if err != nil {
+		return err
+	}
+	certIssuer, err := convertIssuer(iss)
+	if err != nil {
+		return err
+	}
+	cr, creds, err := convertCertificate(cert, iss, certIssuer.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := m.ensureCertIssuer(ctx, iss, certIssuer); err != nil {
+		return err
+	}
+	if creds != nil {
+		if err := m.ensureCredentials(ctx, iss, cr, creds); err != nil {
+			return err
+		}
+	}
+	if err := m.releaseCertificateSecret(ctx, cert); err != nil {
+		return err
+	}
+
+	log.Info("creating certificaterequest migrated from cert-manager", "Namespace", cr.Namespace, "Name", cr.Name, "CertIssuer", certIssuer.Name)
+	return m.Client.Create(ctx, cr)
+}
+
+// getIssuer returns the Issuer or ClusterIssuer of a Certificate.
+func (m *Migrator) getIssuer(ctx context.Context, cert *certificate) (*issuer, error) {
+	ref := cert.Spec.IssuerRef
+	if ref.Group != "" && ref.Group != certManagerGroup {
+		return nil, fmt.Errorf("external issuers of group %s are not supported", ref.Group)
+	}
+
+	obj := &unstructured.Unstructured{}
+	key := types.NamespacedName{Name: ref.Name}
+	switch ref.Kind {
+	case "", issuerKind:
+		obj.SetGroupVersionKind(issuerGVK)
+		key.Namespace = cert.Namespace
+	case clusterIssuerKind:
+		obj.SetGroupVersionKind(clusterIssuerGVK)
+	default:
+		return nil, fmt.Errorf("issuer kind %s is not supported", ref.Kind)
+	}
+	if err := m.APIReader.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+
+	iss := &issuer{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, iss); err != nil {
+		return nil, err
+	}
+	return iss, nil
+}
+
+// ensureCertIssuer creates the CertIssuer of an Issuer and its account secret, holding the private key and URL of
+// the ACME account the Issuer registered, unless they exist.
+func (m *Migrator) ensureCertIssuer(ctx context.Context, iss *issuer, certIssuer *certmanv1alpha1.CertIssuer) error {
+	secretRef := certIssuer.Spec.ACME.AccountSecretRef
+	accountSecret := &corev1.Secret{}
+	err := m.APIReader.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, accountSecret)
+	if errors.IsNotFound(err) {
+		key, err := m.readSecretKey(ctx, m.secretNamespace(iss), keyOrDefault(iss.Spec.ACME.PrivateKeySecretRef))
+		if err != nil {
+			return fmt.Errorf("failed to read the ACME account key of issuer %s: %w", iss.Name, err)
+		}
+		// the operator reads PKCS#1 RSA and SEC 1 EC account keys, as written by cert-manager
+		if block, _ := pem.Decode(key); block == nil || (block.Type != "RSA PRIVATE KEY" && block.Type != "EC PRIVATE KEY") {
+			return fmt.Errorf("the ACME account key of issuer %s is not a PEM encoded RSA or EC private key", iss.Name)
+		}
+
+		log.Info("creating ACME account secret migrated from cert-manager", "Namespace", secretRef.Namespace, "Name", secretRef.Name)
+		accountSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        secretRef.Name,
+				Namespace:   secretRef.Namespace,
+				Annotations: certIssuer.Annotations,
+			},
+			Data: map[string][]byte{
+				accountPrivateKeyKey: key,
+				accountURLKey:        []byte(iss.Status.ACME.URI),
+			},
+		}
+		if err := m.Client.Create(ctx, accountSecret); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	existing := &certmanv1alpha1.CertIssuer{}
+	err = m.APIReader.Get(ctx, types.NamespacedName{Name: certIssuer.Name}, existing)
+	if errors.IsNotFound(err) {
+		log.Info("creating certissuer migrated from cert-manager", "Name", certIssuer.Name)
+		if err := m.Client.Create(ctx, certIssuer); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+	return err
+}
+
+// ensureCredentials creates the credentials secret of the DNS provider of a CertificateRequest from the secrets of
+// its cert-manager solver, unless it was created by an earlier attempt. A secret of the same name that was not
+// created by the migration is never overwritten.
+func (m *Migrator) ensureCredentials(ctx context.Context, iss *issuer, cr *certmanv1alpha1.CertificateRequest, creds *credentials) error {
+	existing := &corev1.Secret{}
+	err := m.APIReader.Get(ctx, types.NamespacedName{Namespace: cr.Namespace, Name: creds.name}, existing)
+	if err == nil {
+		if existing.Annotations[certmanv1alpha1.MigratedFromAnnotation] == "" {
+			return fmt.Errorf("secret %s already exists and was not created by the migration", creds.name)
+		}
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	values := map[string]string{}
+	for k, v := range creds.values {
+		values[k] = v
+	}
+	for k, ref := range creds.refs {
+		value, err := m.readSecretKey(ctx, m.secretNamespace(iss), ref)
+		if err != nil {
+			return fmt.Errorf("failed to read the DNS provider credentials of issuer %s: %w", iss.Name, err)
+		}
+		values[k] = string(value)
+	}
+
+	data := map[string][]byte{}
+	if creds.jsonKey != "" {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		data[creds.jsonKey] = encoded
+	} else {
+		for k, v := range values {
+			data[k] = []byte(v)
+		}
+	}
+
+	log.Info("creating DNS provider credentials migrated from cert-manager", "Namespace", cr.Namespace, "Name", creds.name)
+	return m.Client.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        creds.name,
+			Namespace:   cr.Namespace,
+			Annotations: map[string]string{certmanv1alpha1.MigratedFromAnnotation: objectKey(&iss.ObjectMeta)},
+		},
+		Data: data,
+	})
+}
+
+// releaseCertificateSecret removes the owner reference cert-manager sets on the certificate secret of a Certificate
+// when configured to, so that the CertificateRequest can adopt the secret and the secret survives the deletion of
+// the Certificate.
+func (m *Migrator) releaseCertificateSecret(ctx context.Context, cert *certificate) error {
+	secret := &corev1.Secret{}
+	err := m.APIReader.Get(ctx, types.NamespacedName{Namespace: cert.Namespace, Name: cert.Spec.SecretName}, secret)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	owners := []metav1.OwnerReference{}
+	for _, owner := range secret.OwnerReferences {
+		gv, err := schema.ParseGroupVersion(owner.APIVersion)
+		if err == nil && gv.Group == certManagerGroup && owner.Kind == certificateGVK.Kind {
+			continue
+		}
+		owners = append(owners, owner)
+	}
+	if len(owners) == len(secret.OwnerReferences) {
+		return nil
+	}
+
+	log.Info("releasing certificate secret from cert-manager", "Namespace", secret.Namespace, "Name", secret.Name)
+	secret.OwnerReferences = owners
+	return m.Client.Update(ctx, secret)
+}
+
+// secretNamespace returns the namespace cert-manager reads the secrets of an Issuer or ClusterIssuer from.
+func (m *Migrator) secretNamespace(iss *issuer) string {
+	if iss.Namespace != "" {
+		return iss.Namespace
+	}
+	if m.ClusterResourceNamespace != "" {
+		return m.ClusterResourceNamespace
+	}
+	return DefaultClusterResourceNamespace
+}
+
+// readSecretKey returns the value of a key of a secret.
+func (m *Migrator) readSecretKey(ctx context.Context, namespace string, ref secretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := m.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, err
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no key %s", namespace, ref.Name, ref.Key)
+	}
+	return value, nil
+}
@@ -18,29 +18,41 @@ package clusterdeployment
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/controllers/utils"
 	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+	"github.com/openshift/certman-operator/pkg/tracing"
 )
 
 var log = logf.Log.WithName("controller_clusterdeployment")
@@ -50,7 +62,39 @@ const (
 	hiveRelocationAnnotation             = "hive.openshift.io/relocate"
 	hiveRelocationOutgoingValue          = "outgoing"
 	fakeClusterDeploymentAnnotation      = "managed.openshift.com/fake"
+	extraSANsAnnotation                  = "certman.managed.openshift.io/extra-sans"
+	nonWildcardIngressAnnotation         = "certman.managed.openshift.io/non-wildcard-ingress"
+	apexIngressAnnotation                = "certman.managed.openshift.io/include-apex-ingress"
+	notificationEmailsAnnotation         = "certman.managed.openshift.io/notification-emails"
+	dnsProviderAnnotation                = "certman.managed.openshift.io/dns-provider"
+	importedCertificatesAnnotation       = "certman.managed.openshift.io/imported-certificates"
+	syncSetDeliveryAnnotation            = "certman.managed.openshift.io/deliver-with-syncset"
+	customDomainsAnnotation              = "certman.managed.openshift.io/custom-domains"
 	ClusterDeploymentLimitedSupportLabel = "api.openshift.com/limited-support"
+
+	// outcomeControllerName labels the reconcile outcomes of the controller
+	outcomeControllerName = "clusterdeployment"
+
+	// Reasons of the Events recorded on ClusterDeployments
+	certificateRequestCreatedReason      = "CertificateRequestCreated"
+	certificateRequestUpdatedReason      = "CertificateRequestUpdated"
+	certificateRequestDeletedReason      = "CertificateRequestDeleted"
+	createCertificateRequestFailedReason = "CreateCertificateRequestFailed"
+	updateCertificateRequestFailedReason = "UpdateCertificateRequestFailed"
+	deleteCertificateRequestFailedReason = "DeleteCertificateRequestFailed"
+	invalidDNSProviderReason             = "InvalidDNSProvider"
+	invalidImportedCertificatesReason    = "InvalidImportedCertificates"
+	invalidCustomDomainsReason           = "InvalidCustomDomains"
+
+	// customDomainBundlePrefix prefixes the names of the CustomDomains in the names of their CertificateRequests,
+	// so that they do not collide with those of the certificate bundles.
+	customDomainBundlePrefix = "customdomain-"
+
+	// ownerUIDIndex indexes the CertificateRequests in the cache by the UID of the ClusterDeployment owning them.
+	ownerUIDIndex = "metadata.ownerReferences.clusterDeploymentUID"
+	// noClusterDeploymentOwner is the value of ownerUIDIndex of the CertificateRequests without a ClusterDeployment
+	// owner reference, which is never the UID of an object.
+	noClusterDeploymentOwner = "none"
 )
 
 var _ reconcile.Reconciler = &ClusterDeploymentReconciler{}
@@ -59,49 +103,76 @@ var _ reconcile.Reconciler = &ClusterDeploymentReconciler{}
 type ClusterDeploymentReconciler struct {
 	Client client.Client
 	Scheme *runtime.Scheme
+	// Scope restricts the ClusterDeployments the controller manages. A nil Scope manages all of them.
+	Scope *utils.Scope
+	// Recorder records Events on the ClusterDeployments.
+	Recorder record.EventRecorder
+	// RateLimiter tunes the rate limiter of the workqueue of the controller.
+	RateLimiter utils.RateLimiterOptions
 }
 
 // Reconcile reads that state of the cluster for a ClusterDeployment object and sets up
 // any needed CertificateRequest objects.
-func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
-	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, request reconcile.Request) (_ reconcile.Result, err error) {
+	reqLogger := log.WithValues(logging.Cluster, request.String())
 	reqLogger.Info("reconciling ClusterDeployment")
 
 	timer := prometheus.NewTimer(localmetrics.MetricClusterDeploymentReconcileDuration)
+	outcome := localmetrics.ReconcileUnchanged
 	defer func() {
 		reconcileDuration := timer.ObserveDuration()
+		localmetrics.ObserveReconcileOutcome(outcomeControllerName, outcome, err)
 		reqLogger.WithValues("Duration", reconcileDuration).Info("Reconcile complete.")
 	}()
 
+	ctx, span := tracing.Start(ctx, "ClusterDeployment.Reconcile", attribute.String("k8s.namespace.name", request.Namespace), attribute.String("clusterdeployment.name", request.Name))
+	defer span.End()
+
 	// Fetch the ClusterDeployment instance
 	cd := &hivev1.ClusterDeployment{}
-	err := r.Client.Get(context.TODO(), request.NamespacedName, cd)
+	err = r.Client.Get(ctx, request.NamespacedName, cd)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			localmetrics.ClearClusterMetrics(request.Name, request.Namespace)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
 		reqLogger.Error(err, "error looking up clusterDeployment")
 		return reconcile.Result{}, err
 	}
-	// Report LimitedSupport status clusters
-	val, ok := cd.Labels[ClusterDeploymentLimitedSupportLabel]
-	if val == "true" {
-		reqLogger.Info("Cluster is in Limited Support")
-		localmetrics.ClusterInLimitedSupport(cd.Name, cd.Namespace)
+
+	// Leave the ClusterDeployments of other operator instances alone
+	if !r.Scope.Matches(cd) {
+		reqLogger.Info("clusterdeployment is out of the scope of this operator, skipping reconcile")
+		outcome = localmetrics.ReconcileSkippedNotManaged
+		return reconcile.Result{}, nil
 	}
-	if val != "true" || !ok {
-		reqLogger.Info("Cluster is in Full Support")
-		localmetrics.ClusterInFullSupport(cd.Name, cd.Namespace)
+
+	// Report LimitedSupport status clusters, and stop reporting the clusters being deleted
+	val, ok := cd.Labels[ClusterDeploymentLimitedSupportLabel]
+	if !cd.DeletionTimestamp.IsZero() {
+		localmetrics.ClearClusterMetrics(cd.Name, cd.Namespace)
+	} else {
+		if val == "true" {
+			reqLogger.Info("Cluster is in Limited Support")
+			localmetrics.ClusterInLimitedSupport(cd.Name, cd.Namespace)
+		}
+		if val != "true" || !ok {
+			reqLogger.Info("Cluster is in Full Support")
+			localmetrics.ClusterInFullSupport(cd.Name, cd.Namespace)
+		}
+
+		localmetrics.SetClusterHibernating(cd.Name, cd.Namespace, cd.Spec.PowerState == hivev1.ClusterPowerStateHibernating)
 	}
 
 	// Do not make certificate request if the cluster is not a Red Hat managed cluster.
 	val, ok = cd.Labels[ClusterDeploymentManagedLabel]
 	if !ok || val != "true" {
 		reqLogger.Info("not a managed cluster")
+		outcome = localmetrics.ReconcileSkippedNotManaged
 		return reconcile.Result{}, nil
 	}
 
@@ -109,19 +180,22 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, request rec
 	val, ok = cd.Annotations[fakeClusterDeploymentAnnotation]
 	if ok && val == "true" {
 		reqLogger.Info("fake cluster identified, skipping reconcile")
+		outcome = localmetrics.ReconcileSkippedFake
 		return reconcile.Result{}, nil
 	}
 
 	//Do not reconcile if cluster is not installed
 	if !cd.Spec.Installed {
-		reqLogger.Info(fmt.Sprintf("cluster %v is not yet in installed state", cd.Name))
+		reqLogger.Info("cluster is not yet in installed state")
+		outcome = localmetrics.ReconcileSkippedNotInstalled
 		return reconcile.Result{}, nil
 	}
 
 	// Do not reconcile if the cluster is being relocated
 	for a, v := range cd.Annotations {
 		if a == hiveRelocationAnnotation && strings.Split(v, "/")[1] == hiveRelocationOutgoingValue {
-			reqLogger.Info(fmt.Sprintf("Not reconciling: ClusterDeployment %s is relocating", cd.Name))
+			reqLogger.Info("Not reconciling: ClusterDeployment is relocating")
+			outcome = localmetrics.ReconcileSkippedRelocating
 			return reconcile.Result{}, nil
 		}
 	}
@@ -131,7 +205,7 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, request rec
 		// The object is being deleted
 		if utils.ContainsString(cd.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel) {
 			reqLogger.Info("deleting the CertificateRequest for the ClusterDeployment")
-			if err := r.handleDelete(cd, reqLogger); err != nil {
+			if err := r.handleDelete(ctx, cd, reqLogger); err != nil {
 				reqLogger.Error(err, "error deleting CertificateRequests")
 				return reconcile.Result{}, err
 			}
@@ -139,65 +213,122 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, request rec
 			reqLogger.Info("removing CertmanOperator finalizer from the ClusterDeployment")
 			baseToPatch := client.MergeFrom(cd.DeepCopy())
 			cd.Finalizers = utils.RemoveString(cd.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel)
-			if err := r.Client.Patch(context.TODO(), cd, baseToPatch); err != nil {
+			if err := r.Client.Patch(ctx, cd, baseToPatch); err != nil {
 				reqLogger.Error(err, "error removing finalizer from ClusterDeployment")
 				return reconcile.Result{}, err
 			}
 		}
 		return reconcile.Result{}, nil
 	}
+	// Do not create, update or delete CertificateRequests while the cluster is paused
+	if utils.IsPaused(cd) {
+		reqLogger.Info("Not reconciling: ClusterDeployment is paused")
+		outcome = localmetrics.ReconcileSkippedPaused
+		return reconcile.Result{}, nil
+	}
+
+	// Do not order certificates for pool clusters before they are claimed, and delete those of released clusters
+	claimed, err := r.isClaimed(ctx, cd)
+	if err != nil {
+		reqLogger.Error(err, "error looking up the ClusterClaim of the ClusterDeployment")
+		return reconcile.Result{}, err
+	}
+	if !claimed {
+		reqLogger.Info("Not reconciling: ClusterDeployment is not claimed from its ClusterPool")
+		if err := r.handleDelete(ctx, cd, reqLogger); err != nil {
+			reqLogger.Error(err, "error deleting CertificateRequests")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
 	// add finalizer
 	if !utils.ContainsString(cd.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel) {
 		reqLogger.Info("adding CertmanOperator finalizer to the ClusterDeployment")
 		baseToPatch := client.MergeFrom(cd.DeepCopy())
 		cd.Finalizers = append(cd.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel)
-		if err := r.Client.Patch(context.TODO(), cd, baseToPatch); err != nil {
+		if err := r.Client.Patch(ctx, cd, baseToPatch); err != nil {
 			reqLogger.Error(err, "error adding finalizer to ClusterDeployment")
 			return reconcile.Result{}, err
 		}
 	}
 
-	if err := r.syncCertificateRequests(cd, reqLogger); err != nil {
+	if err := r.syncCertificateRequests(ctx, cd, reqLogger); err != nil {
 		reqLogger.Error(err, "error syncing CertificateRequests")
 		return reconcile.Result{}, err
 	}
 
 	reqLogger.Info("done syncing")
+	outcome = localmetrics.ReconcileSynced
 	return reconcile.Result{}, nil
 }
 
 // syncCertificateRequests generates/updates a CertificateRequest for each CertificateBundle
 // with CertificateBundle.Generate == true. Returns an error if anything fails in this process.
 // Cleanup is performed by deleting old CertificateRequests.
-func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.ClusterDeployment, logger logr.Logger) error {
+func (r *ClusterDeploymentReconciler) syncCertificateRequests(ctx context.Context, cd *hivev1.ClusterDeployment, logger logr.Logger) error {
 	desiredCRs := []certmanv1alpha1.CertificateRequest{}
 
 	// get a list of current CertificateRequests
-	currentCRs, err := r.getCurrentCertificateRequests(cd, logger)
+	currentCRs, err := r.getCurrentCertificateRequests(ctx, cd, logger)
+	if err != nil {
+		logger.Error(err, err.Error())
+		return err
+	}
+
+	extraRecord, err := utils.GetExtraRecord(ctx, r.Client)
+	if err != nil {
+		logger.Error(err, err.Error())
+		return err
+	}
+
+	dnsProvider, err := dnsProvider(cd)
+	if err != nil {
+		logger.Error(err, err.Error())
+		r.Recorder.Event(cd, corev1.EventTypeWarning, invalidDNSProviderReason, err.Error())
+		return err
+	}
+
+	importedSecrets, err := importedCertificates(cd)
 	if err != nil {
 		logger.Error(err, err.Error())
+		r.Recorder.Event(cd, corev1.EventTypeWarning, invalidImportedCertificatesReason, err.Error())
+		return err
+	}
+
+	customDomains, err := customDomains(cd)
+	if err != nil {
+		logger.Error(err, err.Error())
+		r.Recorder.Event(cd, corev1.EventTypeWarning, invalidCustomDomainsReason, err.Error())
 		return err
 	}
 
 	// for each certbundle with generate==true make a CertificateRequest
 	for _, cb := range cd.Spec.CertificateBundles {
 
-		logger.Info(fmt.Sprintf("processing certificate bundle %v", cb.Name),
+		logger.Info("processing certificate bundle",
 			"CertificateBundleName", cb.Name,
 			"GenerateCertificate", cb.Generate,
 		)
 
 		if cb.Generate {
-			domains := getDomainsForCertBundle(cb, cd, logger)
+			domains := getDomainsForCertBundle(cb, cd, extraRecord, logger)
 
-			emailAddress, err := utils.GetDefaultNotificationEmailAddress(r.Client)
+			emails, err := r.notificationEmails(ctx, cd)
 			if err != nil {
 				logger.Error(err, err.Error())
 				return err
 			}
 
 			if len(domains) > 0 {
-				certReq := createCertificateRequest(cb.Name, cb.CertificateSecretRef.Name, domains, cd, emailAddress)
+				certReq := createCertificateRequest(cb.Name, cb.CertificateSecretRef.Name, domains, cd, emails)
+				certReq.Spec.DNSProvider = dnsProvider.DeepCopy()
+				if secretName, ok := importedSecrets[cb.Name]; ok {
+					certReq.Spec.ImportedSecret = &corev1.LocalObjectReference{Name: secretName}
+				}
+				if cd.Annotations[syncSetDeliveryAnnotation] == "true" {
+					certReq.Spec.SyncSet = syncSetFor(cb, cd)
+				}
 				desiredCRs = append(desiredCRs, certReq)
 			} else {
 				err := fmt.Errorf("no domains provided for certificate bundle %v in the cluster deployment %v", cb.Name, cd.Name)
@@ -206,6 +337,24 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 		}
 	}
 
+	if len(customDomains) > 0 {
+		emails, err := r.notificationEmails(ctx, cd)
+		if err != nil {
+			logger.Error(err, err.Error())
+			return err
+		}
+
+		names := make([]string, 0, len(customDomains))
+		for name := range customDomains {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			logger.Info("processing custom domain", "CustomDomain", name, logging.Domain, customDomains[name])
+			desiredCRs = append(desiredCRs, customDomainCertificateRequest(name, customDomains[name], cd, emails, dnsProvider))
+		}
+	}
+
 	deleteCRs := []certmanv1alpha1.CertificateRequest{}
 
 	// find any extra certificateRequests and mark them for deletion
@@ -231,7 +380,7 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 		searchKey := types.NamespacedName{Name: desiredCR.Name, Namespace: desiredCR.Namespace}
 		certBundleStatus := hivev1.CertificateBundleStatus{}
 		certBundleStatus.Name = strings.TrimPrefix(desiredCR.Name, cd.Name+"-")
-		if err := r.Client.Get(context.TODO(), searchKey, currentCR); err != nil {
+		if err := r.Client.Get(ctx, searchKey, currentCR); err != nil {
 			certBundleStatus.Generated = false
 			if errors.IsNotFound(err) {
 				// create
@@ -241,12 +390,14 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 					continue
 				}
 
-				logger.Info(fmt.Sprintf("creating CertificateRequest resource config %v", desiredCR.Name))
-				if err := r.Client.Create(context.TODO(), &desiredCR); err != nil {
+				logger.Info("creating CertificateRequest resource config", logging.CertRequest, desiredCR.Namespace+"/"+desiredCR.Name)
+				if err := r.Client.Create(ctx, &desiredCR); err != nil {
 					logger.Error(err, "error creating certificaterequest")
+					r.Recorder.Eventf(cd, corev1.EventTypeWarning, createCertificateRequestFailedReason, "failed to create CertificateRequest %s: %v", desiredCR.Name, err)
 					errs = append(errs, err)
 					continue
 				}
+				r.Recorder.Eventf(cd, corev1.EventTypeNormal, certificateRequestCreatedReason, "created CertificateRequest %s for %s", desiredCR.Name, strings.Join(desiredCR.Spec.DnsNames, ", "))
 
 			} else {
 				logger.Error(err, "error checking for existing certificaterequest")
@@ -257,11 +408,13 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 			if !reflect.DeepEqual(currentCR.Spec, desiredCR.Spec) {
 				certBundleStatus.Generated = false
 				currentCR.Spec = desiredCR.Spec
-				if err := r.Client.Update(context.TODO(), currentCR); err != nil {
+				if err := r.Client.Update(ctx, currentCR); err != nil {
 					logger.Error(err, "error updating certificaterequest", "certrequest", currentCR.Name)
+					r.Recorder.Eventf(cd, corev1.EventTypeWarning, updateCertificateRequestFailedReason, "failed to update CertificateRequest %s: %v", currentCR.Name, err)
 					errs = append(errs, err)
 					continue
 				}
+				r.Recorder.Eventf(cd, corev1.EventTypeNormal, certificateRequestUpdatedReason, "updated CertificateRequest %s", currentCR.Name)
 			} else {
 				if currentCR.Status.Issued {
 					certBundleStatus.Generated = true
@@ -272,21 +425,25 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 				logger.Info("no update needed for certificaterequest", "certrequest", desiredCR.Name)
 			}
 		}
-		certBundleStatusList = append(certBundleStatusList, certBundleStatus)
+		// custom domains are not certificate bundles of the ClusterDeployment
+		if desiredCR.Annotations[certmanv1alpha1.CustomDomainAnnotation] == "" {
+			certBundleStatusList = append(certBundleStatusList, certBundleStatus)
+		}
 	}
 	cd.Status.CertificateBundles = certBundleStatusList
-	if len(errs) > 0 {
-		return fmt.Errorf("met multiple errors when sync certificaterequests")
-	}
 
-	// delete the  certificaterequests
+	// delete the certificaterequests, carrying on past failures so that one broken object does not block the
+	// cleanup of the others
 	for _, deleteCR := range deleteCRs {
 		deleteCR := deleteCR
-		logger.Info(fmt.Sprintf("deleting CertificateRequest resource config  %v", deleteCR.Name))
-		if err := r.Client.Delete(context.TODO(), &deleteCR); err != nil {
+		logger.Info("deleting CertificateRequest resource config", logging.CertRequest, deleteCR.Namespace+"/"+deleteCR.Name)
+		if err := r.Client.Delete(ctx, &deleteCR); err != nil && !errors.IsNotFound(err) {
 			logger.Error(err, "error deleting CertificateRequest that is no longer needed", "certrequest", deleteCR.Name)
-			return err
+			r.Recorder.Eventf(cd, corev1.EventTypeWarning, deleteCertificateRequestFailedReason, "failed to delete CertificateRequest %s that is no longer needed: %v", deleteCR.Name, err)
+			errs = append(errs, fmt.Errorf("failed to delete certificaterequest %s: %w", deleteCR.Name, err))
+			continue
 		}
+		r.Recorder.Eventf(cd, corev1.EventTypeNormal, certificateRequestDeletedReason, "deleted CertificateRequest %s that is no longer needed", deleteCR.Name)
 	}
 
 	cdCopy := cd.DeepCopy()
@@ -294,34 +451,62 @@ func (r *ClusterDeploymentReconciler) syncCertificateRequests(cd *hivev1.Cluster
 	if !reflect.DeepEqual(cd.Status, cdCopy.Status) {
 		baseToPatch := client.MergeFrom(cdCopy.DeepCopy())
 		cdCopy.Status.CertificateBundles = certBundleStatusList
-		if err := r.Client.Patch(context.TODO(), cdCopy, baseToPatch); err != nil {
+		if err := r.Client.Patch(ctx, cdCopy, baseToPatch); err != nil {
 			logger.Error(err, "error when update clusterDeploymentStatus")
 		}
 	}
 
+	if len(errs) > 0 {
+		return fmt.Errorf("met multiple errors when sync certificaterequests: %w", kerrors.NewAggregate(errs))
+	}
 	return nil
 }
 
 // getCurrentCertificateRequests returns an array of CertificateRequests owned by the cluster, within the clusters namespace.
-func (r *ClusterDeploymentReconciler) getCurrentCertificateRequests(cd *hivev1.ClusterDeployment, logger logr.Logger) ([]certmanv1alpha1.CertificateRequest, error) {
+// They are looked up by the owner index of the cache rather than by listing the whole namespace. CertificateRequests
+// that lost their owner references are returned too, so that they are still cleaned up, unless they were migrated
+// from cert-manager, as those share the namespace but belong to no ClusterDeployment.
+func (r *ClusterDeploymentReconciler) getCurrentCertificateRequests(ctx context.Context, cd *hivev1.ClusterDeployment, logger logr.Logger) ([]certmanv1alpha1.CertificateRequest, error) {
 	certReqsForCluster := []certmanv1alpha1.CertificateRequest{}
 
-	// get all CRs in the cluster's namespace
-	currentCRs := &certmanv1alpha1.CertificateRequestList{}
-	if err := r.Client.List(context.TODO(), currentCRs, client.InNamespace(cd.Namespace)); err != nil {
+	ownedCRs := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, ownedCRs, client.InNamespace(cd.Namespace), client.MatchingFields{ownerUIDIndex: string(cd.UID)}); err != nil {
 		logger.Error(err, "error listing current CertificateRequests")
 		return certReqsForCluster, err
 	}
+	certReqsForCluster = append(certReqsForCluster, ownedCRs.Items...)
 
-	certReqsForCluster = append(certReqsForCluster, currentCRs.Items...)
+	ownerlessCRs := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, ownerlessCRs, client.InNamespace(cd.Namespace), client.MatchingFields{ownerUIDIndex: noClusterDeploymentOwner}); err != nil {
+		logger.Error(err, "error listing current CertificateRequests")
+		return []certmanv1alpha1.CertificateRequest{}, err
+	}
+	for _, cr := range ownerlessCRs.Items {
+		if cr.Annotations[certmanv1alpha1.MigratedFromAnnotation] != "" {
+			continue
+		}
+		certReqsForCluster = append(certReqsForCluster, cr)
+	}
 
 	return certReqsForCluster, nil
 }
 
+// clusterDeploymentOwnerUID is the indexer of ownerUIDIndex. CertificateRequests without a ClusterDeployment owner
+// reference are indexed under noClusterDeploymentOwner.
+func clusterDeploymentOwnerUID(obj client.Object) []string {
+	for _, o := range obj.GetOwnerReferences() {
+		if o.Kind == "ClusterDeployment" {
+			return []string{string(o.UID)}
+		}
+	}
+	return []string{noClusterDeploymentOwner}
+}
+
 // getDomainsForCertBundle returns a slice of domains after validating if CertificateBundleSpec.Name
 // matches the default control plane name and appending any other matching domain names from the rest
-// of the control plane and ingress list to the domain slice.
-func getDomainsForCertBundle(cb hivev1.CertificateBundleSpec, cd *hivev1.ClusterDeployment, logger logr.Logger) []string {
+// of the control plane and ingress list to the domain slice. A non-empty extraRecord adds
+// <extraRecord>.<cluster name>.<base domain> to the control plane domains.
+func getDomainsForCertBundle(cb hivev1.CertificateBundleSpec, cd *hivev1.ClusterDeployment, extraRecord string, logger logr.Logger) []string {
 	// declare a slice to hold domains
 	domains := []string{}
 	dLogger := logger.WithValues("CertificateBundle", cb.Name)
@@ -335,12 +520,19 @@ func getDomainsForCertBundle(cb hivev1.CertificateBundleSpec, cd *hivev1.Cluster
 		domains = append(domains, controlPlaneCertDomain)
 
 		// Check for extra record option and add to SAN if it's present
-		userDomain := os.Getenv("EXTRA_RECORD")
-		if userDomain != "" {
-			extraDomain := fmt.Sprintf("%s.%s.%s", userDomain, cd.Spec.ClusterName, cd.Spec.BaseDomain)
+		if extraRecord != "" {
+			extraDomain := fmt.Sprintf("%s.%s.%s", extraRecord, cd.Spec.ClusterName, cd.Spec.BaseDomain)
 			dLogger.Info("RH private control plane config DNS name: " + extraDomain)
 			domains = append(domains, extraDomain)
 		}
+
+		// Add any extra SANs requested through the ClusterDeployment annotation
+		for _, extraSAN := range getExtraSANs(cd, dLogger) {
+			if !utils.ContainsString(domains, extraSAN) {
+				dLogger.Info("extra SAN added to certificate request: " + extraSAN)
+				domains = append(domains, extraSAN)
+			}
+		}
 	}
 
 	// now check the rest of the control plane
@@ -352,26 +544,190 @@ func getDomainsForCertBundle(cb hivev1.CertificateBundleSpec, cd *hivev1.Cluster
 	}
 
 	// and lastly the ingress list
+	wildcardIngress := !utils.ContainsString(splitAnnotationList(cd, nonWildcardIngressAnnotation), cb.Name)
+	apexIngress := utils.ContainsString(splitAnnotationList(cd, apexIngressAnnotation), cb.Name)
 	for _, ingress := range cd.Spec.Ingress {
 		if ingress.ServingCertificate == cb.Name {
 			ingressDomain := ingress.Domain
 
-			// always request wildcard certificates for the ingress domain
-			if !strings.HasPrefix(ingressDomain, "*.") {
+			// request wildcard certificates for the ingress domain unless the bundle opted out
+			if wildcardIngress && !strings.HasPrefix(ingressDomain, "*.") {
 				ingressDomain = fmt.Sprintf("*.%s", ingress.Domain)
 			}
 
 			dLogger.Info("ingress domain added to certificate request: " + ingressDomain)
 			domains = append(domains, ingressDomain)
+
+			// the wildcard does not cover the apex ingress domain itself, so add it when the bundle opted in
+			apexDomain := strings.TrimPrefix(ingressDomain, "*.")
+			if apexIngress && apexDomain != ingressDomain && !utils.ContainsString(domains, apexDomain) {
+				dLogger.Info("apex ingress domain added to certificate request: " + apexDomain)
+				domains = append(domains, apexDomain)
+			}
 		}
 	}
 
 	return domains
 }
 
+// getExtraSANs returns the comma-separated domains of the extra SANs annotation on the ClusterDeployment.
+// Domains that do not fall under the cluster's base domain are logged and skipped.
+func getExtraSANs(cd *hivev1.ClusterDeployment, logger logr.Logger) []string {
+	extraSANs := []string{}
+
+	baseDomain := strings.ToLower(cd.Spec.BaseDomain)
+	for _, san := range splitAnnotationList(cd, extraSANsAnnotation) {
+		san = strings.ToLower(san)
+		if san != baseDomain && !strings.HasSuffix(san, "."+baseDomain) {
+			logger.Info("skipping extra SAN as it is not under the base domain", logging.Domain, san, "baseDomain", baseDomain)
+			continue
+		}
+
+		extraSANs = append(extraSANs, san)
+	}
+
+	return extraSANs
+}
+
+// splitAnnotationList returns the non-empty entries of a comma-separated annotation on the ClusterDeployment.
+func splitAnnotationList(cd *hivev1.ClusterDeployment, annotation string) []string {
+	entries := []string{}
+	for _, entry := range strings.Split(cd.Annotations[annotation], ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// notificationEmails returns the contacts of the certificates of the ClusterDeployment: the addresses of its
+// notification-emails annotation, or else the default notification email address of the operator.
+func (r *ClusterDeploymentReconciler) notificationEmails(ctx context.Context, cd *hivev1.ClusterDeployment) ([]string, error) {
+	if emails := splitAnnotationList(cd, notificationEmailsAnnotation); len(emails) > 0 {
+		return emails, nil
+	}
+
+	emailAddress, err := utils.GetDefaultNotificationEmailAddress(ctx, r.Client)
+	if err != nil {
+		return nil, err
+	}
+	return []string{emailAddress}, nil
+}
+
+// dnsProvider returns the DNS provider override of the ClusterDeployment, or nil without one. Its dns-provider
+// annotation holds the JSON of the platform answering the challenges, such as
+// {"cloudflare":{"credentials":{"name":"cloudflare-api-token"}}}.
+func dnsProvider(cd *hivev1.ClusterDeployment) (*certmanv1alpha1.Platform, error) {
+	value := strings.TrimSpace(cd.Annotations[dnsProviderAnnotation])
+	if value == "" {
+		return nil, nil
+	}
+
+	platform := &certmanv1alpha1.Platform{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(platform); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", dnsProviderAnnotation, err)
+	}
+
+	providers := 0
+	for _, provider := range []bool{platform.AWS != nil, platform.GCP != nil, platform.Azure != nil, platform.Cloudflare != nil, platform.AcmeDNS != nil, platform.Mock != nil} {
+		if provider {
+			providers++
+		}
+	}
+	if providers != 1 {
+		return nil, fmt.Errorf("invalid %s annotation: expected exactly one DNS provider, got %d", dnsProviderAnnotation, providers)
+	}
+	return platform, nil
+}
+
+// importedCertificates returns the names of the secrets holding the certificates imported for the certificate
+// bundles of the ClusterDeployment. Its imported-certificates annotation holds the JSON map of the bundle names to
+// the secret names, such as {"primary-cert-bundle":"customer-certificate"}.
+func importedCertificates(cd *hivev1.ClusterDeployment) (map[string]string, error) {
+	value := strings.TrimSpace(cd.Annotations[importedCertificatesAnnotation])
+	if value == "" {
+		return nil, nil
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &secrets); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", importedCertificatesAnnotation, err)
+	}
+	for bundle, secret := range secrets {
+		if secret == "" {
+			return nil, fmt.Errorf("invalid %s annotation: no secret for certificate bundle %s", importedCertificatesAnnotation, bundle)
+		}
+	}
+	return secrets, nil
+}
+
+// customDomains returns the domains of the CustomDomains of the managed cluster, which are issued certificates like
+// its certificate bundles. Its custom-domains annotation holds the JSON map of the names of the CustomDomains to their
+// domains, such as {"acme-apps":"apps.acme.io"}, mirroring the CustomDomain resources of the custom-domains-operator.
+func customDomains(cd *hivev1.ClusterDeployment) (map[string]string, error) {
+	value := strings.TrimSpace(cd.Annotations[customDomainsAnnotation])
+	if value == "" {
+		return nil, nil
+	}
+
+	domains := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &domains); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", customDomainsAnnotation, err)
+	}
+	for name, domain := range domains {
+		// the name of a CustomDomain names its CertificateRequest and the ingress controller serving it
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s annotation: invalid custom domain name %q: %s", customDomainsAnnotation, name, strings.Join(errs, ", "))
+		}
+		if domain == "" || strings.HasPrefix(domain, "*.") {
+			return nil, fmt.Errorf("invalid %s annotation: custom domain %s must be a domain without wildcard, got %q", customDomainsAnnotation, name, domain)
+		}
+	}
+	return domains, nil
+}
+
+// customDomainCertificateRequest returns the CertificateRequest of the wildcard certificate of the CustomDomain
+// name, served by the ingress controller the custom-domains-operator names after it. The challenges are answered
+// in the closest DNS zone of the provider enclosing the domain, which is looked up from the domain itself.
+func customDomainCertificateRequest(name, domain string, cd *hivev1.ClusterDeployment, emails []string, dnsProvider *certmanv1alpha1.Platform) certmanv1alpha1.CertificateRequest {
+	certReq := createCertificateRequest(customDomainBundlePrefix+name, name+"-custom-domain-certificate", []string{"*." + domain}, cd, emails)
+	certReq.Annotations = map[string]string{certmanv1alpha1.CustomDomainAnnotation: name}
+	certReq.Spec.ACMEDNSDomain = domain
+	certReq.Spec.DNSProvider = dnsProvider.DeepCopy()
+	if cd.Annotations[syncSetDeliveryAnnotation] == "true" {
+		certReq.Spec.SyncSet = &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress, IngressController: name}
+	}
+	return certReq
+}
+
+// syncSetFor returns the SyncSet delivery of the certificate bundle, serving it from the API server if the control
+// plane of the ClusterDeployment uses the bundle, or from the ingress controller of the first ingress using it. It
+// returns nil if neither uses the bundle.
+func syncSetFor(cb hivev1.CertificateBundleSpec, cd *hivev1.ClusterDeployment) *certmanv1alpha1.CertificateSyncSet {
+	servingCertificates := cd.Spec.ControlPlaneConfig.ServingCertificates
+	if servingCertificates.Default == cb.Name {
+		return &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetAPIServer}
+	}
+	for _, additional := range servingCertificates.Additional {
+		if additional.Name == cb.Name {
+			return &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetAPIServer}
+		}
+	}
+
+	for _, ingress := range cd.Spec.Ingress {
+		if ingress.ServingCertificate == cb.Name {
+			return &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress, IngressController: ingress.Name}
+		}
+	}
+
+	return nil
+}
+
 // createCertificateRequest constructs a CertificateRequest constructed by the
-// certmanv1alpha1.CertificateRequest schema.
-func createCertificateRequest(certBundleName string, secretName string, domains []string, cd *hivev1.ClusterDeployment, emailAddress string) certmanv1alpha1.CertificateRequest {
+// certmanv1alpha1.CertificateRequest schema. The first of emails is its Email, and the others its further Emails.
+func createCertificateRequest(certBundleName string, secretName string, domains []string, cd *hivev1.ClusterDeployment, emails []string) certmanv1alpha1.CertificateRequest {
 	name := fmt.Sprintf("%s-%s", cd.Name, certBundleName)
 	name = strings.ToLower(name)
 
@@ -388,11 +744,16 @@ func createCertificateRequest(certBundleName string, secretName string, domains
 				Name:      secretName,
 			},
 			DnsNames:      domains,
-			Email:         emailAddress,
 			APIURL:        cd.Status.APIURL,
 			WebConsoleURL: cd.Status.WebConsoleURL,
 		},
 	}
+	if len(emails) > 0 {
+		cr.Spec.Email = emails[0]
+	}
+	if len(emails) > 1 {
+		cr.Spec.Emails = emails[1:]
+	}
 
 	// GCP platform
 	if cd.Spec.Platform.GCP != nil {
@@ -431,10 +792,92 @@ func createCertificateRequest(certBundleName string, secretName string, domains
 	return cr
 }
 
+// watchedLabels and watchedAnnotations are the ClusterDeployment metadata the reconcile reads.
+var (
+	watchedLabels      = []string{ClusterDeploymentManagedLabel, ClusterDeploymentLimitedSupportLabel}
+	watchedAnnotations = []string{
+		hiveRelocationAnnotation,
+		fakeClusterDeploymentAnnotation,
+		extraSANsAnnotation,
+		nonWildcardIngressAnnotation,
+		apexIngressAnnotation,
+		notificationEmailsAnnotation,
+		dnsProviderAnnotation,
+		importedCertificatesAnnotation,
+		syncSetDeliveryAnnotation,
+		customDomainsAnnotation,
+		certmanv1alpha1.PausedAnnotation,
+	}
+)
+
+// clusterDeploymentChanged filters ClusterDeployment updates down to the changes the reconcile reacts to, so that
+// the status heartbeats Hive writes do not each trigger a full reconcile.
+var clusterDeploymentChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCD, okOld := e.ObjectOld.(*hivev1.ClusterDeployment)
+		newCD, okNew := e.ObjectNew.(*hivev1.ClusterDeployment)
+		if !okOld || !okNew {
+			return true
+		}
+
+		if !oldCD.DeletionTimestamp.Equal(newCD.DeletionTimestamp) ||
+			!reflect.DeepEqual(oldCD.Finalizers, newCD.Finalizers) {
+			return true
+		}
+		for _, label := range watchedLabels {
+			if oldCD.Labels[label] != newCD.Labels[label] {
+				return true
+			}
+		}
+		for _, annotation := range watchedAnnotations {
+			if oldCD.Annotations[annotation] != newCD.Annotations[annotation] {
+				return true
+			}
+		}
+
+		return oldCD.Spec.Installed != newCD.Spec.Installed ||
+			oldCD.Spec.PowerState != newCD.Spec.PowerState ||
+			oldCD.Spec.ClusterName != newCD.Spec.ClusterName ||
+			oldCD.Spec.BaseDomain != newCD.Spec.BaseDomain ||
+			!reflect.DeepEqual(oldCD.Spec.Platform, newCD.Spec.Platform) ||
+			!reflect.DeepEqual(oldCD.Spec.CertificateBundles, newCD.Spec.CertificateBundles) ||
+			!reflect.DeepEqual(oldCD.Spec.Ingress, newCD.Spec.Ingress) ||
+			!reflect.DeepEqual(oldCD.Spec.ControlPlaneConfig, newCD.Spec.ControlPlaneConfig) ||
+			!reflect.DeepEqual(oldCD.Spec.ClusterPoolRef, newCD.Spec.ClusterPoolRef) ||
+			oldCD.Status.APIURL != newCD.Status.APIURL ||
+			oldCD.Status.WebConsoleURL != newCD.Status.WebConsoleURL
+	},
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &certmanv1alpha1.CertificateRequest{}, ownerUIDIndex, clusterDeploymentOwnerUID); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&hivev1.ClusterDeployment{}).
-		Owns(&certmanv1alpha1.CertificateRequest{}).
+		For(&hivev1.ClusterDeployment{}, builder.WithPredicates(r.Scope.ClusterDeploymentPredicate(), predicate.Or(clusterDeploymentChanged, r.Scope.EnteredPredicate()))).
+		Owns(&certmanv1alpha1.CertificateRequest{}, builder.WithPredicates(r.Scope.NamespacePredicate())).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.clusterDeploymentsForConfigMap), builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(r.clusterDeploymentsForConfigMap), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Watches(&hivev1.ClusterClaim{}, handler.EnqueueRequestsFromMapFunc(r.clusterDeploymentsForClusterClaim)).
+		WithOptions(controller.Options{RateLimiter: utils.NewRateLimiter(r.RateLimiter, utils.DefaultRateLimiterOptions)}).
 		Complete(r)
 }
+
+// clusterDeploymentsForConfigMap enqueues every ClusterDeployment when the operator ConfigMap or the
+// CertmanOperatorConfig changes, so that CertificateRequests are re-stamped with the current default notification
+// email address and extra record.
+func (r *ClusterDeploymentReconciler) clusterDeploymentsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.Client.List(ctx, cdList); err != nil {
+		log.Error(err, "error listing ClusterDeployments")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(cdList.Items))
+	for _, cd := range cdList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cd.Name, Namespace: cd.Namespace}})
+	}
+	return requests
+}
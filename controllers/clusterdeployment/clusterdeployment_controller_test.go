@@ -23,11 +23,13 @@ import (
 
 	"github.com/go-logr/logr"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
 	hiveapis "github.com/openshift/hive/apis"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
 	"github.com/openshift/hive/apis/hive/v1/azure"
 	"github.com/openshift/hive/apis/hive/v1/gcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
@@ -35,8 +37,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
@@ -52,6 +56,7 @@ const (
 	testAWSCredentialsSecret     = "aws-iam-secret"
 	testExtraControlPlaneDNSName = "anotherapi.testing.example.com"
 	testIngressDefaultDomain     = "apps.testing.example.com"
+	testPoolNamespace            = "pools"
 )
 
 // CertificateRequestEntry generates a test Certificate that logic is validated
@@ -97,6 +102,15 @@ func TestClusterDeploymentReconciler(t *testing.T) {
 			localObjects:           testObjects(testNotInstalledClusterDeployment()),
 			expectFinalizerPresent: false,
 		},
+		{
+			name: "Test paused cluster deployment",
+			localObjects: testObjects(func() *hivev1.ClusterDeployment {
+				cd := testClusterDeploymentWithGenerateAPI()
+				cd.Annotations = map[string]string{certmanv1alpha1.PausedAnnotation: "true"}
+				return cd
+			}()),
+			expectFinalizerPresent: false,
+		},
 		{
 			name:         "Test generate control plane cert",
 			localObjects: testObjects(testClusterDeploymentWithGenerateAPI()),
@@ -108,6 +122,62 @@ func TestClusterDeploymentReconciler(t *testing.T) {
 			},
 			expectFinalizerPresent: true,
 		},
+		{
+			name: "Test generate custom domain certs",
+			localObjects: testObjects(func() *hivev1.ClusterDeployment {
+				cd := testClusterDeploymentWithGenerateAPI()
+				cd.Annotations = map[string]string{customDomainsAnnotation: `{"acme-apps":"apps.acme.io"}`}
+				return cd
+			}()),
+			expectedCertificateRequests: []CertificateRequestEntry{
+				{
+					name:     fmt.Sprintf("%s-%s", testClusterName, testCertBundleName),
+					dnsNames: []string{fmt.Sprintf("api.%s.%s", testClusterName, testBaseDomain)},
+				},
+				{
+					name:     fmt.Sprintf("%s-customdomain-acme-apps", testClusterName),
+					dnsNames: []string{"*.apps.acme.io"},
+				},
+			},
+			expectFinalizerPresent: true,
+		},
+		{
+			name: "Test unclaimed pool cluster deployment",
+			localObjects: func() []runtime.Object {
+				cd := testClusterDeploymentWithGenerateAPI()
+				cd.Spec.ClusterPoolRef = &hivev1.ClusterPoolReference{Namespace: testPoolNamespace, PoolName: "pool"}
+				return append(testObjects(cd), testCertificateRequest(cd))
+			}(),
+			expectFinalizerPresent: false,
+		},
+		{
+			name: "Test released pool cluster deployment",
+			localObjects: func() []runtime.Object {
+				cd := testClusterDeploymentWithGenerateAPI()
+				cd.Spec.ClusterPoolRef = &hivev1.ClusterPoolReference{Namespace: testPoolNamespace, PoolName: "pool", ClaimName: "claim"}
+				return append(testObjects(cd), testCertificateRequest(cd))
+			}(),
+			expectFinalizerPresent: false,
+		},
+		{
+			name: "Test claimed pool cluster deployment",
+			localObjects: func() []runtime.Object {
+				cd := testClusterDeploymentWithGenerateAPI()
+				cd.Spec.ClusterPoolRef = &hivev1.ClusterPoolReference{Namespace: testPoolNamespace, PoolName: "pool", ClaimName: "claim"}
+				claim := &hivev1.ClusterClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: testPoolNamespace},
+					Spec:       hivev1.ClusterClaimSpec{ClusterPoolName: "pool", Namespace: testNamespace},
+				}
+				return append(testObjects(cd), claim)
+			}(),
+			expectedCertificateRequests: []CertificateRequestEntry{
+				{
+					name:     fmt.Sprintf("%s-%s", testClusterName, testCertBundleName),
+					dnsNames: []string{fmt.Sprintf("api.%s.%s", testClusterName, testBaseDomain)},
+				},
+			},
+			expectFinalizerPresent: true,
+		},
 		{
 			name:         "Test generate cert with multi control plane",
 			localObjects: testObjects(testClusterDeploymentWithAdditionalControlPlaneCert()),
@@ -198,12 +268,13 @@ func TestClusterDeploymentReconciler(t *testing.T) {
 
 			// Create a NewFakeClient to interact with Reconcile functionality.
 			// localObjects are defined within each test
-			fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.localObjects...).Build()
+			fakeClient := newFakeClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(test.localObjects...).Build()
 
 			// Instantiate a ClusterDeploymentReconciler type to act as a reconcile client
 			rcd := &ClusterDeploymentReconciler{
-				Client: fakeClient,
-				Scheme: scheme.Scheme,
+				Recorder: &record.FakeRecorder{},
+				Client:   fakeClient,
+				Scheme:   scheme.Scheme,
 			}
 
 			// Call the ClusterDeploymentReconciler types Reconcile method with a test name and namespace object
@@ -256,6 +327,45 @@ func TestClusterDeploymentReconciler(t *testing.T) {
 	}
 }
 
+func TestReconcileOutcome(t *testing.T) {
+	require.NoError(t, certmanv1alpha1.AddToScheme(scheme.Scheme))
+	require.NoError(t, hiveapis.AddToScheme(scheme.Scheme))
+
+	fakeCD := testClusterDeploymentAws()
+	fakeCD.SetAnnotations(map[string]string{fakeClusterDeploymentAnnotation: "true"})
+	relocatingCD := testClusterDeploymentAws()
+	relocatingCD.SetAnnotations(map[string]string{"hive.openshift.io/relocate": "fakehive/outgoing"})
+
+	tests := []struct {
+		name     string
+		cd       *hivev1.ClusterDeployment
+		expected string
+	}{
+		{name: "managed cluster", cd: testClusterDeploymentAws(), expected: localmetrics.ReconcileSynced},
+		{name: "unmanaged cluster", cd: testUnmanagedClusterDeployment(), expected: localmetrics.ReconcileSkippedNotManaged},
+		{name: "fake cluster", cd: fakeCD, expected: localmetrics.ReconcileSkippedFake},
+		{name: "relocating cluster", cd: relocatingCD, expected: localmetrics.ReconcileSkippedRelocating},
+		{name: "cluster not installed", cd: testNotInstalledClusterDeployment(), expected: localmetrics.ReconcileSkippedNotInstalled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			localmetrics.MetricReconcileOutcomes.Reset()
+			rcd := &ClusterDeploymentReconciler{
+				Recorder: &record.FakeRecorder{},
+				Client:   newFakeClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(append(testObjects(), test.cd)...).Build(),
+				Scheme:   scheme.Scheme,
+			}
+
+			_, err := rcd.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: testClusterName, Namespace: testNamespace}})
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, testutil.CollectAndCount(localmetrics.MetricReconcileOutcomes))
+			assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricReconcileOutcomes.WithLabelValues(outcomeControllerName, test.expected)))
+		})
+	}
+}
+
 // TestCertificateRequestDeletion tests the deletion of the CertificateRequest.
 // Recent version of controller-runtime handles the Patch request in Reconcile differently which fails Get request for ClusterDeployment as well.
 // Since the only test having deletiontimestamp set is this test "Test deletion of certificate request",
@@ -275,14 +385,18 @@ func TestCertificateRequestDeletion(t *testing.T) {
 
 		// Create a NewFakeClient to interact with Reconcile functionality.
 		// localObjects are defined within each test
-		fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(testObjects(testhandleDeleteClusterDeployment())...).Build()
+		fakeClient := newFakeClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(testObjects(testhandleDeleteClusterDeployment())...).Build()
 
 		// Instantiate a ClusterDeploymentReconciler type to act as a reconcile client
 		rcd := &ClusterDeploymentReconciler{
-			Client: fakeClient,
-			Scheme: scheme.Scheme,
+			Recorder: &record.FakeRecorder{},
+			Client:   fakeClient,
+			Scheme:   scheme.Scheme,
 		}
 
+		localmetrics.MetricLimitedSupportCluster.Reset()
+		localmetrics.ClusterInLimitedSupport(testClusterName, testNamespace)
+
 		// Call the ClusterDeploymentReconciler types Reconcile method with a test name and namespace object
 		// to Reconcile. Validate no error is returned.
 		_, err := rcd.Reconcile(context.TODO(), reconcile.Request{
@@ -299,6 +413,9 @@ func TestCertificateRequestDeletion(t *testing.T) {
 		cd := &hivev1.ClusterDeployment{}
 		err = fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: testNamespace, Name: testClusterName}, cd)
 		assert.NotNil(t, err, "unable to delete ClusterDeployment: %q", err)
+
+		// the deleted cluster is no longer reported
+		assert.Equal(t, 0, testutil.CollectAndCount(localmetrics.MetricLimitedSupportCluster))
 	})
 }
 
@@ -422,6 +539,40 @@ func testNotInstalledClusterDeployment() *hivev1.ClusterDeployment {
 
 // testhandleDeleteClusterDeployment returns testClusterDeployment with
 // SetDeletionTimestamp and Finalizer to test certificate deletion.
+func TestSyncCertificateRequestsDeletionErrors(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+
+	cd := testClusterDeploymentAws()
+	broken := testCertificateRequest(cd)
+	broken.Name = "broken-cert-request"
+	stale := testCertificateRequest(cd)
+	stale.Name = "stale-cert-request"
+
+	objects := append(testObjects(), cd, broken, stale)
+	fakeClient := newFakeClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).Build()
+	recorder := record.NewFakeRecorder(10)
+	rcd := &ClusterDeploymentReconciler{
+		Client:   &failingDeleteClient{Client: fakeClient, name: broken.Name},
+		Scheme:   scheme.Scheme,
+		Recorder: recorder,
+	}
+
+	err = rcd.syncCertificateRequests(context.TODO(), cd, logr.Discard())
+	assert.ErrorContains(t, err, broken.Name)
+
+	// the failure to delete one CertificateRequest does not keep the others from being deleted
+	remaining := &certmanv1alpha1.CertificateRequestList{}
+	require.NoError(t, fakeClient.List(context.TODO(), remaining, client.InNamespace(testNamespace)))
+	require.Len(t, remaining.Items, 1)
+	assert.Equal(t, broken.Name, remaining.Items[0].Name)
+
+	require.Len(t, recorder.Events, 2)
+	events := []string{<-recorder.Events, <-recorder.Events}
+	assert.Contains(t, events, "Warning DeleteCertificateRequestFailed failed to delete CertificateRequest broken-cert-request that is no longer needed: simulated delete error")
+	assert.Contains(t, events, "Normal CertificateRequestDeleted deleted CertificateRequest stale-cert-request that is no longer needed")
+}
+
 func testhandleDeleteClusterDeployment() *hivev1.ClusterDeployment {
 	cd := testClusterDeploymentAws()
 	now := metav1.Now()
@@ -453,6 +604,12 @@ func testCertificateRequest(cd *hivev1.ClusterDeployment) *certmanv1alpha1.Certi
 	return &cr
 }
 
+// newFakeClientBuilder returns a fake client builder with the owner index of the CertificateRequests registered, as
+// SetupWithManager registers it with the cache of the manager.
+func newFakeClientBuilder() *fake.ClientBuilder {
+	return fake.NewClientBuilder().WithIndex(&certmanv1alpha1.CertificateRequest{}, ownerUIDIndex, clusterDeploymentOwnerUID)
+}
+
 // testObjects returns a testing objects
 func testObjects() []runtime.Object {
 	objects := []runtime.Object{}
@@ -611,7 +768,7 @@ func TestCreateCertificateRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cr := createCertificateRequest(tt.certBundle, tt.secretName, tt.domains, tt.cd, tt.email)
+			cr := createCertificateRequest(tt.certBundle, tt.secretName, tt.domains, tt.cd, []string{tt.email})
 
 			assert.Equal(t, fmt.Sprintf("%s-%s", tt.cd.Name, tt.certBundle), cr.Name)
 			assert.Equal(t, tt.cd.Namespace, cr.Namespace)
@@ -674,22 +831,356 @@ func TestGetDomainsForCertBundle(t *testing.T) {
 				"extra.foo.bar.io",
 			},
 		},
+		{
+			name:   "default_control_plane_cert_with_extra_sans_annotation",
+			cbName: "default-cert",
+			cd: &hivev1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						extraSANsAnnotation: "console.foo.bar.io, *.apps2.foo.bar.io,evil.example.com,,extra.foo.bar.io,notbar.io",
+					},
+				},
+				Spec: hivev1.ClusterDeploymentSpec{
+					ClusterName: "foo",
+					BaseDomain:  "bar.io",
+					ControlPlaneConfig: hivev1.ControlPlaneConfigSpec{
+						ServingCertificates: hivev1.ControlPlaneServingCertificateSpec{
+							Default: "default-cert",
+						},
+					},
+				},
+			},
+			expectDomains: []string{
+				"api.foo.bar.io",
+				"extra.foo.bar.io",
+				"console.foo.bar.io",
+				"*.apps2.foo.bar.io",
+			},
+		},
+		{
+			name:   "extra_sans_annotation_ignored_for_other_bundles",
+			cbName: "other-cert",
+			cd: &hivev1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{extraSANsAnnotation: "console.foo.bar.io"},
+				},
+				Spec: hivev1.ClusterDeploymentSpec{
+					ClusterName: "foo",
+					BaseDomain:  "bar.io",
+					ControlPlaneConfig: hivev1.ControlPlaneConfigSpec{
+						ServingCertificates: hivev1.ControlPlaneServingCertificateSpec{
+							Default: "default-cert",
+						},
+					},
+				},
+			},
+			expectDomains: []string{},
+		},
+		{
+			name:   "wildcard_ingress_cert",
+			cbName: "ingress-cert",
+			cd: &hivev1.ClusterDeployment{
+				Spec: hivev1.ClusterDeploymentSpec{
+					Ingress: []hivev1.ClusterIngress{
+						{Name: "default", Domain: "apps.foo.bar.io", ServingCertificate: "ingress-cert"},
+					},
+				},
+			},
+			expectDomains: []string{"*.apps.foo.bar.io"},
+		},
+		{
+			name:   "non_wildcard_ingress_cert",
+			cbName: "ingress-cert",
+			cd: &hivev1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{nonWildcardIngressAnnotation: "other-cert, ingress-cert"},
+				},
+				Spec: hivev1.ClusterDeploymentSpec{
+					Ingress: []hivev1.ClusterIngress{
+						{Name: "default", Domain: "apps.foo.bar.io", ServingCertificate: "ingress-cert"},
+					},
+				},
+			},
+			expectDomains: []string{"apps.foo.bar.io"},
+		},
+		{
+			name:   "wildcard_ingress_cert_with_apex_domain",
+			cbName: "ingress-cert",
+			cd: &hivev1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{apexIngressAnnotation: "ingress-cert"},
+				},
+				Spec: hivev1.ClusterDeploymentSpec{
+					Ingress: []hivev1.ClusterIngress{
+						{Name: "default", Domain: "apps.foo.bar.io", ServingCertificate: "ingress-cert"},
+					},
+				},
+			},
+			expectDomains: []string{"*.apps.foo.bar.io", "apps.foo.bar.io"},
+		},
+		{
+			name:   "non_wildcard_ingress_cert_with_apex_domain",
+			cbName: "ingress-cert",
+			cd: &hivev1.ClusterDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						apexIngressAnnotation:        "ingress-cert",
+						nonWildcardIngressAnnotation: "ingress-cert",
+					},
+				},
+				Spec: hivev1.ClusterDeploymentSpec{
+					Ingress: []hivev1.ClusterIngress{
+						{Name: "default", Domain: "apps.foo.bar.io", ServingCertificate: "ingress-cert"},
+					},
+				},
+			},
+			expectDomains: []string{"apps.foo.bar.io"},
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			t.Setenv("EXTRA_RECORD", "extra")
 			cb := hivev1.CertificateBundleSpec{
 				Name: tc.cbName,
 			}
 
 			logger := logr.Discard()
-			domains := getDomainsForCertBundle(cb, tc.cd, logger)
+			domains := getDomainsForCertBundle(cb, tc.cd, "extra", logger)
 			assert.ElementsMatch(t, tc.expectDomains, domains)
 		})
 	}
 }
 
+func TestNotificationEmails(t *testing.T) {
+	reconciler := &ClusterDeploymentReconciler{
+		Client: newFakeClientBuilder().WithRuntimeObjects(testObjects()...).Build(),
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    []string
+	}{
+		{
+			name:     "the default notification email address without the annotation",
+			expected: []string{"email@example.com"},
+		},
+		{
+			name:        "the addresses of the annotation",
+			annotations: map[string]string{notificationEmailsAnnotation: "team@example.com, shared@example.com,"},
+			expected:    []string{"team@example.com", "shared@example.com"},
+		},
+		{
+			name:        "the default notification email address with an empty annotation",
+			annotations: map[string]string{notificationEmailsAnnotation: " "},
+			expected:    []string{"email@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-ns", Annotations: tt.annotations}}
+			emails, err := reconciler.notificationEmails(context.TODO(), cd)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, emails)
+
+			cr := createCertificateRequest("bundle", "secret", []string{"api.example.com"}, cd, emails)
+			assert.Equal(t, tt.expected[0], cr.Spec.Email)
+			if len(tt.expected) > 1 {
+				assert.Equal(t, tt.expected[1:], cr.Spec.Emails)
+			} else {
+				assert.Nil(t, cr.Spec.Emails)
+			}
+		})
+	}
+}
+
+func TestDNSProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  string
+		expected    *certmanv1alpha1.Platform
+		expectedErr string
+	}{
+		{
+			name: "no override without the annotation",
+		},
+		{
+			name:       "the platform of the annotation",
+			annotation: `{"cloudflare":{"credentials":{"name":"cloudflare-api-token"}}}`,
+			expected: &certmanv1alpha1.Platform{
+				Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "cloudflare-api-token"}},
+			},
+		},
+		{
+			name:        "invalid JSON",
+			annotation:  `{"cloudflare":`,
+			expectedErr: "invalid certman.managed.openshift.io/dns-provider annotation",
+		},
+		{
+			name:        "an unknown provider",
+			annotation:  `{"route53":{"credentials":{"name":"aws"}}}`,
+			expectedErr: `unknown field "route53"`,
+		},
+		{
+			name:        "several providers",
+			annotation:  `{"gcp":{"credentials":{"name":"gcp"}},"cloudflare":{"credentials":{"name":"cloudflare"}}}`,
+			expectedErr: "expected exactly one DNS provider, got 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-ns"}}
+			if tt.annotation != "" {
+				cd.Annotations = map[string]string{dnsProviderAnnotation: tt.annotation}
+			}
+			platform, err := dnsProvider(cd)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, platform)
+		})
+	}
+}
+
+func TestImportedCertificates(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "no imported certificates without the annotation",
+		},
+		{
+			name:       "the secrets of the annotation",
+			annotation: `{"primary-cert-bundle":"customer-certificate"}`,
+			expected:   map[string]string{"primary-cert-bundle": "customer-certificate"},
+		},
+		{
+			name:        "invalid JSON",
+			annotation:  `["customer-certificate"]`,
+			expectedErr: "invalid certman.managed.openshift.io/imported-certificates annotation",
+		},
+		{
+			name:        "a bundle without a secret",
+			annotation:  `{"primary-cert-bundle":""}`,
+			expectedErr: "no secret for certificate bundle primary-cert-bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-ns"}}
+			if tt.annotation != "" {
+				cd.Annotations = map[string]string{importedCertificatesAnnotation: tt.annotation}
+			}
+			secrets, err := importedCertificates(cd)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, secrets)
+		})
+	}
+}
+
+func TestCustomDomains(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "no custom domains without the annotation",
+		},
+		{
+			name:       "the domains of the annotation",
+			annotation: `{"acme-apps":"apps.acme.io"}`,
+			expected:   map[string]string{"acme-apps": "apps.acme.io"},
+		},
+		{
+			name:        "invalid JSON",
+			annotation:  `["apps.acme.io"]`,
+			expectedErr: "invalid certman.managed.openshift.io/custom-domains annotation",
+		},
+		{
+			name:        "a name that is not a DNS label",
+			annotation:  `{"Acme.Apps":"apps.acme.io"}`,
+			expectedErr: `invalid custom domain name "Acme.Apps"`,
+		},
+		{
+			name:        "a wildcard domain",
+			annotation:  `{"acme-apps":"*.apps.acme.io"}`,
+			expectedErr: "custom domain acme-apps must be a domain without wildcard",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "test-ns"}}
+			if tt.annotation != "" {
+				cd.Annotations = map[string]string{customDomainsAnnotation: tt.annotation}
+			}
+			domains, err := customDomains(cd)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, domains)
+		})
+	}
+}
+
+func TestCustomDomainCertificateRequest(t *testing.T) {
+	cd := testClusterDeploymentAws()
+	cd.Annotations = map[string]string{syncSetDeliveryAnnotation: "true"}
+	dnsProvider := &certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{Credentials: corev1.LocalObjectReference{Name: "cloudflare-api-token"}}}
+
+	cr := customDomainCertificateRequest("acme-apps", "apps.acme.io", cd, []string{"sre@example.com"}, dnsProvider)
+
+	assert.Equal(t, testClusterName+"-customdomain-acme-apps", cr.Name)
+	assert.Equal(t, "acme-apps", cr.Annotations[certmanv1alpha1.CustomDomainAnnotation])
+	assert.Equal(t, []string{"*.apps.acme.io"}, cr.Spec.DnsNames)
+	assert.Equal(t, "apps.acme.io", cr.Spec.ACMEDNSDomain)
+	assert.Equal(t, "acme-apps-custom-domain-certificate", cr.Spec.CertificateSecret.Name)
+	assert.Equal(t, dnsProvider, cr.Spec.DNSProvider)
+	assert.Equal(t, &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress, IngressController: "acme-apps"}, cr.Spec.SyncSet)
+}
+
+func TestSyncSetFor(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{Spec: hivev1.ClusterDeploymentSpec{
+		ControlPlaneConfig: hivev1.ControlPlaneConfigSpec{ServingCertificates: hivev1.ControlPlaneServingCertificateSpec{
+			Default:    "api-bundle",
+			Additional: []hivev1.ControlPlaneAdditionalCertificate{{Name: "extra-api-bundle", Domain: "api.example.com"}},
+		}},
+		Ingress: []hivev1.ClusterIngress{{Name: "default", ServingCertificate: "ingress-bundle"}},
+	}}
+
+	tests := []struct {
+		bundle   string
+		expected *certmanv1alpha1.CertificateSyncSet
+	}{
+		{bundle: "api-bundle", expected: &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetAPIServer}},
+		{bundle: "extra-api-bundle", expected: &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetAPIServer}},
+		{bundle: "ingress-bundle", expected: &certmanv1alpha1.CertificateSyncSet{Target: certmanv1alpha1.SyncSetTargetIngress, IngressController: "default"}},
+		{bundle: "unused-bundle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bundle, func(t *testing.T) {
+			assert.Equal(t, tt.expected, syncSetFor(hivev1.CertificateBundleSpec{Name: tt.bundle}, cd))
+		})
+	}
+}
+
 func TestGetCurrentCertificateRequests(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, certmanv1alpha1.AddToScheme(scheme))
@@ -701,9 +1192,24 @@ func TestGetCurrentCertificateRequests(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-cluster",
 			Namespace: namespace,
+			UID:       testUID,
+		},
+	}
+	otherClusterDeployment := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other-cluster",
+			Namespace: namespace,
+			UID:       types.UID("5678"),
 		},
 	}
 
+	owned := func(cd *hivev1.ClusterDeployment, name string) *certmanv1alpha1.CertificateRequest {
+		cr := testCertificateRequest(cd)
+		cr.Name = name
+		cr.Namespace = namespace
+		return cr
+	}
+
 	tests := []struct {
 		name          string
 		clientBuilder func() client.Client
@@ -718,16 +1224,51 @@ func TestGetCurrentCertificateRequests(t *testing.T) {
 			expectedNames: nil,
 			expectError:   true,
 		},
+		{
+			name: "should_skip_certificaterequests_migrated_from_cert_manager",
+			clientBuilder: func() client.Client {
+				return newFakeClientBuilder().WithScheme(scheme).WithObjects(
+					&certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster-primary-cert-bundle", Namespace: namespace}},
+					&certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{
+						Name:        "web",
+						Namespace:   namespace,
+						Annotations: map[string]string{certmanv1alpha1.MigratedFromAnnotation: namespace + "/web"},
+					}},
+				).Build()
+			},
+			expectedNames: []string{"test-cluster-primary-cert-bundle"},
+		},
+		{
+			name: "should_return_owned_and_ownerless_certificaterequests",
+			clientBuilder: func() client.Client {
+				return newFakeClientBuilder().WithScheme(scheme).WithObjects(
+					owned(clusterDeployment, "test-cluster-ingress-cert-bundle"),
+					&certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster-primary-cert-bundle", Namespace: namespace}},
+				).Build()
+			},
+			expectedNames: []string{"test-cluster-ingress-cert-bundle", "test-cluster-primary-cert-bundle"},
+		},
+		{
+			name: "should_skip_certificaterequests_of_other_clusterdeployments",
+			clientBuilder: func() client.Client {
+				return newFakeClientBuilder().WithScheme(scheme).WithObjects(
+					owned(clusterDeployment, "test-cluster-primary-cert-bundle"),
+					owned(otherClusterDeployment, "other-cluster-primary-cert-bundle"),
+				).Build()
+			},
+			expectedNames: []string{"test-cluster-primary-cert-bundle"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			reconciler := &ClusterDeploymentReconciler{
-				Client: tt.clientBuilder(),
-				Scheme: scheme,
+				Recorder: &record.FakeRecorder{},
+				Client:   tt.clientBuilder(),
+				Scheme:   scheme,
 			}
 
-			crs, err := reconciler.getCurrentCertificateRequests(clusterDeployment, logr.Discard())
+			crs, err := reconciler.getCurrentCertificateRequests(context.TODO(), clusterDeployment, logr.Discard())
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -791,14 +1332,15 @@ func TestHandleDelete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cl := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(tt.objects...).Build()
+			cl := newFakeClientBuilder().WithScheme(scheme).WithRuntimeObjects(tt.objects...).Build()
 
 			r := &ClusterDeploymentReconciler{
-				Client: cl,
-				Scheme: scheme,
+				Recorder: &record.FakeRecorder{},
+				Client:   cl,
+				Scheme:   scheme,
 			}
 
-			err := r.handleDelete(cd, logr.Discard())
+			err := r.handleDelete(context.TODO(), cd, logr.Discard())
 			if (err != nil) != tt.expectErr {
 				t.Errorf("expected error = %v, got %v", tt.expectErr, err)
 			}
@@ -828,3 +1370,122 @@ func (f *failingClient) Scheme() *runtime.Scheme {
 func (f *failingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
 	return fmt.Errorf("simulated list error")
 }
+
+func TestClusterDeploymentChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		update   func(cd *hivev1.ClusterDeployment)
+		expected bool
+	}{
+		{
+			name: "status heartbeat",
+			update: func(cd *hivev1.ClusterDeployment) {
+				cd.Status.Conditions = []hivev1.ClusterDeploymentCondition{{Type: "Hibernating"}}
+			},
+			expected: false,
+		},
+		{
+			name:     "unwatched label",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Labels["hive.openshift.io/version"] = "4.16" },
+			expected: false,
+		},
+		{
+			name:     "resource version",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.ResourceVersion = "2" },
+			expected: false,
+		},
+		{
+			name:     "managed label",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Labels[ClusterDeploymentManagedLabel] = "false" },
+			expected: true,
+		},
+		{
+			name:     "extra SANs annotation",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Annotations = map[string]string{extraSANsAnnotation: "foo"} },
+			expected: true,
+		},
+		{
+			name:     "installed",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Spec.Installed = false },
+			expected: true,
+		},
+		{
+			name: "certificate bundles",
+			update: func(cd *hivev1.ClusterDeployment) {
+				cd.Spec.CertificateBundles = []hivev1.CertificateBundleSpec{{Name: "bundle", Generate: true}}
+			},
+			expected: true,
+		},
+		{
+			name:     "ingress",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Spec.Ingress = []hivev1.ClusterIngress{{Name: "default"}} },
+			expected: true,
+		},
+		{
+			name: "control plane config",
+			update: func(cd *hivev1.ClusterDeployment) {
+				cd.Spec.ControlPlaneConfig.ServingCertificates.Default = "bundle"
+			},
+			expected: true,
+		},
+		{
+			name:     "API URL",
+			update:   func(cd *hivev1.ClusterDeployment) { cd.Status.APIURL = "https://api.example.com:6443" },
+			expected: true,
+		},
+		{
+			name: "deletion",
+			update: func(cd *hivev1.ClusterDeployment) {
+				now := metav1.Now()
+				cd.DeletionTimestamp = &now
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			oldCD := testClusterDeploymentAws()
+			newCD := oldCD.DeepCopy()
+			test.update(newCD)
+
+			assert.Equal(t, test.expected, clusterDeploymentChanged.Update(event.UpdateEvent{ObjectOld: oldCD, ObjectNew: newCD}))
+		})
+	}
+}
+
+func TestClusterDeploymentsForClusterClaim(t *testing.T) {
+	err := hiveapis.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+
+	claimed := testClusterDeploymentAws()
+	claimed.Spec.ClusterPoolRef = &hivev1.ClusterPoolReference{Namespace: testPoolNamespace, PoolName: "pool", ClaimName: "claim"}
+	other := testClusterDeploymentAws()
+	other.Name = "bar"
+	other.Spec.ClusterPoolRef = &hivev1.ClusterPoolReference{Namespace: testPoolNamespace, PoolName: "pool", ClaimName: "other-claim"}
+
+	rcd := &ClusterDeploymentReconciler{
+		Recorder: &record.FakeRecorder{},
+		Client:   newFakeClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(claimed, other).Build(),
+	}
+	claim := &hivev1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim", Namespace: testPoolNamespace},
+		Spec:       hivev1.ClusterClaimSpec{ClusterPoolName: "pool", Namespace: testNamespace},
+	}
+
+	requests := rcd.clusterDeploymentsForClusterClaim(context.TODO(), claim)
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: testClusterName, Namespace: testNamespace}}}, requests)
+}
+
+// helper type to simulate a client failing to delete the object of a given name
+type failingDeleteClient struct {
+	client.Client
+	name string
+}
+
+func (f *failingDeleteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if obj.GetName() == f.name {
+		return fmt.Errorf("simulated delete error")
+	}
+	return f.Client.Delete(ctx, obj, opts...)
+}
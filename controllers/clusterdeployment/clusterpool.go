@@ -0,0 +1,72 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdeployment
+
+import (
+	"context"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// isClaimed returns false for a ClusterDeployment created by a ClusterPool that is waiting in the pool, or whose
+// ClusterClaim has been deleted: the former may never be handed out, and the latter is about to be deprovisioned.
+func (r *ClusterDeploymentReconciler) isClaimed(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, error) {
+	poolRef := cd.Spec.ClusterPoolRef
+	if poolRef == nil {
+		return true, nil
+	}
+	if poolRef.ClaimName == "" {
+		return false, nil
+	}
+
+	// ClusterClaims live in the namespace of their ClusterPool
+	claim := &hivev1.ClusterClaim{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: poolRef.Namespace, Name: poolRef.ClaimName}, claim); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return claim.DeletionTimestamp.IsZero(), nil
+}
+
+// clusterDeploymentsForClusterClaim enqueues the ClusterDeployment assigned to a ClusterClaim, so that its
+// CertificateRequests are deleted as soon as the claim is released.
+func (r *ClusterDeploymentReconciler) clusterDeploymentsForClusterClaim(ctx context.Context, obj client.Object) []reconcile.Request {
+	claim, ok := obj.(*hivev1.ClusterClaim)
+	if !ok || claim.Spec.Namespace == "" {
+		return nil
+	}
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.Client.List(ctx, cdList, client.InNamespace(claim.Spec.Namespace)); err != nil {
+		log.Error(err, "error listing ClusterDeployments of ClusterClaim", "ClusterClaim", claim.Name)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, cd := range cdList.Items {
+		if cd.Spec.ClusterPoolRef != nil && cd.Spec.ClusterPoolRef.Namespace == claim.Namespace && cd.Spec.ClusterPoolRef.ClaimName == claim.Name {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cd.Name, Namespace: cd.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grafanadashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	// dashboardUID keeps the URL of the dashboard stable across its updates.
+	dashboardUID = "certman-operator"
+
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+type dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Editable      bool       `json:"editable"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Time          timeRange  `json:"time"`
+	Refresh       string     `json:"refresh"`
+	Templating    templating `json:"templating"`
+	Panels        []panel    `json:"panels"`
+}
+
+type timeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type templating struct {
+	List []variable `json:"list"`
+}
+
+type variable struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Query string `json:"query"`
+}
+
+type panel struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	GridPos     gridPos    `json:"gridPos"`
+	Datasource  datasource `json:"datasource"`
+	Targets     []target   `json:"targets"`
+}
+
+type gridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// newDashboard returns the JSON of a Grafana dashboard with a panel for each metric definition, two panels a row.
+func newDashboard(definitions []localmetrics.MetricDefinition) ([]byte, error) {
+	d := dashboard{
+		UID:           dashboardUID,
+		Title:         "Certman Operator",
+		Tags:          []string{"certman-operator"},
+		SchemaVersion: 39,
+		Time:          timeRange{From: "now-24h", To: "now"},
+		Refresh:       "1m",
+		Templating: templating{List: []variable{{
+			Name:  "datasource",
+			Label: "Data source",
+			Type:  "datasource",
+			Query: "prometheus",
+		}}},
+		Panels: []panel{},
+	}
+
+	for i, definition := range definitions {
+		d.Panels = append(d.Panels, panel{
+			ID:          i + 1,
+			Type:        "timeseries",
+			Title:       definition.Name,
+			Description: definition.Help,
+			GridPos: gridPos{
+				H: panelHeight,
+				W: panelWidth,
+				X: (i % 2) * panelWidth,
+				Y: (i / 2) * panelHeight,
+			},
+			Datasource: datasource{Type: "prometheus", UID: "${datasource}"},
+			Targets: []target{{
+				RefID:        "A",
+				Expr:         panelQuery(definition),
+				LegendFormat: legendFormat(definition.Labels),
+			}},
+		})
+	}
+
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// panelQuery returns the query charting the metric: the per-second rate of a counter, the 95th percentile of a
+// histogram, the mean of a summary, or the value of a gauge.
+func panelQuery(definition localmetrics.MetricDefinition) string {
+	switch definition.Type {
+	case localmetrics.MetricTypeCounter:
+		if len(definition.Labels) == 0 {
+			return fmt.Sprintf("sum(rate(%s[5m]))", definition.Name)
+		}
+		return fmt.Sprintf("sum by (%s) (rate(%s[5m]))", strings.Join(definition.Labels, ", "), definition.Name)
+	case localmetrics.MetricTypeHistogram:
+		labels := append([]string{"le"}, definition.Labels...)
+		return fmt.Sprintf("histogram_quantile(0.95, sum by (%s) (rate(%s_bucket[5m])))", strings.Join(labels, ", "), definition.Name)
+	case localmetrics.MetricTypeSummary:
+		return fmt.Sprintf("rate(%[1]s_sum[5m]) / rate(%[1]s_count[5m])", definition.Name)
+	}
+	return definition.Name
+}
+
+// legendFormat names the series of a panel after their labels.
+func legendFormat(labels []string) string {
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, "{{"+label+"}}")
+	}
+	return strings.Join(parts, " ")
+}
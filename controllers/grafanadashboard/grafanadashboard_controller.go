@@ -0,0 +1,157 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grafanadashboard
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	controllerName = "controller_grafanadashboard"
+
+	// ConfigMapName is the name of the ConfigMap of the dashboard managed in the operator namespace.
+	ConfigMapName = "certman-operator-dashboard"
+
+	// DashboardKey is the key of the dashboard JSON in the ConfigMap.
+	DashboardKey = "certman-operator.json"
+
+	// dashboardLabel is the label the Grafana sidecar discovers dashboard ConfigMaps by.
+	dashboardLabel = "grafana_dashboard"
+
+	// resyncInterval is how often the ConfigMap is reconciled, which reverts changes made to it by hand.
+	resyncInterval = time.Hour
+)
+
+var log = logf.Log.WithName(controllerName)
+
+var _ reconcile.Reconciler = &GrafanaDashboardReconciler{}
+
+// GrafanaDashboardReconciler creates the ConfigMap of a Grafana dashboard of the operator's metrics when the
+// operator ConfigMap opts in to it, and deletes it when the ConfigMap opts out. The dashboard is built from the
+// metrics the operator registers, so that it follows the metrics across operator versions.
+type GrafanaDashboardReconciler struct {
+	Client client.Client
+	// APIReader reads the dashboard ConfigMap, so that the operator does not cache the ConfigMaps of every namespace.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+}
+
+// Reconcile creates, updates or deletes the dashboard ConfigMap according to the operator ConfigMap.
+func (r *GrafanaDashboardReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", config.OperatorNamespace, "Request.Name", ConfigMapName)
+
+	enabled, err := utils.GetManageDashboard(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "error reading the operator ConfigMap")
+		return reconcile.Result{}, err
+	}
+
+	current := &corev1.ConfigMap{}
+	err = r.APIReader.Get(ctx, types.NamespacedName{Namespace: config.OperatorNamespace, Name: ConfigMapName}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		reqLogger.Error(err, "error looking up the dashboard ConfigMap")
+		return reconcile.Result{}, err
+	}
+	found := err == nil
+
+	if !enabled {
+		if found {
+			reqLogger.Info("deleting the dashboard ConfigMap")
+			if err := r.Client.Delete(ctx, current); err != nil && !errors.IsNotFound(err) {
+				reqLogger.Error(err, "error deleting the dashboard ConfigMap")
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+
+	desired, err := newDashboardConfigMap()
+	if err != nil {
+		reqLogger.Error(err, "error building the dashboard")
+		return reconcile.Result{}, err
+	}
+	if !found {
+		reqLogger.Info("creating the dashboard ConfigMap")
+		if err := r.Client.Create(ctx, desired); err != nil {
+			reqLogger.Error(err, "error creating the dashboard ConfigMap")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	if equality.Semantic.DeepEqual(current.Data, desired.Data) && equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return reconcile.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	reqLogger.Info("updating the dashboard ConfigMap")
+	current.Labels = desired.Labels
+	current.Data = desired.Data
+	if err := r.Client.Update(ctx, current); err != nil {
+		reqLogger.Error(err, "error updating the dashboard ConfigMap")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. Only the operator ConfigMap and the CertmanOperatorConfig
+// are watched, the dashboard ConfigMap is resynced periodically instead.
+func (r *GrafanaDashboardReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("grafanadashboard").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Complete(r)
+}
+
+// newDashboardConfigMap returns the ConfigMap of the dashboard of the operator's metrics.
+func newDashboardConfigMap() (*corev1.ConfigMap, error) {
+	dashboard, err := newDashboard(localmetrics.Definitions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: config.OperatorNamespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": config.OperatorName,
+				dashboardLabel:                 "1",
+			},
+		},
+		Data: map[string]string{DashboardKey: string(dashboard)},
+	}, nil
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grafanadashboard
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+func testConfigMap(manageDashboard string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.ManageDashboard: manageDashboard},
+	}
+}
+
+func testDashboardConfigMap(t *testing.T) *corev1.ConfigMap {
+	cm, err := newDashboardConfigMap()
+	require.NoError(t, err)
+	return cm
+}
+
+func TestReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+
+	drifted := testDashboardConfigMap(t)
+	drifted.Data[DashboardKey] = "{}"
+
+	tests := []struct {
+		name              string
+		objects           []client.Object
+		expectedDashboard bool
+	}{
+		{
+			name:              "creates the dashboard when it is enabled",
+			objects:           []client.Object{testConfigMap("true")},
+			expectedDashboard: true,
+		},
+		{
+			name:              "restores a dashboard changed by hand",
+			objects:           []client.Object{testConfigMap("true"), drifted},
+			expectedDashboard: true,
+		},
+		{
+			name:              "deletes the dashboard when it is disabled",
+			objects:           []client.Object{testConfigMap("false"), testDashboardConfigMap(t)},
+			expectedDashboard: false,
+		},
+		{
+			name:              "deletes the dashboard when the ConfigMap is missing",
+			objects:           []client.Object{testDashboardConfigMap(t)},
+			expectedDashboard: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()
+			r := &GrafanaDashboardReconciler{Client: kubeClient, APIReader: kubeClient, Scheme: s}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: config.OperatorNamespace, Name: config.OperatorName}})
+			require.NoError(t, err)
+
+			cm := &corev1.ConfigMap{}
+			err = kubeClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: ConfigMapName}, cm)
+			if !tt.expectedDashboard {
+				assert.True(t, errors.IsNotFound(err), "expected the dashboard ConfigMap to be deleted, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testDashboardConfigMap(t).Data, cm.Data)
+			assert.Equal(t, "1", cm.Labels[dashboardLabel])
+		})
+	}
+}
+
+func TestNewDashboard(t *testing.T) {
+	definitions := []localmetrics.MetricDefinition{
+		{Name: "counter", Type: localmetrics.MetricTypeCounter},
+		{Name: "counter_vec", Type: localmetrics.MetricTypeCounter, Labels: []string{"operation", "status"}},
+		{Name: "gauge_vec", Type: localmetrics.MetricTypeGauge, Labels: []string{"account"}},
+		{Name: "histogram_vec", Type: localmetrics.MetricTypeHistogram, Labels: []string{"provider"}},
+		{Name: "summary", Type: localmetrics.MetricTypeSummary},
+	}
+
+	data, err := newDashboard(definitions)
+	require.NoError(t, err)
+
+	d := dashboard{}
+	require.NoError(t, json.Unmarshal(data, &d))
+	assert.Equal(t, dashboardUID, d.UID)
+	require.Len(t, d.Panels, len(definitions))
+
+	expressions := []string{}
+	for _, p := range d.Panels {
+		expressions = append(expressions, p.Targets[0].Expr)
+	}
+	assert.Equal(t, []string{
+		"sum(rate(counter[5m]))",
+		"sum by (operation, status) (rate(counter_vec[5m]))",
+		"gauge_vec",
+		"histogram_quantile(0.95, sum by (le, provider) (rate(histogram_vec_bucket[5m])))",
+		"rate(summary_sum[5m]) / rate(summary_count[5m])",
+	}, expressions)
+	assert.Equal(t, "{{operation}} {{status}}", d.Panels[1].Targets[0].LegendFormat)
+
+	// two panels a row
+	assert.Equal(t, gridPos{H: panelHeight, W: panelWidth, X: panelWidth, Y: 0}, d.Panels[1].GridPos)
+	assert.Equal(t, gridPos{H: panelHeight, W: panelWidth, X: 0, Y: panelHeight}, d.Panels[2].GridPos)
+}
+
+func TestNewDashboardConfigMap(t *testing.T) {
+	cm := testDashboardConfigMap(t)
+
+	d := dashboard{}
+	require.NoError(t, json.Unmarshal([]byte(cm.Data[DashboardKey]), &d))
+	titles := []string{}
+	for _, p := range d.Panels {
+		titles = append(titles, p.Title)
+	}
+	// the dashboard follows the metrics the operator registers
+	assert.Len(t, titles, len(localmetrics.MetricsList))
+	assert.Contains(t, titles, "certman_operator_acme_certificates_issued_7d")
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingresscertificaterollout
+
+import (
+	"context"
+	"fmt"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+)
+
+const (
+	controllerName = "controller_ingresscertificaterollout"
+
+	// selectorSyncSetPrefix prefixes the name of the rollout in the name of its SelectorSyncSet, which shares the
+	// cluster scope with the SelectorSyncSets of other operators.
+	selectorSyncSetPrefix = "certman-"
+
+	// serialNumberAnnotation records on the SelectorSyncSet the serial number of the certificate it delivers, so
+	// that a renewal changes the SelectorSyncSet and Hive syncs the renewed secret to every cluster.
+	serialNumberAnnotation = "certman.managed.openshift.io/serial-number"
+
+	// Reasons of the Ready condition of a rollout
+	rolledOutReason                  = "RolledOut"
+	certificateRequestNotFoundReason = "CertificateRequestNotFound"
+	certificateNotIssuedReason       = "CertificateNotIssued"
+)
+
+var log = logf.Log.WithName(controllerName)
+
+var _ reconcile.Reconciler = &IngressCertificateRolloutReconciler{}
+
+// IngressCertificateRolloutReconciler maintains the SelectorSyncSet delivering the certificate of each
+// IngressCertificateRollout to the clusters it selects.
+type IngressCertificateRolloutReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// Reconcile creates or updates the SelectorSyncSet of the IngressCertificateRollout so that it copies the current
+// certificate of the CertificateRequest to the selected clusters and makes it the default certificate of their
+// ingress controller, and records the certificate and the number of selected clusters in its status.
+func (r *IngressCertificateRolloutReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("reconciling IngressCertificateRollout")
+
+	rollout := &certmanv1alpha1.IngressCertificateRollout{}
+	err := r.Client.Get(ctx, request.NamespacedName, rollout)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("cannot find ingresscertificaterollout, assumed deleted")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	status := certmanv1alpha1.IngressCertificateRolloutStatus{
+		SelectorSyncSet: rollout.Status.SelectorSyncSet,
+		SerialNumber:    rollout.Status.SerialNumber,
+		NotAfter:        rollout.Status.NotAfter,
+		Conditions:      rollout.Status.Conditions,
+	}
+
+	clusters, err := r.countClusters(ctx, rollout)
+	if err != nil {
+		reqLogger.Error(err, "error counting the selected ClusterDeployments")
+		return reconcile.Result{}, err
+	}
+	status.Clusters = clusters
+
+	ready, err := r.syncSelectorSyncSet(ctx, rollout, &status)
+	if err != nil {
+		reqLogger.Error(err, "error updating the SelectorSyncSet")
+		return reconcile.Result{}, err
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+
+	if !equality.Semantic.DeepEqual(rollout.Status, status) {
+		rollout.Status = status
+		if err := r.Client.Status().Update(ctx, rollout); err != nil {
+			reqLogger.Error(err, "failed to update IngressCertificateRollout status")
+			return reconcile.Result{}, err
+		}
+		reqLogger.Info("updated ingress certificate rollout", "SerialNumber", status.SerialNumber, "Clusters", status.Clusters)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// syncSelectorSyncSet creates or updates the SelectorSyncSet of the rollout and returns its Ready condition. A
+// missing CertificateRequest or certificate leaves the SelectorSyncSet on the last certificate rolled out, and the
+// rollout is reconciled again once the CertificateRequest changes.
+func (r *IngressCertificateRolloutReconciler) syncSelectorSyncSet(ctx context.Context, rollout *certmanv1alpha1.IngressCertificateRollout, status *certmanv1alpha1.IngressCertificateRolloutStatus) (metav1.Condition, error) {
+	ref := rollout.Spec.CertificateRequest
+	cr := &certmanv1alpha1.CertificateRequest{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cr)
+	if errors.IsNotFound(err) {
+		return notReady(rollout, certificateRequestNotFoundReason, fmt.Sprintf("CertificateRequest %s/%s not found", ref.Namespace, ref.Name)), nil
+	}
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+
+	certificate, err := certificaterequest.GetCertificate(ctx, r.Client, cr)
+	if err != nil {
+		return notReady(rollout, certificateNotIssuedReason, fmt.Sprintf("CertificateRequest %s/%s has no certificate: %v", ref.Namespace, ref.Name, err)), nil
+	}
+	serialNumber := certificate.SerialNumber.String()
+
+	selectorSyncSet := &hivev1.SelectorSyncSet{ObjectMeta: metav1.ObjectMeta{Name: selectorSyncSetPrefix + rollout.Name}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, selectorSyncSet, func() error {
+		metav1.SetMetaDataAnnotation(&selectorSyncSet.ObjectMeta, serialNumberAnnotation, serialNumber)
+		selectorSyncSet.Spec = desiredSelectorSyncSetSpec(rollout, cr)
+		return controllerutil.SetControllerReference(rollout, selectorSyncSet, r.Scheme)
+	})
+	if err != nil {
+		return metav1.Condition{}, err
+	}
+
+	status.SelectorSyncSet = selectorSyncSet.Name
+	status.SerialNumber = serialNumber
+	status.NotAfter = &metav1.Time{Time: certificate.NotAfter}
+	return metav1.Condition{
+		Type:               certmanv1alpha1.IngressCertificateRolloutReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             rolledOutReason,
+		Message:            fmt.Sprintf("certificate %s is delivered by SelectorSyncSet %s", serialNumber, selectorSyncSet.Name),
+		ObservedGeneration: rollout.Generation,
+	}, nil
+}
+
+// desiredSelectorSyncSetSpec returns the spec of the SelectorSyncSet copying the certificate secret of the
+// CertificateRequest to the openshift-ingress namespace of the selected clusters, and patching their ingress
+// controller to serve it.
+func desiredSelectorSyncSetSpec(rollout *certmanv1alpha1.IngressCertificateRollout, cr *certmanv1alpha1.CertificateRequest) hivev1.SelectorSyncSetSpec {
+	secretName := rollout.Spec.SecretName
+	if secretName == "" {
+		secretName = cr.Spec.CertificateSecret.Name
+	}
+	ingressController := rollout.Spec.IngressController
+	if ingressController == "" {
+		ingressController = certificaterequest.DefaultIngressController
+	}
+
+	return hivev1.SelectorSyncSetSpec{
+		SyncSetCommonSpec: hivev1.SyncSetCommonSpec{
+			Secrets: []hivev1.SecretMapping{{
+				SourceRef: hivev1.SecretReference{Name: cr.Spec.CertificateSecret.Name, Namespace: cr.Namespace},
+				TargetRef: hivev1.SecretReference{Name: secretName, Namespace: certificaterequest.IngressSecretNamespace},
+			}},
+			Patches: []hivev1.SyncObjectPatch{certificaterequest.IngressControllerPatch(ingressController, secretName)},
+		},
+		ClusterDeploymentSelector: rollout.Spec.ClusterSelector,
+	}
+}
+
+// countClusters returns the number of ClusterDeployments the rollout selects.
+func (r *IngressCertificateRolloutReconciler) countClusters(ctx context.Context, rollout *certmanv1alpha1.IngressCertificateRollout) (int, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&rollout.Spec.ClusterSelector)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clusterSelector: %w", err)
+	}
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.Client.List(ctx, cdList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return 0, err
+	}
+	return len(cdList.Items), nil
+}
+
+// notReady returns a false Ready condition of the rollout.
+func notReady(rollout *certmanv1alpha1.IngressCertificateRollout, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               certmanv1alpha1.IngressCertificateRolloutReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: rollout.Generation,
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IngressCertificateRolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&certmanv1alpha1.IngressCertificateRollout{}).
+		Owns(&hivev1.SelectorSyncSet{}).
+		Watches(&certmanv1alpha1.CertificateRequest{}, handler.EnqueueRequestsFromMapFunc(r.rolloutsForCertificateRequest)).
+		Watches(&hivev1.ClusterDeployment{}, handler.EnqueueRequestsFromMapFunc(r.allRollouts),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
+
+// rolloutsForCertificateRequest enqueues the IngressCertificateRollouts of a CertificateRequest when it changes,
+// such as when its certificate is renewed.
+func (r *IngressCertificateRolloutReconciler) rolloutsForCertificateRequest(ctx context.Context, obj client.Object) []reconcile.Request {
+	rolloutList := &certmanv1alpha1.IngressCertificateRolloutList{}
+	if err := r.Client.List(ctx, rolloutList); err != nil {
+		log.Error(err, "error listing IngressCertificateRollouts")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, rollout := range rolloutList.Items {
+		ref := rollout.Spec.CertificateRequest
+		if ref.Namespace == obj.GetNamespace() && ref.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: rollout.Name}})
+		}
+	}
+	return requests
+}
+
+// allRollouts enqueues every IngressCertificateRollout when the labels of a ClusterDeployment change, so that the
+// number of clusters each selects stays current.
+func (r *IngressCertificateRolloutReconciler) allRollouts(ctx context.Context, _ client.Object) []reconcile.Request {
+	rolloutList := &certmanv1alpha1.IngressCertificateRolloutList{}
+	if err := r.Client.List(ctx, rolloutList); err != nil {
+		log.Error(err, "error listing IngressCertificateRollouts")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(rolloutList.Items))
+	for _, rollout := range rolloutList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: rollout.Name}})
+	}
+	return requests
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingresscertificaterollout
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	testRolloutName = "wildcard"
+	testNamespace   = "certificates"
+)
+
+// generateCertPEM returns a PEM encoded self-signed certificate with the serial number.
+func generateCertPEM(t *testing.T, serialNumber int64) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serialNumber),
+		Subject:      pkix.Name{CommonName: "*.apps.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newClusterDeployment(namespace string, labels map[string]string) *hivev1.ClusterDeployment {
+	return &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: namespace, Labels: labels}}
+}
+
+func setUpReconciler(t *testing.T, objects ...runtime.Object) *IngressCertificateRolloutReconciler {
+	t.Helper()
+
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+	require.NoError(t, hivev1.AddToScheme(s))
+
+	kubeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).
+		WithStatusSubresource(&certmanv1alpha1.IngressCertificateRollout{}).Build()
+	return &IngressCertificateRolloutReconciler{Client: kubeClient, Scheme: s}
+}
+
+func TestIngressCertificateRolloutReconciler(t *testing.T) {
+	rollout := &certmanv1alpha1.IngressCertificateRollout{
+		ObjectMeta: metav1.ObjectMeta{Name: testRolloutName},
+		Spec: certmanv1alpha1.IngressCertificateRolloutSpec{
+			CertificateRequest: certmanv1alpha1.CertificateRequestReference{Namespace: testNamespace, Name: "wildcard"},
+			ClusterSelector:    metav1.LabelSelector{MatchLabels: map[string]string{"cohort": "blue"}},
+		},
+	}
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: testNamespace},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			CertificateSecret: corev1.ObjectReference{Name: "wildcard-certificate"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard-certificate", Namespace: testNamespace},
+		Data:       map[string][]byte{corev1.TLSCertKey: generateCertPEM(t, 1)},
+	}
+
+	r := setUpReconciler(t, rollout, cr, secret,
+		newClusterDeployment("uhc-blue-1", map[string]string{"cohort": "blue"}),
+		newClusterDeployment("uhc-blue-2", map[string]string{"cohort": "blue"}),
+		newClusterDeployment("uhc-green", map[string]string{"cohort": "green"}),
+	)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: testRolloutName}}
+
+	_, err := r.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+
+	selectorSyncSet := &hivev1.SelectorSyncSet{}
+	require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: "certman-wildcard"}, selectorSyncSet))
+	assert.Equal(t, rollout.Spec.ClusterSelector, selectorSyncSet.Spec.ClusterDeploymentSelector)
+	assert.Equal(t, []hivev1.SecretMapping{{
+		SourceRef: hivev1.SecretReference{Name: "wildcard-certificate", Namespace: testNamespace},
+		TargetRef: hivev1.SecretReference{Name: "wildcard-certificate", Namespace: "openshift-ingress"},
+	}}, selectorSyncSet.Spec.Secrets)
+	assert.Equal(t, []hivev1.SyncObjectPatch{{
+		APIVersion: "operator.openshift.io/v1",
+		Kind:       "IngressController",
+		Name:       "default",
+		Namespace:  "openshift-ingress-operator",
+		Patch:      `{"spec":{"defaultCertificate":{"name":"wildcard-certificate"}}}`,
+		PatchType:  "merge",
+	}}, selectorSyncSet.Spec.Patches)
+	assert.Equal(t, "1", selectorSyncSet.Annotations[serialNumberAnnotation])
+
+	updated := &certmanv1alpha1.IngressCertificateRollout{}
+	require.NoError(t, r.Client.Get(context.TODO(), request.NamespacedName, updated))
+	assert.Equal(t, "certman-wildcard", updated.Status.SelectorSyncSet)
+	assert.Equal(t, "1", updated.Status.SerialNumber)
+	assert.Equal(t, 2, updated.Status.Clusters)
+	assert.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, certmanv1alpha1.IngressCertificateRolloutReady))
+
+	// a renewed certificate is rolled out again
+	secret.Data[corev1.TLSCertKey] = generateCertPEM(t, 2)
+	require.NoError(t, r.Client.Update(context.TODO(), secret))
+
+	_, err = r.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: "certman-wildcard"}, selectorSyncSet))
+	assert.Equal(t, "2", selectorSyncSet.Annotations[serialNumberAnnotation])
+	require.NoError(t, r.Client.Get(context.TODO(), request.NamespacedName, updated))
+	assert.Equal(t, "2", updated.Status.SerialNumber)
+}
+
+func TestIngressCertificateRolloutNotReady(t *testing.T) {
+	tests := []struct {
+		name           string
+		objects        []runtime.Object
+		expectedReason string
+	}{
+		{
+			name:           "the CertificateRequest does not exist",
+			expectedReason: certificateRequestNotFoundReason,
+		},
+		{
+			name: "the certificate has not been issued",
+			objects: []runtime.Object{&certmanv1alpha1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: testNamespace},
+				Spec:       certmanv1alpha1.CertificateRequestSpec{CertificateSecret: corev1.ObjectReference{Name: "wildcard-certificate"}},
+			}},
+			expectedReason: certificateNotIssuedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{&certmanv1alpha1.IngressCertificateRollout{
+				ObjectMeta: metav1.ObjectMeta{Name: testRolloutName},
+				Spec: certmanv1alpha1.IngressCertificateRolloutSpec{
+					CertificateRequest: certmanv1alpha1.CertificateRequestReference{Namespace: testNamespace, Name: "wildcard"},
+				},
+			}}
+			r := setUpReconciler(t, append(objects, tt.objects...)...)
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: testRolloutName}}
+
+			_, err := r.Reconcile(context.TODO(), request)
+			require.NoError(t, err)
+
+			updated := &certmanv1alpha1.IngressCertificateRollout{}
+			require.NoError(t, r.Client.Get(context.TODO(), request.NamespacedName, updated))
+			ready := meta.FindStatusCondition(updated.Status.Conditions, certmanv1alpha1.IngressCertificateRolloutReady)
+			require.NotNil(t, ready)
+			assert.Equal(t, metav1.ConditionFalse, ready.Status)
+			assert.Equal(t, tt.expectedReason, ready.Reason)
+
+			selectorSyncSets := &hivev1.SelectorSyncSetList{}
+			require.NoError(t, r.Client.List(context.TODO(), selectorSyncSets))
+			assert.Empty(t, selectorSyncSets.Items)
+		})
+	}
+}
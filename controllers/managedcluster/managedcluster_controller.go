@@ -0,0 +1,366 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedcluster
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+)
+
+var log = logf.Log.WithName("controller_managedcluster")
+
+const (
+	// outcomeControllerName labels the reconcile outcomes of the controller
+	outcomeControllerName = "managedcluster"
+
+	apiCertificateRequestSuffix     = "-api"
+	ingressCertificateRequestSuffix = "-ingress"
+
+	// consoleURLClaim is the cluster claim of a ManagedCluster holding the URL of its web console, which is served
+	// by the default ingress as console-openshift-console.<ingress domain>.
+	consoleURLClaim   = "consoleurl.cluster.open-cluster-management.io"
+	consoleHostPrefix = "console-openshift-console."
+
+	// Reasons of the Ready condition of a config
+	syncedReason                  = "Synced"
+	managedClusterNotFoundReason  = "ManagedClusterNotFound"
+	managedClusterNotJoinedReason = "ManagedClusterNotJoined"
+	clusterDomainsUnknownReason   = "ClusterDomainsUnknown"
+)
+
+// ManagedClusterGVK is the GroupVersionKind of the Open Cluster Management ManagedClusters, which are read as
+// unstructured objects.
+var ManagedClusterGVK = schema.GroupVersionKind{Group: "cluster.open-cluster-management.io", Version: "v1", Kind: "ManagedCluster"}
+
+var _ reconcile.Reconciler = &ManagedClusterReconciler{}
+
+// ManagedClusterReconciler creates the CertificateRequests of the API and the default ingress of the Open Cluster
+// Management ManagedClusters of ACM and MCE hubs, which have no Hive ClusterDeployments, as configured by their
+// ManagedClusterCertificateConfigs.
+type ManagedClusterReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+	// RateLimiter tunes the rate limiter of the workqueue of the controller.
+	RateLimiter utils.RateLimiterOptions
+}
+
+// Reconcile reads the ManagedClusterCertificateConfig and the ManagedCluster it is named after, and keeps the
+// CertificateRequests of the cluster in line with them.
+func (r *ManagedClusterReconciler) Reconcile(ctx context.Context, request reconcile.Request) (_ reconcile.Result, err error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("reconciling ManagedClusterCertificateConfig")
+
+	outcome := localmetrics.ReconcileUnchanged
+	defer func() {
+		localmetrics.ObserveReconcileOutcome(outcomeControllerName, outcome, err)
+	}()
+
+	mcc := &certmanv1alpha1.ManagedClusterCertificateConfig{}
+	if err := r.Client.Get(ctx, request.NamespacedName, mcc); err != nil {
+		if errors.IsNotFound(err) {
+			// the CertificateRequests of a deleted config are garbage collected
+			reqLogger.Info("cannot find managedclustercertificateconfig, assumed deleted")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, "error looking up the ManagedClusterCertificateConfig")
+		return reconcile.Result{}, err
+	}
+	if mcc.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	ready, err := r.syncCertificateRequests(ctx, reqLogger, mcc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	status := mcc.Status.DeepCopy()
+	if ready.Status == metav1.ConditionTrue {
+		status.CertificateRequests = []string{mcc.Name + apiCertificateRequestSuffix, mcc.Name + ingressCertificateRequestSuffix}
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+	if !equality.Semantic.DeepEqual(mcc.Status, *status) {
+		mcc.Status = *status
+		if err := r.Client.Status().Update(ctx, mcc); err != nil {
+			reqLogger.Error(err, "failed to update ManagedClusterCertificateConfig status")
+			return reconcile.Result{}, err
+		}
+	}
+
+	outcome = localmetrics.ReconcileSynced
+	return reconcile.Result{}, nil
+}
+
+// syncCertificateRequests creates or updates the CertificateRequests of the cluster and returns the Ready condition
+// of the config. The CertificateRequests of a ManagedCluster that is missing or has not joined the hub are left
+// alone, and the config is reconciled again once the ManagedCluster changes.
+func (r *ManagedClusterReconciler) syncCertificateRequests(ctx context.Context, reqLogger logr.Logger, mcc *certmanv1alpha1.ManagedClusterCertificateConfig) (metav1.Condition, error) {
+	mc := &unstructured.Unstructured{}
+	mc.SetGroupVersionKind(ManagedClusterGVK)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: mcc.Name}, mc); err != nil {
+		if errors.IsNotFound(err) {
+			return notReady(mcc, managedClusterNotFoundReason, fmt.Sprintf("ManagedCluster %s not found", mcc.Name)), nil
+		}
+		reqLogger.Error(err, "error looking up the ManagedCluster")
+		return metav1.Condition{}, err
+	}
+	if mc.GetDeletionTimestamp() != nil {
+		return notReady(mcc, managedClusterNotFoundReason, fmt.Sprintf("ManagedCluster %s is being deleted", mcc.Name)), nil
+	}
+	if accepted, _, _ := unstructured.NestedBool(mc.Object, "spec", "hubAcceptsClient"); !accepted {
+		return notReady(mcc, managedClusterNotJoinedReason, fmt.Sprintf("ManagedCluster %s is not accepted by the hub", mcc.Name)), nil
+	}
+
+	emails := mcc.Spec.Emails
+	if len(emails) == 0 {
+		emailAddress, err := utils.GetDefaultNotificationEmailAddress(ctx, r.Client)
+		if err != nil {
+			reqLogger.Error(err, "could not get default notification email")
+			return metav1.Condition{}, err
+		}
+		emails = []string{emailAddress}
+	}
+
+	desiredCRs, err := desiredCertificateRequests(mcc, mc, emails)
+	if err != nil {
+		reqLogger.Info("cannot determine the domains of the cluster", "reason", err.Error())
+		return notReady(mcc, clusterDomainsUnknownReason, err.Error()), nil
+	}
+
+	for i := range desiredCRs {
+		if err := r.syncCertificateRequest(ctx, reqLogger, mcc, &desiredCRs[i]); err != nil {
+			return metav1.Condition{}, err
+		}
+	}
+
+	return metav1.Condition{
+		Type:               certmanv1alpha1.ManagedClusterCertificateConfigReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             syncedReason,
+		Message:            fmt.Sprintf("CertificateRequests of ManagedCluster %s are up to date", mcc.Name),
+		ObservedGeneration: mcc.Generation,
+	}, nil
+}
+
+// syncCertificateRequest creates the desired CertificateRequest, owned by the config, or updates the spec of the
+// existing one.
+func (r *ManagedClusterReconciler) syncCertificateRequest(ctx context.Context, reqLogger logr.Logger, mcc *certmanv1alpha1.ManagedClusterCertificateConfig, desiredCR *certmanv1alpha1.CertificateRequest) error {
+	currentCR := &certmanv1alpha1.CertificateRequest{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: desiredCR.Name, Namespace: desiredCR.Namespace}, currentCR)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			reqLogger.Error(err, "error checking for existing certificaterequest")
+			return err
+		}
+
+		if err := controllerutil.SetControllerReference(mcc, desiredCR, r.Scheme); err != nil {
+			reqLogger.Error(err, "error setting owner reference", "certrequest", desiredCR.Name)
+			return err
+		}
+		reqLogger.Info("creating CertificateRequest resource config", logging.CertRequest, desiredCR.Namespace+"/"+desiredCR.Name)
+		if err := r.Client.Create(ctx, desiredCR); err != nil {
+			reqLogger.Error(err, "error creating certificaterequest")
+			return err
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(currentCR.Spec, desiredCR.Spec) {
+		reqLogger.Info("no update needed for certificaterequest", "certrequest", desiredCR.Name)
+		return nil
+	}
+
+	currentCR.Spec = desiredCR.Spec
+	if err := r.Client.Update(ctx, currentCR); err != nil {
+		reqLogger.Error(err, "error updating certificaterequest", "certrequest", currentCR.Name)
+		return err
+	}
+	return nil
+}
+
+// desiredCertificateRequests returns the CertificateRequests of the API and of the default ingress of the cluster,
+// in the namespace of its config. The domains the config does not set are read from the ManagedCluster.
+func desiredCertificateRequests(mcc *certmanv1alpha1.ManagedClusterCertificateConfig, mc *unstructured.Unstructured, emails []string) ([]certmanv1alpha1.CertificateRequest, error) {
+	apiURL := ""
+	clientConfigs, _, _ := unstructured.NestedSlice(mc.Object, "spec", "managedClusterClientConfigs")
+	if len(clientConfigs) > 0 {
+		if clientConfig, ok := clientConfigs[0].(map[string]interface{}); ok {
+			apiURL, _, _ = unstructured.NestedString(clientConfig, "url")
+		}
+	}
+	apiDomain := mcc.Spec.APIDomain
+	if apiDomain == "" {
+		parsed, err := url.Parse(apiURL)
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("ManagedCluster %s has no API server URL and the config sets no apiDomain", mc.GetName())
+		}
+		apiDomain = parsed.Hostname()
+	}
+
+	consoleURL := clusterClaim(mc, consoleURLClaim)
+	ingressDomain := mcc.Spec.IngressDomain
+	if ingressDomain == "" {
+		parsed, err := url.Parse(consoleURL)
+		if err != nil || !strings.HasPrefix(parsed.Hostname(), consoleHostPrefix) {
+			return nil, fmt.Errorf("ManagedCluster %s has no %s claim and the config sets no ingressDomain", mc.GetName(), consoleURLClaim)
+		}
+		ingressDomain = strings.TrimPrefix(parsed.Hostname(), consoleHostPrefix)
+	}
+
+	// the CertificateRequests are reconciled in standalone mode, whose DNS clients find the zone of the base domain
+	// themselves rather than reading a Hive DNSZone
+	spec := certmanv1alpha1.CertificateRequestSpec{
+		ACMEDNSDomain: mcc.Spec.BaseDomain,
+		Email:         emails[0],
+		Platform:      *mcc.Spec.Platform.DeepCopy(),
+		APIURL:        apiURL,
+		WebConsoleURL: consoleURL,
+	}
+	if len(emails) > 1 {
+		spec.Emails = emails[1:]
+	}
+
+	apiCR := newCertificateRequest(mcc, mcc.Name+apiCertificateRequestSuffix, spec)
+	apiCR.Spec.DnsNames = []string{apiDomain}
+
+	ingressCR := newCertificateRequest(mcc, mcc.Name+ingressCertificateRequestSuffix, spec)
+	ingressCR.Spec.DnsNames = []string{"*." + ingressDomain}
+
+	return []certmanv1alpha1.CertificateRequest{apiCR, ingressCR}, nil
+}
+
+// newCertificateRequest returns a CertificateRequest in the namespace of the config with a copy of spec.
+func newCertificateRequest(mcc *certmanv1alpha1.ManagedClusterCertificateConfig, name string, spec certmanv1alpha1.CertificateRequestSpec) certmanv1alpha1.CertificateRequest {
+	cr := certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: mcc.Namespace,
+		},
+		Spec: *spec.DeepCopy(),
+	}
+	cr.Spec.CertificateSecret = corev1.ObjectReference{
+		Kind:      "secret",
+		Namespace: mcc.Namespace,
+		Name:      name + "-certificate",
+	}
+	return cr
+}
+
+// clusterClaim returns the value of the cluster claim name reported in the status of the ManagedCluster, or an empty
+// string without one.
+func clusterClaim(mc *unstructured.Unstructured, name string) string {
+	claims, _, _ := unstructured.NestedSlice(mc.Object, "status", "clusterClaims")
+	for _, claim := range claims {
+		claim, ok := claim.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if claimName, _, _ := unstructured.NestedString(claim, "name"); claimName == name {
+			value, _, _ := unstructured.NestedString(claim, "value")
+			return value
+		}
+	}
+	return ""
+}
+
+// notReady returns a false Ready condition of the config.
+func notReady(mcc *certmanv1alpha1.ManagedClusterCertificateConfig, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               certmanv1alpha1.ManagedClusterCertificateConfigReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: mcc.Generation,
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ManagedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	mc := &unstructured.Unstructured{}
+	mc.SetGroupVersionKind(ManagedClusterGVK)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("managedcluster").
+		For(&certmanv1alpha1.ManagedClusterCertificateConfig{}).
+		Owns(&certmanv1alpha1.CertificateRequest{}).
+		Watches(mc, handler.EnqueueRequestsFromMapFunc(r.configsForManagedCluster)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.allConfigs), builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(r.allConfigs), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		WithOptions(controller.Options{RateLimiter: utils.NewRateLimiter(r.RateLimiter, utils.DefaultRateLimiterOptions)}).
+		Complete(r)
+}
+
+// configsForManagedCluster enqueues the ManagedClusterCertificateConfigs named after a ManagedCluster when it
+// changes, such as when it joins the hub or reports its claims.
+func (r *ManagedClusterReconciler) configsForManagedCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	configList := &certmanv1alpha1.ManagedClusterCertificateConfigList{}
+	if err := r.Client.List(ctx, configList); err != nil {
+		log.Error(err, "error listing ManagedClusterCertificateConfigs")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, mcc := range configList.Items {
+		if mcc.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mcc.Namespace, Name: mcc.Name}})
+		}
+	}
+	return requests
+}
+
+// allConfigs enqueues every ManagedClusterCertificateConfig when the operator config changes, so that a new default
+// notification email address applies.
+func (r *ManagedClusterReconciler) allConfigs(ctx context.Context, _ client.Object) []reconcile.Request {
+	configList := &certmanv1alpha1.ManagedClusterCertificateConfigList{}
+	if err := r.Client.List(ctx, configList); err != nil {
+		log.Error(err, "error listing ManagedClusterCertificateConfigs")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(configList.Items))
+	for _, mcc := range configList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: mcc.Namespace, Name: mcc.Name}})
+	}
+	return requests
+}
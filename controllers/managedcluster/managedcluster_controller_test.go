@@ -0,0 +1,275 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package managedcluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/eggsampler/acme"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	testClusterName = "spoke-1"
+)
+
+func testManagedCluster(accepted bool) *unstructured.Unstructured {
+	mc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hubAcceptsClient": accepted,
+			"managedClusterClientConfigs": []interface{}{
+				map[string]interface{}{"url": "https://api.spoke-1.example.com:6443"},
+			},
+		},
+		"status": map[string]interface{}{
+			"clusterClaims": []interface{}{
+				map[string]interface{}{"name": "id.openshift.io", "value": "cluster-uuid"},
+				map[string]interface{}{"name": consoleURLClaim, "value": "https://console-openshift-console.apps.spoke-1.example.com"},
+			},
+		},
+	}}
+	mc.SetGroupVersionKind(ManagedClusterGVK)
+	mc.SetName(testClusterName)
+	return mc
+}
+
+func testConfig() *certmanv1alpha1.ManagedClusterCertificateConfig {
+	return &certmanv1alpha1.ManagedClusterCertificateConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: testClusterName, Namespace: testClusterName, UID: "config-uid"},
+		Spec: certmanv1alpha1.ManagedClusterCertificateConfigSpec{
+			BaseDomain: "example.com",
+			Platform: certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{
+				Credentials: corev1.LocalObjectReference{Name: "route53-credentials"},
+				Region:      "us-east-1",
+			}},
+		},
+	}
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+	s.AddKnownTypeWithName(ManagedClusterGVK, &unstructured.Unstructured{})
+	s.AddKnownTypeWithName(ManagedClusterGVK.GroupVersion().WithKind("ManagedClusterList"), &unstructured.UnstructuredList{})
+	return s
+}
+
+func TestManagedClusterReconciler(t *testing.T) {
+	s := testScheme(t)
+	operatorConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.DefaultNotificationEmailAddress: "admin@example.com"},
+	}
+
+	testClient := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(testConfig(), testManagedCluster(true), operatorConfig).
+		WithStatusSubresource(&certmanv1alpha1.ManagedClusterCertificateConfig{}).
+		Build()
+	r := &ManagedClusterReconciler{Client: testClient, Scheme: s}
+
+	// reconciling again is a no-op
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testClusterName, Name: testClusterName}})
+		require.NoError(t, err)
+	}
+
+	apiCR := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testClusterName, Name: testClusterName + "-api"}, apiCR))
+	assert.Equal(t, []string{"api.spoke-1.example.com"}, apiCR.Spec.DnsNames)
+	assert.Equal(t, "example.com", apiCR.Spec.ACMEDNSDomain)
+	assert.Equal(t, "admin@example.com", apiCR.Spec.Email)
+	assert.Equal(t, "https://api.spoke-1.example.com:6443", apiCR.Spec.APIURL)
+	assert.Equal(t, testClusterName+"-api-certificate", apiCR.Spec.CertificateSecret.Name)
+	require.NotNil(t, apiCR.Spec.Platform.AWS)
+	assert.Equal(t, "route53-credentials", apiCR.Spec.Platform.AWS.Credentials.Name)
+	require.Len(t, apiCR.OwnerReferences, 1)
+	assert.Equal(t, "ManagedClusterCertificateConfig", apiCR.OwnerReferences[0].Kind)
+
+	ingressCR := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testClusterName, Name: testClusterName + "-ingress"}, ingressCR))
+	assert.Equal(t, []string{"*.apps.spoke-1.example.com"}, ingressCR.Spec.DnsNames)
+	assert.Equal(t, "https://console-openshift-console.apps.spoke-1.example.com", ingressCR.Spec.WebConsoleURL)
+
+	mcc := &certmanv1alpha1.ManagedClusterCertificateConfig{}
+	require.NoError(t, testClient.Get(context.TODO(), client.ObjectKeyFromObject(testConfig()), mcc))
+	assert.Equal(t, []string{testClusterName + "-api", testClusterName + "-ingress"}, mcc.Status.CertificateRequests)
+	assert.True(t, meta.IsStatusConditionTrue(mcc.Status.Conditions, certmanv1alpha1.ManagedClusterCertificateConfigReady))
+}
+
+func TestManagedClusterReconcilerNotReady(t *testing.T) {
+	withoutClaims := testManagedCluster(true)
+	unstructured.RemoveNestedField(withoutClaims.Object, "status")
+
+	withDomains := testConfig()
+	withDomains.Spec.IngressDomain = "apps.spoke-1.example.com"
+
+	tests := []struct {
+		name           string
+		config         *certmanv1alpha1.ManagedClusterCertificateConfig
+		managedCluster *unstructured.Unstructured
+		expectedReason string
+	}{
+		{
+			name:           "ManagedCluster not found",
+			config:         testConfig(),
+			expectedReason: managedClusterNotFoundReason,
+		},
+		{
+			name:           "ManagedCluster not accepted",
+			config:         testConfig(),
+			managedCluster: testManagedCluster(false),
+			expectedReason: managedClusterNotJoinedReason,
+		},
+		{
+			name:           "ManagedCluster without console URL claim",
+			config:         testConfig(),
+			managedCluster: withoutClaims,
+			expectedReason: clusterDomainsUnknownReason,
+		},
+		{
+			name:           "ingress domain set by the config",
+			config:         withDomains,
+			managedCluster: withoutClaims,
+			expectedReason: syncedReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := testScheme(t)
+			// the config sets its contacts, so no operator ConfigMap is needed
+			test.config.Spec.Emails = []string{"sre@example.com"}
+			objects := []client.Object{test.config}
+			if test.managedCluster != nil {
+				objects = append(objects, test.managedCluster.DeepCopy())
+			}
+
+			testClient := fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).
+				WithStatusSubresource(&certmanv1alpha1.ManagedClusterCertificateConfig{}).
+				Build()
+			r := &ManagedClusterReconciler{Client: testClient, Scheme: s}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(test.config)})
+			require.NoError(t, err)
+
+			mcc := &certmanv1alpha1.ManagedClusterCertificateConfig{}
+			require.NoError(t, testClient.Get(context.TODO(), client.ObjectKeyFromObject(test.config), mcc))
+			ready := meta.FindStatusCondition(mcc.Status.Conditions, certmanv1alpha1.ManagedClusterCertificateConfigReady)
+			require.NotNil(t, ready)
+			assert.Equal(t, test.expectedReason, ready.Reason)
+
+			crList := &certmanv1alpha1.CertificateRequestList{}
+			require.NoError(t, testClient.List(context.TODO(), crList))
+			if test.expectedReason == syncedReason {
+				assert.Len(t, crList.Items, 2)
+			} else {
+				assert.Empty(t, crList.Items)
+			}
+		})
+	}
+}
+
+// zoneRecordingDNSClient is a fake DNS client recording the zone IDs the challenge records are written in.
+type zoneRecordingDNSClient struct {
+	certificaterequest.FakeAWSClient
+	zones *[]string
+}
+
+func (c zoneRecordingDNSClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
+	*c.zones = append(*c.zones, dnsZone)
+	return cTypes.AcmeChallengeSubDomain + "." + domain, nil
+}
+
+func TestManagedClusterCertificateRequestIssuance(t *testing.T) {
+	s := testScheme(t)
+	mccConfig := testConfig()
+	mccConfig.Spec.Emails = []string{"sre@example.com"}
+	testClient := fake.NewClientBuilder().WithScheme(s).
+		WithObjects(mccConfig, testManagedCluster(true)).
+		WithStatusSubresource(&certmanv1alpha1.ManagedClusterCertificateConfig{}, &certmanv1alpha1.CertificateRequest{}).
+		Build()
+
+	r := &ManagedClusterReconciler{Client: testClient, Scheme: s}
+	_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(mccConfig)})
+	require.NoError(t, err)
+
+	// the namespace of the ManagedCluster has no Hive DNSZone, so the DNS client finds the zone of the base domain
+	var zones []string
+	crReconciler := &certificaterequest.CertificateRequestReconciler{
+		Client:   testClient,
+		Scheme:   s,
+		Recorder: record.NewFakeRecorder(100),
+		ClientBuilder: func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platfromSecret certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error) {
+			return zoneRecordingDNSClient{zones: &zones}, nil
+		},
+		IssuerClientBuilder: func(ctx context.Context, kubeClient client.Client, cr *certmanv1alpha1.CertificateRequest) (leclient.LetsEncryptClientInterface, error) {
+			domain := strings.TrimPrefix(cr.Spec.DnsNames[0], "*.")
+			return &leclient.LetsEncryptClient{
+				Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{
+					Available:      true,
+					NewOrderResult: acme.Order{Authorizations: []string{"proto://a.fake.url"}},
+					FetchAuthorizationResult: acme.Authorization{
+						Identifier: acme.Identifier{Type: "dns", Value: domain},
+						Wildcard:   domain != cr.Spec.DnsNames[0],
+						ChallengeMap: map[string]acme.Challenge{
+							string(certmanv1alpha1.DNS01ChallengeType): {Type: string(certmanv1alpha1.DNS01ChallengeType), KeyAuthorization: "key-authorization"},
+						},
+					},
+				}),
+			}, nil
+		},
+		// the operator manages the certificates of ManagedClusters in standalone mode
+		Standalone: true,
+	}
+
+	for _, name := range []string{testClusterName + "-api", testClusterName + "-ingress"} {
+		_, err := crReconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testClusterName, Name: name}})
+		require.NoError(t, err)
+
+		secret := &corev1.Secret{}
+		require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testClusterName, Name: name + "-certificate"}, secret))
+		assert.NotEmpty(t, secret.Data[corev1.TLSCertKey])
+
+		cr := &certmanv1alpha1.CertificateRequest{}
+		require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Namespace: testClusterName, Name: name}, cr))
+		assert.True(t, cr.Status.Issued)
+	}
+	assert.Equal(t, []string{"", ""}, zones)
+}
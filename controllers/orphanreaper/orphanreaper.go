@@ -0,0 +1,193 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanreaper
+
+import (
+	"context"
+	"time"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	clusterDeploymentType = "ClusterDeployment"
+
+	// DefaultInterval is how often the CertificateRequests are checked for a missing ClusterDeployment.
+	DefaultInterval = time.Hour
+)
+
+var log = logf.Log.WithName("orphanreaper")
+
+var _ manager.LeaderElectionRunnable = &Reaper{}
+
+// Reaper periodically deletes the CertificateRequests whose owning ClusterDeployment no longer exists, as happens
+// when a ClusterDeployment is force-deleted with its finalizers stripped. Deleting a CertificateRequest runs its
+// finalizer, which cleans up its DNS records, revokes its certificate if configured to and deletes its secrets.
+// The Reaper also prunes the metric series of the ClusterDeployments and CertificateRequests that no longer exist.
+type Reaper struct {
+	Client client.Client
+	// APIReader confirms that a ClusterDeployment is gone before its CertificateRequests are deleted, since the
+	// cache may lag behind the API server.
+	APIReader client.Reader
+	// Scope restricts the CertificateRequests the reaper considers. A nil Scope considers all of them.
+	Scope *utils.Scope
+	// Interval is how often the CertificateRequests are checked. Zero uses DefaultInterval.
+	Interval time.Duration
+}
+
+// Start reaps orphaned CertificateRequests every Interval until ctx is done.
+func (r *Reaper) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Reap(ctx); err != nil {
+			log.Error(err, "failed to reap orphaned CertificateRequests")
+		}
+		if err := r.PruneMetrics(ctx); err != nil {
+			log.Error(err, "failed to prune the metrics of deleted ClusterDeployments")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection keeps replicas that are not the leader from deleting CertificateRequests.
+func (r *Reaper) NeedLeaderElection() bool {
+	return true
+}
+
+// Reap deletes every CertificateRequest whose owning ClusterDeployment no longer exists. A failure to check or delete
+// one CertificateRequest does not keep the others from being reaped.
+func (r *Reaper) Reap(ctx context.Context) error {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList); err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		if !cr.DeletionTimestamp.IsZero() || !r.Scope.MatchesNamespace(cr.Namespace) {
+			continue
+		}
+
+		owner := clusterDeploymentOwner(cr)
+		if owner == nil {
+			continue
+		}
+
+		orphaned, err := r.isOrphaned(ctx, cr.Namespace, owner)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !orphaned {
+			continue
+		}
+
+		log.Info("deleting orphaned certificaterequest", "Namespace", cr.Namespace, "Name", cr.Name, "ClusterDeployment", owner.Name)
+		if err := r.Client.Delete(ctx, cr); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return kerrors.NewAggregate(errs)
+}
+
+// PruneMetrics deletes the metric series of the ClusterDeployments and CertificateRequests that no longer exist, or
+// have left the Scope. The series are deleted when the deletion is reconciled, but the operator never sees some
+// deletions, such as those of force-deleted ClusterDeployments.
+func (r *Reaper) PruneMetrics(ctx context.Context) error {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.Client.List(ctx, cdList); err != nil {
+		return err
+	}
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList); err != nil {
+		return err
+	}
+
+	cds := map[types.NamespacedName]bool{}
+	for i := range cdList.Items {
+		cd := &cdList.Items[i]
+		if cd.DeletionTimestamp.IsZero() && r.Scope.Matches(cd) {
+			cds[types.NamespacedName{Namespace: cd.Namespace, Name: cd.Name}] = true
+		}
+	}
+	crs := map[types.NamespacedName]bool{}
+	for _, cr := range crList.Items {
+		crs[types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}] = true
+	}
+
+	localmetrics.PruneClusterMetrics(func(namespace, name string) bool {
+		return cds[types.NamespacedName{Namespace: namespace, Name: name}]
+	}, func(namespace, name string) bool {
+		return crs[types.NamespacedName{Namespace: namespace, Name: name}]
+	})
+	return nil
+}
+
+// isOrphaned returns true if the ClusterDeployment referred to by owner no longer exists, or has been replaced by
+// another one of the same name.
+func (r *Reaper) isOrphaned(ctx context.Context, namespace string, owner *metav1.OwnerReference) (bool, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: owner.Name}
+
+	cd := &hivev1.ClusterDeployment{}
+	err := r.Client.Get(ctx, key, cd)
+	if err == nil {
+		return cd.UID != owner.UID, nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	// a ClusterDeployment created a moment ago may not be cached yet
+	err = r.APIReader.Get(ctx, key, cd)
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return cd.UID != owner.UID, nil
+}
+
+// clusterDeploymentOwner returns the owner reference of the ClusterDeployment of the CertificateRequest, or nil if it
+// has none. CertificateRequests that lost their owner references are never reaped, since there is no telling which
+// ClusterDeployment they belonged to.
+func clusterDeploymentOwner(cr *certmanv1alpha1.CertificateRequest) *metav1.OwnerReference {
+	for i, o := range cr.OwnerReferences {
+		if o.Kind == clusterDeploymentType {
+			return &cr.OwnerReferences[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orphanreaper
+
+import (
+	"context"
+	"testing"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const testNamespace = "uhc-production-1234"
+
+func testClusterDeployment(name string, uid types.UID) *hivev1.ClusterDeployment {
+	return &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, UID: uid},
+	}
+}
+
+func testCertificateRequest(name string, namespace string, owner *hivev1.ClusterDeployment) *certmanv1alpha1.CertificateRequest {
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	if owner != nil {
+		cr.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: hivev1.SchemeGroupVersion.String(),
+			Kind:       clusterDeploymentType,
+			Name:       owner.Name,
+			UID:        owner.UID,
+		}}
+	}
+	return cr
+}
+
+func TestReap(t *testing.T) {
+	require.NoError(t, certmanv1alpha1.AddToScheme(scheme.Scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme.Scheme))
+
+	live := testClusterDeployment("live", "live-uid")
+	deleted := testClusterDeployment("deleted", "deleted-uid")
+	replaced := testClusterDeployment("replaced", "old-uid")
+
+	objects := []runtime.Object{
+		live,
+		testClusterDeployment("replaced", "new-uid"),
+		testCertificateRequest("live-primary-cert-bundle-secret", testNamespace, live),
+		testCertificateRequest("deleted-primary-cert-bundle-secret", testNamespace, deleted),
+		testCertificateRequest("replaced-primary-cert-bundle-secret", testNamespace, replaced),
+		testCertificateRequest("unowned-primary-cert-bundle-secret", testNamespace, nil),
+		testCertificateRequest("deleted-primary-cert-bundle-secret", "out-of-scope", deleted),
+	}
+
+	scope, err := utils.NewScope("uhc-*", "")
+	require.NoError(t, err)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).Build()
+	r := &Reaper{Client: kubeClient, APIReader: kubeClient, Scope: scope}
+	require.NoError(t, r.Reap(context.TODO()))
+
+	remaining := &certmanv1alpha1.CertificateRequestList{}
+	require.NoError(t, kubeClient.List(context.TODO(), remaining))
+
+	names := []string{}
+	for _, cr := range remaining.Items {
+		names = append(names, cr.Namespace+"/"+cr.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		testNamespace + "/live-primary-cert-bundle-secret",
+		testNamespace + "/unowned-primary-cert-bundle-secret",
+		"out-of-scope/deleted-primary-cert-bundle-secret",
+	}, names)
+}
+
+func TestPruneMetrics(t *testing.T) {
+	require.NoError(t, certmanv1alpha1.AddToScheme(scheme.Scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme.Scheme))
+	localmetrics.MetricLimitedSupportCluster.Reset()
+	localmetrics.MetricHibernatingCluster.Reset()
+
+	live := testClusterDeployment("live", "live-uid")
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(live).Build()
+
+	for _, name := range []string{"live", "deleted"} {
+		localmetrics.ClusterInFullSupport(name, testNamespace)
+		localmetrics.SetClusterHibernating(name, testNamespace, false)
+	}
+
+	r := &Reaper{Client: kubeClient, APIReader: kubeClient}
+	require.NoError(t, r.PruneMetrics(context.TODO()))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(localmetrics.MetricLimitedSupportCluster))
+	assert.Equal(t, 1, testutil.CollectAndCount(localmetrics.MetricHibernatingCluster))
+}
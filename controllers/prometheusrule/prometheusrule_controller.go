@@ -0,0 +1,198 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheusrule
+
+import (
+	"context"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+const (
+	controllerName = "controller_prometheusrule"
+
+	// RuleName is the name of the PrometheusRule managed in the operator namespace.
+	RuleName = "certman-operator-alerts"
+
+	// resyncInterval is how often the PrometheusRule is reconciled, which reverts changes made to it by hand.
+	resyncInterval = time.Hour
+)
+
+var log = logf.Log.WithName(controllerName)
+
+var _ reconcile.Reconciler = &PrometheusRuleReconciler{}
+
+// PrometheusRuleReconciler creates the PrometheusRule of the alerts on the operator's metrics when the operator
+// ConfigMap opts in to them, and deletes it when the ConfigMap opts out.
+type PrometheusRuleReconciler struct {
+	Client client.Client
+	// APIReader reads the PrometheusRule, so that the operator does not cache the PrometheusRules of every namespace.
+	APIReader client.Reader
+	Scheme    *runtime.Scheme
+}
+
+// Reconcile creates, updates or deletes the PrometheusRule according to the operator ConfigMap. Nothing is done if
+// the PrometheusRule CRD is not installed.
+func (r *PrometheusRuleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", config.OperatorNamespace, "Request.Name", RuleName)
+
+	enabled, err := utils.GetManageAlerts(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "error reading the operator ConfigMap")
+		return reconcile.Result{}, err
+	}
+
+	current := &monitoringv1.PrometheusRule{}
+	err = r.APIReader.Get(ctx, types.NamespacedName{Namespace: config.OperatorNamespace, Name: RuleName}, current)
+	if meta.IsNoMatchError(err) {
+		reqLogger.Info("PrometheusRule CRD is not installed, skipping alerts")
+		return reconcile.Result{}, nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		reqLogger.Error(err, "error looking up the PrometheusRule")
+		return reconcile.Result{}, err
+	}
+	found := err == nil
+
+	if !enabled {
+		if found {
+			reqLogger.Info("deleting the PrometheusRule")
+			if err := r.Client.Delete(ctx, current); err != nil && !errors.IsNotFound(err) {
+				reqLogger.Error(err, "error deleting the PrometheusRule")
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+
+	desired := newPrometheusRule()
+	if !found {
+		reqLogger.Info("creating the PrometheusRule")
+		if err := r.Client.Create(ctx, desired); err != nil {
+			reqLogger.Error(err, "error creating the PrometheusRule")
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec, desired.Spec) && equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return reconcile.Result{RequeueAfter: resyncInterval}, nil
+	}
+
+	reqLogger.Info("updating the PrometheusRule")
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	if err := r.Client.Update(ctx, current); err != nil {
+		reqLogger.Error(err, "error updating the PrometheusRule")
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. Only the operator ConfigMap and the CertmanOperatorConfig
+// are watched, the PrometheusRule is resynced periodically instead, so that the controller starts on clusters without
+// the PrometheusRule CRD.
+func (r *PrometheusRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("prometheusrule").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Complete(r)
+}
+
+// newPrometheusRule returns the PrometheusRule of the alerts on the operator's metrics.
+func newPrometheusRule() *monitoringv1.PrometheusRule {
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RuleName,
+			Namespace: config.OperatorNamespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": config.OperatorName},
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{{
+				Name: "certman-operator",
+				Rules: []monitoringv1.Rule{
+					{
+						Alert: "CertmanCertificateExpiringSoon",
+						Expr:  intstr.FromString(`certman_operator_certificate_valid_duration_days < 14`),
+						For:   "1h",
+						Labels: map[string]string{
+							"severity": "warning",
+						},
+						Annotations: map[string]string{
+							"summary":     "Certificate {{ $labels.cn }} expires in less than 14 days.",
+							"description": "The certificate of CertificateRequest {{ $labels.certificaterequest_namespace }}/{{ $labels.certificaterequest_name }} expires in {{ $value }} days and has not been renewed.",
+						},
+					},
+					{
+						Alert: "CertmanIssuanceFailing",
+						Expr:  intstr.FromString(`increase(certman_operator_certificate_requests_failed_total[1h]) > 0`),
+						Labels: map[string]string{
+							"severity": "warning",
+						},
+						Annotations: map[string]string{
+							"summary":     "CertificateRequests exhausted their retry budget.",
+							"description": "{{ $value }} CertificateRequests were marked Failed in the last hour and are no longer retried.",
+						},
+					},
+					{
+						Alert: "CertmanRateLimited",
+						Expr:  intstr.FromString(`certman_operator_issuance_tokens_remaining < 1 or increase(certman_operator_acme_request_errors_total{problem_type="rateLimited"}[1h]) > 0`),
+						For:   "15m",
+						Labels: map[string]string{
+							"severity": "warning",
+						},
+						Annotations: map[string]string{
+							"summary":     "Certificate issuance is rate limited.",
+							"description": "New certificate orders are throttled by the operator or refused by the ACME server with a rateLimited error.",
+						},
+					},
+					{
+						Alert: "CertmanDNSCleanupFailed",
+						Expr:  intstr.FromString(`increase(certman_operator_dns_cleanup_failures_total[1h]) > 0`),
+						Labels: map[string]string{
+							"severity": "warning",
+						},
+						Annotations: map[string]string{
+							"summary":     "DNS-01 challenge records could not be deleted.",
+							"description": "{{ $value }} deletions of _acme-challenge records failed in the last hour, leaving stale records in the DNS zones.",
+						},
+					},
+				},
+			}},
+		},
+	}
+}
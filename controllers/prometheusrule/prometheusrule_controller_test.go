@@ -0,0 +1,114 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheusrule
+
+import (
+	"context"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+func testConfigMap(manageAlerts string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.ManageAlerts: manageAlerts},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, monitoringv1.AddToScheme(s))
+
+	drifted := newPrometheusRule()
+	drifted.Spec.Groups[0].Rules[0].Expr = intstr.FromString("vector(1)")
+
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		expectedRule bool
+	}{
+		{
+			name:         "creates the rule when alerts are enabled",
+			objects:      []client.Object{testConfigMap("true")},
+			expectedRule: true,
+		},
+		{
+			name:         "restores a rule changed by hand",
+			objects:      []client.Object{testConfigMap("true"), drifted},
+			expectedRule: true,
+		},
+		{
+			name:         "deletes the rule when alerts are disabled",
+			objects:      []client.Object{testConfigMap("false"), newPrometheusRule()},
+			expectedRule: false,
+		},
+		{
+			name:         "deletes the rule when the ConfigMap is missing",
+			objects:      []client.Object{newPrometheusRule()},
+			expectedRule: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()
+			r := &PrometheusRuleReconciler{Client: kubeClient, APIReader: kubeClient, Scheme: s}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: config.OperatorNamespace, Name: config.OperatorName}})
+			require.NoError(t, err)
+
+			rule := &monitoringv1.PrometheusRule{}
+			err = kubeClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: RuleName}, rule)
+			if !tt.expectedRule {
+				assert.True(t, errors.IsNotFound(err), "expected the PrometheusRule to be deleted, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, newPrometheusRule().Spec, rule.Spec)
+		})
+	}
+}
+
+func TestNewPrometheusRule(t *testing.T) {
+	alerts := []string{}
+	for _, rule := range newPrometheusRule().Spec.Groups[0].Rules {
+		alerts = append(alerts, rule.Alert)
+	}
+	assert.ElementsMatch(t, []string{
+		"CertmanCertificateExpiringSoon",
+		"CertmanIssuanceFailing",
+		"CertmanRateLimited",
+		"CertmanDNSCleanupFailed",
+	}, alerts)
+}
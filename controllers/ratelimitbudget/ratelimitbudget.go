@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimitbudget
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	// DefaultInterval is how often the rate-limit budget is reported.
+	DefaultInterval = 10 * time.Minute
+
+	// DefaultWeeklyLimit is the number of certificates Let's Encrypt issues per registered domain per week.
+	DefaultWeeklyLimit = 50
+
+	// window is the period the certificate authority counts the certificates of a registered domain over.
+	window = 7 * 24 * time.Hour
+
+	// statusTimeLayout parses the validity times of the status, which are formatted by time.Time.String.
+	statusTimeLayout = "2006-01-02 15:04:05 -0700 MST"
+)
+
+var log = logf.Log.WithName("ratelimitbudget")
+
+var _ manager.LeaderElectionRunnable = &Reporter{}
+
+// Reporter periodically counts the certificates issued over the last week per ACME account and registered domain,
+// from the history of the CertificateRequests, and reports them along with the certificates remaining before the
+// weekly limit of the certificate authority. The counts survive restarts of the operator since they are read from
+// the CertificateRequests, but miss the certificates of the CertificateRequests that have been deleted.
+type Reporter struct {
+	Client client.Client
+	// Interval is how often the budget is reported. Zero uses DefaultInterval.
+	Interval time.Duration
+	// WeeklyLimit is the number of certificates allowed per registered domain per week. Zero uses
+	// DefaultWeeklyLimit.
+	WeeklyLimit int
+}
+
+// Start reports the rate-limit budget every Interval until ctx is done.
+func (r *Reporter) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.Report(ctx, time.Now()); err != nil {
+			log.Error(err, "failed to report the rate-limit budget")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection keeps replicas that are not the leader from reporting the same budget twice.
+func (r *Reporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Report counts the certificates issued in the week before now and sets the rate-limit budget metrics.
+func (r *Reporter) Report(ctx context.Context, now time.Time) error {
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := r.Client.List(ctx, crList); err != nil {
+		return err
+	}
+
+	weeklyLimit := r.WeeklyLimit
+	if weeklyLimit == 0 {
+		weeklyLimit = DefaultWeeklyLimit
+	}
+
+	since := now.Add(-window)
+	issued := map[string]map[string]int{}
+	issuedPerDomain := map[string]int{}
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		count := issuedSince(cr, since)
+		if count == 0 {
+			continue
+		}
+
+		account := certificaterequest.IssuanceAccount(cr)
+		if issued[account] == nil {
+			issued[account] = map[string]int{}
+		}
+		for _, domain := range registeredDomains(cr.Spec.DnsNames) {
+			issued[account][domain] += count
+			issuedPerDomain[domain] += count
+		}
+	}
+
+	remaining := map[string]int{}
+	for domain, count := range issuedPerDomain {
+		remaining[domain] = max(weeklyLimit-count, 0)
+	}
+
+	localmetrics.SetRateLimitBudget(issued, remaining)
+	return nil
+}
+
+// issuedSince returns the number of certificates issued for the CertificateRequest since since. A CertificateRequest
+// without history counts its current certificate, if it was issued since.
+func issuedSince(cr *certmanv1alpha1.CertificateRequest, since time.Time) int {
+	if len(cr.Status.History) == 0 {
+		notBefore, err := time.Parse(statusTimeLayout, cr.Status.NotBefore)
+		if err != nil || notBefore.Before(since) {
+			return 0
+		}
+		return 1
+	}
+
+	count := 0
+	for _, entry := range cr.Status.History {
+		if entry.Action != certmanv1alpha1.CertificateIssued && entry.Action != certmanv1alpha1.CertificateRenewed {
+			continue
+		}
+		if !entry.Time.Time.Before(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// registeredDomains returns the distinct registered domains of the DNS names, which are the names directly below a
+// public suffix. Names that are not below a public suffix are ignored.
+func registeredDomains(dnsNames []string) []string {
+	domains := []string{}
+	seen := map[string]bool{}
+	for _, dnsName := range dnsNames {
+		domain, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimPrefix(strings.TrimSuffix(dnsName, "."), "*."))
+		if err != nil || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimitbudget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+var now = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testCertificateRequest(name string, dnsNames []string, issuer string, issuedDaysAgo ...int) *certmanv1alpha1.CertificateRequest {
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "uhc-production-1234"},
+		Spec:       certmanv1alpha1.CertificateRequestSpec{DnsNames: dnsNames},
+	}
+	if issuer != "" {
+		cr.Spec.IssuerRef = &corev1.LocalObjectReference{Name: issuer}
+	}
+	for i, days := range issuedDaysAgo {
+		action := certmanv1alpha1.CertificateRenewed
+		if i == 0 {
+			action = certmanv1alpha1.CertificateIssued
+		}
+		cr.Status.History = append(cr.Status.History, certmanv1alpha1.CertificateHistoryEntry{
+			Action: action,
+			Time:   metav1.NewTime(now.AddDate(0, 0, -days)),
+		})
+	}
+	return cr
+}
+
+func TestReport(t *testing.T) {
+	require.NoError(t, certmanv1alpha1.AddToScheme(scheme.Scheme))
+
+	revoked := testCertificateRequest("revoked", []string{"api.revoked.example.com"}, "", 1)
+	revoked.Status.History = append(revoked.Status.History, certmanv1alpha1.CertificateHistoryEntry{
+		Action: certmanv1alpha1.CertificateRevoked,
+		Time:   metav1.NewTime(now),
+	})
+
+	withoutHistory := testCertificateRequest("without-history", []string{"api.old.example.org"}, "")
+	withoutHistory.Status.NotBefore = now.Add(-time.Hour).String()
+
+	objects := []runtime.Object{
+		testCertificateRequest("renewed", []string{"api.one.example.com", "*.apps.one.example.com"}, "", 20, 2),
+		testCertificateRequest("expired-history", []string{"api.two.example.com"}, "", 30, 10),
+		testCertificateRequest("other-account", []string{"api.three.example.com"}, "staging", 3),
+		testCertificateRequest("other-domain", []string{"api.example.net"}, "", 0),
+		revoked,
+		withoutHistory,
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).Build()
+
+	r := &Reporter{Client: c}
+	require.NoError(t, r.Report(context.TODO(), now))
+
+	issued := localmetrics.MetricACMECertificatesIssued7d
+	assert.Equal(t, 2.0, testutil.ToFloat64(issued.WithLabelValues("default", "example.com")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(issued.WithLabelValues("certissuer/staging", "example.com")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(issued.WithLabelValues("default", "example.net")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(issued.WithLabelValues("default", "example.org")))
+	assert.Equal(t, 4, testutil.CollectAndCount(issued))
+
+	remaining := localmetrics.MetricACMERateLimitRemaining
+	assert.Equal(t, 47.0, testutil.ToFloat64(remaining.WithLabelValues("example.com")))
+	assert.Equal(t, 49.0, testutil.ToFloat64(remaining.WithLabelValues("example.net")))
+	assert.Equal(t, 3, testutil.CollectAndCount(remaining))
+
+	// the series of the domains without certificates in the last week are dropped
+	require.NoError(t, c.Delete(context.TODO(), withoutHistory))
+	r.WeeklyLimit = 2
+	require.NoError(t, r.Report(context.TODO(), now))
+	assert.Equal(t, 3, testutil.CollectAndCount(issued))
+	assert.Equal(t, 0.0, testutil.ToFloat64(remaining.WithLabelValues("example.com")))
+	assert.Equal(t, 2, testutil.CollectAndCount(remaining))
+}
+
+func TestRegisteredDomains(t *testing.T) {
+	tests := []struct {
+		name     string
+		dnsNames []string
+		expected []string
+	}{
+		{
+			name:     "names of a domain",
+			dnsNames: []string{"api.cluster.example.com", "*.apps.cluster.example.com", "example.com."},
+			expected: []string{"example.com"},
+		},
+		{
+			name:     "names of several domains",
+			dnsNames: []string{"api.example.co.uk", "api.example.com"},
+			expected: []string{"example.co.uk", "example.com"},
+		},
+		{
+			name:     "public suffix",
+			dnsNames: []string{"co.uk"},
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, registeredDomains(test.dnsNames))
+		})
+	}
+}
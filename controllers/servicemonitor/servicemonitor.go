@@ -0,0 +1,253 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicemonitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/pkg/securemetrics"
+)
+
+const (
+	// ServiceMonitorName is the name of the ServiceMonitor managed in the operator namespace.
+	ServiceMonitorName = "certman-operator"
+
+	// SecureServiceName is the name of the Service of the metrics served over TLS.
+	SecureServiceName = "certman-operator-metrics"
+
+	// ServingCertSecretName is the name of the secret the OpenShift service CA issues the serving certificate of
+	// SecureServiceName in.
+	ServingCertSecretName = "certman-operator-metrics-tls"
+
+	// DefaultInterval is how often the Service and ServiceMonitor are reconciled, which reverts changes made to them
+	// by hand.
+	DefaultInterval = time.Hour
+
+	// servingCertAnnotation asks the OpenShift service CA for a serving certificate of the Service.
+	servingCertAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+
+	// plainServiceName and plainPortName are those of the Service created by operator-custom-metrics.
+	plainServiceName = "certman-operator"
+	plainPortName    = "metrics"
+	securePortName   = "https"
+
+	metricsPath    = "/metrics"
+	scrapeInterval = monitoringv1.Duration("30s")
+
+	// serviceAccountTokenFile and serviceCAFile are the paths of the token and the service CA bundle in the pods of
+	// the OpenShift cluster monitoring Prometheus.
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceCAFile           = "/etc/prometheus/configmaps/serving-certs-ca-bundle/service-ca.crt"
+)
+
+var log = logf.Log.WithName("servicemonitor")
+
+var _ manager.LeaderElectionRunnable = &Syncer{}
+
+// Syncer periodically creates or updates the Service of the metrics served over TLS and the ServiceMonitor that
+// scrapes the operator's metrics, so that neither depends on manifests deployed alongside the operator.
+type Syncer struct {
+	Client client.Client
+	// APIReader reads the Service and the ServiceMonitor, so that the operator does not cache those of every
+	// namespace.
+	APIReader client.Reader
+	// Secure manages the Service of the metrics served over TLS, and has the ServiceMonitor scrape it instead of the
+	// plain HTTP endpoint.
+	Secure bool
+	// ManageServiceMonitor manages the ServiceMonitor. An existing ServiceMonitor is left alone otherwise.
+	ManageServiceMonitor bool
+	// Interval is how often the Service and the ServiceMonitor are reconciled. Zero uses DefaultInterval.
+	Interval time.Duration
+}
+
+// Start syncs the Service and the ServiceMonitor every Interval until ctx is done.
+func (s *Syncer) Start(ctx context.Context) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.Sync(ctx); err != nil {
+			log.Error(err, "failed to sync the metrics Service and ServiceMonitor")
+		}
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection keeps replicas that are not the leader from updating the same objects.
+func (s *Syncer) NeedLeaderElection() bool {
+	return true
+}
+
+// Sync creates or updates the Service of the metrics served over TLS when Secure is set, and the ServiceMonitor when
+// ManageServiceMonitor is set. The ServiceMonitor is skipped on clusters without the ServiceMonitor CRD.
+func (s *Syncer) Sync(ctx context.Context) error {
+	if s.Secure {
+		if err := s.syncService(ctx); err != nil {
+			return err
+		}
+	}
+	if s.ManageServiceMonitor {
+		return s.syncServiceMonitor(ctx)
+	}
+	return nil
+}
+
+func (s *Syncer) syncService(ctx context.Context) error {
+	desired := newSecureService()
+
+	current := &corev1.Service{}
+	err := s.APIReader.Get(ctx, types.NamespacedName{Namespace: config.OperatorNamespace, Name: SecureServiceName}, current)
+	if errors.IsNotFound(err) {
+		log.Info("creating the metrics Service", "Name", SecureServiceName)
+		return s.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(current.Labels, desired.Labels) &&
+		current.Annotations[servingCertAnnotation] == ServingCertSecretName &&
+		equality.Semantic.DeepEqual(current.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(current.Spec.Ports, desired.Spec.Ports) {
+		return nil
+	}
+
+	log.Info("updating the metrics Service", "Name", SecureServiceName)
+	current.Labels = desired.Labels
+	if current.Annotations == nil {
+		current.Annotations = map[string]string{}
+	}
+	current.Annotations[servingCertAnnotation] = ServingCertSecretName
+	current.Spec.Selector = desired.Spec.Selector
+	current.Spec.Ports = desired.Spec.Ports
+	return s.Client.Update(ctx, current)
+}
+
+func (s *Syncer) syncServiceMonitor(ctx context.Context) error {
+	desired := newServiceMonitor(s.Secure)
+
+	current := &monitoringv1.ServiceMonitor{}
+	err := s.APIReader.Get(ctx, types.NamespacedName{Namespace: config.OperatorNamespace, Name: ServiceMonitorName}, current)
+	if meta.IsNoMatchError(err) {
+		log.Info("ServiceMonitor CRD is not installed, skipping the ServiceMonitor")
+		return nil
+	}
+	if errors.IsNotFound(err) {
+		log.Info("creating the ServiceMonitor", "Name", ServiceMonitorName)
+		return s.Client.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(current.Spec, desired.Spec) && equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return nil
+	}
+
+	log.Info("updating the ServiceMonitor", "Name", ServiceMonitorName)
+	current.Labels = desired.Labels
+	current.Spec = desired.Spec
+	return s.Client.Update(ctx, current)
+}
+
+// newSecureService returns the Service of the metrics served over TLS, annotated for the OpenShift service CA to
+// issue its serving certificate.
+func newSecureService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecureServiceName,
+			Namespace: config.OperatorNamespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": config.OperatorName,
+				"name":                         SecureServiceName,
+			},
+			Annotations: map[string]string{servingCertAnnotation: ServingCertSecretName},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"name": config.OperatorName},
+			Ports: []corev1.ServicePort{{
+				Name:       securePortName,
+				Port:       securemetrics.Port,
+				Protocol:   corev1.ProtocolTCP,
+				TargetPort: intstr.FromInt32(securemetrics.Port),
+			}},
+		},
+	}
+}
+
+// newServiceMonitor returns the ServiceMonitor scraping the metrics over TLS with the token of Prometheus if secure
+// is set, or the plain HTTP endpoint of operator-custom-metrics otherwise.
+func newServiceMonitor(secure bool) *monitoringv1.ServiceMonitor {
+	serviceName := plainServiceName
+	endpoint := monitoringv1.Endpoint{
+		Port:     plainPortName,
+		Path:     metricsPath,
+		Scheme:   "http",
+		Interval: scrapeInterval,
+	}
+	if secure {
+		serviceName = SecureServiceName
+		endpoint = monitoringv1.Endpoint{
+			Port:            securePortName,
+			Path:            metricsPath,
+			Scheme:          "https",
+			Interval:        scrapeInterval,
+			BearerTokenFile: serviceAccountTokenFile,
+			TLSConfig: &monitoringv1.TLSConfig{
+				CAFile: serviceCAFile,
+				SafeTLSConfig: monitoringv1.SafeTLSConfig{
+					ServerName: fmt.Sprintf("%s.%s.svc", SecureServiceName, config.OperatorNamespace),
+				},
+			},
+		}
+	}
+
+	return &monitoringv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ServiceMonitorName,
+			Namespace: config.OperatorNamespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": config.OperatorName},
+		},
+		Spec: monitoringv1.ServiceMonitorSpec{
+			Endpoints: []monitoringv1.Endpoint{endpoint},
+			NamespaceSelector: monitoringv1.NamespaceSelector{
+				MatchNames: []string{config.OperatorNamespace},
+			},
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"name": serviceName},
+			},
+		},
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicemonitor
+
+import (
+	"context"
+	"testing"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+func TestSync(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, monitoringv1.AddToScheme(s))
+
+	drifted := newServiceMonitor(true)
+	drifted.Spec.Endpoints[0].Scheme = "http"
+
+	driftedService := newSecureService()
+	driftedService.Annotations = nil
+	driftedService.Spec.Ports[0].Port = 9443
+
+	tests := []struct {
+		name                 string
+		objects              []client.Object
+		secure               bool
+		manageServiceMonitor bool
+		expectedService      bool
+		expectedScheme       string
+	}{
+		{
+			name:                 "creates a ServiceMonitor of the plain endpoint",
+			manageServiceMonitor: true,
+			expectedScheme:       "http",
+		},
+		{
+			name:                 "creates the Service and a ServiceMonitor of the TLS endpoint",
+			secure:               true,
+			manageServiceMonitor: true,
+			expectedService:      true,
+			expectedScheme:       "https",
+		},
+		{
+			name:                 "restores a Service and a ServiceMonitor changed by hand",
+			objects:              []client.Object{driftedService, drifted},
+			secure:               true,
+			manageServiceMonitor: true,
+			expectedService:      true,
+			expectedScheme:       "https",
+		},
+		{
+			name:            "creates only the Service when the ServiceMonitor is not managed",
+			secure:          true,
+			expectedService: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()
+			syncer := &Syncer{Client: kubeClient, APIReader: kubeClient, Secure: tt.secure, ManageServiceMonitor: tt.manageServiceMonitor}
+			require.NoError(t, syncer.Sync(context.TODO()))
+
+			service := &corev1.Service{}
+			err := kubeClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: SecureServiceName}, service)
+			if tt.expectedService {
+				require.NoError(t, err)
+				assert.Equal(t, ServingCertSecretName, service.Annotations[servingCertAnnotation])
+				assert.Equal(t, newSecureService().Spec.Ports, service.Spec.Ports)
+			} else {
+				assert.True(t, errors.IsNotFound(err), "expected no Service, got %v", err)
+			}
+
+			serviceMonitor := &monitoringv1.ServiceMonitor{}
+			err = kubeClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: ServiceMonitorName}, serviceMonitor)
+			if tt.expectedScheme == "" {
+				assert.True(t, errors.IsNotFound(err), "expected no ServiceMonitor, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, newServiceMonitor(tt.secure).Spec, serviceMonitor.Spec)
+			assert.Equal(t, tt.expectedScheme, serviceMonitor.Spec.Endpoints[0].Scheme)
+		})
+	}
+}
+
+func TestNewServiceMonitor(t *testing.T) {
+	plain := newServiceMonitor(false)
+	assert.Equal(t, map[string]string{"name": "certman-operator"}, plain.Spec.Selector.MatchLabels)
+	assert.Equal(t, "metrics", plain.Spec.Endpoints[0].Port)
+	assert.Nil(t, plain.Spec.Endpoints[0].TLSConfig)
+
+	secure := newServiceMonitor(true)
+	assert.Equal(t, map[string]string{"name": SecureServiceName}, secure.Spec.Selector.MatchLabels)
+	assert.Equal(t, "https", secure.Spec.Endpoints[0].Port)
+	assert.Equal(t, serviceAccountTokenFile, secure.Spec.Endpoints[0].BearerTokenFile)
+	require.NotNil(t, secure.Spec.Endpoints[0].TLSConfig)
+	assert.Equal(t, "certman-operator-metrics.certman-operator.svc", secure.Spec.Endpoints[0].TLSConfig.ServerName)
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+)
+
+var log = logf.Log.WithName("controller_standalone")
+
+const (
+	// outcomeControllerName labels the reconcile outcomes of the controller
+	outcomeControllerName = "standalone"
+
+	// clusterConfigName is the name of the cluster-wide configuration resources.
+	clusterConfigName = "cluster"
+
+	apiCertificateRequestName     = "api"
+	ingressCertificateRequestName = "ingress"
+
+	// CredentialsSecretName is the secret in the operator namespace holding the credentials of the DNS zone of the
+	// cluster, in the format of the Hive platform credentials secrets.
+	CredentialsSecretName = "certman-operator-dns-credentials"
+
+	// apiServerCertificateNamespace and ingressCertificateNamespace are where the API server and the default
+	// IngressController read their serving certificates from.
+	apiServerCertificateNamespace = "openshift-config"
+	ingressCertificateNamespace   = "openshift-ingress"
+
+	defaultAPIServerCertificateName = "certman-api-certificate"
+	ingressCertificateName          = "certman-ingress-certificate"
+)
+
+var _ reconcile.Reconciler = &StandaloneReconciler{}
+
+// StandaloneReconciler creates the CertificateRequests of the API and the default ingress of the cluster the operator
+// runs on, for clusters that are not managed by Hive.
+type StandaloneReconciler struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// Reconcile reads the Infrastructure, DNS, Ingress and APIServer configuration of the cluster and keeps its
+// CertificateRequests in line with them.
+func (r *StandaloneReconciler) Reconcile(ctx context.Context, request reconcile.Request) (_ reconcile.Result, err error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("reconciling cluster configuration")
+
+	outcome := localmetrics.ReconcileUnchanged
+	defer func() {
+		localmetrics.ObserveReconcileOutcome(outcomeControllerName, outcome, err)
+	}()
+
+	infra := &configv1.Infrastructure{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: clusterConfigName}, infra); err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("cannot find the cluster Infrastructure")
+			return reconcile.Result{}, nil
+		}
+		reqLogger.Error(err, "error looking up the cluster Infrastructure")
+		return reconcile.Result{}, err
+	}
+
+	dns := &configv1.DNS{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: clusterConfigName}, dns); err != nil {
+		reqLogger.Error(err, "error looking up the cluster DNS")
+		return reconcile.Result{}, err
+	}
+
+	ingress := &configv1.Ingress{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: clusterConfigName}, ingress); err != nil {
+		reqLogger.Error(err, "error looking up the cluster Ingress")
+		return reconcile.Result{}, err
+	}
+
+	apiServer := &configv1.APIServer{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: clusterConfigName}, apiServer); err != nil {
+		reqLogger.Error(err, "error looking up the cluster APIServer")
+		return reconcile.Result{}, err
+	}
+
+	emailAddress, err := utils.GetDefaultNotificationEmailAddress(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "could not get default notification email")
+		return reconcile.Result{}, err
+	}
+
+	desiredCRs, err := desiredCertificateRequests(infra, dns, ingress, apiServer, emailAddress)
+	if err != nil {
+		reqLogger.Error(err, "error building the CertificateRequests of the cluster")
+		return reconcile.Result{}, err
+	}
+
+	for i := range desiredCRs {
+		if err := r.syncCertificateRequest(ctx, reqLogger, infra, &desiredCRs[i]); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	outcome = localmetrics.ReconcileSynced
+	return reconcile.Result{}, nil
+}
+
+// syncCertificateRequest creates the desired CertificateRequest, owned by the Infrastructure, or updates the spec of
+// the existing one.
+func (r *StandaloneReconciler) syncCertificateRequest(ctx context.Context, reqLogger logr.Logger, infra *configv1.Infrastructure, desiredCR *certmanv1alpha1.CertificateRequest) error {
+	currentCR := &certmanv1alpha1.CertificateRequest{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: desiredCR.Name, Namespace: desiredCR.Namespace}, currentCR)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			reqLogger.Error(err, "error checking for existing certificaterequest")
+			return err
+		}
+
+		if err := controllerutil.SetControllerReference(infra, desiredCR, r.Scheme); err != nil {
+			reqLogger.Error(err, "error setting owner reference", "certrequest", desiredCR.Name)
+			return err
+		}
+		reqLogger.Info("creating CertificateRequest resource config", logging.CertRequest, desiredCR.Namespace+"/"+desiredCR.Name)
+		if err := r.Client.Create(ctx, desiredCR); err != nil {
+			reqLogger.Error(err, "error creating certificaterequest")
+			return err
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(currentCR.Spec, desiredCR.Spec) {
+		reqLogger.Info("no update needed for certificaterequest", "certrequest", desiredCR.Name)
+		return nil
+	}
+
+	currentCR.Spec = desiredCR.Spec
+	if err := r.Client.Update(ctx, currentCR); err != nil {
+		reqLogger.Error(err, "error updating certificaterequest", "certrequest", currentCR.Name)
+		return err
+	}
+	return nil
+}
+
+// desiredCertificateRequests returns the CertificateRequests of the API and of the default ingress of the cluster.
+// Their certificate secrets are replicated to where the API server and the default IngressController can use them.
+func desiredCertificateRequests(infra *configv1.Infrastructure, dns *configv1.DNS, ingress *configv1.Ingress, apiServer *configv1.APIServer, emailAddress string) ([]certmanv1alpha1.CertificateRequest, error) {
+	apiURL, err := url.Parse(infra.Status.APIServerURL)
+	if err != nil || apiURL.Hostname() == "" {
+		return nil, fmt.Errorf("cannot parse the API server URL %q of the cluster", infra.Status.APIServerURL)
+	}
+	if ingress.Spec.Domain == "" {
+		return nil, fmt.Errorf("the cluster Ingress has no domain")
+	}
+
+	platform, err := platformFor(infra, dns)
+	if err != nil {
+		return nil, err
+	}
+
+	acmeDNSDomain, err := acmeDNSDomainFor(dns)
+	if err != nil {
+		return nil, err
+	}
+
+	apiHost := apiURL.Hostname()
+	spec := certmanv1alpha1.CertificateRequestSpec{
+		ACMEDNSDomain: acmeDNSDomain,
+		Email:         emailAddress,
+		Platform:      platform,
+		APIURL:        infra.Status.APIServerURL,
+		WebConsoleURL: "https://console-openshift-console." + ingress.Spec.Domain,
+	}
+
+	apiCR := newCertificateRequest(apiCertificateRequestName, spec)
+	apiCR.Spec.DnsNames = []string{apiHost}
+	apiCR.Spec.SecretReplicas = []corev1.SecretReference{{Name: apiServerCertificateName(apiServer, apiHost), Namespace: apiServerCertificateNamespace}}
+
+	ingressCR := newCertificateRequest(ingressCertificateRequestName, spec)
+	ingressCR.Spec.DnsNames = []string{"*." + ingress.Spec.Domain}
+	ingressCR.Spec.SecretReplicas = []corev1.SecretReference{{Name: ingressCertificateName, Namespace: ingressCertificateNamespace}}
+
+	return []certmanv1alpha1.CertificateRequest{apiCR, ingressCR}, nil
+}
+
+// newCertificateRequest returns a CertificateRequest in the operator namespace with a copy of spec.
+func newCertificateRequest(name string, spec certmanv1alpha1.CertificateRequestSpec) certmanv1alpha1.CertificateRequest {
+	cr := certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: config.OperatorNamespace,
+		},
+		Spec: *spec.DeepCopy(),
+	}
+	cr.Spec.CertificateSecret = corev1.ObjectReference{
+		Kind:      "secret",
+		Namespace: config.OperatorNamespace,
+		Name:      name + "-certificate",
+	}
+	return cr
+}
+
+// apiServerCertificateName returns the name of the secret the APIServer serves apiHost with, so that a named
+// certificate configured ahead of time is kept up to date, or a default name otherwise.
+func apiServerCertificateName(apiServer *configv1.APIServer, apiHost string) string {
+	for _, namedCertificate := range apiServer.Spec.ServingCerts.NamedCertificates {
+		if utils.ContainsString(namedCertificate.Names, apiHost) && namedCertificate.ServingCertificate.Name != "" {
+			return namedCertificate.ServingCertificate.Name
+		}
+	}
+	return defaultAPIServerCertificateName
+}
+
+// acmeDNSDomainFor returns the domain of the public DNS zone the cluster domain belongs to, which the installer names
+// <cluster name>.<base domain>.
+func acmeDNSDomainFor(dns *configv1.DNS) (string, error) {
+	_, baseDomain, found := strings.Cut(dns.Spec.BaseDomain, ".")
+	if !found || baseDomain == "" {
+		return "", fmt.Errorf("cannot find the base domain of the cluster domain %q", dns.Spec.BaseDomain)
+	}
+	return baseDomain, nil
+}
+
+// platformFor returns the DNS platform of the cluster, using the credentials in CredentialsSecretName.
+func platformFor(infra *configv1.Infrastructure, dns *configv1.DNS) (certmanv1alpha1.Platform, error) {
+	credentials := corev1.LocalObjectReference{Name: CredentialsSecretName}
+	platformStatus := infra.Status.PlatformStatus
+	if platformStatus == nil {
+		return certmanv1alpha1.Platform{}, fmt.Errorf("the cluster Infrastructure has no platform status")
+	}
+
+	switch platformStatus.Type {
+	case configv1.AWSPlatformType:
+		region := ""
+		if platformStatus.AWS != nil {
+			region = platformStatus.AWS.Region
+		}
+		return certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: credentials, Region: region}}, nil
+	case configv1.GCPPlatformType:
+		return certmanv1alpha1.Platform{GCP: &certmanv1alpha1.GCPPlatformSecrets{Credentials: credentials}}, nil
+	case configv1.AzurePlatformType:
+		resourceGroupName, err := azureResourceGroupName(dns)
+		if err != nil {
+			return certmanv1alpha1.Platform{}, err
+		}
+		return certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{Credentials: credentials, ResourceGroupName: resourceGroupName}}, nil
+	}
+	return certmanv1alpha1.Platform{}, fmt.Errorf("platform %s is not supported in standalone mode", platformStatus.Type)
+}
+
+// azureResourceGroupName returns the resource group of the public DNS zone of an Azure cluster, whose ID has the
+// form /subscriptions/<id>/resourceGroups/<name>/providers/Microsoft.Network/dnszones/<zone>.
+func azureResourceGroupName(dns *configv1.DNS) (string, error) {
+	if dns.Spec.PublicZone != nil {
+		segments := strings.Split(dns.Spec.PublicZone.ID, "/")
+		for i := 0; i+1 < len(segments); i++ {
+			if strings.EqualFold(segments[i], "resourceGroups") {
+				return segments[i+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot find the resource group of the public DNS zone of the cluster")
+}
+
+// clusterConfigPredicate filters events down to the cluster-wide configuration resources.
+var clusterConfigPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetName() == clusterConfigName
+})
+
+// enqueueInfrastructure maps events of the cluster configuration and of the operator ConfigMap to the Infrastructure.
+func enqueueInfrastructure(context.Context, client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: clusterConfigName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StandaloneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("standalone").
+		For(&configv1.Infrastructure{}, builder.WithPredicates(clusterConfigPredicate)).
+		Owns(&certmanv1alpha1.CertificateRequest{}).
+		Watches(&configv1.DNS{}, handler.EnqueueRequestsFromMapFunc(enqueueInfrastructure), builder.WithPredicates(clusterConfigPredicate)).
+		Watches(&configv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(enqueueInfrastructure), builder.WithPredicates(clusterConfigPredicate)).
+		Watches(&configv1.APIServer{}, handler.EnqueueRequestsFromMapFunc(enqueueInfrastructure), builder.WithPredicates(clusterConfigPredicate)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(enqueueInfrastructure), builder.WithPredicates(utils.OperatorConfigMapPredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(enqueueInfrastructure), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Complete(r)
+}
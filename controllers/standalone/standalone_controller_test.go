@@ -0,0 +1,161 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+func testClusterConfig() []runtime.Object {
+	return []runtime.Object{
+		&configv1.Infrastructure{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterConfigName, UID: "infra-uid"},
+			Status: configv1.InfrastructureStatus{
+				APIServerURL: "https://api.mycluster.example.com:6443",
+				PlatformStatus: &configv1.PlatformStatus{
+					Type: configv1.AWSPlatformType,
+					AWS:  &configv1.AWSPlatformStatus{Region: "us-east-1"},
+				},
+			},
+		},
+		&configv1.DNS{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterConfigName},
+			Spec:       configv1.DNSSpec{BaseDomain: "mycluster.example.com"},
+		},
+		&configv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterConfigName},
+			Spec:       configv1.IngressSpec{Domain: "apps.mycluster.example.com"},
+		},
+		&configv1.APIServer{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterConfigName},
+			Spec: configv1.APIServerSpec{
+				ServingCerts: configv1.APIServerServingCerts{
+					NamedCertificates: []configv1.APIServerNamedServingCert{{
+						Names:              []string{"api.mycluster.example.com"},
+						ServingCertificate: configv1.SecretNameReference{Name: "api-serving-cert"},
+					}},
+				},
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+			Data:       map[string]string{cTypes.DefaultNotificationEmailAddress: "admin@example.com"},
+		},
+	}
+}
+
+func TestStandaloneReconciler(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	require.NoError(t, configv1.Install(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+
+	testClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(testClusterConfig()...).Build()
+	r := &StandaloneReconciler{Client: testClient, Scheme: s}
+
+	// reconciling again is a no-op
+	for i := 0; i < 2; i++ {
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: clusterConfigName}})
+		require.NoError(t, err)
+	}
+
+	apiCR := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Name: apiCertificateRequestName, Namespace: config.OperatorNamespace}, apiCR))
+	assert.Equal(t, []string{"api.mycluster.example.com"}, apiCR.Spec.DnsNames)
+	assert.Equal(t, "example.com", apiCR.Spec.ACMEDNSDomain)
+	assert.Equal(t, "admin@example.com", apiCR.Spec.Email)
+	require.NotNil(t, apiCR.Spec.Platform.AWS)
+	assert.Equal(t, "us-east-1", apiCR.Spec.Platform.AWS.Region)
+	assert.Equal(t, CredentialsSecretName, apiCR.Spec.Platform.AWS.Credentials.Name)
+	assert.Equal(t, []corev1.SecretReference{{Name: "api-serving-cert", Namespace: apiServerCertificateNamespace}}, apiCR.Spec.SecretReplicas)
+	require.Len(t, apiCR.OwnerReferences, 1)
+	assert.Equal(t, "Infrastructure", apiCR.OwnerReferences[0].Kind)
+
+	ingressCR := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, testClient.Get(context.TODO(), types.NamespacedName{Name: ingressCertificateRequestName, Namespace: config.OperatorNamespace}, ingressCR))
+	assert.Equal(t, []string{"*.apps.mycluster.example.com"}, ingressCR.Spec.DnsNames)
+	assert.Equal(t, []corev1.SecretReference{{Name: ingressCertificateName, Namespace: ingressCertificateNamespace}}, ingressCR.Spec.SecretReplicas)
+}
+
+func TestPlatformFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		platformStatus *configv1.PlatformStatus
+		publicZone     *configv1.DNSZone
+		expected       certmanv1alpha1.Platform
+		expectError    bool
+	}{
+		{
+			name:           "gcp",
+			platformStatus: &configv1.PlatformStatus{Type: configv1.GCPPlatformType},
+			expected:       certmanv1alpha1.Platform{GCP: &certmanv1alpha1.GCPPlatformSecrets{Credentials: corev1.LocalObjectReference{Name: CredentialsSecretName}}},
+		},
+		{
+			name:           "azure",
+			platformStatus: &configv1.PlatformStatus{Type: configv1.AzurePlatformType},
+			publicZone:     &configv1.DNSZone{ID: "/subscriptions/123/resourceGroups/dns-rg/providers/Microsoft.Network/dnszones/example.com"},
+			expected: certmanv1alpha1.Platform{Azure: &certmanv1alpha1.AzurePlatformSecrets{
+				Credentials:       corev1.LocalObjectReference{Name: CredentialsSecretName},
+				ResourceGroupName: "dns-rg",
+			}},
+		},
+		{
+			name:           "azure without public zone",
+			platformStatus: &configv1.PlatformStatus{Type: configv1.AzurePlatformType},
+			expectError:    true,
+		},
+		{
+			name:           "unsupported platform",
+			platformStatus: &configv1.PlatformStatus{Type: configv1.BareMetalPlatformType},
+			expectError:    true,
+		},
+		{
+			name:        "no platform status",
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			infra := &configv1.Infrastructure{Status: configv1.InfrastructureStatus{PlatformStatus: test.platformStatus}}
+			dns := &configv1.DNS{Spec: configv1.DNSSpec{PublicZone: test.publicZone}}
+
+			platform, err := platformFor(infra, dns)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, platform)
+		})
+	}
+}
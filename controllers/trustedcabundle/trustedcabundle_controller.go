@@ -0,0 +1,106 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedcabundle
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/trust"
+)
+
+const (
+	controllerName = "controller_trustedcabundle"
+
+	// bundleKey is the key of the CA bundle in its ConfigMap.
+	bundleKey = "ca-bundle.crt"
+)
+
+var log = logf.Log.WithName(controllerName)
+
+var _ reconcile.Reconciler = &TrustedCABundleReconciler{}
+
+// TrustedCABundleReconciler trusts the CA bundle of the ConfigMap named by the operator config in the connections to
+// the ACME servers and the DNS providers.
+type TrustedCABundleReconciler struct {
+	Client client.Client
+}
+
+// Reconcile replaces the trusted CA bundle of the operator config with the bundle of its ConfigMap, or removes it
+// if the operator config names none.
+func (r *TrustedCABundleReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", config.OperatorNamespace, "Request.Name", config.OperatorName)
+
+	name, err := utils.GetTrustedCABundle(ctx, r.Client)
+	if err != nil {
+		reqLogger.Error(err, "error reading the operator ConfigMap")
+		return reconcile.Result{}, err
+	}
+
+	var bundle []byte
+	if name != "" {
+		cm := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: config.OperatorNamespace}, cm)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				// the ConfigMap is watched, it is reconciled again once it is created
+				reqLogger.Info("trusted CA bundle ConfigMap not found", "ConfigMap", name)
+				return reconcile.Result{}, nil
+			}
+			reqLogger.Error(err, "error reading the trusted CA bundle", "ConfigMap", name)
+			return reconcile.Result{}, err
+		}
+		bundle = []byte(cm.Data[bundleKey])
+	}
+
+	if err := trust.SetBundle(trust.OperatorConfigBundle, bundle); err != nil {
+		err = fmt.Errorf("invalid %s of ConfigMap %s: %w", bundleKey, name, err)
+		reqLogger.Error(err, "error trusting the CA bundle")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager. The ConfigMaps of the operator namespace, which hold both
+// the operator config and the CA bundle, and the CertmanOperatorConfig are watched.
+func (r *TrustedCABundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("trustedcabundle").
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests), builder.WithPredicates(operatorNamespacePredicate)).
+		Watches(&certmanv1alpha1.CertmanOperatorConfig{}, handler.EnqueueRequestsFromMapFunc(utils.OperatorConfigMapRequests), builder.WithPredicates(utils.OperatorConfigPredicate)).
+		Complete(r)
+}
+
+// operatorNamespacePredicate filters events down to the ConfigMaps of the operator namespace.
+var operatorNamespacePredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetNamespace() == config.OperatorNamespace
+})
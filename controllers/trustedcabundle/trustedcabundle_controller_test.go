@@ -0,0 +1,126 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedcabundle
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/trust"
+)
+
+func generateCAPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "internal-acme-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testConfigMap(bundleName string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: config.OperatorName, Namespace: config.OperatorNamespace},
+		Data:       map[string]string{cTypes.TrustedCABundle: bundleName},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+
+	bundle := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal-ca", Namespace: config.OperatorNamespace},
+		Data:       map[string]string{bundleKey: string(generateCAPEM(t))},
+	}
+	invalid := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid-ca", Namespace: config.OperatorNamespace},
+		Data:       map[string]string{bundleKey: "not a certificate"},
+	}
+
+	tests := []struct {
+		name          string
+		objects       []client.Object
+		expectError   bool
+		expectTrusted bool
+	}{
+		{
+			name:          "trusts the bundle of the ConfigMap",
+			objects:       []client.Object{testConfigMap("internal-ca"), bundle},
+			expectTrusted: true,
+		},
+		{
+			name:    "trusts no bundle when the operator config names none",
+			objects: []client.Object{testConfigMap(""), bundle},
+		},
+		{
+			name:    "waits for the ConfigMap of the bundle",
+			objects: []client.Object{testConfigMap("internal-ca")},
+		},
+		{
+			name:        "rejects a bundle without certificates",
+			objects:     []client.Object{testConfigMap("invalid-ca"), invalid},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, trust.SetBundle(trust.OperatorConfigBundle, nil))
+			t.Cleanup(func() { _ = trust.SetBundle(trust.OperatorConfigBundle, nil) })
+
+			r := &TrustedCABundleReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()}
+
+			_, err := r.Reconcile(context.TODO(), reconcile.Request{})
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectTrusted, trust.Roots() != nil)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+// OperatorConfigPredicate filters events down to the CertmanOperatorConfig the operator reads.
+var OperatorConfigPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetName() == certmanv1alpha1.CertmanOperatorConfigName
+})
+
+// OperatorConfigMapRequests maps events of the CertmanOperatorConfig to the request of the operator ConfigMap, for the
+// controllers reconciling the operator ConfigMap.
+func OperatorConfigMapRequests(ctx context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace}}}
+}
+
+// GetOperatorConfig returns the CertmanOperatorConfig of the operator, or nil if there is none. Clusters without the
+// CertmanOperatorConfig CRD, and clients whose scheme lacks the type, have none.
+func GetOperatorConfig(ctx context.Context, kubeClient client.Client) (*certmanv1alpha1.CertmanOperatorConfig, error) {
+	operatorConfig := &certmanv1alpha1.CertmanOperatorConfig{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: certmanv1alpha1.CertmanOperatorConfigName}, operatorConfig)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return operatorConfig, nil
+}
+
+// operatorConfigData returns the settings of spec under the keys of the operator ConfigMap they replace, so that they
+// are parsed and validated the same way. Unset fields are left out.
+func operatorConfigData(spec *certmanv1alpha1.CertmanOperatorConfigSpec) map[string]string {
+	data := map[string]string{}
+	setString := func(key, value string) {
+		if value != "" {
+			data[key] = value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			data[key] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(key string, value *int32) {
+		if value != nil {
+			data[key] = strconv.Itoa(int(*value))
+		}
+	}
+	setDuration := func(key string, value *metav1.Duration) {
+		if value != nil {
+			data[key] = value.Duration.String()
+		}
+	}
+
+	setString(cTypes.DefaultNotificationEmailAddress, spec.DefaultNotificationEmail)
+	setString(cTypes.ACMEDirectoryURL, spec.ACMEDirectoryURL)
+	setString(cTypes.ExtraRecord, spec.ExtraRecord)
+	setString(cTypes.TrustedCABundle, spec.TrustedCABundle)
+	setString(cTypes.CTLogList, spec.CTLogList)
+	setInt(cTypes.DefaultKeySize, spec.DefaultKeySize)
+	setBool(cTypes.RevokeCertificatesOnDelete, spec.RevokeCertificatesOnDelete)
+	setBool(cTypes.VerifyCertificateTransparency, spec.VerifyCertificateTransparency)
+	setBool(cTypes.VerifyCTInclusion, spec.VerifyCertificateTransparencyInclusion)
+	setBool(cTypes.ManageAlerts, spec.ManageAlerts)
+	setBool(cTypes.ManageDashboard, spec.ManageDashboard)
+	setDuration(cTypes.ReconcileTimeout, spec.ReconcileTimeout)
+	setInt(cTypes.MaxIssuanceAttempts, spec.MaxIssuanceAttempts)
+
+	if renewal := spec.Renewal; renewal != nil {
+		setDuration(cTypes.RenewalCheckInterval, renewal.CheckInterval)
+		setDuration(cTypes.RenewalJitterWindow, renewal.JitterWindow)
+		setString(cTypes.HibernationRenewalPolicy, string(renewal.HibernationPolicy))
+	}
+
+	if rateLimits := spec.RateLimits; rateLimits != nil {
+		setInt(cTypes.MaxOrdersPerHour, rateLimits.MaxOrdersPerHour)
+		setInt(cTypes.MaxOrdersPerWeek, rateLimits.MaxOrdersPerWeek)
+	}
+
+	if notifications := spec.Notifications; notifications != nil {
+		if webhook := notifications.Webhook; webhook != nil {
+			setString(cTypes.NotificationWebhookFormat, webhook.Format)
+			setString(cTypes.NotificationWebhookTemplate, webhook.Template)
+		}
+		if email := notifications.Email; email != nil {
+			setBool(cTypes.EmailNotifications, &email.Enabled)
+			setInt(cTypes.EmailExpiryWarningDays, email.ExpiryWarningDays)
+		}
+		if pagerDuty := notifications.PagerDuty; pagerDuty != nil {
+			setDuration(cTypes.PagerDutyFailureDuration, pagerDuty.FailureDuration)
+			setInt(cTypes.PagerDutyExpiryDays, pagerDuty.ExpiryDays)
+		}
+		setInt(cTypes.ServiceLogFailureThreshold, notifications.ServiceLogFailureThreshold)
+	}
+
+	if dns := spec.DNS; dns != nil {
+		setInt(cTypes.DNSPropagationCheckAttempts, dns.PropagationCheckAttempts)
+		setDuration(cTypes.DNSPropagationCheckInterval, dns.PropagationCheckInterval)
+	}
+
+	return data
+}
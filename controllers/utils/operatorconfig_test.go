@@ -0,0 +1,219 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/throttle"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func newOperatorConfig(spec certmanv1alpha1.CertmanOperatorConfigSpec) *certmanv1alpha1.CertmanOperatorConfig {
+	return &certmanv1alpha1.CertmanOperatorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: certmanv1alpha1.CertmanOperatorConfigName},
+		Spec:       spec,
+	}
+}
+
+func TestGetConfigWithOperatorConfig(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+
+	operatorConfig := newOperatorConfig(certmanv1alpha1.CertmanOperatorConfigSpec{
+		MaxIssuanceAttempts: int32Ptr(3),
+		RateLimits:          &certmanv1alpha1.RateLimitsConfig{MaxOrdersPerWeek: int32Ptr(100)},
+	})
+	configMap := &v1.ConfigMap{
+		ObjectMeta: testConfigMap.ObjectMeta,
+		Data: map[string]string{
+			cTypes.DefaultNotificationEmailAddress: fakeEmailAddress,
+			cTypes.MaxIssuanceAttempts:             "5",
+			cTypes.MaxOrdersPerHour:                "10",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		validate    func(t *testing.T, cm *v1.ConfigMap, err error)
+	}{
+		{
+			name:        "the operator config takes precedence over the configmap",
+			runtimeObjs: []runtime.Object{configMap, operatorConfig},
+			validate: func(t *testing.T, cm *v1.ConfigMap, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{
+					cTypes.DefaultNotificationEmailAddress: fakeEmailAddress,
+					cTypes.MaxIssuanceAttempts:             "3",
+					cTypes.MaxOrdersPerHour:                "10",
+					cTypes.MaxOrdersPerWeek:                "100",
+				}, cm.Data)
+			},
+		},
+		{
+			name:        "the operator config replaces a missing configmap",
+			runtimeObjs: []runtime.Object{operatorConfig},
+			validate: func(t *testing.T, cm *v1.ConfigMap, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{
+					cTypes.MaxIssuanceAttempts: "3",
+					cTypes.MaxOrdersPerWeek:    "100",
+				}, cm.Data)
+			},
+		},
+		{
+			name:        "the configmap is used alone without an operator config",
+			runtimeObjs: []runtime.Object{configMap},
+			validate: func(t *testing.T, cm *v1.ConfigMap, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, configMap.Data, cm.Data)
+			},
+		},
+		{
+			name: "neither is found",
+			validate: func(t *testing.T, cm *v1.ConfigMap, err error) {
+				assert.True(t, errors.IsNotFound(err), "expected a not found error, got %v", err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+			cm, err := getConfig(context.TODO(), fakeClient, testNamespaceName)
+			tt.validate(t, cm, err)
+		})
+	}
+
+	t.Run("getters read the operator config", func(t *testing.T) {
+		fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(configMap, operatorConfig).Build()
+
+		attempts, err := GetMaxIssuanceAttempts(context.TODO(), fakeClient)
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+
+		limits, err := GetIssuanceLimits(context.TODO(), fakeClient)
+		require.NoError(t, err)
+		assert.Equal(t, throttle.Limits{PerHour: 10, PerWeek: 100}, limits)
+	})
+}
+
+func TestGetOperatorConfig(t *testing.T) {
+	t.Run("ignores clients that do not know the type", func(t *testing.T) {
+		operatorConfig, err := GetOperatorConfig(context.TODO(), fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build())
+		assert.NoError(t, err)
+		assert.Nil(t, operatorConfig)
+	})
+
+	t.Run("ignores other names", func(t *testing.T) {
+		s := runtime.NewScheme()
+		require.NoError(t, certmanv1alpha1.AddToScheme(s))
+		other := newOperatorConfig(certmanv1alpha1.CertmanOperatorConfigSpec{})
+		other.Name = "other"
+
+		operatorConfig, err := GetOperatorConfig(context.TODO(), fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(other).Build())
+		assert.NoError(t, err)
+		assert.Nil(t, operatorConfig)
+	})
+}
+
+func TestOperatorConfigData(t *testing.T) {
+	spec := certmanv1alpha1.CertmanOperatorConfigSpec{
+		DefaultNotificationEmail:               "sre@example.com",
+		ACMEDirectoryURL:                       "https://acme.example.com/directory",
+		ExtraRecord:                            "rh-api",
+		DefaultKeySize:                         int32Ptr(4096),
+		RevokeCertificatesOnDelete:             boolPtr(true),
+		VerifyCertificateTransparency:          boolPtr(false),
+		VerifyCertificateTransparencyInclusion: boolPtr(true),
+		CTLogList:                              "ct-logs",
+		ManageAlerts:                           boolPtr(true),
+		ManageDashboard:                        boolPtr(true),
+		ReconcileTimeout:                       &metav1.Duration{Duration: 20 * time.Minute},
+		MaxIssuanceAttempts:                    int32Ptr(0),
+		Renewal: &certmanv1alpha1.RenewalConfig{
+			CheckInterval:     &metav1.Duration{Duration: time.Hour},
+			JitterWindow:      &metav1.Duration{Duration: 72 * time.Hour},
+			HibernationPolicy: certmanv1alpha1.DeferHibernationRenewalPolicy,
+		},
+		RateLimits: &certmanv1alpha1.RateLimitsConfig{MaxOrdersPerHour: int32Ptr(50)},
+		Notifications: &certmanv1alpha1.NotificationsConfig{
+			Webhook: &certmanv1alpha1.WebhookNotificationConfig{Format: "slack"},
+			Email:   &certmanv1alpha1.EmailNotificationConfig{Enabled: true, ExpiryWarningDays: int32Ptr(14)},
+			PagerDuty: &certmanv1alpha1.PagerDutyNotificationConfig{
+				FailureDuration: &metav1.Duration{Duration: 6 * time.Hour},
+				ExpiryDays:      int32Ptr(5),
+			},
+			ServiceLogFailureThreshold: int32Ptr(5),
+		},
+		DNS: &certmanv1alpha1.DNSConfig{
+			PropagationCheckAttempts: int32Ptr(20),
+			PropagationCheckInterval: &metav1.Duration{Duration: 15 * time.Second},
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		cTypes.DefaultNotificationEmailAddress: "sre@example.com",
+		cTypes.ACMEDirectoryURL:                "https://acme.example.com/directory",
+		cTypes.ExtraRecord:                     "rh-api",
+		cTypes.DefaultKeySize:                  "4096",
+		cTypes.RevokeCertificatesOnDelete:      "true",
+		cTypes.VerifyCertificateTransparency:   "false",
+		cTypes.VerifyCTInclusion:               "true",
+		cTypes.CTLogList:                       "ct-logs",
+		cTypes.ManageAlerts:                    "true",
+		cTypes.ManageDashboard:                 "true",
+		cTypes.ReconcileTimeout:                "20m0s",
+		cTypes.MaxIssuanceAttempts:             "0",
+		cTypes.RenewalCheckInterval:            "1h0m0s",
+		cTypes.RenewalJitterWindow:             "72h0m0s",
+		cTypes.HibernationRenewalPolicy:        "Defer",
+		cTypes.MaxOrdersPerHour:                "50",
+		cTypes.NotificationWebhookFormat:       "slack",
+		cTypes.EmailNotifications:              "true",
+		cTypes.EmailExpiryWarningDays:          "14",
+		cTypes.PagerDutyFailureDuration:        "6h0m0s",
+		cTypes.PagerDutyExpiryDays:             "5",
+		cTypes.ServiceLogFailureThreshold:      "5",
+		cTypes.DNSPropagationCheckAttempts:     "20",
+		cTypes.DNSPropagationCheckInterval:     "15s",
+	}, operatorConfigData(&spec))
+
+	assert.Empty(t, operatorConfigData(&certmanv1alpha1.CertmanOperatorConfigSpec{}))
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultRateLimiterOptions are those of the default rate limiter of controller-runtime, which the controllers
+// without defaults of their own use.
+var DefaultRateLimiterOptions = RateLimiterOptions{
+	BaseDelay: 5 * time.Millisecond,
+	MaxDelay:  1000 * time.Second,
+	QPS:       10,
+	Burst:     100,
+}
+
+// RateLimiterOptions tune the rate limiter of the workqueue of a controller, which delays the retries of the
+// reconciles that failed or asked to be requeued. Zero fields keep the defaults of the controller.
+type RateLimiterOptions struct {
+	// BaseDelay is the delay before the first retry of a request, doubled on each of its consecutive retries.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between the retries of a request.
+	MaxDelay time.Duration
+	// QPS is the rate the retries of all the requests together are limited to, once Burst of them were let through.
+	QPS float64
+	// Burst is the number of retries let through at once before QPS applies.
+	Burst int
+}
+
+// NewRateLimiter returns the rate limiter of the options, taking the fields left at zero from defaults. The retries of
+// all the requests together are only limited when QPS is set.
+func NewRateLimiter(o, defaults RateLimiterOptions) workqueue.TypedRateLimiter[reconcile.Request] {
+	if o.BaseDelay == 0 {
+		o.BaseDelay = defaults.BaseDelay
+	}
+	if o.MaxDelay == 0 {
+		o.MaxDelay = defaults.MaxDelay
+	}
+	if o.QPS == 0 {
+		o.QPS = defaults.QPS
+	}
+	if o.Burst == 0 {
+		o.Burst = defaults.Burst
+	}
+
+	perItem := workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](o.BaseDelay, o.MaxDelay)
+	if o.QPS == 0 {
+		return perItem
+	}
+	return workqueue.NewTypedMaxOfRateLimiter(
+		perItem,
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(o.QPS), o.Burst)},
+	)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "uhc-production-1", Name: name}}
+	}
+	defaults := RateLimiterOptions{BaseDelay: time.Second, MaxDelay: 30 * time.Second, Burst: 100}
+
+	t.Run("defaults", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimiterOptions{}, defaults)
+		assert.Equal(t, time.Second, limiter.When(request("a")))
+		assert.Equal(t, 2*time.Second, limiter.When(request("a")))
+		assert.Equal(t, time.Second, limiter.When(request("b")), "the delays are counted per request")
+	})
+
+	t.Run("delays", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimiterOptions{BaseDelay: 10 * time.Second, MaxDelay: 15 * time.Second}, defaults)
+		assert.Equal(t, 10*time.Second, limiter.When(request("a")))
+		assert.Equal(t, 15*time.Second, limiter.When(request("a")), "the delay is capped")
+
+		limiter.Forget(request("a"))
+		assert.Equal(t, 10*time.Second, limiter.When(request("a")))
+	})
+
+	t.Run("overall rate", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimiterOptions{BaseDelay: time.Millisecond, QPS: 0.1, Burst: 1}, defaults)
+		assert.Equal(t, time.Millisecond, limiter.When(request("a")))
+		assert.Greater(t, limiter.When(request("b")), 9*time.Second, "the retries past the burst are limited overall")
+	})
+}
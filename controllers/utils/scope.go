@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Scope restricts the ClusterDeployments an operator instance manages, so that several instances can share a Hive
+// hub. A nil Scope manages every ClusterDeployment.
+type Scope struct {
+	// namespaces are glob patterns matched against the namespace of an object. Empty matches every namespace.
+	namespaces []string
+	// selector is matched against the labels of a ClusterDeployment.
+	selector labels.Selector
+}
+
+// NewScope parses a comma separated list of namespace glob patterns and a ClusterDeployment label selector, either
+// of which may be empty. It returns nil if both are empty.
+func NewScope(namespaces, selector string) (*Scope, error) {
+	if namespaces == "" && selector == "" {
+		return nil, nil
+	}
+
+	s := &Scope{selector: labels.Everything()}
+	for _, pattern := range strings.Split(namespaces, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+		s.namespaces = append(s.namespaces, pattern)
+	}
+
+	if selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ClusterDeployment selector %q: %w", selector, err)
+		}
+		s.selector = parsed
+	}
+	return s, nil
+}
+
+// HasSelector returns true if the Scope selects ClusterDeployments by label.
+func (s *Scope) HasSelector() bool {
+	return s != nil && !s.selector.Empty()
+}
+
+// MatchesNamespace returns true if objects in namespace are in the Scope.
+func (s *Scope) MatchesNamespace(namespace string) bool {
+	if s == nil || len(s.namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range s.namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the ClusterDeployment cd is in the Scope.
+func (s *Scope) Matches(cd metav1.Object) bool {
+	if s == nil {
+		return true
+	}
+	return s.MatchesNamespace(cd.GetNamespace()) && s.selector.Matches(labels.Set(cd.GetLabels()))
+}
+
+// NamespacePredicate filters events down to the objects in the namespaces of the Scope.
+func (s *Scope) NamespacePredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.MatchesNamespace(obj.GetNamespace())
+	})
+}
+
+// ClusterDeploymentPredicate filters ClusterDeployment events down to the ClusterDeployments in the Scope.
+func (s *Scope) ClusterDeploymentPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return s.Matches(obj)
+	})
+}
+
+// EnteredPredicate filters ClusterDeployment updates down to the ones moving a ClusterDeployment into the Scope, for
+// example by relabelling it, which other update filters would otherwise drop.
+func (s *Scope) EnteredPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !s.Matches(e.ObjectOld) && s.Matches(e.ObjectNew)
+		},
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespaces  string
+		selector    string
+		object      metav1.ObjectMeta
+		expected    bool
+		expectError bool
+	}{
+		{
+			name:     "unset scope",
+			object:   metav1.ObjectMeta{Namespace: "uhc-production-1"},
+			expected: true,
+		},
+		{
+			name:       "matching namespace pattern",
+			namespaces: "uhc-staging-*, uhc-integration-*",
+			object:     metav1.ObjectMeta{Namespace: "uhc-integration-1"},
+			expected:   true,
+		},
+		{
+			name:       "other namespace",
+			namespaces: "uhc-staging-*",
+			object:     metav1.ObjectMeta{Namespace: "uhc-production-1"},
+			expected:   false,
+		},
+		{
+			name:     "matching labels",
+			selector: "shard in (a,b)",
+			object:   metav1.ObjectMeta{Namespace: "uhc-production-1", Labels: map[string]string{"shard": "a"}},
+			expected: true,
+		},
+		{
+			name:       "matching labels in other namespace",
+			namespaces: "uhc-staging-*",
+			selector:   "shard=a",
+			object:     metav1.ObjectMeta{Namespace: "uhc-production-1", Labels: map[string]string{"shard": "a"}},
+			expected:   false,
+		},
+		{
+			name:     "unlabelled",
+			selector: "shard=a",
+			object:   metav1.ObjectMeta{Namespace: "uhc-production-1"},
+			expected: false,
+		},
+		{
+			name:        "invalid namespace pattern",
+			namespaces:  "uhc-[",
+			expectError: true,
+		},
+		{
+			name:        "invalid selector",
+			selector:    "shard in a",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, err := NewScope(tt.namespaces, tt.selector)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, scope.Matches(&tt.object))
+			assert.Equal(t, tt.selector != "", scope.HasSelector())
+		})
+	}
+}
+
+func TestScopeEnteredPredicate(t *testing.T) {
+	scope, err := NewScope("", "shard=a")
+	require.NoError(t, err)
+
+	unlabelled := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "uhc-production-1"}}
+	labelled := unlabelled.DeepCopy()
+	labelled.Labels = map[string]string{"shard": "a"}
+
+	assert.True(t, scope.EnteredPredicate().Update(event.UpdateEvent{ObjectOld: unlabelled, ObjectNew: labelled}))
+	assert.False(t, scope.EnteredPredicate().Update(event.UpdateEvent{ObjectOld: labelled, ObjectNew: labelled}))
+	assert.False(t, scope.EnteredPredicate().Update(event.UpdateEvent{ObjectOld: labelled, ObjectNew: unlabelled}))
+}
@@ -19,23 +19,76 @@ package utils
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"time"
 
 	"golang.org/x/oauth2/google"
 	dnsv1 "google.golang.org/api/dns/v1"
 	iamv1 "google.golang.org/api/iam/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/notification"
+	"github.com/openshift/certman-operator/pkg/throttle"
 
 	"github.com/openshift/certman-operator/config"
 )
 
+const (
+	// DefaultRenewalCheckInterval matches the default resync period of the controller manager, which used to be the
+	// only thing checking certificates for renewal.
+	DefaultRenewalCheckInterval = 10 * time.Hour
+
+	// MinRenewalCheckInterval keeps a misconfigured interval from reconciling every CertificateRequest in a loop.
+	MinRenewalCheckInterval = time.Minute
+
+	// DefaultReconcileTimeout leaves room for the DNS propagation checks of an order with several authorizations.
+	DefaultReconcileTimeout = 30 * time.Minute
+
+	// MinReconcileTimeout is the shortest timeout that fits a single DNS propagation check.
+	MinReconcileTimeout = time.Minute
+
+	// DefaultMaxIssuanceAttempts gives up on a CertificateRequest after a few hours of failed attempts at the
+	// maximum requeue backoff.
+	DefaultMaxIssuanceAttempts = 10
+
+	// HibernationRenewalPolicyRenew keeps renewing the certificates of hibernating clusters.
+	HibernationRenewalPolicyRenew = "Renew"
+
+	// HibernationRenewalPolicyDefer defers the renewals of hibernating clusters until they resume.
+	HibernationRenewalPolicyDefer = "Defer"
+
+	// DefaultEmailExpiryWarningDays leaves a week to fix a renewal that keeps failing.
+	DefaultEmailExpiryWarningDays = 7
+
+	// DefaultDNSPropagationCheckAttempts and DefaultDNSPropagationCheckInterval wait up to 5 minutes for a challenge
+	// record to propagate.
+	DefaultDNSPropagationCheckAttempts = 10
+	DefaultDNSPropagationCheckInterval = 30 * time.Second
+
+	// MinDNSPropagationCheckInterval keeps a misconfigured interval from flooding the public DNS resolver.
+	MinDNSPropagationCheckInterval = time.Second
+
+	// CachedSecretLabel is set to "true" on the secrets the operator caches and watches: the certificate secrets and
+	// their replicas, and the imported and credentials secrets of the CertificateRequests. The other secrets are read
+	// from the API server.
+	CachedSecretLabel = "certman.managed.openshift.io/cached"
+
+	// extraRecordEnvVar is the environment variable the extra record was set with before the operator config.
+	extraRecordEnvVar = "EXTRA_RECORD"
+)
+
 // After instantiating a configmap object, GetDefaultNotificationEmailAddress validates
 // if there is a default email address present or not.
-func GetDefaultNotificationEmailAddress(kubeClient client.Client) (string, error) {
-	cm, err := getConfig(kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+func GetDefaultNotificationEmailAddress(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
 	if err != nil {
 		return "", err
 	}
@@ -47,8 +100,528 @@ func GetDefaultNotificationEmailAddress(kubeClient client.Client) (string, error
 	return cm.Data[cTypes.DefaultNotificationEmailAddress], nil
 }
 
-func GetCredentialsJSON(kubeClient client.Client, namespacesedName types.NamespacedName) (*google.Credentials, error) {
-	secret, err := getSecret(kubeClient, namespacesedName)
+// GetRevokeCertificatesOnDelete returns false if the operator ConfigMap opts out of revoking
+// certificates when their CertificateRequest is deleted. They are revoked by default, including when the ConfigMap
+// is missing.
+func GetRevokeCertificatesOnDelete(ctx context.Context, kubeClient client.Client) (bool, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return cm.Data[cTypes.RevokeCertificatesOnDelete] != "false", nil
+}
+
+// GetVerifyCertificateTransparency returns true if the operator ConfigMap requires issued
+// certificates to carry embedded Certificate Transparency SCTs. A missing ConfigMap is treated as opted out.
+func GetVerifyCertificateTransparency(ctx context.Context, kubeClient client.Client) (bool, error) {
+	return getBoolConfigValue(ctx, kubeClient, cTypes.VerifyCertificateTransparency)
+}
+
+// GetVerifyCTInclusion returns true if the operator ConfigMap requires issued certificates to be included in one of
+// the Certificate Transparency logs of its log list. A missing ConfigMap is treated as opted out.
+func GetVerifyCTInclusion(ctx context.Context, kubeClient client.Client) (bool, error) {
+	return getBoolConfigValue(ctx, kubeClient, cTypes.VerifyCTInclusion)
+}
+
+// GetCTLogList returns the name of the ConfigMap of the operator namespace holding the Certificate Transparency log
+// list set in the operator config, or an empty string if none is set.
+func GetCTLogList(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return cm.Data[cTypes.CTLogList], nil
+}
+
+// GetManageAlerts returns true if the operator ConfigMap opts in to the PrometheusRule of alerts managed by the
+// operator. A missing ConfigMap is treated as opted out.
+func GetManageAlerts(ctx context.Context, kubeClient client.Client) (bool, error) {
+	return getBoolConfigValue(ctx, kubeClient, cTypes.ManageAlerts)
+}
+
+// GetManageDashboard returns true if the operator ConfigMap opts in to the ConfigMap of the Grafana dashboard managed
+// by the operator. A missing ConfigMap is treated as opted out.
+func GetManageDashboard(ctx context.Context, kubeClient client.Client) (bool, error) {
+	return getBoolConfigValue(ctx, kubeClient, cTypes.ManageDashboard)
+}
+
+// GetEmailNotifications returns true if the owners of the certificates are emailed about their renewals and
+// expiries, as set in the operator ConfigMap.
+func GetEmailNotifications(ctx context.Context, kubeClient client.Client) (bool, error) {
+	return getBoolConfigValue(ctx, kubeClient, cTypes.EmailNotifications)
+}
+
+// GetDefaultKeySize returns the RSA key size set in the operator ConfigMap, or 0 if the ConfigMap
+// or the key is missing.
+func GetDefaultKeySize(ctx context.Context, kubeClient client.Client) (int, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.DefaultKeySize]
+	if value == "" {
+		return 0, nil
+	}
+
+	keySize, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.DefaultKeySize, value, err)
+	}
+
+	return keySize, nil
+}
+
+// GetRenewalJitterWindow returns the window over which renewals are spread, as set in the operator ConfigMap, or 0
+// if the ConfigMap or the key is missing.
+func GetRenewalJitterWindow(ctx context.Context, kubeClient client.Client) (time.Duration, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.RenewalJitterWindow]
+	if value == "" {
+		return 0, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.RenewalJitterWindow, value, err)
+	}
+	if window < 0 {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", cTypes.RenewalJitterWindow, value)
+	}
+
+	return window, nil
+}
+
+// GetRenewalCheckInterval returns how often certificates are checked for renewal, as set in the operator ConfigMap,
+// or DefaultRenewalCheckInterval if the ConfigMap or the key is missing.
+func GetRenewalCheckInterval(ctx context.Context, kubeClient client.Client) (time.Duration, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return DefaultRenewalCheckInterval, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.RenewalCheckInterval]
+	if value == "" {
+		return DefaultRenewalCheckInterval, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.RenewalCheckInterval, value, err)
+	}
+	if interval < MinRenewalCheckInterval {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must be at least %s", cTypes.RenewalCheckInterval, value, MinRenewalCheckInterval)
+	}
+
+	return interval, nil
+}
+
+// GetReconcileTimeout returns how long a single reconcile may take before its API, ACME and DNS calls are cancelled,
+// as set in the operator ConfigMap, or DefaultReconcileTimeout if the ConfigMap or the key is missing.
+func GetReconcileTimeout(ctx context.Context, kubeClient client.Client) (time.Duration, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return DefaultReconcileTimeout, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.ReconcileTimeout]
+	if value == "" {
+		return DefaultReconcileTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.ReconcileTimeout, value, err)
+	}
+	if timeout < MinReconcileTimeout {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must be at least %s", cTypes.ReconcileTimeout, value, MinReconcileTimeout)
+	}
+
+	return timeout, nil
+}
+
+// GetMaxIssuanceAttempts returns the number of consecutive failed attempts to issue a certificate after which a
+// CertificateRequest is marked Failed, as set in the operator ConfigMap, or DefaultMaxIssuanceAttempts if the
+// ConfigMap or the key is missing. 0 retries forever.
+func GetMaxIssuanceAttempts(ctx context.Context, kubeClient client.Client) (int, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return DefaultMaxIssuanceAttempts, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.MaxIssuanceAttempts]
+	if value == "" {
+		return DefaultMaxIssuanceAttempts, nil
+	}
+
+	attempts, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.MaxIssuanceAttempts, value, err)
+	}
+	if attempts < 0 {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", cTypes.MaxIssuanceAttempts, value)
+	}
+
+	return attempts, nil
+}
+
+// GetServiceLogFailureThreshold returns the number of failed attempts to issue a certificate since it was last issued
+// after which a service log is posted to the owners of the cluster, as set in the operator ConfigMap. 0, the default
+// when the ConfigMap or the key is missing, posts no service logs.
+func GetServiceLogFailureThreshold(ctx context.Context, kubeClient client.Client) (int, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.ServiceLogFailureThreshold]
+	if value == "" {
+		return 0, nil
+	}
+
+	threshold, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.ServiceLogFailureThreshold, value, err)
+	}
+	if threshold < 0 {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", cTypes.ServiceLogFailureThreshold, value)
+	}
+
+	return threshold, nil
+}
+
+// GetEmailExpiryWarningDays returns how many days before its expiry the owner of a certificate that was not renewed
+// is emailed, as set in the operator ConfigMap, or DefaultEmailExpiryWarningDays if the ConfigMap or the key is
+// missing.
+func GetEmailExpiryWarningDays(ctx context.Context, kubeClient client.Client) (int, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return DefaultEmailExpiryWarningDays, nil
+		}
+		return 0, err
+	}
+
+	value := cm.Data[cTypes.EmailExpiryWarningDays]
+	if value == "" {
+		return DefaultEmailExpiryWarningDays, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.EmailExpiryWarningDays, value, err)
+	}
+	if days <= 0 {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must be positive", cTypes.EmailExpiryWarningDays, value)
+	}
+
+	return days, nil
+}
+
+// GetPagerDutyEscalation returns how long a certificate may fail to be issued, and within how many days of its
+// expiry a certificate that was not renewed may be, before a PagerDuty incident is opened, as set in the operator
+// ConfigMap. 0, the default when the ConfigMap or a key is missing, opens no incidents.
+func GetPagerDutyEscalation(ctx context.Context, kubeClient client.Client) (time.Duration, int, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	var failureDuration time.Duration
+	if value := cm.Data[cTypes.PagerDutyFailureDuration]; value != "" {
+		failureDuration, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.PagerDutyFailureDuration, value, err)
+		}
+		if failureDuration < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", cTypes.PagerDutyFailureDuration, value)
+		}
+	}
+
+	var expiryDays int
+	if value := cm.Data[cTypes.PagerDutyExpiryDays]; value != "" {
+		expiryDays, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.PagerDutyExpiryDays, value, err)
+		}
+		if expiryDays < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", cTypes.PagerDutyExpiryDays, value)
+		}
+	}
+
+	return failureDuration, expiryDays, nil
+}
+
+// GetNotificationWebhook returns the format of the notifications of the certificate events and the template of their
+// payload, as set in the operator ConfigMap. An empty format, the default when the ConfigMap or the key is missing,
+// sends no notifications.
+func GetNotificationWebhook(ctx context.Context, kubeClient client.Client) (string, string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	switch format := cm.Data[cTypes.NotificationWebhookFormat]; format {
+	case "":
+		return "", "", nil
+	case notification.FormatJSON, notification.FormatSlack:
+		return format, cm.Data[cTypes.NotificationWebhookTemplate], nil
+	default:
+		return "", "", fmt.Errorf("invalid %s %q in configmap: must be %s or %s", cTypes.NotificationWebhookFormat, format, notification.FormatJSON, notification.FormatSlack)
+	}
+}
+
+// GetACMEDirectoryURL returns the directory URL of the ACME server of the lets-encrypt-account secret set in the
+// operator config, or an empty string if the directory URL is derived from the account URL.
+func GetACMEDirectoryURL(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return cm.Data[cTypes.ACMEDirectoryURL], nil
+}
+
+// GetTrustedCABundle returns the name of the ConfigMap of the operator namespace holding the additional CA bundle
+// set in the operator config, or an empty string if only the system roots are trusted.
+func GetTrustedCABundle(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return cm.Data[cTypes.TrustedCABundle], nil
+}
+
+// GetExtraRecord returns the label of the extra DNS name of the control plane certificates set in the operator
+// config, falling back to the EXTRA_RECORD environment variable.
+func GetExtraRecord(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil && !errors.IsNotFound(err) {
+		return "", err
+	}
+
+	if err == nil && cm.Data[cTypes.ExtraRecord] != "" {
+		return cm.Data[cTypes.ExtraRecord], nil
+	}
+	return os.Getenv(extraRecordEnvVar), nil
+}
+
+// GetDNSPropagationCheck returns how many times a challenge record is looked up and the wait between the lookups, as
+// set in the operator ConfigMap, or DefaultDNSPropagationCheckAttempts and DefaultDNSPropagationCheckInterval if the
+// ConfigMap or the keys are missing.
+func GetDNSPropagationCheck(ctx context.Context, kubeClient client.Client) (int, time.Duration, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return DefaultDNSPropagationCheckAttempts, DefaultDNSPropagationCheckInterval, nil
+		}
+		return 0, 0, err
+	}
+
+	attempts := DefaultDNSPropagationCheckAttempts
+	if value := cm.Data[cTypes.DNSPropagationCheckAttempts]; value != "" {
+		attempts, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.DNSPropagationCheckAttempts, value, err)
+		}
+		if attempts <= 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: must be positive", cTypes.DNSPropagationCheckAttempts, value)
+		}
+	}
+
+	interval := DefaultDNSPropagationCheckInterval
+	if value := cm.Data[cTypes.DNSPropagationCheckInterval]; value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: %w", cTypes.DNSPropagationCheckInterval, value, err)
+		}
+		if interval < MinDNSPropagationCheckInterval {
+			return 0, 0, fmt.Errorf("invalid %s %q in configmap: must be at least %s", cTypes.DNSPropagationCheckInterval, value, MinDNSPropagationCheckInterval)
+		}
+	}
+
+	return attempts, interval, nil
+}
+
+// GetHibernationRenewalPolicy returns the renewal policy of hibernating clusters set in the operator ConfigMap, or
+// HibernationRenewalPolicyRenew if the ConfigMap or the key is missing.
+func GetHibernationRenewalPolicy(ctx context.Context, kubeClient client.Client) (string, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return HibernationRenewalPolicyRenew, nil
+		}
+		return "", err
+	}
+
+	switch policy := cm.Data[cTypes.HibernationRenewalPolicy]; policy {
+	case "", HibernationRenewalPolicyRenew:
+		return HibernationRenewalPolicyRenew, nil
+	case HibernationRenewalPolicyDefer:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q in configmap: must be %s or %s", cTypes.HibernationRenewalPolicy, policy, HibernationRenewalPolicyRenew, HibernationRenewalPolicyDefer)
+	}
+}
+
+// GetIssuanceLimits returns the maximum numbers of new orders per ACME account and hour and week set in the
+// operator ConfigMap. Missing keys, or a missing ConfigMap, are unlimited.
+func GetIssuanceLimits(ctx context.Context, kubeClient client.Client) (throttle.Limits, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return throttle.Limits{}, nil
+		}
+		return throttle.Limits{}, err
+	}
+
+	perHour, err := getOrderLimit(cm, cTypes.MaxOrdersPerHour)
+	if err != nil {
+		return throttle.Limits{}, err
+	}
+	perWeek, err := getOrderLimit(cm, cTypes.MaxOrdersPerWeek)
+	if err != nil {
+		return throttle.Limits{}, err
+	}
+
+	return throttle.Limits{PerHour: perHour, PerWeek: perWeek}, nil
+}
+
+// getOrderLimit returns the non-negative order limit set under key in the operator ConfigMap, or 0 if it is missing.
+func getOrderLimit(cm *corev1.ConfigMap, key string) (int, error) {
+	value := cm.Data[key]
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in configmap: %w", key, value, err)
+	}
+	if limit < 0 {
+		return 0, fmt.Errorf("invalid %s %q in configmap: must not be negative", key, value)
+	}
+
+	return limit, nil
+}
+
+// getBoolConfigValue returns true if key is set to "true" in the operator ConfigMap.
+// A missing ConfigMap is treated as false.
+func getBoolConfigValue(ctx context.Context, kubeClient client.Client, key string) (bool, error) {
+	cm, err := getConfig(ctx, kubeClient, types.NamespacedName{Name: config.OperatorName, Namespace: config.OperatorNamespace})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return cm.Data[key] == "true", nil
+}
+
+// IsPaused returns true if obj carries the paused annotation.
+func IsPaused(obj metav1.Object) bool {
+	return obj.GetAnnotations()[certmanv1alpha1.PausedAnnotation] == "true"
+}
+
+// ClusterDeploymentName returns the name of the ClusterDeployment owning the CertificateRequest, or an empty string
+// if it is not owned by one, such as in standalone mode.
+func ClusterDeploymentName(cr *certmanv1alpha1.CertificateRequest) string {
+	name := ""
+	for _, ownerRef := range cr.OwnerReferences {
+		if ownerRef.Kind == "ClusterDeployment" {
+			name = ownerRef.Name
+		}
+	}
+	return name
+}
+
+// DNSPlatform returns the platform whose DNS provider answers the challenges of the CertificateRequest: its DNS
+// provider override if set, else the platform of its cluster.
+func DNSPlatform(cr *certmanv1alpha1.CertificateRequest) certmanv1alpha1.Platform {
+	if cr.Spec.DNSProvider != nil {
+		return *cr.Spec.DNSProvider
+	}
+	return cr.Spec.Platform
+}
+
+// CredentialsSecretName returns the name of the secret holding the credentials of the DNS provider of the platform,
+// or an empty string if it has none.
+func CredentialsSecretName(platform certmanv1alpha1.Platform) string {
+	switch {
+	case platform.AWS != nil:
+		return platform.AWS.Credentials.Name
+	case platform.GCP != nil:
+		return platform.GCP.Credentials.Name
+	case platform.Azure != nil:
+		return platform.Azure.Credentials.Name
+	case platform.Cloudflare != nil:
+		return platform.Cloudflare.Credentials.Name
+	case platform.AcmeDNS != nil:
+		return platform.AcmeDNS.Credentials.Name
+	}
+	return ""
+}
+
+// ACMEDNSZone returns the DNS zone the challenges of the CertificateRequest are answered in: the zone discovered for
+// it if any, else its ACME DNS domain.
+func ACMEDNSZone(cr *certmanv1alpha1.CertificateRequest) string {
+	if cr.Status.ACMEDNSZone != "" {
+		return cr.Status.ACMEDNSZone
+	}
+	return cr.Spec.ACMEDNSDomain
+}
+
+// OperatorConfigMapPredicate filters events down to the operator ConfigMap.
+var OperatorConfigMapPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	return obj.GetName() == config.OperatorName && obj.GetNamespace() == config.OperatorNamespace
+})
+
+func GetCredentialsJSON(ctx context.Context, kubeClient client.Client, namespacesedName types.NamespacedName) (*google.Credentials, error) {
+	secret, err := getSecret(ctx, kubeClient, namespacesedName)
 	if err != nil {
 		return nil, err
 	}
@@ -63,21 +636,40 @@ func GetCredentialsJSON(kubeClient client.Client, namespacesedName types.Namespa
 	return cred, err
 }
 
-// getConfig retrieves config from kubernetes and returns a ConfigMap object.
-func getConfig(kubeClient client.Client, namespacesedName types.NamespacedName) (*corev1.ConfigMap, error) {
+// getConfig retrieves config from kubernetes and returns a ConfigMap object. The settings of the
+// CertmanOperatorConfig take precedence over the keys of the ConfigMap, which may then be missing.
+func getConfig(ctx context.Context, kubeClient client.Client, namespacesedName types.NamespacedName) (*corev1.ConfigMap, error) {
 	cm := &corev1.ConfigMap{}
-	err := kubeClient.Get(context.TODO(), namespacesedName, cm)
+	cmErr := kubeClient.Get(ctx, namespacesedName, cm)
+	if cmErr != nil && !errors.IsNotFound(cmErr) {
+		return nil, cmErr
+	}
+
+	operatorConfig, err := GetOperatorConfig(ctx, kubeClient)
 	if err != nil {
 		return nil, err
 	}
+	if operatorConfig == nil {
+		if cmErr != nil {
+			return nil, cmErr
+		}
+		return cm, nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	for key, value := range operatorConfigData(&operatorConfig.Spec) {
+		cm.Data[key] = value
+	}
 
 	return cm, nil
 }
 
 // getSecret retrieves config from kubernetes and returns a ConfigMap object.
-func getSecret(kubeClient client.Client, namespacesedName types.NamespacedName) (*corev1.Secret, error) {
+func getSecret(ctx context.Context, kubeClient client.Client, namespacesedName types.NamespacedName) (*corev1.Secret, error) {
 	secret := &corev1.Secret{}
-	err := kubeClient.Get(context.TODO(), namespacesedName, secret)
+	err := kubeClient.Get(ctx, namespacesedName, secret)
 	if err != nil {
 		return nil, err
 	}
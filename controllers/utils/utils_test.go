@@ -19,11 +19,15 @@ package utils
 import (
 	"context"
 	"testing"
+	"time"
 
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/config"
 	"github.com/stretchr/testify/assert"
 
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/notification"
+	"github.com/openshift/certman-operator/pkg/throttle"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -102,7 +106,7 @@ func TestGetConfig(t *testing.T) {
 			name:        "Validate getConfig",
 			runtimeObjs: []runtime.Object{testConfigMap},
 			validate: func(c client.Client, t *testing.T) {
-				_, err := getConfig(c, testNamespaceName)
+				_, err := getConfig(context.TODO(), c, testNamespaceName)
 				assert.NoError(t, err)
 			},
 		},
@@ -113,7 +117,7 @@ func TestGetConfig(t *testing.T) {
 				// Break the test based on operator name.
 				breakNamespaceName := testNamespaceName
 				breakNamespaceName.Name = fakeOperatorName
-				_, err := getConfig(c, breakNamespaceName)
+				_, err := getConfig(context.TODO(), c, breakNamespaceName)
 				assert.Error(t, err)
 			},
 		},
@@ -124,7 +128,7 @@ func TestGetConfig(t *testing.T) {
 				// Break the test based on operator namespace.
 				breakNamespaceName := testNamespaceName
 				breakNamespaceName.Namespace = fakeOperatorNamespace
-				_, err := getConfig(c, breakNamespaceName)
+				_, err := getConfig(context.TODO(), c, breakNamespaceName)
 				assert.Error(t, err)
 			},
 		},
@@ -155,7 +159,7 @@ func TestGetDefaultNotificationEmailAddress(t *testing.T) {
 			name:        "Validate GetDefaultNotificationEmailAddress",
 			runtimeObjs: []runtime.Object{testConfigMap},
 			validate: func(c client.Client, t *testing.T) {
-				email, err := GetDefaultNotificationEmailAddress(c)
+				email, err := GetDefaultNotificationEmailAddress(context.TODO(), c)
 				assert.NoError(t, err)
 				assert.Equal(t, email, testConfigMap.Data[cTypes.DefaultNotificationEmailAddress])
 			},
@@ -167,7 +171,7 @@ func TestGetDefaultNotificationEmailAddress(t *testing.T) {
 				testConfigMap.Data[cTypes.DefaultNotificationEmailAddress] = ""
 				err := c.Update(context.TODO(), testConfigMap)
 				assert.NoError(t, err)
-				email, err := GetDefaultNotificationEmailAddress(c)
+				email, err := GetDefaultNotificationEmailAddress(context.TODO(), c)
 				assert.Error(t, err)
 				assert.Equal(t, email, testConfigMap.Data[cTypes.DefaultNotificationEmailAddress])
 			},
@@ -186,6 +190,730 @@ func TestGetDefaultNotificationEmailAddress(t *testing.T) {
 	}
 }
 
+func TestGetRevokeCertificatesOnDelete(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		expected    bool
+	}{
+		{
+			name:        "Validate GetRevokeCertificatesOnDelete configmap missing",
+			runtimeObjs: []runtime.Object{},
+			expected:    true,
+		},
+		{
+			name:        "Validate GetRevokeCertificatesOnDelete key not set",
+			runtimeObjs: []runtime.Object{testConfigMap},
+			expected:    true,
+		},
+		{
+			name: "Validate GetRevokeCertificatesOnDelete disabled",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data: map[string]string{
+					cTypes.RevokeCertificatesOnDelete: "false",
+				},
+			}},
+			expected: false,
+		},
+		{
+			name: "Validate GetRevokeCertificatesOnDelete enabled",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data: map[string]string{
+					cTypes.RevokeCertificatesOnDelete: "true",
+				},
+			}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+
+			revoke, err := GetRevokeCertificatesOnDelete(context.TODO(), fakeClient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, revoke)
+		})
+	}
+}
+
+func TestGetManageAlerts(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		expected    bool
+	}{
+		{
+			name:        "Validate GetManageAlerts configmap missing",
+			runtimeObjs: []runtime.Object{},
+			expected:    false,
+		},
+		{
+			name:        "Validate GetManageAlerts key not set",
+			runtimeObjs: []runtime.Object{testConfigMap},
+			expected:    false,
+		},
+		{
+			name: "Validate GetManageAlerts enabled",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data: map[string]string{
+					cTypes.ManageAlerts: "true",
+				},
+			}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+
+			manage, err := GetManageAlerts(context.TODO(), fakeClient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, manage)
+		})
+	}
+}
+
+func TestGetManageDashboard(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		expected    bool
+	}{
+		{
+			name:        "Validate GetManageDashboard configmap missing",
+			runtimeObjs: []runtime.Object{},
+			expected:    false,
+		},
+		{
+			name:        "Validate GetManageDashboard key not set",
+			runtimeObjs: []runtime.Object{testConfigMap},
+			expected:    false,
+		},
+		{
+			name: "Validate GetManageDashboard enabled",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data: map[string]string{
+					cTypes.ManageDashboard: "true",
+				},
+			}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+
+			manage, err := GetManageDashboard(context.TODO(), fakeClient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, manage)
+		})
+	}
+}
+
+func TestGetEmailNotifications(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		expected    bool
+	}{
+		{
+			name:        "Validate GetEmailNotifications configmap missing",
+			runtimeObjs: []runtime.Object{},
+			expected:    false,
+		},
+		{
+			name:        "Validate GetEmailNotifications key not set",
+			runtimeObjs: []runtime.Object{testConfigMap},
+			expected:    false,
+		},
+		{
+			name: "Validate GetEmailNotifications enabled",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data: map[string]string{
+					cTypes.EmailNotifications: "true",
+				},
+			}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+
+			enabled, err := GetEmailNotifications(context.TODO(), fakeClient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, enabled)
+		})
+	}
+}
+
+func TestGetRenewalCheckInterval(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    time.Duration
+		expectError bool
+	}{
+		{
+			name:     "Validate GetRenewalCheckInterval key not set",
+			expected: DefaultRenewalCheckInterval,
+		},
+		{
+			name:     "Validate GetRenewalCheckInterval short interval",
+			data:     map[string]string{cTypes.RenewalCheckInterval: "15m"},
+			expected: 15 * time.Minute,
+		},
+		{
+			name:        "Validate GetRenewalCheckInterval below the minimum",
+			data:        map[string]string{cTypes.RenewalCheckInterval: "1s"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetRenewalCheckInterval invalid interval",
+			data:        map[string]string{cTypes.RenewalCheckInterval: "daily"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			interval, err := GetRenewalCheckInterval(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, interval)
+		})
+	}
+}
+
+func TestGetReconcileTimeout(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    time.Duration
+		expectError bool
+	}{
+		{
+			name:     "Validate GetReconcileTimeout key not set",
+			expected: DefaultReconcileTimeout,
+		},
+		{
+			name:     "Validate GetReconcileTimeout custom timeout",
+			data:     map[string]string{cTypes.ReconcileTimeout: "1h"},
+			expected: time.Hour,
+		},
+		{
+			name:        "Validate GetReconcileTimeout below the minimum",
+			data:        map[string]string{cTypes.ReconcileTimeout: "10s"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetReconcileTimeout invalid timeout",
+			data:        map[string]string{cTypes.ReconcileTimeout: "forever"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			timeout, err := GetReconcileTimeout(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, timeout)
+		})
+	}
+}
+
+func TestGetMaxIssuanceAttempts(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    int
+		expectError bool
+	}{
+		{
+			name:     "Validate GetMaxIssuanceAttempts key not set",
+			expected: DefaultMaxIssuanceAttempts,
+		},
+		{
+			name:     "Validate GetMaxIssuanceAttempts custom budget",
+			data:     map[string]string{cTypes.MaxIssuanceAttempts: "3"},
+			expected: 3,
+		},
+		{
+			name:     "Validate GetMaxIssuanceAttempts unlimited",
+			data:     map[string]string{cTypes.MaxIssuanceAttempts: "0"},
+			expected: 0,
+		},
+		{
+			name:        "Validate GetMaxIssuanceAttempts negative budget",
+			data:        map[string]string{cTypes.MaxIssuanceAttempts: "-1"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetMaxIssuanceAttempts invalid budget",
+			data:        map[string]string{cTypes.MaxIssuanceAttempts: "many"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			attempts, err := GetMaxIssuanceAttempts(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, attempts)
+		})
+	}
+}
+
+func TestGetServiceLogFailureThreshold(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    int
+		expectError bool
+	}{
+		{
+			name:     "Validate GetServiceLogFailureThreshold key not set",
+			expected: 0,
+		},
+		{
+			name:     "Validate GetServiceLogFailureThreshold custom threshold",
+			data:     map[string]string{cTypes.ServiceLogFailureThreshold: "5"},
+			expected: 5,
+		},
+		{
+			name:        "Validate GetServiceLogFailureThreshold negative threshold",
+			data:        map[string]string{cTypes.ServiceLogFailureThreshold: "-1"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetServiceLogFailureThreshold invalid threshold",
+			data:        map[string]string{cTypes.ServiceLogFailureThreshold: "often"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			threshold, err := GetServiceLogFailureThreshold(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, threshold)
+		})
+	}
+}
+
+func TestGetPagerDutyEscalation(t *testing.T) {
+
+	testUnits := []struct {
+		name                    string
+		data                    map[string]string
+		expectedFailureDuration time.Duration
+		expectedExpiryDays      int
+		expectError             bool
+	}{
+		{
+			name: "Validate GetPagerDutyEscalation keys not set",
+		},
+		{
+			name:                    "Validate GetPagerDutyEscalation custom thresholds",
+			data:                    map[string]string{cTypes.PagerDutyFailureDuration: "6h", cTypes.PagerDutyExpiryDays: "5"},
+			expectedFailureDuration: 6 * time.Hour,
+			expectedExpiryDays:      5,
+		},
+		{
+			name:        "Validate GetPagerDutyEscalation invalid failure duration",
+			data:        map[string]string{cTypes.PagerDutyFailureDuration: "a while"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetPagerDutyEscalation negative expiry days",
+			data:        map[string]string{cTypes.PagerDutyExpiryDays: "-1"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			failureDuration, expiryDays, err := GetPagerDutyEscalation(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFailureDuration, failureDuration)
+			assert.Equal(t, tt.expectedExpiryDays, expiryDays)
+		})
+	}
+}
+
+func TestGetEmailExpiryWarningDays(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    int
+		expectError bool
+	}{
+		{
+			name:     "Validate GetEmailExpiryWarningDays key not set",
+			expected: DefaultEmailExpiryWarningDays,
+		},
+		{
+			name:     "Validate GetEmailExpiryWarningDays custom days",
+			data:     map[string]string{cTypes.EmailExpiryWarningDays: "3"},
+			expected: 3,
+		},
+		{
+			name:        "Validate GetEmailExpiryWarningDays zero days",
+			data:        map[string]string{cTypes.EmailExpiryWarningDays: "0"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetEmailExpiryWarningDays invalid days",
+			data:        map[string]string{cTypes.EmailExpiryWarningDays: "week"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			days, err := GetEmailExpiryWarningDays(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, days)
+		})
+	}
+}
+
+func TestGetNotificationWebhook(t *testing.T) {
+
+	testUnits := []struct {
+		name             string
+		data             map[string]string
+		expectedFormat   string
+		expectedTemplate string
+		expectError      bool
+	}{
+		{
+			name: "Validate GetNotificationWebhook key not set",
+		},
+		{
+			name:           "Validate GetNotificationWebhook slack",
+			data:           map[string]string{cTypes.NotificationWebhookFormat: "slack"},
+			expectedFormat: notification.FormatSlack,
+		},
+		{
+			name:             "Validate GetNotificationWebhook json template",
+			data:             map[string]string{cTypes.NotificationWebhookFormat: "json", cTypes.NotificationWebhookTemplate: `{"text":"{{ .Summary }}"}`},
+			expectedFormat:   notification.FormatJSON,
+			expectedTemplate: `{"text":"{{ .Summary }}"}`,
+		},
+		{
+			name:        "Validate GetNotificationWebhook unknown format",
+			data:        map[string]string{cTypes.NotificationWebhookFormat: "teams"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			format, payloadTemplate, err := GetNotificationWebhook(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFormat, format)
+			assert.Equal(t, tt.expectedTemplate, payloadTemplate)
+		})
+	}
+}
+
+func TestGetHibernationRenewalPolicy(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Validate GetHibernationRenewalPolicy key not set",
+			expected: HibernationRenewalPolicyRenew,
+		},
+		{
+			name:     "Validate GetHibernationRenewalPolicy defer",
+			data:     map[string]string{cTypes.HibernationRenewalPolicy: "Defer"},
+			expected: HibernationRenewalPolicyDefer,
+		},
+		{
+			name:        "Validate GetHibernationRenewalPolicy unknown policy",
+			data:        map[string]string{cTypes.HibernationRenewalPolicy: "Skip"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			policy, err := GetHibernationRenewalPolicy(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestGetIssuanceLimits(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		data        map[string]string
+		expected    throttle.Limits
+		expectError bool
+	}{
+		{
+			name:     "Validate GetIssuanceLimits keys not set",
+			expected: throttle.Limits{},
+		},
+		{
+			name: "Validate GetIssuanceLimits hourly and weekly limits",
+			data: map[string]string{
+				cTypes.MaxOrdersPerHour: "50",
+				cTypes.MaxOrdersPerWeek: "300",
+			},
+			expected: throttle.Limits{PerHour: 50, PerWeek: 300},
+		},
+		{
+			name:        "Validate GetIssuanceLimits negative limit",
+			data:        map[string]string{cTypes.MaxOrdersPerWeek: "-1"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetIssuanceLimits invalid limit",
+			data:        map[string]string{cTypes.MaxOrdersPerHour: "many"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			limits, err := GetIssuanceLimits(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestGetDNSPropagationCheck(t *testing.T) {
+
+	testUnits := []struct {
+		name             string
+		data             map[string]string
+		expectedAttempts int
+		expectedInterval time.Duration
+		expectError      bool
+	}{
+		{
+			name:             "Validate GetDNSPropagationCheck keys not set",
+			expectedAttempts: DefaultDNSPropagationCheckAttempts,
+			expectedInterval: DefaultDNSPropagationCheckInterval,
+		},
+		{
+			name: "Validate GetDNSPropagationCheck attempts and interval",
+			data: map[string]string{
+				cTypes.DNSPropagationCheckAttempts: "20",
+				cTypes.DNSPropagationCheckInterval: "15s",
+			},
+			expectedAttempts: 20,
+			expectedInterval: 15 * time.Second,
+		},
+		{
+			name:        "Validate GetDNSPropagationCheck no attempts",
+			data:        map[string]string{cTypes.DNSPropagationCheckAttempts: "0"},
+			expectError: true,
+		},
+		{
+			name:        "Validate GetDNSPropagationCheck interval below the minimum",
+			data:        map[string]string{cTypes.DNSPropagationCheckInterval: "10ms"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       tt.data,
+			}).Build()
+
+			attempts, interval, err := GetDNSPropagationCheck(context.TODO(), fakeClient)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedAttempts, attempts)
+			assert.Equal(t, tt.expectedInterval, interval)
+		})
+	}
+}
+
+func TestGetExtraRecord(t *testing.T) {
+
+	testUnits := []struct {
+		name        string
+		runtimeObjs []runtime.Object
+		env         string
+		expected    string
+	}{
+		{
+			name:     "Validate GetExtraRecord falls back to the environment",
+			env:      "rh-api",
+			expected: "rh-api",
+		},
+		{
+			name: "Validate GetExtraRecord prefers the configmap",
+			runtimeObjs: []runtime.Object{&v1.ConfigMap{
+				ObjectMeta: testConfigMap.ObjectMeta,
+				Data:       map[string]string{cTypes.ExtraRecord: "private-api"},
+			}},
+			env:      "rh-api",
+			expected: "private-api",
+		},
+		{
+			name:     "Validate GetExtraRecord not set",
+			expected: "",
+		},
+	}
+
+	for _, tt := range testUnits {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(extraRecordEnvVar, tt.env)
+
+			s := scheme.Scheme
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.runtimeObjs...).Build()
+
+			extraRecord, err := GetExtraRecord(context.TODO(), fakeClient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, extraRecord)
+		})
+	}
+}
+
 func TestGetCredentialsJSON(t *testing.T) {
 
 	testUnits := []struct {
@@ -197,7 +925,7 @@ func TestGetCredentialsJSON(t *testing.T) {
 			name:        "Validate GetCredentialsJSON",
 			runtimeObjs: []runtime.Object{testSecret},
 			validate: func(c client.Client, t *testing.T) {
-				_, err := GetCredentialsJSON(c, testNamespaceName)
+				_, err := GetCredentialsJSON(context.TODO(), c, testNamespaceName)
 				assert.NoError(t, err)
 			},
 		},
@@ -206,7 +934,7 @@ func TestGetCredentialsJSON(t *testing.T) {
 			runtimeObjs: []runtime.Object{testSecret},
 			validate: func(c client.Client, t *testing.T) {
 				testNamespaceName.Namespace = fakeOperatorNamespace
-				_, err := GetCredentialsJSON(c, testNamespaceName)
+				_, err := GetCredentialsJSON(context.TODO(), c, testNamespaceName)
 				assert.Error(t, err)
 			},
 		},
@@ -248,3 +976,40 @@ func TestRemoveString(t *testing.T) {
 		assert.Equal(t, result, sliceOfStrings)
 	})
 }
+
+func TestDNSPlatform(t *testing.T) {
+	platform := certmanv1alpha1.Platform{AWS: &certmanv1alpha1.AWSPlatformSecrets{Region: "us-east-1"}}
+	override := certmanv1alpha1.Platform{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{}}
+
+	t.Run("the platform of the cluster without an override", func(t *testing.T) {
+		cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{Platform: platform}}
+		assert.Equal(t, platform, DNSPlatform(cr))
+	})
+
+	t.Run("the DNS provider override", func(t *testing.T) {
+		cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{Platform: platform, DNSProvider: &override}}
+		assert.Equal(t, override, DNSPlatform(cr))
+	})
+}
+
+func TestCredentialsSecretName(t *testing.T) {
+	credentials := v1.LocalObjectReference{Name: "dns-credentials"}
+	for _, platform := range []certmanv1alpha1.Platform{
+		{AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: credentials}},
+		{GCP: &certmanv1alpha1.GCPPlatformSecrets{Credentials: credentials}},
+		{Azure: &certmanv1alpha1.AzurePlatformSecrets{Credentials: credentials}},
+		{Cloudflare: &certmanv1alpha1.CloudflarePlatformSecrets{Credentials: credentials}},
+		{AcmeDNS: &certmanv1alpha1.AcmeDNSPlatformSecrets{Credentials: credentials}},
+	} {
+		assert.Equal(t, "dns-credentials", CredentialsSecretName(platform))
+	}
+	assert.Empty(t, CredentialsSecretName(certmanv1alpha1.Platform{Mock: &certmanv1alpha1.MockPlatformSecrets{}}))
+}
+
+func TestACMEDNSZone(t *testing.T) {
+	cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{ACMEDNSDomain: "cluster.example.com"}}
+	assert.Equal(t, "cluster.example.com", ACMEDNSZone(cr))
+
+	cr.Status.ACMEDNSZone = "example.com"
+	assert.Equal(t, "example.com", ACMEDNSZone(cr))
+}
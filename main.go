@@ -21,46 +21,83 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"runtime"
 	"strings"
-	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
-	zaplogfmt "github.com/sykesm/zap-logfmt"
-	uzap "go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
-	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
+	"github.com/go-logr/logr"
 	"github.com/operator-framework/operator-lib/leader"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
+	configv1 "github.com/openshift/api/config/v1"
 	routev1 "github.com/openshift/api/route/v1"
 	aaov1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/openshift/operator-custom-metrics/pkg/metrics"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	certmanv1beta1 "github.com/openshift/certman-operator/api/v1beta1"
 	operatorconfig "github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/acmeaccount"
+	"github.com/openshift/certman-operator/controllers/apipriority"
+	"github.com/openshift/certman-operator/controllers/certificateinventory"
+	"github.com/openshift/certman-operator/controllers/certificatemetrics"
 	"github.com/openshift/certman-operator/controllers/certificaterequest"
+	"github.com/openshift/certman-operator/controllers/certmanagermigration"
 	"github.com/openshift/certman-operator/controllers/clusterdeployment"
+	"github.com/openshift/certman-operator/controllers/grafanadashboard"
+	"github.com/openshift/certman-operator/controllers/ingresscertificaterollout"
+	"github.com/openshift/certman-operator/controllers/managedcluster"
+	"github.com/openshift/certman-operator/controllers/orphanreaper"
+	"github.com/openshift/certman-operator/controllers/prometheusrule"
+	"github.com/openshift/certman-operator/controllers/ratelimitbudget"
+	"github.com/openshift/certman-operator/controllers/servicemonitor"
+	"github.com/openshift/certman-operator/controllers/standalone"
+	"github.com/openshift/certman-operator/controllers/trustedcabundle"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/audit"
+	"github.com/openshift/certman-operator/pkg/certpolicy"
 	cClient "github.com/openshift/certman-operator/pkg/clients"
+	"github.com/openshift/certman-operator/pkg/clients/azure"
+	"github.com/openshift/certman-operator/pkg/fips"
+	"github.com/openshift/certman-operator/pkg/health"
+	"github.com/openshift/certman-operator/pkg/http01"
 	"github.com/openshift/certman-operator/pkg/k8sutil"
+	"github.com/openshift/certman-operator/pkg/kms"
+	"github.com/openshift/certman-operator/pkg/leclient"
 	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
+	"github.com/openshift/certman-operator/pkg/mailer"
+	"github.com/openshift/certman-operator/pkg/notification"
+	"github.com/openshift/certman-operator/pkg/pagerduty"
+	"github.com/openshift/certman-operator/pkg/proxy"
+	"github.com/openshift/certman-operator/pkg/secretsink"
+	"github.com/openshift/certman-operator/pkg/securemetrics"
+	"github.com/openshift/certman-operator/pkg/servicelog"
+	"github.com/openshift/certman-operator/pkg/servingcert"
+	"github.com/openshift/certman-operator/pkg/tracing"
 	"github.com/openshift/certman-operator/pkg/version"
 	//+kubebuilder:scaffold:imports
 )
@@ -78,9 +115,12 @@ var log = logf.Log.WithName("cmd")
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(certmanv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(certmanv1beta1.AddToScheme(scheme))
 	utilruntime.Must(routev1.Install(scheme))
 	utilruntime.Must(hivev1.AddToScheme(scheme))
 	utilruntime.Must(aaov1alpha1.AddToScheme(scheme))
+	utilruntime.Must(configv1.Install(scheme))
+	utilruntime.Must(monitoringv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -94,54 +134,180 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var scopeNamespaces string
+	var scopeSelector string
+	var standaloneMode bool
+	var managedClusterMode bool
+	var enableProfiling bool
+	var profilingAddr string
+	var metricsSecure bool
+	var metricsCertDir string
+	var manageServiceMonitor bool
+	var webhookCertDir string
+	var http01Addr string
+	var migrateCertManager bool
+	var certManagerClusterResourceNamespace string
+	var auditMode bool
+	var kubeAPIQPS float64
+	var kubeAPIBurst int
+	var manageAPIPriority bool
+	var apiPriorityShares int
+	var rateLimiter utils.RateLimiterOptions
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":"+metricsPort, "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
-	opts := zap.Options{
-		Development: true,
-	}
-	opts.BindFlags(flag.CommandLine)
+	flag.StringVar(&scopeNamespaces, "cluster-deployment-namespaces", "",
+		"Comma separated glob patterns of the namespaces whose ClusterDeployments are managed, e.g. uhc-staging-*. "+
+			"All namespaces are managed if unset.")
+	flag.StringVar(&scopeSelector, "cluster-deployment-selector", "",
+		"Label selector of the ClusterDeployments that are managed, e.g. certman.managed.openshift.io/shard=a. "+
+			"All ClusterDeployments are managed if unset.")
+	flag.BoolVar(&standaloneMode, "standalone", false,
+		"Manage the certificates of the API and default ingress of the cluster the operator runs on, "+
+			"instead of those of Hive ClusterDeployments.")
+	flag.BoolVar(&managedClusterMode, "managed-clusters", false,
+		"Manage the certificates of the Open Cluster Management ManagedClusters of an ACM or MCE hub, "+
+			"configured by ManagedClusterCertificateConfigs, instead of those of Hive ClusterDeployments.")
+	flag.BoolVar(&enableProfiling, "enable-profiling", false,
+		"Serve the net/http/pprof CPU and heap profiles on the profiling bind address.")
+	flag.StringVar(&profilingAddr, "profiling-bind-address", "localhost:6060",
+		"The address the profiling endpoint binds to when profiling is enabled. "+
+			"It is only reachable from within the pod by default, e.g. with kubectl port-forward.")
+	flag.BoolVar(&metricsSecure, "metrics-secure", false,
+		"Serve the metrics over TLS on port 8443 to the clients authorized to get /metrics, "+
+			"instead of over plain HTTP on port "+metricsPort+".")
+	flag.StringVar(&metricsCertDir, "metrics-cert-dir", securemetrics.DefaultCertDir,
+		"The directory of the tls.crt and tls.key of the metrics served over TLS.")
+	flag.BoolVar(&manageServiceMonitor, "manage-service-monitor", false,
+		"Create and reconcile the ServiceMonitor that scrapes the operator's metrics.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/etc/certman-operator/webhook-tls",
+		"The directory of the tls.crt and tls.key of the CertificateRequest conversion and validating webhooks.")
+	flag.StringVar(&http01Addr, "http01-bind-address", "",
+		"The address the HTTP-01 challenges are served on, such as :8089. "+
+			"CertificateRequests cannot use http-01 challenges if unset.")
+	flag.BoolVar(&migrateCertManager, "migrate-cert-manager", false,
+		"Convert the cert-manager Certificates of ACME Issuers and ClusterIssuers into CertificateRequests, "+
+			"adopting their certificate secrets.")
+	flag.StringVar(&certManagerClusterResourceNamespace, "cert-manager-cluster-resource-namespace", certmanagermigration.DefaultClusterResourceNamespace,
+		"The namespace cert-manager reads the secrets of ClusterIssuers from, when migrating from cert-manager.")
+	flag.BoolVar(&auditMode, "audit", false,
+		"Run in read-only audit mode: certificates are analysed and metrics computed, but every write to the "+
+			"Kubernetes API, ACME server, DNS provider and external service is blocked and counted.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"The sustained queries per second of the operator to the Kubernetes API server, past which its requests are "+
+			"throttled by the client.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"The number of queries the operator may send to the Kubernetes API server in a burst above --kube-api-qps.")
+	flag.BoolVar(&manageAPIPriority, "manage-api-priority", false,
+		"Create and reconcile a FlowSchema and PriorityLevelConfiguration giving the requests of the operator's "+
+			"service account their own share of the API server's concurrency.")
+	flag.IntVar(&apiPriorityShares, "api-priority-concurrency-shares", apipriority.DefaultNominalConcurrencyShares,
+		"The nominal concurrency shares of the operator's PriorityLevelConfiguration, when --manage-api-priority is set.")
+	flag.DurationVar(&rateLimiter.BaseDelay, "workqueue-base-delay", 0,
+		"The delay before a failed reconcile of the CertificateRequest, ClusterDeployment and ManagedCluster controllers "+
+			"is retried, doubled on each consecutive failure. 0 keeps the default of each controller.")
+	flag.DurationVar(&rateLimiter.MaxDelay, "workqueue-max-delay", 0,
+		"The maximum delay between the retries of a failed reconcile. 0 keeps the default of each controller.")
+	flag.Float64Var(&rateLimiter.QPS, "workqueue-qps", 0,
+		"The overall rate of the retries of the failed reconciles of each controller, past --workqueue-burst. "+
+			"0 keeps the default of each controller.")
+	flag.IntVar(&rateLimiter.Burst, "workqueue-burst", 0,
+		"The number of retries of each controller let through at once before --workqueue-qps applies. "+
+			"0 keeps the default of each controller.")
+	logOpts := logging.Options{}
+	logOpts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
-	// Add a custom logger to log in RFC3339 format instead of UTC
-	configLog := uzap.NewProductionEncoderConfig()
-	configLog.EncodeTime = func(ts time.Time, encoder zapcore.PrimitiveArrayEncoder) {
-		encoder.AppendString(ts.UTC().Format(time.RFC3339Nano))
+	logger, err := logging.New(logOpts, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	logfmtEncoder := zaplogfmt.NewEncoder(configLog)
-	logger := zap.New(zap.UseDevMode(true), zap.WriteTo(os.Stdout), zap.Encoder(logfmtEncoder))
 	logf.SetLogger(logger)
-	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
 	printVersion()
 
+	if standaloneMode && managedClusterMode {
+		log.Error(fmt.Errorf("--standalone and --managed-clusters are mutually exclusive"), "Invalid flags")
+		os.Exit(1)
+	}
+
+	if kubeAPIQPS <= 0 || kubeAPIBurst <= 0 {
+		log.Error(fmt.Errorf("--kube-api-qps and --kube-api-burst must be positive"), "Invalid flags")
+		os.Exit(1)
+	}
+	if apiPriorityShares <= 0 || apiPriorityShares > math.MaxInt32 {
+		log.Error(fmt.Errorf("--api-priority-concurrency-shares must be positive"), "Invalid flags")
+		os.Exit(1)
+	}
+	if rateLimiter.BaseDelay < 0 || rateLimiter.MaxDelay < 0 || rateLimiter.QPS < 0 || rateLimiter.Burst < 0 {
+		log.Error(fmt.Errorf("--workqueue-base-delay, --workqueue-max-delay, --workqueue-qps and --workqueue-burst must not be negative"), "Invalid flags")
+		os.Exit(1)
+	}
+	if rateLimiter.BaseDelay != 0 && rateLimiter.MaxDelay != 0 && rateLimiter.BaseDelay > rateLimiter.MaxDelay {
+		log.Error(fmt.Errorf("--workqueue-base-delay must not be greater than --workqueue-max-delay"), "Invalid flags")
+		os.Exit(1)
+	}
+
+	if err := fips.Validate(); err != nil {
+		log.Error(err, "Failed to enable FIPS mode")
+		os.Exit(1)
+	}
+	log.Info(fmt.Sprintf("FIPS mode enabled: %t", fips.Enabled()))
+
+	if auditMode {
+		audit.Enable()
+	}
+	log.Info(fmt.Sprintf("Read-only audit mode enabled: %t", audit.Enabled()))
+	localmetrics.SetFIPSModeEnabled(fips.Enabled())
+
+	shutdownTracing, err := tracing.Setup(context.TODO())
+	if err != nil {
+		log.Error(err, "Failed to set up tracing")
+		os.Exit(1)
+	}
+	log.Info(fmt.Sprintf("Tracing enabled: %t", tracing.Enabled()))
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
 		os.Exit(1)
 	}
 
+	scope, err := utils.NewScope(scopeNamespaces, scopeSelector)
+	if err != nil {
+		log.Error(err, "Failed to parse the ClusterDeployment scope")
+		os.Exit(1)
+	}
+
 	// Get a config to talk to the apiserver
 	cfg, err := config.GetConfig()
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
 	}
+	cfg.QPS = float32(kubeAPIQPS)
+	cfg.Burst = kubeAPIBurst
+	log.Info(fmt.Sprintf("Kubernetes API client QPS: %g, burst: %d", cfg.QPS, cfg.Burst))
 
 	ctx := context.TODO()
 	// Ensure lock for leader election
 	_, err = k8sutil.GetOperatorNamespace()
-	switch err {
-	case nil:
+	switch {
+	case auditMode:
+		// A passive instance neither writes the lock nor waits for the instance managing the certificates.
+		setupLog.Info("Skipping leader election; running in read-only audit mode.")
+
+	case err == nil:
 		// We are in-cluster, so try to become leader.
 		if err := leader.Become(ctx, "certman-operator-lock"); err != nil {
 			setupLog.Error(err, "failed to create leader lock")
 			os.Exit(1)
 		}
 
-	case k8sutil.ErrRunLocal, k8sutil.ErrNoNamespace:
+	case err == k8sutil.ErrRunLocal, err == k8sutil.ErrNoNamespace:
 		// Running outside a cluster (e.g. `operator-sdk run --local`).
 		setupLog.Info("Skipping leader election; not running in a cluster.")
 
@@ -155,13 +321,29 @@ func main() {
 	options := manager.Options{
 		// Namespace: namespace,
 		Scheme:                 scheme,
-		WebhookServer:          webhook.NewServer(webhook.Options{Port: 9443}),
+		WebhookServer:          webhook.NewServer(webhook.Options{Port: 9443, TLSOpts: servingcert.TLSOpts(webhookCertDir)}),
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "529d7a9e.managed.openshift.io",
-		// Disable controller-runtime metrics serving
+		// Disable controller-runtime metrics serving, unless the metrics are served over TLS
 		Metrics: metricsserver.Options{BindAddress: "0"},
 	}
+	if auditMode {
+		// Block the writes to the Kubernetes API, and elect a leader among audit instances only
+		options.NewClient = audit.NewClient
+		options.LeaderElectionID = "audit." + options.LeaderElectionID
+	}
+	if metricsSecure {
+		if err := securemetrics.Register(localmetrics.MetricsList); err != nil {
+			setupLog.Error(err, "unable to register the metrics")
+			os.Exit(1)
+		}
+		options.Metrics = securemetrics.ServerOptions(metricsCertDir)
+	}
+	if enableProfiling {
+		setupLog.Info("serving the pprof profiles", "address", profilingAddr)
+		options.PprofBindAddress = profilingAddr
+	}
 	// cacheOptions := cache.Options{
 	// 	Scheme: options.Scheme,
 	// }
@@ -176,6 +358,13 @@ func main() {
 		}
 		options.Cache.DefaultNamespaces = ccMap
 	}
+	// Only cache the secrets labeled for the operator, rather than every TLS certificate and kubeconfig of the
+	// watched namespaces. The other secrets, such as those of the CertificateRequests not labeled yet, are read from
+	// the API server.
+	options.Cache.ByObject = map[client.Object]cache.ByObject{
+		&corev1.Secret{}: {Label: labels.SelectorFromSet(labels.Set{utils.CachedSecretLabel: "true"})},
+	}
+	options.Client.Cache = &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}}
 
 	mgr, err := ctrl.NewManager(cfg, options)
 	if err != nil {
@@ -183,25 +372,232 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Send the requests to the ACME servers and the DNS providers through the cluster-wide proxy
+	egressProxy, err := proxy.Load(ctx, mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to read the cluster proxy")
+		os.Exit(1)
+	}
+	if err = proxy.Install(egressProxy); err != nil {
+		setupLog.Error(err, "unable to configure the cluster proxy")
+		os.Exit(1)
+	}
+	setupLog.Info("egress proxy", "enabled", egressProxy.Enabled(), "noProxy", egressProxy.NoProxy)
+
+	// Serve the conversion of CertificateRequests between v1alpha1 and v1beta1
+	if err = ctrl.NewWebhookManagedBy(mgr).For(&certmanv1beta1.CertificateRequest{}).Complete(); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "CertificateRequest")
+		os.Exit(1)
+	}
+
+	// Reject CertificateRequests that do not conform to the CertificatePolicies
+	if err = (&certpolicy.Validator{Client: mgr.GetClient()}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "CertificateRequest validation")
+		os.Exit(1)
+	}
+
+	var http01Solver *http01.Server
+	if http01Addr != "" {
+		http01Solver = &http01.Server{BindAddress: http01Addr}
+		if err = mgr.Add(http01Solver); err != nil {
+			setupLog.Error(err, "unable to add the HTTP-01 challenge server")
+			os.Exit(1)
+		}
+	}
+
+	var recorder record.EventRecorder = mgr.GetEventRecorderFor("certman-operator")
+	if auditMode {
+		recorder = audit.EventRecorder{}
+	}
+
 	// Add CertificateRequest controller to the manager
-	if err = (&certificaterequest.CertificateRequestReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		ClientBuilder: cClient.NewClient,
-	}).SetupWithManager(mgr); err != nil {
+	certificateRequestReconciler := &certificaterequest.CertificateRequestReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		ClientBuilder:           cClient.NewClient,
+		KMSClientBuilder:        kms.NewClient,
+		KeyVaultClientBuilder:   azure.NewKeyVaultClient,
+		SecretSinkBuilder:       secretsink.NewSink,
+		ServiceLogClientBuilder: servicelog.NewClient,
+		NotificationSinkBuilder: notification.NewSink,
+		MailSenderBuilder:       mailer.NewSender,
+		PagerDutyClientBuilder:  pagerduty.NewClient,
+		Scope:                   scope,
+		Standalone:              standaloneMode || managedClusterMode,
+		HTTP01Solver:            http01Solver,
+		Recorder:                recorder,
+		RateLimiter:             rateLimiter,
+	}
+	if auditMode {
+		blockExternalWrites(certificateRequestReconciler)
+	}
+	if err = certificateRequestReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "CertificateRequest")
 		os.Exit(1)
 	}
 
-	// Add ClusterDeployment controller to the manager
-	if err = (&clusterdeployment.ClusterDeploymentReconciler{
+	// Add the collector of the CertificateRequest metrics to the manager
+	if err = mgr.Add(&certificatemetrics.Collector{
+		Informers: mgr.GetCache(),
+		Reader:    mgr.GetCache(),
+		Scope:     scope,
+	}); err != nil {
+		setupLog.Error(err, "unable to add the CertificateRequest metrics collector")
+		os.Exit(1)
+	}
+
+	if standaloneMode {
+		// Add the controller of the local cluster's certificates to the manager
+		if err = (&standalone.StandaloneReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Standalone")
+			os.Exit(1)
+		}
+	} else if managedClusterMode {
+		// Add the controller of the certificates of the ManagedClusters to the manager
+		if err = (&managedcluster.ManagedClusterReconciler{
+			Client:      mgr.GetClient(),
+			Scheme:      mgr.GetScheme(),
+			RateLimiter: rateLimiter,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ManagedCluster")
+			os.Exit(1)
+		}
+	} else {
+		// Add ClusterDeployment controller to the manager
+		if err = (&clusterdeployment.ClusterDeploymentReconciler{
+			Client:      mgr.GetClient(),
+			Scheme:      mgr.GetScheme(),
+			Scope:       scope,
+			Recorder:    recorder,
+			RateLimiter: rateLimiter,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterDeployment")
+			os.Exit(1)
+		}
+
+		// Add the reaper of the CertificateRequests of force-deleted ClusterDeployments to the manager
+		if err = mgr.Add(&orphanreaper.Reaper{
+			Client:    mgr.GetClient(),
+			APIReader: mgr.GetAPIReader(),
+			Scope:     scope,
+		}); err != nil {
+			setupLog.Error(err, "unable to add orphaned CertificateRequest reaper")
+			os.Exit(1)
+		}
+
+		// Add IngressCertificateRollout controller to the manager
+		if err = (&ingresscertificaterollout.IngressCertificateRolloutReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "IngressCertificateRollout")
+			os.Exit(1)
+		}
+	}
+
+	// Add ACMEAccount controller to the manager
+	acmeAccountReconciler := &acmeaccount.ACMEAccountReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		LEClientBuilder: leclient.NewClientForAccount,
+	}
+	if auditMode {
+		acmeAccountReconciler.LEClientBuilder = func(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer) (leclient.LetsEncryptClientInterface, error) {
+			c, err := leclient.NewClientForAccount(ctx, kubeClient, issuer)
+			if err != nil {
+				return nil, err
+			}
+			return audit.IssuerClient(c), nil
+		}
+	}
+	if err = acmeAccountReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ACMEAccount")
+		os.Exit(1)
+	}
+
+	// Add CertificateInventory controller to the manager
+	if err = (&certificateinventory.CertificateInventoryReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "ClusterDeployment")
+		setupLog.Error(err, "unable to create controller", "controller", "CertificateInventory")
 		os.Exit(1)
 	}
 
+	// Add the reporter of the weekly rate-limit budget of the ACME accounts to the manager
+	if err = mgr.Add(&ratelimitbudget.Reporter{Client: mgr.GetClient()}); err != nil {
+		setupLog.Error(err, "unable to add rate-limit budget reporter")
+		os.Exit(1)
+	}
+
+	// Add GrafanaDashboard controller to the manager
+	if err = (&grafanadashboard.GrafanaDashboardReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GrafanaDashboard")
+		os.Exit(1)
+	}
+
+	// Add PrometheusRule controller to the manager
+	if err = (&prometheusrule.PrometheusRuleReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PrometheusRule")
+		os.Exit(1)
+	}
+
+	// Add TrustedCABundle controller to the manager
+	if err = (&trustedcabundle.TrustedCABundleReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TrustedCABundle")
+		os.Exit(1)
+	}
+
+	// Add the syncer of the metrics Service and ServiceMonitor to the manager
+	if metricsSecure || manageServiceMonitor {
+		if err = mgr.Add(&servicemonitor.Syncer{
+			Client:               mgr.GetClient(),
+			APIReader:            mgr.GetAPIReader(),
+			Secure:               metricsSecure,
+			ManageServiceMonitor: manageServiceMonitor,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the metrics Service and ServiceMonitor syncer")
+			os.Exit(1)
+		}
+	}
+
+	// Add the syncer of the FlowSchema and PriorityLevelConfiguration to the manager
+	if manageAPIPriority {
+		if err = mgr.Add(&apipriority.Syncer{
+			Client:                   mgr.GetClient(),
+			APIReader:                mgr.GetAPIReader(),
+			NominalConcurrencyShares: int32(apiPriorityShares),
+		}); err != nil {
+			setupLog.Error(err, "unable to add the FlowSchema and PriorityLevelConfiguration syncer")
+			os.Exit(1)
+		}
+	}
+
+	// Add the cert-manager migration to the manager
+	if migrateCertManager {
+		if err = mgr.Add(&certmanagermigration.Migrator{
+			Client:                   mgr.GetClient(),
+			APIReader:                mgr.GetAPIReader(),
+			ClusterResourceNamespace: certManagerClusterResourceNamespace,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the cert-manager migration")
+			os.Exit(1)
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -213,6 +609,24 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Go NotReady when certificates cannot be issued because the ACME server or the DNS providers are unreachable
+	healthChecker := &health.Checker{
+		Client:        mgr.GetClient(),
+		ClientBuilder: cClient.NewClient,
+	}
+	if err := mgr.Add(healthChecker); err != nil {
+		setupLog.Error(err, "unable to set up the ACME and DNS provider checks")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("acme", healthChecker.ACME); err != nil {
+		setupLog.Error(err, "unable to set up the ACME ready check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("dns", healthChecker.DNS); err != nil {
+		setupLog.Error(err, "unable to set up the DNS ready check")
+		os.Exit(1)
+	}
+
 	// Instantiate metricsServer object configured with variables defined in
 	// localmetrics package.
 	metricsServer := metrics.NewBuilder(operatorconfig.OperatorNamespace, operatorconfig.OperatorName).
@@ -223,7 +637,9 @@ func main() {
 		GetConfig()
 
 	// Get the namespace the operator is currently deployed in.
-	if _, err := k8sutil.GetOperatorNamespace(); err != nil {
+	if metricsSecure {
+		log.Info("Skipping CR metrics server creation; metrics are served over TLS.")
+	} else if _, err := k8sutil.GetOperatorNamespace(); err != nil {
 		if errors.Is(err, k8sutil.ErrRunLocal) {
 			log.Info("Skipping CR metrics server creation; not running in a cluster.")
 		} else {
@@ -246,4 +662,78 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	// Export the spans of the last reconciles
+	if err := shutdownTracing(context.TODO()); err != nil {
+		setupLog.Error(err, "failed to flush traces")
+	}
+}
+
+// blockExternalWrites wraps the clients built by the CertificateRequest reconciler so that their mutating calls are
+// blocked and counted in read-only audit mode. The issuer clients are wrapped by the reconciler itself.
+func blockExternalWrites(r *certificaterequest.CertificateRequestReconciler) {
+	dnsClientBuilder := r.ClientBuilder
+	r.ClientBuilder = func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error) {
+		c, err := dnsClientBuilder(ctx, reqLogger, kubeClient, platform, namespace, clusterDeploymentName)
+		if err != nil {
+			return nil, err
+		}
+		return audit.DNSClient(c), nil
+	}
+	kmsClientBuilder := r.KMSClientBuilder
+	r.KMSClientBuilder = func(ctx context.Context, kubeClient client.Client, kmsKey *certmanv1alpha1.KMSKey, namespace string) (kms.Client, error) {
+		c, err := kmsClientBuilder(ctx, kubeClient, kmsKey, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return audit.KMSClient(c), nil
+	}
+	keyVaultClientBuilder := r.KeyVaultClientBuilder
+	r.KeyVaultClientBuilder = func(ctx context.Context, kubeClient client.Client, secretName string, namespace string) (azure.KeyVaultClient, error) {
+		c, err := keyVaultClientBuilder(ctx, kubeClient, secretName, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return audit.KeyVaultClient(c), nil
+	}
+	secretSinkBuilder := r.SecretSinkBuilder
+	r.SecretSinkBuilder = func(ctx context.Context, kubeClient client.Client, sink *certmanv1alpha1.SecretSink, namespace string) (secretsink.Sink, error) {
+		s, err := secretSinkBuilder(ctx, kubeClient, sink, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return audit.SecretSink(s), nil
+	}
+	serviceLogClientBuilder := r.ServiceLogClientBuilder
+	r.ServiceLogClientBuilder = func(ctx context.Context, kubeClient client.Client) (servicelog.Client, error) {
+		c, err := serviceLogClientBuilder(ctx, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return audit.ServiceLogClient(c), nil
+	}
+	notificationSinkBuilder := r.NotificationSinkBuilder
+	r.NotificationSinkBuilder = func(ctx context.Context, kubeClient client.Client, format, payloadTemplate string) (notification.Sink, error) {
+		s, err := notificationSinkBuilder(ctx, kubeClient, format, payloadTemplate)
+		if err != nil {
+			return nil, err
+		}
+		return audit.NotificationSink(s), nil
+	}
+	mailSenderBuilder := r.MailSenderBuilder
+	r.MailSenderBuilder = func(ctx context.Context, kubeClient client.Client) (mailer.Sender, error) {
+		s, err := mailSenderBuilder(ctx, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return audit.MailSender(s), nil
+	}
+	pagerDutyClientBuilder := r.PagerDutyClientBuilder
+	r.PagerDutyClientBuilder = func(ctx context.Context, kubeClient client.Client) (pagerduty.Client, error) {
+		c, err := pagerDutyClientBuilder(ctx, kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		return audit.PagerDutyClient(c), nil
+	}
 }
@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit runs the operator in read-only audit mode, in which certificates are read and analysed, and metrics
+// and statuses computed, but every mutating call is blocked and counted. It lets a second, passive instance verify
+// the view of certificate health of the instance that manages them.
+package audit
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	// TargetKubernetes labels the blocked writes to the Kubernetes API, including events.
+	TargetKubernetes = "kubernetes"
+	// TargetACME labels the blocked calls to the ACME servers and the private certificate authorities.
+	TargetACME = "acme"
+	// TargetDNS labels the blocked changes to DNS records.
+	TargetDNS = "dns"
+	// TargetExternal labels the other blocked calls, to key management services, secret stores and notification
+	// services.
+	TargetExternal = "external"
+)
+
+// ErrBlocked is returned by the clients of external services for the mutating calls blocked in audit mode.
+var ErrBlocked = errors.New("blocked in read-only audit mode")
+
+var enabled atomic.Bool
+
+// Enable turns on read-only audit mode. It must be called before the manager and its clients are created.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled returns true if the operator runs in read-only audit mode.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Block counts a mutating call of operation to target blocked in audit mode and returns ErrBlocked.
+func Block(target, operation string) error {
+	localmetrics.ObserveAuditBlockedCall(target, operation)
+	return ErrBlocked
+}
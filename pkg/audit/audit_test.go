@@ -0,0 +1,134 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/leclient"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+func blocked(target, operation string) float64 {
+	return testutil.ToFloat64(localmetrics.MetricAuditBlockedCalls.WithLabelValues(target, operation))
+}
+
+func TestWrapClient(t *testing.T) {
+	localmetrics.MetricAuditBlockedCalls.Reset()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, certmanv1alpha1.AddToScheme(scheme))
+	existing := &certmanv1alpha1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Name: "cr", Namespace: "ns"}}
+	c := WrapClient(fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithStatusSubresource(existing).Build())
+	ctx := context.TODO()
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cert", Namespace: "ns"}}
+	assert.NoError(t, c.Create(ctx, secret))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})))
+	assert.Equal(t, 1.0, blocked(TargetKubernetes, "create Secret"))
+
+	cr := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(existing), cr))
+	cr.Status.Status = "Success"
+	assert.NoError(t, c.Status().Update(ctx, cr))
+	assert.NoError(t, c.Delete(ctx, cr))
+	assert.Equal(t, 1.0, blocked(TargetKubernetes, "update CertificateRequest/status"))
+	assert.Equal(t, 1.0, blocked(TargetKubernetes, "delete CertificateRequest"))
+
+	stored := &certmanv1alpha1.CertificateRequest{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(existing), stored))
+	assert.Empty(t, stored.Status.Status)
+}
+
+type fakeIssuer struct {
+	leclient.LetsEncryptClientInterface
+}
+
+func (fakeIssuer) GetOrderURL() string {
+	return "https://acme.example.com/order/1"
+}
+
+type fakePrivateCA struct {
+	fakeIssuer
+}
+
+func (fakePrivateCA) PrivateCA() string {
+	return "example-ca"
+}
+
+func TestIssuerClient(t *testing.T) {
+	localmetrics.MetricAuditBlockedCalls.Reset()
+
+	c := IssuerClient(fakeIssuer{})
+	assert.False(t, leclient.IsPrivateCAClient(c))
+	assert.Equal(t, "https://acme.example.com/order/1", c.GetOrderURL())
+	assert.ErrorIs(t, c.CreateOrder([]string{"example.com"}), ErrBlocked)
+	assert.ErrorIs(t, c.FinalizeOrder(nil), ErrBlocked)
+	assert.Equal(t, 1.0, blocked(TargetACME, "CreateOrder"))
+	assert.Equal(t, 1.0, blocked(TargetACME, "FinalizeOrder"))
+
+	ca := IssuerClient(fakePrivateCA{})
+	require.True(t, leclient.IsPrivateCAClient(ca))
+	assert.Equal(t, "example-ca", ca.(leclient.PrivateCAClient).PrivateCA())
+	assert.ErrorIs(t, ca.RevokeCertificate(nil), ErrBlocked)
+	assert.Equal(t, 1.0, blocked(TargetACME, "RevokeCertificate"))
+}
+
+type fakeBatchDNS struct {
+	cClient.Client
+}
+
+func (fakeBatchDNS) AnswerDNSChallenges(context.Context, logr.Logger, []cTypes.DNSChallenge, *certmanv1alpha1.CertificateRequest, string) ([]string, error) {
+	return []string{"_acme-challenge.example.com"}, nil
+}
+
+func TestDNSClient(t *testing.T) {
+	localmetrics.MetricAuditBlockedCalls.Reset()
+
+	_, ok := DNSClient(fakeBatchDNS{}.Client).(cClient.BatchClient)
+	assert.False(t, ok)
+
+	c := DNSClient(fakeBatchDNS{})
+	require.Implements(t, (*cClient.BatchClient)(nil), c)
+	_, err := c.(cClient.BatchClient).AnswerDNSChallenges(context.TODO(), logr.Discard(), nil, nil, "")
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.Equal(t, 1.0, blocked(TargetDNS, "AnswerDNSChallenges"))
+}
+
+func TestEnable(t *testing.T) {
+	t.Cleanup(func() { enabled.Store(false) })
+
+	assert.False(t, Enabled())
+	Enable()
+	assert.True(t, Enabled())
+}
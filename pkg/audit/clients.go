@@ -0,0 +1,216 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/go-logr/logr"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	"github.com/openshift/certman-operator/pkg/clients/azure"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/kms"
+	"github.com/openshift/certman-operator/pkg/leclient"
+	"github.com/openshift/certman-operator/pkg/mailer"
+	"github.com/openshift/certman-operator/pkg/notification"
+	"github.com/openshift/certman-operator/pkg/pagerduty"
+	"github.com/openshift/certman-operator/pkg/secretsink"
+	"github.com/openshift/certman-operator/pkg/servicelog"
+)
+
+// The wrappers below pass the reads of the clients through and block their mutating calls with ErrBlocked.
+
+// IssuerClient wraps the client of an ACME server or private certificate authority. Accounts are not updated and
+// certificates are neither ordered nor revoked. The wrapper of a PrivateCAClient is a PrivateCAClient.
+func IssuerClient(c leclient.LetsEncryptClientInterface) leclient.LetsEncryptClientInterface {
+	if ca, ok := c.(leclient.PrivateCAClient); ok {
+		return privateCAClient{issuerClient: issuerClient{ca}, ca: ca}
+	}
+	return issuerClient{c}
+}
+
+type issuerClient struct {
+	leclient.LetsEncryptClientInterface
+}
+
+func (issuerClient) UpdateAccount(...string) error {
+	return Block(TargetACME, "UpdateAccount")
+}
+
+func (issuerClient) CreateOrder([]string) error {
+	return Block(TargetACME, "CreateOrder")
+}
+
+func (issuerClient) UpdateChallenge() error {
+	return Block(TargetACME, "UpdateChallenge")
+}
+
+func (issuerClient) FinalizeOrder(*x509.CertificateRequest) error {
+	return Block(TargetACME, "FinalizeOrder")
+}
+
+func (issuerClient) RevokeCertificate(*x509.Certificate) error {
+	return Block(TargetACME, "RevokeCertificate")
+}
+
+func (issuerClient) RevokeCertificateWithReason(*x509.Certificate, int) error {
+	return Block(TargetACME, "RevokeCertificate")
+}
+
+type privateCAClient struct {
+	issuerClient
+	ca leclient.PrivateCAClient
+}
+
+func (c privateCAClient) PrivateCA() string {
+	return c.ca.PrivateCA()
+}
+
+// DNSClient wraps the client of a DNS provider. Challenge records are neither written nor deleted. The wrapper of a
+// BatchClient is a BatchClient.
+func DNSClient(c cClient.Client) cClient.Client {
+	if _, ok := c.(cClient.BatchClient); ok {
+		return dnsBatchClient{dnsClient{c}}
+	}
+	return dnsClient{c}
+}
+
+type dnsClient struct {
+	cClient.Client
+}
+
+func (dnsClient) AnswerDNSChallenge(context.Context, logr.Logger, string, string, *certmanv1alpha1.CertificateRequest, string) (string, error) {
+	return "", Block(TargetDNS, "AnswerDNSChallenge")
+}
+
+func (dnsClient) ValidateDNSWriteAccess(context.Context, logr.Logger, *certmanv1alpha1.CertificateRequest) (bool, error) {
+	return false, Block(TargetDNS, "ValidateDNSWriteAccess")
+}
+
+func (dnsClient) DeleteAcmeChallengeResourceRecords(context.Context, logr.Logger, *certmanv1alpha1.CertificateRequest) error {
+	return Block(TargetDNS, "DeleteAcmeChallengeResourceRecords")
+}
+
+type dnsBatchClient struct {
+	dnsClient
+}
+
+func (dnsBatchClient) AnswerDNSChallenges(context.Context, logr.Logger, []cTypes.DNSChallenge, *certmanv1alpha1.CertificateRequest, string) ([]string, error) {
+	return nil, Block(TargetDNS, "AnswerDNSChallenges")
+}
+
+// KMSClient wraps the client of a key management service. Keys are neither created nor scheduled for deletion.
+func KMSClient(c kms.Client) kms.Client {
+	return kmsClient{c}
+}
+
+type kmsClient struct {
+	kms.Client
+}
+
+func (kmsClient) CreateKey(*certmanv1alpha1.CertificateRequest, int) (string, error) {
+	return "", Block(TargetExternal, "kms.CreateKey")
+}
+
+func (kmsClient) ScheduleKeyDeletion(string) error {
+	return Block(TargetExternal, "kms.ScheduleKeyDeletion")
+}
+
+// KeyVaultClient wraps the client of the Azure Key Vaults. Certificates are not imported.
+func KeyVaultClient(c azure.KeyVaultClient) azure.KeyVaultClient {
+	return keyVaultClient{c}
+}
+
+type keyVaultClient struct {
+	azure.KeyVaultClient
+}
+
+func (keyVaultClient) ImportCertificate(context.Context, string, string, []byte, string) (string, error) {
+	return "", Block(TargetExternal, "keyvault.ImportCertificate")
+}
+
+// SecretSink wraps the sink of an external secret store. Bundles are not pushed.
+func SecretSink(s secretsink.Sink) secretsink.Sink {
+	return secretSink{s}
+}
+
+type secretSink struct {
+	secretsink.Sink
+}
+
+func (secretSink) Push(context.Context, *secretsink.Bundle) (string, error) {
+	return "", Block(TargetExternal, "secretsink.Push")
+}
+
+// ServiceLogClient wraps the client of the service logs. Service logs are not posted.
+func ServiceLogClient(c servicelog.Client) servicelog.Client {
+	return serviceLogClient{c}
+}
+
+type serviceLogClient struct {
+	servicelog.Client
+}
+
+func (serviceLogClient) Post(context.Context, servicelog.Log) error {
+	return Block(TargetExternal, "servicelog.Post")
+}
+
+// NotificationSink wraps the sink of the notifications. Notifications are not sent.
+func NotificationSink(s notification.Sink) notification.Sink {
+	return notificationSink{s}
+}
+
+type notificationSink struct {
+	notification.Sink
+}
+
+func (notificationSink) Send(context.Context, notification.Event) error {
+	return Block(TargetExternal, "notification.Send")
+}
+
+// MailSender wraps the sender of the emails. Emails are not sent.
+func MailSender(s mailer.Sender) mailer.Sender {
+	return mailSender{s}
+}
+
+type mailSender struct {
+	mailer.Sender
+}
+
+func (mailSender) Send(context.Context, mailer.Message) error {
+	return Block(TargetExternal, "mailer.Send")
+}
+
+// PagerDutyClient wraps the client of PagerDuty. Incidents are neither triggered nor resolved.
+func PagerDutyClient(c pagerduty.Client) pagerduty.Client {
+	return pagerDutyClient{c}
+}
+
+type pagerDutyClient struct {
+	pagerduty.Client
+}
+
+func (pagerDutyClient) Trigger(context.Context, pagerduty.Incident) error {
+	return Block(TargetExternal, "pagerduty.Trigger")
+}
+
+func (pagerDutyClient) Resolve(context.Context, string) error {
+	return Block(TargetExternal, "pagerduty.Resolve")
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certpolicy checks CertificateRequests against the CertificatePolicies of the cluster, and enforces them in
+// the validating webhook of CertificateRequests.
+package certpolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const wildcardPrefix = "*."
+
+// Violations returns the constraints of policy that spec does not conform to, or nil if it conforms.
+func Violations(policy *certmanv1alpha1.CertificatePolicy, spec *certmanv1alpha1.CertificateRequestSpec) []string {
+	var violations []string
+
+	if maxDNSNames := policy.Spec.MaxDNSNames; maxDNSNames != nil && len(spec.DnsNames) > int(*maxDNSNames) {
+		violations = append(violations, fmt.Sprintf("%d dnsNames exceed the maximum of %d", len(spec.DnsNames), *maxDNSNames))
+	}
+
+	for _, dnsName := range spec.DnsNames {
+		name := strings.ToLower(strings.TrimSuffix(dnsName, "."))
+		if strings.HasPrefix(name, wildcardPrefix) {
+			if policy.Spec.AllowWildcards != nil && !*policy.Spec.AllowWildcards {
+				violations = append(violations, fmt.Sprintf("wildcard dnsName %q is not allowed", dnsName))
+			}
+			name = strings.TrimPrefix(name, wildcardPrefix)
+		}
+		if len(policy.Spec.AllowedDomains) > 0 && !inDomains(name, policy.Spec.AllowedDomains) {
+			violations = append(violations, fmt.Sprintf("dnsName %q is not in the allowed domains %s", dnsName, strings.Join(policy.Spec.AllowedDomains, ", ")))
+		}
+	}
+
+	keyAlgorithm := spec.KeyAlgorithm
+	if keyAlgorithm == "" {
+		keyAlgorithm = certmanv1alpha1.RSAKeyAlgorithm
+	}
+	if len(policy.Spec.AllowedKeyAlgorithms) > 0 && !slices.Contains(policy.Spec.AllowedKeyAlgorithms, keyAlgorithm) {
+		violations = append(violations, fmt.Sprintf("keyAlgorithm %s is not allowed", keyAlgorithm))
+	}
+
+	return violations
+}
+
+// inDomains returns true if name is one of domains or a subdomain of one.
+func inDomains(name string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the violations of spec of every CertificatePolicy of the cluster, each prefixed with the name of its
+// policy. Clusters without the CertificatePolicy CRD have no policies.
+func Check(ctx context.Context, kubeClient client.Reader, spec *certmanv1alpha1.CertificateRequestSpec) ([]string, error) {
+	policies := &certmanv1alpha1.CertificatePolicyList{}
+	if err := kubeClient.List(ctx, policies); err != nil {
+		if meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list CertificatePolicies: %w", err)
+	}
+
+	var violations []string
+	for i := range policies.Items {
+		for _, violation := range Violations(&policies.Items[i], spec) {
+			violations = append(violations, fmt.Sprintf("CertificatePolicy %s: %s", policies.Items[i].Name, violation))
+		}
+	}
+	return violations, nil
+}
+
+// Validator rejects CertificateRequests that do not conform to the CertificatePolicies of the cluster. Updates that
+// leave the spec unchanged are allowed, so that the finalizers and metadata of CertificateRequests created before a
+// policy can still be updated.
+type Validator struct {
+	Client client.Reader
+}
+
+var _ admission.CustomValidator = &Validator{}
+
+// SetupWebhookWithManager registers the validating webhook of CertificateRequests with the webhook server of mgr.
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&certmanv1alpha1.CertificateRequest{}).WithValidator(v).Complete()
+}
+
+// ValidateCreate checks a new CertificateRequest against the CertificatePolicies.
+func (v *Validator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	cr, ok := obj.(*certmanv1alpha1.CertificateRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a CertificateRequest, got %T", obj)
+	}
+	return nil, v.validate(ctx, cr)
+}
+
+// ValidateUpdate checks a changed spec of a CertificateRequest against the CertificatePolicies.
+func (v *Validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldCR, ok := oldObj.(*certmanv1alpha1.CertificateRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a CertificateRequest, got %T", oldObj)
+	}
+	newCR, ok := newObj.(*certmanv1alpha1.CertificateRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a CertificateRequest, got %T", newObj)
+	}
+	if equality.Semantic.DeepEqual(oldCR.Spec, newCR.Spec) {
+		return nil, nil
+	}
+	return nil, v.validate(ctx, newCR)
+}
+
+// ValidateDelete allows every deletion.
+func (v *Validator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *Validator) validate(ctx context.Context, cr *certmanv1alpha1.CertificateRequest) error {
+	violations, err := Check(ctx, v.Client, &cr.Spec)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("CertificateRequest %s/%s violates the certificate policies: %s", cr.Namespace, cr.Name, strings.Join(violations, "; "))
+	}
+	return nil
+}
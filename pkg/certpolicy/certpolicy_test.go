@@ -0,0 +1,150 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func newPolicy(name string, spec certmanv1alpha1.CertificatePolicySpec) *certmanv1alpha1.CertificatePolicy {
+	return &certmanv1alpha1.CertificatePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+}
+
+func TestViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   certmanv1alpha1.CertificatePolicySpec
+		spec     certmanv1alpha1.CertificateRequestSpec
+		expected []string
+	}{
+		{
+			name:   "an empty policy allows anything",
+			policy: certmanv1alpha1.CertificatePolicySpec{},
+			spec:   certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"*.apps.example.com", "api.other.org"}, KeyAlgorithm: certmanv1alpha1.ECDSAKeyAlgorithm},
+		},
+		{
+			name:   "names in and under the allowed domains conform",
+			policy: certmanv1alpha1.CertificatePolicySpec{AllowedDomains: []string{"example.com"}},
+			spec:   certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"example.com", "API.cluster.example.com.", "*.apps.example.com"}},
+		},
+		{
+			name:     "names outside the allowed domains violate it",
+			policy:   certmanv1alpha1.CertificatePolicySpec{AllowedDomains: []string{"example.com", "example.net"}},
+			spec:     certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com", "notexample.com", "*.example.org"}},
+			expected: []string{`dnsName "notexample.com" is not in the allowed domains example.com, example.net`, `dnsName "*.example.org" is not in the allowed domains example.com, example.net`},
+		},
+		{
+			name:     "too many names violate it",
+			policy:   certmanv1alpha1.CertificatePolicySpec{MaxDNSNames: int32Ptr(1)},
+			spec:     certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com", "*.apps.example.com"}},
+			expected: []string{"2 dnsNames exceed the maximum of 1"},
+		},
+		{
+			name:     "wildcards violate it when they are not allowed",
+			policy:   certmanv1alpha1.CertificatePolicySpec{AllowWildcards: boolPtr(false)},
+			spec:     certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com", "*.apps.example.com"}},
+			expected: []string{`wildcard dnsName "*.apps.example.com" is not allowed`},
+		},
+		{
+			name:   "wildcards conform when they are allowed",
+			policy: certmanv1alpha1.CertificatePolicySpec{AllowWildcards: boolPtr(true)},
+			spec:   certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"*.apps.example.com"}},
+		},
+		{
+			name:   "an unset key algorithm is RSA",
+			policy: certmanv1alpha1.CertificatePolicySpec{AllowedKeyAlgorithms: []certmanv1alpha1.KeyAlgorithm{certmanv1alpha1.RSAKeyAlgorithm}},
+			spec:   certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com"}},
+		},
+		{
+			name:     "a key algorithm that is not allowed violates it",
+			policy:   certmanv1alpha1.CertificatePolicySpec{AllowedKeyAlgorithms: []certmanv1alpha1.KeyAlgorithm{certmanv1alpha1.ECDSAKeyAlgorithm}},
+			spec:     certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com"}},
+			expected: []string{"keyAlgorithm RSA is not allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Violations(newPolicy("policy", tt.policy), &tt.spec))
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+
+	policy := newPolicy("no-wildcards", certmanv1alpha1.CertificatePolicySpec{AllowWildcards: boolPtr(false)})
+	conforming := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "conforming", Namespace: "ns"},
+		Spec:       certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"api.example.com"}},
+	}
+	violating := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "violating", Namespace: "ns"},
+		Spec:       certmanv1alpha1.CertificateRequestSpec{DnsNames: []string{"*.apps.example.com"}},
+	}
+
+	ctx := context.Background()
+	validator := &Validator{Client: fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(policy).Build()}
+
+	t.Run("allows conforming CertificateRequests", func(t *testing.T) {
+		_, err := validator.ValidateCreate(ctx, conforming)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects violating CertificateRequests", func(t *testing.T) {
+		_, err := validator.ValidateCreate(ctx, violating)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `CertificatePolicy no-wildcards: wildcard dnsName "*.apps.example.com" is not allowed`)
+
+		_, err = validator.ValidateUpdate(ctx, conforming, violating)
+		assert.Error(t, err)
+	})
+
+	t.Run("allows updates that leave a violating spec unchanged", func(t *testing.T) {
+		updated := violating.DeepCopy()
+		updated.Finalizers = nil
+		_, err := validator.ValidateUpdate(ctx, violating, updated)
+		assert.NoError(t, err)
+	})
+
+	t.Run("allows anything without the CertificatePolicy type", func(t *testing.T) {
+		validator := &Validator{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+		_, err := validator.ValidateCreate(ctx, violating)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmedns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/clients/guardrail"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	accountsKey  = "acmedns.json" //#nosec - G101: Potential hardcoded credentials
+	providerName = "acme-dns"
+)
+
+// acmeDNSClient implements the Client interface
+type acmeDNSClient struct {
+	httpClient *http.Client
+	host       string
+	accounts   map[string]account
+}
+
+// account is the account of a domain registered with the acme-dns server, as returned by its register endpoint.
+type account struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	FullDomain string `json:"fulldomain"`
+	SubDomain  string `json:"subdomain"`
+}
+
+// updateRequest is the body of the update endpoint of the acme-dns server.
+type updateRequest struct {
+	SubDomain string `json:"subdomain"`
+	TXT       string `json:"txt"`
+}
+
+func (c *acmeDNSClient) GetDNSName() string {
+	return "acme-dns"
+}
+
+// ListZones returns no zones: the acme-dns server answers the challenges of the accounts it registered, not of the
+// zones of the domains, so the challenges keep being answered in the ACME DNS domain.
+func (c *acmeDNSClient) ListZones(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+func (c *acmeDNSClient) GetFedrampHostedZoneIDPath(_ context.Context, _ string) (string, error) {
+	return "", fmt.Errorf("fedRamp is not supported by acme-dns")
+}
+
+// AnswerDNSChallenge sets the TXT record of the account of the domain to the challenge token. The _acme-challenge
+// record of the domain is a CNAME of the full domain of the account, which is returned as the record to verify.
+func (c *acmeDNSClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
+	acc, err := c.account(domain)
+	if err != nil {
+		return "", err
+	}
+
+	// the record updated is that of the account, but it answers the challenge of the delegated _acme-challenge record
+	// of the domain, which must be inside the zone like the records written by the other providers
+	challenge := fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, normalizeDomain(domain))
+	if err := guardrail.CheckRecord(providerName, "update", challenge, "TXT", utils.ACMEDNSZone(cr)); err != nil {
+		return "", err
+	}
+
+	fqdn := strings.TrimSuffix(acc.FullDomain, ".")
+	reqLogger.Info("answering the acme challenge", "domain", domain, "fqdn", fqdn)
+
+	err = c.do(ctx, "update", http.MethodPost, "/update", &acc, updateRequest{SubDomain: acc.SubDomain, TXT: acmeChallengeToken})
+	if err != nil {
+		return "", err
+	}
+	return fqdn, nil
+}
+
+// ValidateDNSWriteAccess checks that the acme-dns server is healthy. Its accounts can only update their own records,
+// so no test record is written; a domain without an account fails once its challenge is answered.
+func (c *acmeDNSClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+	if err := c.do(ctx, "health", http.MethodGet, "/health", nil, nil); err != nil {
+		reqLogger.Error(err, "acme-dns server is unhealthy", "host", c.host)
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteAcmeChallengeResourceRecords does nothing: acme-dns cannot delete records, and only keeps the two latest
+// TXT records of each account, which are replaced by the next challenges.
+func (c *acmeDNSClient) DeleteAcmeChallengeResourceRecords(_ context.Context, _ logr.Logger, _ *certmanv1alpha1.CertificateRequest) error {
+	return nil
+}
+
+// NewClient returns an acme-dns client of the server at host using the accounts of the secret.
+func NewClient(ctx context.Context, kubeClient client.Client, host, secretName, namespace string) (*acmeDNSClient, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[accountsKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: secret %s/%s has no %s", cTypes.ErrInvalidCredentials, namespace, secretName, accountsKey)
+	}
+	registered := map[string]account{}
+	if err := json.Unmarshal(data, &registered); err != nil {
+		return nil, fmt.Errorf("%w: secret %s/%s has invalid %s: %w", cTypes.ErrInvalidCredentials, namespace, secretName, accountsKey, err)
+	}
+
+	accounts := map[string]account{}
+	for domain, acc := range registered {
+		accounts[normalizeDomain(domain)] = acc
+	}
+
+	return &acmeDNSClient{
+		httpClient: http.DefaultClient,
+		host:       strings.TrimSuffix(host, "/"),
+		accounts:   accounts,
+	}, nil
+}
+
+// account returns the account of the domain, which is shared by the domain and its wildcard.
+func (c *acmeDNSClient) account(domain string) (account, error) {
+	acc, ok := c.accounts[normalizeDomain(domain)]
+	if !ok {
+		return account{}, fmt.Errorf("%w: no acme-dns account is registered for %s", cTypes.ErrInvalidCredentials, domain)
+	}
+	if acc.Username == "" || acc.Password == "" || acc.SubDomain == "" || acc.FullDomain == "" {
+		return account{}, fmt.Errorf("%w: acme-dns account of %s is incomplete", cTypes.ErrInvalidCredentials, domain)
+	}
+	return acc, nil
+}
+
+// normalizeDomain lowercases the domain and removes its wildcard label and trailing dot.
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(domain, "*."), "."))
+}
+
+// do sends a request to the acme-dns server, authenticated as acc if set, counting it in the DNS API metrics.
+func (c *acmeDNSClient) do(ctx context.Context, operation, method, path string, acc *account, body interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, reader)
+	if err != nil {
+		return err
+	}
+	if acc != nil {
+		req.Header.Set("X-Api-User", acc.Username)
+		req.Header.Set("X-Api-Key", acc.Password)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		localmetrics.ObserveDNSAPIRequest(providerName, operation, "error", false)
+		return err
+	}
+	defer resp.Body.Close()
+
+	code := ""
+	if resp.StatusCode >= http.StatusBadRequest {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	localmetrics.ObserveDNSAPIRequest(providerName, operation, code, resp.StatusCode == http.StatusTooManyRequests)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		// errors are returned as {"error": "..."}
+		apiErr := struct {
+			Error string `json:"error"`
+		}{}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		err := fmt.Errorf("acme-dns %s returned %s: %s", operation, resp.Status, apiErr.Error)
+		if resp.StatusCode == http.StatusUnauthorized {
+			// the account does not exist, or does not own the subdomain
+			return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+		}
+		return err
+	}
+	return nil
+}
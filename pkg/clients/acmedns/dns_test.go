@@ -0,0 +1,163 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmedns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+var (
+	testAccount = account{
+		Username:   "c36f50e8-4632-44f0-83fe-e070fef28a10",
+		Password:   "htB9mR9DYgcu9bX_afHF62erXaH2TS7bg9KW3F7Z",
+		FullDomain: "d420c923-bbd7-4056-ab64-c3ca54c9b3cf.auth.example.org",
+		SubDomain:  "d420c923-bbd7-4056-ab64-c3ca54c9b3cf",
+	}
+
+	testCertRequest = &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr", Namespace: "ns"},
+		Spec:       certmanv1alpha1.CertificateRequestSpec{ACMEDNSDomain: "example.com"},
+	}
+)
+
+// fakeServer serves the update and health endpoints of an acme-dns server with testAccount registered.
+type fakeServer struct {
+	txt     []string
+	healthy bool
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/health" && r.Method == http.MethodGet:
+		if !f.healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	case r.URL.Path == "/update" && r.Method == http.MethodPost:
+		if r.Header.Get("X-Api-User") != testAccount.Username || r.Header.Get("X-Api-Key") != testAccount.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "forbidden"})
+			return
+		}
+		update := updateRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		if update.SubDomain != testAccount.SubDomain {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "forbidden"})
+			return
+		}
+		f.txt = append(f.txt, update.TXT)
+		_ = json.NewEncoder(w).Encode(map[string]string{"txt": update.TXT})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, server *fakeServer, accounts map[string]account) *acmeDNSClient {
+	s := httptest.NewServer(server)
+	t.Cleanup(s.Close)
+	return &acmeDNSClient{httpClient: s.Client(), host: s.URL, accounts: accounts}
+}
+
+func TestNewClient(t *testing.T) {
+	accounts, err := json.Marshal(map[string]account{"Example.com.": testAccount})
+	require.NoError(t, err)
+	kubeClient := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "acme-dns", Namespace: "ns"}, Data: map[string][]byte{accountsKey: accounts}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "ns"}, Data: map[string][]byte{accountsKey: []byte("{")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "ns"}},
+	).Build()
+
+	c, err := NewClient(context.TODO(), kubeClient, "https://auth.example.org/", "acme-dns", "ns")
+	require.NoError(t, err)
+	assert.Equal(t, "https://auth.example.org", c.host)
+	assert.Equal(t, map[string]account{"example.com": testAccount}, c.accounts)
+
+	for _, name := range []string{"invalid", "empty", "missing"} {
+		_, err = NewClient(context.TODO(), kubeClient, "https://auth.example.org", name, "ns")
+		assert.Error(t, err, name)
+	}
+	_, err = NewClient(context.TODO(), kubeClient, "https://auth.example.org", "empty", "ns")
+	assert.ErrorIs(t, err, cTypes.ErrInvalidCredentials)
+}
+
+func TestAnswerDNSChallenge(t *testing.T) {
+	server := &fakeServer{}
+	c := newTestClient(t, server, map[string]account{
+		"example.com":     testAccount,
+		"api.example.com": {Username: "u", Password: "p", SubDomain: "s"},
+	})
+
+	// the domain and its wildcard share the account
+	for _, domain := range []string{"example.com", "*.example.com"} {
+		fqdn, err := c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge-"+domain, domain, testCertRequest, "")
+		require.NoError(t, err)
+		assert.Equal(t, testAccount.FullDomain, fqdn)
+	}
+	assert.Equal(t, []string{"challenge-example.com", "challenge-*.example.com"}, server.txt)
+
+	_, err := c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "www.example.com", testCertRequest, "")
+	assert.ErrorContains(t, err, "no acme-dns account is registered for www.example.com")
+
+	_, err = c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "api.example.com", testCertRequest, "")
+	assert.ErrorContains(t, err, "acme-dns account of api.example.com is incomplete")
+
+	c.accounts["example.org"] = testAccount
+	_, err = c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "example.org", testCertRequest, "")
+	assert.ErrorIs(t, err, cTypes.ErrRecordRefused)
+
+	c.accounts["example.com"] = account{Username: "u", Password: "p", SubDomain: testAccount.SubDomain, FullDomain: testAccount.FullDomain}
+	_, err = c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "example.com", testCertRequest, "")
+	assert.ErrorContains(t, err, "acme-dns update returned 401 Unauthorized: forbidden")
+	assert.ErrorIs(t, err, cTypes.ErrInvalidCredentials)
+}
+
+func TestValidateDNSWriteAccess(t *testing.T) {
+	ok, err := newTestClient(t, &fakeServer{healthy: true}, nil).ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = newTestClient(t, &fakeServer{}, nil).ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestObserveRequests(t *testing.T) {
+	localmetrics.MetricDNSAPIRequests.Reset()
+	localmetrics.MetricDNSAPIErrors.Reset()
+
+	_, _ = newTestClient(t, &fakeServer{healthy: true}, nil).ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+	_, _ = newTestClient(t, &fakeServer{}, nil).ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(localmetrics.MetricDNSAPIRequests.WithLabelValues("acme-dns", "health")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("acme-dns", "health", "500")))
+}
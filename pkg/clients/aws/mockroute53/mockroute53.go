@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 )
@@ -14,6 +15,10 @@ import (
 type MockRoute53Client struct {
 	route53iface.Route53API
 	ZoneCount int
+	// ChangeBatches records the change batches of the ChangeResourceRecordSets calls
+	ChangeBatches []*route53.ChangeBatch
+	// WaitedChangeIDs records the IDs of the changes waited for
+	WaitedChangeIDs []string
 }
 
 func (m *MockRoute53Client) GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (string, error) {
@@ -104,6 +109,7 @@ func (c *MockRoute53Client) GetHostedZone(input *route53.GetHostedZoneInput) (ou
 }
 
 func (c *MockRoute53Client) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (output *route53.ChangeResourceRecordSetsOutput, err error) {
+	c.ChangeBatches = append(c.ChangeBatches, input.ChangeBatch)
 	output = &route53.ChangeResourceRecordSetsOutput{
 		ChangeInfo: &route53.ChangeInfo{
 			Id:          aws.String("mockchangeid"),
@@ -120,7 +126,11 @@ func (c *MockRoute53Client) ListResourceRecordSets(input *route53.ListResourceRe
 			{},
 		},
 	}
+	// the listed record is the one the listing starts from
 	output.ResourceRecordSets[0].Name = aws.String("_acme-challenge.api.gibberish.goes.here.")
+	if input.StartRecordName != nil {
+		output.ResourceRecordSets[0].Name = aws.String(strings.TrimSuffix(*input.StartRecordName, ".") + ".")
+	}
 	output.ResourceRecordSets[0].Type = aws.String(route53.RRTypeTxt)
 
 	for i := 0; i < c.ZoneCount; i++ {
@@ -131,3 +141,24 @@ func (c *MockRoute53Client) ListResourceRecordSets(input *route53.ListResourceRe
 
 	return
 }
+
+func (c *MockRoute53Client) ListHostedZonesWithContext(_ aws.Context, input *route53.ListHostedZonesInput, _ ...request.Option) (*route53.ListHostedZonesOutput, error) {
+	return c.ListHostedZones(input)
+}
+
+func (c *MockRoute53Client) GetHostedZoneWithContext(_ aws.Context, input *route53.GetHostedZoneInput, _ ...request.Option) (*route53.GetHostedZoneOutput, error) {
+	return c.GetHostedZone(input)
+}
+
+func (c *MockRoute53Client) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	return c.ChangeResourceRecordSets(input)
+}
+
+func (c *MockRoute53Client) ListResourceRecordSetsWithContext(_ aws.Context, input *route53.ListResourceRecordSetsInput, _ ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
+	return c.ListResourceRecordSets(input)
+}
+
+func (c *MockRoute53Client) WaitUntilResourceRecordSetsChangedWithContext(_ aws.Context, input *route53.GetChangeInput, _ ...request.WaiterOption) error {
+	c.WaitedChangeIDs = append(c.WaitedChangeIDs, aws.StringValue(input.Id))
+	return nil
+}
@@ -18,8 +18,10 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,6 +29,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsclient "github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
@@ -39,13 +43,19 @@ import (
 	aaov1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/clients/guardrail"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/clients/zonecache"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 )
 
 const (
 	awsCredsSecretIDKey         = "aws_access_key_id"
 	awsCredsSecretAccessKey     = "aws_secret_access_key" //#nosec - G101: Potential hardcoded credentials
+	awsCredsSecretSessionToken  = "aws_session_token"     //#nosec - G101: Potential hardcoded credentials
 	awsCredsSecretName          = "certman-operator-aws-credentials"
 	fedrampEnvVariable          = "FEDRAMP"
 	fedrampHostedZoneIDVariable = "HOSTED_ZONE_ID"
@@ -56,33 +66,55 @@ const (
 	retryerMinThrottleDelaySec  = 1
 	assumeRolePollingRetries    = 100
 	assumeRolePollingDelayMilli = 500
+	changeWaitDelaySec          = 5
 	clusterDeploymentSTSLabel   = "api.openshift.com/sts"
+	providerName                = "aws"
 	configMapSTSJumpRoleField   = "sts-jump-role"
+	roleSessionName             = "certman-operator"
 )
 
 var fedramp = os.Getenv(fedrampEnvVariable) == "true"
 var fedrampHostedZoneID = os.Getenv(fedrampHostedZoneIDVariable)
 
+// errNoPublicHostedZone is wrapped by the errors of the lookups of domains without a public hosted zone.
+var errNoPublicHostedZone = errors.New("no public hosted zone")
+
 // awsClient implements the Client interface
 type awsClient struct {
 	client route53iface.Route53API
+	// zones caches the hosted zones of the domains for credentials, the fingerprint of those of the client
+	zones       *zonecache.Cache
+	credentials string
 }
 
 func (c *awsClient) GetDNSName() string {
 	return "Route53"
 }
 
-func (c *awsClient) GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (string, error) {
-	zone, err := c.client.GetHostedZone(&route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
+// ListZones returns the names of the hosted zones the credentials can read.
+func (c *awsClient) ListZones(ctx context.Context) ([]string, error) {
+	hostedZones, err := listAllHostedZones(ctx, c.client, &route53.ListHostedZonesInput{})
+	if err != nil {
+		return nil, err
+	}
+	zones := []string{}
+	for _, hostedZone := range hostedZones {
+		zones = append(zones, aws.StringValue(hostedZone.Name))
+	}
+	return zones, nil
+}
+
+func (c *awsClient) GetFedrampHostedZoneIDPath(ctx context.Context, fedrampHostedZoneID string) (string, error) {
+	zone, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
 	if err != nil {
 		return "", err
 	}
 	return *zone.HostedZone.Id, nil
 }
 
-func (c *awsClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+func (c *awsClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
 	fqdn = fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, domain)
-	reqLogger.Info(fmt.Sprintf("fqdn acme challenge domain is %v", fqdn))
+	reqLogger.Info("answering the acme challenge", "fqdn", fqdn)
 
 	input := &route53.ChangeResourceRecordSetsInput{
 		ChangeBatch: &route53.ChangeBatch{
@@ -105,29 +137,158 @@ func (c *awsClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken
 		},
 	}
 
+	// Without the hosted zone of a Hive DNSZone, such as when Route53 is an overridden DNS provider, the public
+	// hosted zone of the domain of the CertificateRequest is used.
+	if dnsZone == "" {
+		dnsZone, err = c.publicHostedZoneID(ctx, utils.ACMEDNSZone(cr))
+		if err != nil {
+			return "", err
+		}
+	}
+
 	input.HostedZoneId = &dnsZone
-	result, err := c.client.ChangeResourceRecordSets(input)
+	result, err := c.changeResourceRecordSets(ctx, input, utils.ACMEDNSZone(cr))
 	if err != nil {
 		reqLogger.Error(err, result.GoString(), "fqdn", fqdn)
 		return "", err
 	}
-	reqLogger.Info(fmt.Sprintf("updating hosted zone %v", input.HostedZoneId))
+	reqLogger.Info("updated hosted zone", "hostedZoneID", aws.StringValue(input.HostedZoneId))
 	return fqdn, nil
 }
 
+// changeResourceRecordSets submits the changes of input once the guardrail allowed every record they change in zone.
+// AnswerDNSChallenges answers the challenges in a single ChangeBatch, upserting one TXT record per fqdn with the key
+// authorizations of its challenges, and waits for the change to be in sync on the name servers of the hosted zone.
+// Multi-SAN orders thus make one ChangeResourceRecordSets call instead of one per domain.
+func (c *awsClient) AnswerDNSChallenges(ctx context.Context, reqLogger logr.Logger, challenges []cTypes.DNSChallenge, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdns []string, err error) {
+	if len(challenges) == 0 {
+		return nil, nil
+	}
+
+	// the challenges of a domain and its wildcard share a record, and a ChangeBatch may only change a record once
+	var changes []*route53.Change
+	records := map[string]*route53.ResourceRecordSet{}
+	for _, challenge := range challenges {
+		fqdn := fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, challenge.Domain)
+		fqdns = append(fqdns, fqdn)
+
+		rrs, ok := records[fqdn]
+		if !ok {
+			rrs = &route53.ResourceRecordSet{
+				Name: aws.String(fqdn),
+				TTL:  aws.Int64(resourceRecordTTL),
+				Type: aws.String(route53.RRTypeTxt),
+			}
+			records[fqdn] = rrs
+			changes = append(changes, &route53.Change{
+				Action:            aws.String(route53.ChangeActionUpsert),
+				ResourceRecordSet: rrs,
+			})
+		}
+		rrs.ResourceRecords = append(rrs.ResourceRecords, &route53.ResourceRecord{
+			Value: aws.String(fmt.Sprintf("\"%s\"", challenge.KeyAuthorization)),
+		})
+	}
+	reqLogger.Info("answering the acme challenges", "fqdns", fqdns)
+
+	if dnsZone == "" {
+		dnsZone, err = c.publicHostedZoneID(ctx, utils.ACMEDNSZone(cr))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	input := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: changes,
+			Comment: aws.String(""),
+		},
+		HostedZoneId: &dnsZone,
+	}
+	result, err := c.changeResourceRecordSets(ctx, input, utils.ACMEDNSZone(cr))
+	if err != nil {
+		reqLogger.Error(err, result.GoString(), "fqdns", fqdns)
+		return nil, err
+	}
+
+	changeID := aws.StringValue(result.ChangeInfo.Id)
+	reqLogger.Info("updated hosted zone, waiting for the change to be in sync", "hostedZoneID", dnsZone, "changeID", changeID)
+	err = c.client.WaitUntilResourceRecordSetsChangedWithContext(ctx, &route53.GetChangeInput{Id: result.ChangeInfo.Id},
+		request.WithWaiterDelay(request.ConstantWaiterDelay(changeWaitDelaySec*time.Second)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for change %s of hosted zone %s: %w", changeID, dnsZone, err)
+	}
+	return fqdns, nil
+}
+
+func (c *awsClient) changeResourceRecordSets(ctx context.Context, input *route53.ChangeResourceRecordSetsInput, zone string) (*route53.ChangeResourceRecordSetsOutput, error) {
+	for _, change := range input.ChangeBatch.Changes {
+		rrs := change.ResourceRecordSet
+		if err := guardrail.CheckRecord(providerName, "ChangeResourceRecordSets", aws.StringValue(rrs.Name), aws.StringValue(rrs.Type), zone); err != nil {
+			// like the SDK, return an output even on error, as its callers log it
+			return &route53.ChangeResourceRecordSetsOutput{}, err
+		}
+	}
+	output, err := c.client.ChangeResourceRecordSetsWithContext(ctx, input)
+	c.invalidateZone(zone, err)
+	return output, err
+}
+
+// publicHostedZoneID returns the ID of the public hosted zone of baseDomain, which is cached for the credentials of
+// the client.
+func (c *awsClient) publicHostedZoneID(ctx context.Context, baseDomain string) (string, error) {
+	return c.zones.Lookup(ctx, providerName, c.credentials, baseDomain, func(ctx context.Context) (string, error) {
+		hostedZones, err := listAllHostedZones(ctx, c.client, &route53.ListHostedZonesInput{})
+		if err != nil {
+			return "", err
+		}
+
+		if !strings.HasSuffix(baseDomain, ".") {
+			baseDomain = baseDomain + "."
+		}
+
+		for _, hostedZone := range hostedZones {
+			if strings.EqualFold(baseDomain, aws.StringValue(hostedZone.Name)) && (hostedZone.Config == nil || !aws.BoolValue(hostedZone.Config.PrivateZone)) {
+				return aws.StringValue(hostedZone.Id), nil
+			}
+		}
+		return "", fmt.Errorf("unable to find a public hosted zone matching baseDomain %s: %w", baseDomain, errNoPublicHostedZone)
+	})
+}
+
+// invalidateZone drops the cached hosted zone of baseDomain if err reports that a hosted zone does not exist.
+func (c *awsClient) invalidateZone(baseDomain string, err error) {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == route53.ErrCodeNoSuchHostedZone {
+		c.zones.Invalidate(providerName, c.credentials, baseDomain)
+	}
+}
+
+// publicHostedZones returns the public hosted zone of baseDomain, if any, looked up with publicHostedZoneID.
+func (c *awsClient) publicHostedZones(ctx context.Context, baseDomain string) ([]*route53.HostedZone, error) {
+	zoneID, err := c.publicHostedZoneID(ctx, baseDomain)
+	if errors.Is(err, errNoPublicHostedZone) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*route53.HostedZone{{Id: aws.String(zoneID), Name: aws.String(baseDomain)}}, nil
+}
+
 // ValidateDnsWriteAccess spawns a route53 client to retrieve the baseDomain's hostedZoneOutput
 // and attempts to write a test TXT ResourceRecord to it. If successful, will return `true, nil`.
-func (c *awsClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+func (c *awsClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (_ bool, err error) {
+	defer func() { err = credentialsError(err) }()
 
 	var hostedZones []*route53.HostedZone
-	var err error
 	if fedramp {
-		zone, err := c.client.GetHostedZone(&route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
+		zone, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
 		if err != nil {
 			reqLogger.Error(err, err.Error())
 			return false, err
 		}
-		baseDomain := cr.Spec.ACMEDNSDomain
+		baseDomain := utils.ACMEDNSZone(cr)
 		if !strings.HasSuffix(baseDomain, ".") {
 			baseDomain = baseDomain + "."
 		}
@@ -154,17 +315,17 @@ func (c *awsClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 			HostedZoneId: zone.HostedZone.Id,
 		}
 
-		reqLogger.Info(fmt.Sprintf("updating hosted zone %v", zone.HostedZone.Name))
+		reqLogger.Info("updating hosted zone", "hostedZone", aws.StringValue(zone.HostedZone.Name))
 
 		// Initiate the Write test
-		_, err = c.client.ChangeResourceRecordSets(input)
+		_, err = c.changeResourceRecordSets(ctx, input, baseDomain)
 		if err != nil {
 			return false, err
 		}
 
 		// After successful write test clean up the test record and test deletion of that record.
 		input.ChangeBatch.Changes[0].Action = aws.String(route53.ChangeActionDelete)
-		_, err = c.client.ChangeResourceRecordSets(input)
+		_, err = c.changeResourceRecordSets(ctx, input, baseDomain)
 		if err != nil {
 			reqLogger.Error(err, "Error while deleting Write Access record")
 			return false, err
@@ -173,23 +334,24 @@ func (c *awsClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 		return true, nil
 	}
 
-	hostedZones, err = listAllHostedZones(c.client, &route53.ListHostedZonesInput{})
+	baseDomain := utils.ACMEDNSZone(cr)
+	if !strings.HasSuffix(baseDomain, ".") {
+		baseDomain = baseDomain + "."
+	}
+
+	hostedZones, err = c.publicHostedZones(ctx, baseDomain)
 	if err != nil {
 		reqLogger.Error(err, err.Error())
 		return false, err
 	}
 
-	baseDomain := cr.Spec.ACMEDNSDomain
-	if !strings.HasSuffix(baseDomain, ".") {
-		baseDomain = baseDomain + "."
-	}
-
 	for _, hostedzone := range hostedZones {
 		// Find our specific hostedzone
 		if strings.EqualFold(baseDomain, *hostedzone.Name) {
 
-			zone, err := c.client.GetHostedZone(&route53.GetHostedZoneInput{Id: hostedzone.Id})
+			zone, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: hostedzone.Id})
 			if err != nil {
+				c.invalidateZone(baseDomain, err)
 				return false, err
 			}
 
@@ -217,17 +379,17 @@ func (c *awsClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 					HostedZoneId: hostedzone.Id,
 				}
 
-				reqLogger.Info(fmt.Sprintf("updating hosted zone %v", hostedzone.Name))
+				reqLogger.Info("updating hosted zone", "hostedZone", aws.StringValue(hostedzone.Name))
 
 				// Initiate the Write test
-				_, err := c.client.ChangeResourceRecordSets(input)
+				_, err := c.changeResourceRecordSets(ctx, input, *hostedzone.Name)
 				if err != nil {
 					return false, err
 				}
 
 				// After successful write test clean up the test record and test deletion of that record.
 				input.ChangeBatch.Changes[0].Action = aws.String(route53.ChangeActionDelete)
-				_, err = c.client.ChangeResourceRecordSets(input)
+				_, err = c.changeResourceRecordSets(ctx, input, *hostedzone.Name)
 				if err != nil {
 					reqLogger.Error(err, "Error while deleting Write Access record")
 					return false, err
@@ -243,96 +405,105 @@ func (c *awsClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 
 // DeleteAcmeChallengeResourceRecords spawns an AWS client, constructs baseDomain to retrieve the HostedZones. The ResourceRecordSets are
 // then requested, if returned and validated, the record is updated to an empty struct to remove the ACME challenge.
-func (c *awsClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+func (c *awsClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
 
 	var hostedZones []*route53.HostedZone
 
 	if fedramp {
-		zone, err := c.client.GetHostedZone(&route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
+		zone, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: &fedrampHostedZoneID})
 		if err != nil {
 			reqLogger.Error(err, err.Error())
 			return err
 		}
 		hostedZones = []*route53.HostedZone{zone.HostedZone}
-	} else {
-		hostedZoneOutput, err := c.client.ListHostedZones(&route53.ListHostedZonesInput{})
-		if err != nil {
-			return err
-		}
-		hostedZones = hostedZoneOutput.HostedZones
 	}
 
-	baseDomain := cr.Spec.ACMEDNSDomain
+	baseDomain := utils.ACMEDNSZone(cr)
 	if !strings.HasSuffix(baseDomain, ".") {
 		baseDomain = baseDomain + "."
 	}
 
+	if !fedramp {
+		var err error
+		hostedZones, err = c.publicHostedZones(ctx, baseDomain)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, hostedzone := range hostedZones {
 		// For fedramp clusters, there will only be one hostedZone and the baseDomain won't match
 		// the hostedZone name, so just use the first hostedZone in the loop.
 		if strings.EqualFold(baseDomain, *hostedzone.Name) || fedramp {
-			zone, err := c.client.GetHostedZone(&route53.GetHostedZoneInput{Id: hostedzone.Id})
+			zone, err := c.client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: hostedzone.Id})
 			if err != nil {
+				c.invalidateZone(baseDomain, err)
 				return err
 			}
 
 			if !*zone.HostedZone.Config.PrivateZone {
 
-				for _, domain := range cr.Spec.DnsNames {
+				// the records of all the challenges are deleted in one ChangeBatch, and a record can only be deleted
+				// with all of its values, as listed
+				var changes []*route53.Change
+				deleted := map[string]bool{}
+				for _, dnsName := range cr.Spec.DnsNames {
 					// Format domain strings, no leading '*', must lead with '.'
-					domain = strings.TrimPrefix(domain, "*")
+					domain := strings.TrimPrefix(dnsName, "*")
 					if !strings.HasPrefix(domain, ".") {
 						domain = "." + domain
 					}
 					fqdn := cTypes.AcmeChallengeSubDomain + domain
 					fqdnWithDot := fqdn + "."
+					if deleted[fqdn] {
+						continue
+					}
 
-					reqLogger.Info(fmt.Sprintf("deleting resource record %v", fqdn))
+					reqLogger.Info("deleting resource record", logging.Domain, dnsName, "fqdn", fqdn)
 
-					resp, err := c.client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+					resp, err := c.client.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
 						HostedZoneId:    aws.String(*hostedzone.Id), // Required
 						StartRecordName: aws.String(fqdn),
 						StartRecordType: aws.String(route53.RRTypeTxt),
 					})
 
 					if err != nil {
+						c.invalidateZone(baseDomain, err)
 						return err
 					}
 					if len(resp.ResourceRecordSets) > 0 &&
 						*resp.ResourceRecordSets[0].Name == fqdnWithDot &&
 						*resp.ResourceRecordSets[0].Type == route53.RRTypeTxt &&
 						len(resp.ResourceRecordSets[0].ResourceRecords) > 0 {
-						for _, rr := range resp.ResourceRecordSets[0].ResourceRecords {
-							input := &route53.ChangeResourceRecordSetsInput{
-								ChangeBatch: &route53.ChangeBatch{
-									Changes: []*route53.Change{
-										{
-											Action: aws.String(route53.ChangeActionDelete),
-											ResourceRecordSet: &route53.ResourceRecordSet{
-												Name: aws.String(fqdn),
-												ResourceRecords: []*route53.ResourceRecord{
-													{
-														Value: aws.String(*rr.Value),
-													},
-												},
-												TTL:  aws.Int64(resourceRecordTTL),
-												Type: aws.String(route53.RRTypeTxt),
-											},
-										},
-									},
-									Comment: aws.String(""),
-								},
-								HostedZoneId: hostedzone.Id,
-							}
+						rrs := resp.ResourceRecordSets[0]
+						deleted[fqdn] = true
+						changes = append(changes, &route53.Change{
+							Action: aws.String(route53.ChangeActionDelete),
+							ResourceRecordSet: &route53.ResourceRecordSet{
+								Name:            aws.String(fqdn),
+								ResourceRecords: rrs.ResourceRecords,
+								TTL:             rrs.TTL,
+								Type:            aws.String(route53.RRTypeTxt),
+							},
+						})
+					}
+				}
+
+				if len(changes) > 0 {
+					input := &route53.ChangeResourceRecordSetsInput{
+						ChangeBatch: &route53.ChangeBatch{
+							Changes: changes,
+							Comment: aws.String(""),
+						},
+						HostedZoneId: hostedzone.Id,
+					}
 
-							reqLogger.Info(fmt.Sprintf("updating hosted zone %v", hostedzone.Name))
+					reqLogger.Info("updating hosted zone", "hostedZone", aws.StringValue(hostedzone.Name), "records", len(changes))
 
-							result, err := c.client.ChangeResourceRecordSets(input)
-							if err != nil {
-								reqLogger.Error(err, result.GoString())
-								return nil
-							}
-						}
+					result, err := c.changeResourceRecordSets(ctx, input, baseDomain)
+					if err != nil {
+						reqLogger.Error(err, result.GoString())
+						return nil
 					}
 				}
 			}
@@ -342,12 +513,15 @@ func (c *awsClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr
 	return nil
 }
 
-// NewClient returns an awsclient.Client object to the caller. If NewClient is passed a non-null
-// secretName, an attempt to retrieve the secret from the namespace argument will be performed.
-// AWS credentials are returned as these secrets and a new session is initiated prior to returning
+// NewClient returns an awsclient.Client object to the caller. If the platform names a credentials
+// secret, an attempt to retrieve the secret from the namespace argument will be performed.
+// AWS credentials are returned as these secrets, which may be temporary credentials with a session token
+// and are read again once the secret is updated, and a new session is initiated prior to returning
 // a client. If secrets fail to return, the IAM role of the masters is used to create a
-// new session for the client.
-func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, namespace, region, clusterDeploymentName string) (*awsClient, error) {
+// new session for the client. If the platform sets a role, these credentials are only used to
+// assume it, and the client manages the DNS records as the role.
+func NewClient(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.AWSPlatformSecrets, namespace, clusterDeploymentName string) (*awsClient, error) {
+	secretName, region := platform.Credentials.Name, platform.Region
 	awsConfig := &aws.Config{
 		Region: aws.String(region),
 		// MaxRetries to limit the number of attempts on failed API calls
@@ -365,7 +539,7 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 	if fedramp {
 		awsConfig.Region = aws.String(fedrampAWSRegion)
 		secret := &corev1.Secret{}
-		err := kubeClient.Get(context.TODO(),
+		err := kubeClient.Get(ctx,
 			types.NamespacedName{
 				Name:      awsCredsSecretName,
 				Namespace: "certman-operator",
@@ -378,13 +552,13 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
 		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
+			return nil, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
 				secretName, awsCredsSecretIDKey)
 		}
 
 		secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
 		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
+			return nil, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
 				secretName, awsCredsSecretAccessKey)
 		}
 
@@ -399,25 +573,29 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 		}
 
 		c := &awsClient{
-			client: route53.New(s),
+			client:      newRoute53(s),
+			zones:       zonecache.Default,
+			credentials: zonecache.Fingerprint(strings.Trim(string(accessKeyID), "\n")),
 		}
 
 		return c, err
 	}
 
-	// Check if ClusterDeployment is labelled for STS
+	// Check if ClusterDeployment is labelled for STS. A standalone cluster has no ClusterDeployment.
 	clusterDeployment := &hivev1.ClusterDeployment{}
-	err := kubeClient.Get(context.TODO(), types.NamespacedName{
-		Name:      clusterDeploymentName,
-		Namespace: namespace,
-	}, clusterDeployment)
-	if err != nil {
-		return nil, err
+	if clusterDeploymentName != "" {
+		err := kubeClient.Get(ctx, types.NamespacedName{
+			Name:      clusterDeploymentName,
+			Namespace: namespace,
+		}, clusterDeployment)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if stsEnabled, ok := clusterDeployment.Labels[clusterDeploymentSTSLabel]; ok && stsEnabled == "true" {
 		// Get STS jump role from from aws-account-operator ConfigMap
 		cm := &corev1.ConfigMap{}
-		err := kubeClient.Get(context.TODO(), types.NamespacedName{
+		err := kubeClient.Get(ctx, types.NamespacedName{
 			Name:      aaov1alpha1.DefaultConfigMap,
 			Namespace: aaov1alpha1.AccountCrNamespace,
 		}, cm)
@@ -433,7 +611,7 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		// Get STS Creds
 		secret := &corev1.Secret{}
-		err = kubeClient.Get(context.TODO(),
+		err = kubeClient.Get(ctx,
 			types.NamespacedName{
 				Name:      awsCredsSecretName,
 				Namespace: config.OperatorNamespace,
@@ -446,13 +624,13 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
 		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
+			return nil, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
 				secretName, awsCredsSecretIDKey)
 		}
 
 		secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
 		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
+			return nil, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
 				secretName, awsCredsSecretAccessKey)
 		}
 
@@ -469,7 +647,7 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		hiveAwsClient := sts.New(s)
 
-		jumpRoleCreds, err := getSTSCredentials(reqLogger, hiveAwsClient, stsAccessARN, "", "certmanOperator")
+		jumpRoleCreds, err := getSTSCredentials(ctx, reqLogger, hiveAwsClient, stsAccessARN, "", "certmanOperator")
 		if err != nil {
 			return nil, fmt.Errorf("unable to assume jump role %s: %v", stsAccessARN, err)
 		}
@@ -497,7 +675,7 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		// Get Account's STS role from AccountClaim
 		accountClaim := &aaov1alpha1.AccountClaim{}
-		err = kubeClient.Get(context.TODO(), types.NamespacedName{
+		err = kubeClient.Get(ctx, types.NamespacedName{
 			Name:      clusterDeploymentName,
 			Namespace: namespace,
 		}, accountClaim)
@@ -512,7 +690,7 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 
 		}
 
-		customerAccountCreds, err := getSTSCredentials(reqLogger, jumpRoleClient, accountClaim.Spec.STSRoleARN, accountClaim.Spec.STSExternalID, "RH-Account-Initilization")
+		customerAccountCreds, err := getSTSCredentials(ctx, reqLogger, jumpRoleClient, accountClaim.Spec.STSRoleARN, accountClaim.Spec.STSExternalID, "RH-Account-Initilization")
 
 		if err != nil {
 			return nil, fmt.Errorf("unable to assume customer role %s: %v", accountClaim.Spec.STSRoleARN, err)
@@ -538,42 +716,29 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 		}
 
 		c := &awsClient{
-			client: route53.New(cs),
+			client:      newRoute53(cs),
+			zones:       zonecache.Default,
+			credentials: zonecache.Fingerprint(accountClaim.Spec.STSRoleARN),
 		}
 
 		return c, err
 	}
 
+	// without a credentials secret, the hosted zones are those of the account of the IAM role of the masters
+	var accessKeyID string
 	if secretName != "" {
-		secret := &corev1.Secret{}
-		err := kubeClient.Get(context.TODO(),
-			types.NamespacedName{
-				Name:      secretName,
-				Namespace: namespace,
-			},
-			secret)
-
+		provider := &secretCredentialsProvider{
+			ctx:        ctx,
+			kubeClient: kubeClient,
+			key:        types.NamespacedName{Name: secretName, Namespace: namespace},
+		}
+		// read the secret now, so that missing keys are reported before the first request
+		value, err := provider.Retrieve()
 		if err != nil {
 			return nil, err
 		}
-
-		accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
-		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
-				secretName, awsCredsSecretIDKey)
-		}
-
-		secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
-		if !ok {
-			return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v",
-				secretName, awsCredsSecretAccessKey)
-		}
-
-		awsConfig.Credentials = credentials.NewStaticCredentials(
-			strings.Trim(string(accessKeyID), "\n"),
-			strings.Trim(string(secretAccessKey), "\n"),
-			"",
-		)
+		awsConfig.Credentials = credentials.NewCredentials(provider)
+		accessKeyID = value.AccessKeyID
 	}
 
 	//// Otherwise default to relying on the IAM role of the masters where the actuator is running:
@@ -582,17 +747,95 @@ func NewClient(reqLogger logr.Logger, kubeClient client.Client, secretName, name
 		return nil, err
 	}
 
+	if platform.RoleARN != "" {
+		reqLogger.Info("assuming the DNS role", "roleARN", platform.RoleARN)
+		s, err = session.NewSession(awsConfig.Copy(&aws.Config{
+			Credentials: credentials.NewCredentials(newAssumeRoleProvider(sts.New(s), platform)),
+		}))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c := &awsClient{
-		client: route53.New(s),
+		client:      newRoute53(s),
+		zones:       zonecache.Default,
+		credentials: zonecache.Fingerprint(accessKeyID, platform.RoleARN),
 	}
 	return c, err
 }
 
-func getSTSCredentials(reqLogger logr.Logger, client *sts.STS, roleArn string, externalID string, roleSessionName string) (*sts.AssumeRoleOutput, error) {
+// secretCredentialsProvider provides the credentials of a platform secret, which may be temporary session credentials
+// with an aws_session_token. The secret is read again once it is updated, so that the credentials minted by an
+// external broker are picked up by the requests of an issuance in progress.
+type secretCredentialsProvider struct {
+	ctx             context.Context
+	kubeClient      client.Client
+	key             types.NamespacedName
+	resourceVersion string
+}
+
+// Retrieve reads the credentials of the secret.
+func (p *secretCredentialsProvider) Retrieve() (credentials.Value, error) {
+	secret := &corev1.Secret{}
+	if err := p.kubeClient.Get(p.ctx, p.key, secret); err != nil {
+		return credentials.Value{}, err
+	}
+
+	accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
+	if !ok {
+		return credentials.Value{}, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
+			p.key.Name, awsCredsSecretIDKey)
+	}
+
+	secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
+	if !ok {
+		return credentials.Value{}, fmt.Errorf("%w: AWS credentials secret %v did not contain key %v", cTypes.ErrInvalidCredentials,
+			p.key.Name, awsCredsSecretAccessKey)
+	}
+
+	p.resourceVersion = secret.ResourceVersion
+	return credentials.Value{
+		AccessKeyID:     strings.Trim(string(accessKeyID), "\n"),
+		SecretAccessKey: strings.Trim(string(secretAccessKey), "\n"),
+		SessionToken:    strings.Trim(string(secret.Data[awsCredsSecretSessionToken]), "\n"),
+		ProviderName:    "CertmanSecretProvider",
+	}, nil
+}
+
+// IsExpired returns true once the secret was updated. The current credentials are kept if it cannot be read.
+func (p *secretCredentialsProvider) IsExpired() bool {
+	secret := &corev1.Secret{}
+	if err := p.kubeClient.Get(p.ctx, p.key, secret); err != nil {
+		return false
+	}
+	return secret.ResourceVersion != p.resourceVersion
+}
+
+// newRoute53 returns a Route 53 client of the session counting each attempt of its requests in the DNS API metrics.
+func newRoute53(s *session.Session) *route53.Route53 {
+	r53 := route53.New(s)
+	r53.Handlers.CompleteAttempt.PushBack(observeRequest)
+	return r53
+}
+
+// observeRequest counts an attempt of a Route 53 request in the DNS API metrics.
+func observeRequest(r *request.Request) {
+	code := ""
+	if r.Error != nil {
+		code = "error"
+		if awsErr, ok := r.Error.(awserr.Error); ok {
+			code = awsErr.Code()
+		}
+	}
+	localmetrics.ObserveDNSAPIRequest(providerName, r.Operation.Name, code, r.IsErrorThrottle())
+}
+
+func getSTSCredentials(ctx context.Context, reqLogger logr.Logger, client *sts.STS, roleArn string, externalID string, roleSessionName string) (*sts.AssumeRoleOutput, error) {
 	// Default duration in seconds of the session token 3600. We need to have the roles policy
 	// changed if we want it to be longer than 3600 seconds
 	var roleSessionDuration int64 = 3600
-	reqLogger.Info(fmt.Sprintf("Creating STS credentials for AWS ARN: %s", roleArn))
+	reqLogger.Info("Creating STS credentials", "roleARN", roleArn)
 	// Build input for AssumeRole
 	assumeRoleInput := sts.AssumeRoleInput{
 		DurationSeconds: &roleSessionDuration,
@@ -606,8 +849,12 @@ func getSTSCredentials(reqLogger logr.Logger, client *sts.STS, roleArn string, e
 	assumeRoleOutput := &sts.AssumeRoleOutput{}
 	var err error
 	for i := 0; i < assumeRolePollingRetries; i++ {
-		time.Sleep(assumeRolePollingDelayMilli * time.Millisecond)
-		assumeRoleOutput, err = client.AssumeRole(&assumeRoleInput)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(assumeRolePollingDelayMilli * time.Millisecond):
+		}
+		assumeRoleOutput, err = client.AssumeRoleWithContext(ctx, &assumeRoleInput)
 		if err == nil {
 			break
 		}
@@ -633,23 +880,64 @@ func getSTSCredentials(reqLogger logr.Logger, client *sts.STS, roleArn string, e
 		}
 		// If we still have retries, log the failure and try again
 		if i == 1 || i%25 == 0 {
-			reqLogger.Info(fmt.Sprintf("failed to assumeRole (attempt %d out of %d). Subsequent attempts will be looged at Verbosity 1 instead of 0 to reduce noise.", i, assumeRolePollingRetries))
+			reqLogger.Info("failed to assumeRole. Subsequent attempts will be logged at Verbosity 1 instead of 0 to reduce noise.", "attempt", i, "retries", assumeRolePollingRetries)
 		} else {
-			reqLogger.V(1).Info(fmt.Sprintf("failed to assumeRole (attempt %d out of %d)", i, assumeRolePollingRetries))
+			reqLogger.V(1).Info("failed to assumeRole", "attempt", i, "retries", assumeRolePollingRetries)
 		}
 	}
 	return assumeRoleOutput, nil
 }
 
+// newAssumeRoleProvider returns the provider of the credentials of the role of the platform, assumed by client
+// with the external ID and session tags of the platform. The credentials are refreshed before they expire.
+func newAssumeRoleProvider(client stscreds.AssumeRoler, platform certmanv1alpha1.AWSPlatformSecrets) *stscreds.AssumeRoleProvider {
+	p := &stscreds.AssumeRoleProvider{
+		Client:          client,
+		RoleARN:         platform.RoleARN,
+		RoleSessionName: roleSessionName,
+		Duration:        stscreds.DefaultDuration,
+	}
+	if platform.ExternalID != "" {
+		p.ExternalID = aws.String(platform.ExternalID)
+	}
+
+	// sort the tags, so that the sessions of the same platform are tagged alike
+	keys := make([]string, 0, len(platform.SessionTags))
+	for key := range platform.SessionTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p.Tags = append(p.Tags, &sts.Tag{Key: aws.String(key), Value: aws.String(platform.SessionTags[key])})
+	}
+	return p
+}
+
+// credentialsError wraps err in cTypes.ErrInvalidCredentials if AWS rejected the credentials of the request, or in
+// cTypes.ErrInsufficientPermissions if they are not allowed to make it.
+func credentialsError(err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return err
+	}
+	switch awsErr.Code() {
+	case "InvalidClientTokenId", "InvalidAccessKeyId", "SignatureDoesNotMatch", "IncompleteSignature", "UnrecognizedClientException", "ExpiredToken", sts.ErrCodeExpiredTokenException:
+		return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation":
+		return fmt.Errorf("%w: %w", cTypes.ErrInsufficientPermissions, err)
+	}
+	return err
+}
+
 // listAllHostedZones is a wrapper around the Route53API function
 // ListHostedZones() that keeps looping if the results are truncated
 // and returns all the hosted zones
-func listAllHostedZones(r53 route53iface.Route53API, lhzi *route53.ListHostedZonesInput) ([]*route53.HostedZone, error) {
+func listAllHostedZones(ctx context.Context, r53 route53iface.Route53API, lhzi *route53.ListHostedZonesInput) ([]*route53.HostedZone, error) {
 	var hostedZones []*route53.HostedZone
 
 	more := true
 	for more {
-		output, err := r53.ListHostedZones(lhzi)
+		output, err := r53.ListHostedZonesWithContext(ctx, lhzi)
 		if err != nil {
 			return []*route53.HostedZone{}, err
 		}
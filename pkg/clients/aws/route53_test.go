@@ -17,15 +17,24 @@ limitations under the License.
 package aws
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +47,8 @@ import (
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/pkg/clients/aws/mockroute53"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/clients/zonecache"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -99,7 +110,7 @@ func TestNewClient(t *testing.T) {
 		testClient := setUpEmptyTestClient(t)
 		reqLogger := log.WithValues("Request.Namespace", testHiveNamespace, "Request.Name", testHiveCertificateRequestName)
 
-		_, actual := NewClient(reqLogger, testClient, testHiveAWSSecretName, testHiveNamespace, testHiveAWSRegion, testHiveClusterDeploymentName)
+		_, actual := NewClient(context.TODO(), reqLogger, testClient, *certRequestPlatform.AWS, testHiveNamespace, testHiveClusterDeploymentName)
 
 		if actual == nil {
 			t.Error("expected an error when attempting to get missing account secret")
@@ -110,12 +121,129 @@ func TestNewClient(t *testing.T) {
 		testClient := setUpTestClient(t)
 		reqLogger := log.WithValues("Request.Namespace", testHiveNamespace, "Request.Name", testHiveCertificateRequestName)
 
-		_, err := NewClient(reqLogger, testClient, testHiveAWSSecretName, testHiveNamespace, testHiveAWSRegion, testHiveClusterDeploymentName)
+		_, err := NewClient(context.TODO(), reqLogger, testClient, *certRequestPlatform.AWS, testHiveNamespace, testHiveClusterDeploymentName)
 
 		if err != nil {
 			t.Errorf("unexpected error when creating the client: %q", err)
 		}
 	})
+
+	t.Run("returns a client if a role is set", func(t *testing.T) {
+		testClient := setUpTestClient(t)
+		reqLogger := log.WithValues("Request.Namespace", testHiveNamespace, "Request.Name", testHiveCertificateRequestName)
+		platform := *certRequestPlatform.AWS
+		platform.RoleARN = "arn:aws:iam::123456789012:role/dns"
+
+		// the role is only assumed by the first request of the client
+		_, err := NewClient(context.TODO(), reqLogger, testClient, platform, testHiveNamespace, testHiveClusterDeploymentName)
+
+		if err != nil {
+			t.Errorf("unexpected error when creating the client: %q", err)
+		}
+	})
+}
+
+func TestSecretCredentialsProvider(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: testHiveNamespace},
+		Data: map[string][]byte{
+			awsCredsSecretIDKey:        []byte("ASIAFIRST"),
+			awsCredsSecretAccessKey:    []byte("secret"),
+			awsCredsSecretSessionToken: []byte("token\n"),
+		},
+	}
+	kubeClient := fake.NewClientBuilder().WithObjects(secret).Build()
+	p := &secretCredentialsProvider{ctx: context.TODO(), kubeClient: kubeClient, key: client.ObjectKeyFromObject(secret)}
+
+	value, err := p.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error retrieving the credentials: %q", err)
+	}
+	if value.AccessKeyID != "ASIAFIRST" || value.SessionToken != "token" {
+		t.Errorf("unexpected credentials %q with session token %q", value.AccessKeyID, value.SessionToken)
+	}
+	if p.IsExpired() {
+		t.Error("expected the credentials not to expire until the secret is updated")
+	}
+
+	// the broker rotates the credentials
+	secret.Data[awsCredsSecretIDKey] = []byte("ASIASECOND")
+	if err := kubeClient.Update(context.TODO(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsExpired() {
+		t.Error("expected the credentials to expire once the secret is updated")
+	}
+	value, err = p.Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error retrieving the credentials: %q", err)
+	}
+	if value.AccessKeyID != "ASIASECOND" || p.IsExpired() {
+		t.Errorf("expected the rotated credentials, got %q", value.AccessKeyID)
+	}
+
+	delete(secret.Data, awsCredsSecretAccessKey)
+	if err := kubeClient.Update(context.TODO(), secret); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Retrieve(); !errors.Is(err, cTypes.ErrInvalidCredentials) {
+		t.Errorf("expected invalid credentials, got %v", err)
+	}
+}
+
+// fakeAssumeRoler returns credentials for the last AssumeRole request it received.
+type fakeAssumeRoler struct {
+	input *sts.AssumeRoleInput
+}
+
+func (f *fakeAssumeRoler) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	f.input = input
+	return &sts.AssumeRoleOutput{Credentials: &sts.Credentials{
+		AccessKeyId:     aws.String("ASIAROLE"),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestNewAssumeRoleProvider(t *testing.T) {
+	roler := &fakeAssumeRoler{}
+	platform := *certRequestPlatform.AWS
+	platform.RoleARN = "arn:aws:iam::123456789012:role/dns"
+	platform.ExternalID = "external-id"
+	platform.SessionTags = map[string]string{"service": "certman", "cluster": testHiveClusterDeploymentName}
+
+	value, err := newAssumeRoleProvider(roler, platform).Retrieve()
+	if err != nil {
+		t.Fatalf("unexpected error assuming the role: %v", err)
+	}
+	if value.AccessKeyID != "ASIAROLE" || value.SessionToken != "token" {
+		t.Errorf("newAssumeRoleProvider(): got credentials %q, expected those of the role", value.AccessKeyID)
+	}
+
+	input := roler.input
+	if aws.StringValue(input.RoleArn) != platform.RoleARN || aws.StringValue(input.RoleSessionName) != roleSessionName {
+		t.Errorf("newAssumeRoleProvider(): assumed %s as %s, expected %s as %s", aws.StringValue(input.RoleArn), aws.StringValue(input.RoleSessionName), platform.RoleARN, roleSessionName)
+	}
+	if aws.StringValue(input.ExternalId) != platform.ExternalID {
+		t.Errorf("newAssumeRoleProvider(): got external ID %q, expected %q", aws.StringValue(input.ExternalId), platform.ExternalID)
+	}
+	tags := []string{}
+	for _, tag := range input.Tags {
+		tags = append(tags, aws.StringValue(tag.Key)+"="+aws.StringValue(tag.Value))
+	}
+	if expected := []string{"cluster=" + testHiveClusterDeploymentName, "service=certman"}; strings.Join(tags, ",") != strings.Join(expected, ",") {
+		t.Errorf("newAssumeRoleProvider(): got session tags %v, expected %v", tags, expected)
+	}
+
+	platform.ExternalID = ""
+	platform.SessionTags = nil
+	if _, err := newAssumeRoleProvider(roler, platform).Retrieve(); err != nil {
+		t.Fatalf("unexpected error assuming the role: %v", err)
+	}
+	if roler.input.ExternalId != nil || len(roler.input.Tags) != 0 {
+		t.Errorf("newAssumeRoleProvider(): got external ID %v and tags %v, expected none", roler.input.ExternalId, roler.input.Tags)
+	}
 }
 func TestNewClient_Fedramp(t *testing.T) {
 
@@ -200,7 +328,10 @@ func TestNewClient_Fedramp(t *testing.T) {
 				kubeClient = fake.NewClientBuilder().WithScheme(scheme).Build()
 			}
 
-			c, err := NewClient(logr.Discard(), kubeClient, "certman-operator-aws-credentials", "", "us-gov-west-1", "")
+			c, err := NewClient(context.TODO(), logr.Discard(), kubeClient, certmanv1alpha1.AWSPlatformSecrets{
+				Credentials: corev1.LocalObjectReference{Name: "certman-operator-aws-credentials"},
+				Region:      "us-gov-west-1",
+			}, "", "")
 			if tt.expectError {
 				if err == nil {
 					t.Fatalf("expected error but got none")
@@ -228,7 +359,7 @@ func TestListAllHostedZones(t *testing.T) {
 		ZoneCount: 550,
 	}
 
-	hostedZones, err := listAllHostedZones(r53, &route53.ListHostedZonesInput{})
+	hostedZones, err := listAllHostedZones(context.TODO(), r53, &route53.ListHostedZonesInput{})
 	if err != nil {
 		t.Fatalf("TestListAllHostedZones(): unexpected error: %s\n", err)
 	}
@@ -239,11 +370,90 @@ func TestListAllHostedZones(t *testing.T) {
 	}
 }
 
+func TestPublicHostedZoneID(t *testing.T) {
+	r53Mock := &mockroute53.MockRoute53Client{ZoneCount: 1}
+	r53 := &awsClient{client: r53Mock, zones: zonecache.New(time.Minute), credentials: "credentials"}
+
+	zoneID, err := r53.publicHostedZoneID(context.TODO(), testHiveACMEDomain)
+	if err != nil || zoneID != "id0" {
+		t.Fatalf("publicHostedZoneID(): expected id0, got %q, %v", zoneID, err)
+	}
+
+	// the hosted zone is cached, so it is found without listing the zones
+	r53Mock.ZoneCount = 0
+	zoneID, err = r53.publicHostedZoneID(context.TODO(), testHiveACMEDomain)
+	if err != nil || zoneID != "id0" {
+		t.Errorf("publicHostedZoneID(): expected the cached id0, got %q, %v", zoneID, err)
+	}
+
+	// until the hosted zone is reported as missing
+	r53.invalidateZone(testHiveACMEDomain+".", awserr.New(route53.ErrCodeNoSuchHostedZone, "No hosted zone found with ID: id0", nil))
+	if _, err := r53.publicHostedZoneID(context.TODO(), testHiveACMEDomain); !errors.Is(err, errNoPublicHostedZone) {
+		t.Errorf("publicHostedZoneID(): expected errNoPublicHostedZone, got %v", err)
+	}
+}
+
+func TestListZones(t *testing.T) {
+	c := &awsClient{client: &mockroute53.MockRoute53Client{ZoneCount: 3}}
+
+	zones, err := c.ListZones(context.TODO())
+	if err != nil {
+		t.Fatalf("TestListZones(): unexpected error: %s\n", err)
+	}
+	if len(zones) != 3 {
+		t.Errorf("TestListZones(): got %d zones, expected 3\n", len(zones))
+	}
+}
+
+func TestObserveRequest(t *testing.T) {
+	localmetrics.MetricDNSAPIRequests.Reset()
+	localmetrics.MetricDNSAPIErrors.Reset()
+	localmetrics.MetricDNSAPIThrottled.Reset()
+
+	operation := &request.Operation{Name: "ChangeResourceRecordSets"}
+	observeRequest(&request.Request{Operation: operation})
+	observeRequest(&request.Request{Operation: operation, Error: awserr.New("Throttling", "Rate exceeded", nil)})
+	observeRequest(&request.Request{Operation: operation, Error: awserr.New("InvalidChangeBatch", "Tried to delete resource record set but it was not found", nil)})
+
+	if requests := testutil.ToFloat64(localmetrics.MetricDNSAPIRequests.WithLabelValues("aws", "ChangeResourceRecordSets")); requests != 3 {
+		t.Errorf("TestObserveRequest(): got %.0f requests, expected 3\n", requests)
+	}
+	if errors := testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("aws", "ChangeResourceRecordSets", "InvalidChangeBatch")); errors != 1 {
+		t.Errorf("TestObserveRequest(): got %.0f InvalidChangeBatch errors, expected 1\n", errors)
+	}
+	if throttled := testutil.ToFloat64(localmetrics.MetricDNSAPIThrottled.WithLabelValues("aws", "ChangeResourceRecordSets")); throttled != 1 {
+		t.Errorf("TestObserveRequest(): got %.0f throttled requests, expected 1\n", throttled)
+	}
+}
+
+func TestCredentialsError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected error
+	}{
+		{err: awserr.New("InvalidClientTokenId", "The security token included in the request is invalid.", nil), expected: cTypes.ErrInvalidCredentials},
+		{err: awserr.New("SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", nil), expected: cTypes.ErrInvalidCredentials},
+		{err: awserr.New("AccessDenied", "User is not authorized to perform: route53:ChangeResourceRecordSets", nil), expected: cTypes.ErrInsufficientPermissions},
+		{err: awserr.New("Throttling", "Rate exceeded", nil)},
+		{err: fmt.Errorf("no hosted zone")},
+	}
+
+	for _, test := range tests {
+		err := credentialsError(test.err)
+		for _, sentinel := range []error{cTypes.ErrInvalidCredentials, cTypes.ErrInsufficientPermissions} {
+			if got := errors.Is(err, sentinel); got != (sentinel == test.expected) {
+				t.Errorf("credentialsError(%q): errors.Is(%q) = %t, expected %t\n", test.err, sentinel, got, !got)
+			}
+		}
+	}
+}
+
 func TestAnswerDNSChallenge(t *testing.T) {
 	tests := []struct {
 		Name         string
 		TestClient   route53iface.Route53API
 		Namespace    string
+		DNSZone      string
 		ExpectedFQDN string
 		ExpectError  bool
 	}{
@@ -253,9 +463,28 @@ func TestAnswerDNSChallenge(t *testing.T) {
 				ZoneCount: 1,
 			},
 			Namespace:    testHiveNamespace,
+			DNSZone:      "id0",
+			ExpectedFQDN: fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, testHiveACMEDomain),
+			ExpectError:  false,
+		},
+		{
+			Name: "finds the hosted zone of the domain without a dnsZone",
+			TestClient: &mockroute53.MockRoute53Client{
+				ZoneCount: 3,
+			},
+			Namespace:    testHiveNamespace,
 			ExpectedFQDN: fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, testHiveACMEDomain),
 			ExpectError:  false,
 		},
+		{
+			Name: "fails without a hosted zone of the domain",
+			TestClient: &mockroute53.MockRoute53Client{
+				ZoneCount: 0,
+			},
+			Namespace:    testHiveNamespace,
+			ExpectedFQDN: "",
+			ExpectError:  true,
+		},
 	}
 
 	for _, test := range tests {
@@ -264,7 +493,7 @@ func TestAnswerDNSChallenge(t *testing.T) {
 				client: test.TestClient,
 			}
 
-			actualFQDN, err := r53.AnswerDNSChallenge(logr.Discard(), "fakechallengetoken", certRequest.Spec.ACMEDNSDomain, certRequest, "id0")
+			actualFQDN, err := r53.AnswerDNSChallenge(context.TODO(), logr.Discard(), "fakechallengetoken", certRequest.Spec.ACMEDNSDomain, certRequest, test.DNSZone)
 			if test.ExpectError == (err == nil) {
 				t.Errorf("AnswerDNSChallenge() %s: ExpectError: %t, actual error: %s\n", test.Name, test.ExpectError, err)
 			}
@@ -276,6 +505,41 @@ func TestAnswerDNSChallenge(t *testing.T) {
 	}
 }
 
+func TestAnswerDNSChallenges(t *testing.T) {
+	r53Mock := &mockroute53.MockRoute53Client{ZoneCount: 1}
+	r53 := &awsClient{client: r53Mock}
+	challenges := []cTypes.DNSChallenge{
+		{Domain: testHiveACMEDomain, KeyAuthorization: "apex"},
+		{Domain: testHiveACMEDomain, KeyAuthorization: "wildcard"},
+		{Domain: "api." + testHiveACMEDomain, KeyAuthorization: "api"},
+	}
+
+	fqdns, err := r53.AnswerDNSChallenges(context.TODO(), logr.Discard(), challenges, certRequest, "id0")
+	if err != nil {
+		t.Fatalf("AnswerDNSChallenges(): unexpected error: %s", err)
+	}
+
+	apexFQDN := fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, testHiveACMEDomain)
+	apiFQDN := fmt.Sprintf("%s.api.%s", cTypes.AcmeChallengeSubDomain, testHiveACMEDomain)
+	if !reflect.DeepEqual(fqdns, []string{apexFQDN, apexFQDN, apiFQDN}) {
+		t.Errorf("AnswerDNSChallenges(): unexpected fqdns %v", fqdns)
+	}
+
+	if len(r53Mock.ChangeBatches) != 1 {
+		t.Fatalf("AnswerDNSChallenges(): expected 1 change batch, got %d", len(r53Mock.ChangeBatches))
+	}
+	changes := r53Mock.ChangeBatches[0].Changes
+	if len(changes) != 2 {
+		t.Fatalf("AnswerDNSChallenges(): expected a change per record, got %d", len(changes))
+	}
+	if values := len(changes[0].ResourceRecordSet.ResourceRecords); values != 2 {
+		t.Errorf("AnswerDNSChallenges(): expected the record of the apex and wildcard challenges to hold 2 values, got %d", values)
+	}
+	if !reflect.DeepEqual(r53Mock.WaitedChangeIDs, []string{"mockchangeid"}) {
+		t.Errorf("AnswerDNSChallenges(): expected to wait for the change, waited for %v", r53Mock.WaitedChangeIDs)
+	}
+}
+
 func TestValidateDNSWriteAccess(t *testing.T) {
 	tests := []struct {
 		Name               string
@@ -339,7 +603,7 @@ func TestValidateDNSWriteAccess(t *testing.T) {
 				fedrampHostedZoneID = os.Getenv(fedrampHostedZoneIDVariable)
 				fedramp = os.Getenv(fedrampEnvVariable) == "true"
 			}
-			actualResult, err := r53.ValidateDNSWriteAccess(logr.Discard(), test.CertificateRequest)
+			actualResult, err := r53.ValidateDNSWriteAccess(context.TODO(), logr.Discard(), test.CertificateRequest)
 			if test.ExpectError == (err == nil) {
 				t.Errorf("ValidateDNSWriteAccess() %s: ExpectError: %t, actual error: %s\n", test.Name, test.ExpectError, err)
 			}
@@ -363,7 +627,15 @@ func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
 			TestClient: &mockroute53.MockRoute53Client{
 				ZoneCount: 1,
 			},
-			CertificateRequest: certRequest,
+			CertificateRequest: zoneCertRequest,
+			ExpectError:        false,
+		},
+		{
+			Name: "cleans dns records with several values",
+			TestClient: &mockroute53.MockRoute53Client{
+				ZoneCount: 2,
+			},
+			CertificateRequest: zoneCertRequest,
 			ExpectError:        false,
 		},
 	}
@@ -374,10 +646,24 @@ func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
 				client: test.TestClient,
 			}
 
-			err := r53.DeleteAcmeChallengeResourceRecords(logr.Discard(), test.CertificateRequest)
+			err := r53.DeleteAcmeChallengeResourceRecords(context.TODO(), logr.Discard(), test.CertificateRequest)
 			if test.ExpectError == (err == nil) {
 				t.Errorf("ValidateDNSWriteAccess() %s: ExpectError: %t, actual error: %s\n", test.Name, test.ExpectError, err)
 			}
+
+			// the records are deleted in one change, each with all of its values, and the record shared by the apex
+			// and wildcard challenges only once
+			if len(test.TestClient.ChangeBatches) != 1 {
+				t.Fatalf("DeleteAcmeChallengeResourceRecords() %s: expected 1 change batch, got %d\n", test.Name, len(test.TestClient.ChangeBatches))
+			}
+			if changes := len(test.TestClient.ChangeBatches[0].Changes); changes != 2 {
+				t.Errorf("DeleteAcmeChallengeResourceRecords() %s: expected 2 records deleted, got %d\n", test.Name, changes)
+			}
+			for _, change := range test.TestClient.ChangeBatches[0].Changes {
+				if values := len(change.ResourceRecordSet.ResourceRecords); values != test.TestClient.ZoneCount {
+					t.Errorf("DeleteAcmeChallengeResourceRecords() %s: expected %d values deleted, got %d\n", test.Name, test.TestClient.ZoneCount, values)
+				}
+			}
 		})
 	}
 }
@@ -481,3 +767,10 @@ func setUpTestClient(t *testing.T) (testClient client.Client) {
 	testClient = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
 	return
 }
+
+// zoneCertRequest is a CertificateRequest for names of the first hosted zone of the mock, including a wildcard.
+var zoneCertRequest = func() *certmanv1alpha1.CertificateRequest {
+	cr := certRequest.DeepCopy()
+	cr.Spec.DnsNames = []string{testHiveACMEDomain, "*." + testHiveACMEDomain, "api." + testHiveACMEDomain}
+	return cr
+}()
@@ -17,12 +17,21 @@ limitations under the License.
 package azure
 
 import (
+	"bytes"
 	"context"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2018-05-01/dns" //nolint
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -31,14 +40,25 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"software.sslmate.com/src/go-pkcs12"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/clients/guardrail"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/logging"
 )
 
 const (
 	resourceRecordTTL = 60
 	azureCredsSPKey   = "osServicePrincipal.json" //nolint:gosec // not a hard-coded credential
+	providerName      = "azure"
+
+	// azureCredsCertificateKey holds the PEM or PFX client certificate of a service principal authenticated with a
+	// certificate instead of a client secret. azureCredsCertificatePasswordKey holds the password of a PFX bundle.
+	azureCredsCertificateKey         = "clientCertificate"
+	azureCredsCertificatePasswordKey = "clientCertificatePassword" //nolint:gosec // not a hard-coded credential
 )
 
 // client implements the Client interface
@@ -48,7 +68,10 @@ type azureClient struct {
 	zonesClient       *dns.ZonesClient
 }
 
-func (c *azureClient) createTxtRecord(reqLogger logr.Logger, recordKey string, recordValue string, zoneName string) (result dns.RecordSet, err error) {
+func (c *azureClient) createTxtRecord(ctx context.Context, reqLogger logr.Logger, recordKey string, recordValue string, zoneName string) (result dns.RecordSet, err error) {
+	if err := guardrail.CheckRecord(providerName, "RecordSets.CreateOrUpdate", recordKey+"."+zoneName, string(dns.TXT), zoneName); err != nil {
+		return dns.RecordSet{}, err
+	}
 	recordSetProperties := &dns.RecordSet{
 		RecordSetProperties: &dns.RecordSetProperties{
 			TTL: to.Int64Ptr(resourceRecordTTL),
@@ -61,8 +84,17 @@ func (c *azureClient) createTxtRecord(reqLogger logr.Logger, recordKey string, r
 			},
 		},
 	}
-	reqLogger.Info(fmt.Sprintf("updating hosted zone %v", zoneName))
-	return c.recordSetsClient.CreateOrUpdate(context.TODO(), c.resourceGroupName, zoneName, recordKey, dns.TXT, *recordSetProperties, "", "")
+	reqLogger.Info("updating hosted zone", "hostedZone", zoneName)
+	return c.recordSetsClient.CreateOrUpdate(ctx, c.resourceGroupName, zoneName, recordKey, dns.TXT, *recordSetProperties, "", "")
+}
+
+// deleteTxtRecord deletes the TXT record set of the zone named by its relative recordKey.
+func (c *azureClient) deleteTxtRecord(ctx context.Context, recordKey string, zoneName string) error {
+	if err := guardrail.CheckRecord(providerName, "RecordSets.Delete", recordKey+"."+zoneName, string(dns.TXT), zoneName); err != nil {
+		return err
+	}
+	_, err := c.recordSetsClient.Delete(ctx, c.resourceGroupName, zoneName, recordKey, dns.TXT, "")
+	return err
 }
 
 func (c *azureClient) generateTxtRecordName(domain string, rootDomain string) string {
@@ -85,45 +117,63 @@ func (c *azureClient) GetDNSName() string {
 	return "DNS Zone"
 }
 
-func (c *azureClient) GetFedrampHostedZoneIDPath(_ string) (string, error) {
+// ListZones returns the names of the DNS zones of the resource group.
+func (c *azureClient) ListZones(ctx context.Context) ([]string, error) {
+	zones := []string{}
+	iterator, err := c.zonesClient.ListByResourceGroupComplete(ctx, c.resourceGroupName, nil)
+	if err != nil {
+		return nil, err
+	}
+	for iterator.NotDone() {
+		if name := iterator.Value().Name; name != nil {
+			zones = append(zones, *name)
+		}
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return zones, nil
+}
+
+func (c *azureClient) GetFedrampHostedZoneIDPath(_ context.Context, _ string) (string, error) {
 	return "", fmt.Errorf("FedRamp is not supported by Azure")
 }
 
-func (c *azureClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
-	zone, err := c.zonesClient.Get(context.TODO(), c.resourceGroupName, cr.Spec.ACMEDNSDomain)
+func (c *azureClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+	zone, err := c.zonesClient.Get(ctx, c.resourceGroupName, utils.ACMEDNSZone(cr))
 	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error getting dns zone %v", cr.Spec.ACMEDNSDomain))
+		reqLogger.Error(err, "Error getting dns zone", "hostedZone", utils.ACMEDNSZone(cr))
 		return "", err
 	}
 
 	txtRecordName := c.generateTxtRecordName(domain, *zone.Name)
-	_, err = c.createTxtRecord(reqLogger, txtRecordName, acmeChallengeToken, *zone.Name)
+	_, err = c.createTxtRecord(ctx, reqLogger, txtRecordName, acmeChallengeToken, *zone.Name)
 
 	if err != nil {
 		reqLogger.Error(err, "Error adding acme challenge DNS entry")
 		return "", err
 	}
 
-	reqLogger.Info(fmt.Sprintf("record set added: %v in DNS Zone: %v", txtRecordName, *zone.Name))
+	reqLogger.Info("record set added", "recordSet", txtRecordName, "hostedZone", *zone.Name)
 
-	return txtRecordName + "." + cr.Spec.ACMEDNSDomain, nil
+	return txtRecordName + "." + utils.ACMEDNSZone(cr), nil
 }
 
-func (c *azureClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
-	zone, err := c.zonesClient.Get(context.TODO(), c.resourceGroupName, cr.Spec.ACMEDNSDomain)
+func (c *azureClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	zone, err := c.zonesClient.Get(ctx, c.resourceGroupName, utils.ACMEDNSZone(cr))
 	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error getting dns zone %v", cr.Spec.ACMEDNSDomain))
+		reqLogger.Error(err, "Error getting dns zone", "hostedZone", utils.ACMEDNSZone(cr))
 		return err
 	}
 
 	for _, dnsName := range cr.Spec.DnsNames {
-		txtRecordName := c.generateTxtRecordName(dnsName, cr.Spec.ACMEDNSDomain)
+		txtRecordName := c.generateTxtRecordName(dnsName, utils.ACMEDNSZone(cr))
 
-		reqLogger.Info(fmt.Sprintf("Deleting record set %v in DNS ZONE: %v", txtRecordName, *zone.Name))
-		_, err = c.recordSetsClient.Delete(context.TODO(), c.resourceGroupName, *zone.Name, txtRecordName, dns.TXT, "")
+		reqLogger.Info("Deleting record set", logging.Domain, dnsName, "recordSet", txtRecordName, "hostedZone", *zone.Name)
+		err = c.deleteTxtRecord(ctx, txtRecordName, *zone.Name)
 
 		if err != nil {
-			reqLogger.Error(err, "Error deleting DNS record: %v from DNS Zone: %v", txtRecordName, *zone.Name)
+			reqLogger.Error(err, "Error deleting DNS record", logging.Domain, dnsName, "recordSet", txtRecordName, "hostedZone", *zone.Name)
 			return err
 		}
 	}
@@ -133,12 +183,13 @@ func (c *azureClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger,
 
 // ValidateDnsWriteAccess spawns a zones client to retrieve the baseDomain's hostedZoneOutput
 // and attempts to write a test TXT ResourceRecord to it. If successful, will return `true, nil`.
-func (c *azureClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+func (c *azureClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (_ bool, err error) {
+	defer func() { err = credentialsError(err) }()
 
-	zone, err := c.zonesClient.Get(context.TODO(), c.resourceGroupName, cr.Spec.ACMEDNSDomain)
+	zone, err := c.zonesClient.Get(ctx, c.resourceGroupName, utils.ACMEDNSZone(cr))
 
 	if err != nil {
-		reqLogger.Error(err, fmt.Sprintf("Error getting dns zone %v", cr.Spec.ACMEDNSDomain))
+		reqLogger.Error(err, "Error getting dns zone", "hostedZone", utils.ACMEDNSZone(cr))
 		return false, err
 	}
 
@@ -149,14 +200,14 @@ func (c *azureClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv
 		return false, nil
 	}
 	// Build the test record
-	_, err = c.createTxtRecord(reqLogger, recordKey, "\"txt_entry\"", *zone.Name)
+	_, err = c.createTxtRecord(ctx, reqLogger, recordKey, "\"txt_entry\"", *zone.Name)
 
 	if err != nil {
 		return false, err
 	}
 
 	// After successful write test clean up the test record and test deletion of that record.
-	_, err = c.recordSetsClient.Delete(context.TODO(), c.resourceGroupName, *zone.Name, recordKey, dns.TXT, "")
+	err = c.deleteTxtRecord(ctx, recordKey, *zone.Name)
 
 	if err != nil {
 		reqLogger.Error(err, "Error while deleting Write Access record")
@@ -166,6 +217,26 @@ func (c *azureClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv
 	return true, nil
 }
 
+// credentialsError wraps err in cTypes.ErrInvalidCredentials if Azure rejected the service principal of the request,
+// or in cTypes.ErrInsufficientPermissions if it is not allowed to make it.
+func credentialsError(err error) error {
+	// the adal.TokenRefreshError of a service principal whose token cannot be acquired
+	var refreshErr interface{ Response() *http.Response }
+	if errors.As(err, &refreshErr) {
+		return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+	}
+	var detailedErr autorest.DetailedError
+	if errors.As(err, &detailedErr) {
+		switch detailedErr.StatusCode {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", cTypes.ErrInsufficientPermissions, err)
+		}
+	}
+	return err
+}
+
 func getAzureCredentialsFromSecret(secret corev1.Secret) (clientID string, clientSecret string, tenantID string, subscriptionID string, err error) {
 
 	var authMap map[string]string
@@ -182,7 +253,7 @@ func getAzureCredentialsFromSecret(secret corev1.Secret) (clientID string, clien
 		return "", "", "", "", fmt.Errorf("key: '%v', secret: '%v', namespace: '%v' doesn't have clientId", azureCredsSPKey, secret.Name, secret.Namespace)
 	}
 	clientSecret, ok = authMap["clientSecret"]
-	if !ok {
+	if _, hasCertificate := secret.Data[azureCredsCertificateKey]; !ok && !hasCertificate {
 		return "", "", "", "", fmt.Errorf("key: '%v', secret: '%v', namespace: '%v' doesn't have clientSecret", azureCredsSPKey, secret.Name, secret.Namespace)
 	}
 	tenantID, ok = authMap["tenantId"]
@@ -197,11 +268,92 @@ func getAzureCredentialsFromSecret(secret corev1.Secret) (clientID string, clien
 	return clientID, clientSecret, tenantID, subscriptionID, nil
 }
 
+// newAuthorizer returns the authorizer of the service principal for resource, authenticated with the client
+// certificate of the secret if it holds one, or with its client secret otherwise.
+func newAuthorizer(secret corev1.Secret, clientID, clientSecret, tenantID, resource string) (autorest.Authorizer, error) {
+	certificateData, ok := secret.Data[azureCredsCertificateKey]
+	if !ok {
+		config := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
+		config.Resource = resource
+		return config.Authorizer()
+	}
+
+	certificate, privateKey, err := decodeClientCertificate(certificateData, string(secret.Data[azureCredsCertificatePasswordKey]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: key: '%v', secret: '%v', namespace: '%v': %w", cTypes.ErrInvalidCredentials, azureCredsCertificateKey, secret.Name, secret.Namespace, err)
+	}
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	token, err := adal.NewServicePrincipalTokenFromCertificate(*oauthConfig, clientID, certificate, privateKey, resource)
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// decodeClientCertificate decodes a client certificate and its RSA private key, either PEM encoded, or a PFX bundle
+// encrypted with password.
+func decodeClientCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		key, certificate, _, err := pkcs12.DecodeChain(data, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.New("the private key of the client certificate is not an RSA key")
+		}
+		return certificate, privateKey, nil
+	}
+
+	var certificate *x509.Certificate
+	var privateKey *rsa.PrivateKey
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate != nil {
+				// the first certificate is the client's, the others its chain
+				continue
+			}
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			certificate = c
+		case "RSA PRIVATE KEY":
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			privateKey = key
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New("the private key of the client certificate is not an RSA key")
+			}
+			privateKey = rsaKey
+		}
+	}
+	if certificate == nil {
+		return nil, nil, errors.New("no certificate found in the PEM data")
+	}
+	if privateKey == nil {
+		return nil, nil, errors.New("no private key found in the PEM data")
+	}
+	return certificate, privateKey, nil
+}
+
 // NewClient returns new Azure DNS client
-func NewClient(kubeClient client.Client, secretName string, namespace string, resourceGroupName string) (*azureClient, error) {
+func NewClient(ctx context.Context, kubeClient client.Client, secretName string, namespace string, resourceGroupName string) (*azureClient, error) {
 	secret := &corev1.Secret{}
 
-	err := kubeClient.Get(context.TODO(),
+	err := kubeClient.Get(ctx,
 		types.NamespacedName{
 			Name:      secretName,
 			Namespace: namespace,
@@ -215,21 +367,21 @@ func NewClient(kubeClient client.Client, secretName string, namespace string, re
 	clientID, clientSecret, tenantID, subscriptionID, err := getAzureCredentialsFromSecret(*secret)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
 	}
 
-	config := auth.NewClientCredentialsConfig(clientID, clientSecret, tenantID)
-
-	authorizer, err := config.Authorizer()
+	authorizer, err := newAuthorizer(*secret, clientID, clientSecret, tenantID, azure.PublicCloud.ResourceManagerEndpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	recordSetsClient := dns.NewRecordSetsClientWithBaseURI(azure.PublicCloud.ResourceManagerEndpoint, subscriptionID)
 	recordSetsClient.Authorizer = authorizer
+	recordSetsClient.Sender = newSender(observeRequests)
 
 	zonesClient := dns.NewZonesClientWithBaseURI(azure.PublicCloud.ResourceManagerEndpoint, subscriptionID)
 	zonesClient.Authorizer = authorizer
+	zonesClient.Sender = newSender(observeRequests)
 
 	return &azureClient{
 		resourceGroupName: resourceGroupName,
@@ -237,3 +389,73 @@ func NewClient(kubeClient client.Client, secretName string, namespace string, re
 		zonesClient:       &zonesClient,
 	}, nil
 }
+
+// newSender returns a sender of the default transport, which honors the cluster-wide proxy, unlike the senders of
+// autorest.CreateSender that only read the proxy environment variables.
+func newSender(decorators ...autorest.SendDecorator) autorest.Sender {
+	return autorest.DecorateSender(&http.Client{}, decorators...)
+}
+
+// observeRequests decorates a sender to count each attempt of the requests to the Azure DNS API in the DNS API
+// metrics.
+func observeRequests(s autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		response, err := s.Do(r)
+
+		code := ""
+		switch {
+		case err != nil:
+			code = "error"
+		case response.StatusCode >= http.StatusBadRequest:
+			code = strconv.Itoa(response.StatusCode)
+		}
+		throttled := err == nil && response.StatusCode == http.StatusTooManyRequests
+		localmetrics.ObserveDNSAPIRequest(providerName, operation(r), code, throttled)
+
+		return response, err
+	})
+}
+
+// operation names the Azure DNS API operation of a request after the resource its path addresses and its method,
+// such as RecordSets.CreateOrUpdate.
+func operation(r *http.Request) string {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	zoneIndex := -1
+	for i, segment := range segments {
+		if strings.EqualFold(segment, "dnszones") {
+			zoneIndex = i
+		}
+	}
+	if zoneIndex == -1 {
+		return "unknown"
+	}
+
+	// the segments below dnsZones are the zone, then the record type or "recordsets", then the record set
+	rest := segments[zoneIndex+1:]
+	switch {
+	case len(rest) == 0:
+		return "Zones.List"
+	case len(rest) == 1:
+		return "Zones." + itemVerb(r.Method)
+	case len(rest) == 2 && (strings.EqualFold(rest[1], "recordsets") || strings.EqualFold(rest[1], "all")):
+		return "RecordSets.ListByDNSZone"
+	case len(rest) == 2:
+		return "RecordSets.ListByType"
+	}
+	return "RecordSets." + itemVerb(r.Method)
+}
+
+// itemVerb names the operation of method on a single resource.
+func itemVerb(method string) string {
+	switch method {
+	case http.MethodGet:
+		return "Get"
+	case http.MethodPut:
+		return "CreateOrUpdate"
+	case http.MethodPatch:
+		return "Update"
+	case http.MethodDelete:
+		return "Delete"
+	}
+	return method
+}
@@ -17,7 +17,16 @@ limitations under the License.
 package azure
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -26,12 +35,17 @@ import (
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/go-logr/logr"
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 func TestNewClient(t *testing.T) {
@@ -53,12 +67,18 @@ func TestNewClient(t *testing.T) {
 			wantError:   false,
 			err:         nil,
 		},
+		{
+			description: "returns a client if a client certificate is set",
+			secret:      getAzureCertificateSecret(t, validCertificateSecretData),
+			wantError:   false,
+			err:         nil,
+		},
 	}
 	for _, tt := range clientTests {
 		t.Run(tt.description, func(t *testing.T) {
 			testClient := setUpTestClient(t, tt.secret)
 
-			client, err := NewClient(testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
+			client, err := NewClient(context.TODO(), testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
 
 			if tt.wantError {
 				if err == nil || tt.err.Error() != err.Error() {
@@ -177,6 +197,106 @@ func TestGetAzureCredentialsFromSecret(t *testing.T) {
 	}
 }
 
+func TestDecodeClientCertificate(t *testing.T) {
+	certificate, key := newClientCertificate(t)
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfx, err := pkcs12.Modern.Encode(key, certificate, nil, "changeit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		data        []byte
+		password    string
+		wantError   bool
+	}{
+		{
+			description: "decodes a PEM certificate with a PKCS#1 key",
+			data:        append(certificatePEM, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...),
+		},
+		{
+			description: "decodes a PEM PKCS#8 key followed by its certificate",
+			data:        append(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), certificatePEM...),
+		},
+		{
+			description: "decodes a PFX bundle",
+			data:        pfx,
+			password:    "changeit",
+		},
+		{
+			description: "returns an error for a PFX bundle with the wrong password",
+			data:        pfx,
+			password:    "wrong",
+			wantError:   true,
+		},
+		{
+			description: "returns an error for a PEM certificate without a key",
+			data:        certificatePEM,
+			wantError:   true,
+		},
+		{
+			description: "returns an error for an ECDSA key",
+			data:        append(certificatePEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPKCS8})...),
+			wantError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			decodedCertificate, decodedKey, err := decodeClientCertificate(tt.data, tt.password)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, certificate.Equal(decodedCertificate))
+			assert.True(t, key.Equal(decodedKey))
+		})
+	}
+}
+
+// newClientCertificate returns a self-signed client certificate and its RSA key.
+func newClientCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "certman-operator"}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate, key
+}
+
+// getAzureCertificateSecret returns an Azure credentials secret holding a PEM client certificate.
+func getAzureCertificateSecret(t *testing.T, credsData string) *v1.Secret {
+	certificate, key := newClientCertificate(t)
+	secret := getAzureSecret(credsData)
+	secret.Data[azureCredsCertificateKey] = append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...)
+	return secret
+}
+
 // helpers
 var testHiveNamespace = "uhc-doesntexist-123456"
 var testHiveCertificateRequestName = "clustername-1313-0-primary-cert-bundle"
@@ -198,6 +318,11 @@ var validSecretData = "{" +
 	"\"tenantId\":\"" + testTenantID + "\"," +
 	"\"subscriptionId\":\"" + testSubscriptionID + "\"" +
 	"}"
+var validCertificateSecretData = "{" +
+	"\"clientId\":\"" + testClientID + "\"," +
+	"\"tenantId\":\"" + testTenantID + "\"," +
+	"\"subscriptionId\":\"" + testSubscriptionID + "\"" +
+	"}"
 
 var certRequest = &certmanv1alpha1.CertificateRequest{
 	ObjectMeta: metav1.ObjectMeta{
@@ -364,7 +489,7 @@ func TestAnswerDNSChallenge(t *testing.T) {
 			defer server.Close()
 
 			testClient := setUpTestClient(t, getAzureSecret(validSecretData))
-			client, err := NewClient(testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
+			client, err := NewClient(context.TODO(), testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
 			if err != nil {
 				t.Fatalf("Failed to create client: %v", err)
 			}
@@ -377,7 +502,7 @@ func TestAnswerDNSChallenge(t *testing.T) {
 			client.zonesClient.Authorizer = &mockAuthorizer{}
 			client.recordSetsClient.Authorizer = &mockAuthorizer{}
 
-			fqdn, err := client.AnswerDNSChallenge(logr.Discard(), tt.acmeChallengeToken, tt.domain, tt.cr, tt.cr.Spec.ACMEDNSDomain)
+			fqdn, err := client.AnswerDNSChallenge(context.TODO(), logr.Discard(), tt.acmeChallengeToken, tt.domain, tt.cr, tt.cr.Spec.ACMEDNSDomain)
 
 			if tt.expectError {
 				if err == nil {
@@ -470,7 +595,7 @@ func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
 			defer server.Close()
 
 			testClient := setUpTestClient(t, getAzureSecret(validSecretData))
-			client, err := NewClient(testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
+			client, err := NewClient(context.TODO(), testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
 			if err != nil {
 				t.Fatalf("Failed to create client: %v", err)
 			}
@@ -483,7 +608,7 @@ func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
 			client.recordSetsClient.Authorizer = &mockAuthorizer{}
 
 			// Test DeleteAcmeChallengeResourceRecords with mock Azure API
-			err = client.DeleteAcmeChallengeResourceRecords(logr.Discard(), tt.cr)
+			err = client.DeleteAcmeChallengeResourceRecords(context.TODO(), logr.Discard(), tt.cr)
 
 			if tt.expectError {
 				if err == nil {
@@ -678,7 +803,7 @@ func TestValidateDNSWriteAccess(t *testing.T) {
 
 			// Create Azure client
 			testClient := setUpTestClient(t, getAzureSecret(validSecretData))
-			client, err := NewClient(testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
+			client, err := NewClient(context.TODO(), testClient, testHiveAzureSecretName, testHiveNamespace, testHiveResourceGroupName)
 			if err != nil {
 				t.Fatalf("Failed to create client: %v", err)
 			}
@@ -690,7 +815,7 @@ func TestValidateDNSWriteAccess(t *testing.T) {
 			client.zonesClient.Authorizer = &mockAuthorizer{}
 			client.recordSetsClient.Authorizer = &mockAuthorizer{}
 
-			result, err := client.ValidateDNSWriteAccess(logr.Discard(), tt.cr)
+			result, err := client.ValidateDNSWriteAccess(context.TODO(), logr.Discard(), tt.cr)
 
 			if tt.expectError {
 				if err == nil {
@@ -732,3 +857,61 @@ func TestValidateDNSWriteAccess(t *testing.T) {
 		})
 	}
 }
+
+func TestCredentialsError(t *testing.T) {
+	assert.ErrorIs(t, credentialsError(autorest.DetailedError{StatusCode: http.StatusUnauthorized}), cTypes.ErrInvalidCredentials)
+	assert.ErrorIs(t, credentialsError(autorest.DetailedError{StatusCode: http.StatusForbidden}), cTypes.ErrInsufficientPermissions)
+
+	err := credentialsError(autorest.DetailedError{StatusCode: http.StatusNotFound})
+	assert.NotErrorIs(t, err, cTypes.ErrInvalidCredentials)
+	assert.NotErrorIs(t, err, cTypes.ErrInsufficientPermissions)
+}
+
+func TestOperation(t *testing.T) {
+	const zones = "https://management.azure.com/subscriptions/s/resourceGroups/rg/providers/Microsoft.Network/dnsZones"
+	tests := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{method: http.MethodGet, url: zones + "?api-version=2018-05-01", expected: "Zones.List"},
+		{method: http.MethodGet, url: zones + "/example.com", expected: "Zones.Get"},
+		{method: http.MethodGet, url: zones + "/example.com/recordsets", expected: "RecordSets.ListByDNSZone"},
+		{method: http.MethodGet, url: zones + "/example.com/TXT", expected: "RecordSets.ListByType"},
+		{method: http.MethodPut, url: zones + "/example.com/TXT/_acme-challenge", expected: "RecordSets.CreateOrUpdate"},
+		{method: http.MethodDelete, url: zones + "/example.com/TXT/_acme-challenge", expected: "RecordSets.Delete"},
+		{method: http.MethodGet, url: "https://management.azure.com/subscriptions/s", expected: "unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			r, err := http.NewRequest(test.method, test.url, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, operation(r))
+		})
+	}
+}
+
+func TestObserveRequests(t *testing.T) {
+	localmetrics.MetricDNSAPIRequests.Reset()
+	localmetrics.MetricDNSAPIErrors.Reset()
+	localmetrics.MetricDNSAPIThrottled.Reset()
+
+	responses := []int{http.StatusOK, http.StatusTooManyRequests}
+	sender := observeRequests(autorest.SenderFunc(func(*http.Request) (*http.Response, error) {
+		status := responses[0]
+		responses = responses[1:]
+		return &http.Response{StatusCode: status}, nil
+	}))
+
+	for range 2 {
+		r, err := http.NewRequest(http.MethodPut, "https://management.azure.com/subscriptions/s/resourceGroups/rg/providers/Microsoft.Network/dnsZones/example.com/TXT/_acme-challenge", nil)
+		assert.NoError(t, err)
+		_, err = sender.Do(r)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(localmetrics.MetricDNSAPIRequests.WithLabelValues("azure", "RecordSets.CreateOrUpdate")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("azure", "RecordSets.CreateOrUpdate", "429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIThrottled.WithLabelValues("azure", "RecordSets.CreateOrUpdate")))
+}
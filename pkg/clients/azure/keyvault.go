@@ -0,0 +1,154 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	keyVaultAPIVersion = "7.1"
+	pkcs12ContentType  = "application/x-pkcs12"
+)
+
+// KeyVaultClient imports certificates into Azure Key Vaults.
+type KeyVaultClient interface {
+	// ImportCertificate imports the PKCS#12 bundle pfx, encrypted with password, as a new version of the certificate
+	// name of the key vault at vaultURL, and returns the identifier of that version.
+	ImportCertificate(ctx context.Context, vaultURL, name string, pfx []byte, password string) (string, error)
+}
+
+// keyVaultClient calls the Key Vault data plane REST API.
+type keyVaultClient struct {
+	authorizer autorest.Authorizer
+	sender     autorest.Sender
+}
+
+type keyVaultImportRequest struct {
+	Value  string               `json:"value"`
+	Pwd    string               `json:"pwd,omitempty"`
+	Policy keyVaultImportPolicy `json:"policy"`
+}
+
+type keyVaultImportPolicy struct {
+	SecretProps keyVaultSecretProperties `json:"secret_props"`
+}
+
+type keyVaultSecretProperties struct {
+	ContentType string `json:"contentType"`
+}
+
+type keyVaultCertificateBundle struct {
+	ID string `json:"id"`
+}
+
+type keyVaultError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewKeyVaultClient returns a KeyVaultClient authenticated as the service principal in the osServicePrincipal.json
+// of the secret, with its client certificate if the secret holds one.
+func NewKeyVaultClient(ctx context.Context, kubeClient client.Client, secretName string, namespace string) (KeyVaultClient, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, clientSecret, tenantID, _, err := getAzureCredentialsFromSecret(*secret)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizer, err := newAuthorizer(*secret, clientID, clientSecret, tenantID, strings.TrimSuffix(azure.PublicCloud.ResourceIdentifiers.KeyVault, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyVaultClient{
+		authorizer: authorizer,
+		sender:     newSender(),
+	}, nil
+}
+
+func (c *keyVaultClient) ImportCertificate(ctx context.Context, vaultURL, name string, pfx []byte, password string) (string, error) {
+	body, err := json.Marshal(&keyVaultImportRequest{
+		Value:  base64.StdEncoding.EncodeToString(pfx),
+		Pwd:    password,
+		Policy: keyVaultImportPolicy{SecretProps: keyVaultSecretProperties{ContentType: pkcs12ContentType}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	importURL := fmt.Sprintf("%s/certificates/%s/import?api-version=%s", strings.TrimSuffix(vaultURL, "/"), url.PathEscape(name), keyVaultAPIVersion)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, importURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	request, err = autorest.Prepare(request, c.authorizer.WithAuthorization())
+	if err != nil {
+		return "", fmt.Errorf("failed to authorize the import of certificate %s: %w", name, err)
+	}
+
+	response, err := c.sender.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		kvErr := &keyVaultError{}
+		if json.Unmarshal(data, kvErr) == nil && kvErr.Error.Message != "" {
+			return "", fmt.Errorf("key vault %s refused to import certificate %s: %s: %s", vaultURL, name, kvErr.Error.Code, kvErr.Error.Message)
+		}
+		return "", fmt.Errorf("key vault %s refused to import certificate %s: %s", vaultURL, name, response.Status)
+	}
+
+	bundle := &keyVaultCertificateBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return "", fmt.Errorf("failed to decode the certificate imported into key vault %s: %w", vaultURL, err)
+	}
+	if bundle.ID == "" {
+		return "", fmt.Errorf("key vault %s returned no identifier for certificate %s", vaultURL, name)
+	}
+	return bundle.ID, nil
+}
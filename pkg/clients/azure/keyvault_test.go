@@ -0,0 +1,98 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeyVaultClient(t *testing.T) {
+	t.Run("returns an error if the credentials aren't set", func(t *testing.T) {
+		_, err := NewKeyVaultClient(context.TODO(), setUpTestClient(t, nil), testHiveAzureSecretName, testHiveNamespace)
+		assert.Error(t, err)
+	})
+
+	t.Run("returns a client if the credentials are set", func(t *testing.T) {
+		c, err := NewKeyVaultClient(context.TODO(), setUpTestClient(t, getAzureSecret(validSecretData)), testHiveAzureSecretName, testHiveNamespace)
+		assert.NoError(t, err)
+		assert.NotNil(t, c)
+	})
+}
+
+func TestImportCertificate(t *testing.T) {
+	tests := []struct {
+		description string
+		status      int
+		response    string
+		wantID      string
+		wantError   string
+	}{
+		{
+			description: "returns the identifier of the imported version",
+			status:      http.StatusOK,
+			response:    `{"id": "https://certs.vault.azure.net/certificates/api/0123"}`,
+			wantID:      "https://certs.vault.azure.net/certificates/api/0123",
+		},
+		{
+			description: "returns the error of the key vault",
+			status:      http.StatusForbidden,
+			response:    `{"error": {"code": "Forbidden", "message": "caller is not authorized"}}`,
+			wantError:   "Forbidden: caller is not authorized",
+		},
+		{
+			description: "fails without an identifier",
+			status:      http.StatusOK,
+			response:    `{}`,
+			wantError:   "returned no identifier",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			var got keyVaultImportRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/certificates/api/import", r.URL.Path)
+				assert.Equal(t, keyVaultAPIVersion, r.URL.Query().Get("api-version"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			c := &keyVaultClient{authorizer: autorest.NullAuthorizer{}, sender: server.Client()}
+			id, err := c.ImportCertificate(context.TODO(), server.URL+"/", "api", []byte("pfx"), "secret")
+
+			if tt.wantError != "" {
+				assert.ErrorContains(t, err, tt.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, id)
+			assert.Equal(t, "cGZ4", got.Value)
+			assert.Equal(t, "secret", got.Pwd)
+			assert.Equal(t, pkcs12ContentType, got.Policy.SecretProps.ContentType)
+		})
+	}
+}
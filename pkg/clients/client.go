@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/go-logr/logr"
@@ -8,10 +9,13 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/clients/acmedns"
 	"github.com/openshift/certman-operator/pkg/clients/aws"
 	"github.com/openshift/certman-operator/pkg/clients/azure"
+	"github.com/openshift/certman-operator/pkg/clients/cloudflare"
 	"github.com/openshift/certman-operator/pkg/clients/gcp"
 	mockclient "github.com/openshift/certman-operator/pkg/clients/mock"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
 )
 
 var (
@@ -21,28 +25,59 @@ var (
 // Client is a wrapper object for actual AWS SDK clients to allow for easier testing.
 type Client interface {
 	// Client methods
-	GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (string, error)
+	GetFedrampHostedZoneIDPath(ctx context.Context, fedrampHostedZoneID string) (string, error)
 	GetDNSName() string
-	AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error)
-	ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error)
-	DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error
+	AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error)
+	ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error)
+	DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error
+	ListZones(ctx context.Context) ([]string, error)
 }
 
-// NewClient returns an individual cloud implementation based on CertificateRequest cloud coniguration
-func NewClient(reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (Client, error) {
+// BatchClient is implemented by the clients of DNS providers able to present the challenges of an order in a
+// single change, whose records must then hold several values when the challenges of a domain and its wildcard share
+// a name.
+type BatchClient interface {
+	// AnswerDNSChallenges answers the challenges in one change and waits for it to be applied by the provider. It
+	// returns the fqdn of the record of each challenge, in the order of the challenges.
+	AnswerDNSChallenges(ctx context.Context, reqLogger logr.Logger, challenges []cTypes.DNSChallenge, cr *certmanv1alpha1.CertificateRequest, dnsZone string) ([]string, error)
+}
+
+// NewClient returns an individual cloud implementation based on CertificateRequest cloud coniguration. ctx bounds
+// the calls made to build the client; the client's own calls are bounded by the context passed to each of them.
+func NewClient(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (Client, error) {
+	c, err := newPlatformClient(ctx, reqLogger, kubeClient, platform, namespace, clusterDeploymentName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.(BatchClient); ok {
+		return &tracedBatchClient{tracedClient: tracedClient{Client: c}}, nil
+	}
+	return &tracedClient{Client: c}, nil
+}
+
+// newPlatformClient returns the cloud implementation of the platform.
+func newPlatformClient(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (Client, error) {
 	// TODO: Add multicloud checking here
 	if platform.AWS != nil {
 		log.Info("build aws client")
-		return aws.NewClient(reqLogger, kubeClient, platform.AWS.Credentials.Name, namespace, platform.AWS.Region, clusterDeploymentName)
+		return aws.NewClient(ctx, reqLogger, kubeClient, *platform.AWS, namespace, clusterDeploymentName)
 	}
 	if platform.GCP != nil {
 		log.Info("build gcp client")
 		// TODO: Add project as configurable
-		return gcp.NewClient(kubeClient, platform.GCP.Credentials.Name, namespace)
+		return gcp.NewClient(ctx, kubeClient, platform.GCP.Credentials.Name, namespace)
 	}
 	if platform.Azure != nil {
 		log.Info("Build Azure client")
-		return azure.NewClient(kubeClient, platform.Azure.Credentials.Name, namespace, platform.Azure.ResourceGroupName)
+		return azure.NewClient(ctx, kubeClient, platform.Azure.Credentials.Name, namespace, platform.Azure.ResourceGroupName)
+	}
+	if platform.Cloudflare != nil {
+		log.Info("build cloudflare client")
+		return cloudflare.NewClient(ctx, kubeClient, platform.Cloudflare.Credentials.Name, namespace)
+	}
+	if platform.AcmeDNS != nil {
+		log.Info("build acme-dns client")
+		return acmedns.NewClient(ctx, kubeClient, platform.AcmeDNS.Host, platform.AcmeDNS.Credentials.Name, namespace)
 	}
 	// NOTE this allows a mock client to be created from a Mock platform secret defined in the platform
 	// this allows for better testing of controllers but should be avoided in a live system for obvious reasons
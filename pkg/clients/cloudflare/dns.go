@@ -0,0 +1,390 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/clients/guardrail"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	apiTokenKey       = "api_token" //#nosec - G101: Potential hardcoded credentials
+	apiURL            = "https://api.cloudflare.com/client/v4"
+	resourceRecordTTL = 60
+	recordsPerPage    = 100
+	providerName      = "cloudflare"
+
+	// the error codes of the Cloudflare API rejecting an API token
+	invalidTokenErrorCode   = 9109
+	authenticationErrorCode = 10000
+)
+
+// cloudflareClient implements the Client interface
+type cloudflareClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiToken   string
+}
+
+// zone is a zone of the Cloudflare API.
+type zone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// dnsRecord is a DNS record of the Cloudflare API.
+type dnsRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl,omitempty"`
+}
+
+// response is the envelope of the responses of the Cloudflare API.
+type response struct {
+	Success    bool            `json:"success"`
+	Errors     []apiError      `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo *struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (c *cloudflareClient) GetDNSName() string {
+	return "Cloudflare"
+}
+
+// ListZones returns the names of the zones the API token can read.
+func (c *cloudflareClient) ListZones(ctx context.Context) ([]string, error) {
+	var zones []zone
+	if err := c.list(ctx, "zones.list", "/zones", url.Values{}, func(result json.RawMessage) error {
+		var page []zone
+		err := json.Unmarshal(result, &page)
+		zones = append(zones, page...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, z := range zones {
+		names = append(names, z.Name)
+	}
+	return names, nil
+}
+
+func (c *cloudflareClient) GetFedrampHostedZoneIDPath(_ context.Context, _ string) (string, error) {
+	return "", fmt.Errorf("fedRamp is not supported by Cloudflare")
+}
+
+func (c *cloudflareClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+	fqdn = fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, domain)
+	reqLogger.Info("answering the acme challenge", "fqdn", fqdn)
+
+	// Calls function to get the zone of the domain of our CertificateRequest
+	z, err := c.getZone(ctx, utils.ACMEDNSZone(cr))
+	if err != nil {
+		reqLogger.Error(err, "Unable to find appropriate zone")
+		return "", err
+	}
+
+	// add/update challenge record
+	err = c.upsertDnsRecord(ctx, z, &dnsRecord{
+		Type:    "TXT",
+		Name:    strings.TrimSuffix(fqdn, "."),
+		Content: acmeChallengeToken,
+		TTL:     resourceRecordTTL,
+	})
+	if err != nil {
+		return "", err
+	}
+	reqLogger.Info("updated zone", "zone", z.Name)
+	return fqdn, nil
+}
+
+// ValidateDNSWriteAccess retrieves the zone of the baseDomain and attempts to write a test TXT record to it. If
+// successful, will return `true, nil`.
+func (c *cloudflareClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+	// Calls function to get the zone of the domain of our CertificateRequest
+	z, err := c.getZone(ctx, utils.ACMEDNSZone(cr))
+	if err != nil {
+		reqLogger.Error(err, "Unable to find appropriate zone")
+		return false, err
+	}
+
+	record := &dnsRecord{
+		Type:    "TXT",
+		Name:    fmt.Sprintf("%s.%s", cTypes.WriteValidationSubDomain, z.Name),
+		Content: "txt_entry",
+		TTL:     resourceRecordTTL,
+	}
+
+	reqLogger.Info("updating zone", "zone", z.Name)
+
+	// test if we can add a record
+	err = c.upsertDnsRecord(ctx, z, record)
+	if err != nil {
+		return false, err
+	}
+
+	// clean up record
+	err = c.deleteDnsRecords(ctx, z, []dnsRecord{*record})
+	if err != nil {
+		reqLogger.Error(err, "Error while deleting Write Access record")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteAcmeChallengeResourceRecords deletes the TXT records of the zone of the CertificateRequest whose names begin
+// with the acme challenge or the write validation label.
+func (c *cloudflareClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	// Calls function to get the zone of the domain of our CertificateRequest
+	z, err := c.getZone(ctx, utils.ACMEDNSZone(cr))
+	if err != nil {
+		reqLogger.Error(err, "Unable to find appropriate zone")
+		return err
+	}
+
+	records, err := c.listDnsRecords(ctx, z, url.Values{"type": {"TXT"}})
+	if err != nil {
+		return err
+	}
+
+	var deletions []dnsRecord
+	for _, record := range records {
+		if strings.HasPrefix(record.Name, cTypes.AcmeChallengeSubDomain+".") || strings.HasPrefix(record.Name, cTypes.WriteValidationSubDomain+".") {
+			deletions = append(deletions, record)
+		}
+	}
+
+	// clean up records
+	return c.deleteDnsRecords(ctx, z, deletions)
+}
+
+// NewClient returns a Cloudflare DNS client using the API token of the secret.
+func NewClient(ctx context.Context, kubeClient client.Client, secretName, namespace string) (*cloudflareClient, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	apiToken := strings.TrimSpace(string(secret.Data[apiTokenKey]))
+	if apiToken == "" {
+		return nil, fmt.Errorf("%w: secret %s/%s has no %s", cTypes.ErrInvalidCredentials, namespace, secretName, apiTokenKey)
+	}
+
+	return &cloudflareClient{
+		httpClient: http.DefaultClient,
+		baseURL:    apiURL,
+		apiToken:   apiToken,
+	}, nil
+}
+
+// getZone finds and returns the zone matching the baseDomain provided
+func (c *cloudflareClient) getZone(ctx context.Context, baseDomain string) (*zone, error) {
+	baseDomain = strings.TrimSuffix(baseDomain, ".")
+
+	var zones []zone
+	if err := c.list(ctx, "zones.list", "/zones", url.Values{"name": {baseDomain}}, func(result json.RawMessage) error {
+		var page []zone
+		err := json.Unmarshal(result, &page)
+		zones = append(zones, page...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	for i := range zones {
+		if strings.EqualFold(baseDomain, zones[i].Name) {
+			return &zones[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find zone matching baseDomain: %s", baseDomain)
+}
+
+// listDnsRecords returns the DNS records of the zone matching query.
+func (c *cloudflareClient) listDnsRecords(ctx context.Context, z *zone, query url.Values) ([]dnsRecord, error) {
+	var records []dnsRecord
+	err := c.list(ctx, "dnsRecords.list", fmt.Sprintf("/zones/%s/dns_records", z.ID), query, func(result json.RawMessage) error {
+		var page []dnsRecord
+		err := json.Unmarshal(result, &page)
+		records = append(records, page...)
+		return err
+	})
+	return records, err
+}
+
+// upsertDnsRecord ensures that record is the only record of its name and type in the zone
+func (c *cloudflareClient) upsertDnsRecord(ctx context.Context, z *zone, record *dnsRecord) error {
+	if err := guardrail.CheckRecord(providerName, "dnsRecords.create", record.Name, record.Type, z.Name); err != nil {
+		return err
+	}
+	existing, err := c.listDnsRecords(ctx, z, url.Values{"type": {record.Type}, "name": {record.Name}})
+	if err != nil {
+		return fmt.Errorf("error retrieving records for %q: %w", z.Name, err)
+	}
+	if err := c.deleteDnsRecords(ctx, z, existing); err != nil {
+		return err
+	}
+	return c.do(ctx, "dnsRecords.create", http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", z.ID), nil, record, nil)
+}
+
+// deleteDnsRecords deletes the records of the zone, finding the ones without an ID by name and type.
+func (c *cloudflareClient) deleteDnsRecords(ctx context.Context, z *zone, records []dnsRecord) error {
+	for _, record := range records {
+		if err := guardrail.CheckRecord(providerName, "dnsRecords.delete", record.Name, record.Type, z.Name); err != nil {
+			return err
+		}
+		ids := []string{record.ID}
+		if record.ID == "" {
+			existing, err := c.listDnsRecords(ctx, z, url.Values{"type": {record.Type}, "name": {record.Name}})
+			if err != nil {
+				return err
+			}
+			ids = nil
+			for _, e := range existing {
+				ids = append(ids, e.ID)
+			}
+		}
+		for _, id := range ids {
+			if err := c.do(ctx, "dnsRecords.delete", http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", z.ID, id), nil, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// list requests every page of a collection of the Cloudflare API, passing the result of each to handle.
+func (c *cloudflareClient) list(ctx context.Context, operation, path string, query url.Values, handle func(json.RawMessage) error) error {
+	for page := 1; ; page++ {
+		pageQuery := url.Values{}
+		for key, values := range query {
+			pageQuery[key] = values
+		}
+		pageQuery.Set("page", strconv.Itoa(page))
+		pageQuery.Set("per_page", strconv.Itoa(recordsPerPage))
+
+		resp := &response{}
+		if err := c.do(ctx, operation, http.MethodGet, path, pageQuery, nil, resp); err != nil {
+			return err
+		}
+		if err := handle(resp.Result); err != nil {
+			return err
+		}
+		if resp.ResultInfo == nil || page >= resp.ResultInfo.TotalPages {
+			return nil
+		}
+	}
+}
+
+// do sends a request to the Cloudflare API, counting it in the DNS API metrics, and decodes its response into result
+// if set.
+func (c *cloudflareClient) do(ctx context.Context, operation, method, path string, query url.Values, body interface{}, result *response) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		localmetrics.ObserveDNSAPIRequest(providerName, operation, "error", false)
+		return err
+	}
+	defer resp.Body.Close()
+
+	code := ""
+	if resp.StatusCode >= http.StatusBadRequest {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	localmetrics.ObserveDNSAPIRequest(providerName, operation, code, resp.StatusCode == http.StatusTooManyRequests)
+
+	if result == nil {
+		result = &response{}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("cloudflare %s returned %s: %w", operation, resp.Status, err)
+	}
+	if !result.Success || resp.StatusCode >= http.StatusBadRequest {
+		messages := []string{}
+		for _, e := range result.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", e.Code, e.Message))
+		}
+		err := fmt.Errorf("cloudflare %s returned %s: %s", operation, resp.Status, strings.Join(messages, "; "))
+		return credentialsError(resp.StatusCode, result.Errors, err)
+	}
+	return nil
+}
+
+// credentialsError wraps err in cTypes.ErrInvalidCredentials if Cloudflare rejected the API token of the request, or
+// in cTypes.ErrInsufficientPermissions if it is not allowed to make it.
+func credentialsError(statusCode int, apiErrors []apiError, err error) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+	case http.StatusForbidden:
+		for _, e := range apiErrors {
+			if e.Code == invalidTokenErrorCode || e.Code == authenticationErrorCode {
+				return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+			}
+		}
+		return fmt.Errorf("%w: %w", cTypes.ErrInsufficientPermissions, err)
+	}
+	return err
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+const (
+	testToken  = "token"
+	testZoneID = "zone0"
+)
+
+var testCertRequest = &certmanv1alpha1.CertificateRequest{
+	ObjectMeta: metav1.ObjectMeta{Name: "cr", Namespace: "ns"},
+	Spec:       certmanv1alpha1.CertificateRequestSpec{ACMEDNSDomain: "example.com"},
+}
+
+// fakeAPI serves the zone example.com and its records like the Cloudflare API.
+type fakeAPI struct {
+	records []dnsRecord
+	nextID  int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "errors": []apiError{{Code: 9109, Message: "Invalid access token"}}})
+		return
+	}
+
+	var result interface{}
+	recordsPath := fmt.Sprintf("/zones/%s/dns_records", testZoneID)
+	switch {
+	case r.URL.Path == "/zones":
+		zones := []zone{}
+		if name := r.URL.Query().Get("name"); name == "" || name == "example.com" {
+			zones = append(zones, zone{ID: testZoneID, Name: "example.com"})
+		}
+		result = zones
+	case r.URL.Path == recordsPath && r.Method == http.MethodGet:
+		records := []dnsRecord{}
+		for _, record := range f.records {
+			if name := r.URL.Query().Get("name"); name != "" && name != record.Name {
+				continue
+			}
+			if recordType := r.URL.Query().Get("type"); recordType != "" && recordType != record.Type {
+				continue
+			}
+			records = append(records, record)
+		}
+		result = records
+	case r.URL.Path == recordsPath && r.Method == http.MethodPost:
+		record := dnsRecord{}
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		record.ID = fmt.Sprintf("record%d", f.nextID)
+		f.nextID++
+		f.records = append(f.records, record)
+		result = record
+	case strings.HasPrefix(r.URL.Path, recordsPath+"/") && r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(r.URL.Path, recordsPath+"/")
+		for i, record := range f.records {
+			if record.ID == id {
+				f.records = append(f.records[:i], f.records[i+1:]...)
+				break
+			}
+		}
+		result = map[string]string{"id": id}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "errors": []apiError{{Code: 7003, Message: "Could not route"}}})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result, "result_info": map[string]int{"page": 1, "total_pages": 1}})
+}
+
+func newTestClient(t *testing.T, api *fakeAPI, token string) *cloudflareClient {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return &cloudflareClient{httpClient: server.Client(), baseURL: server.URL, apiToken: token}
+}
+
+func TestNewClient(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cloudflare", Namespace: "ns"}, Data: map[string][]byte{apiTokenKey: []byte("token\n")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "ns"}},
+	).Build()
+
+	c, err := NewClient(context.TODO(), kubeClient, "cloudflare", "ns")
+	require.NoError(t, err)
+	assert.Equal(t, "token", c.apiToken)
+	assert.Equal(t, apiURL, c.baseURL)
+
+	_, err = NewClient(context.TODO(), kubeClient, "empty", "ns")
+	assert.Error(t, err)
+
+	_, err = NewClient(context.TODO(), kubeClient, "missing", "ns")
+	assert.Error(t, err)
+}
+
+func TestListZones(t *testing.T) {
+	zones, err := newTestClient(t, &fakeAPI{}, testToken).ListZones(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com"}, zones)
+
+	_, err = newTestClient(t, &fakeAPI{}, "wrong").ListZones(context.TODO())
+	assert.ErrorContains(t, err, "Invalid access token")
+}
+
+func TestAnswerDNSChallenge(t *testing.T) {
+	api := &fakeAPI{records: []dnsRecord{{ID: "old", Type: "TXT", Name: "_acme-challenge.api.example.com", Content: "old"}}}
+	c := newTestClient(t, api, testToken)
+
+	fqdn, err := c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "api.example.com", testCertRequest, "")
+	require.NoError(t, err)
+	assert.Equal(t, "_acme-challenge.api.example.com", fqdn)
+	require.Len(t, api.records, 1)
+	assert.Equal(t, "challenge", api.records[0].Content)
+	assert.Equal(t, resourceRecordTTL, api.records[0].TTL)
+
+	other := testCertRequest.DeepCopy()
+	other.Spec.ACMEDNSDomain = "example.org"
+	_, err = c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "api.example.org", other, "")
+	assert.ErrorContains(t, err, "unable to find zone")
+}
+
+func TestValidateDNSWriteAccess(t *testing.T) {
+	api := &fakeAPI{}
+	ok, err := newTestClient(t, api, testToken).ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, api.records)
+}
+
+func TestAnswerDNSChallengeOutsideOfZone(t *testing.T) {
+	api := &fakeAPI{}
+	c := newTestClient(t, api, testToken)
+
+	_, err := c.AnswerDNSChallenge(context.TODO(), logr.Discard(), "challenge", "api.example.org", testCertRequest, "")
+	assert.ErrorIs(t, err, cTypes.ErrRecordRefused)
+	assert.Empty(t, api.records)
+}
+
+func TestCredentialsError(t *testing.T) {
+	_, err := newTestClient(t, &fakeAPI{}, "revoked").ValidateDNSWriteAccess(context.TODO(), logr.Discard(), testCertRequest)
+	assert.ErrorIs(t, err, cTypes.ErrInvalidCredentials)
+
+	err = credentialsError(http.StatusUnauthorized, nil, errors.New("unauthorized"))
+	assert.ErrorIs(t, err, cTypes.ErrInvalidCredentials)
+
+	err = credentialsError(http.StatusForbidden, []apiError{{Code: 10000, Message: "Authentication error"}}, errors.New("forbidden"))
+	assert.ErrorIs(t, err, cTypes.ErrInvalidCredentials)
+
+	err = credentialsError(http.StatusForbidden, []apiError{{Code: 9999, Message: "Forbidden"}}, errors.New("forbidden"))
+	assert.ErrorIs(t, err, cTypes.ErrInsufficientPermissions)
+
+	err = credentialsError(http.StatusNotFound, nil, errors.New("not found"))
+	assert.NotErrorIs(t, err, cTypes.ErrInvalidCredentials)
+	assert.NotErrorIs(t, err, cTypes.ErrInsufficientPermissions)
+}
+
+func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
+	api := &fakeAPI{records: []dnsRecord{
+		{ID: "a", Type: "TXT", Name: "_acme-challenge.api.example.com", Content: "a"},
+		{ID: "b", Type: "TXT", Name: "_certman_access_test.example.com", Content: "b"},
+		{ID: "c", Type: "TXT", Name: "example.com", Content: "v=spf1 -all"},
+		{ID: "d", Type: "A", Name: "api.example.com", Content: "192.0.2.1"},
+	}}
+
+	err := newTestClient(t, api, testToken).DeleteAcmeChallengeResourceRecords(context.TODO(), logr.Discard(), testCertRequest)
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, record := range api.records {
+		ids = append(ids, record.ID)
+	}
+	assert.Equal(t, []string{"c", "d"}, ids)
+}
+
+func TestObserveRequests(t *testing.T) {
+	localmetrics.MetricDNSAPIRequests.Reset()
+	localmetrics.MetricDNSAPIErrors.Reset()
+
+	_, _ = newTestClient(t, &fakeAPI{}, testToken).ListZones(context.TODO())
+	_, _ = newTestClient(t, &fakeAPI{}, "wrong").ListZones(context.TODO())
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(localmetrics.MetricDNSAPIRequests.WithLabelValues("cloudflare", "zones.list")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("cloudflare", "zones.list", "403")))
+}
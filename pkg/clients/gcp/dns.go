@@ -18,42 +18,77 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-logr/logr"
+	"golang.org/x/oauth2"
 	dnsv1 "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
 	option "google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/clients/guardrail"
 	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/clients/zonecache"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
 )
 
 const (
 	resourceRecordTTL = 60
+	providerName      = "gcp"
 )
 
+// collections maps the collections of the Cloud DNS API in request paths to the names of their resources.
+var collections = map[string]string{
+	"managedZones": "managedZones",
+	"changes":      "changes",
+	"rrsets":       "resourceRecordSets",
+}
+
 // client implements the Client interface
 type gcpClient struct {
 	client  dnsv1.Service
 	project string
+	// zones caches the managed zones of the domains for credentials, the fingerprint of those of the client
+	zones       *zonecache.Cache
+	credentials string
 }
 
 func (c *gcpClient) GetDNSName() string {
 	return "Cloud DNS"
 }
 
-func (c *gcpClient) GetFedrampHostedZoneIDPath(_ string) (string, error) {
+// ListZones returns the names of the managed zones of the project.
+func (c *gcpClient) ListZones(ctx context.Context) ([]string, error) {
+	zones := []string{}
+	err := c.client.ManagedZones.List(c.project).Pages(ctx, func(page *dnsv1.ManagedZonesListResponse) error {
+		for _, zone := range page.ManagedZones {
+			zones = append(zones, zone.DnsName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+func (c *gcpClient) GetFedrampHostedZoneIDPath(_ context.Context, _ string) (string, error) {
 	return "", fmt.Errorf("fedRamp is not supported by GCP")
 }
 
-func (c *gcpClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+func (c *gcpClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
 	fqdn = fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, domain)
-	reqLogger.Info(fmt.Sprintf("fqdn acme challenge domain is %v", fqdn))
+	reqLogger.Info("answering the acme challenge", "fqdn", fqdn)
 
 	var fqdnName string
 	if !strings.HasSuffix(fqdn, ".") {
@@ -63,7 +98,7 @@ func (c *gcpClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken
 	}
 
 	// Calls function to get the hostedzone of the domain of our CertificateRequest
-	zone, err := c.getManagedZone(cr.Spec.ACMEDNSDomain)
+	zone, err := c.getManagedZone(ctx, utils.ACMEDNSZone(cr))
 	if err != nil {
 		reqLogger.Error(err, "Unable to find appropriate managedzone")
 		return "", err
@@ -78,7 +113,7 @@ func (c *gcpClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken
 	}
 
 	// add/update challenge record
-	err = c.upsertDnsRecord(zone, dnsRecord)
+	err = c.upsertDnsRecord(ctx, zone, dnsRecord)
 	if err != nil {
 		return "", err
 	}
@@ -87,11 +122,11 @@ func (c *gcpClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken
 
 // ValidateDNSWriteAccess client to retrieve the baseDomain's hostedZoneOutput
 // and attempts to write a test TXT ResourceRecord to it. If successful, will return `true, nil`.
-func (c *gcpClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
-	var err error
+func (c *gcpClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (_ bool, err error) {
+	defer func() { err = credentialsError(err) }()
 
 	// Calls function to get the hostedzone of the domain of our CertificateRequest
-	zone, err := c.getManagedZone(cr.Spec.ACMEDNSDomain)
+	zone, err := c.getManagedZone(ctx, utils.ACMEDNSZone(cr))
 	if err != nil {
 		reqLogger.Error(err, "Unable to find appropriate managedzone")
 		return false, err
@@ -106,13 +141,13 @@ func (c *gcpClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 	}
 
 	// test if we can add a record
-	err = c.upsertDnsRecord(zone, dnsRecord)
+	err = c.upsertDnsRecord(ctx, zone, dnsRecord)
 	if err != nil {
 		return false, err
 	}
 
 	// clean up record
-	err = c.deleteDnsRecords(zone, []*dnsv1.ResourceRecordSet{dnsRecord})
+	err = c.deleteDnsRecords(ctx, zone, []*dnsv1.ResourceRecordSet{dnsRecord})
 	if err != nil {
 		return false, err
 	}
@@ -121,12 +156,12 @@ func (c *gcpClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1a
 }
 
 // DeleteAcmeChallengeResourceRecords to delete all records in a hosted zone that begin with the prefix defined by the const acmeChallengeSubDomain
-func (c *gcpClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+func (c *gcpClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
 	// This function is for record clean up. If we are unable to find the records to delete them we silently accept these errors
 	// without raising an error. If the record was already deleted that's fine.
 
 	// Calls function to get the hostedzone of the domain of our CertificateRequest
-	zone, err := c.getManagedZone(cr.Spec.ACMEDNSDomain)
+	zone, err := c.getManagedZone(ctx, utils.ACMEDNSZone(cr))
 	if err != nil {
 		reqLogger.Error(err, "Unable to find appropriate managedzone")
 		return err
@@ -134,23 +169,24 @@ func (c *gcpClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr
 
 	var changes []*dnsv1.ResourceRecordSet
 	// Get a list of RecordSets from our hostedzone that match our search criteria
-	// Criteria - record name starts with our acmechallenge prefix or the write testing prefix, record is a TXT type
+	// Criteria - record name starts with our acmechallenge label or the write testing label, record is a TXT type
 	req := c.client.ResourceRecordSets.List(c.project, zone.Name)
-	if err := req.Pages(context.Background(), func(page *dnsv1.ResourceRecordSetsListResponse) error {
+	if err := req.Pages(ctx, func(page *dnsv1.ResourceRecordSetsListResponse) error {
 		for _, resourceRecordSet := range page.Rrsets {
 			if resourceRecordSet.Type == "TXT" {
-				if strings.Contains(resourceRecordSet.Name, cTypes.AcmeChallengeSubDomain) || strings.Contains(resourceRecordSet.Name, cTypes.WriteValidationSubDomain) {
+				if strings.HasPrefix(resourceRecordSet.Name, cTypes.AcmeChallengeSubDomain+".") || strings.HasPrefix(resourceRecordSet.Name, cTypes.WriteValidationSubDomain+".") {
 					changes = append(changes, resourceRecordSet)
 				}
 			}
 		}
 		return nil
 	}); err != nil {
+		c.invalidateZone(zone, err)
 		return err
 	}
 
 	// clean up records
-	err = c.deleteDnsRecords(zone, changes)
+	err = c.deleteDnsRecords(ctx, zone, changes)
 	if err != nil {
 		return err
 	}
@@ -159,58 +195,101 @@ func (c *gcpClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr
 }
 
 // NewClient reuturn new GCP DNS client
-func NewClient(kubeClient client.Client, secretName, namespace string) (*gcpClient, error) {
-	ctx := context.Background()
+func NewClient(ctx context.Context, kubeClient client.Client, secretName, namespace string) (*gcpClient, error) {
 	secret := &corev1.Secret{}
 	err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, secret)
 	if err != nil {
 		return nil, err
 	}
 
-	config, err := utils.GetCredentialsJSON(kubeClient, types.NamespacedName{Namespace: namespace, Name: secretName})
+	config, err := utils.GetCredentialsJSON(ctx, kubeClient, types.NamespacedName{Namespace: namespace, Name: secretName})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+	}
+
+	transport, err := htransport.NewTransport(ctx, &observedTransport{base: http.DefaultTransport}, option.WithCredentials(config))
 	if err != nil {
 		return nil, err
 	}
 
-	service, err := dnsv1.NewService(context.Background(), option.WithCredentials(config))
+	service, err := dnsv1.NewService(ctx, option.WithHTTPClient(&http.Client{Transport: transport}))
 	if err != nil {
 		return nil, err
 	}
 
 	return &gcpClient{
-		client:  *service,
-		project: config.ProjectID,
+		client:      *service,
+		project:     config.ProjectID,
+		zones:       zonecache.Default,
+		credentials: zonecache.Fingerprint(config.ProjectID, string(config.JSON)),
 	}, nil
 }
 
-// getManagedZone finds and returns the ManagedZone matching the baseDomain provided
-func (c *gcpClient) getManagedZone(baseDomain string) (*dnsv1.ManagedZone, error) {
-	// list DNS zones in the project
-	zoneList, err := c.client.ManagedZones.List(c.project).Do()
-	if err != nil {
-		return nil, err
+// credentialsError wraps err in cTypes.ErrInvalidCredentials if Google rejected the service account of the request,
+// or in cTypes.ErrInsufficientPermissions if it is not allowed to make it.
+func credentialsError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
 	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusUnauthorized:
+			return fmt.Errorf("%w: %w", cTypes.ErrInvalidCredentials, err)
+		case http.StatusForbidden:
+			return fmt.Errorf("%w: %w", cTypes.ErrInsufficientPermissions, err)
+		}
+	}
+	return err
+}
 
+// getManagedZone finds and returns the public ManagedZone matching the baseDomain provided. Its name is cached for the
+// credentials of the client.
+func (c *gcpClient) getManagedZone(ctx context.Context, baseDomain string) (*dnsv1.ManagedZone, error) {
 	// ensure base domain has a trailing dot
 	if !strings.HasSuffix(baseDomain, ".") {
 		baseDomain = baseDomain + "."
 	}
 
-	// loop through all zones, and return the matching zone
-	for _, zone := range zoneList.ManagedZones {
-		// Find our specific zone
-		if strings.EqualFold(baseDomain, zone.DnsName) && zone.Visibility == "public" {
-			// always return from this, as we only expect one managed zone from the baseDomain
-			return c.client.ManagedZones.Get(c.project, zone.Name).Do()
+	name, err := c.zones.Lookup(ctx, providerName, c.credentials, baseDomain, func(ctx context.Context) (string, error) {
+		// list DNS zones in the project
+		zoneList, err := c.client.ManagedZones.List(c.project).Context(ctx).Do()
+		if err != nil {
+			return "", err
 		}
+
+		// loop through all zones, and return the matching zone
+		for _, zone := range zoneList.ManagedZones {
+			// Find our specific zone
+			if strings.EqualFold(baseDomain, zone.DnsName) && zone.Visibility == "public" {
+				// always return from this, as we only expect one managed zone from the baseDomain
+				return zone.Name, nil
+			}
+		}
+
+		return "", fmt.Errorf("unable to find zone matching baseDomain: %s", baseDomain)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &dnsv1.ManagedZone{Name: name, DnsName: baseDomain}, nil
+}
 
-	return nil, fmt.Errorf("unable to find zone matching baseDomain: %s", baseDomain)
+// invalidateZone drops the cached managed zone if err reports that it does not exist.
+func (c *gcpClient) invalidateZone(zone *dnsv1.ManagedZone, err error) {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		c.zones.Invalidate(providerName, c.credentials, zone.DnsName)
+	}
 }
 
 // upsertDnsRecord takes a DNS record set, and ensures that it exists
-func (c *gcpClient) upsertDnsRecord(zone *dnsv1.ManagedZone, record *dnsv1.ResourceRecordSet) error {
-	var err error
+func (c *gcpClient) upsertDnsRecord(ctx context.Context, zone *dnsv1.ManagedZone, record *dnsv1.ResourceRecordSet) error {
+	err := guardrail.CheckRecord(providerName, "changes.create", record.Name, record.Type, zone.DnsName)
+	if err != nil {
+		return err
+	}
 
 	// build the change
 	change := &dnsv1.Change{
@@ -218,9 +297,10 @@ func (c *gcpClient) upsertDnsRecord(zone *dnsv1.ManagedZone, record *dnsv1.Resou
 	}
 
 	// get list of current records in the zone
-	res, err := c.client.ResourceRecordSets.List(c.project, zone.Name).Do()
+	res, err := c.client.ResourceRecordSets.List(c.project, zone.Name).Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("error retrieving record sets for %q: %s", zone.Name, err)
+		c.invalidateZone(zone, err)
+		return fmt.Errorf("error retrieving record sets for %q: %w", zone.Name, err)
 	}
 
 	// check if record already exists
@@ -239,8 +319,9 @@ func (c *gcpClient) upsertDnsRecord(zone *dnsv1.ManagedZone, record *dnsv1.Resou
 	}
 
 	// submit change
-	_, err = c.client.Changes.Create(c.project, zone.Name, change).Do()
+	_, err = c.client.Changes.Create(c.project, zone.Name, change).Context(ctx).Do()
 	if err != nil {
+		c.invalidateZone(zone, err)
 		return err
 	}
 
@@ -248,7 +329,7 @@ func (c *gcpClient) upsertDnsRecord(zone *dnsv1.ManagedZone, record *dnsv1.Resou
 }
 
 // deleteDnsRecords takes a slice of DNS record sets, and deletes them
-func (c *gcpClient) deleteDnsRecords(zone *dnsv1.ManagedZone, records []*dnsv1.ResourceRecordSet) error {
+func (c *gcpClient) deleteDnsRecords(ctx context.Context, zone *dnsv1.ManagedZone, records []*dnsv1.ResourceRecordSet) error {
 	var err error
 
 	//check if there are any records to clean up. if not, we can return early.
@@ -256,16 +337,70 @@ func (c *gcpClient) deleteDnsRecords(zone *dnsv1.ManagedZone, records []*dnsv1.R
 		return nil
 	}
 
+	for _, record := range records {
+		if err = guardrail.CheckRecord(providerName, "changes.create", record.Name, record.Type, zone.DnsName); err != nil {
+			return err
+		}
+	}
+
 	// build the change
 	change := &dnsv1.Change{
 		Deletions: records,
 	}
 
 	// submit change
-	_, err = c.client.Changes.Create(c.project, zone.Name, change).Do()
+	_, err = c.client.Changes.Create(c.project, zone.Name, change).Context(ctx).Do()
 	if err != nil {
+		c.invalidateZone(zone, err)
 		return err
 	}
 
 	return nil
 }
+
+// observedTransport counts the requests to the Cloud DNS API in the DNS API metrics.
+type observedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *observedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := t.base.RoundTrip(r)
+
+	code := ""
+	switch {
+	case err != nil:
+		code = "error"
+	case response.StatusCode >= http.StatusBadRequest:
+		code = strconv.Itoa(response.StatusCode)
+	}
+	throttled := err == nil && response.StatusCode == http.StatusTooManyRequests
+	localmetrics.ObserveDNSAPIRequest(providerName, operation(r), code, throttled)
+
+	return response, err
+}
+
+// operation names the Cloud DNS API method of a request after the resource and the verb of its path and method,
+// such as resourceRecordSets.list or changes.create.
+func operation(r *http.Request) string {
+	resource := ""
+	item := false
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if name, ok := collections[segment]; ok {
+			resource = name
+			item = i < len(segments)-1
+		}
+	}
+	if resource == "" {
+		return "unknown"
+	}
+
+	verb := strings.ToLower(r.Method)
+	switch {
+	case r.Method == http.MethodGet && !item:
+		verb = "list"
+	case r.Method == http.MethodPost && !item:
+		verb = "create"
+	}
+	return resource + "." + verb
+}
@@ -1 +1,123 @@
 package gcp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	dnsv1 "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/clients/zonecache"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestOperation(t *testing.T) {
+	tests := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{method: http.MethodGet, url: "https://dns.googleapis.com/dns/v1/projects/p/managedZones", expected: "managedZones.list"},
+		{method: http.MethodGet, url: "https://dns.googleapis.com/dns/v1/projects/p/managedZones/zone", expected: "managedZones.get"},
+		{method: http.MethodPost, url: "https://dns.googleapis.com/dns/v1/projects/p/managedZones/zone/changes", expected: "changes.create"},
+		{method: http.MethodGet, url: "https://dns.googleapis.com/dns/v1/projects/p/managedZones/zone/rrsets?name=x", expected: "resourceRecordSets.list"},
+		{method: http.MethodDelete, url: "https://dns.googleapis.com/dns/v1/projects/p/managedZones/zone/rrsets/x.example.com./TXT", expected: "resourceRecordSets.delete"},
+		{method: http.MethodGet, url: "https://dns.googleapis.com/dns/v1/projects/p", expected: "unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			r, err := http.NewRequest(test.method, test.url, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, operation(r))
+		})
+	}
+}
+
+func TestObservedTransport(t *testing.T) {
+	localmetrics.MetricDNSAPIRequests.Reset()
+	localmetrics.MetricDNSAPIErrors.Reset()
+	localmetrics.MetricDNSAPIThrottled.Reset()
+
+	responses := []int{http.StatusOK, http.StatusTooManyRequests, http.StatusNotFound}
+	transport := &observedTransport{base: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		if len(responses) == 0 {
+			return nil, errors.New("connection refused")
+		}
+		status := responses[0]
+		responses = responses[1:]
+		return &http.Response{StatusCode: status}, nil
+	})}
+
+	for range 4 {
+		r, err := http.NewRequest(http.MethodPost, "https://dns.googleapis.com/dns/v1/projects/p/managedZones/zone/changes", nil)
+		assert.NoError(t, err)
+		_, _ = transport.RoundTrip(r)
+	}
+
+	assert.Equal(t, 4.0, testutil.ToFloat64(localmetrics.MetricDNSAPIRequests.WithLabelValues("gcp", "changes.create")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("gcp", "changes.create", "429")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("gcp", "changes.create", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIErrors.WithLabelValues("gcp", "changes.create", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSAPIThrottled.WithLabelValues("gcp", "changes.create")))
+}
+
+func TestCredentialsError(t *testing.T) {
+	assert.ErrorIs(t, credentialsError(&oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusBadRequest}}), cTypes.ErrInvalidCredentials)
+	assert.ErrorIs(t, credentialsError(&googleapi.Error{Code: http.StatusUnauthorized}), cTypes.ErrInvalidCredentials)
+	assert.ErrorIs(t, credentialsError(&googleapi.Error{Code: http.StatusForbidden}), cTypes.ErrInsufficientPermissions)
+
+	err := credentialsError(&googleapi.Error{Code: http.StatusNotFound})
+	assert.NotErrorIs(t, err, cTypes.ErrInvalidCredentials)
+	assert.NotErrorIs(t, err, cTypes.ErrInsufficientPermissions)
+}
+
+func TestGetManagedZone(t *testing.T) {
+	lists := 0
+	service, err := dnsv1.NewService(context.TODO(), option.WithHTTPClient(&http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		lists++
+		body := `{"managedZones": [
+			{"name": "private", "dnsName": "example.com.", "visibility": "private"},
+			{"name": "public", "dnsName": "example.com.", "visibility": "public"}
+		]}`
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}))
+	require.NoError(t, err)
+	c := &gcpClient{client: *service, project: "p", zones: zonecache.New(time.Minute), credentials: "credentials"}
+
+	zone, err := c.getManagedZone(context.TODO(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "public", zone.Name)
+	assert.Equal(t, "example.com.", zone.DnsName)
+
+	_, err = c.getManagedZone(context.TODO(), "example.com.")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lists, "the managed zone is cached")
+
+	c.invalidateZone(zone, &googleapi.Error{Code: http.StatusForbidden})
+	_, err = c.getManagedZone(context.TODO(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lists, "the managed zone is only invalidated once it is not found")
+
+	c.invalidateZone(zone, &googleapi.Error{Code: http.StatusNotFound})
+	_, err = c.getManagedZone(context.TODO(), "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lists)
+}
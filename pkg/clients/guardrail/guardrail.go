@@ -0,0 +1,56 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package guardrail keeps the DNS clients from changing records other than those answering ACME challenges, limiting
+// the damage a wrong record name could do to the zones they manage.
+package guardrail
+
+import (
+	"fmt"
+	"strings"
+
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+// CheckRecord returns nil if the record of name and recordType, which the operation of the DNS client of provider is
+// about to change, is a TXT record of an ACME challenge or of the write access test inside zone. Otherwise the
+// refusal is counted in the metrics and an error wrapping cTypes.ErrRecordRefused is returned.
+func CheckRecord(provider, operation, name, recordType, zone string) error {
+	fqdn := normalize(name)
+	zone = normalize(zone)
+	label, parent, _ := strings.Cut(fqdn, ".")
+
+	var reason string
+	switch {
+	case !strings.EqualFold(recordType, "TXT"):
+		reason = "it is not a TXT record"
+	case label != cTypes.AcmeChallengeSubDomain && label != cTypes.WriteValidationSubDomain:
+		reason = fmt.Sprintf("its name does not begin with %s.", cTypes.AcmeChallengeSubDomain)
+	case zone == "" || (parent != zone && !strings.HasSuffix(parent, "."+zone)):
+		reason = fmt.Sprintf("it is outside of zone %q", zone)
+	default:
+		return nil
+	}
+
+	localmetrics.ObserveDNSRecordRefused(provider, operation)
+	return fmt.Errorf("%w: %s of %s record %q: %s", cTypes.ErrRecordRefused, operation, recordType, name, reason)
+}
+
+// normalize lowercases a DNS name and removes its trailing dot.
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
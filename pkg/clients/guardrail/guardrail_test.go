@@ -0,0 +1,65 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrail
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+func TestCheckRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     string
+		recordType string
+		zone       string
+		allowed    bool
+	}{
+		{name: "challenge of the apex", record: "_acme-challenge.example.com", recordType: "TXT", zone: "example.com", allowed: true},
+		{name: "challenge of a subdomain", record: "_acme-challenge.apps.cluster.example.com.", recordType: "TXT", zone: "Example.com.", allowed: true},
+		{name: "write access test", record: "_certman_access_test.example.com.", recordType: "TXT", zone: "example.com", allowed: true},
+		{name: "not a TXT record", record: "_acme-challenge.example.com", recordType: "CNAME", zone: "example.com"},
+		{name: "not a challenge", record: "api.example.com", recordType: "TXT", zone: "example.com"},
+		{name: "challenge label not first", record: "www._acme-challenge.example.com", recordType: "TXT", zone: "example.com"},
+		{name: "challenge label as a prefix", record: "_acme-challenge-x.example.com", recordType: "TXT", zone: "example.com"},
+		{name: "outside of the zone", record: "_acme-challenge.example.org", recordType: "TXT", zone: "example.com"},
+		{name: "suffix of the zone", record: "_acme-challenge.badexample.com", recordType: "TXT", zone: "example.com"},
+		{name: "no zone", record: "_acme-challenge.example.com", recordType: "TXT"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			localmetrics.MetricDNSRecordsRefused.Reset()
+
+			err := CheckRecord("aws", "ChangeResourceRecordSets", test.record, test.recordType, test.zone)
+
+			refused := testutil.ToFloat64(localmetrics.MetricDNSRecordsRefused.WithLabelValues("aws", "ChangeResourceRecordSets"))
+			if test.allowed {
+				assert.NoError(t, err)
+				assert.Zero(t, refused)
+			} else {
+				assert.ErrorIs(t, err, cTypes.ErrRecordRefused)
+				assert.Equal(t, 1.0, refused)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"errors"
 
 	"github.com/go-logr/logr"
@@ -44,7 +45,7 @@ func (c *MockClient) GetDNSName() string {
 	return "Mock"
 }
 
-func (c *MockClient) GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (string, error) {
+func (c *MockClient) GetFedrampHostedZoneIDPath(_ context.Context, fedrampHostedZoneID string) (string, error) {
 	zoneID := c.FedrampHostedZoneID
 	var err error
 	if c.FedrampHostedZoneIDErrorString != "" {
@@ -53,7 +54,7 @@ func (c *MockClient) GetFedrampHostedZoneIDPath(fedrampHostedZoneID string) (str
 	return zoneID, err
 }
 
-func (c *MockClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+func (c *MockClient) AnswerDNSChallenge(_ context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
 	fqdn = c.AnswerDNSChallengeFQDN
 
 	if c.AnswerDNSChallengeErrorString != "" {
@@ -63,7 +64,7 @@ func (c *MockClient) AnswerDNSChallenge(reqLogger logr.Logger, acmeChallengeToke
 	return
 }
 
-func (c *MockClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (b bool, err error) {
+func (c *MockClient) ValidateDNSWriteAccess(_ context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (b bool, err error) {
 	b = c.ValidateDNSWriteAccessBool
 
 	if c.ValidateDNSWriteAccessErrorString != "" {
@@ -73,7 +74,11 @@ func (c *MockClient) ValidateDNSWriteAccess(reqLogger logr.Logger, cr *certmanv1
 	return
 }
 
-func (c *MockClient) DeleteAcmeChallengeResourceRecords(reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (err error) {
+func (c *MockClient) ListZones(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+func (c *MockClient) DeleteAcmeChallengeResourceRecords(_ context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (err error) {
 	if c.DeleteAcmeChallengeResourceRecordsErrorString != "" {
 		err = errors.New(c.DeleteAcmeChallengeResourceRecordsErrorString)
 	}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -83,7 +84,7 @@ func TestAnswerDNSChallenge(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			actualFQDN, err := test.TestClient.AnswerDNSChallenge(logr.Discard(), "ignored", "irrelevant", &certmanv1alpha1.CertificateRequest{}, "testvalue")
+			actualFQDN, err := test.TestClient.AnswerDNSChallenge(context.TODO(), logr.Discard(), "ignored", "irrelevant", &certmanv1alpha1.CertificateRequest{}, "testvalue")
 			if err != nil && err.Error() != test.ExpectedAnswerDNSChallengeErrorString {
 				t.Errorf("AnswerDNSChallenge() %s: expected error \"%s\", got error \"%s\"\n", test.Name, test.ExpectedAnswerDNSChallengeErrorString, err.Error())
 			}
@@ -119,7 +120,7 @@ func TestDeleteAcmeChallengeResourceRecords(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			err := test.TestClient.DeleteAcmeChallengeResourceRecords(logr.Discard(), &certmanv1alpha1.CertificateRequest{})
+			err := test.TestClient.DeleteAcmeChallengeResourceRecords(context.TODO(), logr.Discard(), &certmanv1alpha1.CertificateRequest{})
 			if err != nil && err.Error() != test.ExpectedDeleteAcmeChallengeResourceRecordsErrorString {
 				t.Errorf("DeleteAcmeChallengeResourceRecords() %s: expected error \"%s\", got error \"%s\"\n", test.Name, test.ExpectedDeleteAcmeChallengeResourceRecordsErrorString, err.Error())
 			}
@@ -156,7 +157,7 @@ func TestValidateDNSWriteAccess(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
-			actualBool, err := test.TestClient.ValidateDNSWriteAccess(logr.Discard(), &certmanv1alpha1.CertificateRequest{})
+			actualBool, err := test.TestClient.ValidateDNSWriteAccess(context.TODO(), logr.Discard(), &certmanv1alpha1.CertificateRequest{})
 			if err != nil && err.Error() != test.ExpectedValidateDNSWriteAccessErrorString {
 				t.Errorf("ValidateDNSWriteAccess() %s: expected error \"%s\", got error \"%s\"\n", test.Name, test.ExpectedValidateDNSWriteAccessErrorString, err.Error())
 			}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+	"github.com/openshift/certman-operator/pkg/tracing"
+)
+
+// tracedClient records a span for each DNS record operation of the wrapped Client.
+type tracedClient struct {
+	Client
+}
+
+func (c *tracedClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdn string, err error) {
+	ctx, span := tracing.Start(ctx, "dns.AnswerDNSChallenge", c.attributes(attribute.String("dns.domain", domain), attribute.String("dns.zone", dnsZone))...)
+	defer func() { tracing.End(span, err) }()
+	return c.Client.AnswerDNSChallenge(ctx, reqLogger, acmeChallengeToken, domain, cr, dnsZone)
+}
+
+func (c *tracedClient) ValidateDNSWriteAccess(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (ok bool, err error) {
+	ctx, span := tracing.Start(ctx, "dns.ValidateDNSWriteAccess", c.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	return c.Client.ValidateDNSWriteAccess(ctx, reqLogger, cr)
+}
+
+func (c *tracedClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "dns.DeleteAcmeChallengeResourceRecords", c.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	return c.Client.DeleteAcmeChallengeResourceRecords(ctx, reqLogger, cr)
+}
+
+func (c *tracedClient) ListZones(ctx context.Context) (zones []string, err error) {
+	ctx, span := tracing.Start(ctx, "dns.ListZones", c.attributes()...)
+	defer func() { tracing.End(span, err) }()
+	return c.Client.ListZones(ctx)
+}
+
+// tracedBatchClient is the tracedClient of a BatchClient.
+type tracedBatchClient struct {
+	tracedClient
+}
+
+func (c *tracedBatchClient) AnswerDNSChallenges(ctx context.Context, reqLogger logr.Logger, challenges []cTypes.DNSChallenge, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (fqdns []string, err error) {
+	ctx, span := tracing.Start(ctx, "dns.AnswerDNSChallenges", c.attributes(attribute.Int("dns.challenges", len(challenges)), attribute.String("dns.zone", dnsZone))...)
+	defer func() { tracing.End(span, err) }()
+	return c.Client.(BatchClient).AnswerDNSChallenges(ctx, reqLogger, challenges, cr, dnsZone)
+}
+
+// attributes returns the span attributes of the DNS provider, followed by attrs.
+func (c *tracedClient) attributes(attrs ...attribute.KeyValue) []attribute.KeyValue {
+	return append([]attribute.KeyValue{attribute.String("dns.provider", c.GetDNSName())}, attrs...)
+}
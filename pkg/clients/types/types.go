@@ -1,7 +1,53 @@
 package types
 
+import "errors"
+
+var (
+	// ErrInvalidCredentials is wrapped by the errors of the DNS clients whose credentials are missing, malformed or
+	// rejected by their DNS provider.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInsufficientPermissions is wrapped by the errors of the DNS clients whose credentials are not allowed to
+	// change the records of the zone the challenges are answered in.
+	ErrInsufficientPermissions = errors.New("insufficient permissions")
+	// ErrRecordRefused is wrapped by the errors of the DNS clients refusing to change a record that is not a TXT record
+	// answering an ACME challenge, or testing write access, inside the zone of the challenges.
+	ErrRecordRefused = errors.New("DNS record refused")
+)
+
+// DNSChallenge is the DNS-01 challenge of a domain, answered by a TXT record holding its key authorization.
+type DNSChallenge struct {
+	Domain           string
+	KeyAuthorization string
+}
+
 const (
 	AcmeChallengeSubDomain          = "_acme-challenge"
 	WriteValidationSubDomain        = "_certman_access_test"
 	DefaultNotificationEmailAddress = "default_notification_email_address"
+	RevokeCertificatesOnDelete      = "revoke_certificates_on_delete"
+	VerifyCertificateTransparency   = "verify_certificate_transparency"
+	VerifyCTInclusion               = "verify_certificate_transparency_inclusion"
+	CTLogList                       = "ct_log_list"
+	DefaultKeySize                  = "default_key_size"
+	RenewalJitterWindow             = "renewal_jitter_window"
+	MaxOrdersPerHour                = "max_orders_per_hour"
+	MaxOrdersPerWeek                = "max_orders_per_week"
+	RenewalCheckInterval            = "renewal_check_interval"
+	HibernationRenewalPolicy        = "hibernation_renewal_policy"
+	ReconcileTimeout                = "reconcile_timeout"
+	MaxIssuanceAttempts             = "max_issuance_attempts"
+	ManageAlerts                    = "manage_alerts"
+	ManageDashboard                 = "manage_dashboard"
+	ServiceLogFailureThreshold      = "service_log_failure_threshold"
+	NotificationWebhookFormat       = "notification_webhook_format"
+	NotificationWebhookTemplate     = "notification_webhook_template"
+	EmailNotifications              = "email_notifications"
+	EmailExpiryWarningDays          = "email_expiry_warning_days"
+	ACMEDirectoryURL                = "acme_directory_url"
+	ExtraRecord                     = "extra_record"
+	TrustedCABundle                 = "trusted_ca_bundle"
+	PagerDutyFailureDuration        = "pagerduty_failure_duration"
+	PagerDutyExpiryDays             = "pagerduty_expiry_days"
+	DNSPropagationCheckAttempts     = "dns_propagation_check_attempts"
+	DNSPropagationCheckInterval     = "dns_propagation_check_interval"
 )
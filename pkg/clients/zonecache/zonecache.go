@@ -0,0 +1,116 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package zonecache caches the zones the DNS clients find for the domains of the CertificateRequests. The clients
+// are built for each reconcile, so without it every issuance lists the zones of the provider again to find the same
+// zone of the same domain.
+package zonecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+// DefaultTTL is how long the zone of a domain is cached by Default.
+const DefaultTTL = 10 * time.Minute
+
+// Default is the cache shared by the DNS clients of the operator.
+var Default = New(DefaultTTL)
+
+// Cache is a cache of the IDs of the zones of domains, by DNS provider and by the fingerprint of the credentials the
+// zones were found with, as other credentials may see other zones. A nil Cache caches nothing.
+type Cache struct {
+	ttl   time.Duration
+	now   func() time.Time
+	mu    sync.Mutex
+	zones map[key]entry
+}
+
+type key struct {
+	provider    string
+	credentials string
+	domain      string
+}
+
+type entry struct {
+	zoneID  string
+	expires time.Time
+}
+
+// New returns an empty Cache whose zones expire after ttl.
+func New(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, now: time.Now, zones: map[key]entry{}}
+}
+
+// Fingerprint returns the fingerprint of the credentials made of parts, such as an access key ID and the role it
+// assumes. It does not reveal them.
+func Fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup returns the ID of the zone of domain cached for the credentials of provider, or else the one returned by
+// lookup, which is cached unless lookup fails.
+func (c *Cache) Lookup(ctx context.Context, provider, credentials, domain string, lookup func(context.Context) (string, error)) (string, error) {
+	if c == nil {
+		return lookup(ctx)
+	}
+
+	k := key{provider: provider, credentials: credentials, domain: normalize(domain)}
+	c.mu.Lock()
+	e, ok := c.zones[k]
+	if ok && c.now().After(e.expires) {
+		delete(c.zones, k)
+		ok = false
+	}
+	c.mu.Unlock()
+	localmetrics.ObserveDNSZoneCacheLookup(provider, ok)
+	if ok {
+		return e.zoneID, nil
+	}
+
+	zoneID, err := lookup(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.zones[k] = entry{zoneID: zoneID, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return zoneID, nil
+}
+
+// Invalidate removes the zone of domain cached for the credentials of provider. The DNS clients invalidate the zone
+// of a domain once the provider reports that it does not exist, as it was deleted or recreated with another ID.
+func (c *Cache) Invalidate(provider, credentials, domain string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.zones, key{provider: provider, credentials: credentials, domain: normalize(domain)})
+	c.mu.Unlock()
+}
+
+func normalize(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
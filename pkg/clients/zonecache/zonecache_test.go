@@ -0,0 +1,89 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zonecache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+)
+
+func TestCache(t *testing.T) {
+	localmetrics.MetricDNSZoneCacheLookups.Reset()
+	now := time.Now()
+	c := New(time.Minute)
+	c.now = func() time.Time { return now }
+
+	lookups := 0
+	lookup := func(zoneID string, err error) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			lookups++
+			return zoneID, err
+		}
+	}
+	ctx := context.TODO()
+
+	_, err := c.Lookup(ctx, "aws", "creds", "example.com", lookup("", errors.New("throttled")))
+	assert.Error(t, err)
+
+	zoneID, err := c.Lookup(ctx, "aws", "creds", "example.com", lookup("Z1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Z1", zoneID)
+
+	zoneID, err = c.Lookup(ctx, "aws", "creds", "Example.com.", lookup("Z2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Z1", zoneID, "the zone of the domain is cached")
+
+	zoneID, err = c.Lookup(ctx, "aws", "other-creds", "example.com", lookup("Z3", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Z3", zoneID, "the zones are cached per credentials")
+
+	c.Invalidate("aws", "creds", "example.com")
+	zoneID, err = c.Lookup(ctx, "aws", "creds", "example.com", lookup("Z4", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Z4", zoneID, "an invalidated zone is looked up again")
+
+	now = now.Add(2 * time.Minute)
+	zoneID, err = c.Lookup(ctx, "aws", "creds", "example.com", lookup("Z5", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Z5", zoneID, "an expired zone is looked up again")
+
+	assert.Equal(t, 5, lookups)
+	assert.Equal(t, 1.0, testutil.ToFloat64(localmetrics.MetricDNSZoneCacheLookups.WithLabelValues("aws", "hit")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(localmetrics.MetricDNSZoneCacheLookups.WithLabelValues("aws", "miss")))
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	zoneID, err := c.Lookup(context.TODO(), "aws", "creds", "example.com", func(context.Context) (string, error) { return "Z1", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "Z1", zoneID)
+	c.Invalidate("aws", "creds", "example.com")
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("AKIA", "role"), Fingerprint("AKIA", "role"))
+	assert.NotEqual(t, Fingerprint("AKIA", "role"), Fingerprint("AKIAr", "ole"))
+	assert.NotContains(t, Fingerprint("AKIA", "role"), "AKIA")
+}
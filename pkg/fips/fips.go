@@ -0,0 +1,109 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fips restricts the operator to FIPS 140-3 approved cryptography.
+//
+// TLS connections to the ACME server and the DNS providers are restricted by the Go FIPS 140-3 module itself,
+// which disables non-approved cipher suites, key exchanges such as X25519 and signature algorithms such as SHA-1.
+// This package checks the keys the operator generates and the certificates it stores, which the module cannot.
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/fips140"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// EnvVariable requests FIPS mode when set to "true".
+	EnvVariable = "FIPS_MODE"
+
+	// minRSAKeySize is the smallest RSA modulus approved for signatures by SP 800-131A.
+	minRSAKeySize = 2048
+)
+
+// Requested returns true if FIPS mode was requested through the FIPS_MODE environment variable.
+func Requested() bool {
+	return os.Getenv(EnvVariable) == "true"
+}
+
+// Enabled returns true if the operator runs in FIPS mode, either because it was requested or because the Go
+// FIPS 140-3 module was enabled by the build or GODEBUG=fips140=on.
+func Enabled() bool {
+	return Requested() || fips140.Enabled()
+}
+
+// Validate returns an error if FIPS mode was requested but the Go FIPS 140-3 module is not enabled, as TLS
+// connections would then negotiate non-approved algorithms.
+func Validate() error {
+	if Requested() && !fips140.Enabled() {
+		return fmt.Errorf("%s is true but the Go FIPS 140-3 module is not enabled, build with FIPS_ENABLED=true or set GODEBUG=fips140=on", EnvVariable)
+	}
+	return nil
+}
+
+// CheckPrivateKey returns an error if key is not an RSA key of at least 2048 bits or an ECDSA key on P-256 or P-384.
+func CheckPrivateKey(key crypto.Signer) error {
+	return checkPublicKey(key.Public())
+}
+
+// CheckCertificates returns an error if any certificate of the chain has a non-approved public key or is signed
+// with a non-approved algorithm such as SHA-1 or MD5.
+func CheckCertificates(certs []*x509.Certificate) error {
+	for _, cert := range certs {
+		if err := checkPublicKey(cert.PublicKey); err != nil {
+			return fmt.Errorf("certificate %q: %w", cert.Subject.CommonName, err)
+		}
+		if err := checkSignatureAlgorithm(cert.SignatureAlgorithm); err != nil {
+			return fmt.Errorf("certificate %q: %w", cert.Subject.CommonName, err)
+		}
+	}
+	return nil
+}
+
+func checkPublicKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeySize {
+			return fmt.Errorf("RSA key size %d is not FIPS approved, at least %d bits are required", k.N.BitLen(), minRSAKeySize)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() && k.Curve != elliptic.P384() {
+			return fmt.Errorf("elliptic curve %s is not FIPS approved", k.Curve.Params().Name)
+		}
+		return nil
+	case nil:
+		return errors.New("missing public key")
+	default:
+		return fmt.Errorf("public key type %T is not FIPS approved", pub)
+	}
+}
+
+func checkSignatureAlgorithm(algorithm x509.SignatureAlgorithm) error {
+	switch algorithm {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		return nil
+	default:
+		return fmt.Errorf("signature algorithm %s is not FIPS approved", algorithm)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/fips140"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPrivateKey(t *testing.T) {
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		key         crypto.Signer
+		expectError bool
+	}{
+		{name: "RSA 2048", key: rsa2048},
+		{name: "ECDSA P-256", key: p256},
+		{name: "ECDSA P-384", key: p384},
+		{name: "ECDSA P-224", key: p224, expectError: true},
+		{name: "Ed25519", key: ed, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckPrivateKey(test.key)
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		cert        *x509.Certificate
+		expectError bool
+	}{
+		{
+			name: "ECDSA with SHA-256",
+			cert: &x509.Certificate{PublicKey: &key.PublicKey, SignatureAlgorithm: x509.ECDSAWithSHA256},
+		},
+		{
+			name:        "RSA with SHA-1",
+			cert:        &x509.Certificate{PublicKey: &key.PublicKey, SignatureAlgorithm: x509.SHA1WithRSA},
+			expectError: true,
+		},
+		{
+			name:        "Ed25519",
+			cert:        &x509.Certificate{PublicKey: &key.PublicKey, SignatureAlgorithm: x509.PureEd25519},
+			expectError: true,
+		},
+		{
+			name:        "missing public key",
+			cert:        &x509.Certificate{SignatureAlgorithm: x509.ECDSAWithSHA256},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckCertificates([]*x509.Certificate{test.cert})
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv(EnvVariable, "false")
+	assert.NoError(t, Validate())
+	assert.Equal(t, fips140.Enabled(), Enabled())
+
+	t.Setenv(EnvVariable, "true")
+	assert.True(t, Enabled())
+	if fips140.Enabled() {
+		assert.NoError(t, Validate())
+	} else {
+		assert.Error(t, Validate())
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package googlecas issues certificates from a CA pool of Google Certificate Authority Service. Its client
+// implements the ACME client interface of the operator, so that CertificateRequests referencing a Google CAS
+// CertIssuer go through the same issuance, validation and revocation steps as ACME ones. The CA pool signs the
+// certificate signing request directly, there are no authorizations or challenges.
+package googlecas
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/option"
+	privateca "google.golang.org/api/privateca/v1"
+	htransport "google.golang.org/api/transport/http"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+// DefaultLifetime is the lifetime of the certificates requested by CertificateRequests without a duration.
+const DefaultLifetime = 2160 * time.Hour
+
+// certificateIDPrefix prefixes the IDs of the certificates created by the operator in the CA pool.
+const certificateIDPrefix = "certman-"
+
+// revocationReasons maps the RFC 5280 reason codes to the revocation reasons of Certificate Authority Service.
+var revocationReasons = map[int]string{
+	0:  "REVOCATION_REASON_UNSPECIFIED",
+	1:  "KEY_COMPROMISE",
+	2:  "CERTIFICATE_AUTHORITY_COMPROMISE",
+	3:  "AFFILIATION_CHANGED",
+	4:  "SUPERSEDED",
+	5:  "CESSATION_OF_OPERATION",
+	6:  "CERTIFICATE_HOLD",
+	9:  "PRIVILEGE_WITHDRAWN",
+	10: "ATTRIBUTE_AUTHORITY_COMPROMISE",
+}
+
+var _ leclient.PrivateCAClient = &Client{}
+
+// Client requests certificates from a CA pool of Google Certificate Authority Service.
+type Client struct {
+	leclient.NoChallenges
+
+	service              *privateca.Service
+	pool                 string
+	certificateAuthority string
+	lifetime             time.Duration
+
+	// certificate is the certificate created by FinalizeOrder, its name serves as the order URL once it exists
+	certificate *privateca.Certificate
+	ctx         context.Context
+}
+
+// NewClient returns a Client for the CA pool configured on issuer, authenticated with the service account in its
+// credentials secret. Certificates are requested for lifetime, or DefaultLifetime if it is zero.
+func NewClient(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer, lifetime time.Duration) (*Client, error) {
+	cas := issuer.Spec.GoogleCAS
+	if cas == nil {
+		return nil, fmt.Errorf("certissuer %s is not a google certificate authority service issuer", issuer.Name)
+	}
+
+	secretName := types.NamespacedName{Name: cas.CredentialsSecretRef.Name, Namespace: cas.CredentialsSecretRef.Namespace}
+	if secretName.Namespace == "" {
+		secretName.Namespace = config.OperatorNamespace
+	}
+	credentials, err := utils.GetCredentialsJSON(ctx, kubeClient, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the credentials of certissuer %s: %w", issuer.Name, err)
+	}
+
+	transport, err := htransport.NewTransport(ctx, http.DefaultTransport, option.WithCredentials(credentials))
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := privateca.NewService(ctx, option.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, err
+	}
+
+	project := cas.Project
+	if project == "" {
+		project = credentials.ProjectID
+	}
+	return newClient(service, project, cas, lifetime), nil
+}
+
+// newClient returns a Client for the CA pool configured by cas in project.
+func newClient(service *privateca.Service, project string, cas *certmanv1alpha1.GoogleCASIssuer, lifetime time.Duration) *Client {
+	if lifetime == 0 {
+		lifetime = DefaultLifetime
+	}
+	return &Client{
+		service:              service,
+		pool:                 fmt.Sprintf("projects/%s/locations/%s/caPools/%s", project, cas.Location, cas.CAPool),
+		certificateAuthority: cas.CertificateAuthority,
+		lifetime:             lifetime,
+		ctx:                  context.Background(),
+	}
+}
+
+// PrivateCA returns the resource name of the CA pool.
+func (c *Client) PrivateCA() string {
+	return c.pool
+}
+
+// SetTraceContext sets the context of the following requests.
+func (c *Client) SetTraceContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// CreateOrder forgets the certificate of the previous order. The DNS names are taken from the certificate signing
+// request passed to FinalizeOrder.
+func (c *Client) CreateOrder([]string) error {
+	c.certificate = nil
+	return nil
+}
+
+// GetOrderURL returns the resource name of the issued certificate, or of the CA pool until it is issued.
+func (c *Client) GetOrderURL() string {
+	if c.certificate != nil {
+		return c.certificate.Name
+	}
+	return c.pool
+}
+
+// GetOrderEndpoint returns the same as GetOrderURL.
+func (c *Client) GetOrderEndpoint() string {
+	return c.GetOrderURL()
+}
+
+// FinalizeOrder creates a certificate in the CA pool for csr.
+func (c *Client) FinalizeOrder(csr *x509.CertificateRequest) error {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	call := c.service.Projects.Locations.CaPools.Certificates.Create(c.pool, &privateca.Certificate{
+		PemCsr:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw})),
+		Lifetime: fmt.Sprintf("%ds", int64(c.lifetime.Seconds())),
+	}).CertificateId(certificateIDPrefix + hex.EncodeToString(id))
+	if c.certificateAuthority != "" {
+		call = call.IssuingCertificateAuthorityId(c.certificateAuthority)
+	}
+
+	certificate, err := call.Context(c.ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to create certificate in CA pool %s: %w", c.pool, err)
+	}
+	c.certificate = certificate
+	return nil
+}
+
+// FetchCertificates returns the certificate created by FinalizeOrder followed by its chain.
+func (c *Client) FetchCertificates() ([]*x509.Certificate, error) {
+	if c.certificate == nil {
+		return nil, errors.New("no certificate was created")
+	}
+
+	var certs []*x509.Certificate
+	for _, data := range append([]string{c.certificate.PemCertificate}, c.certificate.PemCertificateChain...) {
+		block, _ := pem.Decode([]byte(data))
+		if block == nil {
+			return nil, fmt.Errorf("certificate %s has an invalid PEM certificate", c.certificate.Name)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// RevokeCertificate revokes certificate with an unspecified reason.
+func (c *Client) RevokeCertificate(certificate *x509.Certificate) error {
+	return c.RevokeCertificateWithReason(certificate, 0)
+}
+
+// RevokeCertificateWithReason revokes certificate with the RFC 5280 reason code. A certificate that is already
+// revoked is left alone.
+func (c *Client) RevokeCertificateWithReason(certificate *x509.Certificate, reason int) error {
+	casReason, ok := revocationReasons[reason]
+	if !ok {
+		return fmt.Errorf("unsupported revocation reason code %d", reason)
+	}
+
+	found, err := c.findCertificate(certificate.SerialNumber)
+	if err != nil {
+		return err
+	}
+	if found.RevocationDetails != nil {
+		return nil
+	}
+
+	_, err = c.service.Projects.Locations.CaPools.Certificates.Revoke(found.Name, &privateca.RevokeCertificateRequest{
+		Reason: casReason,
+	}).Context(c.ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to revoke certificate %s: %w", found.Name, err)
+	}
+	return nil
+}
+
+// findCertificate returns the certificate of the CA pool with serial.
+func (c *Client) findCertificate(serial *big.Int) (*privateca.Certificate, error) {
+	filter := fmt.Sprintf("certificate_description.subject_description.hex_serial_number=%q", serial.Text(16))
+
+	var found *privateca.Certificate
+	err := c.service.Projects.Locations.CaPools.Certificates.List(c.pool).Filter(filter).Pages(c.ctx, func(page *privateca.ListCertificatesResponse) error {
+		if len(page.Certificates) > 0 && found == nil {
+			found = page.Certificates[0]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificates of CA pool %s: %w", c.pool, err)
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no certificate with serial number %s in CA pool %s", serial.Text(16), c.pool)
+	}
+	return found, nil
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlecas
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	privateca "google.golang.org/api/privateca/v1"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const testPool = "projects/project/locations/us-central1/caPools/pool"
+
+// fakeCAS serves the certificate requests of the Certificate Authority Service API for a single CA pool.
+type fakeCAS struct {
+	t      *testing.T
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+
+	created  *privateca.Certificate
+	query    map[string]string
+	filter   string
+	revoked  string
+	reason   string
+	serial   int64
+	lifetime string
+}
+
+func newFakeCAS(t *testing.T) *fakeCAS {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, key.Public(), key)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &fakeCAS{t: t, caCert: caCert, caKey: key, serial: 0xabc}
+}
+
+func (f *fakeCAS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	certificates := "/v1/" + testPool + "/certificates"
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == certificates:
+		request := &privateca.Certificate{}
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(request))
+		f.query = map[string]string{
+			"certificateId":                 r.URL.Query().Get("certificateId"),
+			"issuingCertificateAuthorityId": r.URL.Query().Get("issuingCertificateAuthorityId"),
+		}
+		f.lifetime = request.Lifetime
+		f.created = &privateca.Certificate{
+			Name:                certificates[len("/v1/"):] + "/" + f.query["certificateId"],
+			PemCertificate:      f.sign(request.PemCsr),
+			PemCertificateChain: []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: f.caCert.Raw}))},
+		}
+		require.NoError(f.t, json.NewEncoder(w).Encode(f.created))
+	case r.Method == http.MethodGet && r.URL.Path == certificates:
+		f.filter = r.URL.Query().Get("filter")
+		require.NoError(f.t, json.NewEncoder(w).Encode(&privateca.ListCertificatesResponse{Certificates: []*privateca.Certificate{f.created}}))
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, ":revoke"):
+		request := &privateca.RevokeCertificateRequest{}
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(request))
+		f.revoked = strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/"), ":revoke")
+		f.reason = request.Reason
+		f.created.RevocationDetails = &privateca.RevocationDetails{RevocationState: request.Reason}
+		require.NoError(f.t, json.NewEncoder(w).Encode(f.created))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sign returns the PEM certificate signed by the CA for the PEM certificate signing request.
+func (f *fakeCAS) sign(pemCSR string) string {
+	block, _ := pem.Decode([]byte(pemCSR))
+	require.NotNil(f.t, block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(f.t, err)
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(f.serial),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, f.caCert, csr.PublicKey, f.caKey)
+	require.NoError(f.t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func newTestClient(t *testing.T, cas *fakeCAS, lifetime time.Duration) *Client {
+	server := httptest.NewServer(cas)
+	t.Cleanup(server.Close)
+
+	service, err := privateca.NewService(context.TODO(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+	return newClient(service, "project", &certmanv1alpha1.GoogleCASIssuer{
+		Location:             "us-central1",
+		CAPool:               "pool",
+		CertificateAuthority: "ca",
+	}, lifetime)
+}
+
+func newTestCSR(t *testing.T, dnsNames []string) *x509.CertificateRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: dnsNames[0]},
+		DNSNames: dnsNames,
+	}, key)
+	require.NoError(t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	require.NoError(t, err)
+	return csr
+}
+
+func TestIssueCertificate(t *testing.T) {
+	tests := []struct {
+		name             string
+		lifetime         time.Duration
+		expectedLifetime string
+	}{
+		{
+			name:             "requests the default lifetime",
+			expectedLifetime: "7776000s",
+		},
+		{
+			name:             "requests the duration of the certificaterequest",
+			lifetime:         24 * time.Hour,
+			expectedLifetime: "86400s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cas := newFakeCAS(t)
+			c := newTestClient(t, cas, tt.lifetime)
+
+			assert.True(t, leclient.IsPrivateCAClient(c))
+			require.NoError(t, c.UpdateAccount("admin@example.com"))
+			require.NoError(t, c.CreateOrder([]string{"www.example.com"}))
+			assert.Equal(t, testPool, c.GetOrderURL())
+			assert.Empty(t, c.OrderAuthorization())
+
+			require.NoError(t, c.FinalizeOrder(newTestCSR(t, []string{"www.example.com"})))
+			assert.Equal(t, tt.expectedLifetime, cas.lifetime)
+			assert.Equal(t, "ca", cas.query["issuingCertificateAuthorityId"])
+			assert.True(t, strings.HasPrefix(cas.query["certificateId"], certificateIDPrefix))
+			assert.Equal(t, testPool+"/certificates/"+cas.query["certificateId"], c.GetOrderURL())
+
+			certs, err := c.FetchCertificates()
+			require.NoError(t, err)
+			require.Len(t, certs, 2)
+			assert.Equal(t, []string{"www.example.com"}, certs[0].DNSNames)
+			assert.Equal(t, cas.caCert, certs[1])
+		})
+	}
+}
+
+func TestRevokeCertificate(t *testing.T) {
+	cas := newFakeCAS(t)
+	c := newTestClient(t, cas, 0)
+	require.NoError(t, c.FinalizeOrder(newTestCSR(t, []string{"www.example.com"})))
+	certs, err := c.FetchCertificates()
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, c.RevokeCertificateWithReason(certs[0], 7), "unsupported revocation reason code 7")
+
+	require.NoError(t, c.RevokeCertificateWithReason(certs[0], 1))
+	assert.Equal(t, `certificate_description.subject_description.hex_serial_number="abc"`, cas.filter)
+	assert.Equal(t, cas.created.Name, cas.revoked)
+	assert.Equal(t, "KEY_COMPROMISE", cas.reason)
+
+	// a revoked certificate is left alone
+	cas.revoked = ""
+	require.NoError(t, c.RevokeCertificate(certs[0]))
+	assert.Empty(t, cas.revoked)
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health checks that the operator can reach the services it needs to issue certificates, for its readiness
+// probe.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	// checkInterval is how often the ACME server and the DNS providers are checked. The probes return the result of
+	// the last check, so that they neither hit the providers' rate limits nor time out.
+	checkInterval = 5 * time.Minute
+
+	// checkTimeout bounds each check.
+	checkTimeout = 30 * time.Second
+
+	// maxDNSCredentials bounds the number of DNS provider credentials tried by a check.
+	maxDNSCredentials = 5
+)
+
+var log = logf.Log.WithName("health")
+
+var errNotChecked = errors.New("not checked yet")
+
+var _ manager.LeaderElectionRunnable = &Checker{}
+
+// Checker periodically checks that the ACME directory of the operator's default account is reachable and that at
+// least one of the DNS provider credentials of the CertificateRequests can list zones.
+type Checker struct {
+	Client client.Client
+	// ClientBuilder builds the DNS provider client of a platform, such as cClient.NewClient.
+	ClientBuilder func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error)
+	// HTTPClient fetches the ACME directory. http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	mu      sync.RWMutex
+	checked bool
+	acmeErr error
+	dnsErr  error
+}
+
+// Start checks the ACME server and the DNS providers every checkInterval until ctx is done.
+func (c *Checker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		c.check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false, so that standby replicas report their readiness too.
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// ACME is a healthz.Checker failing if the ACME directory was unreachable on the last check.
+func (c *Checker) ACME(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.checked {
+		return errNotChecked
+	}
+	return c.acmeErr
+}
+
+// DNS is a healthz.Checker failing if no DNS provider credential could list zones on the last check.
+func (c *Checker) DNS(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.checked {
+		return errNotChecked
+	}
+	return c.dnsErr
+}
+
+// check runs the checks and stores their results, logging the ones that changed.
+func (c *Checker) check(ctx context.Context) {
+	acmeErr := c.checkACME(ctx)
+	dnsErr := c.checkDNS(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if acmeErr != nil && (!c.checked || c.acmeErr == nil) {
+		log.Error(acmeErr, "the ACME directory is unreachable")
+	} else if acmeErr == nil && c.acmeErr != nil {
+		log.Info("the ACME directory is reachable again")
+	}
+	if dnsErr != nil && (!c.checked || c.dnsErr == nil) {
+		log.Error(dnsErr, "no DNS provider credential can list zones")
+	} else if dnsErr == nil && c.dnsErr != nil {
+		log.Info("the DNS provider credentials can list zones again")
+	}
+	c.checked = true
+	c.acmeErr = acmeErr
+	c.dnsErr = dnsErr
+}
+
+// checkACME fetches the directory of the ACME server of the operator's default account. The mock account has no
+// server and always passes.
+func (c *Checker) checkACME(ctx context.Context) error {
+	directoryURL, err := leclient.DirectoryURL(ctx, c.Client)
+	if err != nil {
+		return fmt.Errorf("cannot find the ACME directory: %w", err)
+	}
+	if directoryURL == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, directoryURL, nil)
+	if err != nil {
+		return err
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("cannot fetch the ACME directory %s: %w", directoryURL, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching the ACME directory %s returned status %d", directoryURL, response.StatusCode)
+	}
+	return nil
+}
+
+// checkDNS lists the zones of the distinct DNS provider credentials of the CertificateRequests until one succeeds.
+// It passes if there are no CertificateRequests to issue certificates for.
+func (c *Checker) checkDNS(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	crList := &certmanv1alpha1.CertificateRequestList{}
+	if err := c.Client.List(ctx, crList); err != nil {
+		return fmt.Errorf("cannot list the CertificateRequests: %w", err)
+	}
+
+	tried := map[string]bool{}
+	var lastErr error
+	for i := range crList.Items {
+		cr := &crList.Items[i]
+		credentials := credentialsKey(cr)
+		if credentials == "" || tried[credentials] {
+			continue
+		}
+		if len(tried) == maxDNSCredentials {
+			break
+		}
+		tried[credentials] = true
+
+		dnsClient, err := c.ClientBuilder(ctx, log, c.Client, utils.DNSPlatform(cr), cr.Namespace, utils.ClusterDeploymentName(cr))
+		if err != nil {
+			lastErr = fmt.Errorf("cannot build the DNS client of %s: %w", credentials, err)
+			continue
+		}
+		if _, err := dnsClient.ListZones(ctx); err != nil {
+			lastErr = fmt.Errorf("cannot list the zones of %s: %w", credentials, err)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// credentialsKey identifies the DNS provider credentials of the CertificateRequest, or returns an empty string if it
+// has no platform of its own.
+func credentialsKey(cr *certmanv1alpha1.CertificateRequest) string {
+	platform := utils.DNSPlatform(cr)
+	switch {
+	case platform.AWS != nil:
+		return fmt.Sprintf("aws:%s/%s", cr.Namespace, platform.AWS.Credentials.Name)
+	case platform.GCP != nil:
+		return fmt.Sprintf("gcp:%s/%s", cr.Namespace, platform.GCP.Credentials.Name)
+	case platform.Azure != nil:
+		return fmt.Sprintf("azure:%s/%s", cr.Namespace, platform.Azure.Credentials.Name)
+	case platform.Cloudflare != nil:
+		return fmt.Sprintf("cloudflare:%s/%s", cr.Namespace, platform.Cloudflare.Credentials.Name)
+	case platform.AcmeDNS != nil:
+		return fmt.Sprintf("acme-dns:%s/%s", cr.Namespace, platform.AcmeDNS.Credentials.Name)
+	case platform.Mock != nil:
+		return "mock"
+	}
+	return ""
+}
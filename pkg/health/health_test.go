@@ -0,0 +1,162 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	mockclient "github.com/openshift/certman-operator/pkg/clients/mock"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// statusClient returns an http client answering every request with status.
+func statusClient(status int) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})}
+}
+
+func accountSecret(accountURL string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "lets-encrypt-account", Namespace: config.OperatorNamespace},
+		Data:       map[string][]byte{"account-url": []byte(accountURL)},
+	}
+}
+
+func awsCertRequest(namespace, credentials string) *certmanv1alpha1.CertificateRequest {
+	return &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "cr-" + credentials, Namespace: namespace},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			Platform: certmanv1alpha1.Platform{
+				AWS: &certmanv1alpha1.AWSPlatformSecrets{Credentials: corev1.LocalObjectReference{Name: credentials}},
+			},
+		},
+	}
+}
+
+func setUpTestClient(t *testing.T, objects ...client.Object) client.Client {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, certmanv1alpha1.AddToScheme(s))
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+}
+
+// failingCredentials is a DNS client whose credentials cannot list zones.
+type failingCredentials struct{ cClient.Client }
+
+func (failingCredentials) ListZones(context.Context) ([]string, error) {
+	return nil, errors.New("access denied")
+}
+
+func testClientBuilder(_ context.Context, _ logr.Logger, _ client.Client, platform certmanv1alpha1.Platform, _ string, _ string) (cClient.Client, error) {
+	mock := mockclient.NewMockClient(&mockclient.MockClientOptions{})
+	if platform.AWS != nil && platform.AWS.Credentials.Name == "bad" {
+		return failingCredentials{mock}, nil
+	}
+	return mock, nil
+}
+
+func TestCheckACME(t *testing.T) {
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		status      int
+		expectError bool
+	}{
+		{name: "directory reachable", objects: []client.Object{accountSecret("https://acme-staging-v02.api.letsencrypt.org/acme/acct/1")}, status: http.StatusOK},
+		{name: "directory unavailable", objects: []client.Object{accountSecret("https://acme-v02.api.letsencrypt.org/acme/acct/1")}, status: http.StatusServiceUnavailable, expectError: true},
+		{name: "mock account", objects: []client.Object{accountSecret("proto://use.mock.acme.client")}, status: http.StatusServiceUnavailable},
+		{name: "missing account secret", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Checker{Client: setUpTestClient(t, test.objects...), HTTPClient: statusClient(test.status)}
+			err := c.checkACME(context.TODO())
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckDNS(t *testing.T) {
+	tests := []struct {
+		name        string
+		objects     []client.Object
+		expectError bool
+	}{
+		{name: "no CertificateRequests"},
+		{name: "credentials can list zones", objects: []client.Object{awsCertRequest("ns1", "good")}},
+		{name: "credentials cannot list zones", objects: []client.Object{awsCertRequest("ns1", "bad")}, expectError: true},
+		{name: "one of the credentials can list zones", objects: []client.Object{awsCertRequest("ns1", "bad"), awsCertRequest("ns2", "good")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Checker{Client: setUpTestClient(t, test.objects...), ClientBuilder: testClientBuilder}
+			err := c.checkDNS(context.TODO())
+			if test.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProbes(t *testing.T) {
+	c := &Checker{
+		Client:        setUpTestClient(t, awsCertRequest("ns1", "bad")),
+		ClientBuilder: testClientBuilder,
+		HTTPClient:    statusClient(http.StatusOK),
+	}
+
+	assert.Equal(t, errNotChecked, c.ACME(nil))
+	assert.Equal(t, errNotChecked, c.DNS(nil))
+
+	c.check(context.TODO())
+	// the account secret is missing
+	assert.Error(t, c.ACME(nil))
+	assert.ErrorContains(t, c.DNS(nil), "access denied")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package http01 serves the key authorizations of the HTTP-01 challenges presented by the operator.
+package http01
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// challengePath is the path the ACME server fetches the key authorization of a token from.
+const challengePath = "/.well-known/acme-challenge/"
+
+var log = logf.Log.WithName("http01")
+
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Server answers the HTTP-01 challenges presented to it. The DNS names of the challenges must route port 80 to its
+// bind address, such as through a Route or a load balancer.
+type Server struct {
+	// BindAddress is the address the challenges are served on.
+	BindAddress string
+
+	mu                sync.RWMutex
+	keyAuthorizations map[string]string
+}
+
+// Present serves keyAuth for token until it is cleaned up.
+func (s *Server) Present(token, keyAuth string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keyAuthorizations == nil {
+		s.keyAuthorizations = map[string]string{}
+	}
+	s.keyAuthorizations[token] = keyAuth
+}
+
+// CleanUp stops serving the key authorization of token.
+func (s *Server) CleanUp(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keyAuthorizations, token)
+}
+
+// ServeHTTP answers the requests for the key authorizations of the presented tokens.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	token, ok := strings.CutPrefix(r.URL.Path, challengePath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	keyAuth, ok := s.keyAuthorizations[token]
+	s.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(keyAuth))
+}
+
+// Start serves the challenges on the bind address until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("serving the HTTP-01 challenges", "address", s.BindAddress)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection returns true, since only the leader presents challenges.
+func (s *Server) NeedLeaderElection() bool {
+	return true
+}
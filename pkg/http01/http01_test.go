@@ -0,0 +1,82 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http01
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeHTTP(t *testing.T) {
+	s := &Server{}
+	s.Present("token", "token.thumbprint")
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "serves the key authorization of a presented token",
+			method:       http.MethodGet,
+			path:         "/.well-known/acme-challenge/token",
+			expectedCode: http.StatusOK,
+			expectedBody: "token.thumbprint",
+		},
+		{
+			name:         "does not serve unknown tokens",
+			method:       http.MethodGet,
+			path:         "/.well-known/acme-challenge/other",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "does not serve other paths",
+			method:       http.MethodGet,
+			path:         "/token",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "rejects other methods",
+			method:       http.MethodPost,
+			path:         "/.well-known/acme-challenge/token",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			s.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, recorder.Body.String())
+			}
+		})
+	}
+
+	t.Run("stops serving a cleaned up token", func(t *testing.T) {
+		s.CleanUp("token")
+		recorder := httptest.NewRecorder()
+		s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/.well-known/acme-challenge/token", nil))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	awsCredsSecretIDKey     = "aws_access_key_id"
+	awsCredsSecretAccessKey = "aws_secret_access_key" //#nosec - G101: Potential hardcoded credentials
+
+	// awsPendingWindowInDays is the shortest time AWS KMS allows between scheduling and deleting a key.
+	awsPendingWindowInDays = 7
+)
+
+// awsClient implements the Client interface for AWS KMS
+type awsClient struct {
+	client kmsiface.KMSAPI
+}
+
+func newAWSClient(ctx context.Context, kubeClient client.Client, kmsKey *certmanv1alpha1.AWSKMSKey, namespace string) (*awsClient, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: kmsKey.Credentials.Name, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
+	if !ok {
+		return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v", kmsKey.Credentials.Name, awsCredsSecretIDKey)
+	}
+
+	secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
+	if !ok {
+		return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v", kmsKey.Credentials.Name, awsCredsSecretAccessKey)
+	}
+
+	s, err := session.NewSession(&aws.Config{
+		Region: aws.String(kmsKey.Region),
+		Credentials: credentials.NewStaticCredentials(
+			strings.Trim(string(accessKeyID), "\n"),
+			strings.Trim(string(secretAccessKey), "\n"),
+			"",
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsClient{client: kms.New(s)}, nil
+}
+
+func (c *awsClient) CreateKey(cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) (string, error) {
+	keySpec, err := awsKeySpec(cr, rsaKeySize)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.client.CreateKey(&kms.CreateKeyInput{
+		Description: aws.String(fmt.Sprintf("certman-operator private key of certificaterequest %s/%s", cr.Namespace, cr.Name)),
+		KeySpec:     aws.String(keySpec),
+		KeyUsage:    aws.String(kms.KeyUsageTypeSignVerify),
+		Tags: []*kms.Tag{
+			{TagKey: aws.String("certman.managed.openshift.io/certificaterequest-namespace"), TagValue: aws.String(cr.Namespace)},
+			{TagKey: aws.String("certman.managed.openshift.io/certificaterequest-name"), TagValue: aws.String(cr.Name)},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create KMS key: %w", err)
+	}
+
+	return aws.StringValue(output.KeyMetadata.Arn), nil
+}
+
+func (c *awsClient) Signer(keyID string) (crypto.Signer, error) {
+	output, err := c.client.GetPublicKey(&kms.GetPublicKeyInput{KeyId: aws.String(keyID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key of KMS key %s: %w", keyID, err)
+	}
+
+	public, err := x509.ParsePKIXPublicKey(output.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key of KMS key %s: %w", keyID, err)
+	}
+
+	return &awsSigner{client: c.client, keyID: keyID, public: public}, nil
+}
+
+func (c *awsClient) ScheduleKeyDeletion(keyID string) error {
+	_, err := c.client.ScheduleKeyDeletion(&kms.ScheduleKeyDeletionInput{
+		KeyId:               aws.String(keyID),
+		PendingWindowInDays: aws.Int64(awsPendingWindowInDays),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion of KMS key %s: %w", keyID, err)
+	}
+	return nil
+}
+
+// awsKeySpec maps the key algorithm, size and curve requested by the CertificateRequest onto an AWS KMS key spec.
+func awsKeySpec(cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) (string, error) {
+	switch cr.Spec.KeyAlgorithm {
+	case "", certmanv1alpha1.RSAKeyAlgorithm:
+		switch rsaKeySize {
+		case 2048:
+			return kms.KeySpecRsa2048, nil
+		case 3072:
+			return kms.KeySpecRsa3072, nil
+		case 4096:
+			return kms.KeySpecRsa4096, nil
+		}
+		return "", fmt.Errorf("unsupported RSA key size %d", rsaKeySize)
+	case certmanv1alpha1.ECDSAKeyAlgorithm:
+		switch cr.Spec.KeyCurve {
+		case "", certmanv1alpha1.P256KeyCurve:
+			return kms.KeySpecEccNistP256, nil
+		case certmanv1alpha1.P384KeyCurve:
+			return kms.KeySpecEccNistP384, nil
+		}
+		return "", fmt.Errorf("unsupported key curve %q", cr.Spec.KeyCurve)
+	default:
+		return "", fmt.Errorf("unsupported key algorithm %q", cr.Spec.KeyAlgorithm)
+	}
+}
+
+// awsSigner signs digests with an AWS KMS key.
+type awsSigner struct {
+	client kmsiface.KMSAPI
+	keyID  string
+	public crypto.PublicKey
+}
+
+func (s *awsSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest with the KMS key. KMS returns PKCS #1 v1.5 or PSS signatures for RSA keys and ASN.1 DER
+// encoded signatures for ECDSA keys, which are the encodings crypto.Signer implementations return.
+func (s *awsSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := awsSigningAlgorithm(s.public, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.client.Sign(&kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with KMS key %s: %w", s.keyID, err)
+	}
+
+	return output.Signature, nil
+}
+
+// awsSigningAlgorithms maps the type of a signature and the hash of the signed digest onto an AWS KMS signing
+// algorithm.
+var awsSigningAlgorithms = map[string]map[crypto.Hash]string{
+	"RSA": {
+		crypto.SHA256: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+		crypto.SHA384: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+		crypto.SHA512: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512,
+	},
+	"RSA-PSS": {
+		crypto.SHA256: kms.SigningAlgorithmSpecRsassaPssSha256,
+		crypto.SHA384: kms.SigningAlgorithmSpecRsassaPssSha384,
+		crypto.SHA512: kms.SigningAlgorithmSpecRsassaPssSha512,
+	},
+	"ECDSA": {
+		crypto.SHA256: kms.SigningAlgorithmSpecEcdsaSha256,
+		crypto.SHA384: kms.SigningAlgorithmSpecEcdsaSha384,
+		crypto.SHA512: kms.SigningAlgorithmSpecEcdsaSha512,
+	},
+}
+
+// awsSigningAlgorithm returns the AWS KMS signing algorithm for a public key and the signer options.
+func awsSigningAlgorithm(public crypto.PublicKey, opts crypto.SignerOpts) (string, error) {
+	var signature string
+	switch public.(type) {
+	case *rsa.PublicKey:
+		signature = "RSA"
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			signature = "RSA-PSS"
+		}
+	case *ecdsa.PublicKey:
+		signature = "ECDSA"
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	algorithm, ok := awsSigningAlgorithms[signature][opts.HashFunc()]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash function %v", opts.HashFunc())
+	}
+	return algorithm, nil
+}
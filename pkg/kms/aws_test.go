@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const testKeyARN = "arn:aws:kms:us-east-1:123456789012:key/test"
+
+// mockKMSClient holds a single ECDSA key in memory.
+type mockKMSClient struct {
+	kmsiface.KMSAPI
+	key         *ecdsa.PrivateKey
+	createInput *kms.CreateKeyInput
+	deletedKey  string
+}
+
+func (m *mockKMSClient) CreateKey(input *kms.CreateKeyInput) (*kms.CreateKeyOutput, error) {
+	m.createInput = input
+	return &kms.CreateKeyOutput{KeyMetadata: &kms.KeyMetadata{Arn: aws.String(testKeyARN)}}, nil
+}
+
+func (m *mockKMSClient) GetPublicKey(input *kms.GetPublicKeyInput) (*kms.GetPublicKeyOutput, error) {
+	der, err := x509.MarshalPKIXPublicKey(&m.key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.GetPublicKeyOutput{KeyId: input.KeyId, PublicKey: der}, nil
+}
+
+func (m *mockKMSClient) Sign(input *kms.SignInput) (*kms.SignOutput, error) {
+	signature, err := ecdsa.SignASN1(rand.Reader, m.key, input.Message)
+	if err != nil {
+		return nil, err
+	}
+	return &kms.SignOutput{KeyId: input.KeyId, Signature: signature, SigningAlgorithm: input.SigningAlgorithm}, nil
+}
+
+func (m *mockKMSClient) ScheduleKeyDeletion(input *kms.ScheduleKeyDeletionInput) (*kms.ScheduleKeyDeletionOutput, error) {
+	m.deletedKey = aws.StringValue(input.KeyId)
+	return &kms.ScheduleKeyDeletionOutput{KeyId: input.KeyId}, nil
+}
+
+func TestAWSClient(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	mock := &mockKMSClient{key: key}
+	c := &awsClient{client: mock}
+	cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{KeyAlgorithm: certmanv1alpha1.ECDSAKeyAlgorithm}}
+
+	keyID, err := c.CreateKey(cr, 2048)
+	require.NoError(t, err)
+	assert.Equal(t, testKeyARN, keyID)
+	assert.Equal(t, kms.KeySpecEccNistP256, aws.StringValue(mock.createInput.KeySpec))
+	assert.Equal(t, kms.KeyUsageTypeSignVerify, aws.StringValue(mock.createInput.KeyUsage))
+
+	signer, err := c.Signer(keyID)
+	require.NoError(t, err)
+	assert.True(t, key.PublicKey.Equal(signer.Public()))
+
+	// a certificate signing request signed by the KMS key verifies against its public key
+	csrDer, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "api.example.com"},
+		DNSNames: []string{"api.example.com"},
+	}, signer)
+	require.NoError(t, err)
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	require.NoError(t, err)
+	assert.NoError(t, csr.CheckSignature())
+
+	require.NoError(t, c.ScheduleKeyDeletion(keyID))
+	assert.Equal(t, testKeyARN, mock.deletedKey)
+}
+
+func TestAWSKeySpec(t *testing.T) {
+	tests := []struct {
+		name         string
+		algorithm    certmanv1alpha1.KeyAlgorithm
+		curve        certmanv1alpha1.KeyCurve
+		rsaKeySize   int
+		expectedSpec string
+		expectError  bool
+	}{
+		{name: "default RSA", rsaKeySize: 2048, expectedSpec: kms.KeySpecRsa2048},
+		{name: "RSA 4096", algorithm: certmanv1alpha1.RSAKeyAlgorithm, rsaKeySize: 4096, expectedSpec: kms.KeySpecRsa4096},
+		{name: "unsupported RSA size", rsaKeySize: 1024, expectError: true},
+		{name: "ECDSA P-384", algorithm: certmanv1alpha1.ECDSAKeyAlgorithm, curve: certmanv1alpha1.P384KeyCurve, expectedSpec: kms.KeySpecEccNistP384},
+		{name: "unsupported algorithm", algorithm: "Ed25519", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cr := &certmanv1alpha1.CertificateRequest{Spec: certmanv1alpha1.CertificateRequestSpec{KeyAlgorithm: test.algorithm, KeyCurve: test.curve}}
+			spec, err := awsKeySpec(cr, test.rsaKeySize)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSpec, spec)
+		})
+	}
+}
+
+func TestAWSSigningAlgorithm(t *testing.T) {
+	rsaKey := &rsa.PublicKey{}
+	ecdsaKey := &ecdsa.PublicKey{}
+
+	tests := []struct {
+		name              string
+		public            crypto.PublicKey
+		opts              crypto.SignerOpts
+		expectedAlgorithm string
+		expectError       bool
+	}{
+		{name: "RSA PKCS #1 v1.5", public: rsaKey, opts: crypto.SHA256, expectedAlgorithm: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256},
+		{name: "RSA PSS", public: rsaKey, opts: &rsa.PSSOptions{Hash: crypto.SHA384}, expectedAlgorithm: kms.SigningAlgorithmSpecRsassaPssSha384},
+		{name: "ECDSA", public: ecdsaKey, opts: crypto.SHA512, expectedAlgorithm: kms.SigningAlgorithmSpecEcdsaSha512},
+		{name: "unsupported hash", public: ecdsaKey, opts: crypto.SHA1, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			algorithm, err := awsSigningAlgorithm(test.public, test.opts)
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedAlgorithm, algorithm)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kms creates certificate private keys in a cloud key management service and signs with them, so that
+// the private keys never leave the service.
+package kms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// Client manages the private keys of certificates in a key management service.
+type Client interface {
+	// CreateKey creates a signing key of the algorithm, size and curve requested by the CertificateRequest and
+	// returns its identifier. rsaKeySize is only used for RSA keys.
+	CreateKey(cr *certmanv1alpha1.CertificateRequest, rsaKeySize int) (string, error)
+
+	// Signer returns a crypto.Signer signing with the key identified by keyID.
+	Signer(keyID string) (crypto.Signer, error)
+
+	// ScheduleKeyDeletion schedules the deletion of the key identified by keyID. The key can no longer sign once
+	// scheduled, but the deletion may be cancelled in the key management service until the pending window ends.
+	ScheduleKeyDeletion(keyID string) error
+}
+
+// NewClient returns a Client for the key management service configured by kmsKey, reading its credentials from
+// namespace.
+func NewClient(ctx context.Context, kubeClient client.Client, kmsKey *certmanv1alpha1.KMSKey, namespace string) (Client, error) {
+	if kmsKey != nil && kmsKey.AWS != nil {
+		return newAWSClient(ctx, kubeClient, kmsKey.AWS, namespace)
+	}
+	return nil, fmt.Errorf("key management service not supported")
+}
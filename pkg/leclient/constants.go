@@ -19,6 +19,8 @@ package leclient
 const (
 	letsEncryptAccountPrivateKey = "private-key"
 	letsEncryptAccountUrl        = "account-url"
+	// externalAccountBindingKey is the key of the MAC key in the external account binding secret of a CertIssuer
+	externalAccountBindingKey = "hmac-key" //#nosec - G101: Potential hardcoded credentials
 	// if letsEncryptAccountUrl is this value then a mock acme client will be used
 	mockAcmeAccountUrl = "proto://use.mock.acme.client"
 	// Deprecated, use letsEncryptAccountSecretName instead
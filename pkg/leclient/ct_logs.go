@@ -0,0 +1,357 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// CTLogs are the Certificate Transparency logs of a log list, keyed by their base64 encoded log ID.
+type CTLogs map[string]CTLog
+
+// CTLog is a Certificate Transparency log of a log list.
+type CTLog struct {
+	// Name is the description of the log, such as Google 'Argon2025h1' log.
+	Name string
+	// URL is the base URL of the RFC 6962 API of the log. Static CT API logs have none.
+	URL string
+	// Key is the DER encoded public key the log signs its tree heads with.
+	Key []byte
+}
+
+// ctLogList is the subset of the v3 log list format published by Google read by the operator.
+type ctLogList struct {
+	Operators []struct {
+		Logs      []ctLogListEntry `json:"logs"`
+		TiledLogs []ctLogListEntry `json:"tiled_logs"`
+	} `json:"operators"`
+}
+
+type ctLogListEntry struct {
+	Description string `json:"description"`
+	LogID       string `json:"log_id"`
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+}
+
+// ParseCTLogList returns the logs of a log list in the v3 format published by Google, such as
+// https://www.gstatic.com/ct/log_list/v3/log_list.json.
+func ParseCTLogList(data []byte) (CTLogs, error) {
+	list := ctLogList{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse the CT log list: %w", err)
+	}
+
+	logs := CTLogs{}
+	for _, operator := range list.Operators {
+		for _, entry := range operator.Logs {
+			key, err := base64.StdEncoding.DecodeString(entry.Key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the key of CT log %q: %w", entry.Description, err)
+			}
+			logs[entry.LogID] = CTLog{Name: entry.Description, URL: entry.URL, Key: key}
+		}
+		// static CT API logs do not serve inclusion proofs by hash, they are only listed to be named
+		for _, entry := range operator.TiledLogs {
+			logs[entry.LogID] = CTLog{Name: entry.Description}
+		}
+	}
+
+	return logs, nil
+}
+
+// NameSCTs sets the name of the log of each of scts the logs list.
+func (logs CTLogs) NameSCTs(scts []certmanv1alpha1.SignedCertificateTimestamp) {
+	for i := range scts {
+		if log, ok := logs[scts[i].LogID]; ok {
+			scts[i].LogName = log.Name
+		}
+	}
+}
+
+// VerifyInclusion checks that the leaf of certs, issued by the next certificate of certs, is included in one of the
+// listed logs that issued its embedded SCTs, and returns the name of that log. A log includes the certificate once
+// it proves that its leaf is in a tree head it signed.
+func (logs CTLogs) VerifyInclusion(ctx context.Context, httpClient *http.Client, certs []*x509.Certificate) (string, error) {
+	if len(certs) < 2 {
+		return "", errors.New("the issuer of the certificate is needed to verify its inclusion in a CT log")
+	}
+
+	scts, err := parseEmbeddedSCTs(certs[0])
+	if err != nil {
+		return "", err
+	}
+
+	tbs, err := precertificateTBS(certs[0])
+	if err != nil {
+		return "", err
+	}
+	issuerKeyHash := sha256.Sum256(certs[1].RawSubjectPublicKeyInfo)
+
+	var errs []error
+	for _, sct := range scts {
+		log, ok := logs[base64.StdEncoding.EncodeToString(sct.logID)]
+		if !ok || log.URL == "" {
+			continue
+		}
+
+		err := log.verifyInclusion(ctx, httpClient, precertificateLeafHash(sct, issuerKeyHash, tbs))
+		if err == nil {
+			return log.Name, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", log.Name, err))
+	}
+
+	if len(errs) == 0 {
+		return "", errors.New("none of the CT logs of the embedded signed certificate timestamps is in the log list")
+	}
+	return "", fmt.Errorf("certificate is not included in any CT log yet: %w", errors.Join(errs...))
+}
+
+// verifyInclusion checks that the log proves the leaf hashed to leafHash is in its latest signed tree head, using the
+// get-sth and get-proof-by-hash requests of RFC 6962 section 4.
+func (log CTLog) verifyInclusion(ctx context.Context, httpClient *http.Client, leafHash []byte) error {
+	sth := struct {
+		TreeSize          uint64 `json:"tree_size"`
+		Timestamp         uint64 `json:"timestamp"`
+		SHA256RootHash    []byte `json:"sha256_root_hash"`
+		TreeHeadSignature []byte `json:"tree_head_signature"`
+	}{}
+	if err := log.get(ctx, httpClient, "get-sth", nil, &sth); err != nil {
+		return err
+	}
+	if err := log.verifyTreeHeadSignature(sth.Timestamp, sth.TreeSize, sth.SHA256RootHash, sth.TreeHeadSignature); err != nil {
+		return err
+	}
+
+	proof := struct {
+		LeafIndex uint64   `json:"leaf_index"`
+		AuditPath [][]byte `json:"audit_path"`
+	}{}
+	query := url.Values{
+		"hash":      {base64.StdEncoding.EncodeToString(leafHash)},
+		"tree_size": {strconv.FormatUint(sth.TreeSize, 10)},
+	}
+	if err := log.get(ctx, httpClient, "get-proof-by-hash", query, &proof); err != nil {
+		return err
+	}
+
+	if !verifyInclusionProof(proof.LeafIndex, sth.TreeSize, leafHash, proof.AuditPath, sth.SHA256RootHash) {
+		return errors.New("inclusion proof does not match the signed tree head")
+	}
+	return nil
+}
+
+// get sends the RFC 6962 request named method to the log and decodes its JSON response into out.
+func (log CTLog) get(ctx context.Context, httpClient *http.Client, method string, query url.Values, out interface{}) error {
+	endpoint := strings.TrimSuffix(log.URL, "/") + "/ct/v1/" + method
+	if query != nil {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// logs answer get-proof-by-hash with 400 until they include the leaf
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", method, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// verifyTreeHeadSignature checks the TLS encoded signature of the log over a tree head, as defined in RFC 6962
+// section 3.5.
+func (log CTLog) verifyTreeHeadSignature(timestamp, treeSize uint64, rootHash, signature []byte) error {
+	// version v1, signature type tree_hash
+	signed := []byte{0, 1}
+	signed = binary.BigEndian.AppendUint64(signed, timestamp)
+	signed = binary.BigEndian.AppendUint64(signed, treeSize)
+	signed = append(signed, rootHash...)
+	digest := sha256.Sum256(signed)
+
+	// hash algorithm (1) + signature algorithm (1) + signature
+	if len(signature) < 2 || signature[0] != 4 {
+		return errors.New("tree head is not signed with sha256")
+	}
+	rest := signature[2:]
+	sig, err := readOpaque16(&rest)
+	if err != nil {
+		return err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(log.Key)
+	if err != nil {
+		return fmt.Errorf("failed to parse the key of the log: %w", err)
+	}
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errors.New("invalid tree head signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("invalid tree head signature: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T of the log", key)
+	}
+	return nil
+}
+
+// precertificateLeafHash returns the hash of the Merkle tree leaf a log adds for the precertificate it issued sct for,
+// as defined in RFC 6962 sections 3.4 and 2.1.
+func precertificateLeafHash(sct signedCertificateTimestamp, issuerKeyHash [32]byte, tbs []byte) []byte {
+	// leaf hash prefix, version v1, leaf type timestamped_entry
+	leaf := []byte{0, 0, 0}
+	leaf = binary.BigEndian.AppendUint64(leaf, sct.timestamp)
+	// entry type precert_entry
+	leaf = append(leaf, 0, 1)
+	leaf = append(leaf, issuerKeyHash[:]...)
+	leaf = append(leaf, byte(len(tbs)>>16), byte(len(tbs)>>8), byte(len(tbs)))
+	leaf = append(leaf, tbs...)
+	leaf = binary.BigEndian.AppendUint16(leaf, uint16(len(sct.extensions))) //#nosec - G115: read from a uint16 length
+	leaf = append(leaf, sct.extensions...)
+
+	hash := sha256.Sum256(leaf)
+	return hash[:]
+}
+
+// precertificateTBS returns the DER encoded TBSCertificate of certificate without its SCT list extension, which is
+// the TBSCertificate of the precertificate the logs issued its SCTs for.
+func precertificateTBS(certificate *x509.Certificate) ([]byte, error) {
+	var tbs asn1.RawValue
+	if _, err := asn1.Unmarshal(certificate.RawTBSCertificate, &tbs); err != nil {
+		return nil, err
+	}
+
+	var fields []byte
+	for rest := tbs.Bytes; len(rest) > 0; {
+		var field asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &field)
+		if err != nil {
+			return nil, err
+		}
+
+		// the extensions are the [3] explicitly tagged field
+		if field.Class != asn1.ClassContextSpecific || field.Tag != 3 {
+			fields = append(fields, field.FullBytes...)
+			continue
+		}
+
+		extensions, err := removeSCTListExtension(field.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 3, IsCompound: true, Bytes: extensions})
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, wrapped...)
+	}
+
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: fields})
+}
+
+// removeSCTListExtension returns the DER encoded sequence of extensions without the SCT list extension.
+func removeSCTListExtension(der []byte) ([]byte, error) {
+	var sequence asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &sequence); err != nil {
+		return nil, err
+	}
+
+	var extensions []byte
+	for rest := sequence.Bytes; len(rest) > 0; {
+		var raw asn1.RawValue
+		var err error
+		rest, err = asn1.Unmarshal(rest, &raw)
+		if err != nil {
+			return nil, err
+		}
+
+		var extension pkix.Extension
+		if _, err := asn1.Unmarshal(raw.FullBytes, &extension); err != nil {
+			return nil, err
+		}
+		if !extension.Id.Equal(sctListExtensionOID) {
+			extensions = append(extensions, raw.FullBytes...)
+		}
+	}
+
+	return asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: extensions})
+}
+
+// verifyInclusionProof checks that auditPath proves that the leaf hashed to leafHash is at leafIndex of the tree of
+// treeSize leaves hashed to rootHash, as described in RFC 9162 section 2.1.3.2.
+func verifyInclusionProof(leafIndex, treeSize uint64, leafHash []byte, auditPath [][]byte, rootHash []byte) bool {
+	if leafIndex >= treeSize {
+		return false
+	}
+
+	fn, sn := leafIndex, treeSize-1
+	r := leafHash
+	for _, p := range auditPath {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	return sn == 0 && bytes.Equal(r, rootHash)
+}
+
+// hashChildren returns the hash of the interior node of a Merkle tree with the children left and right.
+func hashChildren(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{1}, left...), right...))
+	return hash[:]
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// ctTestLog is a CT log serving the RFC 6962 get-sth and get-proof-by-hash requests for a tree of three leaves.
+type ctTestLog struct {
+	key    *ecdsa.PrivateKey
+	logID  []byte
+	leaves [][]byte
+	server *httptest.Server
+}
+
+func newCTTestLog(t *testing.T) *ctTestLog {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the log key: %v", err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal the log key: %v", err)
+	}
+	logID := sha256.Sum256(spki)
+
+	log := &ctTestLog{key: key, logID: logID[:]}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ct/v1/get-sth", log.getSTH(t))
+	mux.HandleFunc("/ct/v1/get-proof-by-hash", log.getProofByHash)
+	log.server = httptest.NewServer(mux)
+	t.Cleanup(log.server.Close)
+	return log
+}
+
+func (l *ctTestLog) root() []byte {
+	return hashChildren(hashChildren(l.leaves[0], l.leaves[1]), l.leaves[2])
+}
+
+func (l *ctTestLog) getSTH(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timestamp := uint64(time.Now().UnixMilli()) //#nosec - G115: test timestamp is positive
+		signed := []byte{0, 1}
+		signed = binary.BigEndian.AppendUint64(signed, timestamp)
+		signed = binary.BigEndian.AppendUint64(signed, uint64(len(l.leaves)))
+		signed = append(signed, l.root()...)
+		digest := sha256.Sum256(signed)
+
+		sig, err := ecdsa.SignASN1(rand.Reader, l.key, digest[:])
+		if err != nil {
+			t.Errorf("failed to sign the tree head: %v", err)
+		}
+		signature := append([]byte{4, 3}, binary.BigEndian.AppendUint16(nil, uint16(len(sig)))...) //#nosec - G115: signature is small
+		signature = append(signature, sig...)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"tree_size":           len(l.leaves),
+			"timestamp":           timestamp,
+			"sha256_root_hash":    l.root(),
+			"tree_head_signature": signature,
+		})
+	}
+}
+
+func (l *ctTestLog) getProofByHash(w http.ResponseWriter, r *http.Request) {
+	hash, _ := base64.StdEncoding.DecodeString(r.URL.Query().Get("hash"))
+
+	var proof map[string]interface{}
+	switch {
+	case bytes.Equal(hash, l.leaves[0]):
+		proof = map[string]interface{}{"leaf_index": 0, "audit_path": [][]byte{l.leaves[1], l.leaves[2]}}
+	case bytes.Equal(hash, l.leaves[1]):
+		proof = map[string]interface{}{"leaf_index": 1, "audit_path": [][]byte{l.leaves[0], l.leaves[2]}}
+	case bytes.Equal(hash, l.leaves[2]):
+		proof = map[string]interface{}{"leaf_index": 2, "audit_path": [][]byte{hashChildren(l.leaves[0], l.leaves[1])}}
+	default:
+		http.Error(w, "no such leaf", http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(proof)
+}
+
+func (l *ctTestLog) logList(t *testing.T) []byte {
+	t.Helper()
+
+	spki, err := x509.MarshalPKIXPublicKey(l.key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal the log key: %v", err)
+	}
+	return []byte(fmt.Sprintf(`{"operators": [{"name": "Test", "logs": [{"description": "Test 'Argon' log", "log_id": %q, "key": %q, "url": %q}]}]}`,
+		base64.StdEncoding.EncodeToString(l.logID), base64.StdEncoding.EncodeToString(spki), l.server.URL+"/"))
+}
+
+// buildLoggedCertificate returns a certificate embedding an SCT of logID, its issuer, and the TBSCertificate of its
+// precertificate.
+func buildLoggedCertificate(t *testing.T, logID []byte, timestamp time.Time) (*x509.Certificate, *x509.Certificate, []byte) {
+	t.Helper()
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the issuer key: %v", err)
+	}
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "R3"},
+		NotBefore:             timestamp,
+		NotAfter:              timestamp.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("failed to create the issuer: %v", err)
+	}
+	issuer, err := x509.ParseCertificate(issuerDER)
+	if err != nil {
+		t.Fatalf("failed to parse the issuer: %v", err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the leaf key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "api.example.com"},
+		DNSNames:     []string{"api.example.com"},
+		NotBefore:    timestamp,
+		NotAfter:     timestamp.Add(time.Hour),
+	}
+
+	// the TBSCertificate of a certificate only differs from its precertificate's by the SCT list extension
+	precertDER, err := x509.CreateCertificate(rand.Reader, template, issuer, leafKey.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("failed to create the precertificate: %v", err)
+	}
+	precert, err := x509.ParseCertificate(precertDER)
+	if err != nil {
+		t.Fatalf("failed to parse the precertificate: %v", err)
+	}
+
+	template.ExtraExtensions = []pkix.Extension{buildSCTListExtension(t, timestamp, logID)}
+	leafDER, err := x509.CreateCertificate(rand.Reader, template, issuer, leafKey.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("failed to create the certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("failed to parse the certificate: %v", err)
+	}
+
+	return leaf, issuer, precert.RawTBSCertificate
+}
+
+func TestPrecertificateTBS(t *testing.T) {
+	leaf, _, precertTBS := buildLoggedCertificate(t, bytes.Repeat([]byte{0xaa}, sctLogIDLength), time.Now())
+
+	tbs, err := precertificateTBS(leaf)
+	if err != nil {
+		t.Fatalf("precertificateTBS() returned an error: %v", err)
+	}
+	if !bytes.Equal(tbs, precertTBS) {
+		t.Errorf("precertificateTBS() did not return the TBSCertificate of the precertificate")
+	}
+}
+
+func TestParseCTLogList(t *testing.T) {
+	logs, err := ParseCTLogList([]byte(`{"operators": [
+		{"name": "Google", "logs": [{"description": "Google 'Argon' log", "log_id": "qqo=", "key": "AQID", "url": "https://ct.example.com/argon/"}]},
+		{"name": "Let's Encrypt", "tiled_logs": [{"description": "Let's Encrypt 'Sycamore' log", "log_id": "u7s=", "key": "AQID", "submission_url": "https://sycamore.example.com/"}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseCTLogList() returned an error: %v", err)
+	}
+
+	if logs["qqo="].Name != "Google 'Argon' log" || logs["qqo="].URL != "https://ct.example.com/argon/" || !bytes.Equal(logs["qqo="].Key, []byte{1, 2, 3}) {
+		t.Errorf("ParseCTLogList() log = %+v", logs["qqo="])
+	}
+	if logs["u7s="].Name != "Let's Encrypt 'Sycamore' log" || logs["u7s="].URL != "" {
+		t.Errorf("ParseCTLogList() tiled log = %+v", logs["u7s="])
+	}
+
+	scts := []certmanv1alpha1.SignedCertificateTimestamp{{LogID: "qqo="}, {LogID: "zMw="}}
+	logs.NameSCTs(scts)
+	if scts[0].LogName != "Google 'Argon' log" || scts[1].LogName != "" {
+		t.Errorf("NameSCTs() = %+v", scts)
+	}
+
+	if _, err := ParseCTLogList([]byte(`{"operators": [`)); err == nil {
+		t.Errorf("ParseCTLogList() expected an error for a malformed log list")
+	}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	log := newCTTestLog(t)
+	timestamp := time.Now().Truncate(time.Millisecond)
+	leaf, issuer, precertTBS := buildLoggedCertificate(t, log.logID, timestamp)
+
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	leafHash := precertificateLeafHash(signedCertificateTimestamp{
+		logID:     log.logID,
+		timestamp: uint64(timestamp.UnixMilli()), //#nosec - G115: test timestamp is positive
+	}, issuerKeyHash, precertTBS)
+	otherLeaf := sha256.Sum256([]byte("other"))
+
+	logs, err := ParseCTLogList(log.logList(t))
+	if err != nil {
+		t.Fatalf("ParseCTLogList() returned an error: %v", err)
+	}
+
+	for index := 0; index < 3; index++ {
+		t.Run(fmt.Sprintf("included at index %d", index), func(t *testing.T) {
+			log.leaves = [][]byte{otherLeaf[:], otherLeaf[:], otherLeaf[:]}
+			log.leaves[index] = leafHash
+
+			name, err := logs.VerifyInclusion(context.TODO(), http.DefaultClient, []*x509.Certificate{leaf, issuer})
+			if err != nil {
+				t.Fatalf("VerifyInclusion() returned an error: %v", err)
+			}
+			if name != "Test 'Argon' log" {
+				t.Errorf("VerifyInclusion() = %q, want %q", name, "Test 'Argon' log")
+			}
+		})
+	}
+
+	t.Run("not included yet", func(t *testing.T) {
+		log.leaves = [][]byte{otherLeaf[:], otherLeaf[:], otherLeaf[:]}
+		if _, err := logs.VerifyInclusion(context.TODO(), http.DefaultClient, []*x509.Certificate{leaf, issuer}); err == nil {
+			t.Errorf("VerifyInclusion() expected an error")
+		}
+	})
+
+	t.Run("tree head not signed by the log", func(t *testing.T) {
+		log.leaves = [][]byte{leafHash, otherLeaf[:], otherLeaf[:]}
+		otherLogs := CTLogs{}
+		for id, l := range logs {
+			otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatalf("failed to generate key: %v", err)
+			}
+			l.Key, err = x509.MarshalPKIXPublicKey(otherKey.Public())
+			if err != nil {
+				t.Fatalf("failed to marshal key: %v", err)
+			}
+			otherLogs[id] = l
+		}
+		if _, err := otherLogs.VerifyInclusion(context.TODO(), http.DefaultClient, []*x509.Certificate{leaf, issuer}); err == nil {
+			t.Errorf("VerifyInclusion() expected an error")
+		}
+	})
+
+	t.Run("log not in the log list", func(t *testing.T) {
+		log.leaves = [][]byte{leafHash, otherLeaf[:], otherLeaf[:]}
+		if _, err := (CTLogs{}).VerifyInclusion(context.TODO(), http.DefaultClient, []*x509.Certificate{leaf, issuer}); err == nil {
+			t.Errorf("VerifyInclusion() expected an error")
+		}
+	})
+}
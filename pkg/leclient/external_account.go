@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	xacme "golang.org/x/crypto/acme"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+)
+
+// registerExternalAccount registers the account whose private key is stored in the secret named secretName with the
+// ACME server of issuer, bound to the account of the certificate authority by the external account binding of issuer,
+// and stores the URL of the account in the secret. The eggsampler acme client cannot send external account bindings,
+// so the account is registered with the acme client of golang.org/x/crypto.
+func registerExternalAccount(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer, secretName types.NamespacedName) (string, error) {
+	eab := issuer.Spec.ACME.ExternalAccountBinding
+
+	privateKey, err := getLetsEncryptAccountPrivateKey(ctx, kubeClient, secretName.Name, secretName.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if privateKey == nil {
+		return "", errors.New("private key cannot be empty")
+	}
+
+	hmacKey, err := getExternalAccountBindingKey(ctx, kubeClient, eab)
+	if err != nil {
+		return "", err
+	}
+
+	acmeClient := &xacme.Client{Key: privateKey, DirectoryURL: issuer.Spec.ACME.DirectoryURL}
+	account, err := acmeClient.Register(ctx, &xacme.Account{
+		ExternalAccountBinding: &xacme.ExternalAccountBinding{KID: eab.KeyID, Key: hmacKey},
+	}, xacme.AcceptTOS)
+
+	var accountURL string
+	switch {
+	case errors.Is(err, xacme.ErrAccountAlreadyExists):
+		// the key is already registered, the client kept the URL of its account
+		accountURL = string(acmeClient.KID)
+	case err != nil:
+		return "", fmt.Errorf("failed to register the account of certissuer %s: %w", issuer.Name, err)
+	default:
+		accountURL = account.URI
+	}
+
+	secret, err := GetSecret(ctx, kubeClient, secretName.Name, secretName.Namespace)
+	if err != nil {
+		return "", err
+	}
+	baseToPatch := client.MergeFrom(secret.DeepCopy())
+	secret.Data[letsEncryptAccountUrl] = []byte(accountURL)
+	if err := kubeClient.Patch(ctx, secret, baseToPatch); err != nil {
+		return "", err
+	}
+
+	return accountURL, nil
+}
+
+// getExternalAccountBindingKey returns the MAC key of eab, stored base64url encoded in its secret.
+func getExternalAccountBindingKey(ctx context.Context, kubeClient client.Client, eab *certmanv1alpha1.ACMEExternalAccountBinding) ([]byte, error) {
+	namespace := eab.KeySecretRef.Namespace
+	if namespace == "" {
+		namespace = config.OperatorNamespace
+	}
+
+	secret, err := GetSecret(ctx, kubeClient, eab.KeySecretRef.Name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret.Data[externalAccountBindingKey]) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no %s", namespace, eab.KeySecretRef.Name, externalAccountBindingKey)
+	}
+
+	// certificate authorities hand out the key with or without padding
+	encoded := strings.TrimRight(strings.TrimSpace(string(secret.Data[externalAccountBindingKey])), "=")
+	key, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the %s of secret %s/%s: %w", externalAccountBindingKey, namespace, eab.KeySecretRef.Name, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+)
+
+// jws is a flattened JSON web signature, as sent by ACME clients.
+type jws struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+func TestNewClientForIssuerExternalAccountBinding(t *testing.T) {
+	hmacKey := []byte("0123456789abcdef0123456789abcdef")
+	var binding *jws
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"newNonce": %q, "newAccount": %q, "newOrder": %q}`, server.URL+"/new-nonce", server.URL+"/new-account", server.URL+"/new-order")
+	})
+	mux.HandleFunc("/new-nonce", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce")
+	})
+	mux.HandleFunc("/new-account", func(w http.ResponseWriter, r *http.Request) {
+		var request jws
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode the signed request: %v", err)
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(request.Payload)
+		if err != nil {
+			t.Errorf("failed to decode the payload: %v", err)
+		}
+		var account struct {
+			ExternalAccountBinding *jws `json:"externalAccountBinding"`
+		}
+		if err := json.Unmarshal(payload, &account); err != nil {
+			t.Errorf("failed to decode the account: %v", err)
+		}
+		binding = account.ExternalAccountBinding
+
+		w.Header().Set("Replay-Nonce", "nonce")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", server.URL+"/account/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"status": "valid"}`)
+	})
+
+	accountSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: config.OperatorNamespace, Name: "zerossl-account"},
+		Data:       map[string][]byte{"private-key": leAccountPrivKey},
+	}
+	eabSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: config.OperatorNamespace, Name: "zerossl-eab"},
+		// padded, as some certificate authorities hand the key out
+		Data: map[string][]byte{"hmac-key": []byte(base64.URLEncoding.EncodeToString(hmacKey) + "\n")},
+	}
+	testClient := fake.NewClientBuilder().WithRuntimeObjects(accountSecret, eabSecret).Build()
+
+	issuer := &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "zerossl"},
+		Spec: certmanv1alpha1.CertIssuerSpec{ACME: &certmanv1alpha1.ACMEIssuer{
+			DirectoryURL:     server.URL + "/directory",
+			AccountSecretRef: v1.SecretReference{Name: "zerossl-account"},
+			ExternalAccountBinding: &certmanv1alpha1.ACMEExternalAccountBinding{
+				KeyID:        "kid-1",
+				KeySecretRef: v1.SecretReference{Name: "zerossl-eab"},
+			},
+			PreferredChain: "ISRG Root X1",
+		}},
+	}
+
+	leClient, err := NewClientForIssuer(context.TODO(), testClient, issuer)
+	if err != nil {
+		t.Fatalf("NewClientForIssuer() returned an error: %v", err)
+	}
+	if leClient.Account.URL != server.URL+"/account/1" {
+		t.Errorf("NewClientForIssuer() account URL = %q, want %q", leClient.Account.URL, server.URL+"/account/1")
+	}
+	if leClient.PreferredChain != "ISRG Root X1" {
+		t.Errorf("NewClientForIssuer() preferred chain = %q, want %q", leClient.PreferredChain, "ISRG Root X1")
+	}
+
+	if binding == nil {
+		t.Fatalf("expected the account to be registered with an external account binding")
+	}
+	protected, err := base64.RawURLEncoding.DecodeString(binding.Protected)
+	if err != nil {
+		t.Fatalf("failed to decode the protected header of the binding: %v", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(protected, &header); err != nil {
+		t.Fatalf("failed to decode the protected header of the binding: %v", err)
+	}
+	if header.Alg != "HS256" || header.Kid != "kid-1" {
+		t.Errorf("binding header = %+v, want alg HS256 and kid kid-1", header)
+	}
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(binding.Protected + "." + binding.Payload))
+	if binding.Signature != base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("binding is not signed with the MAC key")
+	}
+
+	secret := &v1.Secret{}
+	if err := testClient.Get(context.TODO(), types.NamespacedName{Namespace: config.OperatorNamespace, Name: "zerossl-account"}, secret); err != nil {
+		t.Fatalf("failed to get the account secret: %v", err)
+	}
+	if string(secret.Data["account-url"]) != server.URL+"/account/1" {
+		t.Errorf("account secret account-url = %q, want %q", secret.Data["account-url"], server.URL+"/account/1")
+	}
+}
+
+func TestGetExternalAccountBindingKey(t *testing.T) {
+	eab := &certmanv1alpha1.ACMEExternalAccountBinding{KeyID: "kid-1", KeySecretRef: v1.SecretReference{Name: "eab"}}
+
+	tests := []struct {
+		name        string
+		data        map[string][]byte
+		expectError bool
+	}{
+		{
+			name: "unpadded key",
+			data: map[string][]byte{"hmac-key": []byte(base64.RawURLEncoding.EncodeToString([]byte("key")))},
+		},
+		{
+			name: "padded key",
+			data: map[string][]byte{"hmac-key": []byte(base64.URLEncoding.EncodeToString([]byte("key")))},
+		},
+		{
+			name:        "missing key",
+			data:        map[string][]byte{},
+			expectError: true,
+		},
+		{
+			name:        "key not base64url encoded",
+			data:        map[string][]byte{"hmac-key": []byte("not/base64+")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: config.OperatorNamespace, Name: "eab"}, Data: test.data}
+			testClient := fake.NewClientBuilder().WithRuntimeObjects(secret).Build()
+
+			key, err := getExternalAccountBindingKey(context.TODO(), testClient, eab)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("getExternalAccountBindingKey() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getExternalAccountBindingKey() returned an error: %v", err)
+			}
+			if string(key) != "key" {
+				t.Errorf("getExternalAccountBindingKey() = %q, want %q", key, "key")
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package leclient
 
 import (
+	"context"
 	"crypto"
 	"crypto/x509"
 	"encoding/pem"
@@ -26,30 +27,38 @@ import (
 	"strings"
 
 	"github.com/eggsampler/acme"
+	xacme "golang.org/x/crypto/acme"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/controllers/utils"
 	"github.com/openshift/certman-operator/pkg/acmeclient"
 	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
 )
 
 // define the LetsEncryptClientInterface interface
 type LetsEncryptClientInterface interface {
-	UpdateAccount(string) error
+	UpdateAccount(...string) error
 	CreateOrder([]string) error
 	GetOrderURL() string
 	OrderAuthorization() []string
 	FetchAuthorization(string) error
 	GetAuthorizationURL() string
 	GetAuthorizationIndentifier() (string, error)
-	SetChallengeType()
+	IsWildcardAuthorization() bool
+	SetChallengeType(string)
 	GetChallengeURL() string
 	GetDNS01KeyAuthorization() (string, error)
+	GetHTTP01KeyAuthorization() (string, string, error)
 	UpdateChallenge() error
 	FinalizeOrder(*x509.CertificateRequest) error
 	GetOrderEndpoint() string
 	FetchCertificates() ([]*x509.Certificate, error)
 	RevokeCertificate(*x509.Certificate) error
+	RevokeCertificateWithReason(*x509.Certificate, int) error
+	SetTraceContext(context.Context)
 }
 
 type LetsEncryptClient struct {
@@ -58,17 +67,33 @@ type LetsEncryptClient struct {
 	Order         acme.Order
 	Authorization acme.Authorization
 	Challenge     acme.Challenge
+
+	// PreferredChain is the common name of the root, or of the issuer of the top certificate, of the chain
+	// FetchCertificates returns among the alternate chains offered by the ACME server, if set.
+	PreferredChain string
+	chains         chainFetcher
+
+	// traceCtx holds the span the ACME requests are recorded under. The requests themselves cannot be cancelled.
+	traceCtx context.Context
+}
+
+// SetTraceContext records the spans of the following ACME requests as children of the span in ctx.
+func (c *LetsEncryptClient) SetTraceContext(ctx context.Context) {
+	c.traceCtx = ctx
 }
 
 // UpdateAccount updates the ACME clients account by accepting
-// email address/'s as a string. If an error occurs, it is returned.
-func (c *LetsEncryptClient) UpdateAccount(email string) (err error) {
+// email address/'s as strings, skipping empty ones. If an error occurs, it is returned.
+func (c *LetsEncryptClient) UpdateAccount(emails ...string) (err error) {
 	var contacts []string
 
-	if email != "" {
-		contacts = []string{fmt.Sprintf("mailto:%s", email)}
+	for _, email := range emails {
+		if email != "" {
+			contacts = append(contacts, fmt.Sprintf("mailto:%s", email))
+		}
 	}
 
+	defer c.startRequest(updateAccountOperation)(&err)
 	account, err := c.Client.UpdateAccount(c.Account, true, contacts...)
 	if err != nil {
 		return err
@@ -87,6 +112,7 @@ func (c *LetsEncryptClient) CreateOrder(domains []string) (err error) {
 	for _, domain := range domains {
 		ids = append(ids, acme.Identifier{Type: "dns", Value: domain})
 	}
+	defer c.startRequest(newOrderOperation)(&err)
 	c.Order, err = c.Client.NewOrder(c.Account, ids)
 	if err != nil {
 		return err
@@ -109,6 +135,7 @@ func (c *LetsEncryptClient) OrderAuthorization() []string {
 // with both the authURL and c.Account from the ACME struct. If an error
 // occurs it is returned.
 func (c *LetsEncryptClient) FetchAuthorization(authURL string) (err error) {
+	defer c.startRequest(fetchAuthorizationOperation)(&err)
 	c.Authorization, err = c.Client.FetchAuthorization(c.Account, authURL)
 	return err
 }
@@ -129,10 +156,16 @@ func (c *LetsEncryptClient) GetAuthorizationIndentifier() (AuthID string, err er
 	return AuthID, err
 }
 
-// SetChallengeType sets the local ACME structs challenge
-// via the acme pkgs ChallengeMap.
-func (c *LetsEncryptClient) SetChallengeType() {
-	c.Challenge = c.Authorization.ChallengeMap["dns-01"]
+// IsWildcardAuthorization returns true if the authorization is for the wildcard
+// name of its identifier, such as *.apps.example.com for apps.example.com.
+func (c *LetsEncryptClient) IsWildcardAuthorization() bool {
+	return c.Authorization.Wildcard
+}
+
+// SetChallengeType sets the local ACME structs challenge to the challenge of
+// challengeType, such as dns-01 or http-01, via the acme pkgs ChallengeMap.
+func (c *LetsEncryptClient) SetChallengeType(challengeType string) {
+	c.Challenge = c.Authorization.ChallengeMap[challengeType]
 }
 
 // GetDNS01KeyAuthorization passes the KeyAuthorization string from the acme
@@ -146,6 +179,17 @@ func (c *LetsEncryptClient) GetDNS01KeyAuthorization() (keyAuth string, err erro
 	return keyAuth, err
 }
 
+// GetHTTP01KeyAuthorization returns the token and the KeyAuthorization of the
+// acme Challenge struct, which an HTTP-01 challenge serves at
+// /.well-known/acme-challenge/<token>. If the token is not set, such as when the
+// authorization offers no HTTP-01 challenge, an error is returned.
+func (c *LetsEncryptClient) GetHTTP01KeyAuthorization() (token string, keyAuth string, err error) {
+	if c.Challenge.Token == "" {
+		return "", "", errors.New("challenge token not currently set")
+	}
+	return c.Challenge.Token, c.Challenge.KeyAuthorization, nil
+}
+
 // GetChallengeURL returns the URL from the acme Challenge struct.
 func (c *LetsEncryptClient) GetChallengeURL() string {
 	return c.Challenge.URL
@@ -154,6 +198,7 @@ func (c *LetsEncryptClient) GetChallengeURL() string {
 // UpdateChallenge calls the acme UpdateChallenge func with the local ACME
 // structs Account and Challenge. If an error occurs, it is returned.
 func (c *LetsEncryptClient) UpdateChallenge() (err error) {
+	defer c.startRequest(challengeOperation)(&err)
 	c.Challenge, err = c.Client.UpdateChallenge(c.Account, c.Challenge)
 	return err
 }
@@ -162,6 +207,7 @@ func (c *LetsEncryptClient) UpdateChallenge() (err error) {
 // by passing the csr along with the local ACME structs Account and Order. If an error
 // occurs, it is returned.
 func (c *LetsEncryptClient) FinalizeOrder(csr *x509.CertificateRequest) (err error) {
+	defer c.startRequest(finalizeOperation)(&err)
 	c.Order, err = c.Client.FinalizeOrder(c.Account, c.Order, csr)
 	return err
 }
@@ -175,7 +221,17 @@ func (c *LetsEncryptClient) GetOrderEndpoint() string {
 // the local ACME struct and Certificate from the acme Order struct. A slice of x509.Certificate's
 // is returned along with an error if one occurrs.
 func (c *LetsEncryptClient) FetchCertificates() (certbundle []*x509.Certificate, err error) {
+	defer c.startRequest(fetchCertificateOperation)(&err)
 	certbundle, err = c.Client.FetchCertificates(c.Account, c.Order.Certificate)
+	if err != nil || c.PreferredChain == "" || c.chains == nil {
+		return certbundle, err
+	}
+
+	ctx := c.traceCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	certbundle, err = c.preferredChain(ctx, c.Order.Certificate, certbundle, c.PreferredChain)
 	return certbundle, err
 }
 
@@ -183,14 +239,21 @@ func (c *LetsEncryptClient) FetchCertificates() (certbundle []*x509.Certificate,
 // Client method along with local ACME structs Account and PrivateKey from the acme Account struct.
 // If an error occurs, it is returned.
 func (c *LetsEncryptClient) RevokeCertificate(certificate *x509.Certificate) (err error) {
-	err = c.Client.RevokeCertificate(c.Account, certificate, c.Account.PrivateKey, 0)
+	return c.RevokeCertificateWithReason(certificate, acme.ReasonUnspecified)
+}
+
+// RevokeCertificateWithReason behaves like RevokeCertificate but passes the given RFC 5280
+// reason code to the ACME server. If an error occurs, it is returned.
+func (c *LetsEncryptClient) RevokeCertificateWithReason(certificate *x509.Certificate, reason int) (err error) {
+	defer c.startRequest(revokeCertificateOperation)(&err)
+	err = c.Client.RevokeCertificate(c.Account, certificate, c.Account.PrivateKey, reason)
 	return err
 }
 
 // getLetsEncryptAccountPrivateKey accepts client.Client as kubeClient and retrieves the
-// letsEncrypt account secret. The PrivateKey is de
-func getLetsEncryptAccountPrivateKey(kubeClient client.Client) (privateKey crypto.Signer, err error) {
-	secret, err := GetSecret(kubeClient, letsEncryptAccountSecretName, config.OperatorNamespace)
+// account secret named secretName in namespace. The PrivateKey is decoded and returned.
+func getLetsEncryptAccountPrivateKey(ctx context.Context, kubeClient client.Client, secretName, namespace string) (privateKey crypto.Signer, err error) {
+	secret, err := GetSecret(ctx, kubeClient, secretName, namespace)
 	if err != nil {
 		return privateKey, err
 	}
@@ -213,8 +276,8 @@ func getLetsEncryptAccountPrivateKey(kubeClient client.Client) (privateKey crypt
 	return privateKey, nil
 }
 
-func getLetsEncryptAccountURL(kubeClient client.Client) (url string, err error) {
-	secret, err := GetSecret(kubeClient, letsEncryptAccountSecretName, config.OperatorNamespace)
+func getLetsEncryptAccountURL(ctx context.Context, kubeClient client.Client, secretName, namespace string) (url string, err error) {
+	secret, err := GetSecret(ctx, kubeClient, secretName, namespace)
 	if err != nil {
 		return url, err
 	}
@@ -228,44 +291,166 @@ func getLetsEncryptAccountURL(kubeClient client.Client) (url string, err error)
 
 // NewClient accepts a client.Client as kubeClient and calls the acme NewClient func.
 // A LetsEncryptClient is returned, along with any error that occurs.
-func NewClient(kubeClient client.Client) (*LetsEncryptClient, error) {
-	accountURL, err := getLetsEncryptAccountURL(kubeClient)
+func NewClient(ctx context.Context, kubeClient client.Client) (*LetsEncryptClient, error) {
+	accountURL, err := getLetsEncryptAccountURL(ctx, kubeClient, letsEncryptAccountSecretName, config.OperatorNamespace)
 	if err != nil {
 		return nil, err
 	}
 
 	// if the lets encrypt secret is using the mock url, set up a mock client
 	if accountURL == mockAcmeAccountUrl {
-		mockLEClient := LetsEncryptClient{
-			Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{
-				Available: true,
-			}),
-		}
-		return &mockLEClient, err
+		return newMockClient(), nil
 	}
 
-	u, err := url.Parse(accountURL)
+	directoryURL, err := defaultDirectoryURL(ctx, kubeClient, accountURL)
 	if err != nil {
 		return nil, err
 	}
 
-	acmeClient := &LetsEncryptClient{}
+	return newAcmeClient(ctx, kubeClient, directoryURL, accountURL, letsEncryptAccountSecretName, config.OperatorNamespace)
+}
+
+// DirectoryURL returns the directory url of the ACME server of the operator's default Let's Encrypt account, or an
+// empty string if the account is the mock account, which has no server.
+func DirectoryURL(ctx context.Context, kubeClient client.Client) (string, error) {
+	accountURL, err := getLetsEncryptAccountURL(ctx, kubeClient, letsEncryptAccountSecretName, config.OperatorNamespace)
+	if err != nil {
+		return "", err
+	}
+	if accountURL == mockAcmeAccountUrl {
+		return "", nil
+	}
+	return defaultDirectoryURL(ctx, kubeClient, accountURL)
+}
+
+// defaultDirectoryURL returns the directory url of the ACME server of the operator's default account set in the
+// operator config, or the Let's Encrypt directory url matching the host of accountURL.
+func defaultDirectoryURL(ctx context.Context, kubeClient client.Client, accountURL string) (string, error) {
+	directoryURL, err := utils.GetACMEDirectoryURL(ctx, kubeClient)
+	if err != nil {
+		return "", err
+	}
+	if directoryURL != "" {
+		return directoryURL, nil
+	}
+	return directoryURLForAccount(accountURL)
+}
+
+// directoryURLForAccount returns the Let's Encrypt directory url matching the host of accountURL.
+func directoryURLForAccount(accountURL string) (string, error) {
+	u, err := url.Parse(accountURL)
+	if err != nil {
+		return "", err
+	}
 
-	directoryURL := ""
 	if strings.Contains(acme.LetsEncryptStaging, u.Host) {
-		directoryURL = acme.LetsEncryptStaging
+		return acme.LetsEncryptStaging, nil
 	} else if strings.Contains(acme.LetsEncryptProduction, u.Host) {
-		directoryURL = acme.LetsEncryptProduction
-	} else {
-		return nil, errors.New("cannot found let's encrypt directory url")
+		return acme.LetsEncryptProduction, nil
+	}
+	return "", errors.New("cannot found let's encrypt directory url")
+}
+
+// NewClientForIssuer accepts a client.Client as kubeClient and a CertIssuer, and returns a
+// LetsEncryptClient for the ACME server and account configured on the issuer.
+func NewClientForIssuer(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer) (*LetsEncryptClient, error) {
+	if issuer.Spec.ACME == nil {
+		return nil, fmt.Errorf("certissuer %s is not an ACME issuer", issuer.Name)
 	}
 
+	secretName := AccountSecretName(issuer)
+	if secretName.Name == "" {
+		return nil, fmt.Errorf("certissuer %s has no account secret configured", issuer.Name)
+	}
+
+	accountURL, err := getLetsEncryptAccountURL(ctx, kubeClient, secretName.Name, secretName.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if accountURL == mockAcmeAccountUrl {
+		return newMockClient(), nil
+	}
+
+	if issuer.Spec.ACME.DirectoryURL == "" {
+		return nil, fmt.Errorf("certissuer %s has no directory url configured", issuer.Name)
+	}
+
+	// accounts bound to an account of the certificate authority are registered by the operator
+	if accountURL == "" && issuer.Spec.ACME.ExternalAccountBinding != nil {
+		accountURL, err = registerExternalAccount(ctx, kubeClient, issuer, secretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	leClient, err := newAcmeClient(ctx, kubeClient, issuer.Spec.ACME.DirectoryURL, accountURL, secretName.Name, secretName.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	leClient.PreferredChain = issuer.Spec.ACME.PreferredChain
+	return leClient, nil
+}
+
+// NewClientForAccount returns a LetsEncryptClient for the account configured on issuer, or for the
+// operator's default Let's Encrypt account if issuer is nil.
+func NewClientForAccount(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer) (LetsEncryptClientInterface, error) {
+	if issuer == nil {
+		return NewClient(ctx, kubeClient)
+	}
+	return NewClientForIssuer(ctx, kubeClient, issuer)
+}
+
+// AccountSecretName returns the name and namespace of the secret holding the account configured on
+// issuer, or of the operator's default Let's Encrypt account secret if issuer is nil.
+func AccountSecretName(issuer *certmanv1alpha1.CertIssuer) types.NamespacedName {
+	if issuer == nil {
+		return types.NamespacedName{Name: letsEncryptAccountSecretName, Namespace: config.OperatorNamespace}
+	}
+	if issuer.Spec.ACME == nil {
+		return types.NamespacedName{}
+	}
+
+	secretRef := issuer.Spec.ACME.AccountSecretRef
+	if secretRef.Namespace == "" {
+		return types.NamespacedName{Name: secretRef.Name, Namespace: config.OperatorNamespace}
+	}
+	return types.NamespacedName{Name: secretRef.Name, Namespace: secretRef.Namespace}
+}
+
+// newMockClient returns a LetsEncryptClient backed by the fake acme client.
+func newMockClient() *LetsEncryptClient {
+	return &LetsEncryptClient{
+		Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{
+			Available: true,
+		}),
+	}
+}
+
+// IsMockClient returns true if c is backed by the fake acme client, which returns placeholder certificates.
+func IsMockClient(c LetsEncryptClientInterface) bool {
+	leClient, ok := c.(*LetsEncryptClient)
+	if !ok {
+		return false
+	}
+	_, ok = leClient.Client.(*acmemock.FakeAcmeClient)
+	return ok
+}
+
+// newAcmeClient builds a LetsEncryptClient against directoryURL using the account
+// stored in the secret named secretName in namespace.
+func newAcmeClient(ctx context.Context, kubeClient client.Client, directoryURL, accountURL, secretName, namespace string) (*LetsEncryptClient, error) {
+	var err error
+	acmeClient := &LetsEncryptClient{}
+
+	// the acme client pools the nonces returned by the server, and retries the signed requests
+	// rejected with badNonce with a fresh nonce, as described in RFC 8555 section 6.5
 	acmeClient.Client, err = acme.NewClient(directoryURL)
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey, err := getLetsEncryptAccountPrivateKey(kubeClient)
+	privateKey, err := getLetsEncryptAccountPrivateKey(ctx, kubeClient, secretName, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -274,6 +459,7 @@ func NewClient(kubeClient client.Client) (*LetsEncryptClient, error) {
 		return nil, errors.New("private key cannot be empty")
 	}
 	acmeClient.Account = acme.Account{PrivateKey: privateKey, URL: accountURL}
+	acmeClient.chains = &xacme.Client{Key: privateKey, KID: xacme.KeyID(accountURL), DirectoryURL: directoryURL}
 
 	return acmeClient, nil
 }
@@ -17,17 +17,26 @@ limitations under the License.
 package leclient
 
 import (
+	"context"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/eggsampler/acme"
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/openshift/certman-operator/config"
 	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
 	v1 "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -43,7 +52,7 @@ func TestNewClient(t *testing.T) {
 		t.Run("if no account secret is found", func(t *testing.T) {
 			testClient := setUpEmptyTestClient(t)
 
-			_, actual := NewClient(testClient)
+			_, actual := NewClient(context.TODO(), testClient)
 
 			if actual == nil {
 				t.Errorf("expected an error when attempting to get missing account secrets")
@@ -53,7 +62,7 @@ func TestNewClient(t *testing.T) {
 		t.Run("if only deprecated staging secret is set", func(t *testing.T) {
 			testClient := setUpTestClient(t, letsEncryptStagingAccountSecretName)
 
-			_, err := NewClient(testClient)
+			_, err := NewClient(context.TODO(), testClient)
 
 			if !kerr.IsNotFound(err) {
 				t.Error("expected error when using deprecated secret name")
@@ -63,7 +72,7 @@ func TestNewClient(t *testing.T) {
 		t.Run("if only deprecated production secret is set", func(t *testing.T) {
 			testClient := setUpTestClient(t, letsEncryptProductionAccountSecretName)
 
-			_, err := NewClient(testClient)
+			_, err := NewClient(context.TODO(), testClient)
 
 			if !kerr.IsNotFound(err) {
 				t.Error("expected error when using deprecated secret name")
@@ -75,7 +84,7 @@ func TestNewClient(t *testing.T) {
 		t.Run("if only approved secret is set", func(t *testing.T) {
 			testClient := setUpTestClient(t, letsEncryptAccountSecretName)
 
-			leclient, err := NewClient(testClient)
+			leclient, err := NewClient(context.TODO(), testClient)
 			if err != nil {
 				t.Fatalf("unexpected error creating the leclient: %q", err)
 			}
@@ -87,11 +96,141 @@ func TestNewClient(t *testing.T) {
 	})
 }
 
+func TestNewClientForIssuer(t *testing.T) {
+	issuer := &certmanv1alpha1.CertIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-issuer"},
+		Spec: certmanv1alpha1.CertIssuerSpec{
+			ACME: &certmanv1alpha1.ACMEIssuer{
+				DirectoryURL:     acme.LetsEncryptStaging,
+				AccountSecretRef: v1.SecretReference{Name: "test-issuer-account"},
+			},
+		},
+	}
+
+	t.Run("returns an error", func(t *testing.T) {
+		t.Run("if no account secret is referenced", func(t *testing.T) {
+			testClient := setUpEmptyTestClient(t)
+			noSecretIssuer := issuer.DeepCopy()
+			noSecretIssuer.Spec.ACME.AccountSecretRef.Name = ""
+
+			_, err := NewClientForIssuer(context.TODO(), testClient, noSecretIssuer)
+
+			if err == nil {
+				t.Error("expected an error when the issuer has no account secret")
+			}
+		})
+
+		t.Run("if the account secret is not found", func(t *testing.T) {
+			testClient := setUpEmptyTestClient(t)
+
+			_, err := NewClientForIssuer(context.TODO(), testClient, issuer)
+
+			if !kerr.IsNotFound(err) {
+				t.Errorf("expected not found error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("returns an leclient", func(t *testing.T) {
+		t.Run("if the account secret uses the mock url", func(t *testing.T) {
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: config.OperatorNamespace,
+					Name:      "test-issuer-account",
+				},
+				Data: map[string][]byte{
+					letsEncryptAccountPrivateKey: leAccountPrivKey,
+					letsEncryptAccountUrl:        []byte(mockAcmeAccountUrl),
+				},
+			}
+			testClient := fake.NewClientBuilder().WithRuntimeObjects(secret).Build()
+
+			leclient, err := NewClientForIssuer(context.TODO(), testClient, issuer)
+			if err != nil {
+				t.Fatalf("unexpected error creating the leclient: %q", err)
+			}
+
+			if leclient == nil {
+				t.Errorf("leclient failed to set up")
+			}
+		})
+	})
+}
+
+func TestDirectoryURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		accountURL  string
+		expectedURL string
+	}{
+		{name: "production account", accountURL: "https://acme-v02.api.letsencrypt.org/acme/acct/1", expectedURL: acme.LetsEncryptProduction},
+		{name: "staging account", accountURL: "https://acme-staging-v02.api.letsencrypt.org/acme/acct/1", expectedURL: acme.LetsEncryptStaging},
+		{name: "mock account", accountURL: mockAcmeAccountUrl, expectedURL: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: config.OperatorNamespace,
+					Name:      letsEncryptAccountSecretName,
+				},
+				Data: map[string][]byte{
+					letsEncryptAccountPrivateKey: leAccountPrivKey,
+					letsEncryptAccountUrl:        []byte(test.accountURL),
+				},
+			}
+			testClient := fake.NewClientBuilder().WithRuntimeObjects(secret).Build()
+
+			directoryURL, err := DirectoryURL(context.TODO(), testClient)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if directoryURL != test.expectedURL {
+				t.Errorf("expected directory url %q, got %q", test.expectedURL, directoryURL)
+			}
+		})
+	}
+
+	t.Run("uses the directory url of the operator config", func(t *testing.T) {
+		s := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := certmanv1alpha1.AddToScheme(s); err != nil {
+			t.Fatal(err)
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: config.OperatorNamespace, Name: letsEncryptAccountSecretName},
+			Data:       map[string][]byte{letsEncryptAccountUrl: []byte("https://acme.example.com/acct/1")},
+		}
+		operatorConfig := &certmanv1alpha1.CertmanOperatorConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: certmanv1alpha1.CertmanOperatorConfigName},
+			Spec:       certmanv1alpha1.CertmanOperatorConfigSpec{ACMEDirectoryURL: "https://acme.example.com/directory"},
+		}
+		testClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(secret, operatorConfig).Build()
+
+		directoryURL, err := DirectoryURL(context.TODO(), testClient)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if directoryURL != "https://acme.example.com/directory" {
+			t.Errorf("expected the directory url of the operator config, got %q", directoryURL)
+		}
+	})
+
+	t.Run("returns an error if no account secret is found", func(t *testing.T) {
+		if _, err := DirectoryURL(context.TODO(), setUpEmptyTestClient(t)); err == nil {
+			t.Error("expected an error when the account secret is missing")
+		}
+	})
+}
+
 func TestUpdateAccount(t *testing.T) {
 	tests := []struct {
 		Name                string
 		ACME                *acmemock.FakeAcmeClient
-		Email               string
+		Emails              []string
 		ExpectedContacts    []string
 		ExpectError         bool
 		ExpectedErrorString string
@@ -101,17 +240,25 @@ func TestUpdateAccount(t *testing.T) {
 			ACME: &acmemock.FakeAcmeClient{
 				Available: true,
 			},
-			Email:               "doesn't@ma.tter",
+			Emails:              []string{"doesn't@ma.tter"},
 			ExpectedContacts:    []string{"mailto:doesn't@ma.tter"},
 			ExpectError:         false,
 			ExpectedErrorString: "",
 		},
+		{
+			Name: "UpdateAccount with several contacts",
+			ACME: &acmemock.FakeAcmeClient{
+				Available: true,
+			},
+			Emails:           []string{"owner@example.com", "", "team@example.com"},
+			ExpectedContacts: []string{"mailto:owner@example.com", "mailto:team@example.com"},
+		},
 		{
 			Name: "update when Let's Encrypt is down",
 			ACME: &acmemock.FakeAcmeClient{
 				Available: false,
 			},
-			Email:               "doesn't@ma.tter",
+			Emails:              []string{"doesn't@ma.tter"},
 			ExpectError:         true,
 			ExpectedErrorString: "acme: error code 0 \"urn:acme:error:serverInternal\": The service is down for maintenance or had an internal error. Check https://letsencrypt.status.io/ for more details",
 		},
@@ -122,7 +269,7 @@ func TestUpdateAccount(t *testing.T) {
 			testLEClient := &LetsEncryptClient{
 				Client: test.ACME,
 			}
-			err := testLEClient.UpdateAccount(test.Email)
+			err := testLEClient.UpdateAccount(test.Emails...)
 
 			if err != nil {
 				if !test.ExpectError {
@@ -223,6 +370,89 @@ func TestCreateOrder(t *testing.T) {
 	}
 }
 
+// TestCreateOrderBadNonce runs the client against an ACME server rejecting the nonce of the first new order, and
+// checks that the order is created by retrying it with a fresh nonce instead of failing.
+func TestCreateOrderBadNonce(t *testing.T) {
+	var mu sync.Mutex
+	issued := 0
+	var orderNonces []string
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	freshNonce := func(w http.ResponseWriter) {
+		mu.Lock()
+		defer mu.Unlock()
+		issued++
+		w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", issued))
+	}
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"newNonce": %q, "newOrder": %q}`, server.URL+"/new-nonce", server.URL+"/new-order")
+	})
+	mux.HandleFunc("/new-nonce", func(w http.ResponseWriter, r *http.Request) {
+		freshNonce(w)
+	})
+	mux.HandleFunc("/new-order", func(w http.ResponseWriter, r *http.Request) {
+		var jws struct {
+			Protected string `json:"protected"`
+		}
+		var header struct {
+			Nonce string `json:"nonce"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+			t.Errorf("failed to decode the signed request: %v", err)
+		}
+		protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+		if err == nil {
+			err = json.Unmarshal(protected, &header)
+		}
+		if err != nil {
+			t.Errorf("failed to decode the protected header: %v", err)
+		}
+
+		mu.Lock()
+		orderNonces = append(orderNonces, header.Nonce)
+		attempt := len(orderNonces)
+		mu.Unlock()
+
+		freshNonce(w)
+		if attempt == 1 {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"type": "urn:ietf:params:acme:error:badNonce", "detail": "JWS has an invalid anti-replay nonce"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", server.URL+"/order/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"status": "pending", "finalize": %q}`, server.URL+"/order/1/finalize")
+	})
+
+	testClient := setUpTestClient(t, letsEncryptAccountSecretName)
+	leClient, err := newAcmeClient(context.TODO(), testClient, server.URL+"/directory", server.URL+"/account/1", letsEncryptAccountSecretName, config.OperatorNamespace)
+	if err != nil {
+		t.Fatalf("newAcmeClient() returned an error: %v", err)
+	}
+
+	if err := leClient.CreateOrder([]string{"domain.one.tld"}); err != nil {
+		t.Fatalf("CreateOrder() returned an error after a badNonce: %v", err)
+	}
+	if leClient.GetOrderURL() != server.URL+"/order/1" {
+		t.Errorf("CreateOrder() order URL = %q, want %q", leClient.GetOrderURL(), server.URL+"/order/1")
+	}
+	if len(orderNonces) != 2 {
+		t.Fatalf("expected the new order to be sent twice, got %d", len(orderNonces))
+	}
+	if orderNonces[0] == orderNonces[1] {
+		t.Errorf("expected the new order to be retried with a fresh nonce, got %q twice", orderNonces[0])
+	}
+	if orderNonces[1] != "nonce-2" {
+		t.Errorf("expected the retry to use the nonce returned with the badNonce error, got %q", orderNonces[1])
+	}
+}
+
 func TestGetOrderURL(t *testing.T) {
 	tests := []struct {
 		Name        string
@@ -459,7 +689,7 @@ func TestSetChallengeType(t *testing.T) {
 				},
 			}
 
-			testLEClient.SetChallengeType()
+			testLEClient.SetChallengeType("dns-01")
 
 			if !reflect.DeepEqual(testLEClient.Challenge, test.ExpectedChallengeType) {
 				t.Errorf("SetChallengeType() %s: expected %v, got %v\n", test.Name, test.ExpectedChallengeType, testLEClient.Challenge)
@@ -522,6 +752,41 @@ func TestGetDNS01KeyAuthorization(t *testing.T) {
 	}
 }
 
+func TestGetHTTP01KeyAuthorization(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Challenge       acme.Challenge
+		ExpectedToken   string
+		ExpectedKeyAuth string
+		ExpectError     bool
+	}{
+		{
+			Name:            "returns the token and key authorization",
+			Challenge:       acme.Challenge{Type: "http-01", Token: "token", KeyAuthorization: "token.thumbprint"},
+			ExpectedToken:   "token",
+			ExpectedKeyAuth: "token.thumbprint",
+		},
+		{
+			Name:        "errors without a challenge",
+			ExpectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testLEClient := &LetsEncryptClient{Challenge: test.Challenge}
+
+			token, keyAuth, err := testLEClient.GetHTTP01KeyAuthorization()
+			if test.ExpectError != (err != nil) {
+				t.Errorf("GetHTTP01KeyAuthorization() %s: ExpectError: %t, actual error: %v\n", test.Name, test.ExpectError, err)
+			}
+			if token != test.ExpectedToken || keyAuth != test.ExpectedKeyAuth {
+				t.Errorf("GetHTTP01KeyAuthorization() %s: expected %s %s, got %s %s\n", test.Name, test.ExpectedToken, test.ExpectedKeyAuth, token, keyAuth)
+			}
+		})
+	}
+}
+
 func TestGetChallengeURL(t *testing.T) {
 	tests := []struct {
 		Name        string
@@ -0,0 +1,80 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eggsampler/acme"
+
+	"github.com/openshift/certman-operator/pkg/localmetrics"
+	"github.com/openshift/certman-operator/pkg/tracing"
+)
+
+// Operations reported by the ACME request metrics
+const (
+	updateAccountOperation      = "updateAccount"
+	newOrderOperation           = "newOrder"
+	fetchAuthorizationOperation = "fetchAuthorization"
+	challengeOperation          = "challenge"
+	finalizeOperation           = "finalize"
+	fetchCertificateOperation   = "fetchCertificate"
+	revokeCertificateOperation  = "revokeCertificate"
+
+	// acmeProblemTypePrefix is trimmed from the ACME problem types of RFC 8555 section 6.7 to keep the labels short.
+	acmeProblemTypePrefix = "urn:ietf:params:acme:error:"
+)
+
+// startRequest starts the span of an ACME request of operation, and returns a function ending it and reporting the
+// duration and the outcome of the request once it returned *err.
+func (c *LetsEncryptClient) startRequest(operation string) func(*error) {
+	ctx := c.traceCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := tracing.Start(ctx, "acme."+operation)
+	start := time.Now()
+
+	return func(err *error) {
+		tracing.End(span, *err)
+		observeRequest(operation, start, *err)
+	}
+}
+
+// observeRequest reports the duration and the outcome of the ACME request of operation started at start.
+func observeRequest(operation string, start time.Time, err error) {
+	status, problemType := requestOutcome(err)
+	localmetrics.ObserveAcmeRequest(operation, status, problemType, time.Since(start))
+}
+
+// requestOutcome returns the status and the ACME problem type of a request that returned err. A request failing with
+// an ACME problem document has the HTTP status of the response, any other failure has no problem type.
+func requestOutcome(err error) (string, string) {
+	if err == nil {
+		return localmetrics.AcmeRequestSuccess, ""
+	}
+
+	var problem acme.Problem
+	if errors.As(err, &problem) {
+		return strconv.Itoa(problem.Status), strings.TrimPrefix(problem.Type, acmeProblemTypePrefix)
+	}
+	return localmetrics.AcmeRequestError, ""
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/eggsampler/acme"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+)
+
+func TestRequestOutcome(t *testing.T) {
+	tests := []struct {
+		name                string
+		err                 error
+		expectedStatus      string
+		expectedProblemType string
+	}{
+		{
+			name:           "success",
+			expectedStatus: "success",
+		},
+		{
+			name:                "acme problem",
+			err:                 acme.Problem{Status: 429, Type: "urn:ietf:params:acme:error:rateLimited"},
+			expectedStatus:      "429",
+			expectedProblemType: "rateLimited",
+		},
+		{
+			name:                "wrapped acme problem",
+			err:                 fmt.Errorf("failed to create order: %w", acme.Problem{Status: 503, Type: "urn:ietf:params:acme:error:serverInternal"}),
+			expectedStatus:      "503",
+			expectedProblemType: "serverInternal",
+		},
+		{
+			name:           "network error",
+			err:            errors.New("acme: error fetching response: connection refused"),
+			expectedStatus: "error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, problemType := requestOutcome(test.err)
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedProblemType, problemType)
+		})
+	}
+}
+
+func TestStartRequestSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx, parent := otel.Tracer("test").Start(context.TODO(), "IssueCertificate")
+	c := &LetsEncryptClient{Client: acmemock.NewFakeAcmeClient(&acmemock.FakeAcmeClientOptions{Available: true})}
+	c.SetTraceContext(ctx)
+	require.NoError(t, c.FetchAuthorization("proto://a.fake.url"))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "acme.fetchAuthorization", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+)
+
+// chainFetcher fetches the alternate chains an ACME server offers for a certificate, as described in RFC 8555
+// section 7.4.2. The eggsampler acme client only follows the default chain, so the acme client of golang.org/x/crypto
+// implements it.
+type chainFetcher interface {
+	ListCertAlternates(ctx context.Context, url string) ([]string, error)
+	FetchCert(ctx context.Context, url string, bundle bool) ([][]byte, error)
+}
+
+// preferredChain returns the first of the alternate chains of the certificate at certificateURL matching name, or
+// chain if none does.
+func (c *LetsEncryptClient) preferredChain(ctx context.Context, certificateURL string, chain []*x509.Certificate, name string) ([]*x509.Certificate, error) {
+	if chainMatches(chain, name) {
+		return chain, nil
+	}
+
+	alternates, err := c.chains.ListCertAlternates(ctx, certificateURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the alternate chains: %w", err)
+	}
+
+	for _, alternate := range alternates {
+		ders, err := c.chains.FetchCert(ctx, alternate, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the alternate chain %s: %w", alternate, err)
+		}
+
+		alternateChain := make([]*x509.Certificate, 0, len(ders))
+		for _, der := range ders {
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the alternate chain %s: %w", alternate, err)
+			}
+			alternateChain = append(alternateChain, cert)
+		}
+
+		if chainMatches(alternateChain, name) {
+			return alternateChain, nil
+		}
+	}
+
+	return chain, nil
+}
+
+// chainMatches returns true if the top certificate of chain, or its issuer, has the common name name.
+func chainMatches(chain []*x509.Certificate, name string) bool {
+	if len(chain) == 0 {
+		return false
+	}
+	top := chain[len(chain)-1]
+	return top.Subject.CommonName == name || top.Issuer.CommonName == name
+}
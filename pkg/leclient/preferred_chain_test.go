@@ -0,0 +1,188 @@
+/*
+Copyright 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/eggsampler/acme"
+
+	acmemock "github.com/openshift/certman-operator/pkg/acmeclient/mock"
+)
+
+// fakeChainFetcher serves the alternate chains of a certificate from memory.
+type fakeChainFetcher struct {
+	alternates map[string][][]byte
+	listErr    error
+	listed     int
+	fetched    []string
+}
+
+func (f *fakeChainFetcher) ListCertAlternates(_ context.Context, _ string) ([]string, error) {
+	f.listed++
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	urls := []string{}
+	for _, url := range []string{"alt/1", "alt/2"} {
+		if _, ok := f.alternates[url]; ok {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+func (f *fakeChainFetcher) FetchCert(_ context.Context, url string, _ bool) ([][]byte, error) {
+	f.fetched = append(f.fetched, url)
+	return f.alternates[url], nil
+}
+
+// buildChainCertificate returns a certificate with the common name subject, issued by the common name issuer.
+func buildChainCertificate(t *testing.T, subject, issuer string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: subject},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	parent := &x509.Certificate{Subject: pkix.Name{CommonName: issuer}}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestPreferredChain(t *testing.T) {
+	leaf := buildChainCertificate(t, "api.example.com", "R3")
+	defaultIntermediate := buildChainCertificate(t, "R3", "DST Root CA X3")
+	alternateIntermediate := buildChainCertificate(t, "R3", "ISRG Root X1")
+
+	defaultChain := []*x509.Certificate{leaf, defaultIntermediate}
+	alternates := map[string][][]byte{
+		"alt/1": {leaf.Raw, alternateIntermediate.Raw},
+	}
+
+	tests := []struct {
+		name          string
+		preferred     string
+		fetcher       *fakeChainFetcher
+		expectedTop   *x509.Certificate
+		expectFetched bool
+		expectError   bool
+	}{
+		{
+			name:        "default chain matches",
+			preferred:   "DST Root CA X3",
+			fetcher:     &fakeChainFetcher{alternates: alternates},
+			expectedTop: defaultIntermediate,
+		},
+		{
+			name:        "top certificate of the default chain matches",
+			preferred:   "R3",
+			fetcher:     &fakeChainFetcher{alternates: alternates},
+			expectedTop: defaultIntermediate,
+		},
+		{
+			name:          "alternate chain matches",
+			preferred:     "ISRG Root X1",
+			fetcher:       &fakeChainFetcher{alternates: alternates},
+			expectedTop:   alternateIntermediate,
+			expectFetched: true,
+		},
+		{
+			name:          "no chain matches",
+			preferred:     "Unknown Root",
+			fetcher:       &fakeChainFetcher{alternates: alternates},
+			expectedTop:   defaultIntermediate,
+			expectFetched: true,
+		},
+		{
+			name:        "alternates cannot be listed",
+			preferred:   "ISRG Root X1",
+			fetcher:     &fakeChainFetcher{listErr: errors.New("connection refused")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &LetsEncryptClient{chains: test.fetcher}
+
+			chain, err := c.preferredChain(context.TODO(), "cert/1", defaultChain, test.preferred)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("preferredChain() expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("preferredChain() returned an error: %v", err)
+			}
+			if !chain[len(chain)-1].Equal(test.expectedTop) {
+				t.Errorf("preferredChain() returned the chain topped by %q issued by %q", chain[len(chain)-1].Subject.CommonName, chain[len(chain)-1].Issuer.CommonName)
+			}
+			if fetched := len(test.fetcher.fetched) > 0; fetched != test.expectFetched {
+				t.Errorf("preferredChain() fetched the alternate chains: %t, expected %t", fetched, test.expectFetched)
+			}
+		})
+	}
+}
+
+func TestFetchCertificatesPreferredChain(t *testing.T) {
+	fetcher := &fakeChainFetcher{}
+	testLEClient := LetsEncryptClient{
+		Client:  &acmemock.FakeAcmeClient{Available: true},
+		Account: acme.Account{},
+		Order:   acme.Order{Certificate: "cert/1"},
+		chains:  fetcher,
+	}
+
+	// without a preferred chain, the alternate chains are not looked up
+	if _, err := testLEClient.FetchCertificates(); err != nil {
+		t.Fatalf("FetchCertificates() returned an error: %v", err)
+	}
+	if fetcher.listed != 0 {
+		t.Fatalf("FetchCertificates() fetched alternate chains without a preferred chain")
+	}
+
+	fetcher.listErr = errors.New("connection refused")
+	testLEClient.PreferredChain = "ISRG Root X1"
+	if _, err := testLEClient.FetchCertificates(); err == nil {
+		t.Errorf("FetchCertificates() expected the error listing the alternate chains")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+// ErrNoChallenges is returned by the ACME steps that private certificate authorities do not have.
+var ErrNoChallenges = errors.New("private certificate authorities issue certificates without challenges")
+
+// PrivateCAClient is implemented by clients of private certificate authorities, which issue certificates to the
+// operator's credentials without ACME challenges.
+type PrivateCAClient interface {
+	LetsEncryptClientInterface
+
+	// PrivateCA returns the name of the certificate authority, as recorded in events and logs.
+	PrivateCA() string
+}
+
+// IsPrivateCAClient returns true if c issues certificates without ACME challenges.
+func IsPrivateCAClient(c LetsEncryptClientInterface) bool {
+	_, ok := c.(PrivateCAClient)
+	return ok
+}
+
+// CanRevoke returns true if c can revoke certificate: any certificate when c is the client of a private CA, only
+// certificates issued by Let's Encrypt otherwise.
+func CanRevoke(c LetsEncryptClientInterface, certificate *x509.Certificate) bool {
+	return IsPrivateCAClient(c) || IsCertificateIssuerLE(certificate.Issuer)
+}
+
+// NoChallenges implements the account and challenge steps of LetsEncryptClientInterface for clients of private
+// certificate authorities, which have no accounts and return no authorizations.
+type NoChallenges struct{}
+
+// UpdateAccount does nothing, private certificate authorities have no accounts.
+func (NoChallenges) UpdateAccount(...string) error {
+	return nil
+}
+
+// OrderAuthorization returns no authorizations.
+func (NoChallenges) OrderAuthorization() []string {
+	return nil
+}
+
+func (NoChallenges) FetchAuthorization(string) error {
+	return ErrNoChallenges
+}
+
+func (NoChallenges) GetAuthorizationURL() string {
+	return ""
+}
+
+func (NoChallenges) GetAuthorizationIndentifier() (string, error) {
+	return "", ErrNoChallenges
+}
+
+func (NoChallenges) IsWildcardAuthorization() bool {
+	return false
+}
+
+func (NoChallenges) SetChallengeType(string) {}
+
+func (NoChallenges) GetChallengeURL() string {
+	return ""
+}
+
+func (NoChallenges) GetDNS01KeyAuthorization() (string, error) {
+	return "", ErrNoChallenges
+}
+
+func (NoChallenges) GetHTTP01KeyAuthorization() (string, string, error) {
+	return "", "", ErrNoChallenges
+}
+
+func (NoChallenges) UpdateChallenge() error {
+	return ErrNoChallenges
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// sctListExtensionOID is the X.509 extension holding embedded SCTs, as defined in RFC 6962 section 3.3.
+var sctListExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+const sctLogIDLength = 32
+
+var errMalformedSCTList = errors.New("malformed signed certificate timestamp list")
+
+// GetEmbeddedSCTs returns the Certificate Transparency SCTs embedded in the certificate.
+// A certificate without the SCT list extension returns an empty slice.
+func GetEmbeddedSCTs(certificate *x509.Certificate) ([]certmanv1alpha1.SignedCertificateTimestamp, error) {
+	embedded, err := parseEmbeddedSCTs(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	var scts []certmanv1alpha1.SignedCertificateTimestamp
+	for _, sct := range embedded {
+		scts = append(scts, certmanv1alpha1.SignedCertificateTimestamp{
+			LogID:     base64.StdEncoding.EncodeToString(sct.logID),
+			Timestamp: time.UnixMilli(int64(sct.timestamp)).UTC().String(), //#nosec - G115: SCT timestamps fit in an int64
+		})
+	}
+
+	return scts, nil
+}
+
+// signedCertificateTimestamp holds the fields of a v1 SCT a CT log hashes into the leaf of the certificate.
+type signedCertificateTimestamp struct {
+	logID      []byte
+	timestamp  uint64
+	extensions []byte
+}
+
+// parseEmbeddedSCTs decodes the SCTs of the SCT list extension of the certificate.
+func parseEmbeddedSCTs(certificate *x509.Certificate) ([]signedCertificateTimestamp, error) {
+	var scts []signedCertificateTimestamp
+
+	for _, ext := range certificate.Extensions {
+		if !ext.Id.Equal(sctListExtensionOID) {
+			continue
+		}
+
+		var list []byte
+		rest, err := asn1.Unmarshal(ext.Value, &list)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) != 0 {
+			return nil, errMalformedSCTList
+		}
+
+		entries, err := readOpaque16(&list)
+		if err != nil || len(list) != 0 {
+			return nil, errMalformedSCTList
+		}
+
+		for len(entries) > 0 {
+			entry, err := readOpaque16(&entries)
+			if err != nil {
+				return nil, err
+			}
+
+			sct, err := parseSCT(entry)
+			if err != nil {
+				return nil, err
+			}
+			scts = append(scts, sct)
+		}
+	}
+
+	return scts, nil
+}
+
+// parseSCT decodes the log ID, timestamp and extensions from a TLS encoded v1 SCT.
+func parseSCT(entry []byte) (signedCertificateTimestamp, error) {
+	// version (1) + log ID (32) + timestamp (8)
+	if len(entry) < 1+sctLogIDLength+8 {
+		return signedCertificateTimestamp{}, errMalformedSCTList
+	}
+	if entry[0] != 0 {
+		return signedCertificateTimestamp{}, errors.New("unsupported signed certificate timestamp version")
+	}
+
+	rest := entry[1+sctLogIDLength+8:]
+	extensions, err := readOpaque16(&rest)
+	if err != nil {
+		return signedCertificateTimestamp{}, err
+	}
+
+	return signedCertificateTimestamp{
+		logID:      entry[1 : 1+sctLogIDLength],
+		timestamp:  binary.BigEndian.Uint64(entry[1+sctLogIDLength : 1+sctLogIDLength+8]),
+		extensions: extensions,
+	}, nil
+}
+
+// readOpaque16 reads a uint16 length prefixed byte string from data and advances data past it.
+func readOpaque16(data *[]byte) ([]byte, error) {
+	if len(*data) < 2 {
+		return nil, errMalformedSCTList
+	}
+	length := int(binary.BigEndian.Uint16(*data))
+	if len(*data) < 2+length {
+		return nil, errMalformedSCTList
+	}
+
+	value := (*data)[2 : 2+length]
+	*data = (*data)[2+length:]
+	return value, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leclient
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// buildSCTListExtension returns an SCT list extension holding one v1 SCT for each log ID.
+func buildSCTListExtension(t *testing.T, timestamp time.Time, logIDs ...[]byte) pkix.Extension {
+	t.Helper()
+
+	var entries []byte
+	for _, logID := range logIDs {
+		sct := []byte{0}
+		sct = append(sct, logID...)
+		sct = binary.BigEndian.AppendUint64(sct, uint64(timestamp.UnixMilli())) //#nosec - G115: test timestamp is positive
+		sct = append(sct, 0, 0)                                                 // no extensions
+		sct = append(sct, 4, 3, 0, 1, 0)                                        // sha256/ecdsa, 1 byte signature
+		entries = binary.BigEndian.AppendUint16(entries, uint16(len(sct)))      //#nosec - G115: test SCT is small
+		entries = append(entries, sct...)
+	}
+
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(entries))) //#nosec - G115: test SCT list is small
+	list = append(list, entries...)
+
+	value, err := asn1.Marshal(list)
+	if err != nil {
+		t.Fatalf("failed to marshal sct list: %v", err)
+	}
+
+	return pkix.Extension{Id: sctListExtensionOID, Value: value}
+}
+
+func TestGetEmbeddedSCTs(t *testing.T) {
+	timestamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	logA := bytes.Repeat([]byte{0xaa}, sctLogIDLength)
+	logB := bytes.Repeat([]byte{0xbb}, sctLogIDLength)
+
+	tests := []struct {
+		name          string
+		extensions    []pkix.Extension
+		expectedLogs  []string
+		expectedError bool
+	}{
+		{
+			name:         "certificate without scts",
+			extensions:   nil,
+			expectedLogs: nil,
+		},
+		{
+			name:         "certificate with two scts",
+			extensions:   []pkix.Extension{buildSCTListExtension(t, timestamp, logA, logB)},
+			expectedLogs: []string{base64.StdEncoding.EncodeToString(logA), base64.StdEncoding.EncodeToString(logB)},
+		},
+		{
+			name:          "malformed sct list",
+			extensions:    []pkix.Extension{{Id: sctListExtensionOID, Value: []byte{0x04, 0x01, 0x00}}},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scts, err := GetEmbeddedSCTs(&x509.Certificate{Extensions: test.extensions})
+			if (err != nil) != test.expectedError {
+				t.Fatalf("GetEmbeddedSCTs() error = %v, expected error: %t", err, test.expectedError)
+			}
+
+			if len(scts) != len(test.expectedLogs) {
+				t.Fatalf("GetEmbeddedSCTs() returned %d scts, want %d", len(scts), len(test.expectedLogs))
+			}
+
+			for i, sct := range scts {
+				if sct.LogID != test.expectedLogs[i] {
+					t.Errorf("GetEmbeddedSCTs() log id = %s, want %s", sct.LogID, test.expectedLogs[i])
+				}
+				if sct.Timestamp != timestamp.String() {
+					t.Errorf("GetEmbeddedSCTs() timestamp = %s, want %s", sct.Timestamp, timestamp.String())
+				}
+			}
+		})
+	}
+}
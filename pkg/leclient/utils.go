@@ -19,17 +19,19 @@ package leclient
 import (
 	"context"
 	"crypto/x509/pkix"
+	"fmt"
 
+	"github.com/eggsampler/acme"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func GetSecret(kubeClient client.Client, secretName, namespace string) (*corev1.Secret, error) {
+func GetSecret(ctx context.Context, kubeClient client.Client, secretName, namespace string) (*corev1.Secret, error) {
 
 	s := &corev1.Secret{}
 
-	err := kubeClient.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, s)
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, s)
 
 	if err != nil {
 		return nil, err
@@ -54,3 +56,27 @@ func IsCertificateIssuerLE(issuer pkix.Name) bool {
 
 	return false
 }
+
+// revocationReasons maps the RFC 5280 reason names accepted by Let's Encrypt to their reason codes.
+var revocationReasons = map[string]int{
+	"unspecified":          acme.ReasonUnspecified,
+	"keyCompromise":        acme.ReasonKeyCompromise,
+	"affiliationChanged":   acme.ReasonAffiliationChanged,
+	"superseded":           acme.ReasonSuperseded,
+	"cessationOfOperation": acme.ReasonCessationOfOperation,
+}
+
+// RevocationReasonCode returns the reason code for a revocation reason name. An empty
+// name is treated as unspecified.
+func RevocationReasonCode(reason string) (int, error) {
+	if reason == "" {
+		return acme.ReasonUnspecified, nil
+	}
+
+	code, ok := revocationReasons[reason]
+	if !ok {
+		return 0, fmt.Errorf("unsupported revocation reason %q", reason)
+	}
+
+	return code, nil
+}
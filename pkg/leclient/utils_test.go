@@ -90,3 +90,46 @@ func TestIsCertificateIssuerLE(t *testing.T) {
 		})
 	}
 }
+
+func TestRevocationReasonCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		reason    string
+		code      int
+		expectErr bool
+	}{
+		{
+			name:   "empty reason is unspecified",
+			reason: "",
+			code:   0,
+		},
+		{
+			name:   "key compromise",
+			reason: "keyCompromise",
+			code:   1,
+		},
+		{
+			name:   "superseded",
+			reason: "superseded",
+			code:   4,
+		},
+		{
+			name:      "unsupported reason",
+			reason:    "caCompromise",
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := RevocationReasonCode(test.reason)
+
+			if (err != nil) != test.expectErr {
+				t.Errorf("RevocationReasonCode() error = %v, expected error: %t", err, test.expectErr)
+			}
+			if code != test.code {
+				t.Errorf("RevocationReasonCode() got %v, want %v", code, test.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localmetrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Types of the metrics
+const (
+	MetricTypeCounter   = "counter"
+	MetricTypeGauge     = "gauge"
+	MetricTypeHistogram = "histogram"
+	MetricTypeSummary   = "summary"
+)
+
+// MetricDefinition describes a metric of MetricsList.
+type MetricDefinition struct {
+	Name string
+	Help string
+	Type string
+	// Labels are the variable labels of the metric, which its series are told apart by.
+	Labels []string
+}
+
+// descPattern parses the name, help and variable labels out of the string of a prometheus.Desc, which does not
+// expose them otherwise.
+var descPattern = regexp.MustCompile(`^Desc\{fqName: ("(?:[^"\\]|\\.)*"), help: ("(?:[^"\\]|\\.)*"), constLabels: \{.*\}, variableLabels: \{(.*)\}\}$`)
+
+// Definitions returns the definitions of the metrics of MetricsList, in the order of MetricsList.
+func Definitions() []MetricDefinition {
+	definitions := []MetricDefinition{}
+	for _, collector := range MetricsList {
+		metricType := collectorType(collector)
+		if metricType == "" {
+			continue
+		}
+
+		descs := make(chan *prometheus.Desc, 1)
+		go func() {
+			collector.Describe(descs)
+			close(descs)
+		}()
+		for desc := range descs {
+			definition, ok := parseDesc(desc.String())
+			if !ok {
+				logger.Info("cannot parse the description of a metric", "desc", desc.String())
+				continue
+			}
+			definition.Type = metricType
+			definitions = append(definitions, definition)
+		}
+	}
+	return definitions
+}
+
+// collectorType returns the type of the metrics of collector, or an empty string if it is not a metric of a known
+// type. The interfaces of the metrics overlap, a Gauge is a Counter and a Histogram is a Summary, so the type of a
+// metric without labels is read from its value.
+func collectorType(collector prometheus.Collector) string {
+	switch c := collector.(type) {
+	case *prometheus.CounterVec:
+		return MetricTypeCounter
+	case *prometheus.GaugeVec:
+		return MetricTypeGauge
+	case *prometheus.HistogramVec:
+		return MetricTypeHistogram
+	case *prometheus.SummaryVec:
+		return MetricTypeSummary
+	case prometheus.Metric:
+		metric := &dto.Metric{}
+		if err := c.Write(metric); err != nil {
+			return ""
+		}
+		switch {
+		case metric.Counter != nil:
+			return MetricTypeCounter
+		case metric.Gauge != nil:
+			return MetricTypeGauge
+		case metric.Histogram != nil:
+			return MetricTypeHistogram
+		case metric.Summary != nil:
+			return MetricTypeSummary
+		}
+	}
+	return ""
+}
+
+// parseDesc returns the definition of the metric described by desc, without its type.
+func parseDesc(desc string) (MetricDefinition, bool) {
+	match := descPattern.FindStringSubmatch(desc)
+	if match == nil {
+		return MetricDefinition{}, false
+	}
+
+	name, err := strconv.Unquote(match[1])
+	if err != nil {
+		return MetricDefinition{}, false
+	}
+	help, err := strconv.Unquote(match[2])
+	if err != nil {
+		return MetricDefinition{}, false
+	}
+
+	labels := []string{}
+	for _, label := range strings.Split(match[3], ",") {
+		// constrained labels are wrapped in c()
+		label = strings.TrimSuffix(strings.TrimPrefix(label, "c("), ")")
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return MetricDefinition{Name: name, Help: help, Labels: labels}, true
+}
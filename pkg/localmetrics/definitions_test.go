@@ -0,0 +1,84 @@
+package localmetrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefinitions(t *testing.T) {
+	definitions := Definitions()
+	if len(definitions) != len(MetricsList) {
+		t.Fatalf("expected %d definitions, got %d", len(MetricsList), len(definitions))
+	}
+
+	byName := map[string]MetricDefinition{}
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+
+	tests := []MetricDefinition{
+		{
+			Name:   "certman_operator_certificate_issue_duration",
+			Help:   "Runtime of issue certificate function in seconds",
+			Type:   MetricTypeSummary,
+			Labels: []string{},
+		},
+		{
+			Name:   "certman_operator_certificate_request_reconcile_duration_seconds",
+			Help:   "The duration it takes to reconcile a CertificateRequest",
+			Type:   MetricTypeHistogram,
+			Labels: []string{},
+		},
+		{
+			Name:   "certman_operator_certificate_requests_count",
+			Help:   "Report the current count of Certificate Requests",
+			Type:   MetricTypeGauge,
+			Labels: []string{},
+		},
+		{
+			Name:   "certman_operator_lets_encrypt_maintenance_error_count",
+			Help:   "The number of Let's Encrypt maintenance errors received",
+			Type:   MetricTypeCounter,
+			Labels: []string{},
+		},
+		{
+			Name:   "certman_operator_issuance_tokens_remaining",
+			Help:   "The number of new ACME orders an account may place before certificate requests are throttled",
+			Type:   MetricTypeGauge,
+			Labels: []string{"account", "window"},
+		},
+		{
+			Name:   "certman_operator_acme_request_errors_total",
+			Help:   "Counter on the number of failed requests to the ACME server, by operation, HTTP status and ACME problem type",
+			Type:   MetricTypeCounter,
+			Labels: []string{"operation", "status", "problem_type"},
+		},
+	}
+
+	for _, expected := range tests {
+		t.Run(expected.Name, func(t *testing.T) {
+			definition, ok := byName[expected.Name]
+			if !ok {
+				t.Fatalf("missing the definition of %s", expected.Name)
+			}
+			if !reflect.DeepEqual(expected, definition) {
+				t.Errorf("expected %+v, got %+v", expected, definition)
+			}
+		})
+	}
+}
+
+func TestParseDesc(t *testing.T) {
+	definition, ok := parseDesc(`Desc{fqName: "certman_operator_test", help: "A \"quoted\" help, with commas", constLabels: {operator="certman-operator"}, variableLabels: {account,c(window)}}`)
+	if !ok {
+		t.Fatal("expected the description to be parsed")
+	}
+	expected := MetricDefinition{Name: "certman_operator_test", Help: `A "quoted" help, with commas`, Labels: []string{"account", "window"}}
+	if !reflect.DeepEqual(expected, definition) {
+		t.Errorf("expected %+v, got %+v", expected, definition)
+	}
+
+	if _, ok := parseDesc("not a description"); ok {
+		t.Error("expected an invalid description not to be parsed")
+	}
+}
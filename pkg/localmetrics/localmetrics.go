@@ -15,19 +15,40 @@
 package localmetrics
 
 import (
-	"context"
 	"crypto/x509"
 	"fmt"
 	"math"
 	"time"
 
-	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
-	"github.com/openshift/certman-operator/controllers/utils"
 	"github.com/prometheus/client_golang/prometheus"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	dto "github.com/prometheus/client_model/go"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// Results of the reconciles
+const (
+	ReconcileIssued              = "issued"
+	ReconcileRenewed             = "renewed"
+	ReconcileUnchanged           = "unchanged"
+	ReconcileSynced              = "synced"
+	ReconcileThrottled           = "throttled"
+	ReconcileAuditBlocked        = "audit_blocked"
+	ReconcileSkippedNotManaged   = "skipped_not_managed"
+	ReconcileSkippedNotInstalled = "skipped_not_installed"
+	ReconcileSkippedFake         = "skipped_fake"
+	ReconcileSkippedRelocating   = "skipped_relocating"
+	ReconcileSkippedPaused       = "skipped_paused"
+	ReconcileSkippedHibernating  = "skipped_hibernating"
+	ReconcileError               = "error"
+)
+
+const (
+	// AcmeRequestSuccess and AcmeRequestError are the status of a successful request to the ACME server, and of a
+	// request that failed without an ACME problem document.
+	AcmeRequestSuccess = "success"
+	AcmeRequestError   = "error"
+)
+
 var (
 	MetricCertsIssuedInLastDayDevshiftOrg = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "certman_operator_certs_in_last_day_devshift_org",
@@ -91,6 +112,99 @@ var (
 		Help:        "The number of clusters in the Limited Support",
 		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
 	}, []string{"clusterdeployment_name", "clusterdeployment_namespace"})
+	MetricHibernatingCluster = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "certman_operator_cluster_hibernating",
+		Help:        "Report whether a cluster is hibernating",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"clusterdeployment_name", "clusterdeployment_namespace"})
+	MetricFIPSModeEnabled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "certman_operator_fips_mode_enabled",
+		Help:        "Report whether the operator restricts its cryptography to FIPS approved algorithms",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	})
+	MetricIssuanceTokensRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "certman_operator_issuance_tokens_remaining",
+		Help:        "The number of new ACME orders an account may place before certificate requests are throttled",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"account", "window"})
+	MetricACMECertificatesIssued7d = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "certman_operator_acme_certificates_issued_7d",
+		Help:        "The number of certificates an ACME account issued for a registered domain in the last 7 days",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"account", "registered_domain"})
+	MetricACMERateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "certman_operator_acme_rate_limit_remaining",
+		Help:        "The number of certificates that may still be issued for a registered domain before the weekly rate limit of the certificate authority",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"registered_domain"})
+	MetricRenewalCheckInterval = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "certman_operator_renewal_check_interval_seconds",
+		Help:        "The longest time between two checks of a certificate for renewal",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	})
+	MetricCertRequestsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "certman_operator_certificate_requests_failed_total",
+		Help:        "Counter on the number of certificate requests that exhausted their retry budget",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	})
+	MetricAcmeRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "certman_operator_acme_request_duration_seconds",
+		Help:        "The duration of the requests to the ACME server, by operation and HTTP status",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+		Buckets:     []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"operation", "status"})
+	MetricAcmeRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_acme_request_errors_total",
+		Help:        "Counter on the number of failed requests to the ACME server, by operation, HTTP status and ACME problem type",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"operation", "status", "problem_type"})
+	MetricDNSPropagationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "certman_operator_dns_propagation_duration_seconds",
+		Help:        "The time from the creation of a DNS-01 challenge record to the validation of the challenge",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+		Buckets:     []float64{5, 15, 30, 60, 120, 300, 600, 1200},
+	}, []string{"provider", "base_domain"})
+	MetricDNSCleanupFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_cleanup_failures_total",
+		Help:        "Counter on the number of failed deletions of DNS-01 challenge records",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	})
+	MetricReconcileOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_reconcile_outcomes_total",
+		Help:        "Counter on the number of reconciles, by controller and result",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"controller", "result"})
+	MetricDNSAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_api_requests_total",
+		Help:        "Counter on the number of requests to the API of a DNS provider, by provider and operation",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"provider", "operation"})
+	MetricDNSAPIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_api_errors_total",
+		Help:        "Counter on the number of failed requests to the API of a DNS provider, by provider, operation and error code",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"provider", "operation", "code"})
+	MetricDNSAPIThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_api_throttled_total",
+		Help:        "Counter on the number of requests to the API of a DNS provider that were throttled, by provider and operation",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"provider", "operation"})
+	MetricDNSRecordsRefused = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_records_refused_total",
+		Help:        "Counter on the number of changes to DNS records refused for not answering an ACME challenge in the zone, by provider and operation",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"provider", "operation"})
+	MetricDNSZoneCacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_dns_zone_cache_lookups_total",
+		Help:        "Counter on the number of lookups of the zone of a domain in the zone cache of the DNS clients, by provider and result, a hit or a miss",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"provider", "result"})
+
+	MetricAuditBlockedCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "certman_operator_audit_blocked_calls_total",
+		Help:        "Counter on the number of mutating calls blocked in read-only audit mode, by target and operation",
+		ConstLabels: prometheus.Labels{"operator": "certman-operator"},
+	}, []string{"target", "operation"})
 
 	MetricsList = []prometheus.Collector{
 		MetricCertsIssuedInLastDayDevshiftOrg,
@@ -107,36 +221,28 @@ var (
 		MetricCertValidDuration,
 		MetricLetsEncryptMaintenanceErrorCount,
 		MetricLimitedSupportCluster,
+		MetricHibernatingCluster,
+		MetricFIPSModeEnabled,
+		MetricIssuanceTokensRemaining,
+		MetricACMECertificatesIssued7d,
+		MetricACMERateLimitRemaining,
+		MetricRenewalCheckInterval,
+		MetricCertRequestsFailed,
+		MetricAcmeRequestDuration,
+		MetricAcmeRequestErrors,
+		MetricDNSPropagationDuration,
+		MetricDNSCleanupFailures,
+		MetricReconcileOutcomes,
+		MetricDNSAPIRequests,
+		MetricDNSAPIErrors,
+		MetricDNSAPIThrottled,
+		MetricDNSRecordsRefused,
+		MetricDNSZoneCacheLookups,
+		MetricAuditBlockedCalls,
 	}
-	areCountInitialized = false
-	logger              = logf.Log.WithName("localmetrics")
+	logger = logf.Log.WithName("localmetrics")
 )
 
-// Init Initialize the counter at start of the operator
-// Current version does not support well multiple instances of the operator to run on the same Hive cluster
-// In case of error, we don't raise the error as not impactful and Init will be retried next call, pushing correct value
-func CheckInitCounter(c client.Client) {
-	if !areCountInitialized {
-		ctx := context.TODO()
-		counter := 0.0
-
-		var certRequestList certmanv1alpha1.CertificateRequestList
-
-		if err := c.List(ctx, &certRequestList, &client.ListOptions{}); err != nil {
-			logger.Error(err, "Failed to Init counter for Certificate Request")
-		}
-
-		for _, cr := range certRequestList.Items {
-			if utils.ContainsString(cr.Finalizers, certmanv1alpha1.CertmanOperatorFinalizerLabel) {
-				counter++
-			}
-		}
-
-		MetricCertRequestsCount.Set(counter)
-		areCountInitialized = true
-	}
-}
-
 // UpdateCertsIssuedInLastDayGauge sets the gauge metric with the number of certs issued in last day
 func UpdateCertsIssuedInLastDayGauge() {
 
@@ -168,14 +274,9 @@ func UpdateMetrics(hour int) {
 	}
 }
 
-// IncrementCertRequestsCounter Increment the count of certificate requests
-func IncrementCertRequestsCounter() {
-	MetricCertRequestsCount.Inc()
-}
-
-// DecrementCertRequestsCounter Decrement the count of certificate requests
-func DecrementCertRequestsCounter() {
-	MetricCertRequestsCount.Dec()
+// SetCertRequestsCount sets the count of the CertificateRequests managed by the operator
+func SetCertRequestsCount(count int) {
+	MetricCertRequestsCount.Set(float64(count))
 }
 
 // AddCertificateIssuance Increment the count of issued certificate
@@ -233,3 +334,202 @@ func ClusterInFullSupport(name string, namespace string) {
 		"clusterdeployment_namespace": namespace,
 	}).Set(0)
 }
+
+// SetClusterHibernating reports whether a cluster is hibernating
+func SetClusterHibernating(name string, namespace string, hibernating bool) {
+	value := 0.0
+	if hibernating {
+		value = 1
+	}
+	MetricHibernatingCluster.With(prometheus.Labels{
+		"clusterdeployment_name":      name,
+		"clusterdeployment_namespace": namespace,
+	}).Set(value)
+}
+
+// IncrementDNSCleanupFailures Increment the count of failed deletions of DNS-01 challenge records
+func IncrementDNSCleanupFailures() {
+	MetricDNSCleanupFailures.Inc()
+}
+
+// ClearClusterMetrics deletes the series of a ClusterDeployment, so that deleted clusters are no longer reported
+func ClearClusterMetrics(name string, namespace string) {
+	MetricLimitedSupportCluster.DeleteLabelValues(name, namespace)
+	MetricHibernatingCluster.DeleteLabelValues(name, namespace)
+}
+
+// PruneClusterMetrics deletes the series of the ClusterDeployments for which exists returns false, and the series of
+// the certificates of the CertificateRequests for which crExists returns false.
+func PruneClusterMetrics(cdExists, crExists func(namespace, name string) bool) {
+	for _, vec := range []*prometheus.GaugeVec{MetricLimitedSupportCluster, MetricHibernatingCluster} {
+		for _, labels := range seriesLabels(vec) {
+			if !cdExists(labels["clusterdeployment_namespace"], labels["clusterdeployment_name"]) {
+				vec.DeleteLabelValues(labels["clusterdeployment_name"], labels["clusterdeployment_namespace"])
+			}
+		}
+	}
+
+	for _, labels := range seriesLabels(MetricCertValidDuration) {
+		if !crExists(labels["certificaterequest_namespace"], labels["certificaterequest_name"]) {
+			ClearCertValidDuration(labels["certificaterequest_namespace"], labels["certificaterequest_name"])
+		}
+	}
+}
+
+// seriesLabels returns the labels of each series of the collector
+func seriesLabels(c prometheus.Collector) []map[string]string {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	series := []map[string]string{}
+	for m := range ch {
+		metric := &dto.Metric{}
+		if err := m.Write(metric); err != nil {
+			logger.Error(err, "failed to read metric series")
+			continue
+		}
+		labels := map[string]string{}
+		for _, pair := range metric.GetLabel() {
+			labels[pair.GetName()] = pair.GetValue()
+		}
+		series = append(series, labels)
+	}
+	return series
+}
+
+// SetFIPSModeEnabled reports whether the operator runs in FIPS mode
+func SetFIPSModeEnabled(enabled bool) {
+	if enabled {
+		MetricFIPSModeEnabled.Set(1)
+	} else {
+		MetricFIPSModeEnabled.Set(0)
+	}
+}
+
+// SetIssuanceTokensRemaining reports the tokens left in the issuance throttle of an ACME account
+func SetIssuanceTokensRemaining(account string, window string, tokens float64) {
+	MetricIssuanceTokensRemaining.With(prometheus.Labels{
+		"account": account,
+		"window":  window,
+	}).Set(tokens)
+}
+
+// SetRateLimitBudget replaces the series of the certificates issued in the last 7 days per ACME account and
+// registered domain, and of the certificates remaining before the weekly limit per registered domain
+func SetRateLimitBudget(issued map[string]map[string]int, remaining map[string]int) {
+	MetricACMECertificatesIssued7d.Reset()
+	for account, domains := range issued {
+		for domain, count := range domains {
+			MetricACMECertificatesIssued7d.With(prometheus.Labels{
+				"account":           account,
+				"registered_domain": domain,
+			}).Set(float64(count))
+		}
+	}
+
+	MetricACMERateLimitRemaining.Reset()
+	for domain, count := range remaining {
+		MetricACMERateLimitRemaining.With(prometheus.Labels{"registered_domain": domain}).Set(float64(count))
+	}
+}
+
+// SetRenewalCheckInterval reports the effective interval between checks of a certificate for renewal
+func SetRenewalCheckInterval(interval time.Duration) {
+	MetricRenewalCheckInterval.Set(interval.Seconds())
+}
+
+// IncrementCertRequestsFailed Increment the count of certificate requests that exhausted their retry budget
+func IncrementCertRequestsFailed() {
+	MetricCertRequestsFailed.Inc()
+}
+
+// ObserveAcmeRequest reports the duration of a request to the ACME server, and counts it if it failed. status is the
+// HTTP status of a failed request, "success" or "error" if the request failed without a response from the server.
+func ObserveAcmeRequest(operation, status, problemType string, duration time.Duration) {
+	MetricAcmeRequestDuration.With(prometheus.Labels{
+		"operation": operation,
+		"status":    status,
+	}).Observe(duration.Seconds())
+
+	if status != AcmeRequestSuccess {
+		MetricAcmeRequestErrors.With(prometheus.Labels{
+			"operation":    operation,
+			"status":       status,
+			"problem_type": problemType,
+		}).Inc()
+	}
+}
+
+// ObserveDNSPropagation reports the time a DNS-01 challenge took from the creation of its TXT record to its validation
+func ObserveDNSPropagation(provider, baseDomain string, duration time.Duration) {
+	MetricDNSPropagationDuration.With(prometheus.Labels{
+		"provider":    provider,
+		"base_domain": baseDomain,
+	}).Observe(duration.Seconds())
+}
+
+// ObserveDNSAPIRequest counts a request to the API of a DNS provider. code is the error code of a failed request, or
+// an empty string if it succeeded. Each attempt of a retried request is counted.
+func ObserveDNSAPIRequest(provider, operation, code string, throttled bool) {
+	MetricDNSAPIRequests.With(prometheus.Labels{
+		"provider":  provider,
+		"operation": operation,
+	}).Inc()
+
+	if code != "" {
+		MetricDNSAPIErrors.With(prometheus.Labels{
+			"provider":  provider,
+			"operation": operation,
+			"code":      code,
+		}).Inc()
+	}
+	if throttled {
+		MetricDNSAPIThrottled.With(prometheus.Labels{
+			"provider":  provider,
+			"operation": operation,
+		}).Inc()
+	}
+}
+
+// ObserveDNSRecordRefused counts a change to a DNS record refused by the DNS client of provider.
+func ObserveDNSRecordRefused(provider, operation string) {
+	MetricDNSRecordsRefused.With(prometheus.Labels{
+		"provider":  provider,
+		"operation": operation,
+	}).Inc()
+}
+
+// ObserveDNSZoneCacheLookup counts a lookup of the zone of a domain in the zone cache of the DNS client of provider,
+// which is a hit if the zone was cached.
+func ObserveDNSZoneCacheLookup(provider string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	MetricDNSZoneCacheLookups.With(prometheus.Labels{
+		"provider": provider,
+		"result":   result,
+	}).Inc()
+}
+
+// ObserveAuditBlockedCall counts a mutating call to target blocked in read-only audit mode.
+func ObserveAuditBlockedCall(target, operation string) {
+	MetricAuditBlockedCalls.With(prometheus.Labels{
+		"target":    target,
+		"operation": operation,
+	}).Inc()
+}
+
+// ObserveReconcileOutcome counts a reconcile of controller by its result, which is ReconcileError if it returned err.
+func ObserveReconcileOutcome(controller, result string, err error) {
+	if err != nil {
+		result = ReconcileError
+	}
+	MetricReconcileOutcomes.With(prometheus.Labels{
+		"controller": controller,
+		"result":     result,
+	}).Inc()
+}
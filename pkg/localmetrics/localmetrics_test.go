@@ -1,17 +1,14 @@
 package localmetrics
 
 import (
-	"context"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"testing"
 	"time"
 
-	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestUpdateCertValidDuration(t *testing.T) {
@@ -82,76 +79,10 @@ func TestUpdateCertValidDuration(t *testing.T) {
 	}
 }
 
-// mockClient implements client.Client for testing CheckInitCounter
-type mockClient struct {
-	client.Client
-	listFunc func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error
-}
-
-func (m *mockClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
-	return m.listFunc(ctx, list, opts...)
-}
-
-func resetCheckInitCounterState() {
-	areCountInitialized = false
-	MetricCertRequestsCount.Set(0)
-}
-
-func TestCheckInitCounter(t *testing.T) {
-	// Save and restore global state
-	defer func(orig bool) { areCountInitialized = orig }(areCountInitialized)
-
-	tests := []struct {
-		name               string
-		certRequests       []certmanv1alpha1.CertificateRequest
-		finalizerLabel     string
-		listErr            error
-		expectedMetric     float64
-		expectedInitStatus bool
-	}{
-		{
-			name:               "No CertificateRequests",
-			certRequests:       []certmanv1alpha1.CertificateRequest{},
-			finalizerLabel:     certmanv1alpha1.CertmanOperatorFinalizerLabel,
-			expectedMetric:     0,
-			expectedInitStatus: true,
-		},
-		{
-			name: "One CertificateRequest with finalizer",
-			certRequests: []certmanv1alpha1.CertificateRequest{
-				{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{certmanv1alpha1.CertmanOperatorFinalizerLabel}}},
-			},
-			finalizerLabel:     certmanv1alpha1.CertmanOperatorFinalizerLabel,
-			expectedMetric:     1,
-			expectedInitStatus: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			resetCheckInitCounterState()
-			mockclient := &mockClient{
-				listFunc: func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
-					if tt.listErr != nil {
-						return tt.listErr
-					}
-					crList, ok := list.(*certmanv1alpha1.CertificateRequestList)
-					if !ok {
-						t.Fatalf("expected CertificateRequestList, got %T", list)
-					}
-					crList.Items = tt.certRequests
-					return nil
-				},
-			}
-			CheckInitCounter(mockclient)
-			got := testutil.ToFloat64(MetricCertRequestsCount)
-			if got != tt.expectedMetric {
-				t.Errorf("expected metric value %.0f, got %.0f", tt.expectedMetric, got)
-			}
-			if areCountInitialized != tt.expectedInitStatus {
-				t.Errorf("expected areCountInitialized=%v, got %v", tt.expectedInitStatus, areCountInitialized)
-			}
-		})
+func TestSetCertRequestsCount(t *testing.T) {
+	SetCertRequestsCount(3)
+	if got := testutil.ToFloat64(MetricCertRequestsCount); got != 3 {
+		t.Errorf("expected metric value 3, got %.0f", got)
 	}
 }
 
@@ -215,3 +146,106 @@ func TestClearCertValidDuration(t *testing.T) {
 		t.Errorf("Expected metric to be deleted, but found %d metric(s)", count)
 	}
 }
+
+func TestObserveAcmeRequest(t *testing.T) {
+	MetricAcmeRequestDuration.Reset()
+	MetricAcmeRequestErrors.Reset()
+
+	ObserveAcmeRequest("newOrder", AcmeRequestSuccess, "", time.Second)
+	ObserveAcmeRequest("newOrder", "429", "rateLimited", time.Second)
+
+	if count := testutil.CollectAndCount(MetricAcmeRequestDuration); count != 2 {
+		t.Errorf("expected 2 duration series, got %d", count)
+	}
+	// only the failed request is counted as an error
+	if count := testutil.CollectAndCount(MetricAcmeRequestErrors); count != 1 {
+		t.Errorf("expected 1 error series, got %d", count)
+	}
+	errors := testutil.ToFloat64(MetricAcmeRequestErrors.With(prometheus.Labels{"operation": "newOrder", "status": "429", "problem_type": "rateLimited"}))
+	if errors != 1 {
+		t.Errorf("expected 1 rateLimited error, got %.0f", errors)
+	}
+}
+
+func TestObserveDNSPropagation(t *testing.T) {
+	MetricDNSPropagationDuration.Reset()
+
+	ObserveDNSPropagation("aws", "example.com", 30*time.Second)
+	ObserveDNSPropagation("aws", "example.com", 90*time.Second)
+	ObserveDNSPropagation("gcp", "example.org", time.Minute)
+
+	if count := testutil.CollectAndCount(MetricDNSPropagationDuration); count != 2 {
+		t.Errorf("expected 2 series, got %d", count)
+	}
+}
+
+func TestClearClusterMetrics(t *testing.T) {
+	MetricLimitedSupportCluster.Reset()
+	MetricHibernatingCluster.Reset()
+
+	ClusterInLimitedSupport("deleted", "uhc-deleted")
+	SetClusterHibernating("deleted", "uhc-deleted", true)
+	ClusterInFullSupport("live", "uhc-live")
+
+	ClearClusterMetrics("deleted", "uhc-deleted")
+
+	if count := testutil.CollectAndCount(MetricLimitedSupportCluster); count != 1 {
+		t.Errorf("expected 1 limited support series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(MetricHibernatingCluster); count != 0 {
+		t.Errorf("expected no hibernating series, got %d", count)
+	}
+}
+
+func TestPruneClusterMetrics(t *testing.T) {
+	MetricLimitedSupportCluster.Reset()
+	MetricHibernatingCluster.Reset()
+	MetricCertValidDuration.Reset()
+
+	ClusterInLimitedSupport("deleted", "uhc-deleted")
+	SetClusterHibernating("deleted", "uhc-deleted", false)
+	ClusterInFullSupport("live", "uhc-live")
+	SetClusterHibernating("live", "uhc-live", false)
+	for _, name := range []string{"deleted-primary-cert-bundle", "live-primary-cert-bundle"} {
+		MetricCertValidDuration.With(prometheus.Labels{
+			"cn":                           "api.example.com",
+			"certificaterequest_name":      name,
+			"certificaterequest_namespace": "uhc-live",
+		}).Set(90)
+	}
+
+	PruneClusterMetrics(func(namespace, name string) bool {
+		return namespace == "uhc-live" && name == "live"
+	}, func(namespace, name string) bool {
+		return namespace == "uhc-live" && name == "live-primary-cert-bundle"
+	})
+
+	if count := testutil.CollectAndCount(MetricLimitedSupportCluster); count != 1 {
+		t.Errorf("expected 1 limited support series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(MetricHibernatingCluster); count != 1 {
+		t.Errorf("expected 1 hibernating series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(MetricCertValidDuration); count != 1 {
+		t.Errorf("expected 1 certificate series, got %d", count)
+	}
+}
+
+func TestObserveReconcileOutcome(t *testing.T) {
+	MetricReconcileOutcomes.Reset()
+
+	ObserveReconcileOutcome("certificaterequest", ReconcileIssued, nil)
+	ObserveReconcileOutcome("certificaterequest", ReconcileIssued, nil)
+	// a reconcile returning an error is counted as an error whatever it got to
+	ObserveReconcileOutcome("certificaterequest", ReconcileRenewed, errors.New("finalize failed"))
+
+	if issued := testutil.ToFloat64(MetricReconcileOutcomes.WithLabelValues("certificaterequest", ReconcileIssued)); issued != 2 {
+		t.Errorf("expected 2 issued reconciles, got %.0f", issued)
+	}
+	if failed := testutil.ToFloat64(MetricReconcileOutcomes.WithLabelValues("certificaterequest", ReconcileError)); failed != 1 {
+		t.Errorf("expected 1 failed reconcile, got %.0f", failed)
+	}
+	if count := testutil.CollectAndCount(MetricReconcileOutcomes); count != 2 {
+		t.Errorf("expected 2 series, got %d", count)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging builds the logger of the operator and names the keys its log entries share.
+package logging
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	zaplogfmt "github.com/sykesm/zap-logfmt"
+	uzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// Keys of the values shared by the log entries of the controllers and the DNS clients, so that a log pipeline can
+// follow a cluster or a certificate across them.
+const (
+	// Cluster is the namespace/name of a ClusterDeployment.
+	Cluster = "cluster"
+	// CertRequest is the namespace/name of a CertificateRequest.
+	CertRequest = "certrequest"
+	// Domain is a domain name of a certificate.
+	Domain = "domain"
+	// ACMEOrder is the URL of an ACME order.
+	ACMEOrder = "acme_order"
+)
+
+// Formats of the log entries
+const (
+	FormatLogfmt  = "logfmt"
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Options configure the logger of the operator.
+type Options struct {
+	// Format is one of FormatLogfmt, FormatJSON or FormatConsole.
+	Format string
+	// Level is debug, info or error, or the verbosity of the most verbose entries logged, such as 1.
+	Level string
+}
+
+// BindFlags binds the flags of the options to fs.
+func (o *Options) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Format, "log-format", FormatLogfmt,
+		"The format of the log entries, one of logfmt, json or console.")
+	fs.StringVar(&o.Level, "log-level", "info",
+		"The lowest level of the entries logged: debug, info, error, or a verbosity such as 1.")
+}
+
+// New returns a logger writing the entries of at least the level of o to out in the format of o. Timestamps are in
+// RFC 3339 format and UTC.
+func New(o Options, out io.Writer) (logr.Logger, error) {
+	level, err := parseLevel(o.Level)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+
+	encoderConfig := uzap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = func(ts time.Time, encoder zapcore.PrimitiveArrayEncoder) {
+		encoder.AppendString(ts.UTC().Format(time.RFC3339Nano))
+	}
+
+	var encoder zapcore.Encoder
+	switch o.Format {
+	case FormatLogfmt:
+		encoder = zaplogfmt.NewEncoder(encoderConfig)
+	case FormatJSON:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case FormatConsole:
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return logr.Logger{}, fmt.Errorf("invalid log format %q, must be one of logfmt, json or console", o.Format)
+	}
+
+	return zap.New(zap.WriteTo(out), zap.Encoder(encoder), zap.Level(level)), nil
+}
+
+// parseLevel parses a level name or a logr verbosity, which zap represents as a negative level.
+func parseLevel(level string) (zapcore.Level, error) {
+	if verbosity, err := strconv.Atoi(level); err == nil {
+		if verbosity < 0 {
+			return 0, fmt.Errorf("invalid log verbosity %d, must not be negative", verbosity)
+		}
+		return zapcore.Level(-verbosity), nil
+	}
+
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q, must be debug, info, error or a verbosity", level)
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     Options
+		expectError bool
+		expectV1    bool
+	}{
+		{name: "logfmt at info", options: Options{Format: FormatLogfmt, Level: "info"}},
+		{name: "json at debug", options: Options{Format: FormatJSON, Level: "debug"}, expectV1: true},
+		{name: "console at verbosity 2", options: Options{Format: FormatConsole, Level: "2"}, expectV1: true},
+		{name: "unknown format", options: Options{Format: "xml", Level: "info"}, expectError: true},
+		{name: "unknown level", options: Options{Format: FormatJSON, Level: "warn"}, expectError: true},
+		{name: "negative verbosity", options: Options{Format: FormatJSON, Level: "-1"}, expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger, err := New(test.options, out)
+			if test.expectError {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger.V(1).Info("verbose")
+			if got := strings.Contains(out.String(), "verbose"); got != test.expectV1 {
+				t.Errorf("expected verbosity 1 to be logged: %t, got %t", test.expectV1, got)
+			}
+			logger.Info("message", Cluster, "ns/name")
+			if !strings.Contains(out.String(), "ns/name") {
+				t.Errorf("expected the entry to be logged, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestNewJSON(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger, err := New(Options{Format: FormatJSON, Level: "info"}, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.Info("created order", CertRequest, "ns/cr", ACMEOrder, "https://acme/order/1")
+
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON entry, got %q: %v", out.String(), err)
+	}
+	if entry[CertRequest] != "ns/cr" || entry[ACMEOrder] != "https://acme/order/1" {
+		t.Errorf("expected the structured keys in the entry, got %v", entry)
+	}
+}
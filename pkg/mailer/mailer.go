@@ -0,0 +1,180 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mailer sends emails through an SMTP server.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	// SecretName is the name of the secret in the operator namespace holding the address and credentials of the
+	// SMTP server.
+	SecretName = "certman-operator-smtp"
+
+	hostKey     = "host"
+	portKey     = "port"
+	usernameKey = "username"
+	passwordKey = "password" //#nosec - G101: Potential hardcoded credentials
+	fromKey     = "from"
+
+	// DefaultPort is the submission port, used when the secret does not set one.
+	DefaultPort = 587
+
+	sendTimeout = 30 * time.Second
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender sends emails.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// smtpSender implements the Sender interface for an SMTP server.
+type smtpSender struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSender returns a Sender sending through the SMTP server of the SecretName secret.
+func NewSender(ctx context.Context, kubeClient client.Client) (Sender, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: SecretName, Namespace: config.OperatorNamespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := &smtpSender{
+		host:     secretValue(secret, hostKey),
+		port:     DefaultPort,
+		username: secretValue(secret, usernameKey),
+		password: secretValue(secret, passwordKey),
+		from:     secretValue(secret, fromKey),
+	}
+	if sender.host == "" {
+		return nil, fmt.Errorf("SMTP secret %v did not contain key %v", SecretName, hostKey)
+	}
+	if sender.from == "" {
+		return nil, fmt.Errorf("SMTP secret %v did not contain key %v", SecretName, fromKey)
+	}
+	if port := secretValue(secret, portKey); port != "" {
+		sender.port, err = strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v %q in SMTP secret %v: %w", portKey, port, SecretName, err)
+		}
+	}
+	return sender, nil
+}
+
+// Send sends the message, upgrading the connection to TLS when the server supports it. The credentials are only
+// sent over TLS.
+func (s *smtpSender) Send(ctx context.Context, message Message) error {
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(s.host, strconv.Itoa(s.port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send the credentials over a connection that is not encrypted
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("failed to authenticate to SMTP server: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	for _, to := range message.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to send email to %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(s.format(message, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return c.Quit()
+}
+
+// format returns the message with its headers, as sent to the SMTP server.
+func (s *smtpSender) format(message Message, date time.Time) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "From: %s\r\n", s.from)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// secretValue returns the trimmed value of key in the secret.
+func secretValue(secret *corev1.Secret, key string) string {
+	return strings.TrimSpace(string(secret.Data[key]))
+}
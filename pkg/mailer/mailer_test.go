@@ -0,0 +1,191 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+// smtpServer is an SMTP server without TLS nor authentication that records the envelopes and data it receives.
+type smtpServer struct {
+	listener   net.Listener
+	done       chan struct{}
+	recipients []string
+	data       string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{listener: listener, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "RCPT TO:<REJECTED@"):
+			reply("550 no such mailbox")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 go ahead")
+			data := &strings.Builder{}
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpServer) sender() *smtpSender {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &smtpSender{host: addr.IP.String(), port: addr.Port, from: "certman@example.com"}
+}
+
+func TestSend(t *testing.T) {
+	server := newSMTPServer(t)
+
+	err := server.sender().Send(context.TODO(), Message{
+		To:      []string{"owner@example.com"},
+		Subject: "Certificate expires in 7 days",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+	<-server.done
+
+	assert.Equal(t, []string{"owner@example.com"}, server.recipients)
+	assert.Contains(t, server.data, "From: certman@example.com\r\n")
+	assert.Contains(t, server.data, "To: owner@example.com\r\n")
+	assert.Contains(t, server.data, "Subject: Certificate expires in 7 days\r\n")
+	assert.Contains(t, server.data, "\r\n\r\nline one\r\nline two")
+}
+
+func TestSendRejected(t *testing.T) {
+	server := newSMTPServer(t)
+
+	err := server.sender().Send(context.TODO(), Message{To: []string{"rejected@example.com"}, Subject: "subject"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such mailbox")
+}
+
+func TestFormat(t *testing.T) {
+	s := &smtpSender{from: "certman@example.com"}
+	date := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	formatted := string(s.format(Message{To: []string{"a@example.com", "b@example.com"}, Subject: "Zertifikat läuft ab", Body: "body"}, date))
+	assert.Equal(t, "From: certman@example.com\r\n"+
+		"To: a@example.com, b@example.com\r\n"+
+		"Subject: =?utf-8?q?Zertifikat_l=C3=A4uft_ab?=\r\n"+
+		"Date: Sun, 10 Mar 2024 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"body", formatted)
+}
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         map[string][]byte
+		expectedPort int
+		expectError  bool
+	}{
+		{
+			name:         "default port",
+			data:         map[string][]byte{hostKey: []byte("smtp.example.com"), fromKey: []byte("certman@example.com")},
+			expectedPort: DefaultPort,
+		},
+		{
+			name:         "custom port",
+			data:         map[string][]byte{hostKey: []byte("smtp.example.com"), portKey: []byte(strconv.Itoa(2525)), fromKey: []byte("certman@example.com")},
+			expectedPort: 2525,
+		},
+		{
+			name:        "invalid port",
+			data:        map[string][]byte{hostKey: []byte("smtp.example.com"), portKey: []byte("smtp"), fromKey: []byte("certman@example.com")},
+			expectError: true,
+		},
+		{
+			name:        "missing sender address",
+			data:        map[string][]byte{hostKey: []byte("smtp.example.com")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+				Data:       test.data,
+			}
+			sender, err := NewSender(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build())
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPort, sender.(*smtpSender).port)
+		})
+	}
+}
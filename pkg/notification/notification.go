@@ -0,0 +1,200 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notification sends the events of the certificates, such as their renewals, to a webhook, for the
+// consumers of the operator that do not run Prometheus and Alertmanager.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	// SecretName is the name of the secret in the operator namespace holding the URL of the webhook, which often
+	// embeds a token, such as the URL of a Slack incoming webhook.
+	SecretName = "certman-operator-webhook"
+
+	urlKey = "url"
+
+	// FormatJSON posts the event as JSON, or the payload rendered by a template from it.
+	FormatJSON = "json"
+
+	// FormatSlack posts the summary of the event as the message of a Slack incoming webhook.
+	FormatSlack = "slack"
+
+	sendTimeout = 10 * time.Second
+
+	// maxErrorBodySize bounds the part of the body of a failed response quoted in the error.
+	maxErrorBodySize = 512
+)
+
+// Types of the events
+const (
+	EventIssuanceFailed = "IssuanceFailed"
+	EventRenewed        = "Renewed"
+	EventExpiringSoon   = "ExpiringSoon"
+)
+
+// Event is a notable change of the certificate of a CertificateRequest.
+type Event struct {
+	Type               string     `json:"type"`
+	Namespace          string     `json:"namespace"`
+	CertificateRequest string     `json:"certificateRequest"`
+	ClusterDeployment  string     `json:"clusterDeployment,omitempty"`
+	DNSNames           []string   `json:"dnsNames"`
+	Summary            string     `json:"summary"`
+	Message            string     `json:"message,omitempty"`
+	NotAfter           *time.Time `json:"notAfter,omitempty"`
+	Time               time.Time  `json:"time"`
+}
+
+// Sink sends events.
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// webhookSink implements the Sink interface for a webhook.
+type webhookSink struct {
+	httpClient *http.Client
+	url        string
+	format     string
+	payload    *template.Template
+}
+
+// NewSink returns a Sink posting to the webhook whose URL is held by the SecretName secret, in format. A
+// payloadTemplate, a text/template executed with the Event, replaces the JSON of the event in FormatJSON.
+func NewSink(ctx context.Context, kubeClient client.Client, format, payloadTemplate string) (Sink, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: SecretName, Namespace: config.OperatorNamespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookURL := strings.TrimSpace(string(secret.Data[urlKey]))
+	if webhookURL == "" {
+		return nil, fmt.Errorf("webhook secret %v did not contain key %v", SecretName, urlKey)
+	}
+
+	return newWebhookSink(webhookURL, format, payloadTemplate)
+}
+
+func newWebhookSink(webhookURL, format, payloadTemplate string) (*webhookSink, error) {
+	sink := &webhookSink{
+		httpClient: &http.Client{Timeout: sendTimeout},
+		url:        webhookURL,
+		format:     format,
+	}
+
+	switch format {
+	case FormatJSON:
+		if payloadTemplate == "" {
+			break
+		}
+		payload, err := template.New("payload").Option("missingkey=error").Parse(payloadTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook payload template: %w", err)
+		}
+		sink.payload = payload
+	case FormatSlack:
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", format)
+	}
+	return sink, nil
+}
+
+// Send posts the event to the webhook.
+func (s *webhookSink) Send(ctx context.Context, event Event) error {
+	body, err := s.body(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		// the URL of the webhook is a secret, so only the error it wraps is returned
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("failed to send notification: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// body returns the payload of the event in the format of the sink.
+func (s *webhookSink) body(event Event) ([]byte, error) {
+	switch {
+	case s.format == FormatSlack:
+		return json.Marshal(map[string]string{"text": slackText(event)})
+	case s.payload != nil:
+		buf := &bytes.Buffer{}
+		if err := s.payload.Execute(buf, event); err != nil {
+			return nil, fmt.Errorf("failed to render webhook payload: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	return json.Marshal(event)
+}
+
+// slackText returns the message of the event in Slack markup.
+func slackText(event Event) string {
+	icon := ":information_source:"
+	switch event.Type {
+	case EventIssuanceFailed:
+		icon = ":x:"
+	case EventExpiringSoon:
+		icon = ":warning:"
+	case EventRenewed:
+		icon = ":white_check_mark:"
+	}
+
+	text := fmt.Sprintf("%s *%s* - CertificateRequest `%s/%s`", icon, event.Summary, event.Namespace, event.CertificateRequest)
+	if len(event.DNSNames) > 0 {
+		text += fmt.Sprintf(" for `%s`", strings.Join(event.DNSNames, "`, `"))
+	}
+	if event.Message != "" {
+		text += "\n>" + event.Message
+	}
+	return text
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+var (
+	testTime     = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	testNotAfter = testTime.AddDate(0, 0, 10)
+	testEvent    = Event{
+		Type:               EventExpiringSoon,
+		Namespace:          "uhc-production-1234",
+		CertificateRequest: "cluster-primary-cert-bundle-secret",
+		DNSNames:           []string{"api.cluster.example.com", "*.apps.cluster.example.com"},
+		Summary:            "Certificate expires in 10 days",
+		NotAfter:           &testNotAfter,
+		Time:               testTime,
+	}
+)
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		payloadTemplate string
+		expectedBody    string
+	}{
+		{
+			name:         "json",
+			format:       FormatJSON,
+			expectedBody: `{"type":"ExpiringSoon","namespace":"uhc-production-1234","certificateRequest":"cluster-primary-cert-bundle-secret","dnsNames":["api.cluster.example.com","*.apps.cluster.example.com"],"summary":"Certificate expires in 10 days","notAfter":"2024-03-20T12:00:00Z","time":"2024-03-10T12:00:00Z"}`,
+		},
+		{
+			name:            "json template",
+			format:          FormatJSON,
+			payloadTemplate: `{"title":"{{ .Type }} {{ .Namespace }}/{{ .CertificateRequest }}","expires":"{{ .NotAfter.Format "2006-01-02" }}"}`,
+			expectedBody:    `{"title":"ExpiringSoon uhc-production-1234/cluster-primary-cert-bundle-secret","expires":"2024-03-20"}`,
+		},
+		{
+			name:         "slack",
+			format:       FormatSlack,
+			expectedBody: `{"text":":warning: *Certificate expires in 10 days* - CertificateRequest ` + "`uhc-production-1234/cluster-primary-cert-bundle-secret` for `api.cluster.example.com`, `*.apps.cluster.example.com`" + `"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				body = string(b)
+			}))
+			defer server.Close()
+
+			sink, err := newWebhookSink(server.URL, test.format, test.payloadTemplate)
+			require.NoError(t, err)
+			require.NoError(t, sink.Send(context.TODO(), testEvent))
+			assert.Equal(t, test.expectedBody, body)
+		})
+	}
+}
+
+func TestSendFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no_service", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sink, err := newWebhookSink(server.URL+"/services/T000/B000/secret-token", FormatSlack, "")
+	require.NoError(t, err)
+	err = sink.Send(context.TODO(), testEvent)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found: no_service")
+
+	server.Close()
+	err = sink.Send(context.TODO(), testEvent)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token", "the URL of the webhook must not leak into errors")
+}
+
+func TestNewSink(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+		Data:       map[string][]byte{urlKey: []byte("https://hooks.example.com/certman\n")},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+
+	sink, err := NewSink(context.TODO(), kubeClient, FormatJSON, "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/certman", sink.(*webhookSink).url)
+
+	_, err = NewSink(context.TODO(), kubeClient, "xml", "")
+	assert.Error(t, err)
+
+	_, err = NewSink(context.TODO(), kubeClient, FormatJSON, "{{ .Type ")
+	assert.Error(t, err)
+
+	_, err = NewSink(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), FormatJSON, "")
+	assert.Error(t, err, "a missing secret is an error")
+}
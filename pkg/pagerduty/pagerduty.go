@@ -0,0 +1,177 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pagerduty opens and resolves PagerDuty incidents through the Events API v2, escalating the certificates
+// that cannot be issued or renewed to the on-call engineers.
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	// SecretName is the name of the secret in the operator namespace holding the routing key of the PagerDuty
+	// service the incidents are opened on.
+	SecretName = "certman-operator-pagerduty"
+
+	routingKeyKey = "routing_key"
+	urlKey        = "url"
+
+	// DefaultURL is the URL of the Events API v2 used when the secret does not set one.
+	DefaultURL = "https://events.pagerduty.com/v2/enqueue"
+
+	sendTimeout = 10 * time.Second
+
+	// maxErrorBodySize bounds the part of the body of a failed response quoted in the error.
+	maxErrorBodySize = 512
+)
+
+// Severities of an incident
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+)
+
+// Incident is a PagerDuty incident about the certificate of a CertificateRequest.
+type Incident struct {
+	// DedupKey identifies the incident, so that triggering it again updates it and resolving it closes it.
+	DedupKey string
+	Summary  string
+	// Source is the affected system, such as the CertificateRequest.
+	Source   string
+	Severity string
+	// Details are shown with the incident.
+	Details map[string]string
+}
+
+// Client opens and resolves incidents.
+type Client interface {
+	Trigger(ctx context.Context, incident Incident) error
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+// eventsClient implements the Client interface for the PagerDuty Events API v2.
+type eventsClient struct {
+	httpClient *http.Client
+	url        string
+	routingKey string
+}
+
+// event is the body of a request to the Events API v2.
+type event struct {
+	RoutingKey  string        `json:"routing_key"`
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key"`
+	Payload     *eventPayload `json:"payload,omitempty"`
+}
+
+type eventPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// NewClient returns a Client sending events with the routing key of the SecretName secret.
+func NewClient(ctx context.Context, kubeClient client.Client) (Client, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: SecretName, Namespace: config.OperatorNamespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	routingKey := strings.TrimSpace(string(secret.Data[routingKeyKey]))
+	if routingKey == "" {
+		return nil, fmt.Errorf("PagerDuty secret %v did not contain key %v", SecretName, routingKeyKey)
+	}
+
+	eventsURL := strings.TrimSpace(string(secret.Data[urlKey]))
+	if eventsURL == "" {
+		eventsURL = DefaultURL
+	}
+
+	return &eventsClient{
+		httpClient: &http.Client{Timeout: sendTimeout},
+		url:        eventsURL,
+		routingKey: routingKey,
+	}, nil
+}
+
+// Trigger opens the incident, or updates it if it is already open.
+func (c *eventsClient) Trigger(ctx context.Context, incident Incident) error {
+	return c.send(ctx, event{
+		RoutingKey:  c.routingKey,
+		EventAction: "trigger",
+		DedupKey:    incident.DedupKey,
+		Payload: &eventPayload{
+			Summary:       incident.Summary,
+			Source:        incident.Source,
+			Severity:      incident.Severity,
+			Component:     config.OperatorName,
+			CustomDetails: incident.Details,
+		},
+	})
+}
+
+// Resolve resolves the incident of dedupKey. Resolving an incident that is not open does nothing.
+func (c *eventsClient) Resolve(ctx context.Context, dedupKey string) error {
+	return c.send(ctx, event{
+		RoutingKey:  c.routingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey,
+	})
+}
+
+// send posts the event to the Events API.
+func (c *eventsClient) send(ctx context.Context, e event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send PagerDuty event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("failed to send PagerDuty event: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
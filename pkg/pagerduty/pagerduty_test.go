@@ -0,0 +1,128 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+func TestTriggerAndResolve(t *testing.T) {
+	var received []event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if e.DedupKey == "" {
+			http.Error(w, `{"status":"invalid event","message":"Event object is invalid"}`, http.StatusBadRequest)
+			return
+		}
+		received = append(received, e)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+		Data:       map[string][]byte{routingKeyKey: []byte("routing\n"), urlKey: []byte(server.URL)},
+	}
+	c, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build())
+	require.NoError(t, err)
+
+	require.NoError(t, c.Trigger(context.TODO(), Incident{
+		DedupKey: "certman/uhc-1/api",
+		Summary:  "summary",
+		Source:   "uhc-1/api",
+		Severity: SeverityError,
+		Details:  map[string]string{"lastFailure": "acme: error"},
+	}))
+	require.NoError(t, c.Resolve(context.TODO(), "certman/uhc-1/api"))
+
+	assert.Equal(t, []event{
+		{
+			RoutingKey:  "routing",
+			EventAction: "trigger",
+			DedupKey:    "certman/uhc-1/api",
+			Payload: &eventPayload{
+				Summary:       "summary",
+				Source:        "uhc-1/api",
+				Severity:      SeverityError,
+				Component:     config.OperatorName,
+				CustomDetails: map[string]string{"lastFailure": "acme: error"},
+			},
+		},
+		{RoutingKey: "routing", EventAction: "resolve", DedupKey: "certman/uhc-1/api"},
+	}, received)
+
+	err = c.Resolve(context.TODO(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request")
+	assert.Contains(t, err.Error(), "Event object is invalid")
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string][]byte
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "default URL",
+			data:        map[string][]byte{routingKeyKey: []byte("routing")},
+			expectedURL: DefaultURL,
+		},
+		{
+			name:        "missing routing key",
+			data:        map[string][]byte{urlKey: []byte("https://events.example.com")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+				Data:       test.data,
+			}
+			c, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build())
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedURL, c.(*eventsClient).url)
+		})
+	}
+
+	_, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).Build())
+	assert.Error(t, err, "a missing secret is an error")
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxy sends the requests of the operator to the ACME servers and the DNS providers through the
+// cluster-wide proxy of OpenShift.
+//
+// The ACME client only ever uses http.DefaultClient, so the proxy is applied by replacing http.DefaultTransport,
+// which the ACME client and the DNS provider clients share. The replacement rebuilds its transport whenever the
+// trusted CAs change, so that changed bundles apply to the following connections.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"golang.org/x/net/http/httpproxy"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/certman-operator/pkg/trust"
+)
+
+const (
+	// clusterProxyName is the name of the cluster-wide Proxy object.
+	clusterProxyName = "cluster"
+
+	// trustedCANamespace and trustedCAKey locate the CA bundle of the config map referenced by the trustedCA of
+	// the Proxy object.
+	trustedCANamespace = "openshift-config"
+	trustedCAKey       = "ca-bundle.crt"
+)
+
+// Config is the proxy the operator sends its requests through.
+type Config struct {
+	HTTPProxy  string
+	HTTPSProxy string
+
+	// NoProxy is the comma-separated list of hosts, domains and CIDRs reached without the proxy.
+	NoProxy string
+
+	// TrustedCA holds the PEM encoded CA certificates trusted in addition to the system roots, typically those
+	// of a proxy inspecting TLS connections.
+	TrustedCA []byte
+}
+
+// Enabled returns true if requests are sent through a proxy.
+func (c Config) Enabled() bool {
+	return c.HTTPProxy != "" || c.HTTPSProxy != ""
+}
+
+// FromEnvironment returns the proxy of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func FromEnvironment() Config {
+	env := httpproxy.FromEnvironment()
+	return Config{HTTPProxy: env.HTTPProxy, HTTPSProxy: env.HTTPSProxy, NoProxy: env.NoProxy}
+}
+
+// Load returns the proxy of the cluster-wide Proxy object, as observed in its status. The environment variables
+// are used instead if the cluster has no Proxy object, is not an OpenShift cluster, or does not set a proxy.
+func Load(ctx context.Context, kubeClient client.Reader) (Config, error) {
+	proxy := &configv1.Proxy{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: clusterProxyName}, proxy)
+	if kerrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return FromEnvironment(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to get the cluster proxy: %w", err)
+	}
+
+	config := Config{
+		HTTPProxy:  proxy.Status.HTTPProxy,
+		HTTPSProxy: proxy.Status.HTTPSProxy,
+		NoProxy:    proxy.Status.NoProxy,
+	}
+	if !config.Enabled() {
+		config = FromEnvironment()
+	}
+
+	// the trusted CA also applies without a proxy, to a transparent proxy inspecting TLS connections
+	if proxy.Spec.TrustedCA.Name != "" {
+		configMap := &corev1.ConfigMap{}
+		err := kubeClient.Get(ctx, types.NamespacedName{Name: proxy.Spec.TrustedCA.Name, Namespace: trustedCANamespace}, configMap)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to get the trusted CA of the cluster proxy: %w", err)
+		}
+		config.TrustedCA = []byte(configMap.Data[trustedCAKey])
+	}
+
+	return config, nil
+}
+
+// ProxyFunc returns the proxy of a request, or nil if the request is sent directly because its host matches NoProxy.
+func (c Config) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	proxyURL := (&httpproxy.Config{HTTPProxy: c.HTTPProxy, HTTPSProxy: c.HTTPSProxy, NoProxy: c.NoProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyURL(req.URL)
+	}
+}
+
+// Transport returns a copy of base sending requests through the proxy and trusting the trusted CAs of the trust
+// package.
+func (c Config) Transport(base *http.Transport) *http.Transport {
+	transport := base.Clone()
+	transport.Proxy = c.ProxyFunc()
+	transport.TLSClientConfig = trust.TLSConfig()
+	return transport
+}
+
+// reloadingTransport sends requests with a transport rebuilt whenever the trusted CAs change, as the roots of a TLS
+// config cannot change once it is in use.
+type reloadingTransport struct {
+	config  Config
+	base    *http.Transport
+	current atomic.Pointer[http.Transport]
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *reloadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current.Load().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *reloadingTransport) CloseIdleConnections() {
+	t.current.Load().CloseIdleConnections()
+}
+
+// reload replaces the current transport with one trusting the current roots. The idle connections of the previous
+// transport are closed, and those still in use are closed by its idle timeout once their requests complete.
+func (t *reloadingTransport) reload() {
+	previous := t.current.Swap(t.config.Transport(t.base))
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+}
+
+// Install trusts the trusted CA of the proxy and replaces http.DefaultTransport with a transport honoring the proxy.
+// It must be called before the ACME and DNS provider clients are created, as some of them copy the default
+// transport.
+func Install(c Config) error {
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return errors.New("the default transport is not an *http.Transport")
+	}
+
+	if err := trust.SetBundle(trust.ProxyBundle, c.TrustedCA); err != nil {
+		return fmt.Errorf("invalid trusted CA of the cluster proxy: %w", err)
+	}
+
+	transport := &reloadingTransport{config: c, base: base}
+	transport.reload()
+	trust.OnChange(transport.reload)
+	http.DefaultTransport = transport
+	return nil
+}
+
+// CloneDefaultTransport returns a copy of the transport http.DefaultTransport currently sends requests with, for the
+// clients setting their own transport options.
+func CloneDefaultTransport() *http.Transport {
+	if transport, ok := http.DefaultTransport.(*reloadingTransport); ok {
+		return transport.current.Load().Clone()
+	}
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
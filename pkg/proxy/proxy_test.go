@@ -0,0 +1,181 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/pkg/trust"
+)
+
+func generateCAPEM(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "proxy-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestLoad(t *testing.T) {
+	caPEM := generateCAPEM(t)
+
+	tests := []struct {
+		name     string
+		objects  []client.Object
+		env      map[string]string
+		expected Config
+	}{
+		{
+			name:     "uses the environment without a Proxy object",
+			env:      map[string]string{"HTTPS_PROXY": "http://env-proxy:3128", "NO_PROXY": ".internal"},
+			expected: Config{HTTPSProxy: "http://env-proxy:3128", NoProxy: ".internal"},
+		},
+		{
+			name: "uses the status of the Proxy object",
+			objects: []client.Object{&configv1.Proxy{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Status: configv1.ProxyStatus{
+					HTTPProxy:  "http://proxy:3128",
+					HTTPSProxy: "http://proxy:3128",
+					NoProxy:    ".cluster.local,route53.amazonaws.com",
+				},
+			}},
+			env: map[string]string{"HTTPS_PROXY": "http://env-proxy:3128"},
+			expected: Config{
+				HTTPProxy:  "http://proxy:3128",
+				HTTPSProxy: "http://proxy:3128",
+				NoProxy:    ".cluster.local,route53.amazonaws.com",
+			},
+		},
+		{
+			name: "reads the trusted CA of the Proxy object",
+			objects: []client.Object{
+				&configv1.Proxy{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Spec:       configv1.ProxySpec{TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"}},
+					Status:     configv1.ProxyStatus{HTTPSProxy: "http://proxy:3128"},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-ca-bundle", Namespace: "openshift-config"},
+					Data:       map[string]string{"ca-bundle.crt": string(caPEM)},
+				},
+			},
+			expected: Config{HTTPSProxy: "http://proxy:3128", TrustedCA: caPEM},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			s := runtime.NewScheme()
+			require.NoError(t, scheme.AddToScheme(s))
+			require.NoError(t, configv1.AddToScheme(s))
+			kubeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()
+
+			config, err := Load(context.TODO(), kubeClient)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	config := Config{HTTPSProxy: "http://proxy:3128", NoProxy: "route53.amazonaws.com"}
+
+	transport := config.Transport(&http.Transport{})
+
+	request, err := http.NewRequest(http.MethodGet, "https://acme-v02.api.letsencrypt.org/directory", nil)
+	require.NoError(t, err)
+	proxyURL, err := transport.Proxy(request)
+	require.NoError(t, err)
+	require.NotNil(t, proxyURL)
+	assert.Equal(t, "proxy:3128", proxyURL.Host)
+
+	// the DNS provider endpoint matching noProxy is reached directly
+	request, err = http.NewRequest(http.MethodGet, "https://route53.amazonaws.com/2013-04-01/hostedzone", nil)
+	require.NoError(t, err)
+	proxyURL, err = transport.Proxy(request)
+	require.NoError(t, err)
+	assert.Nil(t, proxyURL)
+
+	require.NotNil(t, transport.TLSClientConfig)
+	assert.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestReloadingTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	transport := &reloadingTransport{base: &http.Transport{}}
+	transport.reload()
+	trust.OnChange(transport.reload)
+	defer func() {
+		require.NoError(t, trust.SetBundle(trust.OperatorConfigBundle, nil))
+	}()
+	client := &http.Client{Transport: transport}
+
+	get := func() error {
+		response, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	assert.Error(t, get(), "the server certificate is not trusted without the bundle")
+
+	require.NoError(t, trust.SetBundle(trust.OperatorConfigBundle, serverCA))
+	assert.NoError(t, get(), "a changed bundle applies to the following connections")
+
+	require.NoError(t, trust.SetBundle(trust.OperatorConfigBundle, nil))
+	assert.Error(t, get(), "the server certificate is no longer trusted once the bundle is removed")
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const (
+	awsCredsSecretIDKey     = "aws_access_key_id"
+	awsCredsSecretAccessKey = "aws_secret_access_key" //#nosec - G101: Potential hardcoded credentials
+)
+
+// awsSink writes to a secret of AWS Secrets Manager.
+type awsSink struct {
+	client     secretsmanageriface.SecretsManagerAPI
+	secretName string
+}
+
+func newAWSSink(ctx context.Context, kubeClient client.Client, sink *certmanv1alpha1.AWSSecretsManagerSink, namespace string) (*awsSink, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: sink.Credentials.Name, Namespace: namespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKeyID, ok := secret.Data[awsCredsSecretIDKey]
+	if !ok {
+		return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v", sink.Credentials.Name, awsCredsSecretIDKey)
+	}
+
+	secretAccessKey, ok := secret.Data[awsCredsSecretAccessKey]
+	if !ok {
+		return nil, fmt.Errorf("AWS credentials secret %v did not contain key %v", sink.Credentials.Name, awsCredsSecretAccessKey)
+	}
+
+	s, err := session.NewSession(&aws.Config{
+		Region: aws.String(sink.Region),
+		Credentials: credentials.NewStaticCredentials(
+			strings.Trim(string(accessKeyID), "\n"),
+			strings.Trim(string(secretAccessKey), "\n"),
+			"",
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsSink{client: secretsmanager.New(s), secretName: sink.SecretName}, nil
+}
+
+// Push puts the bundle as the current value of the secret, creating the secret if it does not exist, and returns
+// the version ID of the value.
+func (s *awsSink) Push(ctx context.Context, bundle *Bundle) (string, error) {
+	value, err := bundle.json()
+	if err != nil {
+		return "", err
+	}
+
+	put, err := s.client.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(s.secretName),
+		SecretString: aws.String(string(value)),
+	})
+	if err == nil {
+		return aws.StringValue(put.VersionId), nil
+	}
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) || awsErr.Code() != secretsmanager.ErrCodeResourceNotFoundException {
+		return "", fmt.Errorf("failed to put the value of secret %s: %w", s.secretName, err)
+	}
+
+	created, err := s.client.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+		Name:         aws.String(s.secretName),
+		Description:  aws.String("certificate pushed by certman-operator"),
+		SecretString: aws.String(string(value)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create secret %s: %w", s.secretName, err)
+	}
+	return aws.StringValue(created.VersionId), nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSecretsManagerClient holds secrets in memory.
+type mockSecretsManagerClient struct {
+	secretsmanageriface.SecretsManagerAPI
+	values map[string]string
+}
+
+func (m *mockSecretsManagerClient) PutSecretValueWithContext(_ aws.Context, input *secretsmanager.PutSecretValueInput, _ ...request.Option) (*secretsmanager.PutSecretValueOutput, error) {
+	name := aws.StringValue(input.SecretId)
+	if _, ok := m.values[name]; !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "secret not found", nil)
+	}
+	m.values[name] = aws.StringValue(input.SecretString)
+	return &secretsmanager.PutSecretValueOutput{VersionId: aws.String("put")}, nil
+}
+
+func (m *mockSecretsManagerClient) CreateSecretWithContext(_ aws.Context, input *secretsmanager.CreateSecretInput, _ ...request.Option) (*secretsmanager.CreateSecretOutput, error) {
+	m.values[aws.StringValue(input.Name)] = aws.StringValue(input.SecretString)
+	return &secretsmanager.CreateSecretOutput{VersionId: aws.String("created")}, nil
+}
+
+func TestAWSSinkPush(t *testing.T) {
+	client := &mockSecretsManagerClient{values: map[string]string{}}
+	sink := &awsSink{client: client, secretName: "api"}
+
+	version, err := sink.Push(context.TODO(), testBundle)
+	require.NoError(t, err)
+	assert.Equal(t, "created", version)
+
+	version, err = sink.Push(context.TODO(), testBundle)
+	require.NoError(t, err)
+	assert.Equal(t, "put", version)
+
+	var value map[string]string
+	require.NoError(t, json.Unmarshal([]byte(client.values["api"]), &value))
+	assert.Equal(t, map[string]string{"tls.crt": "certificate", "tls.key": "key", "ca.crt": "ca"}, value)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+	htransport "google.golang.org/api/transport/http"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/utils"
+)
+
+// gcpSink writes to a secret of GCP Secret Manager.
+type gcpSink struct {
+	service  *secretmanager.Service
+	project  string
+	secretID string
+}
+
+func newGCPSink(ctx context.Context, kubeClient client.Client, sink *certmanv1alpha1.GCPSecretManagerSink, namespace string) (*gcpSink, error) {
+	credentials, err := utils.GetCredentialsJSON(ctx, kubeClient, types.NamespacedName{Name: sink.Credentials.Name, Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := htransport.NewTransport(ctx, http.DefaultTransport, option.WithCredentials(credentials))
+	if err != nil {
+		return nil, err
+	}
+
+	service, err := secretmanager.NewService(ctx, option.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		return nil, err
+	}
+
+	project := sink.Project
+	if project == "" {
+		project = credentials.ProjectID
+	}
+	return &gcpSink{service: service, project: project, secretID: sink.SecretID}, nil
+}
+
+// Push adds the bundle as a new version of the secret, creating the secret if it does not exist, and returns the
+// resource name of the version.
+func (s *gcpSink) Push(ctx context.Context, bundle *Bundle) (string, error) {
+	value, err := bundle.json()
+	if err != nil {
+		return "", err
+	}
+
+	request := &secretmanager.AddSecretVersionRequest{
+		Payload: &secretmanager.SecretPayload{Data: base64.StdEncoding.EncodeToString(value)},
+	}
+	name := fmt.Sprintf("projects/%s/secrets/%s", s.project, s.secretID)
+
+	version, err := s.service.Projects.Secrets.AddVersion(name, request).Context(ctx).Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		_, err = s.service.Projects.Secrets.Create("projects/"+s.project, &secretmanager.Secret{
+			Labels:      map[string]string{"managed-by": "certman-operator"},
+			Replication: &secretmanager.Replication{Automatic: &secretmanager.Automatic{}},
+		}).SecretId(s.secretID).Context(ctx).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to create secret %s: %w", name, err)
+		}
+		version, err = s.service.Projects.Secrets.AddVersion(name, request).Context(ctx).Do()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to add a version to secret %s: %w", name, err)
+	}
+	return version.Name, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// fakeSecretManager serves the secret and version creation of Secret Manager from memory.
+type fakeSecretManager struct {
+	versions map[string][]string
+}
+
+func (f *fakeSecretManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/projects/test/secrets":
+		f.versions["projects/test/secrets/"+r.URL.Query().Get("secretId")] = []string{}
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/projects/test/secrets/api:addVersion":
+		name := "projects/test/secrets/api"
+		versions, ok := f.versions[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"code": 404, "message": "secret not found"}}`))
+			return
+		}
+		request := &secretmanager.AddSecretVersionRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := base64.StdEncoding.DecodeString(request.Payload.Data)
+		f.versions[name] = append(versions, string(data))
+		_ = json.NewEncoder(w).Encode(&secretmanager.SecretVersion{Name: fmt.Sprintf("%s/versions/%d", name, len(f.versions[name]))})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGCPSinkPush(t *testing.T) {
+	fake := &fakeSecretManager{versions: map[string][]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	service, err := secretmanager.NewService(context.TODO(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	require.NoError(t, err)
+	sink := &gcpSink{service: service, project: "test", secretID: "api"}
+
+	version, err := sink.Push(context.TODO(), testBundle)
+	require.NoError(t, err)
+	assert.Equal(t, "projects/test/secrets/api/versions/1", version)
+
+	version, err = sink.Push(context.TODO(), testBundle)
+	require.NoError(t, err)
+	assert.Equal(t, "projects/test/secrets/api/versions/2", version)
+
+	var value map[string]string
+	require.NoError(t, json.Unmarshal([]byte(fake.versions["projects/test/secrets/api"][1]), &value))
+	assert.Equal(t, map[string]string{"tls.crt": "certificate", "tls.key": "key", "ca.crt": "ca"}, value)
+}
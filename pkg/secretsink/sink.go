@@ -0,0 +1,79 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package secretsink pushes issued certificates to secret stores outside the cluster, so that consumers that cannot
+// read the certificate secret get a copy of it.
+package secretsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+// caCertKey is the key the issuing chain is pushed under, as in the certificate secret.
+const caCertKey = "ca.crt"
+
+// Bundle is the content of a certificate secret pushed to a sink.
+type Bundle struct {
+	// Certificate is the PEM encoded certificate followed by its issuing intermediate.
+	Certificate []byte
+	// PrivateKey is the PEM encoded private key of the certificate.
+	PrivateKey []byte
+	// CA is the PEM encoded issuing chain, without the certificate itself.
+	CA []byte
+}
+
+// Sink writes certificates to a secret of an external secret store.
+type Sink interface {
+	// Push writes bundle as a new version of the secret and returns the identifier of that version in the store.
+	Push(ctx context.Context, bundle *Bundle) (string, error)
+}
+
+// NewSink returns a Sink for the secret store configured by sink, reading its credentials from namespace.
+func NewSink(ctx context.Context, kubeClient client.Client, sink *certmanv1alpha1.SecretSink, namespace string) (Sink, error) {
+	switch {
+	case sink.Vault != nil:
+		return newVaultSink(ctx, kubeClient, sink.Vault, namespace)
+	case sink.AWSSecretsManager != nil:
+		return newAWSSink(ctx, kubeClient, sink.AWSSecretsManager, namespace)
+	case sink.GCPSecretManager != nil:
+		return newGCPSink(ctx, kubeClient, sink.GCPSecretManager, namespace)
+	}
+	return nil, fmt.Errorf("secret sink %s configures no secret store", sink.Name)
+}
+
+// data returns the keys of the bundle as in the certificate secret. The issuing chain is omitted when empty.
+func (b *Bundle) data() map[string]string {
+	data := map[string]string{
+		corev1.TLSCertKey:       string(b.Certificate),
+		corev1.TLSPrivateKeyKey: string(b.PrivateKey),
+	}
+	if len(b.CA) > 0 {
+		data[caCertKey] = string(b.CA)
+	}
+	return data
+}
+
+// json returns the keys of the bundle as a JSON object, for the stores holding a single value per secret.
+func (b *Bundle) json() ([]byte, error) {
+	return json.Marshal(b.data())
+}
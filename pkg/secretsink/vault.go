@@ -0,0 +1,143 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/pkg/proxy"
+)
+
+const (
+	defaultVaultMountPath = "secret"
+	vaultRequestTimeout   = 30 * time.Second
+)
+
+// vaultSink writes to a secret of a HashiCorp Vault KV version 2 secrets engine.
+type vaultSink struct {
+	url       string
+	namespace string
+	token     string
+	client    *http.Client
+}
+
+type vaultWriteRequest struct {
+	Data map[string]string `json:"data"`
+}
+
+type vaultWriteResponse struct {
+	Data struct {
+		Version int64 `json:"version"`
+	} `json:"data"`
+}
+
+type vaultErrorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+func newVaultSink(ctx context.Context, kubeClient client.Client, vault *certmanv1alpha1.VaultSecretSink, namespace string) (*vaultSink, error) {
+	ref := vault.TokenSecretRef
+	secret := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(string(secret.Data[ref.Key]))
+	if token == "" {
+		return nil, fmt.Errorf("vault token secret %s has no key %s", ref.Name, ref.Key)
+	}
+
+	transport := proxy.CloneDefaultTransport()
+	if len(vault.CABundle) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system roots: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(vault.CABundle) {
+			return nil, errors.New("no valid certificates in the vault CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	mountPath := vault.MountPath
+	if mountPath == "" {
+		mountPath = defaultVaultMountPath
+	}
+	return &vaultSink{
+		url:       fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(vault.Server, "/"), strings.Trim(mountPath, "/"), strings.Trim(vault.Path, "/")),
+		namespace: vault.Namespace,
+		token:     token,
+		client:    &http.Client{Transport: transport, Timeout: vaultRequestTimeout},
+	}, nil
+}
+
+// Push writes the bundle as a new version of the secret and returns its version number.
+func (s *vaultSink) Push(ctx context.Context, bundle *Bundle) (string, error) {
+	body, err := json.Marshal(&vaultWriteRequest{Data: bundle.data()})
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		request.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		vaultErr := &vaultErrorResponse{}
+		if json.Unmarshal(data, vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return "", fmt.Errorf("vault refused to write %s: %s", s.url, strings.Join(vaultErr.Errors, "; "))
+		}
+		return "", fmt.Errorf("vault refused to write %s: %s", s.url, response.Status)
+	}
+
+	written := &vaultWriteResponse{}
+	if err := json.Unmarshal(data, written); err != nil {
+		return "", fmt.Errorf("failed to decode the response of vault to writing %s: %w", s.url, err)
+	}
+	return strconv.FormatInt(written.Data.Version, 10), nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretsink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+)
+
+const testNamespace = "uhc-doesntexist-123456"
+
+var testBundle = &Bundle{Certificate: []byte("certificate"), PrivateKey: []byte("key"), CA: []byte("ca")}
+
+func TestVaultSinkPush(t *testing.T) {
+	var written vaultWriteRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/kv/data/certs/api", r.URL.Path)
+		assert.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&written)) {
+			return
+		}
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"version": 3}}`))
+	}))
+	defer server.Close()
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: testNamespace},
+		Data:       map[string][]byte{"token": []byte("s.token\n")},
+	}).Build()
+
+	sink, err := NewSink(context.TODO(), kubeClient, &certmanv1alpha1.SecretSink{Name: "vault", Vault: &certmanv1alpha1.VaultSecretSink{
+		Server:         server.URL + "/",
+		Namespace:      "team",
+		MountPath:      "/kv/",
+		Path:           "certs/api",
+		TokenSecretRef: certmanv1alpha1.SecretKeyReference{Name: "vault", Key: "token"},
+	}}, testNamespace)
+	require.NoError(t, err)
+
+	version, err := sink.Push(context.TODO(), testBundle)
+	require.NoError(t, err)
+	assert.Equal(t, "3", version)
+	assert.Equal(t, map[string]string{"tls.crt": "certificate", "tls.key": "key", "ca.crt": "ca"}, written.Data)
+
+	sink.(*vaultSink).url += "?fail=true"
+	_, err = sink.Push(context.TODO(), testBundle)
+	assert.ErrorContains(t, err, "permission denied")
+}
+
+func TestNewVaultSinkMissingToken(t *testing.T) {
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault", Namespace: testNamespace},
+	}).Build()
+
+	_, err := NewSink(context.TODO(), kubeClient, &certmanv1alpha1.SecretSink{Name: "vault", Vault: &certmanv1alpha1.VaultSecretSink{
+		Server:         "https://vault.example.com",
+		Path:           "certs/api",
+		TokenSecretRef: certmanv1alpha1.SecretKeyReference{Name: "vault", Key: "token"},
+	}}, testNamespace)
+	assert.ErrorContains(t, err, "has no key token")
+}
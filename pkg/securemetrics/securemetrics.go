@@ -0,0 +1,60 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package securemetrics serves the operator's metrics over TLS to the clients the API server authorizes, in place of
+// the plain HTTP endpoint of operator-custom-metrics.
+package securemetrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/openshift/certman-operator/pkg/servingcert"
+)
+
+const (
+	// Port is the port the metrics are served on over TLS.
+	Port = 8443
+
+	// DefaultCertDir is the directory the serving certificate secret is mounted in.
+	DefaultCertDir = "/etc/certman-operator/metrics-tls"
+)
+
+// ServerOptions returns the options of the controller-runtime metrics server serving the metrics on Port over TLS,
+// with the certificate and key of certDir. Each request must carry a bearer token that the API server
+// authenticates and authorizes to get the /metrics non-resource URL.
+func ServerOptions(certDir string) metricsserver.Options {
+	return metricsserver.Options{
+		BindAddress:    fmt.Sprintf(":%d", Port),
+		SecureServing:  true,
+		FilterProvider: filters.WithAuthenticationAndAuthorization,
+		TLSOpts:        servingcert.TLSOpts(certDir),
+	}
+}
+
+// Register registers the collectors with the registry of the controller-runtime metrics server.
+func Register(collectors []prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := ctrlmetrics.Registry.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package securemetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerOptions(t *testing.T) {
+	options := ServerOptions(DefaultCertDir)
+	assert.Equal(t, ":8443", options.BindAddress)
+	assert.True(t, options.SecureServing)
+	assert.NotNil(t, options.FilterProvider)
+	assert.Len(t, options.TLSOpts, 1)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package servicelog posts service logs to OpenShift Cluster Manager, which shows them to the owners of a cluster
+// and notifies them.
+package servicelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/clientcredentials"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+const (
+	// SecretName is the name of the secret in the operator namespace holding the credentials of the service account
+	// posting the service logs.
+	SecretName = "certman-operator-ocm"
+
+	clientIDKey     = "client_id"
+	clientSecretKey = "client_secret" //#nosec - G101: Potential hardcoded credentials
+	urlKey          = "url"
+	tokenURLKey     = "token_url"
+
+	// DefaultURL is the URL of the OpenShift Cluster Manager API used when the secret does not set one.
+	DefaultURL = "https://api.openshift.com"
+
+	// DefaultTokenURL is the URL of the token endpoint used when the secret does not set one.
+	DefaultTokenURL = "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token" //#nosec - G101: Potential hardcoded credentials
+
+	clusterLogsPath = "/api/service_logs/v1/cluster_logs"
+
+	// maxErrorBodySize bounds the part of the body of a failed response quoted in the error.
+	maxErrorBodySize = 512
+)
+
+// Severities of a service log
+const (
+	SeverityInfo    = "Info"
+	SeverityWarning = "Warning"
+	SeverityMajor   = "Major"
+)
+
+// Log is a service log of a cluster.
+type Log struct {
+	// ClusterUUID is the external ID of the cluster.
+	ClusterUUID string `json:"cluster_uuid"`
+	ServiceName string `json:"service_name"`
+	Severity    string `json:"severity"`
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+	// InternalOnly hides the service log from the owners of the cluster.
+	InternalOnly bool `json:"internal_only"`
+}
+
+// Client posts service logs.
+type Client interface {
+	Post(ctx context.Context, log Log) error
+}
+
+// ocmClient implements the Client interface for the service logs API of OpenShift Cluster Manager.
+type ocmClient struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewClient returns a Client authenticated with the service account credentials of the SecretName secret.
+func NewClient(ctx context.Context, kubeClient client.Client) (Client, error) {
+	secret := &corev1.Secret{}
+	err := kubeClient.Get(ctx, types.NamespacedName{Name: SecretName, Namespace: config.OperatorNamespace}, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID, ok := secret.Data[clientIDKey]
+	if !ok {
+		return nil, fmt.Errorf("OCM credentials secret %v did not contain key %v", SecretName, clientIDKey)
+	}
+	clientSecret, ok := secret.Data[clientSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("OCM credentials secret %v did not contain key %v", SecretName, clientSecretKey)
+	}
+
+	credentials := clientcredentials.Config{
+		ClientID:     strings.TrimSpace(string(clientID)),
+		ClientSecret: strings.TrimSpace(string(clientSecret)),
+		TokenURL:     secretValue(secret, tokenURLKey, DefaultTokenURL),
+		Scopes:       []string{"openid"},
+	}
+
+	return &ocmClient{
+		// the context only bounds the token requests, which are made by the requests of Post
+		httpClient: credentials.Client(context.Background()),
+		url:        strings.TrimSuffix(secretValue(secret, urlKey, DefaultURL), "/"),
+	}, nil
+}
+
+// Post posts the service log.
+func (c *ocmClient) Post(ctx context.Context, log Log) error {
+	body, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+clusterLogsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post service log: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("failed to post service log: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// secretValue returns the trimmed value of key in the secret, or defaultValue if it is missing or empty.
+func secretValue(secret *corev1.Secret, key, defaultValue string) string {
+	if value := strings.TrimSpace(string(secret.Data[key])); value != "" {
+		return value
+	}
+	return defaultValue
+}
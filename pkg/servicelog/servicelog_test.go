@@ -0,0 +1,119 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicelog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift/certman-operator/config"
+)
+
+func TestPost(t *testing.T) {
+	var posted Log
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":900}`))
+	})
+	mux.HandleFunc(clusterLogsPath, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if err := json.NewDecoder(r.Body).Decode(&posted); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if posted.ClusterUUID == "" {
+			http.Error(w, `{"reason":"cluster_uuid is required"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+		Data: map[string][]byte{
+			clientIDKey:     []byte("certman\n"),
+			clientSecretKey: []byte("secret\n"),
+			urlKey:          []byte(server.URL + "/"),
+			tokenURLKey:     []byte(server.URL + "/token"),
+		},
+	}
+	c, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build())
+	require.NoError(t, err)
+
+	log := Log{ClusterUUID: "uuid", ServiceName: "certman-operator", Severity: SeverityWarning, Summary: "summary", Description: "description"}
+	require.NoError(t, c.Post(context.TODO(), log))
+	assert.Equal(t, log, posted)
+
+	err = c.Post(context.TODO(), Log{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request")
+	assert.Contains(t, err.Error(), "cluster_uuid is required")
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string][]byte
+		expectedURL string
+		expectError bool
+	}{
+		{
+			name:        "default URL",
+			data:        map[string][]byte{clientIDKey: []byte("id"), clientSecretKey: []byte("secret")},
+			expectedURL: DefaultURL,
+		},
+		{
+			name:        "missing client secret",
+			data:        map[string][]byte{clientIDKey: []byte("id")},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: SecretName, Namespace: config.OperatorNamespace},
+				Data:       test.data,
+			}
+			c, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build())
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedURL, c.(*ocmClient).url)
+		})
+	}
+
+	_, err := NewClient(context.TODO(), fake.NewClientBuilder().WithScheme(scheme.Scheme).Build())
+	assert.Error(t, err, "a missing secret is an error")
+}
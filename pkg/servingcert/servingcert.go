@@ -0,0 +1,54 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package servingcert loads the serving certificates the OpenShift service CA issues for the operator's Services.
+package servingcert
+
+import (
+	"crypto/tls"
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	certName = "tls.crt"
+	keyName  = "tls.key"
+)
+
+// Loader returns a GetCertificate function that reads the tls.crt and tls.key of certDir on each handshake. The
+// servers start before the serving certificate secret is issued and mounted, and the secret is rotated in place, so
+// the files are not read once at start-up.
+func Loader(certDir string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(filepath.Join(certDir, certName), filepath.Join(certDir, keyName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the serving certificate of %s: %w", certDir, err)
+		}
+		return &cert, nil
+	}
+}
+
+// TLSOpts returns the options of the TLS config of a server presenting the serving certificate of certDir.
+func TLSOpts(certDir string) []func(*tls.Config) {
+	return []func(*tls.Config){
+		func(c *tls.Config) {
+			c.MinVersion = tls.VersionTLS12
+			// HTTP/2 is disabled against the HTTP/2 Rapid Reset and Stream Cancellation vulnerabilities.
+			c.NextProtos = []string{"http/1.1"}
+			c.GetCertificate = Loader(certDir)
+		},
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package throttle caps the rate of new ACME orders per account across the whole fleet, so that mass renewals
+// stay within the weekly limits of the certificate authority instead of failing part way through.
+//
+// The token buckets are held in memory: they start full when the operator starts and are not shared between
+// replicas, which is fine as the operator runs with leader election.
+package throttle
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// HourWindow and WeekWindow name the windows over which the limits are counted.
+	HourWindow = "hour"
+	WeekWindow = "week"
+
+	week = 7 * 24 * time.Hour
+)
+
+// Limits are the maximum numbers of new orders per account. A limit of 0 is unlimited.
+type Limits struct {
+	PerHour int
+	PerWeek int
+}
+
+// Throttle holds an hourly and a weekly token bucket per ACME account. It is safe for concurrent use.
+type Throttle struct {
+	mu       sync.Mutex
+	accounts map[string]*buckets
+	now      func() time.Time
+}
+
+// buckets are the token buckets of one account and the limits they were created for.
+type buckets struct {
+	limits Limits
+	hour   *rate.Limiter
+	week   *rate.Limiter
+}
+
+// New returns a Throttle with no accounts.
+func New() *Throttle {
+	return &Throttle{accounts: map[string]*buckets{}, now: time.Now}
+}
+
+// Take takes a token for a new order of account from both buckets. If either bucket is empty no token is taken,
+// and Take returns false and the time until both buckets hold a token.
+func (t *Throttle) Take(account string, limits Limits) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.buckets(account, limits)
+	now := t.now()
+
+	var reservations []*rate.Reservation
+	var wait time.Duration
+	for _, limiter := range []*rate.Limiter{b.hour, b.week} {
+		if limiter == nil {
+			continue
+		}
+		reservation := limiter.ReserveN(now, 1)
+		reservations = append(reservations, reservation)
+		if delay := reservation.DelayFrom(now); delay > wait {
+			wait = delay
+		}
+	}
+
+	if wait > 0 {
+		for _, reservation := range reservations {
+			reservation.CancelAt(now)
+		}
+		return false, wait
+	}
+	return true, 0
+}
+
+// Remaining returns the tokens left in the buckets of account, keyed by window. Unlimited windows are omitted.
+func (t *Throttle) Remaining(account string) map[string]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remaining := map[string]float64{}
+	b, ok := t.accounts[account]
+	if !ok {
+		return remaining
+	}
+
+	now := t.now()
+	if b.hour != nil {
+		remaining[HourWindow] = b.hour.TokensAt(now)
+	}
+	if b.week != nil {
+		remaining[WeekWindow] = b.week.TokensAt(now)
+	}
+	return remaining
+}
+
+// buckets returns the buckets of account, creating them full if the account is new or its limits changed.
+func (t *Throttle) buckets(account string, limits Limits) *buckets {
+	b, ok := t.accounts[account]
+	if ok && b.limits == limits {
+		return b
+	}
+
+	b = &buckets{
+		limits: limits,
+		hour:   newLimiter(limits.PerHour, time.Hour),
+		week:   newLimiter(limits.PerWeek, week),
+	}
+	t.accounts[account] = b
+	return b
+}
+
+// newLimiter returns a bucket holding limit tokens that refills at limit tokens per window, or nil if limit is 0.
+func newLimiter(limit int, window time.Duration) *rate.Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Every(window/time.Duration(limit)), limit)
+}
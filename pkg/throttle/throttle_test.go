@@ -0,0 +1,95 @@
+// Copyright 2019 RedHat
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThrottle(now *time.Time) *Throttle {
+	t := New()
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestTakeUnlimited(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	for i := 0; i < 100; i++ {
+		ok, _ := throttle.Take("default", Limits{})
+		assert.True(t, ok)
+	}
+	assert.Empty(t, throttle.Remaining("default"))
+}
+
+func TestTakeHourly(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+	limits := Limits{PerHour: 2}
+
+	for i := 0; i < 2; i++ {
+		ok, _ := throttle.Take("default", limits)
+		assert.True(t, ok)
+	}
+
+	ok, wait := throttle.Take("default", limits)
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Minute, wait)
+
+	// another account has its own buckets
+	ok, _ = throttle.Take("other", limits)
+	assert.True(t, ok)
+
+	now = now.Add(wait)
+	ok, _ = throttle.Take("default", limits)
+	assert.True(t, ok)
+}
+
+func TestTakeWeeklyDoesNotConsumeHourly(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+	limits := Limits{PerHour: 10, PerWeek: 1}
+
+	ok, _ := throttle.Take("default", limits)
+	assert.True(t, ok)
+
+	ok, wait := throttle.Take("default", limits)
+	assert.False(t, ok)
+	assert.Equal(t, week, wait)
+
+	// the refused order did not take a token from the hourly bucket
+	remaining := throttle.Remaining("default")
+	assert.InDelta(t, 9, remaining[HourWindow], 0.001)
+	assert.InDelta(t, 0, remaining[WeekWindow], 0.001)
+}
+
+func TestTakeLimitsChanged(t *testing.T) {
+	now := time.Now()
+	throttle := newTestThrottle(&now)
+
+	ok, _ := throttle.Take("default", Limits{PerHour: 1})
+	assert.True(t, ok)
+	ok, _ = throttle.Take("default", Limits{PerHour: 1})
+	assert.False(t, ok)
+
+	// new limits start with full buckets
+	ok, _ = throttle.Take("default", Limits{PerHour: 5})
+	assert.True(t, ok)
+	assert.InDelta(t, 4, throttle.Remaining("default")[HourWindow], 0.001)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trust holds the CA certificates trusted, in addition to the system roots, by the connections to the ACME
+// servers and the DNS providers, such as the roots of a TLS-intercepting proxy or of an internal ACME server.
+//
+// The bundles can change while the operator runs. As the roots of a TLS config cannot change once it is in use, the
+// users of the roots register with OnChange to rebuild their transports when the bundles change.
+package trust
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// ProxyBundle is the source of the trusted CA of the cluster-wide proxy.
+	ProxyBundle = "proxy"
+
+	// OperatorConfigBundle is the source of the CA bundle of the operator config.
+	OperatorConfigBundle = "operator-config"
+)
+
+var (
+	// mu serializes the changes of bundles and roots.
+	mu      sync.Mutex
+	bundles = map[string][]byte{}
+
+	// roots holds the system roots and the certificates of every bundle, or nil while there are no bundles.
+	roots atomic.Pointer[x509.CertPool]
+
+	// listeners are called, under mu, whenever roots changes.
+	listeners []func()
+)
+
+// SetBundle replaces the PEM encoded CA certificates of source. An empty bundle removes the certificates of source.
+func SetBundle(source string, bundle []byte) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(bundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(bundle) {
+		return errors.New("the CA bundle holds no PEM encoded certificate")
+	}
+	if bytes.Equal(bundles[source], bundle) {
+		return nil
+	}
+
+	if len(bundle) == 0 {
+		delete(bundles, source)
+	} else {
+		bundles[source] = bundle
+	}
+
+	if len(bundles) == 0 {
+		roots.Store(nil)
+		notify()
+		return nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	sources := make([]string, 0, len(bundles))
+	for s := range bundles {
+		sources = append(sources, s)
+	}
+	sort.Strings(sources)
+	for _, s := range sources {
+		pool.AppendCertsFromPEM(bundles[s])
+	}
+	roots.Store(pool)
+	notify()
+
+	return nil
+}
+
+// notify calls the listeners of OnChange. mu must be held.
+func notify() {
+	for _, listener := range listeners {
+		listener()
+	}
+}
+
+// OnChange registers listener to be called whenever Roots changes. Calls are serialized, and listener must not call
+// SetBundle.
+func OnChange(listener func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// Roots returns the system roots and the certificates of every bundle, or nil, meaning the system roots, if there
+// are no bundles.
+func Roots() *x509.CertPool {
+	return roots.Load()
+}
+
+// TLSConfig returns a TLS client config verifying the certificate of the server against the current Roots. The
+// config does not follow later changes of the bundles, see OnChange.
+func TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    Roots(),
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trust
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	get := func() error {
+		// a new transport for each request, as the roots only apply to new connections
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: TLSConfig()}}
+		response, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	assert.Error(t, get(), "the server certificate is not trusted without the bundle")
+
+	require.NoError(t, SetBundle(OperatorConfigBundle, serverCA))
+	assert.NoError(t, get())
+	assert.NotNil(t, Roots())
+
+	require.NoError(t, SetBundle(OperatorConfigBundle, nil))
+	assert.Error(t, get(), "the server certificate is no longer trusted once the bundle is removed")
+	assert.Nil(t, Roots())
+
+	assert.Error(t, SetBundle(OperatorConfigBundle, []byte("not a certificate")))
+}
+
+func TestOnChange(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	changes := 0
+	OnChange(func() { changes++ })
+	defer func() {
+		mu.Lock()
+		listeners = nil
+		mu.Unlock()
+	}()
+
+	require.NoError(t, SetBundle(OperatorConfigBundle, serverCA))
+	assert.Equal(t, 1, changes)
+
+	// an unchanged bundle leaves the roots as they are
+	require.NoError(t, SetBundle(OperatorConfigBundle, serverCA))
+	assert.Equal(t, 1, changes)
+
+	require.NoError(t, SetBundle(OperatorConfigBundle, nil))
+	assert.Equal(t, 2, changes)
+}
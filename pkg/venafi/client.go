@@ -0,0 +1,319 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package venafi requests certificates from a zone of Venafi Trust Protection Platform or Venafi as a Service. Like
+// the Google CAS client, its client implements the ACME client interface of the operator without authorizations or
+// challenges: Venafi enforces the policy of the zone on the certificate signing request and brokers it to the
+// certificate authority of the zone.
+package venafi
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/pkg/leclient"
+	"github.com/openshift/certman-operator/pkg/proxy"
+)
+
+const (
+	// tppAccessTokenKey is the key of the credentials secret of Trust Protection Platform holding the access token.
+	tppAccessTokenKey = "access-token"
+	// cloudAPIKeyKey is the key of the credentials secret of Venafi as a Service holding the API key.
+	cloudAPIKeyKey = "api-key"
+
+	requestTimeout = 30 * time.Second
+)
+
+// retrieveInterval is the interval at which the certificate is retrieved until Venafi has issued it.
+var retrieveInterval = 2 * time.Second
+
+// connector requests certificates from one of the Venafi platforms.
+type connector interface {
+	// name returns the URL of the platform.
+	name() string
+	// request requests a certificate for csr in zone, returning its ID and the URL it is recorded as.
+	request(ctx context.Context, zone string, csr *x509.CertificateRequest) (id string, url string, err error)
+	// retrieve returns the PEM certificate chain of the certificate with id, leaf first, or nil while it is pending.
+	retrieve(ctx context.Context, id string) ([]byte, error)
+	// revoke revokes certificate with the RFC 5280 reason code.
+	revoke(ctx context.Context, certificate *x509.Certificate, reason int) error
+}
+
+var _ leclient.PrivateCAClient = &Client{}
+
+// Client requests certificates from a Venafi zone.
+type Client struct {
+	leclient.NoChallenges
+
+	connector connector
+	zone      string
+
+	orderURL string
+	certs    []*x509.Certificate
+	ctx      context.Context
+}
+
+// NewClient returns a Client for the zone configured on issuer, authenticated with the credentials in its secret.
+// Venafi as a Service requests certificates for lifetime, unless it is zero; Trust Protection Platform always applies
+// the validity of the zone.
+func NewClient(ctx context.Context, kubeClient client.Client, issuer *certmanv1alpha1.CertIssuer, lifetime time.Duration) (*Client, error) {
+	venafi := issuer.Spec.Venafi
+	if venafi == nil {
+		return nil, fmt.Errorf("certissuer %s is not a venafi issuer", issuer.Name)
+	}
+
+	var c connector
+	switch {
+	case venafi.TPP != nil:
+		token, err := readCredentials(ctx, kubeClient, venafi.TPP.CredentialsSecretRef, tppAccessTokenKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the credentials of certissuer %s: %w", issuer.Name, err)
+		}
+		httpClient, err := newHTTPClient(venafi.TPP.ServerCABundle)
+		if err != nil {
+			return nil, fmt.Errorf("certissuer %s: %w", issuer.Name, err)
+		}
+		c = &tppConnector{url: strings.TrimSuffix(venafi.TPP.URL, "/"), token: token, client: httpClient}
+	case venafi.Cloud != nil:
+		apiKey, err := readCredentials(ctx, kubeClient, venafi.Cloud.CredentialsSecretRef, cloudAPIKeyKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the credentials of certissuer %s: %w", issuer.Name, err)
+		}
+		url := venafi.Cloud.URL
+		if url == "" {
+			url = defaultCloudURL
+		}
+		httpClient, err := newHTTPClient(nil)
+		if err != nil {
+			return nil, err
+		}
+		c = &cloudConnector{url: strings.TrimSuffix(url, "/"), apiKey: apiKey, client: httpClient, lifetime: lifetime}
+	default:
+		return nil, fmt.Errorf("certissuer %s configures neither venafi tpp nor cloud", issuer.Name)
+	}
+
+	return newClient(c, venafi.Zone), nil
+}
+
+// newClient returns a Client requesting certificates from c in zone.
+func newClient(c connector, zone string) *Client {
+	return &Client{connector: c, zone: zone, ctx: context.Background()}
+}
+
+// PrivateCA returns the URL of the Venafi platform.
+func (c *Client) PrivateCA() string {
+	return c.connector.name()
+}
+
+// SetTraceContext sets the context of the following requests, which also bounds the wait for the certificate.
+func (c *Client) SetTraceContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// CreateOrder forgets the certificate of the previous order. The DNS names are taken from the certificate signing
+// request passed to FinalizeOrder.
+func (c *Client) CreateOrder([]string) error {
+	c.orderURL = ""
+	c.certs = nil
+	return nil
+}
+
+// GetOrderURL returns the URL of the certificate request, or of the platform until it is made.
+func (c *Client) GetOrderURL() string {
+	if c.orderURL != "" {
+		return c.orderURL
+	}
+	return c.connector.name()
+}
+
+// GetOrderEndpoint returns the same as GetOrderURL.
+func (c *Client) GetOrderEndpoint() string {
+	return c.GetOrderURL()
+}
+
+// FinalizeOrder requests a certificate for csr in the zone and waits until it is issued.
+func (c *Client) FinalizeOrder(csr *x509.CertificateRequest) error {
+	id, url, err := c.connector.request(c.ctx, c.zone, csr)
+	if err != nil {
+		return fmt.Errorf("failed to request certificate in zone %s: %w", c.zone, err)
+	}
+	c.orderURL = url
+
+	for {
+		data, err := c.connector.retrieve(c.ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve certificate %s: %w", url, err)
+		}
+		if data != nil {
+			c.certs, err = parseCertificates(data)
+			return err
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return fmt.Errorf("certificate %s was not issued in time: %w", url, c.ctx.Err())
+		case <-time.After(retrieveInterval):
+		}
+	}
+}
+
+// FetchCertificates returns the certificate issued by FinalizeOrder followed by its chain.
+func (c *Client) FetchCertificates() ([]*x509.Certificate, error) {
+	if len(c.certs) == 0 {
+		return nil, errors.New("no certificate was issued")
+	}
+	return c.certs, nil
+}
+
+// RevokeCertificate revokes certificate with an unspecified reason.
+func (c *Client) RevokeCertificate(certificate *x509.Certificate) error {
+	return c.RevokeCertificateWithReason(certificate, 0)
+}
+
+// RevokeCertificateWithReason revokes certificate with the RFC 5280 reason code.
+func (c *Client) RevokeCertificateWithReason(certificate *x509.Certificate, reason int) error {
+	return c.connector.revoke(c.ctx, certificate, reason)
+}
+
+// parseCertificates parses the certificates of a PEM bundle.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("venafi returned no certificates")
+	}
+	return certs, nil
+}
+
+// readCredentials returns the value of key in the secret referenced by ref, in the operator namespace if ref has none.
+func readCredentials(ctx context.Context, kubeClient client.Client, ref corev1.SecretReference, key string) (string, error) {
+	secretName := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if secretName.Namespace == "" {
+		secretName.Namespace = config.OperatorNamespace
+	}
+
+	secret := &corev1.Secret{}
+	if err := kubeClient.Get(ctx, secretName, secret); err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(secret.Data[key]))
+	if value == "" {
+		return "", fmt.Errorf("secret %s has no %s", secretName, key)
+	}
+	return value, nil
+}
+
+// newHTTPClient returns an HTTP client trusting caBundle in addition to the system roots.
+func newHTTPClient(caBundle []byte) (*http.Client, error) {
+	transport := proxy.CloneDefaultTransport()
+	if len(caBundle) > 0 {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load system roots: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("no valid certificates in the server CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport, Timeout: requestTimeout}, nil
+}
+
+// doRequest sends a request with the JSON encoded body, if any, and the headers, and returns the status code and body
+// of the response. Responses with an error status are returned as errors.
+func doRequest(ctx context.Context, httpClient *http.Client, method, url string, headers map[string]string, body interface{}) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return response.StatusCode, data, fmt.Errorf("%s %s returned %s: %s", method, url, response.Status, strings.TrimSpace(string(data)))
+	}
+	return response.StatusCode, data, nil
+}
+
+// doJSON is doRequest decoding the response into out.
+func doJSON(ctx context.Context, httpClient *http.Client, method, url string, headers map[string]string, body, out interface{}) (int, error) {
+	status, data, err := doRequest(ctx, httpClient, method, url, headers, body)
+	if err != nil || out == nil || len(data) == 0 {
+		return status, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return status, fmt.Errorf("failed to decode the response of %s %s: %w", method, url, err)
+	}
+	return status, nil
+}
+
+// encodeCSR returns csr PEM encoded.
+func encodeCSR(csr *x509.CertificateRequest) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw}))
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/config"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+// testCA signs certificate signing requests like the certificate authority of a zone.
+type testCA struct {
+	t    *testing.T
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{t: t, cert: cert, key: key}
+}
+
+// sign returns the PEM chain of the certificate signed for the PEM certificate signing request, leaf first.
+func (ca *testCA) sign(pemCSR string) []byte {
+	block, _ := pem.Decode([]byte(pemCSR))
+	require.NotNil(ca.t, block)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(ca.t, err)
+
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, ca.cert, csr.PublicKey, ca.key)
+	require.NoError(ca.t, err)
+	return append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})...)
+}
+
+func newTestCSR(t *testing.T) *x509.CertificateRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "www.example.com"},
+		DNSNames: []string{"www.example.com"},
+	}, key)
+	require.NoError(t, err)
+	csr, err := x509.ParseCertificateRequest(der)
+	require.NoError(t, err)
+	return csr
+}
+
+func decode(t *testing.T, r *http.Request, v interface{}) {
+	require.NoError(t, json.NewDecoder(r.Body).Decode(v))
+}
+
+func encode(t *testing.T, w http.ResponseWriter, v interface{}) {
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func TestTPP(t *testing.T) {
+	retrieveInterval = time.Millisecond
+	ca := newTestCA(t)
+
+	var request tppRequest
+	var revoke tppRevokeRequest
+	var chain []byte
+	retrieved := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/vedsdk/certificates/request", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		decode(t, r, &request)
+		chain = ca.sign(request.PKCS10)
+		encode(t, w, &tppRequestResponse{CertificateDN: `\VED\Policy\Certificates\www.example.com`})
+	})
+	mux.HandleFunc("/vedsdk/certificates/retrieve", func(w http.ResponseWriter, r *http.Request) {
+		retrieve := &tppRetrieveRequest{}
+		decode(t, r, retrieve)
+		assert.Equal(t, `\VED\Policy\Certificates\www.example.com`, retrieve.CertificateDN)
+		retrieved++
+		if retrieved == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			encode(t, w, map[string]string{"Status": "Pending"})
+			return
+		}
+		encode(t, w, &tppRetrieveResponse{CertificateData: base64.StdEncoding.EncodeToString(chain)})
+	})
+	mux.HandleFunc("/vedsdk/certificates/revoke", func(w http.ResponseWriter, r *http.Request) {
+		decode(t, r, &revoke)
+		encode(t, w, &tppRevokeResponse{Success: true})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newClient(&tppConnector{url: server.URL + "/vedsdk", token: "token", client: server.Client()}, "Certificates")
+	assert.True(t, leclient.IsPrivateCAClient(c))
+	require.NoError(t, c.CreateOrder([]string{"www.example.com"}))
+	require.NoError(t, c.FinalizeOrder(newTestCSR(t)))
+
+	assert.Equal(t, `\VED\Policy\Certificates`, request.PolicyDN)
+	assert.Equal(t, "www.example.com", request.ObjectName)
+	assert.True(t, request.DisableAutomaticRenewal)
+	assert.Equal(t, 2, retrieved)
+	assert.Equal(t, `\VED\Policy\Certificates\www.example.com`, c.GetOrderURL())
+
+	certs, err := c.FetchCertificates()
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	assert.Equal(t, []string{"www.example.com"}, certs[0].DNSNames)
+	assert.Equal(t, ca.cert, certs[1])
+
+	assert.ErrorContains(t, c.RevokeCertificateWithReason(certs[0], 9), "unsupported revocation reason code 9")
+	require.NoError(t, c.RevokeCertificateWithReason(certs[0], 4))
+	assert.Len(t, revoke.Thumbprint, 40)
+	assert.Equal(t, 4, revoke.Reason)
+}
+
+func TestCloud(t *testing.T) {
+	retrieveInterval = time.Millisecond
+	ca := newTestCA(t)
+
+	var request cloudRequest
+	var chain []byte
+	mux := http.NewServeMux()
+	mux.HandleFunc("/outagedetection/v1/applications/name/My%20App", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("tppl-api-key"))
+		encode(t, w, &cloudID{ID: "app-id"})
+	})
+	mux.HandleFunc("/outagedetection/v1/applications/app-id/certificateissuingtemplates/Default", func(w http.ResponseWriter, r *http.Request) {
+		encode(t, w, &cloudID{ID: "template-id"})
+	})
+	mux.HandleFunc("/outagedetection/v1/certificaterequests", func(w http.ResponseWriter, r *http.Request) {
+		decode(t, r, &request)
+		chain = ca.sign(request.CertificateSigningRequest)
+		encode(t, w, &cloudRequestResponse{CertificateRequests: []cloudCertificateRequest{{ID: "request-id", Status: "REQUESTED"}}})
+	})
+	mux.HandleFunc("/outagedetection/v1/certificaterequests/request-id", func(w http.ResponseWriter, r *http.Request) {
+		encode(t, w, &cloudCertificateRequest{ID: "request-id", Status: "ISSUED", CertificateIDs: []string{"certificate-id"}})
+	})
+	mux.HandleFunc("/outagedetection/v1/certificates/certificate-id/contents", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PEM", r.URL.Query().Get("format"))
+		assert.Equal(t, "EE_FIRST", r.URL.Query().Get("chainOrder"))
+		_, _ = w.Write(chain)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newClient(&cloudConnector{url: server.URL, apiKey: "key", client: server.Client(), lifetime: 48 * time.Hour}, `My App\Default`)
+	require.NoError(t, c.FinalizeOrder(newTestCSR(t)))
+	assert.Equal(t, "app-id", request.ApplicationID)
+	assert.Equal(t, "template-id", request.CertificateIssuingTemplateID)
+	assert.Equal(t, "PT48H", request.ValidityPeriod)
+	assert.Equal(t, server.URL+"/outagedetection/v1/certificaterequests/request-id", c.GetOrderURL())
+
+	certs, err := c.FetchCertificates()
+	require.NoError(t, err)
+	assert.Len(t, certs, 2)
+
+	c = newClient(&cloudConnector{url: server.URL, apiKey: "key", client: server.Client()}, "My App")
+	assert.ErrorContains(t, c.FinalizeOrder(newTestCSR(t)), `is not of the form application\issuing template alias`)
+}
+
+func TestCloudRejectedRequest(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/outagedetection/v1/certificaterequests/request-id", func(w http.ResponseWriter, r *http.Request) {
+		encode(t, w, map[string]interface{}{"id": "request-id", "status": "REJECTED", "errorInformation": map[string]string{"message": "policy violation"}})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &cloudConnector{url: server.URL, apiKey: "key", client: server.Client()}
+	_, err := c.retrieve(context.TODO(), "request-id")
+	assert.EqualError(t, err, "certificate request is rejected: policy violation")
+}
+
+func TestNewClient(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "venafi", Namespace: config.OperatorNamespace},
+		Data:       map[string][]byte{tppAccessTokenKey: []byte("token\n")},
+	}
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+
+	tests := []struct {
+		name          string
+		venafi        *certmanv1alpha1.VenafiIssuer
+		expectedError string
+	}{
+		{
+			name: "reads the access token of tpp",
+			venafi: &certmanv1alpha1.VenafiIssuer{Zone: "Certificates", TPP: &certmanv1alpha1.VenafiTPP{
+				URL:                  "https://tpp.example.com/vedsdk/",
+				CredentialsSecretRef: corev1.SecretReference{Name: "venafi"},
+			}},
+		},
+		{
+			name: "requires the api key of cloud",
+			venafi: &certmanv1alpha1.VenafiIssuer{Zone: `App\Template`, Cloud: &certmanv1alpha1.VenafiCloud{
+				CredentialsSecretRef: corev1.SecretReference{Name: "venafi"},
+			}},
+			expectedError: "has no api-key",
+		},
+		{
+			name: "rejects an invalid server ca bundle",
+			venafi: &certmanv1alpha1.VenafiIssuer{Zone: "Certificates", TPP: &certmanv1alpha1.VenafiTPP{
+				URL:                  "https://tpp.example.com/vedsdk",
+				CredentialsSecretRef: corev1.SecretReference{Name: "venafi"},
+				ServerCABundle:       []byte("not a certificate"),
+			}},
+			expectedError: "no valid certificates in the server CA bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := &certmanv1alpha1.CertIssuer{
+				ObjectMeta: metav1.ObjectMeta{Name: "venafi"},
+				Spec:       certmanv1alpha1.CertIssuerSpec{Venafi: tt.venafi},
+			}
+			c, err := NewClient(context.TODO(), kubeClient, issuer, 0)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "https://tpp.example.com/vedsdk", c.PrivateCA())
+			assert.Equal(t, "token", c.connector.(*tppConnector).token)
+		})
+	}
+}
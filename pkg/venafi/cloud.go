@@ -0,0 +1,141 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultCloudURL is the URL of the API of Venafi as a Service.
+const defaultCloudURL = "https://api.venafi.cloud"
+
+// cloudConnector requests certificates from the API of Venafi as a Service.
+type cloudConnector struct {
+	url      string
+	apiKey   string
+	client   *http.Client
+	lifetime time.Duration
+}
+
+type cloudID struct {
+	ID string `json:"id"`
+}
+
+type cloudRequest struct {
+	CertificateSigningRequest    string `json:"certificateSigningRequest"`
+	ApplicationID                string `json:"applicationId"`
+	CertificateIssuingTemplateID string `json:"certificateIssuingTemplateId"`
+	ValidityPeriod               string `json:"validityPeriod,omitempty"`
+}
+
+type cloudCertificateRequest struct {
+	ID               string   `json:"id"`
+	Status           string   `json:"status"`
+	CertificateIDs   []string `json:"certificateIds"`
+	ErrorInformation *struct {
+		Message string `json:"message"`
+	} `json:"errorInformation,omitempty"`
+}
+
+type cloudRequestResponse struct {
+	CertificateRequests []cloudCertificateRequest `json:"certificateRequests"`
+}
+
+func (c *cloudConnector) name() string {
+	return c.url
+}
+
+func (c *cloudConnector) headers() map[string]string {
+	return map[string]string{"tppl-api-key": c.apiKey}
+}
+
+// request requests a certificate from the issuing template of the application named by zone.
+func (c *cloudConnector) request(ctx context.Context, zone string, csr *x509.CertificateRequest) (string, string, error) {
+	application, template, found := strings.Cut(zone, `\`)
+	if !found || application == "" || template == "" {
+		return "", "", fmt.Errorf(`zone %s is not of the form application\issuing template alias`, zone)
+	}
+
+	app := &cloudID{}
+	if _, err := doJSON(ctx, c.client, http.MethodGet, c.url+"/outagedetection/v1/applications/name/"+url.PathEscape(application), c.headers(), nil, app); err != nil {
+		return "", "", err
+	}
+	tpl := &cloudID{}
+	if _, err := doJSON(ctx, c.client, http.MethodGet, c.url+"/outagedetection/v1/applications/"+app.ID+"/certificateissuingtemplates/"+url.PathEscape(template), c.headers(), nil, tpl); err != nil {
+		return "", "", err
+	}
+
+	request := &cloudRequest{
+		CertificateSigningRequest:    encodeCSR(csr),
+		ApplicationID:                app.ID,
+		CertificateIssuingTemplateID: tpl.ID,
+	}
+	if c.lifetime > 0 {
+		request.ValidityPeriod = fmt.Sprintf("PT%dH", int64(c.lifetime.Hours()))
+	}
+
+	response := &cloudRequestResponse{}
+	if _, err := doJSON(ctx, c.client, http.MethodPost, c.url+"/outagedetection/v1/certificaterequests", c.headers(), request, response); err != nil {
+		return "", "", err
+	}
+	if len(response.CertificateRequests) == 0 {
+		return "", "", errors.New("venafi as a service returned no certificate request")
+	}
+	id := response.CertificateRequests[0].ID
+	return id, c.requestURL(id), nil
+}
+
+func (c *cloudConnector) retrieve(ctx context.Context, id string) ([]byte, error) {
+	request := &cloudCertificateRequest{}
+	if _, err := doJSON(ctx, c.client, http.MethodGet, c.requestURL(id), c.headers(), nil, request); err != nil {
+		return nil, err
+	}
+
+	switch request.Status {
+	case "ISSUED":
+	case "FAILED", "REJECTED", "CANCELLED":
+		message := ""
+		if request.ErrorInformation != nil {
+			message = ": " + request.ErrorInformation.Message
+		}
+		return nil, fmt.Errorf("certificate request is %s%s", strings.ToLower(request.Status), message)
+	default:
+		return nil, nil
+	}
+	if len(request.CertificateIDs) == 0 {
+		return nil, errors.New("issued certificate request has no certificate")
+	}
+
+	_, data, err := doRequest(ctx, c.client, http.MethodGet, c.url+"/outagedetection/v1/certificates/"+request.CertificateIDs[0]+"/contents?format=PEM&chainOrder=EE_FIRST", c.headers(), nil)
+	return data, err
+}
+
+// revoke always fails, the API of Venafi as a Service does not revoke certificates.
+func (c *cloudConnector) revoke(context.Context, *x509.Certificate, int) error {
+	return errors.New("venafi as a service does not support revoking certificates through its API")
+}
+
+func (c *cloudConnector) requestURL(id string) string {
+	return c.url + "/outagedetection/v1/certificaterequests/" + id
+}
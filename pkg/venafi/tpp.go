@@ -0,0 +1,146 @@
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package venafi
+
+import (
+	"context"
+	"crypto/sha1" //#nosec - G505: Trust Protection Platform identifies certificates by their SHA-1 thumbprint
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tppPolicyRoot is the root of the policy tree of Trust Protection Platform, which zones are relative to.
+const tppPolicyRoot = `\VED\Policy\`
+
+// tppMaxRevocationReason is the highest RFC 5280 reason code Trust Protection Platform revokes certificates with,
+// cessation of operation. The codes up to it have the same meaning in both.
+const tppMaxRevocationReason = 5
+
+// tppConnector requests certificates from the web SDK of Venafi Trust Protection Platform.
+type tppConnector struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+type tppRequest struct {
+	PolicyDN                string
+	PKCS10                  string
+	ObjectName              string
+	DisableAutomaticRenewal bool
+}
+
+type tppRequestResponse struct {
+	CertificateDN string
+}
+
+type tppRetrieveRequest struct {
+	CertificateDN  string
+	Format         string
+	IncludeChain   bool
+	RootFirstOrder bool
+}
+
+type tppRetrieveResponse struct {
+	CertificateData string
+}
+
+type tppRevokeRequest struct {
+	Thumbprint string
+	Reason     int
+}
+
+type tppRevokeResponse struct {
+	Success bool
+	Error   string
+}
+
+func (c *tppConnector) name() string {
+	return c.url
+}
+
+func (c *tppConnector) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + c.token}
+}
+
+// request requests a certificate in the policy folder of zone. The certificate is renewed by the operator, not by
+// Trust Protection Platform.
+func (c *tppConnector) request(ctx context.Context, zone string, csr *x509.CertificateRequest) (string, string, error) {
+	response := &tppRequestResponse{}
+	_, err := doJSON(ctx, c.client, http.MethodPost, c.url+"/certificates/request", c.headers(), &tppRequest{
+		PolicyDN:                tppPolicyDN(zone),
+		PKCS10:                  encodeCSR(csr),
+		ObjectName:              csr.Subject.CommonName,
+		DisableAutomaticRenewal: true,
+	}, response)
+	if err != nil {
+		return "", "", err
+	}
+	if response.CertificateDN == "" {
+		return "", "", errors.New("trust protection platform returned no certificate DN")
+	}
+	return response.CertificateDN, response.CertificateDN, nil
+}
+
+func (c *tppConnector) retrieve(ctx context.Context, id string) ([]byte, error) {
+	response := &tppRetrieveResponse{}
+	status, err := doJSON(ctx, c.client, http.MethodPost, c.url+"/certificates/retrieve", c.headers(), &tppRetrieveRequest{
+		CertificateDN: id,
+		Format:        "Base64",
+		IncludeChain:  true,
+	}, response)
+	if err != nil {
+		return nil, err
+	}
+	// the certificate is still being issued
+	if status == http.StatusAccepted {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(response.CertificateData)
+}
+
+// revoke revokes certificate, identified by its thumbprint. A certificate that is already revoked is left alone.
+func (c *tppConnector) revoke(ctx context.Context, certificate *x509.Certificate, reason int) error {
+	if reason < 0 || reason > tppMaxRevocationReason {
+		return fmt.Errorf("unsupported revocation reason code %d", reason)
+	}
+
+	response := &tppRevokeResponse{}
+	_, err := doJSON(ctx, c.client, http.MethodPost, c.url+"/certificates/revoke", c.headers(), &tppRevokeRequest{
+		Thumbprint: fmt.Sprintf("%X", sha1.Sum(certificate.Raw)), //#nosec - G401: the thumbprint only identifies the certificate
+		Reason:     reason,
+	}, response)
+	if err != nil {
+		return err
+	}
+	if !response.Success && !strings.Contains(strings.ToLower(response.Error), "already revoked") {
+		return fmt.Errorf("trust protection platform did not revoke the certificate: %s", response.Error)
+	}
+	return nil
+}
+
+// tppPolicyDN returns the DN of the policy folder of zone, which may be relative to the policy root.
+func tppPolicyDN(zone string) string {
+	if strings.HasPrefix(zone, `\VED\`) {
+		return zone
+	}
+	return tppPolicyRoot + strings.TrimPrefix(zone, `\`)
+}
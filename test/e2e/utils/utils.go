@@ -1697,7 +1697,10 @@ func PerformDNS01ChallengeTest(ctx context.Context, cfg *rest.Config, scheme *ru
 
 	// Create operator's AWS client using the reused runtime client
 	log.Printf("Creating AWS client with region: %s, secret: %s", awsRegion, awsSecretName)
-	awsClient, err := awsclient.NewClient(reqLogger, runtimeClient, awsSecretName, namespace, awsRegion, clusterDeploymentName)
+	awsClient, err := awsclient.NewClient(ctx, reqLogger, runtimeClient, certmanv1alpha1.AWSPlatformSecrets{
+		Credentials: corev1.LocalObjectReference{Name: awsSecretName},
+		Region:      awsRegion,
+	}, namespace, clusterDeploymentName)
 	if err != nil {
 		return false, fmt.Errorf("failed to create AWS client: %w", err)
 	}
@@ -1731,7 +1734,7 @@ func PerformDNS01ChallengeTest(ctx context.Context, cfg *rest.Config, scheme *ru
 	cr.Spec.DnsNames = []string{domain}
 
 	// Step 1: Use operator's AnswerDNSChallenge to create DNS record
-	fqdn, err := awsClient.AnswerDNSChallenge(reqLogger, testToken, domain, &cr, hostedZoneID)
+	fqdn, err := awsClient.AnswerDNSChallenge(ctx, reqLogger, testToken, domain, &cr, hostedZoneID)
 	if err != nil {
 		return false, fmt.Errorf("failed to create DNS challenge record using operator function: %w", err)
 	}
@@ -1761,7 +1764,7 @@ func PerformDNS01ChallengeTest(ctx context.Context, cfg *rest.Config, scheme *ru
 
 	// Step 3: Cleanup using operator's DeleteAcmeChallengeResourceRecords
 	log.Println("Cleaning up DNS challenge record...")
-	err = awsClient.DeleteAcmeChallengeResourceRecords(reqLogger, &cr)
+	err = awsClient.DeleteAcmeChallengeResourceRecords(ctx, reqLogger, &cr)
 	if err != nil {
 		return false, fmt.Errorf("failed to cleanup DNS challenge record using operator function: %w", err)
 	}
@@ -0,0 +1,116 @@
+//go:build pebble
+
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pebble
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	cTypes "github.com/openshift/certman-operator/pkg/clients/types"
+)
+
+var _ cClient.Client = &challTestSrvClient{}
+var _ cClient.BatchClient = &challTestSrvClient{}
+
+// challTestSrvClient implements the certman-operator/pkg/clients.Client interface against the
+// management API of pebble-challtestsrv, which serves as the authoritative DNS server for Pebble.
+type challTestSrvClient struct {
+	managementURL string
+}
+
+func (c *challTestSrvClient) GetDNSName() string {
+	return "pebble-challtestsrv"
+}
+
+func (c *challTestSrvClient) GetFedrampHostedZoneIDPath(_ context.Context, fedrampHostedZoneID string) (string, error) {
+	return fedrampHostedZoneID, nil
+}
+
+func (c *challTestSrvClient) ValidateDNSWriteAccess(_ context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) (bool, error) {
+	return true, nil
+}
+
+func (c *challTestSrvClient) AnswerDNSChallenge(ctx context.Context, reqLogger logr.Logger, acmeChallengeToken string, domain string, cr *certmanv1alpha1.CertificateRequest, dnsZone string) (string, error) {
+	fqdn := fmt.Sprintf("%s.%s", cTypes.AcmeChallengeSubDomain, domain)
+	reqLogger.Info(fmt.Sprintf("fqdn acme challenge domain is %v", fqdn))
+
+	return fqdn, c.post(ctx, "set-txt", map[string]string{"host": fqdn + ".", "value": acmeChallengeToken})
+}
+
+// AnswerDNSChallenges sets the TXT record of each challenge. pebble-challtestsrv adds the values set for the same
+// host rather than replacing them, so the challenges of a domain and of its wildcard are all answered.
+func (c *challTestSrvClient) AnswerDNSChallenges(ctx context.Context, reqLogger logr.Logger, challenges []cTypes.DNSChallenge, cr *certmanv1alpha1.CertificateRequest, dnsZone string) ([]string, error) {
+	fqdns := make([]string, len(challenges))
+	for i, challenge := range challenges {
+		fqdn, err := c.AnswerDNSChallenge(ctx, reqLogger, challenge.KeyAuthorization, challenge.Domain, cr, dnsZone)
+		if err != nil {
+			return nil, err
+		}
+		fqdns[i] = fqdn
+	}
+	return fqdns, nil
+}
+
+func (c *challTestSrvClient) DeleteAcmeChallengeResourceRecords(ctx context.Context, reqLogger logr.Logger, cr *certmanv1alpha1.CertificateRequest) error {
+	for _, domain := range cr.Spec.DnsNames {
+		fqdn := fmt.Sprintf("%s.%s.", cTypes.AcmeChallengeSubDomain, strings.TrimPrefix(domain, "*."))
+		if err := c.post(ctx, "clear-txt", map[string]string{"host": fqdn}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListZones returns no zones, as pebble-challtestsrv answers for every domain.
+func (c *challTestSrvClient) ListZones(_ context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// post sends body as JSON to the given challtestsrv management endpoint.
+func (c *challTestSrvClient) post(ctx context.Context, endpoint string, body map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", c.managementURL, endpoint), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req) //#nosec - G107: URL is built from test configuration
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pebble-challtestsrv %s returned %s", endpoint, resp.Status)
+	}
+	return nil
+}
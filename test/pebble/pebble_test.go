@@ -0,0 +1,231 @@
+//go:build pebble
+
+/*
+Copyright 2019 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pebble
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/eggsampler/acme"
+	"github.com/go-logr/logr"
+	hiveapis "github.com/openshift/hive/apis"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	certmanv1alpha1 "github.com/openshift/certman-operator/api/v1alpha1"
+	"github.com/openshift/certman-operator/controllers/certificaterequest"
+	cClient "github.com/openshift/certman-operator/pkg/clients"
+	"github.com/openshift/certman-operator/pkg/leclient"
+)
+
+const (
+	pebbleDirectoryURL     = "https://127.0.0.1:14000/dir"
+	challTestSrvDNSAddress = "127.0.0.1:8053"
+	challTestSrvManagement = "127.0.0.1:8055"
+	testNamespace          = "pebble-test"
+	testZoneID             = "/hostedzone/PEBBLETEST"
+)
+
+// TestMain starts pebble-challtestsrv and Pebble for the duration of the test run. PEBBLE_DIR must point
+// to a checkout of https://github.com/letsencrypt/pebble, whose test certificates Pebble serves, and
+// both binaries must be on the PATH.
+func TestMain(m *testing.M) {
+	pebbleDir := os.Getenv("PEBBLE_DIR")
+	if pebbleDir == "" {
+		fmt.Println("PEBBLE_DIR is unset, skipping pebble integration tests")
+		os.Exit(0)
+	}
+
+	configPath, err := filepath.Abs("pebble-config.json")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	challTestSrv := exec.Command("pebble-challtestsrv",
+		"-defaultIPv4", "127.0.0.1",
+		"-defaultIPv6", "",
+		"-http01", "",
+		"-https01", "",
+		"-tlsalpn01", "",
+		"-doh", "",
+		"-dns01", challTestSrvDNSAddress,
+		"-management", challTestSrvManagement,
+	)
+
+	pebble := exec.Command("pebble", "-config", configPath, "-dnsserver", challTestSrvDNSAddress)
+	pebble.Dir = pebbleDir
+	pebble.Env = append(os.Environ(),
+		// skip the random validation delay and nonce rejection so tests run quickly and deterministically
+		"PEBBLE_VA_NOSLEEP=1",
+		"PEBBLE_WFE_NONCEREJECT=0",
+	)
+
+	for _, cmd := range []*exec.Cmd{challTestSrv, pebble} {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			fmt.Printf("failed to start %s: %v\n", cmd.Path, err)
+			os.Exit(1)
+		}
+	}
+
+	code := 1
+	if err := waitForPebble(); err != nil {
+		fmt.Println(err)
+	} else {
+		code = m.Run()
+	}
+
+	for _, cmd := range []*exec.Cmd{pebble, challTestSrv} {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	os.Exit(code)
+}
+
+// waitForPebble polls the Pebble directory until it responds.
+func waitForPebble() error {
+	httpClient := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, //#nosec - G402: Pebble serves a throwaway test certificate
+	}
+
+	for i := 0; i < 30; i++ {
+		resp, err := httpClient.Get(pebbleDirectoryURL)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("pebble did not become ready at %s", pebbleDirectoryURL)
+}
+
+// newPebbleLEClient registers a new account with Pebble and returns a LetsEncryptClient for it.
+func newPebbleLEClient(t *testing.T) *leclient.LetsEncryptClient {
+	t.Helper()
+
+	acmeClient, err := acme.NewClient(pebbleDirectoryURL, acme.WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("failed to create acme client: %v", err)
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate account key: %v", err)
+	}
+
+	account, err := acmeClient.NewAccount(privateKey, false, true)
+	if err != nil {
+		t.Fatalf("failed to register pebble account: %v", err)
+	}
+
+	return &leclient.LetsEncryptClient{Client: acmeClient, Account: account}
+}
+
+// setUpKubeClient returns a fake kube client holding the DNSZone used to answer challenges.
+func setUpKubeClient(t *testing.T) client.Client {
+	t.Helper()
+
+	s := scheme.Scheme
+	if err := certmanv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := hiveapis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	zoneID := testZoneID
+	dnsZone := &hivev1.DNSZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "pebble-zone", Namespace: testNamespace},
+		Status:     hivev1.DNSZoneStatus{AWS: &hivev1.AWSDNSZoneStatus{ZoneID: &zoneID}},
+	}
+
+	return fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(dnsZone).Build()
+}
+
+func TestIssueCertificate(t *testing.T) {
+	dnsNames := []string{"api.cluster.pebble.test", "*.apps.cluster.pebble.test"}
+
+	cr := &certmanv1alpha1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "pebble-cert-bundle", Namespace: testNamespace},
+		Spec: certmanv1alpha1.CertificateRequestSpec{
+			ACMEDNSDomain: "pebble.test",
+			CertificateSecret: corev1.ObjectReference{
+				Kind:      "Secret",
+				Namespace: testNamespace,
+				Name:      "pebble-cert-bundle-secret",
+			},
+			DnsNames: dnsNames,
+			Email:    "certman@pebble.test",
+		},
+	}
+
+	r := &certificaterequest.CertificateRequestReconciler{
+		Client: setUpKubeClient(t),
+		ClientBuilder: func(ctx context.Context, reqLogger logr.Logger, kubeClient client.Client, platform certmanv1alpha1.Platform, namespace string, clusterDeploymentName string) (cClient.Client, error) {
+			return &challTestSrvClient{managementURL: "http://" + challTestSrvManagement}, nil
+		},
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.IssueCertificate(context.TODO(), logr.Discard(), cr, secret, newPebbleLEClient(t)); err != nil {
+		t.Fatalf("IssueCertificate() returned an error: %v", err)
+	}
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		t.Fatalf("issued secret does not contain a certificate")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse the issued certificate: %v", err)
+	}
+
+	actualNames := append([]string{}, certificate.DNSNames...)
+	sort.Strings(actualNames)
+	sort.Strings(dnsNames)
+	if !reflect.DeepEqual(actualNames, dnsNames) {
+		t.Errorf("issued certificate DNS names = %v, want %v", actualNames, dnsNames)
+	}
+
+	if len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		t.Errorf("issued secret does not contain a private key")
+	}
+}